@@ -0,0 +1,30 @@
+package _import
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cnosdb/cnosdb/pkg/importer"
+)
+
+func importCSVData(config *importer.Config) error {
+	delim, n := utf8.DecodeRuneInString(env.Delimiter)
+	if n == 0 || n != len(env.Delimiter) {
+		return fmt.Errorf("invalid delimiter %q", env.Delimiter)
+	}
+	csvConfig.Delimiter = delim
+
+	f, err := os.Open(config.Path)
+	if err != nil {
+		return fmt.Errorf("open file(%s) err: %v", config.Path, err)
+	}
+	defer f.Close()
+
+	c := *config
+	if strings.HasSuffix(c.Path, ".gz") {
+		c.Compressed = true
+	}
+	return importer.NewCSVImporter(c, csvConfig).Import(f)
+}
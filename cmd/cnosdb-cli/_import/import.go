@@ -26,14 +26,18 @@ const (
 )
 
 var (
-	config = importer.NewConfig()
-	env    = &options{}
+	config    = importer.NewConfig()
+	csvConfig = importer.CSVConfig{}
+	env       = &options{}
 )
 
 type options struct {
 	Host string
 	Port int
 	Ssl  bool
+
+	CSV       bool
+	Delimiter string
 }
 
 func GetCommand() *cobra.Command {
@@ -65,6 +69,14 @@ func GetCommand() *cobra.Command {
 				return
 			}
 
+			if env.CSV {
+				if err := importCSVData(config); err != nil {
+					fmt.Printf("[ERR] %s\n", err)
+				}
+
+				return
+			}
+
 			// Open the file
 			f, err := os.Open(config.Path)
 			if err != nil {
@@ -95,6 +107,22 @@ func GetCommand() *cobra.Command {
 
 	flags.BoolVar(&config.SqlDump, "sqldump", false, "set to true if the import file is from mysqldump/pg_dumpall")
 	flags.StringVar(&config.ConfigFile, "config", "", "if sqldump set true,please set config file.")
+
+	flags.BoolVar(&env.CSV, "csv", false, "set to true if the import file is CSV with a header row")
+	flags.StringVar(&csvConfig.Database, "database", "", "Database the CSV rows are written to.")
+	flags.StringVar(&csvConfig.RetentionPolicy, "retention-policy", "", "Retention policy the CSV rows are written to.")
+	flags.StringVar(&csvConfig.Measurement, "measurement", "", "Measurement of the CSV rows, if no column holds it.")
+	flags.StringVar(&csvConfig.MeasurementColumn, "measurement-column", "", "CSV column holding the measurement.")
+	flags.StringSliceVar(&csvConfig.Tags, "tags", nil, "CSV columns imported as tags.")
+	flags.StringSliceVar(&csvConfig.Fields, "fields", nil, "CSV columns imported as fields, as name or name:type with type float, integer, unsigned, string or boolean.")
+	flags.StringSliceVar(&csvConfig.Ignore, "ignore", nil, "CSV columns not imported.")
+	flags.StringVar(&csvConfig.Timestamp, "timestamp", "", "CSV column holding the time, the column named time by default.")
+	flags.StringVar(&csvConfig.TimestampFormat, "timestamp-format", "", "Format of the CSV time column: RFC3339, unix, unix_ms, unix_us, unix_ns or a Go time layout.")
+	flags.StringVar(&csvConfig.Timezone, "timezone", "", "Timezone of the CSV times with no zone, e.g. Asia/Shanghai. UTC by default.")
+	flags.StringVar(&env.Delimiter, "delimiter", ",", "Delimiter of the CSV columns.")
+	flags.IntVar(&csvConfig.Workers, "workers", 1, "Number of CSV batches written concurrently.")
+	flags.StringVar(&csvConfig.RejectsPath, "rejects", "", "Path of the CSV file the rejected rows are written to.")
+	flags.BoolVar(&csvConfig.DryRun, "dry-run", false, "Print the schema inferred from the CSV file without importing it.")
 	return c
 }
 
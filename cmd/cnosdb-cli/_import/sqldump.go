@@ -2,222 +2,651 @@ package _import
 
 import (
 	"bufio"
+	"compress/gzip"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/cnosdb/cnosdb/pkg/importer"
+	"github.com/cnosdb/cnosdb/vend/db/models"
 )
 
 var useExp *regexp.Regexp
 var connectExp *regexp.Regexp
-var mysqlValuesExp *regexp.Regexp
-var pgsqlValuesExp *regexp.Regexp
+var insertExp *regexp.Regexp
+var mysqlExp *regexp.Regexp
+var postgresExp *regexp.Regexp
+var conformingExp *regexp.Regexp
 
 func init() {
 	useExp = regexp.MustCompile("^USE `(.+)`;")
 	connectExp = regexp.MustCompile("^\\\\connect (.+)")
-	mysqlValuesExp = regexp.MustCompile("^INSERT INTO `(.+?)` VALUES \\((.+)\\);")
-	pgsqlValuesExp = regexp.MustCompile("^INSERT INTO (.+?) VALUES \\((.+)\\);")
+	insertExp = regexp.MustCompile("(?i)^INSERT\\s")
+	mysqlExp = regexp.MustCompile(`^(-- (MySQL|MariaDB) dump|/\*!\d+ )`)
+	postgresExp = regexp.MustCompile(`^-- PostgreSQL database( cluster)? dump`)
+	conformingExp = regexp.MustCompile(`(?i)^SET standard_conforming_strings = '?(on|off)'?;`)
+}
+
+// sqlDialect is the dialect of a dump, which determines how the backslashes
+// of its strings are read.
+type sqlDialect int
+
+const (
+	// dialectUnknown is the dialect of a dump with no header recognised, the
+	// dialect of its INSERT statements is guessed from the statements.
+	dialectUnknown sqlDialect = iota
+
+	// dialectMySQL escapes the characters of every string with backslashes.
+	dialectMySQL
+
+	// dialectPostgres escapes the characters of E'...' strings only, unless
+	// standard_conforming_strings is off.
+	dialectPostgres
+)
+
+// The kinds of the columns of a table, as set in the third row of SqlFields.
+const (
+	columnTime  = 1
+	columnTag   = 2
+	columnField = 3
+)
+
+// The formats of the time column, as set in the optional fourth row of
+// SqlFields. Any other non-empty value is used as a Go time layout.
+const (
+	formatUnix   = "unix"
+	formatUnixMs = "unix_ms"
+	formatUnixUs = "unix_us"
+	formatUnixNs = "unix_ns"
+)
+
+// timeLayouts are tried in order to parse quoted time values when the time
+// column has no format.
+var timeLayouts = []string{
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z07",
+	"2006-01-02 15:04:05.999999999",
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
 }
 
 type SqlConfig struct {
 	Tables map[string]TableInfo
 }
 
+// TableInfo maps the rows of the SQL tables matching TableName onto the
+// measurement Measurement. SqlFields holds, column by column, the SQL column
+// names, the CnosDB tag or field names, the column kinds and, optionally, the
+// column types.
 type TableInfo struct {
 	TableName   string
 	Measurement string
 	SqlFields   [][]interface{}
 }
 
-func importSqlDumpData(config *importer.Config) error {
-	tables, err := parseConfigFile(config.ConfigFile)
-	if err != nil {
-		return err
-	}
+// tableMapping is a validated TableInfo.
+type tableMapping struct {
+	name string
 
-	reader, writer := io.Pipe()
-	defer reader.Close()
-	go func() {
-		if err := parseSqlDumpFile(config.Path, tables, writer); err != nil {
-			writer.CloseWithError(err)
-		} else {
-			writer.Close()
-		}
-	}()
+	sqlDatabase string
+	sqlTable    string
+	database    string
+	measurement string
 
-	scanner := bufio.NewReader(reader)
-	for {
-		line, err := scanner.ReadString('\n')
-		line = strings.Trim(line, "\n")
-		fmt.Printf("%s\n", line)
-		if err != nil {
-			return err
-		}
-	}
+	columns []columnMapping
+	index   map[string]int // SQL column name to index in columns
+}
 
-	// i := importer.NewImporter(*config)
-	// return i.Import(reader)
+type columnMapping struct {
+	name string // name of the tag or field, empty if the column is dropped
+	kind int64
+	typ  string
 }
 
-func parseConfigFile(name string) (map[string]TableInfo, error) {
+// parseConfigFile reads and validates the table mappings in the file name.
+func parseConfigFile(name string) ([]*tableMapping, error) {
 	config := &SqlConfig{}
 	if _, err := toml.DecodeFile(name, config); err != nil {
 		return nil, err
 	}
 
-	for k, v := range config.Tables {
-		if len(v.SqlFields) != 3 {
-			return nil, fmt.Errorf("%s config field array not right", k)
+	keys := make([]string, 0, len(config.Tables))
+	for k := range config.Tables {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
 
+	tables := make([]*tableMapping, 0, len(keys))
+	for _, k := range keys {
+		t, err := newTableMapping(k, config.Tables[k])
+		if err != nil {
+			return nil, err
 		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
+
+func newTableMapping(k string, v TableInfo) (*tableMapping, error) {
+	t := &tableMapping{name: k}
+
+	src := strings.Split(v.TableName, "/")
+	dst := strings.Split(v.Measurement, "/")
+	if len(src) != 2 || len(dst) != 2 {
+		return nil, fmt.Errorf("%s table name and measurement must be in the form db/name", k)
+	}
+	t.sqlDatabase, t.sqlTable = src[0], src[1]
+	t.database, t.measurement = dst[0], dst[1]
 
-		if len(v.SqlFields[0]) != len(v.SqlFields[1]) || len(v.SqlFields[0]) != len(v.SqlFields[2]) {
+	if len(v.SqlFields) != 3 && len(v.SqlFields) != 4 {
+		return nil, fmt.Errorf("%s config field array not right", k)
+	}
+	n := len(v.SqlFields[0])
+	for _, row := range v.SqlFields[1:] {
+		if len(row) != n {
 			return nil, fmt.Errorf("%s config fields not right", k)
 		}
+	}
 
-		for i := 0; i < len(v.SqlFields[2]); i++ {
-			val := v.SqlFields[2][i].(int64)
-			if val <= 0 || val > 3 {
-				continue
+	t.columns = make([]columnMapping, n)
+	t.index = make(map[string]int, n)
+	var hasTime, hasField bool
+	for i := range t.columns {
+		col, ok := v.SqlFields[0][i].(string)
+		if !ok {
+			return nil, fmt.Errorf("%s sql field name %v must be a string", k, v.SqlFields[0][i])
+		}
+		name, ok := v.SqlFields[1][i].(string)
+		if !ok {
+			return nil, fmt.Errorf("%s cnosdb field name %v must be a string", k, v.SqlFields[1][i])
+		}
+		kind, ok := v.SqlFields[2][i].(int64)
+		if !ok {
+			return nil, fmt.Errorf("%s field kind %v must be an integer", k, v.SqlFields[2][i])
+		}
+		var typ string
+		if len(v.SqlFields) == 4 {
+			if typ, ok = v.SqlFields[3][i].(string); !ok {
+				return nil, fmt.Errorf("%s field type %v must be a string", k, v.SqlFields[3][i])
 			}
+		}
 
-			if v.SqlFields[1][i] == "" {
-				return nil, fmt.Errorf("%s cnosdb field name can't be empty", k)
+		switch kind {
+		case columnTime:
+			if hasTime {
+				return nil, fmt.Errorf("%s has more than one time column", k)
 			}
+			hasTime = true
+		case columnTag:
+		case columnField:
+			switch typ {
+			case "", "float", "integer", "unsigned", "string", "boolean":
+			default:
+				return nil, fmt.Errorf("%s invalid type %q for field %s", k, typ, col)
+			}
+			hasField = true
+		default:
+			// The column is not stored.
+			kind, name = 0, ""
+		}
+		if kind != 0 && kind != columnTime && name == "" {
+			return nil, fmt.Errorf("%s cnosdb field name can't be empty", k)
 		}
+
+		t.columns[i] = columnMapping{name: name, kind: kind, typ: typ}
+		t.index[col] = i
+	}
+	if !hasTime {
+		return nil, fmt.Errorf("%s has no time column", k)
+	} else if !hasField {
+		return nil, fmt.Errorf("%s has no field column", k)
 	}
+	return t, nil
+}
 
-	return config.Tables, nil
+// match returns the CnosDB database and measurement of the rows inserted
+// into the SQL table tabName of the database dbName, or empty strings if
+// the table is not mapped by t.
+func (t *tableMapping) match(dbName, tabName string) (string, string) {
+	db := matchValue(dbName, t.sqlDatabase, t.database)
+	meas := matchValue(tabName, t.sqlTable, t.measurement)
+	if db == "" || meas == "" {
+		return "", ""
+	}
+	return db, meas
 }
 
-func parseSqlDumpFile(filename string, tables map[string]TableInfo, writer io.Writer) error {
+func importSqlDumpData(config *importer.Config) error {
+	tables, err := parseConfigFile(config.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	// Times are converted to nanoseconds regardless of the precision flag,
+	// and the converted rows are not compressed even if the dump is.
+	c := *config
+	c.Precision = "ns"
+	c.Compressed = false
+
+	conv := newSqlDumpConverter(tables)
+	reader, writer := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := conv.ConvertFile(config.Path, config.Compressed, writer); err != nil {
+			writer.CloseWithError(err)
+		} else {
+			writer.Close()
+		}
+	}()
+
+	err = importer.NewImporter(c).Import(reader)
+
+	// Unblock and wait for the converter, if the import stopped early.
+	reader.Close()
+	<-done
+
+	conv.PrintSummary(os.Stdout)
+	return err
+}
+
+// sqlDumpConverter converts the INSERT statements of a mysqldump or pg_dump
+// file into line protocol, and counts the rows it could not convert.
+type sqlDumpConverter struct {
+	tables []*tableMapping
+
+	sqlDatabase string // current database of the dump
+	database    string // current database of the output
+
+	dialect sqlDialect
+	// backslashEscapes is true if the backslashes of all the strings of a
+	// PostgreSQL dump are escapes, i.e. standard_conforming_strings is off.
+	backslashEscapes bool
+
+	rows      int
+	skipped   map[string]int // rows skipped, by reason
+	unmapped  map[string]int // rows of the tables with no mapping, by table
+	malformed int            // INSERT statements that could not be parsed
+}
+
+func newSqlDumpConverter(tables []*tableMapping) *sqlDumpConverter {
+	return &sqlDumpConverter{
+		tables:   tables,
+		skipped:  make(map[string]int),
+		unmapped: make(map[string]int),
+	}
+}
+
+// ConvertFile writes the rows inserted by the dump in filename to w, in the
+// format read by the importer. The dump is gunzipped if compressed is true.
+func (c *sqlDumpConverter) ConvertFile(filename string, compressed bool, w io.Writer) error {
 	file, err := os.Open(filename)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	dbName := ""
-	tableName := ""
-	scanner := bufio.NewReader(file)
+	var r io.Reader = file
+	if compressed {
+		gr, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gr.Close()
+		r = gr
+	}
+	return c.Convert(r, w)
+}
+
+// Convert writes the rows inserted by the dump read from r to w.
+func (c *sqlDumpConverter) Convert(r io.Reader, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString("# DML\n"); err != nil {
+		return err
+	}
 
-	io.WriteString(writer, "# DML\n")
+	scanner := bufio.NewReader(r)
+	var stmt strings.Builder
 	for {
 		line, err := scanner.ReadString('\n')
-		if err == io.EOF {
-			return nil
-		} else if err != nil {
+		if err != nil && err != io.EOF {
 			return err
 		}
-		line = strings.Trim(line, "\r\n")
+		eof := err == io.EOF
+		line = strings.TrimRight(line, "\r\n")
+
+		if stmt.Len() > 0 {
+			// Continuation of a statement spanning several lines.
+			stmt.WriteByte('\n')
+			stmt.WriteString(line)
+		} else if m := useExp.FindStringSubmatch(line); m != nil {
+			c.sqlDatabase = m[1]
+			c.dialect = dialectMySQL
+		} else if m := connectExp.FindStringSubmatch(line); m != nil {
+			c.sqlDatabase = connectDatabase(m[1])
+			c.dialect = dialectPostgres
+		} else if mysqlExp.MatchString(line) {
+			c.dialect = dialectMySQL
+		} else if postgresExp.MatchString(line) {
+			c.dialect = dialectPostgres
+		} else if m := conformingExp.FindStringSubmatch(line); m != nil {
+			c.dialect = dialectPostgres
+			c.backslashEscapes = strings.EqualFold(m[1], "off")
+		} else if insertExp.MatchString(line) {
+			stmt.WriteString(line)
+		}
 
-		if m := useExp.FindAllStringSubmatch(line, -1); len(m) == 1 {
-			dbName = m[0][1]
-			io.WriteString(writer, "# CONTEXT-DATABASE: "+dbName+"\n")
+		if stmt.Len() > 0 && (eof || statementComplete(stmt.String(), c.escapes(stmt.String()))) {
+			if err := c.convertInsert(stmt.String(), bw); err != nil {
+				return err
+			}
+			stmt.Reset()
 		}
-		if m := connectExp.FindAllStringSubmatch(line, -1); len(m) == 1 {
-			dbName = m[0][1]
-			io.WriteString(writer, "# CONTEXT-DATABASE: "+dbName+"\n")
+
+		if eof {
+			return bw.Flush()
 		}
+	}
+}
 
-		match := mysqlValuesExp.FindAllStringSubmatch(line, -1)
-		if len(match) != 1 {
-			match = pgsqlValuesExp.FindAllStringSubmatch(line, -1)
-			if len(match) != 1 {
-				continue
+// connectDatabase returns the database of the arguments of a psql \connect
+// command, e.g. `mydb` or `-reuse-previous=on "dbname='mydb'"`.
+func connectDatabase(args string) string {
+	fields := strings.Fields(args)
+	db := strings.Trim(fields[len(fields)-1], `"`)
+	if strings.HasPrefix(db, "dbname=") {
+		db = strings.Trim(strings.TrimPrefix(db, "dbname="), "'")
+	}
+	return db
+}
+
+// escapes returns how the backslashes of the strings of the INSERT
+// statement stmt are read, as set by the dialect of the dump or, if it is
+// unknown, guessed from the statement.
+func (c *sqlDumpConverter) escapes(stmt string) backslashMode {
+	dialect := c.dialect
+	if dialect == dialectUnknown {
+		dialect = insertDialect(stmt)
+	}
+	switch {
+	case dialect == dialectMySQL:
+		return escapeAll
+	case c.backslashEscapes:
+		return escapeAll
+	default:
+		return escapePrefixed
+	}
+}
+
+// insertDialect guesses the dialect of an INSERT statement: MySQL if it
+// inserts IGNORE or into a backquoted table, PostgreSQL otherwise.
+func insertDialect(stmt string) sqlDialect {
+	p := &insertParser{s: stmt}
+	if !p.keyword("INSERT") {
+		return dialectPostgres
+	} else if p.keyword("IGNORE") {
+		return dialectMySQL
+	}
+	p.keyword("INTO")
+	if p.skipSpace(); p.peek() == '`' {
+		return dialectMySQL
+	}
+	return dialectPostgres
+}
+
+// convertInsert writes the rows of the INSERT statement stmt to w.
+func (c *sqlDumpConverter) convertInsert(stmt string, w *bufio.Writer) error {
+	ins, err := parseInsert(stmt, c.escapes(stmt))
+	if err != nil {
+		c.malformed++
+		return nil
+	}
+	c.rows += len(ins.rows)
+
+	var (
+		t                 *tableMapping
+		database, measure string
+	)
+	for _, tm := range c.tables {
+		if database, measure = tm.match(c.sqlDatabase, ins.table); database != "" {
+			t = tm
+			break
+		}
+	}
+	if t == nil {
+		c.unmapped[ins.table] += len(ins.rows)
+		return nil
+	}
+
+	// Map the position of the values onto the columns of the table.
+	positions := make([]int, len(t.columns))
+	if ins.columns == nil {
+		for i := range positions {
+			positions[i] = i
+		}
+	} else {
+		for i := range positions {
+			positions[i] = -1
+		}
+		for i, col := range ins.columns {
+			if j, ok := t.index[col]; ok {
+				positions[j] = i
 			}
 		}
+	}
+
+	if database != c.database {
+		c.database = database
+		if _, err := w.WriteString("# CONTEXT-DATABASE: " + database + "\n"); err != nil {
+			return err
+		}
+	}
 
-		tableName = match[0][1]
-		//fmt.Printf("\nSQL [%s.%s] %s\n", dbName, tableName, line)
-		dbName, measName, fields := getDbnameMeasurement(dbName, tableName, tables)
-		if dbName == "" || measName == "" || fields == nil {
+	for _, row := range ins.rows {
+		if ins.columns == nil && len(row) != len(t.columns) {
+			c.skipped["column count does not match the table mapping"]++
+			continue
+		} else if ins.columns != nil && len(row) != len(ins.columns) {
+			c.skipped["column count does not match the column list"]++
 			continue
 		}
 
-		values, err := parseValues(match[0][2])
-		if err != nil {
-			return fmt.Errorf("parse line failed: %v", line)
+		pt, reason := t.convertRow(measure, row, positions)
+		if reason != "" {
+			c.skipped[reason]++
+			continue
+		}
+		// The importer reads a point per line.
+		line := pt.String()
+		if strings.ContainsAny(line, "\r\n") {
+			c.skipped["value contains a line break"]++
+			continue
 		}
+		if _, err := w.WriteString(line + "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-		if len(values)%len(fields[0]) != 0 {
-			return fmt.Errorf("line fields not right: %v", line)
+// convertRow converts a row of values into a point. It returns the reason
+// the row was skipped if it could not be converted.
+func (t *tableMapping) convertRow(measure string, row []sqlValue, positions []int) (models.Point, string) {
+	var (
+		tags   = make(map[string]string)
+		fields = make(models.Fields)
+		ts     time.Time
+	)
+
+	for i, col := range t.columns {
+		if col.kind == 0 || positions[i] < 0 {
+			continue
 		}
+		v := row[positions[i]]
 
-		for i := 0; i < len(values); i = i + len(fields[0]) {
-			str, err := convertSql2LineProtocol(values[i:i+len(fields[0])], fields)
+		switch col.kind {
+		case columnTime:
+			if v.null {
+				return nil, "null time"
+			}
+			var err error
+			if ts, err = parseTimeValue(v, col.typ); err != nil {
+				return nil, "invalid time"
+			}
+		case columnTag:
+			if !v.null && v.s != "" {
+				tags[col.name] = v.s
+			}
+		case columnField:
+			if v.null {
+				continue
+			}
+			fv, err := fieldValue(v, col.typ)
 			if err != nil {
-				return fmt.Errorf("can't convert to line protocal %v | %s", err, line)
+				return nil, fmt.Sprintf("invalid value for field %s", col.name)
 			}
-
-			io.WriteString(writer, measName+","+str+"\n")
-			//fmt.Printf("Line [%s], %s\n", dbName, "insert " + measName + "," + str)
+			fields[col.name] = fv
 		}
 	}
-}
 
-func convertSql2LineProtocol(strs []string, ftype [][]interface{}) (string, error) {
-	tags := ""
-	fields := ""
-	timestamp := ""
+	if len(fields) == 0 {
+		return nil, "no field values"
+	}
+	if ts.IsZero() {
+		return nil, "no time"
+	}
 
-	for i := 0; i < len(strs); i++ {
-		t := ftype[2][i].(int64)
-		if t == 1 {
-			timestamp = strs[i]
-		} else if t == 2 {
-			tags += ftype[1][i].(string) + "=" + strs[i] + ","
-		} else if t == 3 {
-			fields += ftype[1][i].(string) + "=" + replacePrefixOrSuffix(strs[i], "'", "\"") + ","
-		}
+	pt, err := models.NewPoint(measure, models.NewTags(tags), fields, ts)
+	if err != nil {
+		return nil, "invalid point"
 	}
+	return pt, ""
+}
 
-	if len(tags) == 0 || len(fields) == 0 {
-		return "", fmt.Errorf("tags or fields empty")
+// PrintSummary writes the number of converted and skipped rows to w.
+func (c *sqlDumpConverter) PrintSummary(w io.Writer) {
+	skipped := 0
+	for _, n := range c.skipped {
+		skipped += n
+	}
+	unmapped := 0
+	for _, n := range c.unmapped {
+		unmapped += n
 	}
-	tags = tags[0 : len(tags)-1]
-	fields = fields[0 : len(fields)-1]
 
-	timestamp = replacePrefixOrSuffix(timestamp, "'", "")
-	theTime, err := time.Parse("2006-01-02 15:04:05", timestamp)
-	if err != nil {
-		return "", err
+	fmt.Fprintf(w, "Read %d rows, skipped %d\n", c.rows-unmapped, skipped)
+	for _, reason := range sortedKeys(c.skipped) {
+		fmt.Fprintf(w, "  %s: %d\n", reason, c.skipped[reason])
+	}
+	if len(c.unmapped) > 0 {
+		fmt.Fprintf(w, "Ignored %d rows of tables with no mapping\n", unmapped)
+		for _, table := range sortedKeys(c.unmapped) {
+			fmt.Fprintf(w, "  %s: %d\n", table, c.unmapped[table])
+		}
+	}
+	if c.malformed > 0 {
+		fmt.Fprintf(w, "Ignored %d malformed INSERT statements\n", c.malformed)
 	}
+}
 
-	return tags + " " + fields + " " + strconv.FormatInt(theTime.UnixNano(), 10), nil
+func sortedKeys(m map[string]int) []string {
+	a := make([]string, 0, len(m))
+	for k := range m {
+		a = append(a, k)
+	}
+	sort.Strings(a)
+	return a
 }
 
-func getDbnameMeasurement(dbName, tabName string, tables map[string]TableInfo) (string, string, [][]interface{}) {
-	for _, v := range tables {
-		strs := strings.Split(v.TableName, "/")
-		if len(strs) != 2 {
-			continue
+// fieldValue converts v into a field value of type typ, or of the type
+// inferred from v if typ is empty.
+func fieldValue(v sqlValue, typ string) (interface{}, error) {
+	switch typ {
+	case "":
+		if v.quoted {
+			return v.s, nil
 		}
-		sqlDbName := strs[0]
-		sqlTabName := strs[1]
-
-		strs = strings.Split(v.Measurement, "/")
-		if len(strs) != 2 {
-			continue
+		if b, err := strconv.ParseBool(v.s); err == nil && !isNumber(v.s) {
+			return b, nil
+		}
+		return strconv.ParseFloat(v.s, 64)
+	case "float":
+		return strconv.ParseFloat(v.s, 64)
+	case "integer":
+		if n, err := strconv.ParseInt(v.s, 10, 64); err == nil {
+			return n, nil
 		}
-		cnosdbDbName := strs[0]
-		cnosdbMeas := strs[1]
+		f, err := strconv.ParseFloat(v.s, 64)
+		if err != nil || f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return nil, fmt.Errorf("invalid integer %q", v.s)
+		}
+		return int64(f), nil
+	case "unsigned":
+		return strconv.ParseUint(v.s, 10, 64)
+	case "string":
+		return v.s, nil
+	case "boolean":
+		return strconv.ParseBool(v.s)
+	}
+	return nil, fmt.Errorf("invalid type %q", typ)
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
 
-		realDbName := matchValue(dbName, sqlDbName, cnosdbDbName)
-		realMeasName := matchValue(tabName, sqlTabName, cnosdbMeas)
-		if realDbName != "" && realMeasName != "" {
-			return realDbName, realMeasName, v.SqlFields
+// parseTimeValue converts v into a time according to format. Quoted values
+// are parsed with the common SQL layouts and numbers as Unix seconds when
+// format is empty.
+func parseTimeValue(v sqlValue, format string) (time.Time, error) {
+	var unit time.Duration
+	switch format {
+	case "":
+		if v.quoted {
+			for _, layout := range timeLayouts {
+				if t, err := time.Parse(layout, v.s); err == nil {
+					return t, nil
+				}
+			}
+			return time.Time{}, fmt.Errorf("invalid time %q", v.s)
 		}
+		unit = time.Second
+	case formatUnix:
+		unit = time.Second
+	case formatUnixMs:
+		unit = time.Millisecond
+	case formatUnixUs:
+		unit = time.Microsecond
+	case formatUnixNs:
+		unit = time.Nanosecond
+	default:
+		return time.Parse(format, v.s)
+	}
 
+	if n, err := strconv.ParseInt(v.s, 10, 64); err == nil {
+		if n > math.MaxInt64/int64(unit) || n < math.MinInt64/int64(unit) {
+			return time.Time{}, fmt.Errorf("time %q out of range", v.s)
+		}
+		return time.Unix(0, n*int64(unit)).UTC(), nil
+	}
+	f, err := strconv.ParseFloat(v.s, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	ns := f * float64(unit)
+	if ns > math.MaxInt64 || ns < math.MinInt64 {
+		return time.Time{}, fmt.Errorf("time %q out of range", v.s)
 	}
-	return "", "", nil
+	return time.Unix(0, int64(ns)).UTC(), nil
 }
 
 func matchValue(value, pattern, dest string) string {
@@ -235,17 +664,6 @@ func matchValue(value, pattern, dest string) string {
 	return ""
 }
 
-func replacePrefixOrSuffix(s, old, new string) string {
-	if strings.HasPrefix(s, old) {
-		s = new + s[len(old):]
-	}
-
-	if strings.HasSuffix(s, old) {
-		s = s[0:len(s)-len(old)] + new
-	}
-
-	return s
-}
 func strHasPrefixOrSuffix(s, sub string) bool {
 	if strings.HasPrefix(s, sub) || strings.HasSuffix(s, sub) {
 		return true
@@ -254,81 +672,306 @@ func strHasPrefixOrSuffix(s, sub string) bool {
 	}
 }
 
-func parseValues(str string) ([]string, error) {
-	values := make([]string, 0, 8)
+// sqlValue is a literal of a VALUES list.
+type sqlValue struct {
+	s      string
+	quoted bool
+	null   bool
+}
 
-	i := 0
-	for i < len(str) {
-		if str[i] == '\'' {
-			// read string until another single quote
-			j := i + 1
+// insertStatement is a parsed INSERT statement.
+type insertStatement struct {
+	table   string
+	columns []string // nil if the statement has no column list
+	rows    [][]sqlValue
+}
 
-			escaped := false
-			for j < len(str) {
-				if str[j] == '\\' {
-					// skip escaped character
-					j += 2
-					escaped = true
-					continue
-				} else if str[j] == '\'' {
-					break
-				} else {
-					j++
-				}
-			}
+// backslashMode tells which quoted strings escape characters with
+// backslashes.
+type backslashMode int
 
-			if j >= len(str) {
-				return nil, fmt.Errorf("parse quote values error")
-			}
+const (
+	// escapePrefixed escapes in E'...' strings only, as PostgreSQL does.
+	escapePrefixed backslashMode = iota
+	// escapeAll escapes in every string, as MySQL does.
+	escapeAll
+)
 
-			value := str[i : j+1]
-			if escaped {
-				value = unescapeString(value)
+// stringEscapes returns true if the string quoted at s[i] escapes characters
+// with backslashes.
+func (m backslashMode) stringEscapes(s string, i int) bool {
+	if m == escapeAll {
+		return true
+	}
+	return i > 0 && (s[i-1] == 'E' || s[i-1] == 'e') && (i == 1 || !isIdentChar(s[i-2]))
+}
+
+// statementComplete returns true if s ends with a semicolon outside of any
+// quoted string or identifier. Backslashes escape the next character in the
+// strings escaping characters in the mode m.
+func statementComplete(s string, m backslashMode) bool {
+	var (
+		quote   byte
+		escapes bool
+	)
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case quote != 0 && ch == '\\' && escapes:
+			i++
+		case quote != 0 && ch == quote:
+			quote = 0
+		case quote != 0:
+		case ch == '\'' || ch == '"' || ch == '`':
+			quote = ch
+			escapes = ch == '\'' && m.stringEscapes(s, i)
+		case ch == ';':
+			return true
+		}
+	}
+	return false
+}
+
+// parseInsert parses an INSERT statement of a mysqldump or pg_dump file:
+//
+//	INSERT [IGNORE] INTO table [(column, ...)] VALUES (value, ...)[, (value, ...)]...;
+//
+// Backslashes escape the next character in the strings escaping characters
+// in the mode m.
+func parseInsert(stmt string, m backslashMode) (*insertStatement, error) {
+	p := &insertParser{s: stmt, escapes: m}
+
+	if !p.keyword("INSERT") {
+		return nil, p.errorf("expected INSERT")
+	}
+	p.keyword("IGNORE")
+	if !p.keyword("INTO") {
+		return nil, p.errorf("expected INTO")
+	}
+
+	ins := &insertStatement{}
+	var parts []string
+	for {
+		part, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+		if p.peek() != '.' {
+			break
+		}
+		p.i++
+	}
+	ins.table = strings.Join(parts, ".")
+
+	if p.skipSpace(); p.peek() == '(' {
+		p.i++
+		ins.columns = []string{}
+		for {
+			col, err := p.ident()
+			if err != nil {
+				return nil, err
 			}
-			values = append(values, value)
-			// skip ' and (, or )  )
-			i = j + 2
-		} else if str[i] == '(' || str[i] == ',' || str[i] == ')' || str[i] == ' ' {
-			i = i + 1
-		} else {
-			// no string, read until comma
-			j := i + 1
-			for ; j < len(str) && str[j] != ',' && str[j] != ')'; j++ {
+			ins.columns = append(ins.columns, col)
+			if p.skipSpace(); p.peek() == ',' {
+				p.i++
+				continue
+			} else if p.peek() == ')' {
+				p.i++
+				break
 			}
-			values = append(values, str[i:j])
-			// skip , )
-			i = j + 1
+			return nil, p.errorf("expected , or )")
 		}
+	}
 
-		// need skip blank???
+	if !p.keyword("VALUES") && !p.keyword("VALUE") {
+		return nil, p.errorf("expected VALUES")
 	}
 
-	return values, nil
+	for {
+		row, err := p.tuple()
+		if err != nil {
+			return nil, err
+		}
+		ins.rows = append(ins.rows, row)
+		if p.skipSpace(); p.peek() != ',' {
+			break
+		}
+		p.i++
+	}
+	return ins, nil
 }
 
-func unescapeString(s string) string {
-	i := 0
+type insertParser struct {
+	s       string
+	i       int
+	escapes backslashMode
+}
 
-	value := make([]byte, 0, len(s))
-	for i < len(s) {
-		if s[i] == '\\' {
-			j := i + 1
-			if j == len(s) {
-				// The last char is \, remove
-				break
-			}
+func (p *insertParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("offset %d: %s", p.i, fmt.Sprintf(format, args...))
+}
 
-			value = append(value, unescapeChar(s[j]))
-			i += 2
-		} else {
-			value = append(value, s[i])
-			i++
+func (p *insertParser) peek() byte {
+	if p.i < len(p.s) {
+		return p.s[p.i]
+	}
+	return 0
+}
+
+func (p *insertParser) skipSpace() {
+	for p.i < len(p.s) && isSpace(p.s[p.i]) {
+		p.i++
+	}
+}
+
+// keyword consumes the keyword kw, ignoring case, if it is next.
+func (p *insertParser) keyword(kw string) bool {
+	p.skipSpace()
+	end := p.i + len(kw)
+	if end > len(p.s) || !strings.EqualFold(p.s[p.i:end], kw) {
+		return false
+	}
+	if end < len(p.s) && !isSpace(p.s[end]) && p.s[end] != '(' {
+		return false
+	}
+	p.i = end
+	return true
+}
+
+// ident consumes a bare, backquoted or double-quoted identifier.
+func (p *insertParser) ident() (string, error) {
+	p.skipSpace()
+	switch ch := p.peek(); ch {
+	case '`', '"':
+		return p.quoted(ch, false)
+	case 0:
+		return "", p.errorf("expected identifier")
+	}
+
+	start := p.i
+	for p.i < len(p.s) && !isSpace(p.s[p.i]) && strings.IndexByte(".(),;", p.s[p.i]) < 0 {
+		p.i++
+	}
+	if p.i == start {
+		return "", p.errorf("expected identifier")
+	}
+	return p.s[start:p.i], nil
+}
+
+// quoted consumes a string quoted by q. A doubled quote stands for the quote
+// itself and, if escapes is true, backslashes escape the next character.
+func (p *insertParser) quoted(q byte, escapes bool) (string, error) {
+	p.i++ // opening quote
+	var b strings.Builder
+	for p.i < len(p.s) {
+		ch := p.s[p.i]
+		switch {
+		case ch == '\\' && escapes && p.i+1 < len(p.s):
+			b.WriteByte(unescapeChar(p.s[p.i+1]))
+			p.i += 2
+		case ch == q && p.i+1 < len(p.s) && p.s[p.i+1] == q:
+			b.WriteByte(q)
+			p.i += 2
+		case ch == q:
+			p.i++
+			return b.String(), nil
+		default:
+			b.WriteByte(ch)
+			p.i++
 		}
 	}
+	return "", p.errorf("unterminated quoted string")
+}
+
+// tuple consumes a parenthesized list of values.
+func (p *insertParser) tuple() ([]sqlValue, error) {
+	if p.skipSpace(); p.peek() != '(' {
+		return nil, p.errorf("expected (")
+	}
+	p.i++
 
-	return string(value)
+	var row []sqlValue
+	for {
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		row = append(row, v)
+
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.i++
+		case ')':
+			p.i++
+			return row, nil
+		default:
+			return nil, p.errorf("expected , or )")
+		}
+	}
 }
 
+// value consumes a single literal of a VALUES list.
+func (p *insertParser) value() (sqlValue, error) {
+	p.skipSpace()
+
+	// Strings, optionally prefixed as in E'...', N'...' or _utf8mb4'...'.
+	start := p.i
+	for p.i < len(p.s) && (isLetter(p.s[p.i]) || p.s[p.i] == '_' || (p.i > start && isDigit(p.s[p.i]))) {
+		p.i++
+	}
+	if p.peek() == '\'' {
+		s, err := p.quoted('\'', p.escapes.stringEscapes(p.s, p.i))
+		if err != nil {
+			return sqlValue{}, err
+		}
+		p.skipCast()
+		return sqlValue{s: s, quoted: true}, nil
+	}
+	p.i = start
+
+	// Anything else up to the end of the value, e.g. numbers, NULL or TRUE.
+	depth := 0
+	for ; p.i < len(p.s); p.i++ {
+		ch := p.s[p.i]
+		if ch == '(' {
+			depth++
+		} else if (ch == ',' || ch == ')') && depth == 0 {
+			break
+		} else if ch == ')' {
+			depth--
+		}
+	}
+	s := strings.TrimSpace(p.s[start:p.i])
+	if s == "" {
+		return sqlValue{}, p.errorf("expected value")
+	}
+	if i := strings.Index(s, "::"); i > 0 {
+		s = s[:i]
+	}
+	if strings.EqualFold(s, "NULL") {
+		return sqlValue{null: true}, nil
+	}
+	return sqlValue{s: s}, nil
+}
+
+// skipCast consumes a PostgreSQL type cast following a literal.
+func (p *insertParser) skipCast() {
+	if !strings.HasPrefix(p.s[p.i:], "::") {
+		return
+	}
+	for p.i < len(p.s) && p.s[p.i] != ',' && p.s[p.i] != ')' {
+		p.i++
+	}
+}
+
+func isSpace(ch byte) bool  { return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' }
+func isLetter(ch byte) bool { return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') }
+func isDigit(ch byte) bool  { return ch >= '0' && ch <= '9' }
+
+func isIdentChar(ch byte) bool { return isLetter(ch) || isDigit(ch) || ch == '_' }
+
 func unescapeChar(ch byte) byte {
 	// \" \' \\ \n \0 \b \Z \r \t ==> escape to one char
 	switch ch {
@@ -0,0 +1,309 @@
+package _import
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cnosdb/cnosdb/pkg/importer"
+)
+
+func TestStatementComplete(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		s    string
+		m    backslashMode
+		exp  bool
+	}{
+		{name: "complete", s: `INSERT INTO t VALUES (1);`, exp: true},
+		{name: "no semicolon", s: `INSERT INTO t VALUES (1)`, exp: false},
+		{name: "semicolon in string", s: `INSERT INTO t VALUES ('a;b'`, exp: false},
+		{name: "semicolon in identifier", s: "INSERT INTO `a;b` VALUES (1)", exp: false},
+		{name: "doubled quote", s: `INSERT INTO t VALUES ('it''s;');`, exp: true},
+		{name: "split across lines", s: "INSERT INTO t VALUES ('a\n;b',\n1);", exp: true},
+		{name: "mysql escaped quote", s: `INSERT INTO t VALUES ('a\';');`, m: escapeAll, exp: true},
+		{name: "mysql escaped backslash", s: `INSERT INTO t VALUES ('a\\');`, m: escapeAll, exp: true},
+		{name: "postgres backslash", s: `INSERT INTO t VALUES ('a\');`, exp: true},
+		{name: "postgres backslash after backquote", s: "INSERT INTO t VALUES ('`', 'a\\');", exp: true},
+		{name: "postgres escape string", s: `INSERT INTO t VALUES (E'a\';');`, exp: true},
+		{name: "postgres escape string open", s: `INSERT INTO t VALUES (E'a\');`, exp: false},
+		{name: "postgres prefix of identifier", s: `INSERT INTO t VALUES (TYPE'a\');`, exp: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := statementComplete(tt.s, tt.m); got != tt.exp {
+				t.Fatalf("unexpected result: got=%v exp=%v", got, tt.exp)
+			}
+		})
+	}
+}
+
+func TestParseInsert(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		stmt string
+		m    backslashMode
+		exp  *insertStatement
+		err  bool
+	}{
+		{
+			name: "multi-row",
+			stmt: "INSERT INTO `cpu` VALUES (1,'a',1.5),(2,'b',-2),\n(3,'c',3e2);",
+			m:    escapeAll,
+			exp: &insertStatement{table: "cpu", rows: [][]sqlValue{
+				{{s: "1"}, {s: "a", quoted: true}, {s: "1.5"}},
+				{{s: "2"}, {s: "b", quoted: true}, {s: "-2"}},
+				{{s: "3"}, {s: "c", quoted: true}, {s: "3e2"}},
+			}},
+		},
+		{
+			name: "column list",
+			stmt: `INSERT INTO public.cpu ("time", host, "usage idle") VALUES ('2000-01-01 00:00:00', 'a', 1);`,
+			exp: &insertStatement{table: "public.cpu", columns: []string{"time", "host", "usage idle"}, rows: [][]sqlValue{
+				{{s: "2000-01-01 00:00:00", quoted: true}, {s: "a", quoted: true}, {s: "1"}},
+			}},
+		},
+		{
+			name: "mysql escaped quotes",
+			stmt: `INSERT IGNORE INTO t VALUES ('it\'s','a ''b''','c\\','d\n\"e\"');`,
+			m:    escapeAll,
+			exp: &insertStatement{table: "t", rows: [][]sqlValue{
+				{{s: "it's", quoted: true}, {s: "a 'b'", quoted: true}, {s: `c\`, quoted: true}, {s: "d\n\"e\"", quoted: true}},
+			}},
+		},
+		{
+			name: "postgres escaped quotes",
+			stmt: `INSERT INTO t VALUES ('it''s', 'c:\temp\', E'd\'e\n', '` + "`" + `');`,
+			exp: &insertStatement{table: "t", rows: [][]sqlValue{
+				{{s: "it's", quoted: true}, {s: `c:\temp\`, quoted: true}, {s: "d'e\n", quoted: true}, {s: "`", quoted: true}},
+			}},
+		},
+		{
+			name: "nulls",
+			stmt: `INSERT INTO t VALUES (NULL, null, 'NULL', NULL::text);`,
+			exp: &insertStatement{table: "t", rows: [][]sqlValue{
+				{{null: true}, {null: true}, {s: "NULL", quoted: true}, {null: true}},
+			}},
+		},
+		{
+			name: "casts and prefixes",
+			stmt: `INSERT INTO t VALUES ('1'::integer, _utf8mb4'x', true, now());`,
+			exp: &insertStatement{table: "t", rows: [][]sqlValue{
+				{{s: "1", quoted: true}, {s: "x", quoted: true}, {s: "true"}, {s: "now()"}},
+			}},
+		},
+		{name: "not an insert", stmt: `UPDATE t SET a = 1;`, err: true},
+		{name: "no values", stmt: `INSERT INTO t SELECT 1;`, err: true},
+		{name: "unterminated string", stmt: `INSERT INTO t VALUES ('a);`, err: true},
+		{name: "unterminated row", stmt: `INSERT INTO t VALUES (1, 2;`, err: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ins, err := parseInsert(tt.stmt, tt.m)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", ins)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(ins, tt.exp) {
+				t.Fatalf("unexpected statement:\ngot=%+v\nexp=%+v", ins, tt.exp)
+			}
+		})
+	}
+}
+
+func TestInsertDialect(t *testing.T) {
+	for _, tt := range []struct {
+		stmt string
+		exp  sqlDialect
+	}{
+		{stmt: "INSERT INTO `t` VALUES (1);", exp: dialectMySQL},
+		{stmt: "INSERT IGNORE INTO t VALUES (1);", exp: dialectMySQL},
+		{stmt: "INSERT INTO t VALUES ('`');", exp: dialectPostgres},
+		{stmt: `INSERT INTO "t" VALUES (1);`, exp: dialectPostgres},
+	} {
+		if got := insertDialect(tt.stmt); got != tt.exp {
+			t.Errorf("unexpected dialect of %s: got=%v exp=%v", tt.stmt, got, tt.exp)
+		}
+	}
+}
+
+func TestSqlDumpConverter_Convert(t *testing.T) {
+	tm, err := newTableMapping("cpu", TableInfo{
+		TableName:   "db0/*cpu",
+		Measurement: "cnosdb/cpu",
+		SqlFields: [][]interface{}{
+			{"ts", "host", "note", "value", "id"},
+			{"", "host", "note", "value", ""},
+			{int64(columnTime), int64(columnTag), int64(columnField), int64(columnField), int64(0)},
+			{"unix", "", "string", "float", ""},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		dump    string
+		exp     string
+		summary string
+	}{
+		{
+			name: "mysqldump",
+			dump: "-- MySQL dump 10.13\n" +
+				"/*!40101 SET NAMES utf8mb4 */;\n" +
+				"USE `db0`;\n" +
+				"INSERT INTO `cpu` VALUES (1,'a','it\\'s',1.5,1),(2,'b','c:\\\\',NULL,2),\n" +
+				"(3,NULL,'x;y',3,3);\n" +
+				"INSERT INTO `mem` VALUES (1,2);\n",
+			exp: "# DML\n" +
+				"# CONTEXT-DATABASE: cnosdb\n" +
+				"cpu,host=a note=\"it's\",value=1.5 1000000000\n" +
+				"cpu,host=b note=\"c:\\\\\" 2000000000\n" +
+				"cpu note=\"x;y\",value=3 3000000000\n",
+			summary: "Read 3 rows, skipped 0\n" +
+				"Ignored 1 rows of tables with no mapping\n" +
+				"  mem: 1\n",
+		},
+		{
+			name: "pg_dump",
+			dump: "--\n-- PostgreSQL database dump\n--\n" +
+				"SET standard_conforming_strings = on;\n" +
+				"\\connect db0\n" +
+				"INSERT INTO public.cpu (id, ts, host, note, value) VALUES (1, 1, 'a', 'a`b', 1);\n" +
+				"INSERT INTO public.cpu (id, ts, host, note, value) VALUES (2, 2, 'b`', 'c:\\', 2);\n" +
+				"INSERT INTO public.cpu (id, ts, host, note, value) VALUES (3, 3, 'c', E'd\\'e', 3);\n" +
+				"INSERT INTO public.cpu (id, ts, host, note, value) VALUES (4, 4, 'c', 'multi\n" +
+				"line', 4);\n",
+			exp: "# DML\n" +
+				"# CONTEXT-DATABASE: cnosdb\n" +
+				"cpu,host=a note=\"a`b\",value=1 1000000000\n" +
+				"cpu,host=b` note=\"c:\\\\\",value=2 2000000000\n" +
+				"cpu,host=c note=\"d'e\",value=3 3000000000\n",
+			summary: "Read 4 rows, skipped 1\n" +
+				"  value contains a line break: 1\n",
+		},
+		{
+			name: "pg_dump without standard strings",
+			dump: "SET standard_conforming_strings = off;\n" +
+				"\\connect db0\n" +
+				"INSERT INTO cpu VALUES (1, 'a', 'it\\'s;', 1, 1);\n",
+			exp: "# DML\n" +
+				"# CONTEXT-DATABASE: cnosdb\n" +
+				"cpu,host=a note=\"it's;\",value=1 1000000000\n",
+			summary: "Read 1 rows, skipped 0\n",
+		},
+		{
+			name: "malformed",
+			dump: "USE `db0`;\n" +
+				"INSERT INTO `cpu` VALUES (1,'a','b',1,1),(2;\n" +
+				"INSERT INTO `cpu` VALUES (1,'a','b',1);\n" +
+				"INSERT INTO `cpu` VALUES (NULL,'a','b',1,1);\n" +
+				"INSERT INTO `cpu` VALUES (1,'a','b','x',1);\n",
+			exp: "# DML\n# CONTEXT-DATABASE: cnosdb\n",
+			summary: "Read 3 rows, skipped 3\n" +
+				"  column count does not match the table mapping: 1\n" +
+				"  invalid value for field value: 1\n" +
+				"  null time: 1\n" +
+				"Ignored 1 malformed INSERT statements\n",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newSqlDumpConverter([]*tableMapping{tm})
+			var buf bytes.Buffer
+			if err := c.Convert(strings.NewReader(tt.dump), &buf); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got := buf.String(); got != tt.exp {
+				t.Fatalf("unexpected output:\ngot=%s\nexp=%s", got, tt.exp)
+			}
+
+			var summary bytes.Buffer
+			c.PrintSummary(&summary)
+			if got := summary.String(); got != tt.summary {
+				t.Fatalf("unexpected summary:\ngot=%s\nexp=%s", got, tt.summary)
+			}
+		})
+	}
+}
+
+// Ensure a compressed dump is gunzipped before it is converted, and the
+// converted rows are imported without being gunzipped again.
+func TestImportSqlDumpData_Compressed(t *testing.T) {
+	var mu sync.Mutex
+	var writes []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ping":
+			w.WriteHeader(http.StatusNoContent)
+		case "/write":
+			b, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			writes = append(writes, r.URL.Query().Get("db")+": "+string(b))
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(configFile, []byte(`
+[Tables]
+[Tables.cpu]
+TableName = "db0/cpu"
+Measurement = "cnosdb/cpu"
+SqlFields = [
+	["ts", "host", "value"],
+	["", "host", "value"],
+	[1, 2, 3],
+	["unix", "", "float"],
+]
+`), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	var dump bytes.Buffer
+	gw := gzip.NewWriter(&dump)
+	gw.Write([]byte("USE `db0`;\nINSERT INTO `cpu` VALUES (1,'a',1.5),(2,'b',2);\n"))
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "dump.sql.gz")
+	if err := os.WriteFile(path, dump.Bytes(), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := importer.NewConfig()
+	config.URL = *u
+	config.ClientConfig.Addr = u.String()
+	config.Path = path
+	config.Compressed = true
+	config.SqlDump = true
+	config.ConfigFile = configFile
+	if err := importSqlDumpData(config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	exp := []string{"cnosdb: cpu,host=a value=1.5 1000000000\n\ncpu,host=b value=2 2000000000\n"}
+	if !reflect.DeepEqual(writes, exp) {
+		t.Fatalf("unexpected writes:\ngot=%q\nexp=%q", writes, exp)
+	}
+}
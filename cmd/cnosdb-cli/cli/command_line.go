@@ -181,6 +181,13 @@ func (c *CommandLine) parseCommand(cmd string) error {
 		case "node":
 			c.setNode(cmd)
 		case "insert":
+			// INSERT statements with a column list are run as queries,
+			// other inserts are written as line protocol.
+			if stmt, err := cnosql.ParseStatement(cmd); err == nil {
+				if _, ok := stmt.(*cnosql.InsertStatement); ok {
+					return c.requestQuery(cmd)
+				}
+			}
 			return c.requestInsert(cmd)
 		case "clear":
 			c.clear(cmd)
@@ -0,0 +1,363 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/cnosdb/cnosdb/pkg/columnar"
+	"github.com/cnosdb/cnosdb/vend/db/models"
+	"github.com/cnosdb/cnosdb/vend/db/tsdb/engine/tsm1"
+)
+
+// writeColumnar writes the data of each shard to a file per measurement in
+// the directory <out>/<database>/<retention policy>/<shard id>.
+func (cmd *Command) writeColumnar() error {
+	format, err := columnar.ParseFormat(cmd.format)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(cmd.manifest))
+	for key := range cmd.manifest {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		// The TSM files of a shard are in the directory of its ID, as are
+		// its WAL files.
+		shards := make(map[string]*columnarShard)
+		shard := func(path string) *columnarShard {
+			id := filepath.Base(filepath.Dir(path))
+			if shards[id] == nil {
+				shards[id] = &columnarShard{}
+			}
+			return shards[id]
+		}
+		for _, f := range cmd.tsmFiles[key] {
+			sh := shard(f)
+			sh.tsmFiles = append(sh.tsmFiles, f)
+		}
+		for _, f := range cmd.walFiles[key] {
+			sh := shard(f)
+			sh.walFiles = append(sh.walFiles, f)
+		}
+		ids := make([]string, 0, len(shards))
+		for id := range shards {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		for _, id := range ids {
+			out := filepath.Join(cmd.out, key, id)
+			fmt.Fprintf(cmd.Stdout, "writing out tsm and wal file data for %s to %s...", filepath.Join(key, id), out)
+			if err := cmd.exportShardColumnar(shards[id], out, format); err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.Stdout, "complete.")
+		}
+	}
+	return nil
+}
+
+// columnarShard is the TSM and WAL files of a shard.
+type columnarShard struct {
+	tsmFiles []string
+	walFiles []string
+}
+
+// columnarMeasurement collects the series of a measurement in a shard.
+type columnarMeasurement struct {
+	tags   map[string]struct{}
+	fields map[string]byte
+	// series maps the series keys of the measurement to their field keys.
+	series map[string][]string
+}
+
+// exportShardColumnar writes the data of the TSM and WAL files of a shard to
+// a file per measurement in dir. The values of a series are read from every
+// file and merged into rows by time, the values of the WAL replacing those
+// of the TSM files.
+func (cmd *Command) exportShardColumnar(shard *columnarShard, dir string, format columnar.Format) error {
+	// we need to make sure we read the same order that the files were written
+	files := shard.tsmFiles
+	sort.Strings(files)
+
+	var readers []*tsm1.TSMReader
+	defer func() {
+		for _, r := range readers {
+			r.Close()
+		}
+	}()
+	for _, path := range files {
+		f, err := os.Open(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				fmt.Fprintf(cmd.Stderr, "skipped missing file: %s\n", path)
+				continue
+			}
+			return err
+		}
+		r, err := tsm1.NewTSMReader(f)
+		if err != nil {
+			f.Close()
+			fmt.Fprintf(cmd.Stderr, "unable to read %s, skipping: %s\n", path, err.Error())
+			continue
+		}
+		if min, max := r.TimeRange(); min > cmd.endTime || max < cmd.startTime {
+			r.Close()
+			continue
+		}
+		readers = append(readers, r)
+	}
+
+	wal, err := cmd.readWALColumnar(shard.walFiles)
+	if err != nil {
+		return err
+	}
+
+	measurements := make(map[string]*columnarMeasurement)
+	seen := make(map[string]struct{})
+	add := func(key []byte, typ byte) {
+		if _, ok := seen[string(key)]; ok {
+			return
+		}
+		seen[string(key)] = struct{}{}
+
+		seriesKey, field := tsm1.SeriesAndFieldFromCompositeKey(key)
+		name, tags := models.ParseKeyBytes(seriesKey)
+		m := measurements[string(name)]
+		if m == nil {
+			m = &columnarMeasurement{
+				tags:   make(map[string]struct{}),
+				fields: make(map[string]byte),
+				series: make(map[string][]string),
+			}
+			measurements[string(name)] = m
+		}
+		for _, t := range tags {
+			m.tags[string(t.Key)] = struct{}{}
+		}
+		if _, ok := m.fields[string(field)]; !ok {
+			m.fields[string(field)] = typ
+		}
+		m.series[string(seriesKey)] = append(m.series[string(seriesKey)], string(field))
+	}
+	for _, r := range readers {
+		for i := 0; i < r.KeyCount(); i++ {
+			add(r.KeyAt(i))
+		}
+	}
+	walKeys := make([]string, 0, len(wal))
+	for key := range wal {
+		walKeys = append(walKeys, key)
+	}
+	sort.Strings(walKeys)
+	for _, key := range walKeys {
+		add([]byte(key), valueBlockType(wal[key][0]))
+	}
+
+	names := make([]string, 0, len(measurements))
+	for name := range measurements {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := cmd.exportMeasurementColumnar(readers, wal, name, measurements[name], dir, format); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readWALColumnar replays the WAL files of a shard, in the order they were
+// written, into the values of each series key and field. Files are not
+// modified, and the entries following a corrupt entry of a file are skipped.
+func (cmd *Command) readWALColumnar(files []string) (map[string]tsm1.Values, error) {
+	// we need to make sure we read the same order that the wal received the data
+	sort.Strings(files)
+
+	values := make(map[string]tsm1.Values)
+	for _, path := range files {
+		if err := func() error {
+			f, err := os.Open(path)
+			if err != nil {
+				if os.IsNotExist(err) {
+					fmt.Fprintf(cmd.Stderr, "skipped missing file: %s\n", path)
+					return nil
+				}
+				return err
+			}
+			defer f.Close()
+
+			r := tsm1.NewWALSegmentReader(f)
+			defer r.Close()
+			for r.Next() {
+				entry, err := r.Read()
+				if err != nil {
+					fmt.Fprintf(cmd.Stderr, "file %s corrupt at position %d: %v\n", path, r.Count(), err)
+					return nil
+				}
+
+				switch t := entry.(type) {
+				case *tsm1.WriteWALEntry:
+					for key, v := range t.Values {
+						values[key] = append(values[key], v...)
+					}
+				case *tsm1.DeleteWALEntry:
+					for _, key := range t.Keys {
+						delete(values, string(key))
+					}
+				case *tsm1.DeleteRangeWALEntry:
+					for _, key := range t.Keys {
+						if v := values[string(key)].Exclude(t.Min, t.Max); len(v) > 0 {
+							values[string(key)] = v
+						} else {
+							delete(values, string(key))
+						}
+					}
+				}
+			}
+			return nil
+		}(); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// valueBlockType returns the type of the TSM blocks of values of the type
+// of v.
+func valueBlockType(v tsm1.Value) byte {
+	switch v.Value().(type) {
+	case int64:
+		return tsm1.BlockInteger
+	case uint64:
+		return tsm1.BlockUnsigned
+	case bool:
+		return tsm1.BlockBoolean
+	case string:
+		return tsm1.BlockString
+	}
+	return tsm1.BlockFloat64
+}
+
+func (cmd *Command) exportMeasurementColumnar(readers []*tsm1.TSMReader, wal map[string]tsm1.Values, name string, m *columnarMeasurement, dir string, format columnar.Format) error {
+	s := &columnar.Schema{Measurement: name}
+	for k := range m.tags {
+		s.Tags = append(s.Tags, k)
+	}
+	sort.Strings(s.Tags)
+	tagIndex := make(map[string]int, len(s.Tags))
+	for i, k := range s.Tags {
+		tagIndex[k] = i
+	}
+
+	fieldKeys := make([]string, 0, len(m.fields))
+	for k := range m.fields {
+		fieldKeys = append(fieldKeys, k)
+	}
+	sort.Strings(fieldKeys)
+	fieldIndex := make(map[string]int, len(fieldKeys))
+	for i, k := range fieldKeys {
+		fieldIndex[k] = i
+		s.Fields = append(s.Fields, columnar.Field{Key: k, Type: tsm1.BlockTypeToCnosQLDataType(m.fields[k])})
+	}
+
+	seriesKeys := make([]string, 0, len(m.series))
+	for k := range m.series {
+		seriesKeys = append(seriesKeys, k)
+	}
+	sort.Strings(seriesKeys)
+
+	w, err := columnar.Create(dir, format, s)
+	if err != nil {
+		return err
+	}
+
+	b := columnar.NewBatch(s)
+	tags := make([]string, len(s.Tags))
+	row := make([]interface{}, len(s.Fields))
+	for _, seriesKey := range seriesKeys {
+		for i := range tags {
+			tags[i] = ""
+		}
+		_, t := models.ParseKeyBytes([]byte(seriesKey))
+		for _, tag := range t {
+			tags[tagIndex[string(tag.Key)]] = string(tag.Value)
+		}
+
+		// Read the values of each field of the series, in the order of
+		// the columns.
+		fields := m.series[seriesKey]
+		values := make([]tsm1.Values, len(fields))
+		index := make([]int, len(fields))
+		for i, field := range fields {
+			key := tsm1.SeriesFieldKeyBytes(seriesKey, field)
+			for _, r := range readers {
+				if !r.Contains(key) {
+					continue
+				}
+				v, err := r.ReadAll(key)
+				if err != nil {
+					fmt.Fprintf(cmd.Stderr, "unable to read key %q, skipping: %s\n", string(key), err.Error())
+					continue
+				}
+				values[i] = append(values[i], v...)
+			}
+			values[i] = append(values[i], wal[string(key)]...)
+			values[i] = values[i].Deduplicate()
+			index[i] = fieldIndex[field]
+		}
+
+		// Merge the values of the fields into rows by time.
+		pos := make([]int, len(fields))
+		for {
+			ts := int64(0)
+			found := false
+			for i, v := range values {
+				for pos[i] < len(v) && v[pos[i]].UnixNano() < cmd.startTime {
+					pos[i]++
+				}
+				if pos[i] < len(v) && v[pos[i]].UnixNano() <= cmd.endTime {
+					if t := v[pos[i]].UnixNano(); !found || t < ts {
+						ts, found = t, true
+					}
+				}
+			}
+			if !found {
+				break
+			}
+
+			for i := range row {
+				row[i] = nil
+			}
+			for i, v := range values {
+				if pos[i] < len(v) && v[pos[i]].UnixNano() == ts {
+					row[index[i]] = v[pos[i]].Value()
+					pos[i]++
+				}
+			}
+			if err := b.Append(ts, tags, row); err != nil {
+				w.Close()
+				return err
+			}
+
+			if b.Len() == columnar.RowGroupSize {
+				if err := w.Write(b); err != nil {
+					w.Close()
+					return err
+				}
+				b.Reset()
+			}
+		}
+	}
+
+	if err := w.Write(b); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
@@ -0,0 +1,163 @@
+package export
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/cnosdb/cnosdb/pkg/columnar"
+	"github.com/cnosdb/cnosdb/vend/db/tsdb/engine/tsm1"
+
+	"github.com/golang/snappy"
+)
+
+func compositeKey(seriesKey, field string) string {
+	return string(tsm1.SeriesFieldKeyBytes(seriesKey, field))
+}
+
+func writeTSMFile(t *testing.T, path string, values map[string][]tsm1.Value) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := tsm1.NewTSMWriter(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := w.Write([]byte(k), values[k]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.WriteIndex(); err != nil {
+		t.Fatal(err)
+	} else if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeWALFile(t *testing.T, path string, entries ...tsm1.WALEntry) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := tsm1.NewWALSegmentWriter(f)
+	for _, e := range entries {
+		b, err := e.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		} else if err := w.Write(e.Type(), snappy.Encode(nil, b)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	} else if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readColumnar returns the time, tags and fields of the rows of a Parquet
+// file.
+func readColumnar(t *testing.T, path string) [][]interface{} {
+	t.Helper()
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := columnar.NewReader(bytes.NewReader(buf), int64(len(buf)), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows [][]interface{}
+	for i := 0; i < r.NumRowGroups(); i++ {
+		b, err := r.ReadRowGroup(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := 0; j < b.Len(); j++ {
+			row := []interface{}{b.Time[j]}
+			for _, tags := range b.Tags {
+				row = append(row, tags[j])
+			}
+			for k := range b.Schema().Fields {
+				row = append(row, b.Field(k, j))
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// Ensure the data of the WAL is exported with the data of the TSM files, and
+// the deletes of the WAL are applied.
+func TestCommand_ExportColumnar_WAL(t *testing.T) {
+	dir, err := os.MkdirTemp("", "cnosdb-export-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	dataDir, walDir, out := filepath.Join(dir, "data"), filepath.Join(dir, "wal"), filepath.Join(dir, "out")
+	writeTSMFile(t, filepath.Join(dataDir, "db0", "rp0", "1", "000000001-000000001.tsm"), map[string][]tsm1.Value{
+		compositeKey("cpu,host=a", "value"): {tsm1.NewValue(10, 1.0), tsm1.NewValue(20, 2.0)},
+	})
+	writeWALFile(t, filepath.Join(walDir, "db0", "rp0", "1", "_00001.wal"),
+		&tsm1.WriteWALEntry{Values: map[string][]tsm1.Value{
+			compositeKey("cpu,host=a", "value"): {tsm1.NewValue(30, 3.0), tsm1.NewValue(20, 4.0)},
+			compositeKey("cpu,host=b", "value"): {tsm1.NewValue(10, 5.0), tsm1.NewValue(40, 6.0)},
+			compositeKey("disk", "used"):        {tsm1.NewValue(10, int64(1))},
+		}},
+		&tsm1.DeleteWALEntry{Keys: [][]byte{[]byte(compositeKey("disk", "used"))}},
+		&tsm1.DeleteRangeWALEntry{Keys: [][]byte{[]byte(compositeKey("cpu,host=b", "value"))}, Min: 0, Max: 10},
+	)
+	// A shard whose data is only in its WAL.
+	writeWALFile(t, filepath.Join(walDir, "db0", "rp0", "2", "_00001.wal"),
+		&tsm1.WriteWALEntry{Values: map[string][]tsm1.Value{
+			compositeKey("mem,host=a", "free"): {tsm1.NewValue(1, int64(7))},
+		}},
+	)
+
+	cmd := NewCommand()
+	cmd.Stdout, cmd.Stderr = &bytes.Buffer{}, &bytes.Buffer{}
+	cmd.dataDir, cmd.walDir, cmd.out = dataDir, walDir, out
+	cmd.format = "parquet"
+	cmd.startTime, cmd.endTime = math.MinInt64, math.MaxInt64
+	if err := cmd.export(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if rows, exp := readColumnar(t, filepath.Join(out, "db0", "rp0", "1", "cpu.parquet")), [][]interface{}{
+		{int64(10), "a", 1.0},
+		{int64(20), "a", 4.0},
+		{int64(30), "a", 3.0},
+		{int64(40), "b", 6.0},
+	}; !reflect.DeepEqual(rows, exp) {
+		t.Fatalf("unexpected rows of cpu:\ngot=%v\nexp=%v", rows, exp)
+	}
+	if rows, exp := readColumnar(t, filepath.Join(out, "db0", "rp0", "2", "mem.parquet")), [][]interface{}{
+		{int64(1), "a", int64(7)},
+	}; !reflect.DeepEqual(rows, exp) {
+		t.Fatalf("unexpected rows of mem:\ngot=%v\nexp=%v", rows, exp)
+	}
+	if _, err := os.Stat(filepath.Join(out, "db0", "rp0", "1", "disk.parquet")); !os.IsNotExist(err) {
+		t.Fatalf("expected the deleted series not to be exported: %v", err)
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/cnosdb/cnosdb/pkg/columnar"
 	"github.com/cnosdb/cnosdb/pkg/escape"
 	"github.com/cnosdb/cnosdb/vend/cnosql"
 	"github.com/cnosdb/cnosdb/vend/db/models"
@@ -35,8 +36,8 @@ var (
 func GetCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:     "export",
-		Short:   "exports raw data from a shard to line protocol",
-		Long:    "Exports TSM files into CnosDB line protocol format.",
+		Short:   "exports raw data from a shard to line protocol, Parquet or Arrow",
+		Long:    "Exports TSM files into CnosDB line protocol format, or into Parquet or Arrow IPC files per measurement of each shard.",
 		Example: examples,
 		CompletionOptions: cobra.CompletionOptions{
 			DisableDefaultCmd:   true,
@@ -99,6 +100,7 @@ func GetCommand() *cobra.Command {
 	fs.StringVar(&start, "start", "", "Optional: the start time to export (RFC3339 format)")
 	fs.StringVar(&end, "end", "", "Optional: the end time to export (RFC3339 format)")
 	fs.BoolVar(&exportCmd.compress, "compress", false, "Compress the output")
+	fs.StringVar(&exportCmd.format, "format", "line", "Output format: line, parquet or arrow (parquet and arrow write a directory)")
 
 	return c
 }
@@ -117,6 +119,7 @@ type Command struct {
 	startTime       int64
 	endTime         int64
 	compress        bool
+	format          string
 
 	manifest map[string]struct{}
 	tsmFiles map[string][]string
@@ -128,6 +131,7 @@ func NewCommand() *Command {
 	return &Command{
 		Stderr: os.Stderr,
 		Stdout: os.Stdout,
+		format: "line",
 
 		manifest: make(map[string]struct{}),
 		tsmFiles: make(map[string][]string),
@@ -142,6 +146,11 @@ func (cmd *Command) validate() error {
 	if cmd.startTime != 0 && cmd.endTime != 0 && cmd.endTime < cmd.startTime {
 		return fmt.Errorf("end time before start time")
 	}
+	if cmd.format != "line" {
+		if _, err := columnar.ParseFormat(cmd.format); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -152,6 +161,9 @@ func (cmd *Command) export() error {
 	if err := cmd.walkWALFiles(); err != nil {
 		return err
 	}
+	if cmd.format != "line" {
+		return cmd.writeColumnar()
+	}
 	return cmd.write()
 }
 
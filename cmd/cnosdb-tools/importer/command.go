@@ -32,6 +32,8 @@ type Options struct {
 	shardDuration   time.Duration
 	buildTSI        bool
 	replace         bool
+	format          string
+	path            string
 }
 
 var opt = NewOption(server.NewSingleServer())
@@ -39,7 +41,7 @@ var opt = NewOption(server.NewSingleServer())
 func GetCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "import",
-		Short: "The import tool consumes binary data and write data directly to disk",
+		Short: "The import tool consumes binary or Parquet data and write data directly to disk",
 
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
 
@@ -51,6 +53,16 @@ func GetCommand() *cobra.Command {
 				return errors.New("[ERR] retention policy is required\n")
 			}
 
+			switch opt.format {
+			case "binary":
+			case "parquet":
+				if opt.path == "" {
+					return errors.New("[ERR] path is required to import parquet files\n")
+				}
+			default:
+				return errors.Errorf("[ERR] unknown format %q\n", opt.format)
+			}
+
 			err = opt.server.Open(opt.configPath)
 			if err != nil {
 				return err
@@ -58,10 +70,13 @@ func GetCommand() *cobra.Command {
 
 			i := newImporter(opt.server, opt.database, opt.retentionPolicy, opt.replace, opt.buildTSI, opt.Logger)
 
-			reader := binary.NewReader(opt.Stdin)
-			_, err = reader.ReadHeader()
-			if err != nil {
-				return err
+			var reader *binary.Reader
+			if opt.format == "binary" {
+				reader = binary.NewReader(opt.Stdin)
+				_, err = reader.ReadHeader()
+				if err != nil {
+					return err
+				}
 			}
 
 			rp := &meta.RetentionPolicySpec{Name: opt.retentionPolicy, ShardGroupDuration: opt.shardDuration}
@@ -77,6 +92,10 @@ func GetCommand() *cobra.Command {
 				return err
 			}
 
+			if opt.format == "parquet" {
+				return importParquet(i, opt.path, i.rpi.ShardGroupDuration)
+			}
+
 			var bh *binary.BucketHeader
 			for bh, err = reader.NextBucket(); (bh != nil) && (err == nil); bh, err = reader.NextBucket() {
 				err = importShard(reader, i, bh.Start, bh.End)
@@ -96,6 +115,8 @@ func GetCommand() *cobra.Command {
 	c.PersistentFlags().DurationVar(&opt.shardDuration, "shard-duration", time.Hour*24*7, "Retention policy shard duration")
 	c.PersistentFlags().BoolVar(&opt.buildTSI, "build-tsi", false, "Build the on disk TSI")
 	c.PersistentFlags().BoolVar(&opt.replace, "replace", false, "Enables replacing an existing retention policy")
+	c.PersistentFlags().StringVar(&opt.format, "format", "binary", "Input format: binary, read from stdin, or parquet")
+	c.PersistentFlags().StringVar(&opt.path, "path", "", "Parquet file, or directory of Parquet files, to import")
 
 	return c
 }
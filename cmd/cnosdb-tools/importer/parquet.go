@@ -0,0 +1,246 @@
+package importer
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/internal/errlist"
+	"github.com/cnosdb/cnosdb/pkg/columnar"
+	"github.com/cnosdb/cnosdb/vend/db/models"
+	"github.com/cnosdb/cnosdb/vend/db/tsdb/engine/tsm1"
+)
+
+// parquetFile is a Parquet file of the rows of a measurement.
+type parquetFile struct {
+	path string
+	f    *os.File
+	r    *columnar.Reader
+
+	// The shard groups of the rows of each row group.
+	buckets [][]int64
+	// tagOrder lists the tag columns in the order of their keys.
+	tagOrder []int
+}
+
+// importParquet imports the Parquet files found at path, a file or a
+// directory, creating a shard group for each shard duration holding rows.
+// The rows of a shard group are gathered in memory before being written.
+func importParquet(i *importer, path string, shardDuration time.Duration) error {
+	files, err := openParquetFiles(path, shardDuration)
+	defer func() {
+		for _, pf := range files {
+			pf.f.Close()
+		}
+	}()
+	if err != nil {
+		return err
+	}
+
+	set := make(map[int64]struct{})
+	for _, pf := range files {
+		for _, buckets := range pf.buckets {
+			for _, b := range buckets {
+				set[b] = struct{}{}
+			}
+		}
+	}
+	buckets := make([]int64, 0, len(set))
+	for b := range set {
+		buckets = append(buckets, b)
+	}
+	sort.Slice(buckets, func(a, b int) bool { return buckets[a] < buckets[b] })
+
+	for _, start := range buckets {
+		if err := importParquetShard(i, files, start, start+int64(shardDuration)-1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func openParquetFiles(path string, shardDuration time.Duration) ([]*parquetFile, error) {
+	var paths []string
+	err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && (p == path || filepath.Ext(p) == columnar.Parquet.Ext()) {
+			paths = append(paths, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*parquetFile
+	for _, p := range paths {
+		pf, err := openParquetFile(p, shardDuration)
+		if err != nil {
+			return files, fmt.Errorf("%s: %s", p, err)
+		}
+		files = append(files, pf)
+	}
+	return files, nil
+}
+
+func openParquetFile(path string, shardDuration time.Duration) (*parquetFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	// Files without metadata hold the measurement named after the file.
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if n, err := url.PathUnescape(name); err == nil {
+		name = n
+	}
+	r, err := columnar.NewReader(f, fi.Size(), name)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	pf := &parquetFile{path: path, f: f, r: r}
+	s := r.Schema()
+	pf.tagOrder = make([]int, len(s.Tags))
+	for k := range pf.tagOrder {
+		pf.tagOrder[k] = k
+	}
+	sort.Slice(pf.tagOrder, func(a, b int) bool { return s.Tags[pf.tagOrder[a]] < s.Tags[pf.tagOrder[b]] })
+
+	truncate := func(t int64) int64 { return time.Unix(0, t).Truncate(shardDuration).UnixNano() }
+	for rg := 0; rg < r.NumRowGroups(); rg++ {
+		min, max, err := r.TimeRange(rg)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if start := truncate(min); start == truncate(max) {
+			pf.buckets = append(pf.buckets, []int64{start})
+			continue
+		}
+
+		// The rows span several shard groups, which may not all hold rows.
+		times, err := r.Times(rg)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		set := make(map[int64]struct{})
+		var buckets []int64
+		for _, t := range times {
+			start := truncate(t)
+			if _, ok := set[start]; !ok {
+				set[start] = struct{}{}
+				buckets = append(buckets, start)
+			}
+		}
+		pf.buckets = append(pf.buckets, buckets)
+	}
+	return pf, nil
+}
+
+// importParquetShard writes the rows of the files between start and end to
+// their shard group.
+func importParquetShard(i *importer, files []*parquetFile, start, end int64) error {
+	values := make(map[string]tsm1.Values)
+	for _, pf := range files {
+		s := pf.r.Schema()
+		for rg, buckets := range pf.buckets {
+			found := false
+			for _, b := range buckets {
+				if b == start {
+					found = true
+					break
+				}
+			}
+			if !found {
+				continue
+			}
+
+			b, err := pf.r.ReadRowGroup(rg)
+			if err != nil {
+				return fmt.Errorf("%s: %s", pf.path, err)
+			}
+
+			var key []byte
+			tags := make(models.Tags, 0, len(s.Tags))
+			for row, t := range b.Time {
+				if t < start || t > end {
+					continue
+				}
+				tags = tags[:0]
+				for _, k := range pf.tagOrder {
+					if v := b.Tags[k][row]; v != "" {
+						tags = append(tags, models.NewTag([]byte(s.Tags[k]), []byte(v)))
+					}
+				}
+				key = models.AppendMakeKey(key[:0], []byte(s.Measurement), tags)
+				for j, f := range s.Fields {
+					v := b.Field(j, row)
+					if v == nil {
+						continue
+					}
+					sfk := string(tsm1.SeriesFieldKeyBytes(string(key), f.Key))
+					values[sfk] = append(values[sfk], tsm1.NewValue(t, v))
+				}
+			}
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	if err := i.StartShardGroup(start, end); err != nil {
+		return err
+	}
+
+	el := errlist.NewErrorList()
+	var err error
+	var lastSeries []byte
+	for _, k := range keys {
+		seriesKey, _ := tsm1.SeriesAndFieldFromCompositeKey([]byte(k))
+		if string(seriesKey) != string(lastSeries) {
+			if err = i.AddSeries(seriesKey); err != nil {
+				break
+			}
+			lastSeries = seriesKey
+		}
+
+		vs := values[k].Deduplicate()
+		for len(vs) > 0 && err == nil {
+			n := len(vs)
+			if n > seriesBatchSize {
+				n = seriesBatchSize
+			}
+			err = i.Write([]byte(k), vs[:n])
+			vs = vs[n:]
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	el.Add(err)
+	if i.sh != nil || i.skipShard {
+		el.Add(i.CloseShardGroup())
+	}
+	return el.Err()
+}
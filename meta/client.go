@@ -110,6 +110,11 @@ type MetaClient interface {
 	CreateSubscription(database, rp, name, mode string, destinations []string) error
 	DropSubscription(database, rp, name string) error
 
+	SetJSONWriteMapping(database string, m *JSONWriteMappingInfo) error
+	DropJSONWriteMapping(database string) error
+	CreateMeasurementSchema(database string, schema *MeasurementSchemaInfo) error
+	DropMeasurementSchema(database, name string) error
+
 	SetData(data *Data) error
 	Data() Data
 	WaitForDataChanged() chan struct{}
@@ -1027,6 +1032,78 @@ func (c *Client) CreateSubscription(database, rp, name, mode string, destination
 	return nil
 }
 
+// SetJSONWriteMapping sets the mapping used to convert JSON documents written to the database into points.
+func (c *Client) SetJSONWriteMapping(database string, m *JSONWriteMappingInfo) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data := c.cacheData.Clone()
+
+	if err := data.SetJSONWriteMapping(database, m); err != nil {
+		return err
+	}
+
+	if err := c.commit(data); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DropJSONWriteMapping removes the JSON write mapping of the database.
+func (c *Client) DropJSONWriteMapping(database string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data := c.cacheData.Clone()
+
+	if err := data.DropJSONWriteMapping(database); err != nil {
+		return err
+	}
+
+	if err := c.commit(data); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CreateMeasurementSchema declares the write schema of a measurement in the database.
+func (c *Client) CreateMeasurementSchema(database string, schema *MeasurementSchemaInfo) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data := c.cacheData.Clone()
+
+	if err := data.CreateMeasurementSchema(database, schema); err != nil {
+		return err
+	}
+
+	if err := c.commit(data); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DropMeasurementSchema removes the write schema of a measurement in the database.
+func (c *Client) DropMeasurementSchema(database, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data := c.cacheData.Clone()
+
+	if err := data.DropMeasurementSchema(database, name); err != nil {
+		return err
+	}
+
+	if err := c.commit(data); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // DropSubscription removes the named subscription from the given database and retention policy.
 func (c *Client) DropSubscription(database, rp, name string) error {
 	c.mu.Lock()
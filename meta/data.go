@@ -922,6 +922,74 @@ func (data *Data) DropContinuousQuery(database, name string) error {
 	return nil
 }
 
+// SetJSONWriteMapping sets the mapping used to convert JSON documents
+// written to a database into points.
+func (data *Data) SetJSONWriteMapping(database string, m *JSONWriteMappingInfo) error {
+	di := data.Database(database)
+	if di == nil {
+		return cnosdb.ErrDatabaseNotFound(database)
+	}
+
+	if err := m.validate(); err != nil {
+		return err
+	}
+
+	other := m.clone()
+	di.JSONWriteMapping = &other
+	return nil
+}
+
+// DropJSONWriteMapping removes the JSON write mapping of a database.
+func (data *Data) DropJSONWriteMapping(database string) error {
+	di := data.Database(database)
+	if di == nil {
+		return cnosdb.ErrDatabaseNotFound(database)
+	}
+
+	di.JSONWriteMapping = nil
+	return nil
+}
+
+// CreateMeasurementSchema declares the write schema of a measurement.
+func (data *Data) CreateMeasurementSchema(database string, schema *MeasurementSchemaInfo) error {
+	di := data.Database(database)
+	if di == nil {
+		return cnosdb.ErrDatabaseNotFound(database)
+	}
+
+	if err := schema.validate(); err != nil {
+		return err
+	}
+
+	// Ensure the schema doesn't already exist. Re-declaring an identical
+	// schema is a no-op.
+	if s := di.MeasurementSchema(schema.Name); s != nil {
+		if s.equal(schema) {
+			return nil
+		}
+		return ErrMeasurementSchemaExists
+	}
+
+	di.MeasurementSchemas = append(di.MeasurementSchemas, schema.clone())
+	return nil
+}
+
+// DropMeasurementSchema removes the write schema of a measurement.
+func (data *Data) DropMeasurementSchema(database, name string) error {
+	di := data.Database(database)
+	if di == nil {
+		return cnosdb.ErrDatabaseNotFound(database)
+	}
+
+	for i := range di.MeasurementSchemas {
+		if di.MeasurementSchemas[i].Name == name {
+			di.MeasurementSchemas = append(di.MeasurementSchemas[:i], di.MeasurementSchemas[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
 // validateURL returns an error if the URL does not have a port or uses a scheme other than UDP or HTTP.
 func validateURL(input string) error {
 	u, err := url.Parse(input)
@@ -1443,6 +1511,8 @@ type DatabaseInfo struct {
 	DefaultRetentionPolicy string
 	RetentionPolicies      []RetentionPolicyInfo
 	ContinuousQueries      []ContinuousQueryInfo
+	JSONWriteMapping       *JSONWriteMappingInfo
+	MeasurementSchemas     []MeasurementSchemaInfo
 }
 
 // RetentionPolicy returns a retention policy by name.
@@ -1462,6 +1532,16 @@ func (di DatabaseInfo) RetentionPolicy(name string) *RetentionPolicyInfo {
 	return nil
 }
 
+// MeasurementSchema returns the write schema of a measurement by name.
+func (di DatabaseInfo) MeasurementSchema(name string) *MeasurementSchemaInfo {
+	for i := range di.MeasurementSchemas {
+		if di.MeasurementSchemas[i].Name == name {
+			return &di.MeasurementSchemas[i]
+		}
+	}
+	return nil
+}
+
 // ShardInfos returns a list of all shards' info for the database.
 func (di DatabaseInfo) ShardInfos() []ShardInfo {
 	shards := map[uint64]*ShardInfo{}
@@ -1506,6 +1586,18 @@ func (di DatabaseInfo) clone() DatabaseInfo {
 		}
 	}
 
+	if di.JSONWriteMapping != nil {
+		m := di.JSONWriteMapping.clone()
+		other.JSONWriteMapping = &m
+	}
+
+	if di.MeasurementSchemas != nil {
+		other.MeasurementSchemas = make([]MeasurementSchemaInfo, len(di.MeasurementSchemas))
+		for i := range di.MeasurementSchemas {
+			other.MeasurementSchemas[i] = di.MeasurementSchemas[i].clone()
+		}
+	}
+
 	return other
 }
 
@@ -1524,6 +1616,15 @@ func (di DatabaseInfo) marshal() *internal.DatabaseInfo {
 	for i := range di.ContinuousQueries {
 		pb.ContinuousQueries[i] = di.ContinuousQueries[i].marshal()
 	}
+
+	if di.JSONWriteMapping != nil {
+		pb.JSONWriteMapping = di.JSONWriteMapping.marshal()
+	}
+
+	pb.MeasurementSchemas = make([]*internal.MeasurementSchemaInfo, len(di.MeasurementSchemas))
+	for i := range di.MeasurementSchemas {
+		pb.MeasurementSchemas[i] = di.MeasurementSchemas[i].marshal()
+	}
 	return pb
 }
 
@@ -1545,6 +1646,18 @@ func (di *DatabaseInfo) unmarshal(pb *internal.DatabaseInfo) {
 			di.ContinuousQueries[i].unmarshal(x)
 		}
 	}
+
+	if pb.JSONWriteMapping != nil {
+		di.JSONWriteMapping = &JSONWriteMappingInfo{}
+		di.JSONWriteMapping.unmarshal(pb.GetJSONWriteMapping())
+	}
+
+	if len(pb.GetMeasurementSchemas()) > 0 {
+		di.MeasurementSchemas = make([]MeasurementSchemaInfo, len(pb.GetMeasurementSchemas()))
+		for i, x := range pb.GetMeasurementSchemas() {
+			di.MeasurementSchemas[i].unmarshal(x)
+		}
+	}
 }
 
 // RetentionPolicySpec represents the specification for a new retention policy.
@@ -2099,6 +2212,205 @@ func (cqi *ContinuousQueryInfo) unmarshal(pb *internal.ContinuousQueryInfo) {
 	cqi.Query = pb.GetQuery()
 }
 
+// JSONWriteMappingInfo describes how the JSON documents written to a database
+// are converted into points. Measurement, Tags, Fields and Timestamp hold
+// dot-separated paths into a document.
+type JSONWriteMappingInfo struct {
+	// Measurement is the path of the measurement name.
+	Measurement string `json:"measurement,omitempty"`
+	// DefaultMeasurement is used when the document has no value at Measurement.
+	DefaultMeasurement string `json:"default_measurement,omitempty"`
+	// Tags and Fields are the paths of the tag and field values. A path
+	// referring to an object selects all of its nested values.
+	Tags   []string `json:"tags,omitempty"`
+	Fields []string `json:"fields"`
+	// Timestamp is the path of the timestamp, the time of the write is used
+	// when it is empty or the document has no value at the path.
+	Timestamp string `json:"timestamp,omitempty"`
+	// TimestampFormat is the format of the timestamp: unix, unix_ms, unix_us,
+	// unix_ns or a Go time layout holding at least the date. RFC3339 is used
+	// when empty.
+	TimestampFormat string `json:"timestamp_format,omitempty"`
+}
+
+// validate returns an error if the mapping has no measurement or fields, or
+// an unknown timestamp format.
+func (m *JSONWriteMappingInfo) validate() error {
+	if m == nil || (m.Measurement == "" && m.DefaultMeasurement == "") {
+		return ErrJSONWriteMappingMeasurementRequired
+	} else if len(m.Fields) == 0 {
+		return ErrJSONWriteMappingFieldsRequired
+	}
+
+	switch m.TimestampFormat {
+	case "", "unix", "unix_ms", "unix_us", "unix_ns":
+		return nil
+	}
+	// A time layout must at least hold the date of the timestamps.
+	ref := time.Date(2001, time.February, 3, 4, 5, 6, 0, time.UTC)
+	t, err := time.Parse(m.TimestampFormat, ref.Format(m.TimestampFormat))
+	if err != nil || t.Year() != ref.Year() || t.Month() != ref.Month() || t.Day() != ref.Day() {
+		return ErrInvalidJSONWriteMappingTimestampFormat(m.TimestampFormat)
+	}
+	return nil
+}
+
+// clone returns a deep copy of m.
+func (m JSONWriteMappingInfo) clone() JSONWriteMappingInfo {
+	other := m
+	if m.Tags != nil {
+		other.Tags = make([]string, len(m.Tags))
+		copy(other.Tags, m.Tags)
+	}
+	if m.Fields != nil {
+		other.Fields = make([]string, len(m.Fields))
+		copy(other.Fields, m.Fields)
+	}
+	return other
+}
+
+// marshal serializes to a protobuf representation.
+func (m JSONWriteMappingInfo) marshal() *internal.JSONWriteMappingInfo {
+	return &internal.JSONWriteMappingInfo{
+		Measurement:        proto.String(m.Measurement),
+		DefaultMeasurement: proto.String(m.DefaultMeasurement),
+		Tags:               m.Tags,
+		Fields:             m.Fields,
+		Timestamp:          proto.String(m.Timestamp),
+		TimestampFormat:    proto.String(m.TimestampFormat),
+	}
+}
+
+// unmarshal deserializes from a protobuf representation.
+func (m *JSONWriteMappingInfo) unmarshal(pb *internal.JSONWriteMappingInfo) {
+	m.Measurement = pb.GetMeasurement()
+	m.DefaultMeasurement = pb.GetDefaultMeasurement()
+	m.Tags = pb.GetTags()
+	m.Fields = pb.GetFields()
+	m.Timestamp = pb.GetTimestamp()
+	m.TimestampFormat = pb.GetTimestampFormat()
+}
+
+// MeasurementSchemaInfo is the write schema of a measurement. Points written
+// to the measurement may only use the declared tag keys and fields.
+type MeasurementSchemaInfo struct {
+	Name   string
+	Tags   []string
+	Fields []MeasurementSchemaFieldInfo
+}
+
+// MeasurementSchemaFieldInfo declares a field of a measurement schema.
+type MeasurementSchemaFieldInfo struct {
+	Name     string
+	Type     cnosql.DataType
+	Required bool
+}
+
+// validate returns an error if the schema is incomplete or declares a
+// key more than once.
+func (s *MeasurementSchemaInfo) validate() error {
+	if s == nil || s.Name == "" {
+		return ErrMeasurementSchemaNameRequired
+	} else if len(s.Fields) == 0 {
+		return ErrMeasurementSchemaFieldsRequired
+	}
+
+	keys := make(map[string]struct{}, len(s.Tags)+len(s.Fields))
+	add := func(key string) error {
+		if key == "" || key == "time" {
+			return ErrInvalidMeasurementSchemaKey(key)
+		} else if _, ok := keys[key]; ok {
+			return ErrDuplicateMeasurementSchemaKey(key)
+		}
+		keys[key] = struct{}{}
+		return nil
+	}
+
+	for _, tag := range s.Tags {
+		if err := add(tag); err != nil {
+			return err
+		}
+	}
+	for _, f := range s.Fields {
+		if err := add(f.Name); err != nil {
+			return err
+		}
+		switch f.Type {
+		case cnosql.Float, cnosql.Integer, cnosql.Unsigned, cnosql.String, cnosql.Boolean:
+		default:
+			return ErrInvalidMeasurementSchemaFieldType(f.Name, f.Type)
+		}
+	}
+	return nil
+}
+
+// equal returns true if s and other declare the same schema.
+func (s *MeasurementSchemaInfo) equal(other *MeasurementSchemaInfo) bool {
+	if s.Name != other.Name || len(s.Tags) != len(other.Tags) || len(s.Fields) != len(other.Fields) {
+		return false
+	}
+	for i := range s.Tags {
+		if s.Tags[i] != other.Tags[i] {
+			return false
+		}
+	}
+	for i := range s.Fields {
+		if s.Fields[i] != other.Fields[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// clone returns a deep copy of s.
+func (s MeasurementSchemaInfo) clone() MeasurementSchemaInfo {
+	other := s
+	if s.Tags != nil {
+		other.Tags = make([]string, len(s.Tags))
+		copy(other.Tags, s.Tags)
+	}
+	if s.Fields != nil {
+		other.Fields = make([]MeasurementSchemaFieldInfo, len(s.Fields))
+		copy(other.Fields, s.Fields)
+	}
+	return other
+}
+
+// marshal serializes to a protobuf representation.
+func (s MeasurementSchemaInfo) marshal() *internal.MeasurementSchemaInfo {
+	pb := &internal.MeasurementSchemaInfo{
+		Name: proto.String(s.Name),
+		Tags: s.Tags,
+	}
+
+	pb.Fields = make([]*internal.MeasurementSchemaFieldInfo, len(s.Fields))
+	for i, f := range s.Fields {
+		pb.Fields[i] = &internal.MeasurementSchemaFieldInfo{
+			Name:     proto.String(f.Name),
+			Type:     proto.Int32(int32(f.Type)),
+			Required: proto.Bool(f.Required),
+		}
+	}
+	return pb
+}
+
+// unmarshal deserializes from a protobuf representation.
+func (s *MeasurementSchemaInfo) unmarshal(pb *internal.MeasurementSchemaInfo) {
+	s.Name = pb.GetName()
+	s.Tags = pb.GetTags()
+
+	if len(pb.GetFields()) > 0 {
+		s.Fields = make([]MeasurementSchemaFieldInfo, len(pb.GetFields()))
+		for i, f := range pb.GetFields() {
+			s.Fields[i] = MeasurementSchemaFieldInfo{
+				Name:     f.GetName(),
+				Type:     cnosql.DataType(f.GetType()),
+				Required: f.GetRequired(),
+			}
+		}
+	}
+}
+
 var _ query.FineAuthorizer = (*UserInfo)(nil)
 
 // UserInfo represents metadata about a user in the system.
@@ -219,6 +219,126 @@ func TestData_SetPrivilege(t *testing.T) {
 	}
 }
 
+func TestData_SetJSONWriteMapping(t *testing.T) {
+	data := meta.Data{}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &meta.JSONWriteMappingInfo{Measurement: "type", Tags: []string{"device.id"}, Fields: []string{"readings"}}
+
+	// When the database does not exist, SetJSONWriteMapping returns an error.
+	if got, exp := data.SetJSONWriteMapping("db1", m), cnosdb.ErrDatabaseNotFound("db1"); got == nil || got.Error() != exp.Error() {
+		t.Fatalf("got %v, expected %v", got, exp)
+	}
+
+	// A mapping requires fields.
+	if got, exp := data.SetJSONWriteMapping("db0", &meta.JSONWriteMappingInfo{Measurement: "type"}), meta.ErrJSONWriteMappingFieldsRequired; got != exp {
+		t.Fatalf("got %v, expected %v", got, exp)
+	}
+
+	// A mapping requires a known timestamp format or a time layout with a date.
+	for _, format := range []string{"iso8601", "RFC3339", "15:04:05"} {
+		bad := &meta.JSONWriteMappingInfo{Measurement: "type", Fields: []string{"readings"}, TimestampFormat: format}
+		if got, exp := data.SetJSONWriteMapping("db0", bad), meta.ErrInvalidJSONWriteMappingTimestampFormat(format); got == nil || got.Error() != exp.Error() {
+			t.Fatalf("got %v, expected %v", got, exp)
+		}
+	}
+	for _, format := range []string{"unix_ms", "2006-01-02 15:04:05", "02/01/2006"} {
+		ok := &meta.JSONWriteMappingInfo{Measurement: "type", Fields: []string{"readings"}, TimestampFormat: format}
+		if err := data.SetJSONWriteMapping("db0", ok); err != nil {
+			t.Fatalf("unexpected error for %q: %s", format, err)
+		}
+	}
+
+	if err := data.SetJSONWriteMapping("db0", m); err != nil {
+		t.Fatal(err)
+	}
+
+	// The mapping survives a round trip through the binary representation.
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var other meta.Data
+	if err := other.UnmarshalBinary(buf); err != nil {
+		t.Fatal(err)
+	} else if got := other.Database("db0").JSONWriteMapping; !reflect.DeepEqual(got, m) {
+		t.Fatalf("got %v, expected %v", got, m)
+	}
+
+	if err := data.DropJSONWriteMapping("db0"); err != nil {
+		t.Fatal(err)
+	} else if got := data.Database("db0").JSONWriteMapping; got != nil {
+		t.Fatalf("got %v, expected nil", got)
+	}
+}
+
+func TestData_CreateMeasurementSchema(t *testing.T) {
+	data := meta.Data{}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &meta.MeasurementSchemaInfo{
+		Name: "cpu",
+		Tags: []string{"host", "region"},
+		Fields: []meta.MeasurementSchemaFieldInfo{
+			{Name: "usage", Type: cnosql.Float, Required: true},
+			{Name: "cores", Type: cnosql.Integer},
+		},
+	}
+
+	// When the database does not exist, CreateMeasurementSchema returns an error.
+	if got, exp := data.CreateMeasurementSchema("db1", s), cnosdb.ErrDatabaseNotFound("db1"); got == nil || got.Error() != exp.Error() {
+		t.Fatalf("got %v, expected %v", got, exp)
+	}
+
+	// A schema requires fields with writable types and unique keys.
+	if got, exp := data.CreateMeasurementSchema("db0", &meta.MeasurementSchemaInfo{Name: "cpu"}), meta.ErrMeasurementSchemaFieldsRequired; got != exp {
+		t.Fatalf("got %v, expected %v", got, exp)
+	}
+	invalid := &meta.MeasurementSchemaInfo{Name: "cpu", Fields: []meta.MeasurementSchemaFieldInfo{{Name: "at", Type: cnosql.Time}}}
+	if got, exp := data.CreateMeasurementSchema("db0", invalid), meta.ErrInvalidMeasurementSchemaFieldType("at", cnosql.Time); got == nil || got.Error() != exp.Error() {
+		t.Fatalf("got %v, expected %v", got, exp)
+	}
+	duplicate := &meta.MeasurementSchemaInfo{Name: "cpu", Tags: []string{"host"}, Fields: []meta.MeasurementSchemaFieldInfo{{Name: "host", Type: cnosql.String}}}
+	if got, exp := data.CreateMeasurementSchema("db0", duplicate), meta.ErrDuplicateMeasurementSchemaKey("host"); got == nil || got.Error() != exp.Error() {
+		t.Fatalf("got %v, expected %v", got, exp)
+	}
+
+	if err := data.CreateMeasurementSchema("db0", s); err != nil {
+		t.Fatal(err)
+	}
+
+	// Re-declaring the same schema is a no-op, a different one is rejected.
+	if err := data.CreateMeasurementSchema("db0", s); err != nil {
+		t.Fatal(err)
+	}
+	other := &meta.MeasurementSchemaInfo{Name: "cpu", Fields: []meta.MeasurementSchemaFieldInfo{{Name: "usage", Type: cnosql.Float}}}
+	if got, exp := data.CreateMeasurementSchema("db0", other), meta.ErrMeasurementSchemaExists; got != exp {
+		t.Fatalf("got %v, expected %v", got, exp)
+	}
+
+	// The schema survives a round trip through the binary representation.
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var unmarshaled meta.Data
+	if err := unmarshaled.UnmarshalBinary(buf); err != nil {
+		t.Fatal(err)
+	} else if got := unmarshaled.Database("db0").MeasurementSchema("cpu"); !reflect.DeepEqual(got, s) {
+		t.Fatalf("got %v, expected %v", got, s)
+	}
+
+	if err := data.DropMeasurementSchema("db0", "cpu"); err != nil {
+		t.Fatal(err)
+	} else if got := data.Database("db0").MeasurementSchema("cpu"); got != nil {
+		t.Fatalf("got %v, expected nil", got)
+	}
+}
+
 func TestData_TruncateShardGroups(t *testing.T) {
 	data := &meta.Data{}
 
@@ -3,6 +3,8 @@ package meta
 import (
 	"errors"
 	"fmt"
+
+	"github.com/cnosdb/cnosdb/vend/cnosql"
 )
 
 var (
@@ -110,6 +112,54 @@ var (
 	ErrContinuousQueryNotFound = errors.New("continuous query not found")
 )
 
+var (
+	// ErrJSONWriteMappingMeasurementRequired is returned when setting a JSON write
+	// mapping without a measurement path or default measurement.
+	ErrJSONWriteMappingMeasurementRequired = errors.New("json write mapping requires a measurement or default measurement")
+
+	// ErrJSONWriteMappingFieldsRequired is returned when setting a JSON write
+	// mapping without any field paths.
+	ErrJSONWriteMappingFieldsRequired = errors.New("json write mapping requires at least one field")
+)
+
+// ErrInvalidJSONWriteMappingTimestampFormat is returned when setting a JSON
+// write mapping with an unknown timestamp format.
+func ErrInvalidJSONWriteMappingTimestampFormat(format string) error {
+	return fmt.Errorf("invalid json write mapping timestamp format: %q", format)
+}
+
+var (
+	// ErrMeasurementSchemaExists is returned when creating a measurement schema
+	// that differs from an existing one.
+	ErrMeasurementSchemaExists = errors.New("measurement schema already exists")
+
+	// ErrMeasurementSchemaNameRequired is returned when creating a measurement
+	// schema without a measurement name.
+	ErrMeasurementSchemaNameRequired = errors.New("measurement schema name required")
+
+	// ErrMeasurementSchemaFieldsRequired is returned when creating a measurement
+	// schema without any fields.
+	ErrMeasurementSchemaFieldsRequired = errors.New("measurement schema requires at least one field")
+)
+
+// ErrInvalidMeasurementSchemaKey is returned when a measurement schema
+// declares an invalid tag key or field name.
+func ErrInvalidMeasurementSchemaKey(key string) error {
+	return fmt.Errorf("invalid measurement schema key: %q", key)
+}
+
+// ErrDuplicateMeasurementSchemaKey is returned when a measurement schema
+// declares a tag key or field name more than once.
+func ErrDuplicateMeasurementSchemaKey(key string) error {
+	return fmt.Errorf("duplicate measurement schema key: %q", key)
+}
+
+// ErrInvalidMeasurementSchemaFieldType is returned when a measurement schema
+// declares a field with a type that cannot be written.
+func ErrInvalidMeasurementSchemaFieldType(name string, typ cnosql.DataType) error {
+	return fmt.Errorf("invalid type %s for field %q in measurement schema", typ, name)
+}
+
 var (
 	// ErrSubscriptionExists is returned when creating an already existing subscription.
 	ErrSubscriptionExists = errors.New("subscription already exists")
@@ -54,6 +54,10 @@ const (
 	Command_DropShardCommand                 Command_Type = 30
 	Command_UpdateShardOwnersCommand         Command_Type = 31
 	Command_TruncatedShardsCommand           Command_Type = 32
+	Command_SetJSONWriteMappingCommand       Command_Type = 33
+	Command_DropJSONWriteMappingCommand      Command_Type = 34
+	Command_CreateMeasurementSchemaCommand   Command_Type = 35
+	Command_DropMeasurementSchemaCommand     Command_Type = 36
 )
 
 var Command_Type_name = map[int32]string{
@@ -88,6 +92,10 @@ var Command_Type_name = map[int32]string{
 	30: "DropShardCommand",
 	31: "UpdateShardOwnersCommand",
 	32: "TruncatedShardsCommand",
+	33: "SetJSONWriteMappingCommand",
+	34: "DropJSONWriteMappingCommand",
+	35: "CreateMeasurementSchemaCommand",
+	36: "DropMeasurementSchemaCommand",
 }
 
 var Command_Type_value = map[string]int32{
@@ -122,6 +130,10 @@ var Command_Type_value = map[string]int32{
 	"DropShardCommand":                 30,
 	"UpdateShardOwnersCommand":         31,
 	"TruncatedShardsCommand":           32,
+	"SetJSONWriteMappingCommand":       33,
+	"DropJSONWriteMappingCommand":      34,
+	"CreateMeasurementSchemaCommand":   35,
+	"DropMeasurementSchemaCommand":     36,
 }
 
 func (x Command_Type) Enum() *Command_Type {
@@ -144,7 +156,7 @@ func (x *Command_Type) UnmarshalJSON(data []byte) error {
 }
 
 func (Command_Type) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{12, 0}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{15, 0}
 }
 
 type Data struct {
@@ -321,13 +333,15 @@ func (m *NodeInfo) GetTCPHost() string {
 }
 
 type DatabaseInfo struct {
-	Name                   *string                `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
-	DefaultRetentionPolicy *string                `protobuf:"bytes,2,req,name=DefaultRetentionPolicy" json:"DefaultRetentionPolicy,omitempty"`
-	RetentionPolicies      []*RetentionPolicyInfo `protobuf:"bytes,3,rep,name=RetentionPolicies" json:"RetentionPolicies,omitempty"`
-	ContinuousQueries      []*ContinuousQueryInfo `protobuf:"bytes,4,rep,name=ContinuousQueries" json:"ContinuousQueries,omitempty"`
-	XXX_NoUnkeyedLiteral   struct{}               `json:"-"`
-	XXX_unrecognized       []byte                 `json:"-"`
-	XXX_sizecache          int32                  `json:"-"`
+	Name                   *string                  `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
+	DefaultRetentionPolicy *string                  `protobuf:"bytes,2,req,name=DefaultRetentionPolicy" json:"DefaultRetentionPolicy,omitempty"`
+	RetentionPolicies      []*RetentionPolicyInfo   `protobuf:"bytes,3,rep,name=RetentionPolicies" json:"RetentionPolicies,omitempty"`
+	ContinuousQueries      []*ContinuousQueryInfo   `protobuf:"bytes,4,rep,name=ContinuousQueries" json:"ContinuousQueries,omitempty"`
+	JSONWriteMapping       *JSONWriteMappingInfo    `protobuf:"bytes,5,opt,name=JSONWriteMapping" json:"JSONWriteMapping,omitempty"`
+	MeasurementSchemas     []*MeasurementSchemaInfo `protobuf:"bytes,6,rep,name=MeasurementSchemas" json:"MeasurementSchemas,omitempty"`
+	XXX_NoUnkeyedLiteral   struct{}                 `json:"-"`
+	XXX_unrecognized       []byte                   `json:"-"`
+	XXX_sizecache          int32                    `json:"-"`
 }
 
 func (m *DatabaseInfo) Reset()         { *m = DatabaseInfo{} }
@@ -382,6 +396,206 @@ func (m *DatabaseInfo) GetContinuousQueries() []*ContinuousQueryInfo {
 	return nil
 }
 
+func (m *DatabaseInfo) GetJSONWriteMapping() *JSONWriteMappingInfo {
+	if m != nil {
+		return m.JSONWriteMapping
+	}
+	return nil
+}
+
+func (m *DatabaseInfo) GetMeasurementSchemas() []*MeasurementSchemaInfo {
+	if m != nil {
+		return m.MeasurementSchemas
+	}
+	return nil
+}
+
+type JSONWriteMappingInfo struct {
+	Measurement          *string  `protobuf:"bytes,1,opt,name=Measurement" json:"Measurement,omitempty"`
+	DefaultMeasurement   *string  `protobuf:"bytes,2,opt,name=DefaultMeasurement" json:"DefaultMeasurement,omitempty"`
+	Tags                 []string `protobuf:"bytes,3,rep,name=Tags" json:"Tags,omitempty"`
+	Fields               []string `protobuf:"bytes,4,rep,name=Fields" json:"Fields,omitempty"`
+	Timestamp            *string  `protobuf:"bytes,5,opt,name=Timestamp" json:"Timestamp,omitempty"`
+	TimestampFormat      *string  `protobuf:"bytes,6,opt,name=TimestampFormat" json:"TimestampFormat,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *JSONWriteMappingInfo) Reset()         { *m = JSONWriteMappingInfo{} }
+func (m *JSONWriteMappingInfo) String() string { return proto.CompactTextString(m) }
+func (*JSONWriteMappingInfo) ProtoMessage()    {}
+func (*JSONWriteMappingInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3b5ea8fe65782bcc, []int{3}
+}
+func (m *JSONWriteMappingInfo) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_JSONWriteMappingInfo.Unmarshal(m, b)
+}
+func (m *JSONWriteMappingInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_JSONWriteMappingInfo.Marshal(b, m, deterministic)
+}
+func (m *JSONWriteMappingInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_JSONWriteMappingInfo.Merge(m, src)
+}
+func (m *JSONWriteMappingInfo) XXX_Size() int {
+	return xxx_messageInfo_JSONWriteMappingInfo.Size(m)
+}
+func (m *JSONWriteMappingInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_JSONWriteMappingInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_JSONWriteMappingInfo proto.InternalMessageInfo
+
+func (m *JSONWriteMappingInfo) GetMeasurement() string {
+	if m != nil && m.Measurement != nil {
+		return *m.Measurement
+	}
+	return ""
+}
+
+func (m *JSONWriteMappingInfo) GetDefaultMeasurement() string {
+	if m != nil && m.DefaultMeasurement != nil {
+		return *m.DefaultMeasurement
+	}
+	return ""
+}
+
+func (m *JSONWriteMappingInfo) GetTags() []string {
+	if m != nil {
+		return m.Tags
+	}
+	return nil
+}
+
+func (m *JSONWriteMappingInfo) GetFields() []string {
+	if m != nil {
+		return m.Fields
+	}
+	return nil
+}
+
+func (m *JSONWriteMappingInfo) GetTimestamp() string {
+	if m != nil && m.Timestamp != nil {
+		return *m.Timestamp
+	}
+	return ""
+}
+
+func (m *JSONWriteMappingInfo) GetTimestampFormat() string {
+	if m != nil && m.TimestampFormat != nil {
+		return *m.TimestampFormat
+	}
+	return ""
+}
+
+type MeasurementSchemaInfo struct {
+	Name                 *string                       `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
+	Tags                 []string                      `protobuf:"bytes,2,rep,name=Tags" json:"Tags,omitempty"`
+	Fields               []*MeasurementSchemaFieldInfo `protobuf:"bytes,3,rep,name=Fields" json:"Fields,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                      `json:"-"`
+	XXX_unrecognized     []byte                        `json:"-"`
+	XXX_sizecache        int32                         `json:"-"`
+}
+
+func (m *MeasurementSchemaInfo) Reset()         { *m = MeasurementSchemaInfo{} }
+func (m *MeasurementSchemaInfo) String() string { return proto.CompactTextString(m) }
+func (*MeasurementSchemaInfo) ProtoMessage()    {}
+func (*MeasurementSchemaInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3b5ea8fe65782bcc, []int{4}
+}
+func (m *MeasurementSchemaInfo) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MeasurementSchemaInfo.Unmarshal(m, b)
+}
+func (m *MeasurementSchemaInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MeasurementSchemaInfo.Marshal(b, m, deterministic)
+}
+func (m *MeasurementSchemaInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MeasurementSchemaInfo.Merge(m, src)
+}
+func (m *MeasurementSchemaInfo) XXX_Size() int {
+	return xxx_messageInfo_MeasurementSchemaInfo.Size(m)
+}
+func (m *MeasurementSchemaInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_MeasurementSchemaInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MeasurementSchemaInfo proto.InternalMessageInfo
+
+func (m *MeasurementSchemaInfo) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *MeasurementSchemaInfo) GetTags() []string {
+	if m != nil {
+		return m.Tags
+	}
+	return nil
+}
+
+func (m *MeasurementSchemaInfo) GetFields() []*MeasurementSchemaFieldInfo {
+	if m != nil {
+		return m.Fields
+	}
+	return nil
+}
+
+type MeasurementSchemaFieldInfo struct {
+	Name                 *string  `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
+	Type                 *int32   `protobuf:"varint,2,req,name=Type" json:"Type,omitempty"`
+	Required             *bool    `protobuf:"varint,3,opt,name=Required" json:"Required,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MeasurementSchemaFieldInfo) Reset()         { *m = MeasurementSchemaFieldInfo{} }
+func (m *MeasurementSchemaFieldInfo) String() string { return proto.CompactTextString(m) }
+func (*MeasurementSchemaFieldInfo) ProtoMessage()    {}
+func (*MeasurementSchemaFieldInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3b5ea8fe65782bcc, []int{5}
+}
+func (m *MeasurementSchemaFieldInfo) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MeasurementSchemaFieldInfo.Unmarshal(m, b)
+}
+func (m *MeasurementSchemaFieldInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MeasurementSchemaFieldInfo.Marshal(b, m, deterministic)
+}
+func (m *MeasurementSchemaFieldInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MeasurementSchemaFieldInfo.Merge(m, src)
+}
+func (m *MeasurementSchemaFieldInfo) XXX_Size() int {
+	return xxx_messageInfo_MeasurementSchemaFieldInfo.Size(m)
+}
+func (m *MeasurementSchemaFieldInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_MeasurementSchemaFieldInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MeasurementSchemaFieldInfo proto.InternalMessageInfo
+
+func (m *MeasurementSchemaFieldInfo) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *MeasurementSchemaFieldInfo) GetType() int32 {
+	if m != nil && m.Type != nil {
+		return *m.Type
+	}
+	return 0
+}
+
+func (m *MeasurementSchemaFieldInfo) GetRequired() bool {
+	if m != nil && m.Required != nil {
+		return *m.Required
+	}
+	return false
+}
+
 type RetentionPolicySpec struct {
 	Name                 *string  `protobuf:"bytes,1,opt,name=Name" json:"Name,omitempty"`
 	Duration             *int64   `protobuf:"varint,2,opt,name=Duration" json:"Duration,omitempty"`
@@ -396,7 +610,7 @@ func (m *RetentionPolicySpec) Reset()         { *m = RetentionPolicySpec{} }
 func (m *RetentionPolicySpec) String() string { return proto.CompactTextString(m) }
 func (*RetentionPolicySpec) ProtoMessage()    {}
 func (*RetentionPolicySpec) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{3}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{6}
 }
 func (m *RetentionPolicySpec) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_RetentionPolicySpec.Unmarshal(m, b)
@@ -460,7 +674,7 @@ func (m *RetentionPolicyInfo) Reset()         { *m = RetentionPolicyInfo{} }
 func (m *RetentionPolicyInfo) String() string { return proto.CompactTextString(m) }
 func (*RetentionPolicyInfo) ProtoMessage()    {}
 func (*RetentionPolicyInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{4}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{7}
 }
 func (m *RetentionPolicyInfo) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_RetentionPolicyInfo.Unmarshal(m, b)
@@ -538,7 +752,7 @@ func (m *ShardGroupInfo) Reset()         { *m = ShardGroupInfo{} }
 func (m *ShardGroupInfo) String() string { return proto.CompactTextString(m) }
 func (*ShardGroupInfo) ProtoMessage()    {}
 func (*ShardGroupInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{5}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{8}
 }
 func (m *ShardGroupInfo) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_ShardGroupInfo.Unmarshal(m, b)
@@ -613,7 +827,7 @@ func (m *ShardInfo) Reset()         { *m = ShardInfo{} }
 func (m *ShardInfo) String() string { return proto.CompactTextString(m) }
 func (*ShardInfo) ProtoMessage()    {}
 func (*ShardInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{6}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{9}
 }
 func (m *ShardInfo) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_ShardInfo.Unmarshal(m, b)
@@ -668,7 +882,7 @@ func (m *SubscriptionInfo) Reset()         { *m = SubscriptionInfo{} }
 func (m *SubscriptionInfo) String() string { return proto.CompactTextString(m) }
 func (*SubscriptionInfo) ProtoMessage()    {}
 func (*SubscriptionInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{7}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{10}
 }
 func (m *SubscriptionInfo) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_SubscriptionInfo.Unmarshal(m, b)
@@ -720,7 +934,7 @@ func (m *ShardOwner) Reset()         { *m = ShardOwner{} }
 func (m *ShardOwner) String() string { return proto.CompactTextString(m) }
 func (*ShardOwner) ProtoMessage()    {}
 func (*ShardOwner) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{8}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{11}
 }
 func (m *ShardOwner) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_ShardOwner.Unmarshal(m, b)
@@ -759,7 +973,7 @@ func (m *ContinuousQueryInfo) Reset()         { *m = ContinuousQueryInfo{} }
 func (m *ContinuousQueryInfo) String() string { return proto.CompactTextString(m) }
 func (*ContinuousQueryInfo) ProtoMessage()    {}
 func (*ContinuousQueryInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{9}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{12}
 }
 func (m *ContinuousQueryInfo) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_ContinuousQueryInfo.Unmarshal(m, b)
@@ -807,7 +1021,7 @@ func (m *UserInfo) Reset()         { *m = UserInfo{} }
 func (m *UserInfo) String() string { return proto.CompactTextString(m) }
 func (*UserInfo) ProtoMessage()    {}
 func (*UserInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{10}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{13}
 }
 func (m *UserInfo) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_UserInfo.Unmarshal(m, b)
@@ -867,7 +1081,7 @@ func (m *UserPrivilege) Reset()         { *m = UserPrivilege{} }
 func (m *UserPrivilege) String() string { return proto.CompactTextString(m) }
 func (*UserPrivilege) ProtoMessage()    {}
 func (*UserPrivilege) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{11}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{14}
 }
 func (m *UserPrivilege) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_UserPrivilege.Unmarshal(m, b)
@@ -913,7 +1127,7 @@ func (m *Command) Reset()         { *m = Command{} }
 func (m *Command) String() string { return proto.CompactTextString(m) }
 func (*Command) ProtoMessage()    {}
 func (*Command) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{12}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{15}
 }
 
 var extRange_Command = []proto.ExtensionRange{
@@ -963,7 +1177,7 @@ func (m *CreateNodeCommand) Reset()         { *m = CreateNodeCommand{} }
 func (m *CreateNodeCommand) String() string { return proto.CompactTextString(m) }
 func (*CreateNodeCommand) ProtoMessage()    {}
 func (*CreateNodeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{13}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{16}
 }
 func (m *CreateNodeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CreateNodeCommand.Unmarshal(m, b)
@@ -1018,7 +1232,7 @@ func (m *DeleteNodeCommand) Reset()         { *m = DeleteNodeCommand{} }
 func (m *DeleteNodeCommand) String() string { return proto.CompactTextString(m) }
 func (*DeleteNodeCommand) ProtoMessage()    {}
 func (*DeleteNodeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{14}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{17}
 }
 func (m *DeleteNodeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DeleteNodeCommand.Unmarshal(m, b)
@@ -1073,7 +1287,7 @@ func (m *CreateDatabaseCommand) Reset()         { *m = CreateDatabaseCommand{} }
 func (m *CreateDatabaseCommand) String() string { return proto.CompactTextString(m) }
 func (*CreateDatabaseCommand) ProtoMessage()    {}
 func (*CreateDatabaseCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{15}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{18}
 }
 func (m *CreateDatabaseCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CreateDatabaseCommand.Unmarshal(m, b)
@@ -1127,7 +1341,7 @@ func (m *DropDatabaseCommand) Reset()         { *m = DropDatabaseCommand{} }
 func (m *DropDatabaseCommand) String() string { return proto.CompactTextString(m) }
 func (*DropDatabaseCommand) ProtoMessage()    {}
 func (*DropDatabaseCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{16}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{19}
 }
 func (m *DropDatabaseCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DropDatabaseCommand.Unmarshal(m, b)
@@ -1176,7 +1390,7 @@ func (m *CreateRetentionPolicyCommand) Reset()         { *m = CreateRetentionPol
 func (m *CreateRetentionPolicyCommand) String() string { return proto.CompactTextString(m) }
 func (*CreateRetentionPolicyCommand) ProtoMessage()    {}
 func (*CreateRetentionPolicyCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{17}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{20}
 }
 func (m *CreateRetentionPolicyCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CreateRetentionPolicyCommand.Unmarshal(m, b)
@@ -1238,7 +1452,7 @@ func (m *DropRetentionPolicyCommand) Reset()         { *m = DropRetentionPolicyC
 func (m *DropRetentionPolicyCommand) String() string { return proto.CompactTextString(m) }
 func (*DropRetentionPolicyCommand) ProtoMessage()    {}
 func (*DropRetentionPolicyCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{18}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{21}
 }
 func (m *DropRetentionPolicyCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DropRetentionPolicyCommand.Unmarshal(m, b)
@@ -1293,7 +1507,7 @@ func (m *SetDefaultRetentionPolicyCommand) Reset()         { *m = SetDefaultRete
 func (m *SetDefaultRetentionPolicyCommand) String() string { return proto.CompactTextString(m) }
 func (*SetDefaultRetentionPolicyCommand) ProtoMessage()    {}
 func (*SetDefaultRetentionPolicyCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{19}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{22}
 }
 func (m *SetDefaultRetentionPolicyCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_SetDefaultRetentionPolicyCommand.Unmarshal(m, b)
@@ -1352,7 +1566,7 @@ func (m *UpdateRetentionPolicyCommand) Reset()         { *m = UpdateRetentionPol
 func (m *UpdateRetentionPolicyCommand) String() string { return proto.CompactTextString(m) }
 func (*UpdateRetentionPolicyCommand) ProtoMessage()    {}
 func (*UpdateRetentionPolicyCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{20}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{23}
 }
 func (m *UpdateRetentionPolicyCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_UpdateRetentionPolicyCommand.Unmarshal(m, b)
@@ -1436,7 +1650,7 @@ func (m *CreateShardGroupCommand) Reset()         { *m = CreateShardGroupCommand
 func (m *CreateShardGroupCommand) String() string { return proto.CompactTextString(m) }
 func (*CreateShardGroupCommand) ProtoMessage()    {}
 func (*CreateShardGroupCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{21}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{24}
 }
 func (m *CreateShardGroupCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CreateShardGroupCommand.Unmarshal(m, b)
@@ -1499,7 +1713,7 @@ func (m *DeleteShardGroupCommand) Reset()         { *m = DeleteShardGroupCommand
 func (m *DeleteShardGroupCommand) String() string { return proto.CompactTextString(m) }
 func (*DeleteShardGroupCommand) ProtoMessage()    {}
 func (*DeleteShardGroupCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{22}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{25}
 }
 func (m *DeleteShardGroupCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DeleteShardGroupCommand.Unmarshal(m, b)
@@ -1562,7 +1776,7 @@ func (m *CreateContinuousQueryCommand) Reset()         { *m = CreateContinuousQu
 func (m *CreateContinuousQueryCommand) String() string { return proto.CompactTextString(m) }
 func (*CreateContinuousQueryCommand) ProtoMessage()    {}
 func (*CreateContinuousQueryCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{23}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{26}
 }
 func (m *CreateContinuousQueryCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CreateContinuousQueryCommand.Unmarshal(m, b)
@@ -1624,7 +1838,7 @@ func (m *DropContinuousQueryCommand) Reset()         { *m = DropContinuousQueryC
 func (m *DropContinuousQueryCommand) String() string { return proto.CompactTextString(m) }
 func (*DropContinuousQueryCommand) ProtoMessage()    {}
 func (*DropContinuousQueryCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{24}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{27}
 }
 func (m *DropContinuousQueryCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DropContinuousQueryCommand.Unmarshal(m, b)
@@ -1680,7 +1894,7 @@ func (m *CreateUserCommand) Reset()         { *m = CreateUserCommand{} }
 func (m *CreateUserCommand) String() string { return proto.CompactTextString(m) }
 func (*CreateUserCommand) ProtoMessage()    {}
 func (*CreateUserCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{25}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{28}
 }
 func (m *CreateUserCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CreateUserCommand.Unmarshal(m, b)
@@ -1741,7 +1955,7 @@ func (m *DropUserCommand) Reset()         { *m = DropUserCommand{} }
 func (m *DropUserCommand) String() string { return proto.CompactTextString(m) }
 func (*DropUserCommand) ProtoMessage()    {}
 func (*DropUserCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{26}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{29}
 }
 func (m *DropUserCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DropUserCommand.Unmarshal(m, b)
@@ -1789,7 +2003,7 @@ func (m *UpdateUserCommand) Reset()         { *m = UpdateUserCommand{} }
 func (m *UpdateUserCommand) String() string { return proto.CompactTextString(m) }
 func (*UpdateUserCommand) ProtoMessage()    {}
 func (*UpdateUserCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{27}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{30}
 }
 func (m *UpdateUserCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_UpdateUserCommand.Unmarshal(m, b)
@@ -1845,7 +2059,7 @@ func (m *SetPrivilegeCommand) Reset()         { *m = SetPrivilegeCommand{} }
 func (m *SetPrivilegeCommand) String() string { return proto.CompactTextString(m) }
 func (*SetPrivilegeCommand) ProtoMessage()    {}
 func (*SetPrivilegeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{28}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{31}
 }
 func (m *SetPrivilegeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_SetPrivilegeCommand.Unmarshal(m, b)
@@ -1906,7 +2120,7 @@ func (m *SetDataCommand) Reset()         { *m = SetDataCommand{} }
 func (m *SetDataCommand) String() string { return proto.CompactTextString(m) }
 func (*SetDataCommand) ProtoMessage()    {}
 func (*SetDataCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{29}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{32}
 }
 func (m *SetDataCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_SetDataCommand.Unmarshal(m, b)
@@ -1954,7 +2168,7 @@ func (m *SetAdminPrivilegeCommand) Reset()         { *m = SetAdminPrivilegeComma
 func (m *SetAdminPrivilegeCommand) String() string { return proto.CompactTextString(m) }
 func (*SetAdminPrivilegeCommand) ProtoMessage()    {}
 func (*SetAdminPrivilegeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{30}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{33}
 }
 func (m *SetAdminPrivilegeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_SetAdminPrivilegeCommand.Unmarshal(m, b)
@@ -2009,7 +2223,7 @@ func (m *UpdateNodeCommand) Reset()         { *m = UpdateNodeCommand{} }
 func (m *UpdateNodeCommand) String() string { return proto.CompactTextString(m) }
 func (*UpdateNodeCommand) ProtoMessage()    {}
 func (*UpdateNodeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{31}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{34}
 }
 func (m *UpdateNodeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_UpdateNodeCommand.Unmarshal(m, b)
@@ -2067,7 +2281,7 @@ func (m *CreateSubscriptionCommand) Reset()         { *m = CreateSubscriptionCom
 func (m *CreateSubscriptionCommand) String() string { return proto.CompactTextString(m) }
 func (*CreateSubscriptionCommand) ProtoMessage()    {}
 func (*CreateSubscriptionCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{32}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{35}
 }
 func (m *CreateSubscriptionCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CreateSubscriptionCommand.Unmarshal(m, b)
@@ -2144,7 +2358,7 @@ func (m *DropSubscriptionCommand) Reset()         { *m = DropSubscriptionCommand
 func (m *DropSubscriptionCommand) String() string { return proto.CompactTextString(m) }
 func (*DropSubscriptionCommand) ProtoMessage()    {}
 func (*DropSubscriptionCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{33}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{36}
 }
 func (m *DropSubscriptionCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DropSubscriptionCommand.Unmarshal(m, b)
@@ -2206,7 +2420,7 @@ func (m *RemovePeerCommand) Reset()         { *m = RemovePeerCommand{} }
 func (m *RemovePeerCommand) String() string { return proto.CompactTextString(m) }
 func (*RemovePeerCommand) ProtoMessage()    {}
 func (*RemovePeerCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{34}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{37}
 }
 func (m *RemovePeerCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_RemovePeerCommand.Unmarshal(m, b)
@@ -2262,7 +2476,7 @@ func (m *CreateMetaNodeCommand) Reset()         { *m = CreateMetaNodeCommand{} }
 func (m *CreateMetaNodeCommand) String() string { return proto.CompactTextString(m) }
 func (*CreateMetaNodeCommand) ProtoMessage()    {}
 func (*CreateMetaNodeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{35}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{38}
 }
 func (m *CreateMetaNodeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CreateMetaNodeCommand.Unmarshal(m, b)
@@ -2324,7 +2538,7 @@ func (m *CreateDataNodeCommand) Reset()         { *m = CreateDataNodeCommand{} }
 func (m *CreateDataNodeCommand) String() string { return proto.CompactTextString(m) }
 func (*CreateDataNodeCommand) ProtoMessage()    {}
 func (*CreateDataNodeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{36}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{39}
 }
 func (m *CreateDataNodeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CreateDataNodeCommand.Unmarshal(m, b)
@@ -2380,7 +2594,7 @@ func (m *UpdateDataNodeCommand) Reset()         { *m = UpdateDataNodeCommand{} }
 func (m *UpdateDataNodeCommand) String() string { return proto.CompactTextString(m) }
 func (*UpdateDataNodeCommand) ProtoMessage()    {}
 func (*UpdateDataNodeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{37}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{40}
 }
 func (m *UpdateDataNodeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_UpdateDataNodeCommand.Unmarshal(m, b)
@@ -2441,7 +2655,7 @@ func (m *DeleteMetaNodeCommand) Reset()         { *m = DeleteMetaNodeCommand{} }
 func (m *DeleteMetaNodeCommand) String() string { return proto.CompactTextString(m) }
 func (*DeleteMetaNodeCommand) ProtoMessage()    {}
 func (*DeleteMetaNodeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{38}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{41}
 }
 func (m *DeleteMetaNodeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DeleteMetaNodeCommand.Unmarshal(m, b)
@@ -2488,7 +2702,7 @@ func (m *DeleteDataNodeCommand) Reset()         { *m = DeleteDataNodeCommand{} }
 func (m *DeleteDataNodeCommand) String() string { return proto.CompactTextString(m) }
 func (*DeleteDataNodeCommand) ProtoMessage()    {}
 func (*DeleteDataNodeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{39}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{42}
 }
 func (m *DeleteDataNodeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DeleteDataNodeCommand.Unmarshal(m, b)
@@ -2537,7 +2751,7 @@ func (m *Response) Reset()         { *m = Response{} }
 func (m *Response) String() string { return proto.CompactTextString(m) }
 func (*Response) ProtoMessage()    {}
 func (*Response) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{40}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{43}
 }
 func (m *Response) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Response.Unmarshal(m, b)
@@ -2593,7 +2807,7 @@ func (m *SetMetaNodeCommand) Reset()         { *m = SetMetaNodeCommand{} }
 func (m *SetMetaNodeCommand) String() string { return proto.CompactTextString(m) }
 func (*SetMetaNodeCommand) ProtoMessage()    {}
 func (*SetMetaNodeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{41}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{44}
 }
 func (m *SetMetaNodeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_SetMetaNodeCommand.Unmarshal(m, b)
@@ -2654,7 +2868,7 @@ func (m *DropShardCommand) Reset()         { *m = DropShardCommand{} }
 func (m *DropShardCommand) String() string { return proto.CompactTextString(m) }
 func (*DropShardCommand) ProtoMessage()    {}
 func (*DropShardCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{42}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{45}
 }
 func (m *DropShardCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DropShardCommand.Unmarshal(m, b)
@@ -2703,7 +2917,7 @@ func (m *UpdateShardOwnersCommand) Reset()         { *m = UpdateShardOwnersComma
 func (m *UpdateShardOwnersCommand) String() string { return proto.CompactTextString(m) }
 func (*UpdateShardOwnersCommand) ProtoMessage()    {}
 func (*UpdateShardOwnersCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{43}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{46}
 }
 func (m *UpdateShardOwnersCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_UpdateShardOwnersCommand.Unmarshal(m, b)
@@ -2764,7 +2978,7 @@ func (m *TruncatedShardsCommand) Reset()         { *m = TruncatedShardsCommand{}
 func (m *TruncatedShardsCommand) String() string { return proto.CompactTextString(m) }
 func (*TruncatedShardsCommand) ProtoMessage()    {}
 func (*TruncatedShardsCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{44}
+	return fileDescriptor_3b5ea8fe65782bcc, []int{47}
 }
 func (m *TruncatedShardsCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_TruncatedShardsCommand.Unmarshal(m, b)
@@ -2800,11 +3014,226 @@ var E_TruncatedShardsCommand_Command = &proto.ExtensionDesc{
 	Filename:      "meta.proto",
 }
 
+type SetJSONWriteMappingCommand struct {
+	Database             *string               `protobuf:"bytes,1,req,name=Database" json:"Database,omitempty"`
+	Mapping              *JSONWriteMappingInfo `protobuf:"bytes,2,req,name=Mapping" json:"Mapping,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *SetJSONWriteMappingCommand) Reset()         { *m = SetJSONWriteMappingCommand{} }
+func (m *SetJSONWriteMappingCommand) String() string { return proto.CompactTextString(m) }
+func (*SetJSONWriteMappingCommand) ProtoMessage()    {}
+func (*SetJSONWriteMappingCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3b5ea8fe65782bcc, []int{48}
+}
+func (m *SetJSONWriteMappingCommand) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetJSONWriteMappingCommand.Unmarshal(m, b)
+}
+func (m *SetJSONWriteMappingCommand) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetJSONWriteMappingCommand.Marshal(b, m, deterministic)
+}
+func (m *SetJSONWriteMappingCommand) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetJSONWriteMappingCommand.Merge(m, src)
+}
+func (m *SetJSONWriteMappingCommand) XXX_Size() int {
+	return xxx_messageInfo_SetJSONWriteMappingCommand.Size(m)
+}
+func (m *SetJSONWriteMappingCommand) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetJSONWriteMappingCommand.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetJSONWriteMappingCommand proto.InternalMessageInfo
+
+func (m *SetJSONWriteMappingCommand) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *SetJSONWriteMappingCommand) GetMapping() *JSONWriteMappingInfo {
+	if m != nil {
+		return m.Mapping
+	}
+	return nil
+}
+
+var E_SetJSONWriteMappingCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*SetJSONWriteMappingCommand)(nil),
+	Field:         133,
+	Name:          "meta.SetJSONWriteMappingCommand.command",
+	Tag:           "bytes,133,opt,name=command",
+	Filename:      "meta.proto",
+}
+
+type DropJSONWriteMappingCommand struct {
+	Database             *string  `protobuf:"bytes,1,req,name=Database" json:"Database,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DropJSONWriteMappingCommand) Reset()         { *m = DropJSONWriteMappingCommand{} }
+func (m *DropJSONWriteMappingCommand) String() string { return proto.CompactTextString(m) }
+func (*DropJSONWriteMappingCommand) ProtoMessage()    {}
+func (*DropJSONWriteMappingCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3b5ea8fe65782bcc, []int{49}
+}
+func (m *DropJSONWriteMappingCommand) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DropJSONWriteMappingCommand.Unmarshal(m, b)
+}
+func (m *DropJSONWriteMappingCommand) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DropJSONWriteMappingCommand.Marshal(b, m, deterministic)
+}
+func (m *DropJSONWriteMappingCommand) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DropJSONWriteMappingCommand.Merge(m, src)
+}
+func (m *DropJSONWriteMappingCommand) XXX_Size() int {
+	return xxx_messageInfo_DropJSONWriteMappingCommand.Size(m)
+}
+func (m *DropJSONWriteMappingCommand) XXX_DiscardUnknown() {
+	xxx_messageInfo_DropJSONWriteMappingCommand.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DropJSONWriteMappingCommand proto.InternalMessageInfo
+
+func (m *DropJSONWriteMappingCommand) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+var E_DropJSONWriteMappingCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*DropJSONWriteMappingCommand)(nil),
+	Field:         134,
+	Name:          "meta.DropJSONWriteMappingCommand.command",
+	Tag:           "bytes,134,opt,name=command",
+	Filename:      "meta.proto",
+}
+
+type CreateMeasurementSchemaCommand struct {
+	Database             *string                `protobuf:"bytes,1,req,name=Database" json:"Database,omitempty"`
+	Schema               *MeasurementSchemaInfo `protobuf:"bytes,2,req,name=Schema" json:"Schema,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
+	XXX_unrecognized     []byte                 `json:"-"`
+	XXX_sizecache        int32                  `json:"-"`
+}
+
+func (m *CreateMeasurementSchemaCommand) Reset()         { *m = CreateMeasurementSchemaCommand{} }
+func (m *CreateMeasurementSchemaCommand) String() string { return proto.CompactTextString(m) }
+func (*CreateMeasurementSchemaCommand) ProtoMessage()    {}
+func (*CreateMeasurementSchemaCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3b5ea8fe65782bcc, []int{50}
+}
+func (m *CreateMeasurementSchemaCommand) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CreateMeasurementSchemaCommand.Unmarshal(m, b)
+}
+func (m *CreateMeasurementSchemaCommand) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CreateMeasurementSchemaCommand.Marshal(b, m, deterministic)
+}
+func (m *CreateMeasurementSchemaCommand) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CreateMeasurementSchemaCommand.Merge(m, src)
+}
+func (m *CreateMeasurementSchemaCommand) XXX_Size() int {
+	return xxx_messageInfo_CreateMeasurementSchemaCommand.Size(m)
+}
+func (m *CreateMeasurementSchemaCommand) XXX_DiscardUnknown() {
+	xxx_messageInfo_CreateMeasurementSchemaCommand.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CreateMeasurementSchemaCommand proto.InternalMessageInfo
+
+func (m *CreateMeasurementSchemaCommand) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *CreateMeasurementSchemaCommand) GetSchema() *MeasurementSchemaInfo {
+	if m != nil {
+		return m.Schema
+	}
+	return nil
+}
+
+var E_CreateMeasurementSchemaCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*CreateMeasurementSchemaCommand)(nil),
+	Field:         135,
+	Name:          "meta.CreateMeasurementSchemaCommand.command",
+	Tag:           "bytes,135,opt,name=command",
+	Filename:      "meta.proto",
+}
+
+type DropMeasurementSchemaCommand struct {
+	Database             *string  `protobuf:"bytes,1,req,name=Database" json:"Database,omitempty"`
+	Name                 *string  `protobuf:"bytes,2,req,name=Name" json:"Name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DropMeasurementSchemaCommand) Reset()         { *m = DropMeasurementSchemaCommand{} }
+func (m *DropMeasurementSchemaCommand) String() string { return proto.CompactTextString(m) }
+func (*DropMeasurementSchemaCommand) ProtoMessage()    {}
+func (*DropMeasurementSchemaCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3b5ea8fe65782bcc, []int{51}
+}
+func (m *DropMeasurementSchemaCommand) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DropMeasurementSchemaCommand.Unmarshal(m, b)
+}
+func (m *DropMeasurementSchemaCommand) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DropMeasurementSchemaCommand.Marshal(b, m, deterministic)
+}
+func (m *DropMeasurementSchemaCommand) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DropMeasurementSchemaCommand.Merge(m, src)
+}
+func (m *DropMeasurementSchemaCommand) XXX_Size() int {
+	return xxx_messageInfo_DropMeasurementSchemaCommand.Size(m)
+}
+func (m *DropMeasurementSchemaCommand) XXX_DiscardUnknown() {
+	xxx_messageInfo_DropMeasurementSchemaCommand.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DropMeasurementSchemaCommand proto.InternalMessageInfo
+
+func (m *DropMeasurementSchemaCommand) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *DropMeasurementSchemaCommand) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+var E_DropMeasurementSchemaCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*DropMeasurementSchemaCommand)(nil),
+	Field:         136,
+	Name:          "meta.DropMeasurementSchemaCommand.command",
+	Tag:           "bytes,136,opt,name=command",
+	Filename:      "meta.proto",
+}
+
 func init() {
 	proto.RegisterEnum("meta.Command_Type", Command_Type_name, Command_Type_value)
 	proto.RegisterType((*Data)(nil), "meta.Data")
 	proto.RegisterType((*NodeInfo)(nil), "meta.NodeInfo")
 	proto.RegisterType((*DatabaseInfo)(nil), "meta.DatabaseInfo")
+	proto.RegisterType((*JSONWriteMappingInfo)(nil), "meta.JSONWriteMappingInfo")
+	proto.RegisterType((*MeasurementSchemaInfo)(nil), "meta.MeasurementSchemaInfo")
+	proto.RegisterType((*MeasurementSchemaFieldInfo)(nil), "meta.MeasurementSchemaFieldInfo")
 	proto.RegisterType((*RetentionPolicySpec)(nil), "meta.RetentionPolicySpec")
 	proto.RegisterType((*RetentionPolicyInfo)(nil), "meta.RetentionPolicyInfo")
 	proto.RegisterType((*ShardGroupInfo)(nil), "meta.ShardGroupInfo")
@@ -2883,124 +3312,143 @@ func init() {
 func init() { proto.RegisterFile("meta.proto", fileDescriptor_3b5ea8fe65782bcc) }
 
 var fileDescriptor_3b5ea8fe65782bcc = []byte{
-	// 1890 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x59, 0x4b, 0x6f, 0x1c, 0xc7,
-	0x11, 0x46, 0xcf, 0x3e, 0xb8, 0x5b, 0x7c, 0xaa, 0xf9, 0x1a, 0x52, 0x14, 0xb5, 0x18, 0x08, 0xca,
-	0x22, 0x08, 0x88, 0x60, 0x03, 0xe8, 0x94, 0x17, 0xc5, 0x95, 0xc4, 0x85, 0xc0, 0x47, 0x66, 0xa9,
-	0x6b, 0x80, 0x11, 0xb7, 0x25, 0x6e, 0xc2, 0x9d, 0xd9, 0xcc, 0xcc, 0x4a, 0x62, 0x14, 0x2a, 0xcc,
-	0x03, 0xd0, 0x35, 0x81, 0x10, 0xe4, 0x90, 0x5b, 0x7c, 0xf0, 0xd1, 0xf6, 0xc5, 0x17, 0x9f, 0x7c,
-	0xf0, 0xc9, 0x67, 0xff, 0x05, 0x9f, 0x7c, 0xf7, 0xd5, 0xe8, 0xee, 0xe9, 0xe9, 0x9e, 0x99, 0xee,
-	0x21, 0x69, 0xcb, 0xb7, 0xe9, 0xaa, 0xea, 0xae, 0xaf, 0xaa, 0xab, 0xab, 0xab, 0x7a, 0x00, 0x46,
-	0x24, 0xf6, 0xb6, 0xc6, 0x61, 0x10, 0x07, 0xb8, 0x4a, 0xbf, 0x9d, 0x7f, 0x55, 0xa0, 0xda, 0xf5,
-	0x62, 0x0f, 0x63, 0xa8, 0x1e, 0x91, 0x70, 0x64, 0xa3, 0x96, 0xd5, 0xae, 0xba, 0xec, 0x1b, 0x2f,
-	0x41, 0xad, 0xe7, 0x0f, 0xc8, 0x2b, 0xdb, 0x62, 0x44, 0x3e, 0xc0, 0x1b, 0xd0, 0xdc, 0x39, 0x9d,
-	0x44, 0x31, 0x09, 0x7b, 0x5d, 0xbb, 0xc2, 0x38, 0x92, 0x80, 0xef, 0x40, 0x6d, 0x3f, 0x18, 0x90,
-	0xc8, 0xae, 0xb6, 0x2a, 0xed, 0xe9, 0xce, 0xdc, 0x16, 0x53, 0x49, 0x49, 0x3d, 0xff, 0x59, 0xe0,
-	0x72, 0x26, 0xfe, 0x39, 0x34, 0xa9, 0xd6, 0xa7, 0x5e, 0x44, 0x22, 0xbb, 0xc6, 0x24, 0x31, 0x97,
-	0x14, 0x64, 0x26, 0x2d, 0x85, 0xe8, 0xba, 0x4f, 0x22, 0x12, 0x46, 0x76, 0x5d, 0x5d, 0x97, 0x92,
-	0xf8, 0xba, 0x8c, 0x49, 0xb1, 0xed, 0x79, 0xaf, 0x98, 0xb6, 0xae, 0x3d, 0xc5, 0xb1, 0xa5, 0x04,
-	0xdc, 0x86, 0xf9, 0x3d, 0xef, 0x55, 0xff, 0xc4, 0x0b, 0x07, 0x8f, 0xc2, 0x60, 0x32, 0xee, 0x75,
-	0xed, 0x06, 0x93, 0xc9, 0x93, 0xf1, 0x26, 0x80, 0x20, 0xf5, 0xba, 0x76, 0x93, 0x09, 0x29, 0x14,
-	0xfc, 0x33, 0x8e, 0x9f, 0x5b, 0x0a, 0x5a, 0x4b, 0xa5, 0x00, 0x95, 0xde, 0x23, 0x42, 0x7a, 0x5a,
-	0x2f, 0x9d, 0x0a, 0x38, 0xbb, 0xd0, 0x10, 0x64, 0x3c, 0x07, 0x56, 0xaf, 0x9b, 0xec, 0x89, 0xd5,
-	0xeb, 0xd2, 0x5d, 0xda, 0x0d, 0xa2, 0x98, 0x6d, 0x48, 0xd3, 0x65, 0xdf, 0xd8, 0x86, 0xa9, 0xa3,
-	0x9d, 0x43, 0x46, 0xae, 0xb4, 0x50, 0xbb, 0xe9, 0x8a, 0xa1, 0xf3, 0x0d, 0x82, 0x19, 0xd5, 0x9f,
-	0x74, 0xfa, 0xbe, 0x37, 0x22, 0x6c, 0xc1, 0xa6, 0xcb, 0xbe, 0xf1, 0x3d, 0x58, 0xe9, 0x92, 0x67,
-	0xde, 0xe4, 0x34, 0x76, 0x49, 0x4c, 0xfc, 0x78, 0x18, 0xf8, 0x87, 0xc1, 0xe9, 0xf0, 0xf8, 0x2c,
-	0x51, 0x62, 0xe0, 0xe2, 0x47, 0x70, 0x23, 0x4b, 0x1a, 0x92, 0xc8, 0xae, 0x30, 0xe3, 0xd6, 0xb8,
-	0x71, 0xb9, 0x19, 0xcc, 0xce, 0xe2, 0x1c, 0xba, 0xd0, 0x4e, 0xe0, 0xc7, 0x43, 0x7f, 0x12, 0x4c,
-	0xa2, 0xdf, 0x4d, 0x48, 0x38, 0x4c, 0xa3, 0x27, 0x59, 0x28, 0xcb, 0x4e, 0x16, 0x2a, 0xcc, 0x71,
-	0xfe, 0x8d, 0x60, 0x31, 0xa7, 0xb3, 0x3f, 0x26, 0xc7, 0x8a, 0xd5, 0x28, 0xb5, 0x7a, 0x1d, 0x1a,
-	0xdd, 0x49, 0xe8, 0x51, 0x49, 0xdb, 0x6a, 0xa1, 0x76, 0xc5, 0x4d, 0xc7, 0x78, 0x0b, 0xb0, 0x0c,
-	0x86, 0x54, 0xaa, 0xc2, 0xa4, 0x34, 0x1c, 0xba, 0x96, 0x4b, 0xc6, 0xa7, 0xc3, 0x63, 0x6f, 0xdf,
-	0xae, 0xb6, 0x50, 0x7b, 0xd6, 0x4d, 0xc7, 0xce, 0x5b, 0xab, 0x80, 0xc9, 0xb8, 0x13, 0x59, 0x4c,
-	0xd6, 0x95, 0x30, 0x59, 0x57, 0xc2, 0x64, 0xa9, 0x98, 0xf0, 0x3d, 0x98, 0x96, 0x33, 0xc4, 0xf1,
-	0x5b, 0xe2, 0xae, 0x56, 0x4e, 0x01, 0xf5, 0xb2, 0x2a, 0x88, 0x7f, 0x09, 0xb3, 0xfd, 0xc9, 0xd3,
-	0xe8, 0x38, 0x1c, 0x8e, 0xa9, 0x0e, 0x71, 0x14, 0x57, 0x92, 0x99, 0x0a, 0x8b, 0xcd, 0xcd, 0x0a,
-	0x3b, 0x9f, 0x23, 0x98, 0xcb, 0xae, 0x5e, 0x88, 0xee, 0x0d, 0x68, 0xf6, 0x63, 0x2f, 0x8c, 0x8f,
-	0x86, 0x23, 0x92, 0x78, 0x40, 0x12, 0x68, 0x9c, 0x3f, 0xf0, 0x07, 0x8c, 0xc7, 0xed, 0x16, 0x43,
-	0x3a, 0xaf, 0x4b, 0x4e, 0x49, 0x4c, 0x06, 0xdb, 0x31, 0xb3, 0xb6, 0xe2, 0x4a, 0x02, 0xfe, 0x09,
-	0xd4, 0x99, 0x5e, 0x61, 0xe9, 0xbc, 0x62, 0x29, 0x03, 0x9a, 0xb0, 0x71, 0x0b, 0xa6, 0x8f, 0xc2,
-	0x89, 0x7f, 0xec, 0xf1, 0x85, 0xea, 0x6c, 0xc3, 0x55, 0x92, 0x43, 0xa0, 0x99, 0x4e, 0x2b, 0xa0,
-	0xdf, 0x84, 0xc6, 0xc1, 0x4b, 0x9f, 0x26, 0xc1, 0xc8, 0xb6, 0x5a, 0x95, 0x76, 0xf5, 0xbe, 0x65,
-	0x23, 0x37, 0xa5, 0xe1, 0x36, 0xd4, 0xd9, 0xb7, 0x38, 0x25, 0x0b, 0x0a, 0x0e, 0xc6, 0x70, 0x13,
-	0xbe, 0xf3, 0x7b, 0x58, 0xc8, 0x7b, 0x53, 0x1b, 0x30, 0x18, 0xaa, 0x7b, 0xc1, 0x80, 0x88, 0x6c,
-	0x40, 0xbf, 0xb1, 0x03, 0x33, 0x5d, 0x12, 0xc5, 0x43, 0xdf, 0xe3, 0x7b, 0x44, 0x75, 0x35, 0xdd,
-	0x0c, 0xcd, 0xb9, 0x03, 0x20, 0xb5, 0xe2, 0x15, 0xa8, 0x27, 0x09, 0x93, 0xdb, 0x92, 0x8c, 0x9c,
-	0xdf, 0xc0, 0xa2, 0xe6, 0xe0, 0x69, 0x81, 0x2c, 0x41, 0x8d, 0x09, 0x24, 0x48, 0xf8, 0xc0, 0x39,
-	0x87, 0x86, 0xc8, 0xcf, 0x26, 0xf8, 0xbb, 0x5e, 0x74, 0x92, 0x26, 0x33, 0x2f, 0x3a, 0xa1, 0x2b,
-	0x6d, 0x0f, 0x46, 0x43, 0x1e, 0xda, 0x0d, 0x97, 0x0f, 0xf0, 0x2f, 0x00, 0x0e, 0xc3, 0xe1, 0x8b,
-	0xe1, 0x29, 0x79, 0x9e, 0xe6, 0x86, 0x45, 0x79, 0x03, 0xa4, 0x3c, 0x57, 0x11, 0x73, 0x7a, 0x30,
-	0x9b, 0x61, 0xb2, 0xf3, 0x95, 0x64, 0xc3, 0x04, 0x47, 0x3a, 0xa6, 0x21, 0x94, 0x0a, 0x32, 0x40,
-	0x35, 0x57, 0x12, 0x9c, 0x77, 0x53, 0x30, 0xb5, 0x13, 0x8c, 0x46, 0x9e, 0x3f, 0xc0, 0x77, 0xa1,
-	0x1a, 0x9f, 0x8d, 0xf9, 0x0a, 0x73, 0xe2, 0xd6, 0x4a, 0x98, 0x5b, 0x47, 0x67, 0x63, 0xe2, 0x32,
-	0xbe, 0xf3, 0x55, 0x1d, 0xaa, 0x74, 0x88, 0x97, 0xe1, 0xc6, 0x4e, 0x48, 0xbc, 0x98, 0x50, 0xbf,
-	0x26, 0x82, 0x0b, 0x88, 0x92, 0x79, 0x8c, 0xaa, 0x64, 0x0b, 0xaf, 0xc1, 0x32, 0x97, 0x16, 0xd0,
-	0x04, 0xab, 0x82, 0x57, 0x61, 0xb1, 0x1b, 0x06, 0xe3, 0x3c, 0xa3, 0x8a, 0x5b, 0xb0, 0xc1, 0xe7,
-	0xe4, 0x32, 0x8d, 0x90, 0xa8, 0xe1, 0x4d, 0x58, 0xa7, 0x53, 0x0d, 0xfc, 0x3a, 0xbe, 0x03, 0xad,
-	0x3e, 0x89, 0xf5, 0x99, 0x5e, 0x48, 0x4d, 0x51, 0x3d, 0x4f, 0xc6, 0x03, 0xb3, 0x9e, 0x06, 0xbe,
-	0x09, 0xab, 0x1c, 0x89, 0x3c, 0xe9, 0x82, 0xd9, 0xa4, 0x4c, 0x6e, 0x71, 0x91, 0x09, 0xd2, 0x86,
-	0x5c, 0xcc, 0x09, 0x89, 0x69, 0x61, 0x83, 0x81, 0x3f, 0x23, 0xfd, 0x4c, 0x77, 0x5d, 0x90, 0x67,
-	0xf1, 0x22, 0xcc, 0xd3, 0x69, 0x2a, 0x71, 0x8e, 0xca, 0x72, 0x4b, 0x54, 0xf2, 0x3c, 0xf5, 0x70,
-	0x9f, 0xc4, 0xe9, 0xbe, 0x0b, 0xc6, 0x02, 0xc6, 0x30, 0x47, 0xfd, 0xe3, 0xc5, 0x9e, 0xa0, 0xdd,
-	0xc0, 0x1b, 0x60, 0xf7, 0x49, 0xcc, 0x02, 0xb4, 0x30, 0x03, 0x4b, 0x0d, 0xea, 0xf6, 0x2e, 0xe2,
-	0x5b, 0xb0, 0x96, 0x38, 0x48, 0x39, 0xe0, 0x82, 0xbd, 0xcc, 0x5c, 0x14, 0x06, 0x63, 0x1d, 0x73,
-	0x85, 0x2e, 0xe9, 0x92, 0x51, 0xf0, 0x82, 0x1c, 0x12, 0x09, 0x7a, 0x55, 0x46, 0x8c, 0x28, 0x21,
-	0x04, 0xcb, 0xce, 0x06, 0x93, 0xca, 0x5a, 0xa3, 0x2c, 0x8e, 0x2f, 0xcf, 0x5a, 0xa7, 0x2c, 0xbe,
-	0x4f, 0xf9, 0x05, 0x6f, 0x4a, 0x56, 0x7e, 0xd6, 0x06, 0x5e, 0x01, 0xdc, 0x27, 0x71, 0x7e, 0xca,
-	0x2d, 0xbc, 0x04, 0x0b, 0xcc, 0x24, 0xba, 0xe7, 0x82, 0xba, 0x49, 0x9d, 0xc7, 0xd5, 0xcb, 0x44,
-	0x14, 0x09, 0xee, 0x6d, 0xbc, 0x0e, 0x2b, 0x69, 0xda, 0xe5, 0xc9, 0x59, 0xf0, 0x5a, 0x3f, 0x6d,
-	0x34, 0x06, 0x0b, 0x17, 0x17, 0x17, 0x17, 0x96, 0x73, 0xae, 0x39, 0x58, 0x69, 0x85, 0x84, 0x94,
-	0x0a, 0x09, 0x43, 0xd5, 0xf5, 0xfc, 0x41, 0x52, 0xc6, 0xb2, 0xef, 0xce, 0x6f, 0x61, 0xea, 0x38,
-	0x99, 0x32, 0x9b, 0x39, 0xc3, 0x36, 0x69, 0xa1, 0xf6, 0x74, 0x67, 0x35, 0x21, 0xe6, 0x15, 0xb8,
-	0x62, 0x9a, 0xf3, 0x5a, 0x73, 0x80, 0x0b, 0x97, 0xc2, 0x12, 0xd4, 0x1e, 0x06, 0xe1, 0x31, 0xcf,
-	0x29, 0x0d, 0x97, 0x0f, 0x4a, 0x94, 0x3f, 0x53, 0x95, 0x17, 0x96, 0x97, 0xca, 0x3f, 0x45, 0x86,
-	0x3c, 0xa1, 0xcd, 0xb4, 0x3b, 0x30, 0x5f, 0x2c, 0xee, 0x50, 0x79, 0xa5, 0x96, 0x9f, 0xd1, 0xe9,
-	0x1a, 0x41, 0x3f, 0x67, 0x6b, 0xdd, 0x54, 0x3d, 0x96, 0x43, 0x25, 0x81, 0x8f, 0xb4, 0x49, 0x4c,
-	0x87, 0xba, 0x73, 0xdf, 0xa8, 0xf0, 0x44, 0x05, 0xaf, 0x59, 0x4e, 0xaa, 0xfb, 0x1a, 0x95, 0xe7,
-	0xc6, 0xd2, 0x4b, 0x41, 0xeb, 0x36, 0xeb, 0x7a, 0x6e, 0xa3, 0x65, 0x4b, 0x92, 0x57, 0x93, 0x3b,
-	0x4d, 0x0c, 0x3b, 0x8f, 0x8d, 0xf6, 0x0d, 0x99, 0x7d, 0x8e, 0xea, 0x50, 0x3d, 0x7c, 0x69, 0xe8,
-	0x7f, 0x51, 0x59, 0x8a, 0x2f, 0x35, 0x53, 0xf8, 0xde, 0x52, 0x7c, 0xdf, 0x33, 0x62, 0xfb, 0x03,
-	0xc3, 0xd6, 0x92, 0xbe, 0xbf, 0x0c, 0xd9, 0x07, 0xe8, 0xf2, 0xcb, 0xe5, 0xda, 0xf8, 0x0e, 0x8c,
-	0xf8, 0xfe, 0xc8, 0xf0, 0xdd, 0x4d, 0x8a, 0xab, 0x4b, 0xf4, 0x4a, 0x94, 0x6f, 0xad, 0xf2, 0xcb,
-	0xed, 0xba, 0x08, 0xe9, 0xbe, 0xef, 0x93, 0x97, 0x8c, 0x9c, 0xb4, 0x65, 0xc9, 0x30, 0x53, 0xe7,
-	0x57, 0x73, 0xbd, 0x87, 0x5a, 0xb7, 0xd7, 0xb2, 0xbd, 0x84, 0x1a, 0x49, 0xf5, 0xab, 0x46, 0xd2,
-	0xa9, 0x1a, 0x49, 0x65, 0xf6, 0x49, 0x4f, 0x7c, 0x81, 0x8c, 0x97, 0x78, 0xa9, 0x13, 0xda, 0xfa,
-	0xd3, 0xd2, 0x2c, 0x1e, 0x89, 0x0d, 0x68, 0xd2, 0xba, 0x3d, 0x8a, 0xbd, 0xd1, 0x38, 0xa9, 0xe5,
-	0x25, 0xa1, 0xf3, 0xd0, 0x68, 0xcc, 0x88, 0x19, 0x73, 0x4b, 0x3d, 0x16, 0x05, 0x88, 0xd2, 0x8e,
-	0x2f, 0x91, 0xb1, 0xde, 0x78, 0x4f, 0x76, 0x38, 0x30, 0x93, 0x79, 0x4c, 0xe0, 0x8f, 0x21, 0x19,
-	0x5a, 0x89, 0x35, 0xbe, 0x6a, 0x8d, 0x01, 0xa8, 0xb4, 0xe6, 0x13, 0x54, 0x5e, 0x20, 0x5d, 0x3b,
-	0x3e, 0xd3, 0x9a, 0xbd, 0xa2, 0xd4, 0xec, 0x25, 0x91, 0x14, 0x14, 0x73, 0x92, 0x1e, 0x49, 0x31,
-	0x27, 0xbd, 0x1f, 0xc4, 0x25, 0x39, 0x69, 0x9c, 0xcf, 0x49, 0x97, 0x21, 0x7b, 0x87, 0x34, 0xc5,
-	0xe2, 0x0f, 0x6b, 0x52, 0x4a, 0x2e, 0xf5, 0x3f, 0x15, 0x2b, 0x0a, 0x45, 0xad, 0x44, 0x45, 0x0a,
-	0xa5, 0xaa, 0xf6, 0x5e, 0xfc, 0xb5, 0x51, 0x51, 0xc8, 0x14, 0x2d, 0x4b, 0x3f, 0x68, 0xd5, 0x9c,
-	0x6b, 0x8a, 0xdf, 0xab, 0xda, 0x5e, 0x62, 0x65, 0xa4, 0x5a, 0x59, 0x50, 0x20, 0xd5, 0x7f, 0x84,
-	0xb4, 0x55, 0x36, 0x0d, 0x07, 0x2a, 0xef, 0x4b, 0x14, 0xe9, 0x38, 0x13, 0x2a, 0x56, 0x59, 0xeb,
-	0x56, 0xc9, 0xb5, 0x6e, 0x25, 0x45, 0x44, 0xac, 0x16, 0x11, 0x1a, 0x40, 0x12, 0x71, 0x90, 0xaf,
-	0xfe, 0xf1, 0x26, 0x7f, 0x35, 0x65, 0x38, 0xa7, 0x3b, 0x20, 0x9f, 0x2e, 0x5d, 0x46, 0xef, 0xfc,
-	0xca, 0xa8, 0x75, 0xc2, 0xb4, 0x2e, 0xc9, 0xeb, 0x49, 0xae, 0x2a, 0x15, 0xfe, 0x07, 0x99, 0x7b,
-	0x8b, 0x52, 0x3f, 0xa5, 0x91, 0x69, 0xa9, 0x91, 0xf9, 0xc8, 0x88, 0xe6, 0x05, 0x43, 0xb3, 0x99,
-	0xa2, 0xd1, 0x6a, 0x94, 0xb8, 0xce, 0x34, 0x4d, 0xcd, 0x55, 0xde, 0x28, 0x4b, 0xa2, 0xe6, 0x65,
-	0x31, 0x6a, 0xb4, 0x05, 0xef, 0xb7, 0xa8, 0xa4, 0x73, 0x32, 0x3e, 0xa7, 0x99, 0x62, 0x46, 0x93,
-	0xe3, 0x2b, 0xfa, 0x1c, 0x2f, 0xde, 0x58, 0xaa, 0x25, 0x6f, 0x2c, 0xb5, 0xe2, 0x1b, 0x4b, 0x67,
-	0xd7, 0x68, 0xf1, 0x19, 0xb3, 0xf8, 0x76, 0xe6, 0x16, 0x2b, 0x9a, 0x24, 0x2d, 0xff, 0x0c, 0x19,
-	0x9b, 0xc2, 0x1f, 0xcf, 0xee, 0x92, 0x7b, 0xeb, 0xcf, 0x99, 0x7b, 0x4b, 0x0f, 0x2c, 0x13, 0x32,
-	0x85, 0xa6, 0x35, 0x0d, 0x19, 0x24, 0x43, 0x66, 0x7b, 0x30, 0x08, 0x45, 0xc8, 0xd0, 0xef, 0x92,
-	0x90, 0x79, 0xad, 0x86, 0x4c, 0x61, 0x71, 0xa9, 0xfa, 0x43, 0x64, 0xe8, 0x8c, 0xa9, 0x8b, 0x76,
-	0x8f, 0x8e, 0x0e, 0x99, 0xce, 0xe4, 0x08, 0x89, 0x71, 0xf2, 0x9c, 0xae, 0xc0, 0x11, 0xc3, 0xb4,
-	0x8d, 0xac, 0x28, 0x6d, 0xa4, 0xb9, 0x29, 0xfa, 0x4b, 0xb1, 0x29, 0xca, 0xc1, 0xc8, 0x5c, 0x47,
-	0xfa, 0x46, 0xfd, 0xfb, 0x21, 0x2d, 0x41, 0x75, 0xae, 0x6f, 0xd5, 0xb4, 0xa8, 0xfe, 0x87, 0x0c,
-	0x6f, 0x04, 0xd7, 0xff, 0x2d, 0x61, 0x29, 0xbf, 0x25, 0x4a, 0xd0, 0xbd, 0x51, 0xd1, 0x69, 0x55,
-	0xab, 0x8d, 0xa4, 0xfe, 0x95, 0x22, 0x0f, 0xae, 0x44, 0xdd, 0x5f, 0x55, 0x75, 0xda, 0xc5, 0xa4,
-	0x3a, 0xdf, 0xf0, 0xf2, 0x51, 0x50, 0xf7, 0xc0, 0xa8, 0xee, 0x02, 0x15, 0xf5, 0x19, 0xcd, 0x7b,
-	0x48, 0x1b, 0x81, 0x68, 0x1c, 0xf8, 0x11, 0xa1, 0x2a, 0x0e, 0x1e, 0x33, 0x15, 0x0d, 0xd7, 0x3a,
-	0x78, 0x4c, 0xb3, 0xfc, 0x83, 0x30, 0x0c, 0x42, 0xd6, 0xc4, 0x37, 0x5d, 0x3e, 0x90, 0x7f, 0xeb,
-	0x2a, 0xec, 0x5c, 0xf1, 0x81, 0xf3, 0x7f, 0xa4, 0x7b, 0x97, 0x79, 0x8f, 0x27, 0xc0, 0x7c, 0xc1,
-	0xfe, 0x8d, 0xdb, 0x6b, 0xa7, 0xb7, 0x8b, 0xd1, 0xb9, 0x83, 0xe2, 0x1b, 0x51, 0xc1, 0xaf, 0xe6,
-	0x7c, 0xf0, 0x77, 0xae, 0x67, 0x45, 0xc9, 0x48, 0xca, 0x42, 0x52, 0xcb, 0xc7, 0xc8, 0xfc, 0xe8,
-	0xa4, 0xfb, 0x17, 0xb1, 0x3d, 0x48, 0x64, 0xf8, 0x73, 0xbe, 0x2b, 0x09, 0xc9, 0x1f, 0x07, 0xe5,
-	0x39, 0xbf, 0xea, 0x4a, 0x42, 0x49, 0xee, 0xff, 0x07, 0x52, 0x2f, 0x5c, 0x13, 0x18, 0x09, 0xf9,
-	0x8d, 0xe9, 0x21, 0x2c, 0xdb, 0x43, 0xa1, 0xab, 0xf7, 0x50, 0xff, 0xe4, 0x08, 0x36, 0x38, 0x55,
-	0xbf, 0x78, 0xaa, 0xff, 0xbb, 0x00, 0x00, 0x00, 0xff, 0xff, 0x0a, 0x72, 0x48, 0xb0, 0x31, 0x1e,
-	0x00, 0x00,
+	// 2197 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x59, 0x4b, 0x73, 0x1c, 0x49,
+	0x11, 0x8e, 0xea, 0x79, 0x68, 0x26, 0x65, 0x3d, 0x5c, 0x7a, 0xb8, 0x2d, 0xcb, 0xf2, 0x6c, 0xa3,
+	0x58, 0x14, 0x04, 0xe1, 0x20, 0x66, 0x89, 0x0d, 0x0e, 0xbc, 0xbc, 0x1a, 0xcb, 0x9a, 0x35, 0x7a,
+	0xd0, 0xa3, 0x0d, 0x6e, 0x04, 0xbd, 0x9a, 0xb2, 0x35, 0xa0, 0xe9, 0x9e, 0xed, 0xee, 0xb1, 0x2d,
+	0x16, 0x2d, 0x5a, 0x1e, 0x6b, 0x8e, 0x10, 0x04, 0x41, 0x04, 0x70, 0x82, 0x03, 0x47, 0xe0, 0xc2,
+	0x01, 0x4e, 0x7b, 0xe0, 0xc4, 0x5f, 0xf0, 0x99, 0xbf, 0xc0, 0x95, 0xa8, 0xaa, 0xae, 0xae, 0xea,
+	0xee, 0xaa, 0x92, 0x04, 0xe6, 0xd6, 0x95, 0x99, 0x95, 0xf9, 0x65, 0x56, 0x56, 0x56, 0x65, 0x35,
+	0xc0, 0x98, 0xa4, 0xc1, 0xfd, 0x49, 0x1c, 0xa5, 0x11, 0xae, 0xd3, 0x6f, 0xef, 0xe7, 0x35, 0xa8,
+	0xf7, 0x82, 0x34, 0xc0, 0x18, 0xea, 0x47, 0x24, 0x1e, 0xbb, 0xa8, 0xe3, 0x6c, 0xd5, 0x7d, 0xf6,
+	0x8d, 0x97, 0xa1, 0xd1, 0x0f, 0x87, 0xe4, 0x85, 0xeb, 0x30, 0x22, 0x1f, 0xe0, 0x75, 0x68, 0x6f,
+	0x9f, 0x4e, 0x93, 0x94, 0xc4, 0xfd, 0x9e, 0x5b, 0x63, 0x1c, 0x49, 0xc0, 0x9b, 0xd0, 0xd8, 0x8f,
+	0x86, 0x24, 0x71, 0xeb, 0x9d, 0xda, 0xd6, 0x6c, 0x77, 0xfe, 0x3e, 0x33, 0x49, 0x49, 0xfd, 0xf0,
+	0x49, 0xe4, 0x73, 0x26, 0xfe, 0x02, 0xb4, 0xa9, 0xd5, 0xf7, 0x83, 0x84, 0x24, 0x6e, 0x83, 0x49,
+	0x62, 0x2e, 0x29, 0xc8, 0x4c, 0x5a, 0x0a, 0x51, 0xbd, 0xef, 0x25, 0x24, 0x4e, 0xdc, 0xa6, 0xaa,
+	0x97, 0x92, 0xb8, 0x5e, 0xc6, 0xa4, 0xd8, 0xf6, 0x82, 0x17, 0xcc, 0x5a, 0xcf, 0x9d, 0xe1, 0xd8,
+	0x72, 0x02, 0xde, 0x82, 0x85, 0xbd, 0xe0, 0xc5, 0xe0, 0x24, 0x88, 0x87, 0x8f, 0xe2, 0x68, 0x3a,
+	0xe9, 0xf7, 0xdc, 0x16, 0x93, 0x29, 0x93, 0xf1, 0x06, 0x80, 0x20, 0xf5, 0x7b, 0x6e, 0x9b, 0x09,
+	0x29, 0x14, 0xfc, 0x79, 0x8e, 0x9f, 0x7b, 0x0a, 0x5a, 0x4f, 0xa5, 0x00, 0x95, 0xde, 0x23, 0x42,
+	0x7a, 0x56, 0x2f, 0x9d, 0x0b, 0x78, 0xbb, 0xd0, 0x12, 0x64, 0x3c, 0x0f, 0x4e, 0xbf, 0x97, 0xad,
+	0x89, 0xd3, 0xef, 0xd1, 0x55, 0xda, 0x8d, 0x92, 0x94, 0x2d, 0x48, 0xdb, 0x67, 0xdf, 0xd8, 0x85,
+	0x99, 0xa3, 0xed, 0x43, 0x46, 0xae, 0x75, 0xd0, 0x56, 0xdb, 0x17, 0x43, 0xef, 0xe3, 0x1a, 0xdc,
+	0x50, 0xe3, 0x49, 0xa7, 0xef, 0x07, 0x63, 0xc2, 0x14, 0xb6, 0x7d, 0xf6, 0x8d, 0xdf, 0x86, 0xd5,
+	0x1e, 0x79, 0x12, 0x4c, 0x4f, 0x53, 0x9f, 0xa4, 0x24, 0x4c, 0x47, 0x51, 0x78, 0x18, 0x9d, 0x8e,
+	0x8e, 0xcf, 0x32, 0x23, 0x06, 0x2e, 0x7e, 0x04, 0x37, 0x8b, 0xa4, 0x11, 0x49, 0xdc, 0x1a, 0x73,
+	0xee, 0x36, 0x77, 0xae, 0x34, 0x83, 0xf9, 0x59, 0x9d, 0x43, 0x15, 0x6d, 0x47, 0x61, 0x3a, 0x0a,
+	0xa7, 0xd1, 0x34, 0xf9, 0xe6, 0x94, 0xc4, 0xa3, 0x3c, 0x7b, 0x32, 0x45, 0x45, 0x76, 0xa6, 0xa8,
+	0x32, 0x07, 0xef, 0xc0, 0xe2, 0xbb, 0x83, 0x83, 0xfd, 0x6f, 0xc5, 0xa3, 0x94, 0xec, 0x05, 0x93,
+	0xc9, 0x28, 0x7c, 0xea, 0x36, 0x3a, 0x68, 0x6b, 0xb6, 0xbb, 0xc6, 0xf5, 0x94, 0xb9, 0x4c, 0x51,
+	0x65, 0x0e, 0x7e, 0x0c, 0x78, 0x8f, 0x04, 0xc9, 0x34, 0x26, 0x63, 0x12, 0xa6, 0x83, 0xe3, 0x13,
+	0x32, 0x0e, 0x44, 0xde, 0xdd, 0xe1, 0x9a, 0x2a, 0x7c, 0xa6, 0x4a, 0x33, 0xcd, 0x7b, 0x85, 0x60,
+	0x59, 0x67, 0x17, 0x77, 0x60, 0x56, 0x11, 0x77, 0x11, 0x5b, 0x3a, 0x95, 0x84, 0xef, 0x03, 0xce,
+	0x62, 0xaf, 0x0a, 0x3a, 0x4c, 0x50, 0xc3, 0x61, 0x5b, 0x38, 0x78, 0xca, 0x17, 0xa1, 0xed, 0xb3,
+	0x6f, 0xbc, 0x0a, 0xcd, 0x9d, 0x11, 0x39, 0x1d, 0xf2, 0x88, 0xb6, 0xfd, 0x6c, 0x44, 0x37, 0xca,
+	0xd1, 0x68, 0x4c, 0x92, 0x34, 0x18, 0x4f, 0x58, 0x90, 0xda, 0xbe, 0x24, 0xd0, 0x8d, 0x92, 0x0f,
+	0x76, 0xa2, 0x78, 0x1c, 0xa4, 0x6e, 0x93, 0xc9, 0x94, 0xc9, 0xde, 0x19, 0xac, 0x68, 0x63, 0xa1,
+	0x4d, 0x35, 0x01, 0xd0, 0x51, 0x00, 0x7e, 0x29, 0x07, 0xc8, 0x73, 0xa7, 0x63, 0x08, 0x30, 0x13,
+	0x62, 0x51, 0xce, 0xe4, 0xbd, 0xef, 0xc0, 0x9a, 0x59, 0xca, 0x68, 0xff, 0x6c, 0x42, 0x58, 0x62,
+	0x37, 0x7c, 0xf6, 0x8d, 0xd7, 0xa0, 0xe5, 0x93, 0x0f, 0xa6, 0xa3, 0x98, 0x0c, 0xd9, 0xf6, 0x69,
+	0xf9, 0xf9, 0xd8, 0xfb, 0x05, 0x82, 0xa5, 0x52, 0x12, 0x0f, 0x26, 0xe4, 0x58, 0xd1, 0x8d, 0x72,
+	0xdd, 0x6b, 0xd0, 0xea, 0x4d, 0xe3, 0x80, 0x4a, 0xb2, 0x25, 0xaa, 0xf9, 0xf9, 0x98, 0x2e, 0xa4,
+	0xac, 0x2e, 0xb9, 0x54, 0x8d, 0x49, 0x69, 0x38, 0x1c, 0xd3, 0xe4, 0x74, 0x74, 0x1c, 0xec, 0xbb,
+	0xf5, 0x0e, 0xda, 0x9a, 0xf3, 0xf3, 0xb1, 0xf7, 0xd2, 0xa9, 0x60, 0x32, 0xfa, 0x5b, 0xc4, 0xe4,
+	0x5c, 0x09, 0x93, 0x73, 0x25, 0x4c, 0x8e, 0x8a, 0x09, 0xbf, 0x0d, 0xb3, 0x72, 0x86, 0xa8, 0xe7,
+	0xcb, 0x7c, 0x21, 0x25, 0x83, 0x2d, 0x9e, 0x2a, 0x88, 0xbf, 0x0c, 0x73, 0x83, 0xe9, 0xfb, 0xc9,
+	0x71, 0x3c, 0x9a, 0x50, 0x1b, 0x62, 0x8f, 0xad, 0x66, 0x33, 0x15, 0x16, 0x9b, 0x5b, 0x14, 0xf6,
+	0x3e, 0x45, 0x30, 0x5f, 0xd4, 0x5e, 0x29, 0x97, 0xeb, 0xd0, 0x1e, 0xa4, 0x41, 0x9c, 0xd2, 0xac,
+	0xcd, 0x22, 0x20, 0x09, 0xb4, 0x70, 0x3e, 0x0c, 0x87, 0x8c, 0xc7, 0xfd, 0x16, 0x43, 0x3a, 0xaf,
+	0x47, 0x4e, 0x49, 0x4a, 0x86, 0x0f, 0x52, 0xe6, 0x6d, 0xcd, 0x97, 0x04, 0xfc, 0x59, 0x68, 0x32,
+	0xbb, 0xc2, 0xd3, 0x05, 0xc5, 0x53, 0x9e, 0xa1, 0x9c, 0x4d, 0xb7, 0xf8, 0x51, 0x3c, 0x0d, 0x8f,
+	0x03, 0xae, 0xa8, 0xc9, 0x16, 0x5c, 0x25, 0x79, 0x04, 0xda, 0xf9, 0xb4, 0x0a, 0xfa, 0x0d, 0x68,
+	0x1d, 0x3c, 0x0f, 0xe9, 0xa9, 0xca, 0xb7, 0x4c, 0xfd, 0x1d, 0xc7, 0x45, 0x7e, 0x4e, 0xc3, 0x5b,
+	0xd0, 0x64, 0xdf, 0x62, 0xeb, 0x2c, 0x2a, 0x38, 0x18, 0xc3, 0xcf, 0xf8, 0xde, 0xb7, 0x61, 0xb1,
+	0x1c, 0x4d, 0xd3, 0x06, 0xd9, 0x8b, 0x86, 0x44, 0x1c, 0x2f, 0xf4, 0x1b, 0x7b, 0x70, 0xa3, 0x47,
+	0x92, 0x74, 0x14, 0x06, 0x7c, 0x8d, 0x78, 0x75, 0x29, 0xd0, 0xbc, 0x4d, 0x00, 0x69, 0x95, 0xd6,
+	0x9c, 0xec, 0x04, 0xe6, 0xbe, 0x64, 0x23, 0xef, 0x6b, 0xb0, 0xa4, 0xa9, 0xe4, 0x5a, 0x20, 0xcb,
+	0xd0, 0x60, 0x02, 0x19, 0x12, 0x3e, 0xf0, 0xce, 0xa1, 0x25, 0x0e, 0x7c, 0x13, 0xfc, 0xdd, 0x20,
+	0x39, 0xc9, 0x4f, 0xc7, 0x20, 0x39, 0xa1, 0x9a, 0x1e, 0x0c, 0xc7, 0x23, 0x9e, 0xda, 0x2d, 0x9f,
+	0x0f, 0xf0, 0x5b, 0x00, 0x87, 0xf1, 0xe8, 0xd9, 0xe8, 0x94, 0x3c, 0xcd, 0x0f, 0x9b, 0x25, 0x79,
+	0xa5, 0xc8, 0x79, 0xbe, 0x22, 0xe6, 0xf5, 0x61, 0xae, 0xc0, 0x64, 0xfb, 0x2b, 0x3b, 0x5e, 0x33,
+	0x1c, 0xf9, 0x98, 0xa6, 0x50, 0x2e, 0x98, 0x15, 0x1c, 0x49, 0xf0, 0x7e, 0xd3, 0x82, 0x99, 0xed,
+	0x68, 0x3c, 0x0e, 0xc2, 0x21, 0x7e, 0x13, 0xea, 0xe9, 0xd9, 0x84, 0x6b, 0x98, 0x17, 0xd7, 0xa0,
+	0x8c, 0x79, 0x9f, 0xd6, 0x28, 0x9f, 0xf1, 0xbd, 0x57, 0x33, 0xbc, 0x7c, 0xe1, 0x15, 0xb8, 0xb9,
+	0x1d, 0x93, 0x20, 0x25, 0x34, 0xae, 0x99, 0xe0, 0x22, 0xa2, 0x64, 0x9e, 0xa3, 0x2a, 0xd9, 0xc1,
+	0xb7, 0x61, 0x85, 0x4b, 0x0b, 0x68, 0x82, 0x55, 0xc3, 0xb7, 0x60, 0xa9, 0x17, 0x47, 0x93, 0x32,
+	0xa3, 0x8e, 0x3b, 0xb0, 0xce, 0xe7, 0x94, 0x2a, 0x8d, 0x90, 0x68, 0xe0, 0x0d, 0x58, 0xa3, 0x53,
+	0x0d, 0xfc, 0x26, 0xde, 0x84, 0xce, 0x80, 0xa4, 0xfa, 0xab, 0x83, 0x90, 0x9a, 0xa1, 0x76, 0xde,
+	0x9b, 0x0c, 0xcd, 0x76, 0x5a, 0xf8, 0x0e, 0xdc, 0xe2, 0x48, 0xe4, 0x4e, 0x17, 0xcc, 0x36, 0x65,
+	0x72, 0x8f, 0xab, 0x4c, 0x90, 0x3e, 0x94, 0x72, 0x4e, 0x48, 0xcc, 0x0a, 0x1f, 0x0c, 0xfc, 0x1b,
+	0x32, 0xce, 0x74, 0xd5, 0x05, 0x79, 0x0e, 0x2f, 0xc1, 0x02, 0x9d, 0xa6, 0x12, 0xe7, 0xa9, 0x2c,
+	0xf7, 0x44, 0x25, 0x2f, 0xd0, 0x08, 0x0f, 0x48, 0x9a, 0xaf, 0xbb, 0x60, 0x2c, 0x62, 0x0c, 0xf3,
+	0x34, 0x3e, 0x41, 0x1a, 0x08, 0xda, 0x4d, 0xbc, 0x0e, 0xee, 0x80, 0xa4, 0x2c, 0x41, 0x2b, 0x33,
+	0xb0, 0xb4, 0xa0, 0x2e, 0xef, 0x12, 0xbe, 0x0b, 0xb7, 0xb3, 0x00, 0x29, 0x1b, 0x5c, 0xb0, 0x57,
+	0x58, 0x88, 0xe2, 0x68, 0xa2, 0x63, 0xae, 0x52, 0x95, 0x3e, 0x19, 0x47, 0xcf, 0xc8, 0x21, 0x91,
+	0xa0, 0x6f, 0xc9, 0x8c, 0x11, 0x77, 0x52, 0xc1, 0x72, 0x8b, 0xc9, 0xa4, 0xb2, 0x6e, 0x53, 0x16,
+	0xc7, 0x57, 0x66, 0xad, 0x51, 0x16, 0x5f, 0xa7, 0xb2, 0xc2, 0x3b, 0x92, 0x55, 0x9e, 0xb5, 0x8e,
+	0x57, 0x01, 0x0f, 0x48, 0x5a, 0x9e, 0x72, 0x17, 0x2f, 0xc3, 0x22, 0x73, 0x89, 0xae, 0xb9, 0xa0,
+	0x6e, 0xd0, 0xe0, 0x71, 0xf3, 0xb2, 0x10, 0x25, 0x82, 0x7b, 0x0f, 0xaf, 0xc1, 0x6a, 0x5e, 0x76,
+	0x79, 0x71, 0x16, 0xbc, 0x0e, 0x4d, 0x83, 0x01, 0x49, 0xcb, 0x77, 0x34, 0xc1, 0x7f, 0x03, 0xdf,
+	0x83, 0x3b, 0xd4, 0x9e, 0x49, 0xc0, 0xc3, 0x1e, 0x6c, 0x88, 0x78, 0x95, 0xae, 0x23, 0x42, 0xe6,
+	0x33, 0x34, 0x1b, 0xa9, 0x12, 0xa3, 0xc4, 0xe6, 0xe7, 0x5a, 0xad, 0xe1, 0xe2, 0xc5, 0xc5, 0xc5,
+	0x85, 0xe3, 0x9d, 0x6b, 0xf6, 0x77, 0x7e, 0xf3, 0x47, 0xca, 0xcd, 0x1f, 0x43, 0xdd, 0x0f, 0xc2,
+	0x61, 0xd6, 0x9e, 0xb1, 0xef, 0xee, 0xd7, 0x61, 0xe6, 0x38, 0x9b, 0x32, 0x57, 0x28, 0x25, 0x2e,
+	0x61, 0x57, 0xe1, 0x5b, 0x19, 0xb1, 0x6c, 0xc0, 0x17, 0xd3, 0xbc, 0x0f, 0x35, 0x75, 0xa4, 0x72,
+	0x36, 0x2d, 0x43, 0x63, 0x27, 0x8a, 0x8f, 0x79, 0x69, 0x6b, 0xf9, 0x7c, 0x60, 0x31, 0xfe, 0x44,
+	0x35, 0x5e, 0x51, 0x2f, 0x8d, 0xff, 0x15, 0x19, 0xca, 0x95, 0xb6, 0xe0, 0x6f, 0xc3, 0x42, 0xb5,
+	0x69, 0x41, 0xf6, 0x0e, 0xa4, 0x3c, 0xa3, 0xdb, 0x33, 0x82, 0x7e, 0xda, 0x41, 0xf2, 0xca, 0xaf,
+	0x45, 0x25, 0x81, 0x8f, 0xb5, 0xb5, 0x54, 0x87, 0xba, 0xfb, 0x8e, 0xd1, 0xe0, 0x89, 0x0a, 0x5e,
+	0xa3, 0x4e, 0x9a, 0xfb, 0x17, 0xb2, 0x97, 0x68, 0xeb, 0xd9, 0xa4, 0x0d, 0x9b, 0x73, 0xbd, 0xb0,
+	0xd1, 0xdb, 0x53, 0x56, 0xde, 0xb3, 0xa3, 0x55, 0x0c, 0xbb, 0x8f, 0x8d, 0xfe, 0x8d, 0x98, 0x7f,
+	0x9e, 0x1a, 0x50, 0x3d, 0x7c, 0xe9, 0xe8, 0xaf, 0x91, 0xed, 0xa4, 0xb1, 0xba, 0x29, 0x62, 0xef,
+	0x28, 0xb1, 0xef, 0x1b, 0xb1, 0x7d, 0xb7, 0x83, 0x64, 0xfb, 0x61, 0xb6, 0x28, 0x91, 0xfd, 0x01,
+	0x5d, 0x7e, 0xc6, 0x5d, 0x1b, 0xdf, 0x81, 0x11, 0xdf, 0xf7, 0x18, 0xbe, 0x37, 0x39, 0xf1, 0x32,
+	0xbb, 0x12, 0xe5, 0x4b, 0xc7, 0x7e, 0xc6, 0x5e, 0x17, 0x21, 0x5d, 0xf7, 0x7d, 0xf2, 0x9c, 0x91,
+	0xb3, 0xe7, 0x86, 0x6c, 0x58, 0x68, 0x37, 0xea, 0xa5, 0x16, 0x48, 0x6d, 0x1f, 0x1a, 0xc5, 0x96,
+	0x46, 0xcd, 0xa4, 0xe6, 0x55, 0x33, 0xe9, 0x54, 0xcd, 0x24, 0x9b, 0x7f, 0x32, 0x12, 0xff, 0x40,
+	0xc6, 0xbb, 0x84, 0x35, 0x08, 0x5b, 0xfa, 0xdd, 0xd2, 0xae, 0x6e, 0x89, 0x42, 0x53, 0xcd, 0x5b,
+	0x0a, 0x49, 0xe8, 0xee, 0x18, 0x9d, 0x19, 0x33, 0x67, 0xee, 0xaa, 0xdb, 0xa2, 0x02, 0x51, 0xfa,
+	0xf1, 0x4f, 0x64, 0xbc, 0xf6, 0xbc, 0x26, 0x3f, 0x3c, 0xb8, 0x51, 0x78, 0x24, 0xe3, 0x8f, 0x7c,
+	0x05, 0x9a, 0xc5, 0x9b, 0x50, 0xf5, 0xc6, 0x00, 0x54, 0x7a, 0xf3, 0x17, 0x64, 0xbf, 0xa7, 0x5d,
+	0x3b, 0x3f, 0xf3, 0xd6, 0xa1, 0xa6, 0xb4, 0x0e, 0x96, 0x4c, 0x8a, 0xaa, 0x35, 0x49, 0x8f, 0xa4,
+	0x5a, 0x93, 0x5e, 0x0f, 0x62, 0x4b, 0x4d, 0x9a, 0x94, 0x6b, 0xd2, 0x65, 0xc8, 0x7e, 0x89, 0x34,
+	0x77, 0xd6, 0xff, 0xad, 0x57, 0xb2, 0x1c, 0xea, 0x1f, 0x54, 0x6f, 0x14, 0x8a, 0x59, 0x89, 0x8a,
+	0x54, 0x6e, 0xcc, 0xda, 0x73, 0xf1, 0xab, 0x46, 0x43, 0x31, 0x33, 0xb4, 0x22, 0xe3, 0xa0, 0x35,
+	0x73, 0xae, 0xb9, 0x83, 0x5f, 0xd5, 0x77, 0x8b, 0x97, 0x89, 0xea, 0x65, 0xc5, 0x80, 0x34, 0xff,
+	0x27, 0xa4, 0xbd, 0xec, 0xd3, 0x74, 0xa0, 0xf2, 0xa1, 0x44, 0x91, 0x8f, 0x0b, 0xa9, 0xe2, 0xd8,
+	0x3a, 0xc8, 0x5a, 0xa9, 0x83, 0xb4, 0x5c, 0x22, 0x52, 0xf5, 0x12, 0xa1, 0x01, 0x24, 0x11, 0x47,
+	0xe5, 0x26, 0x04, 0x6f, 0xf0, 0xbf, 0x01, 0x0c, 0xe7, 0x6c, 0x17, 0xe4, 0x93, 0xbc, 0xcf, 0xe8,
+	0xdd, 0xaf, 0x18, 0xad, 0x4e, 0x3b, 0x48, 0x79, 0xf4, 0x29, 0x68, 0x95, 0x06, 0x7f, 0x85, 0xcc,
+	0x2d, 0x8e, 0x35, 0x4e, 0x79, 0x66, 0x3a, 0x6a, 0x66, 0x3e, 0x32, 0xa2, 0x79, 0xc6, 0xd0, 0x6c,
+	0xe4, 0x68, 0xb4, 0x16, 0x25, 0xae, 0x33, 0x4d, 0x6f, 0x75, 0x95, 0xb7, 0x77, 0x4b, 0xd6, 0x3c,
+	0xaf, 0x66, 0x8d, 0xf6, 0xc2, 0xfb, 0x6f, 0x64, 0x69, 0xe0, 0x8c, 0xaf, 0x7a, 0xa6, 0x9c, 0xd1,
+	0xd4, 0xf8, 0x9a, 0xbe, 0xc6, 0x8b, 0xa7, 0x9e, 0xba, 0xe5, 0xa9, 0xa7, 0x51, 0x7d, 0xea, 0xe9,
+	0xee, 0x1a, 0x3d, 0x3e, 0x63, 0x1e, 0xdf, 0x2b, 0x9c, 0x62, 0x55, 0x97, 0xa4, 0xe7, 0x7f, 0x47,
+	0xc6, 0xde, 0xf4, 0xff, 0xe7, 0xb7, 0xe5, 0xdc, 0xfa, 0x7e, 0xe1, 0xdc, 0xd2, 0x03, 0x2b, 0xa4,
+	0x4c, 0xa5, 0x77, 0xce, 0x53, 0x06, 0xc9, 0x94, 0x79, 0x30, 0x1c, 0xc6, 0x22, 0x65, 0xe8, 0xb7,
+	0x25, 0x65, 0x3e, 0x54, 0x53, 0xa6, 0xa2, 0x5c, 0x9a, 0xfe, 0x23, 0x32, 0x34, 0xe8, 0x34, 0x44,
+	0xbb, 0x47, 0x47, 0x87, 0xcc, 0x66, 0xb6, 0x85, 0xc4, 0x38, 0xfb, 0x4d, 0xa4, 0xc0, 0x11, 0xc3,
+	0xbc, 0x8d, 0xac, 0x29, 0x6d, 0xa4, 0xb9, 0x29, 0xfa, 0x41, 0xb5, 0x29, 0x2a, 0xc1, 0x28, 0x1c,
+	0x47, 0xfa, 0xf7, 0x82, 0xff, 0x0e, 0xa9, 0x05, 0xd5, 0xb9, 0xbe, 0x55, 0xd3, 0xa2, 0xfa, 0x2d,
+	0x32, 0x3c, 0x55, 0x5c, 0xff, 0x77, 0x9b, 0xa3, 0xfc, 0x6e, 0xb3, 0xa0, 0xfb, 0x48, 0x45, 0xa7,
+	0x35, 0xad, 0x36, 0x92, 0xfa, 0xc7, 0x92, 0x32, 0x38, 0x8b, 0xb9, 0x1f, 0xaa, 0xe6, 0xb4, 0xca,
+	0xa4, 0xb9, 0xd0, 0xf0, 0x00, 0x53, 0x31, 0xf7, 0xd0, 0x68, 0xee, 0x02, 0x55, 0xed, 0x19, 0xdd,
+	0xdb, 0xa1, 0x8d, 0x40, 0x32, 0x89, 0xc2, 0x84, 0x50, 0x13, 0x07, 0x8f, 0x99, 0x89, 0x96, 0xef,
+	0x1c, 0x3c, 0xa6, 0x55, 0xfe, 0x61, 0x1c, 0x47, 0x71, 0xf6, 0x8f, 0x8b, 0x0f, 0xe4, 0x5f, 0xe8,
+	0x1a, 0xdb, 0x57, 0x7c, 0xe0, 0xfd, 0x1e, 0xe9, 0x9e, 0x87, 0x5e, 0xe3, 0x0e, 0x30, 0x1f, 0xb0,
+	0x1f, 0x73, 0x7f, 0xdd, 0xfc, 0x74, 0x31, 0x06, 0x77, 0x58, 0x7d, 0xaa, 0xaa, 0xc4, 0xd5, 0x5c,
+	0x0f, 0x7e, 0xc4, 0xed, 0xac, 0x2a, 0x15, 0x49, 0x51, 0x24, 0xad, 0xfc, 0x19, 0x99, 0xdf, 0xbe,
+	0x74, 0xbf, 0x44, 0x1e, 0x0c, 0x33, 0x19, 0xfe, 0x57, 0xc1, 0x97, 0x84, 0xec, 0xc7, 0x87, 0xf2,
+	0x57, 0xa1, 0xee, 0x4b, 0x82, 0xa5, 0xf6, 0xff, 0x18, 0xa9, 0x07, 0xae, 0x09, 0x8c, 0x84, 0xfc,
+	0x91, 0xe9, 0x3d, 0xae, 0xd8, 0x43, 0xa1, 0xab, 0xf7, 0x50, 0x3f, 0xe1, 0x08, 0xd6, 0x39, 0x55,
+	0xaf, 0x5c, 0xda, 0xff, 0x1b, 0xb2, 0x3d, 0xfa, 0x59, 0x6f, 0xf0, 0x5f, 0x84, 0x99, 0x4c, 0x3a,
+	0x7b, 0x34, 0xb1, 0xfd, 0x5c, 0x16, 0xa2, 0xdd, 0x77, 0x8d, 0xc0, 0x7f, 0x8a, 0xd4, 0x4b, 0xbe,
+	0x19, 0x94, 0x04, 0xff, 0x09, 0xb2, 0xbe, 0x48, 0xda, 0xd0, 0x5b, 0xfa, 0xa0, 0x4f, 0x38, 0x8e,
+	0x37, 0x64, 0xb6, 0x5d, 0x0a, 0xe4, 0x53, 0x74, 0xd9, 0xcb, 0xa7, 0x35, 0x92, 0x6f, 0x41, 0x93,
+	0x0b, 0x67, 0x81, 0xb4, 0xfe, 0x5b, 0xcf, 0x44, 0xbb, 0xfb, 0x46, 0x07, 0x5e, 0x72, 0x07, 0x36,
+	0x8b, 0x27, 0x93, 0x1e, 0x97, 0xf4, 0xe1, 0x77, 0xc8, 0xfe, 0x32, 0x7b, 0xed, 0x6e, 0xee, 0x1b,
+	0x46, 0x80, 0x3f, 0x43, 0x6a, 0xab, 0x69, 0x33, 0x9a, 0xc3, 0xfb, 0xcf, 0x00, 0xd0, 0xdd, 0x0c,
+	0x17, 0xb2, 0x23, 0x00, 0x00,
 }
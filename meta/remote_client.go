@@ -965,6 +965,51 @@ func (c *RemoteClient) DropSubscription(database, rp, name string) error {
 	)
 }
 
+// SetJSONWriteMapping sets the mapping used to convert JSON documents written to the database into points.
+func (c *RemoteClient) SetJSONWriteMapping(database string, m *JSONWriteMappingInfo) error {
+	if err := m.validate(); err != nil {
+		return err
+	}
+	return c.retryUntilExec(internal.Command_SetJSONWriteMappingCommand, internal.E_SetJSONWriteMappingCommand_Command,
+		&internal.SetJSONWriteMappingCommand{
+			Database: proto.String(database),
+			Mapping:  m.marshal(),
+		},
+	)
+}
+
+// DropJSONWriteMapping removes the JSON write mapping of the database.
+func (c *RemoteClient) DropJSONWriteMapping(database string) error {
+	return c.retryUntilExec(internal.Command_DropJSONWriteMappingCommand, internal.E_DropJSONWriteMappingCommand_Command,
+		&internal.DropJSONWriteMappingCommand{
+			Database: proto.String(database),
+		},
+	)
+}
+
+// CreateMeasurementSchema declares the write schema of a measurement in the database.
+func (c *RemoteClient) CreateMeasurementSchema(database string, schema *MeasurementSchemaInfo) error {
+	if err := schema.validate(); err != nil {
+		return err
+	}
+	return c.retryUntilExec(internal.Command_CreateMeasurementSchemaCommand, internal.E_CreateMeasurementSchemaCommand_Command,
+		&internal.CreateMeasurementSchemaCommand{
+			Database: proto.String(database),
+			Schema:   schema.marshal(),
+		},
+	)
+}
+
+// DropMeasurementSchema removes the write schema of a measurement in the database.
+func (c *RemoteClient) DropMeasurementSchema(database, name string) error {
+	return c.retryUntilExec(internal.Command_DropMeasurementSchemaCommand, internal.E_DropMeasurementSchemaCommand_Command,
+		&internal.DropMeasurementSchemaCommand{
+			Database: proto.String(database),
+			Name:     proto.String(name),
+		},
+	)
+}
+
 func (c *RemoteClient) TruncateShardGroups(t time.Time) error {
 	return c.retryUntilExec(internal.Command_TruncatedShardsCommand, internal.E_TruncatedShardsCommand_Command,
 		&internal.TruncatedShardsCommand{
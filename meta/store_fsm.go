@@ -97,6 +97,14 @@ func (fsm *storeFSM) Apply(l *raft.Log) interface{} {
 			return fsm.applyTrancateShardsCommand(&cmd)
 		case internal.Command_UpdateDataNodeCommand:
 			return fsm.applyUpdateDataNodeCommand(&cmd)
+		case internal.Command_SetJSONWriteMappingCommand:
+			return fsm.applySetJSONWriteMappingCommand(&cmd)
+		case internal.Command_DropJSONWriteMappingCommand:
+			return fsm.applyDropJSONWriteMappingCommand(&cmd)
+		case internal.Command_CreateMeasurementSchemaCommand:
+			return fsm.applyCreateMeasurementSchemaCommand(&cmd)
+		case internal.Command_DropMeasurementSchemaCommand:
+			return fsm.applyDropMeasurementSchemaCommand(&cmd)
 		default:
 			panic(fmt.Errorf("cannot apply command: %x", l.Data))
 		}
@@ -425,6 +433,68 @@ func (fsm *storeFSM) applyDropSubscriptionCommand(cmd *internal.Command) interfa
 	return nil
 }
 
+func (fsm *storeFSM) applySetJSONWriteMappingCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_SetJSONWriteMappingCommand_Command)
+	v := ext.(*internal.SetJSONWriteMappingCommand)
+
+	var m JSONWriteMappingInfo
+	m.unmarshal(v.GetMapping())
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.SetJSONWriteMapping(v.GetDatabase(), &m); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applyDropJSONWriteMappingCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_DropJSONWriteMappingCommand_Command)
+	v := ext.(*internal.DropJSONWriteMappingCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.DropJSONWriteMapping(v.GetDatabase()); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applyCreateMeasurementSchemaCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_CreateMeasurementSchemaCommand_Command)
+	v := ext.(*internal.CreateMeasurementSchemaCommand)
+
+	var schema MeasurementSchemaInfo
+	schema.unmarshal(v.GetSchema())
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.CreateMeasurementSchema(v.GetDatabase(), &schema); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applyDropMeasurementSchemaCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_DropMeasurementSchemaCommand_Command)
+	v := ext.(*internal.DropMeasurementSchemaCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.DropMeasurementSchema(v.GetDatabase(), v.GetName()); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
 func (fsm *storeFSM) applyCreateUserCommand(cmd *internal.Command) interface{} {
 	ext, _ := proto.GetExtension(cmd, internal.E_CreateUserCommand_Command)
 	v := ext.(*internal.CreateUserCommand)
@@ -0,0 +1,121 @@
+// Package arrow writes Apache Arrow IPC streams and files of flat schemas,
+// the layout used to export measurements and query results.
+package arrow
+
+import (
+	"fmt"
+)
+
+// Type is the type of the values of a field.
+type Type int
+
+const (
+	Int64 Type = iota
+	Uint64
+	Float64
+	Bool
+	Utf8
+	// TimestampNanos stores nanoseconds since the Unix epoch in UTC.
+	TimestampNanos
+)
+
+func (t Type) String() string {
+	switch t {
+	case Int64:
+		return "int64"
+	case Uint64:
+		return "uint64"
+	case Float64:
+		return "float64"
+	case Bool:
+		return "bool"
+	case Utf8:
+		return "utf8"
+	case TimestampNanos:
+		return "timestamp[ns, tz=UTC]"
+	}
+	return "unknown"
+}
+
+// Field describes a field of a schema.
+type Field struct {
+	Name     string
+	Type     Type
+	Nullable bool
+}
+
+// KeyValue is an entry of the metadata of a schema.
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+// Schema describes the fields of the records of a stream or file.
+type Schema struct {
+	Fields   []Field
+	Metadata []KeyValue
+}
+
+// Column holds the values of a field for the rows of a record. Only the
+// slice matching the type of the field is used, and it holds a value for
+// every row, null or not. Valid reports which rows are not null, all are if
+// it is nil.
+type Column struct {
+	Valid []bool
+
+	Int64s   []int64
+	Uint64s  []uint64
+	Float64s []float64
+	Bools    []bool
+	Strings  []string
+}
+
+// Len returns the number of rows of c, for a field of type t.
+func (c *Column) Len(t Type) int {
+	switch t {
+	case Int64, TimestampNanos:
+		return len(c.Int64s)
+	case Uint64:
+		return len(c.Uint64s)
+	case Float64:
+		return len(c.Float64s)
+	case Bool:
+		return len(c.Bools)
+	case Utf8:
+		return len(c.Strings)
+	}
+	return 0
+}
+
+// IsNull returns true if row i of c is null.
+func (c *Column) IsNull(i int) bool { return c.Valid != nil && !c.Valid[i] }
+
+// validate checks that columns hold the same number of rows for each field
+// of s, and returns that number.
+func (s *Schema) validate(columns []Column) (int, error) {
+	if len(columns) != len(s.Fields) {
+		return 0, fmt.Errorf("arrow: %d fields, got %d columns", len(s.Fields), len(columns))
+	}
+	n := 0
+	for i, f := range s.Fields {
+		l := columns[i].Len(f.Type)
+		if i == 0 {
+			n = l
+		} else if l != n {
+			return 0, fmt.Errorf("arrow: column %s has %d rows, expected %d", f.Name, l, n)
+		}
+		if v := columns[i].Valid; v != nil {
+			if len(v) != n {
+				return 0, fmt.Errorf("arrow: column %s has %d validity flags, expected %d", f.Name, len(v), n)
+			}
+			if !f.Nullable {
+				for _, ok := range v {
+					if !ok {
+						return 0, fmt.Errorf("arrow: column %s is not nullable", f.Name)
+					}
+				}
+			}
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,544 @@
+package arrow
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files of testdata")
+
+var allSchema = Schema{
+	Fields: []Field{
+		{Name: "time", Type: TimestampNanos},
+		{Name: "int64", Type: Int64, Nullable: true},
+		{Name: "uint64", Type: Uint64, Nullable: true},
+		{Name: "float64", Type: Float64, Nullable: true},
+		{Name: "bool", Type: Bool, Nullable: true},
+		{Name: "utf8", Type: Utf8, Nullable: true},
+		{Name: "required", Type: Utf8},
+	},
+	Metadata: []KeyValue{{Key: "k0", Value: "v0"}, {Key: "k1", Value: ""}},
+}
+
+// allColumns returns the columns of a record of allSchema. Null values are
+// zero.
+func allColumns() []Column {
+	valid := []bool{true, false, true, true, false, true, true, true, true, false}
+	return []Column{
+		{Int64s: []int64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}},
+		{Valid: valid, Int64s: []int64{math.MinInt64, 0, -1, 0, 0, 1, math.MaxInt64, 2, 3, 0}},
+		{Valid: valid, Uint64s: []uint64{0, 0, 1, math.MaxUint64, 0, 2, 3, 4, 5, 0}},
+		{Valid: valid, Float64s: []float64{-1.5, 0, math.Inf(-1), 0, 0, 2.25, math.MaxFloat64, math.SmallestNonzeroFloat64, 1e100, 0}},
+		{Valid: valid, Bools: []bool{true, false, false, true, false, true, true, false, true, false}},
+		{Valid: valid, Strings: []string{"a", "", "", "é", "", "x y", "\n", "z", "日本", ""}},
+		{Strings: []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}},
+	}
+}
+
+// table reads a table of a FlatBuffer.
+type table struct {
+	buf []byte
+	pos int
+}
+
+// rootTable returns the root table of a FlatBuffer.
+func rootTable(buf []byte) table {
+	return table{buf: buf, pos: int(binary.LittleEndian.Uint32(buf))}
+}
+
+// field returns the position of field i of t, or 0 if it is not set.
+func (t table) field(i int) int {
+	vtable := t.pos - int(int32(binary.LittleEndian.Uint32(t.buf[t.pos:])))
+	if 4+2*i >= int(binary.LittleEndian.Uint16(t.buf[vtable:])) {
+		return 0
+	}
+	if off := int(binary.LittleEndian.Uint16(t.buf[vtable+4+2*i:])); off != 0 {
+		return t.pos + off
+	}
+	return 0
+}
+
+func (t table) uint8(i int) uint8 {
+	if p := t.field(i); p != 0 {
+		return t.buf[p]
+	}
+	return 0
+}
+
+func (t table) int16(i int) int16 {
+	if p := t.field(i); p != 0 {
+		return int16(binary.LittleEndian.Uint16(t.buf[p:]))
+	}
+	return 0
+}
+
+func (t table) int32(i int) int32 {
+	if p := t.field(i); p != 0 {
+		return int32(binary.LittleEndian.Uint32(t.buf[p:]))
+	}
+	return 0
+}
+
+func (t table) int64(i int) int64 {
+	if p := t.field(i); p != 0 {
+		return int64(binary.LittleEndian.Uint64(t.buf[p:]))
+	}
+	return 0
+}
+
+// deref returns the position of the object referenced by field i of t.
+func (t table) deref(i int) int {
+	p := t.field(i)
+	if p == 0 {
+		return 0
+	}
+	return p + int(binary.LittleEndian.Uint32(t.buf[p:]))
+}
+
+func (t table) table(i int) table { return table{buf: t.buf, pos: t.deref(i)} }
+
+func (t table) string(i int) string {
+	p := t.deref(i)
+	if p == 0 {
+		return ""
+	}
+	n := int(binary.LittleEndian.Uint32(t.buf[p:]))
+	return string(t.buf[p+4 : p+4+n])
+}
+
+// vector returns the position of the elements of the vector of field i of
+// t and their number.
+func (t table) vector(i int) (int, int) {
+	p := t.deref(i)
+	if p == 0 {
+		return 0, 0
+	}
+	return p + 4, int(binary.LittleEndian.Uint32(t.buf[p:]))
+}
+
+// tables returns the tables of the vector of field i of t.
+func (t table) tables(i int) []table {
+	p, n := t.vector(i)
+	tables := make([]table, n)
+	for j := range tables {
+		q := p + 4*j
+		tables[j] = table{buf: t.buf, pos: q + int(binary.LittleEndian.Uint32(t.buf[q:]))}
+	}
+	return tables
+}
+
+// readSchema decodes a Schema table.
+func readSchema(t table) (Schema, error) {
+	var s Schema
+	for _, f := range t.tables(1) {
+		field := Field{Name: f.string(0), Nullable: f.uint8(1) != 0}
+		typ := f.table(3)
+		switch f.uint8(2) {
+		case typeInt:
+			if typ.int32(0) != 64 {
+				return Schema{}, fmt.Errorf("unexpected bit width: %d", typ.int32(0))
+			}
+			field.Type = Uint64
+			if typ.uint8(1) != 0 {
+				field.Type = Int64
+			}
+		case typeFloatingPoint:
+			if typ.int16(0) != precisionDouble {
+				return Schema{}, fmt.Errorf("unexpected precision: %d", typ.int16(0))
+			}
+			field.Type = Float64
+		case typeBool:
+			field.Type = Bool
+		case typeUtf8:
+			field.Type = Utf8
+		case typeTimestamp:
+			if typ.int16(0) != unitNanosecond || typ.string(1) != "UTC" {
+				return Schema{}, fmt.Errorf("unexpected timestamp: %d, %s", typ.int16(0), typ.string(1))
+			}
+			field.Type = TimestampNanos
+		default:
+			return Schema{}, fmt.Errorf("unexpected type: %d", f.uint8(2))
+		}
+		s.Fields = append(s.Fields, field)
+	}
+	for _, kv := range t.tables(2) {
+		s.Metadata = append(s.Metadata, KeyValue{Key: kv.string(0), Value: kv.string(1)})
+	}
+	return s, nil
+}
+
+// readRecord decodes the columns of a RecordBatch table and its body.
+func readRecord(s Schema, t table, body []byte) ([]Column, error) {
+	n := int(t.int64(0))
+	nodes, numNodes := t.vector(1)
+	buffers, numBuffers := t.vector(2)
+	if numNodes != len(s.Fields) {
+		return nil, fmt.Errorf("%d nodes for %d fields", numNodes, len(s.Fields))
+	}
+
+	next := 0
+	buffer := func() []byte {
+		p := buffers + 16*next
+		next++
+		off := binary.LittleEndian.Uint64(t.buf[p:])
+		size := binary.LittleEndian.Uint64(t.buf[p+8:])
+		return body[off : off+size]
+	}
+	bit := func(b []byte, i int) bool { return b[i/8]&(1<<(uint(i)%8)) != 0 }
+
+	columns := make([]Column, len(s.Fields))
+	for i, f := range s.Fields {
+		c := &columns[i]
+		if l := int(binary.LittleEndian.Uint64(t.buf[nodes+16*i:])); l != n {
+			return nil, fmt.Errorf("field %s has %d rows, expected %d", f.Name, l, n)
+		}
+		validity := buffer()
+		if nulls := binary.LittleEndian.Uint64(t.buf[nodes+16*i+8:]); nulls > 0 {
+			c.Valid = make([]bool, n)
+			for j := range c.Valid {
+				c.Valid[j] = bit(validity, j)
+			}
+		}
+
+		data := buffer()
+		switch f.Type {
+		case Int64, TimestampNanos:
+			c.Int64s = make([]int64, n)
+			for j := range c.Int64s {
+				c.Int64s[j] = int64(binary.LittleEndian.Uint64(data[8*j:]))
+			}
+		case Uint64:
+			c.Uint64s = make([]uint64, n)
+			for j := range c.Uint64s {
+				c.Uint64s[j] = binary.LittleEndian.Uint64(data[8*j:])
+			}
+		case Float64:
+			c.Float64s = make([]float64, n)
+			for j := range c.Float64s {
+				c.Float64s[j] = math.Float64frombits(binary.LittleEndian.Uint64(data[8*j:]))
+			}
+		case Bool:
+			c.Bools = make([]bool, n)
+			for j := range c.Bools {
+				c.Bools[j] = bit(data, j)
+			}
+		case Utf8:
+			values := buffer()
+			c.Strings = make([]string, n)
+			for j := range c.Strings {
+				start := binary.LittleEndian.Uint32(data[4*j:])
+				end := binary.LittleEndian.Uint32(data[4*j+4:])
+				c.Strings[j] = string(values[start:end])
+			}
+		}
+	}
+	if next != numBuffers {
+		return nil, fmt.Errorf("%d buffers, read %d", numBuffers, next)
+	}
+	return columns, nil
+}
+
+// readMessage reads the encapsulated message at the start of buf, and
+// returns its Message table, its body and its size.
+func readMessage(buf []byte) (table, []byte, int, error) {
+	if len(buf) < 8 || binary.LittleEndian.Uint32(buf) != 0xffffffff {
+		return table{}, nil, 0, errors.New("missing continuation marker")
+	}
+	size := int(binary.LittleEndian.Uint32(buf[4:]))
+	if size%8 != 0 {
+		return table{}, nil, 0, fmt.Errorf("unaligned message of %d bytes", size)
+	} else if size == 0 {
+		return table{}, nil, 8, nil
+	}
+	msg := rootTable(buf[8 : 8+size])
+	if msg.int16(0) != metadataV5 {
+		return table{}, nil, 0, fmt.Errorf("unexpected version: %d", msg.int16(0))
+	}
+	bodyLength := int(msg.int64(3))
+	if bodyLength%8 != 0 {
+		return table{}, nil, 0, fmt.Errorf("unaligned body of %d bytes", bodyLength)
+	}
+	return msg, buf[8+size : 8+size+bodyLength], 8 + size + bodyLength, nil
+}
+
+// readStream reads the schema and the records of an IPC stream.
+func readStream(buf []byte) (Schema, [][]Column, error) {
+	var schema Schema
+	var records [][]Column
+	for pos := 0; ; {
+		msg, body, n, err := readMessage(buf[pos:])
+		if err != nil {
+			return Schema{}, nil, err
+		}
+		pos += n
+		if msg.buf == nil {
+			if pos != len(buf) {
+				return Schema{}, nil, fmt.Errorf("%d bytes after the end of the stream", len(buf)-pos)
+			}
+			return schema, records, nil
+		}
+
+		switch msg.uint8(1) {
+		case headerSchema:
+			if schema, err = readSchema(msg.table(2)); err != nil {
+				return Schema{}, nil, err
+			}
+		case headerRecordBatch:
+			columns, err := readRecord(schema, msg.table(2), body)
+			if err != nil {
+				return Schema{}, nil, err
+			}
+			records = append(records, columns)
+		default:
+			return Schema{}, nil, fmt.Errorf("unexpected message: %d", msg.uint8(1))
+		}
+	}
+}
+
+// readFile reads the schema and the records of an IPC file from the blocks
+// of its footer, and checks that they match the stream it holds.
+func readFile(buf []byte) (Schema, [][]Column, error) {
+	if len(buf) < 18 || !bytes.Equal(buf[:8], []byte("ARROW1\x00\x00")) || !bytes.HasSuffix(buf, fileMagic) {
+		return Schema{}, nil, errors.New("missing magic")
+	}
+	size := int(binary.LittleEndian.Uint32(buf[len(buf)-10:]))
+	footer := rootTable(buf[len(buf)-10-size : len(buf)-10])
+	schema, err := readSchema(footer.table(1))
+	if err != nil {
+		return Schema{}, nil, err
+	}
+
+	var records [][]Column
+	blocks, n := footer.vector(3)
+	for i := 0; i < n; i++ {
+		p := blocks + 24*i
+		offset := int(binary.LittleEndian.Uint64(footer.buf[p:]))
+		metaLength := int(binary.LittleEndian.Uint32(footer.buf[p+8:]))
+		bodyLength := int(binary.LittleEndian.Uint64(footer.buf[p+16:]))
+		msg, body, n, err := readMessage(buf[offset:])
+		if err != nil {
+			return Schema{}, nil, err
+		} else if n != metaLength+bodyLength || msg.uint8(1) != headerRecordBatch {
+			return Schema{}, nil, fmt.Errorf("invalid block %d", i)
+		}
+		columns, err := readRecord(schema, msg.table(2), body)
+		if err != nil {
+			return Schema{}, nil, err
+		}
+		records = append(records, columns)
+	}
+
+	// Some writers, like the Go implementation of Apache Arrow, omit the
+	// end-of-stream marker before the footer.
+	stream := buf[8 : len(buf)-10-size]
+	if !bytes.HasSuffix(stream, []byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0}) {
+		stream = append(stream[:len(stream):len(stream)], 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0)
+	}
+	streamSchema, streamRecords, err := readStream(stream)
+	if err != nil {
+		return Schema{}, nil, err
+	} else if !reflect.DeepEqual(streamSchema, schema) || !reflect.DeepEqual(streamRecords, records) {
+		return Schema{}, nil, errors.New("the footer does not match the stream")
+	}
+	return schema, records, nil
+}
+
+// write writes records of allSchema to a stream or a file.
+func write(t *testing.T, file bool, records ...[]Column) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := NewStreamWriter(&buf, allSchema)
+	if file {
+		w = NewFileWriter(&buf, allSchema)
+	}
+	for _, columns := range records {
+		if err := w.Write(columns); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return buf.Bytes()
+}
+
+// read reads a stream or a file.
+func read(t *testing.T, file bool, buf []byte) (Schema, [][]Column) {
+	t.Helper()
+	read := readStream
+	if file {
+		read = readFile
+	}
+	s, records, err := read(buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return s, records
+}
+
+func TestWriter_RoundTrip(t *testing.T) {
+	for _, file := range []bool{false, true} {
+		columns := allColumns()
+		s, records := read(t, file, write(t, file, columns, columns))
+		if !reflect.DeepEqual(s, allSchema) {
+			t.Fatalf("unexpected schema: %+v", s)
+		} else if !reflect.DeepEqual(records, [][]Column{columns, columns}) {
+			t.Fatalf("unexpected records:\ngot=%+v\nexp=%+v", records, columns)
+		}
+	}
+}
+
+func TestWriter_Nulls(t *testing.T) {
+	n := 20
+	nulls := make([]Column, len(allSchema.Fields))
+	for i, f := range allSchema.Fields {
+		c := &nulls[i]
+		if f.Nullable {
+			c.Valid = make([]bool, n)
+			for j := n / 2; j < n; j++ {
+				c.Valid[j] = j%3 == 0
+			}
+		}
+		switch f.Type {
+		case Int64, TimestampNanos:
+			c.Int64s = make([]int64, n)
+		case Uint64:
+			c.Uint64s = make([]uint64, n)
+		case Float64:
+			c.Float64s = make([]float64, n)
+		case Bool:
+			c.Bools = make([]bool, n)
+		case Utf8:
+			c.Strings = make([]string, n)
+		}
+	}
+
+	for _, file := range []bool{false, true} {
+		_, records := read(t, file, write(t, file, nulls))
+		if !reflect.DeepEqual(records, [][]Column{nulls}) {
+			t.Fatalf("unexpected records:\ngot=%+v\nexp=%+v", records, nulls)
+		}
+	}
+}
+
+func TestWriter_Empty(t *testing.T) {
+	for _, file := range []bool{false, true} {
+		s, records := read(t, file, write(t, file))
+		if !reflect.DeepEqual(s, allSchema) {
+			t.Fatalf("unexpected schema: %+v", s)
+		} else if len(records) != 0 {
+			t.Fatalf("unexpected records: %+v", records)
+		}
+	}
+}
+
+func TestWriter_Errors(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		columns func([]Column) []Column
+		err     string
+	}{
+		{
+			name:    "missing column",
+			columns: func(c []Column) []Column { return c[:len(c)-1] },
+			err:     "arrow: 7 fields, got 6 columns",
+		},
+		{
+			name:    "short column",
+			columns: func(c []Column) []Column { c[1].Int64s = c[1].Int64s[:9]; return c },
+			err:     "arrow: column int64 has 9 rows, expected 10",
+		},
+		{
+			name:    "short validity",
+			columns: func(c []Column) []Column { c[1].Valid = c[1].Valid[:9]; return c },
+			err:     "arrow: column int64 has 9 validity flags, expected 10",
+		},
+		{
+			name:    "null required",
+			columns: func(c []Column) []Column { c[6].Valid = make([]bool, 10); return c },
+			err:     "arrow: column required is not nullable",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewStreamWriter(ioutil.Discard, allSchema)
+			if err := w.Write(tt.columns(allColumns())); err == nil || err.Error() != tt.err {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+
+	w := NewFileWriter(ioutil.Discard, allSchema)
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if err := w.Write(allColumns()); err == nil || err.Error() != "arrow: writer closed" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// TestWriter_Golden compares the streams and files written with files of
+// testdata. The files are checked in so that changes of the output are
+// reviewed, and are rewritten with the -update flag.
+func TestWriter_Golden(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		file bool
+	}{
+		{name: "stream.arrows", file: false},
+		{name: "file.arrow", file: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := write(t, tt.file, allColumns())
+
+			path := filepath.Join("testdata", tt.name)
+			if *update {
+				if err := ioutil.WriteFile(path, buf, 0666); err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+			}
+			golden, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			} else if !bytes.Equal(buf, golden) {
+				t.Fatalf("output differs from %s", path)
+			}
+
+			if _, records := read(t, tt.file, golden); !reflect.DeepEqual(records, [][]Column{allColumns()}) {
+				t.Fatalf("unexpected records: %+v", records)
+			}
+		})
+	}
+}
+
+// TestRead_ArrowGo reads the streams and files of testdata written by the
+// Go implementation of Apache Arrow, so that the readers of the tests, and
+// so the writers they check, agree with a reference implementation. The
+// files are rewritten by the tests of tests/reference.
+func TestRead_ArrowGo(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		file bool
+	}{
+		{name: "arrow-go.arrows", file: false},
+		{name: "arrow-go.arrow", file: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			buf, err := ioutil.ReadFile(filepath.Join("testdata", tt.name))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if s, records := read(t, tt.file, buf); !reflect.DeepEqual(s, allSchema) {
+				t.Fatalf("unexpected schema: %+v", s)
+			} else if !reflect.DeepEqual(records, [][]Column{allColumns()}) {
+				t.Fatalf("unexpected records:\ngot=%+v\nexp=%+v", records, allColumns())
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package arrow
+
+import (
+	"encoding/binary"
+)
+
+// builder builds a FlatBuffer back to front, the encoding of the Arrow IPC
+// metadata. Offsets are relative to the end of the buffer.
+type builder struct {
+	buf       []byte
+	head      int
+	minalign  int
+	vtable    []int
+	objectEnd int
+}
+
+func newBuilder(size int) *builder {
+	return &builder{buf: make([]byte, size), head: size, minalign: 1}
+}
+
+// offset returns the offset of the last object written.
+func (b *builder) offset() int { return len(b.buf) - b.head }
+
+func (b *builder) grow() {
+	n := len(b.buf) * 2
+	if n == 0 {
+		n = 64
+	}
+	buf := make([]byte, n)
+	copy(buf[n-len(b.buf):], b.buf)
+	b.head += n - len(b.buf)
+	b.buf = buf
+}
+
+func (b *builder) pad(n int) {
+	for i := 0; i < n; i++ {
+		b.head--
+		b.buf[b.head] = 0
+	}
+}
+
+// prep aligns the buffer to size bytes after additional bytes are written.
+func (b *builder) prep(size, additional int) {
+	if size > b.minalign {
+		b.minalign = size
+	}
+	align := (^(len(b.buf) - b.head + additional) + 1) & (size - 1)
+	for b.head <= align+size+additional {
+		b.grow()
+	}
+	b.pad(align)
+}
+
+func (b *builder) placeUint8(v uint8) {
+	b.head--
+	b.buf[b.head] = v
+}
+
+func (b *builder) placeUint16(v uint16) {
+	b.head -= 2
+	binary.LittleEndian.PutUint16(b.buf[b.head:], v)
+}
+
+func (b *builder) placeUint32(v uint32) {
+	b.head -= 4
+	binary.LittleEndian.PutUint32(b.buf[b.head:], v)
+}
+
+func (b *builder) placeUint64(v uint64) {
+	b.head -= 8
+	binary.LittleEndian.PutUint64(b.buf[b.head:], v)
+}
+
+func (b *builder) prependUint8(v uint8)   { b.prep(1, 0); b.placeUint8(v) }
+func (b *builder) prependUint16(v uint16) { b.prep(2, 0); b.placeUint16(v) }
+func (b *builder) prependUint32(v uint32) { b.prep(4, 0); b.placeUint32(v) }
+func (b *builder) prependUint64(v uint64) { b.prep(8, 0); b.placeUint64(v) }
+
+// prependOffset writes a reference to the object at off.
+func (b *builder) prependOffset(off int) {
+	b.prep(4, 0)
+	b.placeUint32(uint32(b.offset() - off + 4))
+}
+
+func (b *builder) createString(s string) int {
+	b.prep(4, len(s)+1)
+	b.placeUint8(0)
+	b.head -= len(s)
+	copy(b.buf[b.head:], s)
+	b.placeUint32(uint32(len(s)))
+	return b.offset()
+}
+
+// startVector begins a vector of n elements of size bytes, which are then
+// prepended in reverse order.
+func (b *builder) startVector(size, n, align int) {
+	b.prep(4, size*n)
+	b.prep(align, size*n)
+}
+
+func (b *builder) endVector(n int) int {
+	b.placeUint32(uint32(n))
+	return b.offset()
+}
+
+// createOffsetVector writes a vector of references to the objects at offs.
+func (b *builder) createOffsetVector(offs []int) int {
+	b.startVector(4, len(offs), 4)
+	for i := len(offs) - 1; i >= 0; i-- {
+		b.prependOffset(offs[i])
+	}
+	return b.endVector(len(offs))
+}
+
+// startTable begins a table of n fields.
+func (b *builder) startTable(n int) {
+	b.vtable = make([]int, n)
+	b.objectEnd = b.offset()
+}
+
+func (b *builder) slot(i int) { b.vtable[i] = b.offset() }
+
+func (b *builder) addBool(i int, v bool) {
+	if v {
+		b.prependUint8(1)
+		b.slot(i)
+	}
+}
+
+func (b *builder) addUint8(i int, v uint8) {
+	if v != 0 {
+		b.prependUint8(v)
+		b.slot(i)
+	}
+}
+
+func (b *builder) addInt16(i int, v int16) {
+	if v != 0 {
+		b.prependUint16(uint16(v))
+		b.slot(i)
+	}
+}
+
+func (b *builder) addInt32(i int, v int32) {
+	if v != 0 {
+		b.prependUint32(uint32(v))
+		b.slot(i)
+	}
+}
+
+func (b *builder) addInt64(i int, v int64) {
+	if v != 0 {
+		b.prependUint64(uint64(v))
+		b.slot(i)
+	}
+}
+
+func (b *builder) addOffset(i int, off int) {
+	b.prependOffset(off)
+	b.slot(i)
+}
+
+// endTable writes the vtable of the table and returns its offset.
+func (b *builder) endTable() int {
+	b.prependUint32(0) // replaced by the offset of the vtable
+	object := b.offset()
+
+	n := len(b.vtable)
+	for n > 0 && b.vtable[n-1] == 0 {
+		n--
+	}
+	for i := n - 1; i >= 0; i-- {
+		var off uint16
+		if b.vtable[i] != 0 {
+			off = uint16(object - b.vtable[i])
+		}
+		b.prependUint16(off)
+	}
+	b.prependUint16(uint16(object - b.objectEnd))
+	b.prependUint16(uint16((n + 2) * 2))
+
+	pos := len(b.buf) - object
+	binary.LittleEndian.PutUint32(b.buf[pos:], uint32(int32(b.offset()-object)))
+	b.vtable = nil
+	return object
+}
+
+// finish writes the reference to the root table and returns the buffer.
+func (b *builder) finish(root int) []byte {
+	b.prep(b.minalign, 4)
+	b.prependOffset(root)
+	return b.buf[b.head:]
+}
@@ -0,0 +1,353 @@
+package arrow
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+// The values of the Arrow format enumerations and unions.
+const (
+	metadataV5 = 4
+
+	headerSchema      = 1
+	headerRecordBatch = 3
+
+	typeInt           = 2
+	typeFloatingPoint = 3
+	typeUtf8          = 5
+	typeBool          = 6
+	typeTimestamp     = 10
+
+	precisionDouble = 2
+	unitNanosecond  = 3
+)
+
+var fileMagic = []byte("ARROW1")
+
+// ContentType is the media type of Arrow IPC streams.
+const ContentType = "application/vnd.apache.arrow.stream"
+
+// Writer writes records in the Arrow IPC stream or file format.
+type Writer struct {
+	w      io.Writer
+	pos    int64
+	schema Schema
+	file   bool
+	err    error
+
+	started bool
+	closed  bool
+	blocks  []block
+}
+
+// block locates a record batch in a file.
+type block struct {
+	offset     int64
+	metaLength int32
+	bodyLength int64
+}
+
+// NewStreamWriter returns a writer of an IPC stream of records with the
+// given schema.
+func NewStreamWriter(w io.Writer, schema Schema) *Writer {
+	return &Writer{w: w, schema: schema}
+}
+
+// NewFileWriter returns a writer of an IPC file of records with the given
+// schema. Files support random access to their records.
+func NewFileWriter(w io.Writer, schema Schema) *Writer {
+	return &Writer{w: w, schema: schema, file: true}
+}
+
+// Schema returns the schema of the records.
+func (w *Writer) Schema() Schema { return w.schema }
+
+func (w *Writer) write(buf []byte) error {
+	if w.err != nil {
+		return w.err
+	}
+	n, err := w.w.Write(buf)
+	w.pos += int64(n)
+	w.err = err
+	return err
+}
+
+func (w *Writer) start() error {
+	if w.started {
+		return w.err
+	}
+	w.started = true
+	if w.file {
+		if err := w.write(append(fileMagic[:len(fileMagic):len(fileMagic)], 0, 0)); err != nil {
+			return err
+		}
+	}
+	b := newBuilder(1024)
+	schema := w.buildSchema(b)
+	_, err := w.writeMessage(w.message(b, headerSchema, schema, 0), nil)
+	return err
+}
+
+// Write writes a record made of a column per field of the schema.
+func (w *Writer) Write(columns []Column) error {
+	if w.closed {
+		return errors.New("arrow: writer closed")
+	}
+	n, err := w.schema.validate(columns)
+	if err != nil {
+		return err
+	}
+	if err := w.start(); err != nil {
+		return err
+	}
+
+	var (
+		body    []byte
+		nodes   [][2]int64
+		buffers [][2]int64
+	)
+	appendBuffer := func(buf []byte) {
+		buffers = append(buffers, [2]int64{int64(len(body)), int64(len(buf))})
+		body = append(body, buf...)
+		for len(body)%8 != 0 {
+			body = append(body, 0)
+		}
+	}
+
+	for i, f := range w.schema.Fields {
+		c := &columns[i]
+		nulls := 0
+		for j := 0; j < n; j++ {
+			if c.IsNull(j) {
+				nulls++
+			}
+		}
+		nodes = append(nodes, [2]int64{int64(n), int64(nulls)})
+
+		if nulls > 0 {
+			bitmap := make([]byte, (n+7)/8)
+			for j := 0; j < n; j++ {
+				if !c.IsNull(j) {
+					bitmap[j/8] |= 1 << (uint(j) % 8)
+				}
+			}
+			appendBuffer(bitmap)
+		} else {
+			appendBuffer(nil)
+		}
+
+		switch f.Type {
+		case Int64, TimestampNanos:
+			buf := make([]byte, 8*n)
+			for j, v := range c.Int64s {
+				binary.LittleEndian.PutUint64(buf[8*j:], uint64(v))
+			}
+			appendBuffer(buf)
+		case Uint64:
+			buf := make([]byte, 8*n)
+			for j, v := range c.Uint64s {
+				binary.LittleEndian.PutUint64(buf[8*j:], v)
+			}
+			appendBuffer(buf)
+		case Float64:
+			buf := make([]byte, 8*n)
+			for j, v := range c.Float64s {
+				binary.LittleEndian.PutUint64(buf[8*j:], math.Float64bits(v))
+			}
+			appendBuffer(buf)
+		case Bool:
+			buf := make([]byte, (n+7)/8)
+			for j, v := range c.Bools {
+				if v {
+					buf[j/8] |= 1 << (uint(j) % 8)
+				}
+			}
+			appendBuffer(buf)
+		case Utf8:
+			offsets := make([]byte, 4*(n+1))
+			var data []byte
+			for j, v := range c.Strings {
+				if !c.IsNull(j) {
+					data = append(data, v...)
+				}
+				if len(data) > math.MaxInt32 {
+					return errors.New("arrow: record too large")
+				}
+				binary.LittleEndian.PutUint32(offsets[4*(j+1):], uint32(len(data)))
+			}
+			appendBuffer(offsets)
+			appendBuffer(data)
+		}
+	}
+
+	b := newBuilder(1024)
+	b.startVector(16, len(buffers), 8)
+	for i := len(buffers) - 1; i >= 0; i-- {
+		b.prep(8, 16)
+		b.placeUint64(uint64(buffers[i][1]))
+		b.placeUint64(uint64(buffers[i][0]))
+	}
+	buffersVec := b.endVector(len(buffers))
+	b.startVector(16, len(nodes), 8)
+	for i := len(nodes) - 1; i >= 0; i-- {
+		b.prep(8, 16)
+		b.placeUint64(uint64(nodes[i][1]))
+		b.placeUint64(uint64(nodes[i][0]))
+	}
+	nodesVec := b.endVector(len(nodes))
+
+	b.startTable(4)
+	b.addInt64(0, int64(n))
+	b.addOffset(1, nodesVec)
+	b.addOffset(2, buffersVec)
+	batch := b.endTable()
+
+	blk, err := w.writeMessage(w.message(b, headerRecordBatch, batch, int64(len(body))), body)
+	if err != nil {
+		return err
+	}
+	w.blocks = append(w.blocks, blk)
+	return nil
+}
+
+// Close writes the end of the stream, and the footer of a file. It does not
+// close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return w.err
+	}
+	if err := w.start(); err != nil {
+		return err
+	}
+	w.closed = true
+
+	// End of stream marker.
+	if err := w.write([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0}); err != nil {
+		return err
+	}
+	if !w.file {
+		return nil
+	}
+
+	b := newBuilder(1024)
+	schema := w.buildSchema(b)
+	b.startVector(24, len(w.blocks), 8)
+	for i := len(w.blocks) - 1; i >= 0; i-- {
+		blk := w.blocks[i]
+		b.prep(8, 24)
+		b.placeUint64(uint64(blk.bodyLength))
+		b.pad(4)
+		b.placeUint32(uint32(blk.metaLength))
+		b.placeUint64(uint64(blk.offset))
+	}
+	blocks := b.endVector(len(w.blocks))
+	b.startVector(24, 0, 8)
+	dictionaries := b.endVector(0)
+
+	b.startTable(5)
+	b.addInt16(0, metadataV5)
+	b.addOffset(1, schema)
+	b.addOffset(2, dictionaries)
+	b.addOffset(3, blocks)
+	footer := b.finish(b.endTable())
+
+	buf := make([]byte, 0, len(footer)+4+len(fileMagic))
+	buf = append(buf, footer...)
+	buf = append(buf, 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(buf[len(footer):], uint32(len(footer)))
+	buf = append(buf, fileMagic...)
+	return w.write(buf)
+}
+
+// buildSchema writes the Schema table of w to b.
+func (w *Writer) buildSchema(b *builder) int {
+	fields := make([]int, len(w.schema.Fields))
+	for i, f := range w.schema.Fields {
+		name := b.createString(f.Name)
+
+		var typ int
+		var typeType uint8
+		switch f.Type {
+		case Int64, Uint64:
+			b.startTable(2)
+			b.addInt32(0, 64)
+			b.addBool(1, f.Type == Int64)
+			typ, typeType = b.endTable(), typeInt
+		case Float64:
+			b.startTable(1)
+			b.addInt16(0, precisionDouble)
+			typ, typeType = b.endTable(), typeFloatingPoint
+		case Bool:
+			b.startTable(0)
+			typ, typeType = b.endTable(), typeBool
+		case Utf8:
+			b.startTable(0)
+			typ, typeType = b.endTable(), typeUtf8
+		case TimestampNanos:
+			tz := b.createString("UTC")
+			b.startTable(2)
+			b.addInt16(0, unitNanosecond)
+			b.addOffset(1, tz)
+			typ, typeType = b.endTable(), typeTimestamp
+		}
+		children := b.createOffsetVector(nil)
+
+		b.startTable(7)
+		b.addOffset(0, name)
+		b.addBool(1, f.Nullable)
+		b.addUint8(2, typeType)
+		b.addOffset(3, typ)
+		b.addOffset(5, children)
+		fields[i] = b.endTable()
+	}
+	fieldsVec := b.createOffsetVector(fields)
+
+	var metadata int
+	if len(w.schema.Metadata) > 0 {
+		kvs := make([]int, len(w.schema.Metadata))
+		for i, kv := range w.schema.Metadata {
+			k, v := b.createString(kv.Key), b.createString(kv.Value)
+			b.startTable(2)
+			b.addOffset(0, k)
+			b.addOffset(1, v)
+			kvs[i] = b.endTable()
+		}
+		metadata = b.createOffsetVector(kvs)
+	}
+
+	b.startTable(4)
+	b.addOffset(1, fieldsVec)
+	if metadata != 0 {
+		b.addOffset(2, metadata)
+	}
+	return b.endTable()
+}
+
+// message finishes a Message table with the given header in b.
+func (w *Writer) message(b *builder, headerType uint8, header int, bodyLength int64) []byte {
+	b.startTable(5)
+	b.addInt16(0, metadataV5)
+	b.addUint8(1, headerType)
+	b.addOffset(2, header)
+	b.addInt64(3, bodyLength)
+	return b.finish(b.endTable())
+}
+
+// writeMessage writes an encapsulated message and its body.
+func (w *Writer) writeMessage(meta, body []byte) (block, error) {
+	blk := block{offset: w.pos, bodyLength: int64(len(body))}
+
+	size := (len(meta) + 7) &^ 7
+	buf := make([]byte, 8+size)
+	binary.LittleEndian.PutUint32(buf, 0xffffffff)
+	binary.LittleEndian.PutUint32(buf[4:], uint32(size))
+	copy(buf[8:], meta)
+	blk.metaLength = int32(len(buf))
+
+	if err := w.write(buf); err != nil {
+		return block{}, err
+	}
+	return blk, w.write(body)
+}
@@ -0,0 +1,239 @@
+// Package columnar converts the points of a measurement to and from columnar
+// files, with a column for the time, each tag and each field.
+//
+// Files are written in the Parquet or the Arrow IPC file format. The keys of
+// the metadata of the files record the measurement and which columns are
+// tags and fields, so that files can be read back into shards.
+package columnar
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+)
+
+// Format is the format of a columnar file.
+type Format string
+
+const (
+	Parquet Format = "parquet"
+	Arrow   Format = "arrow"
+)
+
+// ParseFormat returns the format named s.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case Parquet, Arrow:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown columnar format %q", s)
+}
+
+// Ext returns the file name extension of the format.
+func (f Format) Ext() string { return "." + string(f) }
+
+// FileName returns the name of the file of measurement in format f.
+func FileName(measurement string, f Format) string {
+	return url.PathEscape(measurement) + f.Ext()
+}
+
+// The keys of the metadata of the files.
+const (
+	MetaMeasurement = "cnosdb.measurement"
+	// MetaTags is a JSON array of the tag keys of the tag columns.
+	MetaTags = "cnosdb.tags"
+	// MetaFields is a JSON array of the field keys of the field columns.
+	MetaFields = "cnosdb.fields"
+)
+
+// TimeColumn is the name of the time column, the first column of files.
+const TimeColumn = "time"
+
+// Field is a field of a measurement.
+type Field struct {
+	Key  string
+	Type cnosql.DataType
+}
+
+// Schema describes the columns of a measurement: the time, then a column per
+// tag key, then a column per field.
+type Schema struct {
+	Measurement string
+	Tags        []string
+	Fields      []Field
+}
+
+// Columns returns the names of the columns of s. Fields take the name of
+// their key, suffixed with a number if a tag or the time has it already.
+func (s *Schema) Columns() []string {
+	names := make([]string, 0, 1+len(s.Tags)+len(s.Fields))
+	used := map[string]bool{TimeColumn: true}
+	names = append(names, TimeColumn)
+	for _, k := range s.Tags {
+		name := k
+		for i := 1; used[name]; i++ {
+			name = k + "_" + strconv.Itoa(i)
+		}
+		used[name] = true
+		names = append(names, name)
+	}
+	for _, f := range s.Fields {
+		name := f.Key
+		for i := 1; used[name]; i++ {
+			name = f.Key + "_" + strconv.Itoa(i)
+		}
+		used[name] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// Batch holds rows of a measurement in columns.
+type Batch struct {
+	schema *Schema
+
+	// Time holds the time of the rows in nanoseconds since the epoch.
+	Time []int64
+	// Tags holds the values of each tag for the rows. Rows without the tag
+	// have an empty value.
+	Tags   [][]string
+	fields []fieldColumn
+}
+
+// fieldColumn holds the values of a field. Only the slice of the type of the
+// field is used.
+type fieldColumn struct {
+	valid    []bool
+	floats   []float64
+	integers []int64
+	unsigned []uint64
+	booleans []bool
+	strings  []string
+}
+
+// NewBatch returns an empty batch of rows of the given schema.
+func NewBatch(s *Schema) *Batch {
+	return &Batch{
+		schema: s,
+		Tags:   make([][]string, len(s.Tags)),
+		fields: make([]fieldColumn, len(s.Fields)),
+	}
+}
+
+// Schema returns the schema of the rows of b.
+func (b *Batch) Schema() *Schema { return b.schema }
+
+// Len returns the number of rows of b.
+func (b *Batch) Len() int { return len(b.Time) }
+
+// Reset removes the rows of b.
+func (b *Batch) Reset() {
+	b.Time = b.Time[:0]
+	for i := range b.Tags {
+		b.Tags[i] = b.Tags[i][:0]
+	}
+	for i := range b.fields {
+		c := &b.fields[i]
+		c.valid = c.valid[:0]
+		c.floats, c.integers, c.unsigned = c.floats[:0], c.integers[:0], c.unsigned[:0]
+		c.booleans, c.strings = c.booleans[:0], c.strings[:0]
+	}
+}
+
+// Append appends a row to b. tags and fields hold a value for each tag and
+// field of the schema, a nil field value being null. Integers are accepted
+// for float fields and any value for string fields.
+func (b *Batch) Append(t int64, tags []string, fields []interface{}) error {
+	if len(tags) != len(b.schema.Tags) || len(fields) != len(b.schema.Fields) {
+		return fmt.Errorf("row of %d tags and %d fields, expected %d and %d",
+			len(tags), len(fields), len(b.schema.Tags), len(b.schema.Fields))
+	}
+	for i, f := range b.schema.Fields {
+		if v := fields[i]; v != nil && !assignable(f.Type, v) {
+			return fmt.Errorf("field %s: %T value for a %s field", f.Key, v, f.Type)
+		}
+	}
+
+	b.Time = append(b.Time, t)
+	for i, v := range tags {
+		b.Tags[i] = append(b.Tags[i], v)
+	}
+	for i, f := range b.schema.Fields {
+		b.fields[i].append(f.Type, fields[i])
+	}
+	return nil
+}
+
+func assignable(t cnosql.DataType, v interface{}) bool {
+	switch v.(type) {
+	case float64:
+		return t == cnosql.Float || t == cnosql.String
+	case int64:
+		return t == cnosql.Integer || t == cnosql.Float || t == cnosql.String
+	case uint64:
+		return t == cnosql.Unsigned || t == cnosql.Float || t == cnosql.String
+	case bool:
+		return t == cnosql.Boolean || t == cnosql.String
+	}
+	return t == cnosql.String
+}
+
+func (c *fieldColumn) append(t cnosql.DataType, v interface{}) {
+	c.valid = append(c.valid, v != nil)
+	switch t {
+	case cnosql.Float:
+		var f float64
+		switch v := v.(type) {
+		case float64:
+			f = v
+		case int64:
+			f = float64(v)
+		case uint64:
+			f = float64(v)
+		}
+		c.floats = append(c.floats, f)
+	case cnosql.Integer:
+		i, _ := v.(int64)
+		c.integers = append(c.integers, i)
+	case cnosql.Unsigned:
+		u, _ := v.(uint64)
+		c.unsigned = append(c.unsigned, u)
+	case cnosql.Boolean:
+		b, _ := v.(bool)
+		c.booleans = append(c.booleans, b)
+	default:
+		var s string
+		switch v := v.(type) {
+		case nil:
+		case string:
+			s = v
+		case float64:
+			s = strconv.FormatFloat(v, 'g', -1, 64)
+		default:
+			s = fmt.Sprint(v)
+		}
+		c.strings = append(c.strings, s)
+	}
+}
+
+// Field returns the value of field i of row j of b, or nil if it is null.
+func (b *Batch) Field(i, j int) interface{} {
+	c := &b.fields[i]
+	if !c.valid[j] {
+		return nil
+	}
+	switch b.schema.Fields[i].Type {
+	case cnosql.Float:
+		return c.floats[j]
+	case cnosql.Integer:
+		return c.integers[j]
+	case cnosql.Unsigned:
+		return c.unsigned[j]
+	case cnosql.Boolean:
+		return c.booleans[j]
+	}
+	return c.strings[j]
+}
@@ -0,0 +1,353 @@
+package columnar_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cnosdb/cnosdb/pkg/arrow"
+	"github.com/cnosdb/cnosdb/pkg/columnar"
+	"github.com/cnosdb/cnosdb/pkg/parquet"
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+	"github.com/cnosdb/cnosdb/vend/db/models"
+)
+
+// allSchema has a tag and a field of each type.
+var allSchema = &columnar.Schema{
+	Measurement: "cpu/0",
+	Tags:        []string{"host", "region"},
+	Fields: []columnar.Field{
+		{Key: "float", Type: cnosql.Float},
+		{Key: "integer", Type: cnosql.Integer},
+		{Key: "unsigned", Type: cnosql.Unsigned},
+		{Key: "boolean", Type: cnosql.Boolean},
+		{Key: "string", Type: cnosql.String},
+	},
+}
+
+type row struct {
+	time   int64
+	tags   []string
+	fields []interface{}
+}
+
+// allRows are rows of allSchema with nulls of each field and rows without
+// a tag.
+var allRows = []row{
+	{0, []string{"a", "east"}, []interface{}{1.5, int64(-1), uint64(1), true, "x"}},
+	{1, []string{"a", ""}, []interface{}{nil, int64(0), uint64(1 << 63), false, ""}},
+	{2, []string{"", "west"}, []interface{}{-0.25, nil, uint64(0), nil, "é"}},
+	{3, []string{"", ""}, []interface{}{nil, nil, nil, nil, nil}},
+	{-4, []string{"b", "east"}, []interface{}{1e300, int64(1) << 62, nil, true, nil}},
+}
+
+func newBatch(t *testing.T, s *columnar.Schema, rows []row) *columnar.Batch {
+	t.Helper()
+	b := columnar.NewBatch(s)
+	for _, r := range rows {
+		if err := b.Append(r.time, r.tags, r.fields); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	return b
+}
+
+// batchRows returns the rows of b.
+func batchRows(b *columnar.Batch) []row {
+	var rows []row
+	for j := 0; j < b.Len(); j++ {
+		r := row{time: b.Time[j]}
+		for i := range b.Tags {
+			r.tags = append(r.tags, b.Tags[i][j])
+		}
+		for i := range b.Schema().Fields {
+			r.fields = append(r.fields, b.Field(i, j))
+		}
+		rows = append(rows, r)
+	}
+	return rows
+}
+
+// readParquet reads the rows of a Parquet file.
+func readParquet(t *testing.T, buf []byte, name string) (*columnar.Reader, []row) {
+	t.Helper()
+	r, err := columnar.NewReader(bytes.NewReader(buf), int64(len(buf)), name)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var rows []row
+	for i := 0; i < r.NumRowGroups(); i++ {
+		b, err := r.ReadRowGroup(i)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		rows = append(rows, batchRows(b)...)
+	}
+	return r, rows
+}
+
+func TestParseFormat(t *testing.T) {
+	for s, exp := range map[string]columnar.Format{"parquet": columnar.Parquet, "Arrow": columnar.Arrow} {
+		if f, err := columnar.ParseFormat(s); err != nil || f != exp {
+			t.Fatalf("unexpected format of %q: %q, %v", s, f, err)
+		}
+	}
+	if _, err := columnar.ParseFormat("csv"); err == nil || err.Error() != `unknown columnar format "csv"` {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if name := columnar.FileName("cpu/0", columnar.Parquet); name != "cpu%2F0.parquet" {
+		t.Fatalf("unexpected file name: %s", name)
+	}
+}
+
+func TestSchema_Columns(t *testing.T) {
+	s := &columnar.Schema{
+		Tags:   []string{"host", "time"},
+		Fields: []columnar.Field{{Key: "value"}, {Key: "host"}, {Key: "time"}, {Key: "time_1"}},
+	}
+	exp := []string{"time", "host", "time_1", "value", "host_1", "time_2", "time_1_1"}
+	if got := s.Columns(); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected columns: %v", got)
+	}
+}
+
+func TestBatch_Append(t *testing.T) {
+	b := columnar.NewBatch(allSchema)
+	if err := b.Append(0, []string{"a"}, make([]interface{}, 5)); err == nil || err.Error() != "row of 1 tags and 5 fields, expected 2 and 5" {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := b.Append(0, []string{"a", "b"}, []interface{}{"1", nil, nil, nil, nil}); err == nil || err.Error() != "field float: string value for a float field" {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := b.Append(0, []string{"a", "b"}, []interface{}{nil, 1.5, nil, nil, nil}); err == nil || err.Error() != "field integer: float64 value for a integer field" {
+		t.Fatalf("unexpected error: %v", err)
+	} else if b.Len() != 0 {
+		t.Fatalf("unexpected rows: %d", b.Len())
+	}
+
+	// Numbers are converted to floats, and any value to strings.
+	if err := b.Append(0, []string{"a", "b"}, []interface{}{int64(2), nil, nil, nil, true}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if err := b.Append(1, []string{"a", "b"}, []interface{}{uint64(3), nil, nil, nil, 0.5}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	exp := []row{
+		{0, []string{"a", "b"}, []interface{}{2.0, nil, nil, nil, "true"}},
+		{1, []string{"a", "b"}, []interface{}{3.0, nil, nil, nil, "0.5"}},
+	}
+	if got := batchRows(b); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected rows:\ngot=%v\nexp=%v", got, exp)
+	}
+
+	b.Reset()
+	if b.Len() != 0 || len(batchRows(b)) != 0 {
+		t.Fatalf("unexpected rows after reset: %d", b.Len())
+	}
+}
+
+func TestParquet_RoundTrip(t *testing.T) {
+	for _, codec := range []parquet.Compression{parquet.Uncompressed, parquet.Snappy, parquet.Gzip, parquet.Zstd} {
+		var buf bytes.Buffer
+		w := columnar.NewParquetWriter(&buf, allSchema, codec)
+		if err := w.Write(newBatch(t, allSchema, allRows)); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		} else if err := w.Write(newBatch(t, allSchema, allRows[:1])); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		} else if err := w.Close(); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		r, rows := readParquet(t, buf.Bytes(), "ignored")
+		if !reflect.DeepEqual(r.Schema(), allSchema) {
+			t.Fatalf("%d: unexpected schema: %+v", codec, r.Schema())
+		} else if exp := append(allRows[:len(allRows):len(allRows)], allRows[0]); !reflect.DeepEqual(rows, exp) {
+			t.Fatalf("%d: unexpected rows:\ngot=%v\nexp=%v", codec, rows, exp)
+		}
+
+		if min, max, err := r.TimeRange(0); err != nil || min != -4 || max != 3 {
+			t.Fatalf("%d: unexpected time range: %d, %d, %v", codec, min, max, err)
+		} else if times, err := r.Times(1); err != nil || !reflect.DeepEqual(times, []int64{0}) {
+			t.Fatalf("%d: unexpected times: %v, %v", codec, times, err)
+		}
+	}
+}
+
+// TestReader_ArrowGo reads a file of testdata written by the Go
+// implementation of Apache Arrow, without the metadata of this package and
+// with columns of types it does not write. The file is rewritten by the
+// tests of tests/reference.
+func TestReader_ArrowGo(t *testing.T) {
+	buf, err := os.ReadFile(filepath.Join("testdata", "arrow-go.parquet"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	r, rows := readParquet(t, buf, "m")
+	exp := &columnar.Schema{
+		Measurement: "m",
+		Fields: []columnar.Field{
+			{Key: "value", Type: cnosql.Float},
+			{Key: "count", Type: cnosql.Integer},
+			{Key: "name", Type: cnosql.String},
+		},
+	}
+	if !reflect.DeepEqual(r.Schema(), exp) {
+		t.Fatalf("unexpected schema: %+v", r.Schema())
+	} else if exp := []row{
+		{2000, nil, []interface{}{0.5, int64(-1), "a"}},
+		{1000, nil, []interface{}{nil, int64(7), "b"}},
+	}; !reflect.DeepEqual(rows, exp) {
+		t.Fatalf("unexpected rows:\ngot=%v\nexp=%v", rows, exp)
+	} else if min, max, err := r.TimeRange(0); err != nil || min != 1000 || max != 2000 {
+		t.Fatalf("unexpected time range: %d, %d, %v", min, max, err)
+	}
+}
+
+// TestReader_NoTime checks that files without a time column are rejected.
+func TestReader_NoTime(t *testing.T) {
+	var buf bytes.Buffer
+	w := parquet.NewWriter(&buf, []parquet.Column{{Name: "value", Type: parquet.Float, Optional: true}}, parquet.WriterConfig{})
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if _, err := columnar.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()), "m"); err == nil || err.Error() != "no time column" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestArrowSchema(t *testing.T) {
+	exp := arrow.Schema{
+		Fields: []arrow.Field{
+			{Name: "time", Type: arrow.TimestampNanos},
+			{Name: "host", Type: arrow.Utf8, Nullable: true},
+			{Name: "region", Type: arrow.Utf8, Nullable: true},
+			{Name: "float", Type: arrow.Float64, Nullable: true},
+			{Name: "integer", Type: arrow.Int64, Nullable: true},
+			{Name: "unsigned", Type: arrow.Uint64, Nullable: true},
+			{Name: "boolean", Type: arrow.Bool, Nullable: true},
+			{Name: "string", Type: arrow.Utf8, Nullable: true},
+		},
+		Metadata: []arrow.KeyValue{
+			{Key: columnar.MetaMeasurement, Value: "cpu/0"},
+			{Key: columnar.MetaTags, Value: `["host","region"]`},
+			{Key: columnar.MetaFields, Value: `["float","integer","unsigned","boolean","string"]`},
+		},
+	}
+	if got := columnar.ArrowSchema(allSchema); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected schema:\ngot=%+v\nexp=%+v", got, exp)
+	}
+}
+
+func TestArrowColumns(t *testing.T) {
+	columns := columnar.ArrowColumns(newBatch(t, allSchema, allRows), 1, 3)
+	exp := []arrow.Column{
+		{Int64s: []int64{1, 2}},
+		{Valid: []bool{true, false}, Strings: []string{"a", ""}},
+		{Valid: []bool{false, true}, Strings: []string{"", "west"}},
+		{Valid: []bool{false, true}, Float64s: []float64{0, -0.25}},
+		{Valid: []bool{true, false}, Int64s: []int64{0, 0}},
+		{Valid: []bool{true, true}, Uint64s: []uint64{1 << 63, 0}},
+		{Valid: []bool{true, false}, Bools: []bool{false, false}},
+		{Valid: []bool{true, true}, Strings: []string{"", "é"}},
+	}
+	if !reflect.DeepEqual(columns, exp) {
+		t.Fatalf("unexpected columns:\ngot=%+v\nexp=%+v", columns, exp)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir, err := os.MkdirTemp("", "columnar-")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, f := range []columnar.Format{columnar.Parquet, columnar.Arrow} {
+		w, err := columnar.Create(filepath.Join(dir, "out"), f, allSchema)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		} else if err := w.Write(newBatch(t, allSchema, allRows)); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		} else if err := w.Close(); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		buf, err := os.ReadFile(filepath.Join(dir, "out", columnar.FileName(allSchema.Measurement, f)))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		switch f {
+		case columnar.Parquet:
+			if _, rows := readParquet(t, buf, ""); !reflect.DeepEqual(rows, allRows) {
+				t.Fatalf("unexpected rows:\ngot=%v\nexp=%v", rows, allRows)
+			}
+		case columnar.Arrow:
+			if !bytes.HasPrefix(buf, []byte("ARROW1")) || !bytes.HasSuffix(buf, []byte("ARROW1")) {
+				t.Fatal("expected an Arrow IPC file")
+			}
+		}
+	}
+}
+
+func TestRowsSchema(t *testing.T) {
+	rows := []*models.Row{
+		{
+			Name:    "cpu",
+			Tags:    map[string]string{"host": "a"},
+			Columns: []string{"time", "value", "mixed", "other", "empty"},
+			Values: [][]interface{}{
+				{time.Unix(0, 1), int64(1), int64(1), true, nil},
+				{time.Unix(0, 2), int64(2), 1.5, "x", nil},
+			},
+		},
+		{
+			Name:    "cpu",
+			Tags:    map[string]string{"region": "east"},
+			Columns: []string{"time", "value", "mixed", "other", "empty"},
+			Values: [][]interface{}{
+				{time.Unix(0, 3), nil, uint64(2), nil, nil},
+			},
+		},
+	}
+
+	s := columnar.RowsSchema(rows)
+	exp := &columnar.Schema{
+		Measurement: "cpu",
+		Tags:        []string{"host", "region"},
+		Fields: []columnar.Field{
+			{Key: "value", Type: cnosql.Integer},
+			{Key: "mixed", Type: cnosql.Float},
+			{Key: "other", Type: cnosql.String},
+			{Key: "empty", Type: cnosql.String},
+		},
+	}
+	if !reflect.DeepEqual(s, exp) {
+		t.Fatalf("unexpected schema: %+v", s)
+	} else if s.Accepts(rows) {
+		t.Fatal("expected rows of values to convert to be rejected")
+	} else if !s.Accepts(rows[1:]) {
+		t.Fatal("expected the rows to be accepted")
+	}
+
+	b := columnar.NewBatch(s)
+	if err := b.AppendRows(rows); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, exp := batchRows(b), []row{
+		{1, []string{"a", ""}, []interface{}{int64(1), 1.0, "true", nil}},
+		{2, []string{"a", ""}, []interface{}{int64(2), 1.5, "x", nil}},
+		{3, []string{"", "east"}, []interface{}{nil, 2.0, nil, nil}},
+	}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected rows:\ngot=%v\nexp=%v", got, exp)
+	}
+
+	if s.Accepts([]*models.Row{{Name: "mem"}}) {
+		t.Fatal("expected rows of another measurement to be rejected")
+	} else if s.Accepts([]*models.Row{{Name: "cpu", Tags: map[string]string{"dc": "x"}, Columns: []string{"time", "value", "mixed", "other", "empty"}}}) {
+		t.Fatal("expected rows of another tag to be rejected")
+	} else if s.Accepts([]*models.Row{{Name: "cpu", Columns: []string{"time", "value"}}}) {
+		t.Fatal("expected rows of other columns to be rejected")
+	} else if s.Accepts([]*models.Row{{Name: "cpu", Columns: []string{"time", "value", "mixed", "other", "empty"}, Values: [][]interface{}{{nil, "x"}}}}) {
+		t.Fatal("expected rows of values of other types to be rejected")
+	}
+}
@@ -0,0 +1,219 @@
+package columnar
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/cnosdb/cnosdb/pkg/parquet"
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+)
+
+// Reader reads the rows of a measurement from a Parquet file.
+type Reader struct {
+	r      *parquet.Reader
+	schema *Schema
+
+	// The columns of the time, the tags and the fields.
+	time   int
+	tags   []int
+	fields []int
+}
+
+// NewReader returns a reader of the Parquet file of the given size read from
+// r. Files not written by this package must have an integer or timestamp
+// column named "time", and their other columns are read as fields of the
+// measurement named name.
+func NewReader(r io.ReaderAt, size int64, name string) (*Reader, error) {
+	pr, err := parquet.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	rd := &Reader{r: pr, schema: &Schema{Measurement: name}}
+
+	columns := pr.Columns()
+	if m, ok := pr.Lookup(MetaMeasurement); ok {
+		rd.schema.Measurement = m
+		tags, _ := pr.Lookup(MetaTags)
+		fields, _ := pr.Lookup(MetaFields)
+		var fieldKeys []string
+		if err := json.Unmarshal([]byte(tags), &rd.schema.Tags); err != nil {
+			return nil, fmt.Errorf("invalid %s metadata: %s", MetaTags, err)
+		}
+		if err := json.Unmarshal([]byte(fields), &fieldKeys); err != nil {
+			return nil, fmt.Errorf("invalid %s metadata: %s", MetaFields, err)
+		}
+		if len(columns) != 1+len(rd.schema.Tags)+len(fieldKeys) {
+			return nil, fmt.Errorf("%d columns for %d tags and %d fields", len(columns), len(rd.schema.Tags), len(fieldKeys))
+		}
+		for i := range rd.schema.Tags {
+			if columns[1+i].Type != parquet.ByteArray {
+				return nil, fmt.Errorf("tag column %s is not a string column", columns[1+i].Name)
+			}
+			rd.tags = append(rd.tags, 1+i)
+		}
+		for i, k := range fieldKeys {
+			j := 1 + len(rd.schema.Tags) + i
+			rd.schema.Fields = append(rd.schema.Fields, Field{Key: k, Type: fieldType(columns[j])})
+			rd.fields = append(rd.fields, j)
+		}
+	} else {
+		rd.time = -1
+		for i, c := range columns {
+			if c.Name == TimeColumn {
+				rd.time = i
+				continue
+			}
+			rd.schema.Fields = append(rd.schema.Fields, Field{Key: c.Name, Type: fieldType(c)})
+			rd.fields = append(rd.fields, i)
+		}
+		if rd.time < 0 {
+			return nil, fmt.Errorf("no %s column", TimeColumn)
+		}
+	}
+
+	if c := columns[rd.time]; c.Type != parquet.Int64 || c.Optional {
+		return nil, fmt.Errorf("%s column must be a required integer or timestamp column", c.Name)
+	}
+	return rd, nil
+}
+
+func fieldType(c parquet.Column) cnosql.DataType {
+	switch c.Type {
+	case parquet.Boolean:
+		return cnosql.Boolean
+	case parquet.Int32:
+		return cnosql.Integer
+	case parquet.Int64:
+		if c.Logical == parquet.Uint64 {
+			return cnosql.Unsigned
+		}
+		return cnosql.Integer
+	case parquet.Float, parquet.Double:
+		return cnosql.Float
+	}
+	return cnosql.String
+}
+
+// Schema returns the schema of the rows of the file.
+func (r *Reader) Schema() *Schema { return r.schema }
+
+// NumRowGroups returns the number of row groups of the file.
+func (r *Reader) NumRowGroups() int { return r.r.NumRowGroups() }
+
+// times converts the values of the time column to nanoseconds.
+func (r *Reader) times(v parquet.Values) []int64 {
+	var scale int64 = 1
+	switch r.r.Columns()[r.time].Logical {
+	case parquet.TimestampMillis:
+		scale = int64(1e6)
+	case parquet.TimestampMicros:
+		scale = int64(1e3)
+	}
+	if scale != 1 {
+		for i := range v.Int64s {
+			v.Int64s[i] *= scale
+		}
+	}
+	return v.Int64s
+}
+
+// TimeRange returns the minimum and maximum time of the rows of row group
+// rg, read from the statistics of the file if it has them.
+func (r *Reader) TimeRange(rg int) (min, max int64, err error) {
+	if r.r.RowGroupNumRows(rg) == 0 {
+		return 0, 0, nil
+	}
+	if r.r.Columns()[r.time].Logical == parquet.TimestampNanos {
+		if min, max, ok := r.r.Int64Range(rg, r.time); ok {
+			return min, max, nil
+		}
+	}
+	times, err := r.Times(rg)
+	if err != nil {
+		return 0, 0, err
+	}
+	min, max = math.MaxInt64, math.MinInt64
+	for _, t := range times {
+		if t < min {
+			min = t
+		}
+		if t > max {
+			max = t
+		}
+	}
+	return min, max, nil
+}
+
+// Times reads the times of the rows of row group rg in nanoseconds.
+func (r *Reader) Times(rg int) ([]int64, error) {
+	v, err := r.r.ReadColumn(rg, r.time)
+	if err != nil {
+		return nil, err
+	}
+	return r.times(v), nil
+}
+
+// ReadRowGroup reads the rows of row group rg.
+func (r *Reader) ReadRowGroup(rg int) (*Batch, error) {
+	values, err := r.r.ReadRowGroup(rg)
+	if err != nil {
+		return nil, err
+	}
+
+	b := NewBatch(r.schema)
+	b.Time = r.times(values[r.time])
+	n := len(b.Time)
+	for i, col := range r.tags {
+		v := &values[col]
+		tags := make([]string, n)
+		for j := range tags {
+			if !v.IsNull(j) {
+				tags[j] = string(v.Bytes[j])
+			}
+		}
+		b.Tags[i] = tags
+	}
+
+	columns := r.r.Columns()
+	for i, col := range r.fields {
+		v := &values[col]
+		c := &b.fields[i]
+		c.valid = make([]bool, n)
+		for j := range c.valid {
+			c.valid[j] = !v.IsNull(j)
+		}
+		switch columns[col].Type {
+		case parquet.Boolean:
+			c.booleans = v.Booleans
+		case parquet.Int32:
+			c.integers = make([]int64, n)
+			for j, x := range v.Int32s {
+				c.integers[j] = int64(x)
+			}
+		case parquet.Int64:
+			if r.schema.Fields[i].Type == cnosql.Unsigned {
+				c.unsigned = make([]uint64, n)
+				for j, x := range v.Int64s {
+					c.unsigned[j] = uint64(x)
+				}
+			} else {
+				c.integers = v.Int64s
+			}
+		case parquet.Float:
+			c.floats = make([]float64, n)
+			for j, x := range v.Floats {
+				c.floats[j] = float64(x)
+			}
+		case parquet.Double:
+			c.floats = v.Doubles
+		default:
+			c.strings = make([]string, n)
+			for j, x := range v.Bytes {
+				c.strings[j] = string(x)
+			}
+		}
+	}
+	return b, nil
+}
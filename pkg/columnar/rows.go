@@ -0,0 +1,192 @@
+package columnar
+
+import (
+	"sort"
+	"time"
+
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+	"github.com/cnosdb/cnosdb/vend/db/models"
+)
+
+// RowsSchema returns the schema of the series of rows, which share their
+// name. The columns of the rows other than the time are fields, typed after
+// their first value that is not null: float if their values are of several
+// numeric types, and string if of several other types.
+func RowsSchema(rows []*models.Row) *Schema {
+	s := &Schema{}
+	if len(rows) > 0 {
+		s.Measurement = rows[0].Name
+	}
+
+	tags := make(map[string]struct{})
+	fields := make(map[string]int)
+	for _, row := range rows {
+		for k := range row.Tags {
+			tags[k] = struct{}{}
+		}
+		for i, col := range row.Columns {
+			if i == 0 && col == TimeColumn {
+				continue
+			}
+			j, ok := fields[col]
+			if !ok {
+				j = len(s.Fields)
+				fields[col] = j
+				s.Fields = append(s.Fields, Field{Key: col, Type: cnosql.Unknown})
+			}
+			for _, values := range row.Values {
+				if i < len(values) {
+					s.Fields[j].Type = mergeType(s.Fields[j].Type, valueType(values[i]))
+				}
+			}
+		}
+	}
+	for k := range tags {
+		s.Tags = append(s.Tags, k)
+	}
+	sort.Strings(s.Tags)
+	for i := range s.Fields {
+		if s.Fields[i].Type == cnosql.Unknown {
+			s.Fields[i].Type = cnosql.String
+		}
+	}
+	return s
+}
+
+func valueType(v interface{}) cnosql.DataType {
+	switch v.(type) {
+	case nil:
+		return cnosql.Unknown
+	case float64:
+		return cnosql.Float
+	case int64:
+		return cnosql.Integer
+	case uint64:
+		return cnosql.Unsigned
+	case bool:
+		return cnosql.Boolean
+	}
+	return cnosql.String
+}
+
+func mergeType(a, b cnosql.DataType) cnosql.DataType {
+	switch {
+	case a == b || b == cnosql.Unknown:
+		return a
+	case a == cnosql.Unknown:
+		return b
+	case numeric(a) && numeric(b):
+		return cnosql.Float
+	}
+	return cnosql.String
+}
+
+func numeric(t cnosql.DataType) bool {
+	return t == cnosql.Float || t == cnosql.Integer || t == cnosql.Unsigned
+}
+
+// Accepts returns true if the series of rows have the columns of s and
+// values of the types of its fields, so that they can be appended to a batch
+// of s as they are.
+func (s *Schema) Accepts(rows []*models.Row) bool {
+	tags := make(map[string]struct{}, len(s.Tags))
+	for _, k := range s.Tags {
+		tags[k] = struct{}{}
+	}
+	for _, row := range rows {
+		if row.Name != s.Measurement {
+			return false
+		}
+		for k := range row.Tags {
+			if _, ok := tags[k]; !ok {
+				return false
+			}
+		}
+
+		columns := row.Columns
+		offset := 0
+		if len(columns) > 0 && columns[0] == TimeColumn {
+			columns, offset = columns[1:], 1
+		}
+		if len(columns) != len(s.Fields) {
+			return false
+		}
+		for i, f := range s.Fields {
+			if columns[i] != f.Key {
+				return false
+			}
+			for _, values := range row.Values {
+				if i+offset >= len(values) {
+					continue
+				}
+				if t := valueType(values[i+offset]); t != cnosql.Unknown && t != f.Type && (f.Type != cnosql.Float || !numeric(t)) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// AppendRows appends the values of the series of rows to b. Rows without a
+// time column have a zero time.
+func (b *Batch) AppendRows(rows []*models.Row) error {
+	s := b.schema
+	tagIndex := make(map[string]int, len(s.Tags))
+	for i, k := range s.Tags {
+		tagIndex[k] = i
+	}
+	fieldIndex := make(map[string]int, len(s.Fields))
+	for i, f := range s.Fields {
+		fieldIndex[f.Key] = i
+	}
+
+	tags := make([]string, len(s.Tags))
+	fields := make([]interface{}, len(s.Fields))
+	for _, row := range rows {
+		for i := range tags {
+			tags[i] = ""
+		}
+		for k, v := range row.Tags {
+			if i, ok := tagIndex[k]; ok {
+				tags[i] = v
+			}
+		}
+
+		hasTime := len(row.Columns) > 0 && row.Columns[0] == TimeColumn
+		for _, values := range row.Values {
+			for i := range fields {
+				fields[i] = nil
+			}
+			var t int64
+			for i, v := range values {
+				if i >= len(row.Columns) {
+					break
+				}
+				if i == 0 && hasTime {
+					t = timeValue(v)
+					continue
+				}
+				if j, ok := fieldIndex[row.Columns[i]]; ok {
+					fields[j] = v
+				}
+			}
+			if err := b.Append(t, tags, fields); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func timeValue(v interface{}) int64 {
+	switch v := v.(type) {
+	case time.Time:
+		return v.UnixNano()
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	}
+	return 0
+}
@@ -0,0 +1,275 @@
+package columnar
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/cnosdb/cnosdb/pkg/arrow"
+	"github.com/cnosdb/cnosdb/pkg/parquet"
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+)
+
+// RowGroupSize is the number of rows written by writers in a row group or
+// record batch.
+const RowGroupSize = 64 * 1024
+
+// Writer writes batches of rows of a measurement to a file.
+type Writer interface {
+	// Write writes the rows of b, which must have the schema of the writer.
+	Write(b *Batch) error
+	// Close finishes the file.
+	Close() error
+}
+
+// NewWriter returns a writer of rows of schema s to w in format f. Parquet
+// pages are compressed with Snappy, and Arrow is written in the IPC file
+// format. Closing the writer does not close w.
+func NewWriter(w io.Writer, f Format, s *Schema) (Writer, error) {
+	switch f {
+	case Parquet:
+		return NewParquetWriter(w, s, parquet.Snappy), nil
+	case Arrow:
+		return NewArrowWriter(w, s, true), nil
+	}
+	return nil, fmt.Errorf("unknown columnar format %q", f)
+}
+
+// Create creates the file of the measurement of s in dir, and returns a
+// writer of its rows in format f. Closing the writer closes the file.
+func Create(dir string, f Format, s *Schema) (Writer, error) {
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, err
+	}
+	file, err := os.Create(filepath.Join(dir, FileName(s.Measurement, f)))
+	if err != nil {
+		return nil, err
+	}
+	bw := bufio.NewWriterSize(file, 1024*1024)
+	w, err := NewWriter(bw, f, s)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &fileWriter{Writer: w, bw: bw, f: file}, nil
+}
+
+type fileWriter struct {
+	Writer
+	bw *bufio.Writer
+	f  *os.File
+}
+
+func (w *fileWriter) Close() error {
+	err := w.Writer.Close()
+	if e := w.bw.Flush(); err == nil {
+		err = e
+	}
+	if e := w.f.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+// metadata returns the metadata recording the measurement and the tag and
+// field columns of s.
+func (s *Schema) metadata() [][2]string {
+	tags, _ := json.Marshal(s.Tags)
+	keys := make([]string, len(s.Fields))
+	for i, f := range s.Fields {
+		keys[i] = f.Key
+	}
+	fields, _ := json.Marshal(keys)
+	return [][2]string{
+		{MetaMeasurement, s.Measurement},
+		{MetaTags, string(tags)},
+		{MetaFields, string(fields)},
+	}
+}
+
+type parquetWriter struct {
+	schema *Schema
+	w      *parquet.Writer
+}
+
+// NewParquetWriter returns a writer of rows of schema s to a Parquet file
+// written to w, with pages compressed by codec.
+func NewParquetWriter(w io.Writer, s *Schema, codec parquet.Compression) Writer {
+	names := s.Columns()
+	columns := make([]parquet.Column, len(names))
+	columns[0] = parquet.Column{Name: names[0], Type: parquet.Int64, Logical: parquet.TimestampNanos}
+	for i := range s.Tags {
+		columns[1+i] = parquet.Column{Name: names[1+i], Type: parquet.ByteArray, Logical: parquet.String, Optional: true}
+	}
+	for i, f := range s.Fields {
+		c := parquet.Column{Name: names[1+len(s.Tags)+i], Optional: true}
+		switch f.Type {
+		case cnosql.Float:
+			c.Type = parquet.Double
+		case cnosql.Integer:
+			c.Type = parquet.Int64
+		case cnosql.Unsigned:
+			c.Type, c.Logical = parquet.Int64, parquet.Uint64
+		case cnosql.Boolean:
+			c.Type = parquet.Boolean
+		default:
+			c.Type, c.Logical = parquet.ByteArray, parquet.String
+		}
+		columns[1+len(s.Tags)+i] = c
+	}
+
+	config := parquet.WriterConfig{Compression: codec, CreatedBy: "cnosdb"}
+	for _, kv := range s.metadata() {
+		config.Metadata = append(config.Metadata, parquet.KeyValue{Key: kv[0], Value: kv[1]})
+	}
+	return &parquetWriter{schema: s, w: parquet.NewWriter(w, columns, config)}
+}
+
+func (w *parquetWriter) Write(b *Batch) error {
+	for start := 0; start < b.Len(); start += RowGroupSize {
+		end := start + RowGroupSize
+		if end > b.Len() {
+			end = b.Len()
+		}
+		if err := w.w.WriteRowGroup(w.values(b, start, end)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// values returns the values of rows [start, end) of b.
+func (w *parquetWriter) values(b *Batch, start, end int) []parquet.Values {
+	values := make([]parquet.Values, 0, 1+len(b.Tags)+len(b.fields))
+	values = append(values, parquet.Values{Int64s: b.Time[start:end]})
+	for _, tags := range b.Tags {
+		v := parquet.Values{Valid: make([]bool, end-start), Bytes: make([][]byte, end-start)}
+		for i, t := range tags[start:end] {
+			v.Valid[i], v.Bytes[i] = t != "", []byte(t)
+		}
+		values = append(values, v)
+	}
+	for i, f := range w.schema.Fields {
+		c := &b.fields[i]
+		v := parquet.Values{Valid: c.valid[start:end]}
+		switch f.Type {
+		case cnosql.Float:
+			v.Doubles = c.floats[start:end]
+		case cnosql.Integer:
+			v.Int64s = c.integers[start:end]
+		case cnosql.Unsigned:
+			v.Int64s = make([]int64, end-start)
+			for j, u := range c.unsigned[start:end] {
+				v.Int64s[j] = int64(u)
+			}
+		case cnosql.Boolean:
+			v.Booleans = c.booleans[start:end]
+		default:
+			v.Bytes = make([][]byte, end-start)
+			for j, s := range c.strings[start:end] {
+				v.Bytes[j] = []byte(s)
+			}
+		}
+		values = append(values, v)
+	}
+	return values
+}
+
+func (w *parquetWriter) Close() error { return w.w.Close() }
+
+type arrowWriter struct {
+	schema *Schema
+	w      *arrow.Writer
+}
+
+// NewArrowWriter returns a writer of rows of schema s to w in the Arrow IPC
+// file format, or in the stream format if file is false.
+func NewArrowWriter(w io.Writer, s *Schema, file bool) Writer {
+	schema := ArrowSchema(s)
+	aw := &arrowWriter{schema: s}
+	if file {
+		aw.w = arrow.NewFileWriter(w, schema)
+	} else {
+		aw.w = arrow.NewStreamWriter(w, schema)
+	}
+	return aw
+}
+
+// ArrowSchema returns the Arrow schema of the records of the rows of s.
+func ArrowSchema(s *Schema) arrow.Schema {
+	names := s.Columns()
+	schema := arrow.Schema{Fields: make([]arrow.Field, len(names))}
+	schema.Fields[0] = arrow.Field{Name: names[0], Type: arrow.TimestampNanos}
+	for i := range s.Tags {
+		schema.Fields[1+i] = arrow.Field{Name: names[1+i], Type: arrow.Utf8, Nullable: true}
+	}
+	for i, f := range s.Fields {
+		af := arrow.Field{Name: names[1+len(s.Tags)+i], Nullable: true}
+		switch f.Type {
+		case cnosql.Float:
+			af.Type = arrow.Float64
+		case cnosql.Integer:
+			af.Type = arrow.Int64
+		case cnosql.Unsigned:
+			af.Type = arrow.Uint64
+		case cnosql.Boolean:
+			af.Type = arrow.Bool
+		default:
+			af.Type = arrow.Utf8
+		}
+		schema.Fields[1+len(s.Tags)+i] = af
+	}
+	for _, kv := range s.metadata() {
+		schema.Metadata = append(schema.Metadata, arrow.KeyValue{Key: kv[0], Value: kv[1]})
+	}
+	return schema
+}
+
+func (w *arrowWriter) Write(b *Batch) error {
+	for start := 0; start < b.Len(); start += RowGroupSize {
+		end := start + RowGroupSize
+		if end > b.Len() {
+			end = b.Len()
+		}
+		if err := w.w.Write(ArrowColumns(b, start, end)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *arrowWriter) Close() error { return w.w.Close() }
+
+// ArrowColumns returns the columns of the record of rows [start, end) of b.
+func ArrowColumns(b *Batch, start, end int) []arrow.Column {
+	columns := make([]arrow.Column, 0, 1+len(b.Tags)+len(b.fields))
+	columns = append(columns, arrow.Column{Int64s: b.Time[start:end]})
+	for _, tags := range b.Tags {
+		c := arrow.Column{Valid: make([]bool, end-start), Strings: tags[start:end]}
+		for i, t := range c.Strings {
+			c.Valid[i] = t != ""
+		}
+		columns = append(columns, c)
+	}
+	for i, f := range b.schema.Fields {
+		fc := &b.fields[i]
+		c := arrow.Column{Valid: fc.valid[start:end]}
+		switch f.Type {
+		case cnosql.Float:
+			c.Float64s = fc.floats[start:end]
+		case cnosql.Integer:
+			c.Int64s = fc.integers[start:end]
+		case cnosql.Unsigned:
+			c.Uint64s = fc.unsigned[start:end]
+		case cnosql.Boolean:
+			c.Bools = fc.booleans[start:end]
+		default:
+			c.Strings = fc.strings[start:end]
+		}
+		columns = append(columns, c)
+	}
+	return columns
+}
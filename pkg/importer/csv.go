@@ -0,0 +1,652 @@
+package importer
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cnosdb/cnosdb/client"
+	"github.com/cnosdb/cnosdb/vend/db/models"
+)
+
+const (
+	// csvSampleSize is the number of rows read to infer the types of the
+	// fields with no declared type.
+	csvSampleSize = 1000
+
+	// csvDatatypeAnnotation starts a row declaring the kind of each column.
+	csvDatatypeAnnotation = "#datatype"
+)
+
+// The kinds of the columns of a CSV file.
+const (
+	CSVColumnField       = "field"
+	CSVColumnTag         = "tag"
+	CSVColumnMeasurement = "measurement"
+	CSVColumnTime        = "time"
+	CSVColumnIgnore      = "ignore"
+)
+
+// The formats of a CSV time column, besides Go time layouts.
+const (
+	CSVTimeRFC3339 = "RFC3339"
+	CSVTimeUnix    = "unix"
+	CSVTimeUnixMs  = "unix_ms"
+	CSVTimeUnixUs  = "unix_us"
+	CSVTimeUnixNs  = "unix_ns"
+)
+
+// CSVConfig describes how the rows of a CSV file are imported.
+//
+// The first row of the file that is not an annotation is the header naming
+// the columns. The kind of a column is taken, in order, from the options
+// below, from a "#datatype" annotation row preceding the header, e.g.
+//
+//	#datatype measurement,tag,float,time:unix_ms
+//
+// or, if neither declares it, the column is a field whose type is inferred
+// from the first rows of the file.
+type CSVConfig struct {
+	Database        string
+	RetentionPolicy string
+
+	// Measurement is the measurement of the rows when no column holds it.
+	Measurement string
+	// MeasurementColumn is the name of the column holding the measurement.
+	MeasurementColumn string
+	// Tags are the names of the tag columns.
+	Tags []string
+	// Fields are the field columns, as name or name:type.
+	Fields []string
+	// Ignore are the names of the columns not imported.
+	Ignore []string
+	// Timestamp is the name of the time column. The column named time is
+	// used when empty, and the time of the import if there is none.
+	Timestamp string
+	// TimestampFormat is RFC3339, unix, unix_ms, unix_us, unix_ns or a Go
+	// time layout. It defaults to RFC3339.
+	TimestampFormat string
+	// Timezone is the location of times without a zone. It defaults to UTC.
+	Timezone string
+
+	Delimiter rune
+	// Workers is the number of batches written concurrently.
+	Workers int
+	// RejectsPath is the path of the CSV file the rejected rows are written
+	// to, along with the reason of the rejection.
+	RejectsPath string
+	// DryRun prints the schema of the file instead of importing it.
+	DryRun bool
+}
+
+// csvColumn is a column of a CSV file.
+type csvColumn struct {
+	name     string
+	kind     string
+	typ      string // type of a field, or format of the time column
+	inferred bool   // typ was inferred from the values of the column
+}
+
+// csvSchema maps the columns of a CSV file onto points.
+type csvSchema struct {
+	columns     []csvColumn
+	measurement string
+	loc         *time.Location
+}
+
+// CSVImporter imports the rows of CSV files as points.
+type CSVImporter struct {
+	config Config
+	csv    CSVConfig
+
+	importer *Importer
+
+	mu       sync.Mutex
+	rejects  *csv.Writer
+	rows     int
+	written  int
+	rejected int
+
+	stdout io.Writer
+}
+
+// NewCSVImporter returns an importer of CSV files with the connection
+// settings of c.
+func NewCSVImporter(c Config, cc CSVConfig) *CSVImporter {
+	if cc.Delimiter == 0 {
+		cc.Delimiter = ','
+	}
+	if cc.Workers <= 0 {
+		cc.Workers = 1
+	}
+	c.Precision = "ns"
+	return &CSVImporter{
+		config:   c,
+		csv:      cc,
+		importer: NewImporter(c),
+		stdout:   os.Stdout,
+	}
+}
+
+// Import reads a CSV file from f and writes its rows to the database.
+func (i *CSVImporter) Import(f io.Reader) error {
+	if i.config.Compressed {
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gr.Close()
+		f = gr
+	}
+
+	r := csv.NewReader(bufio.NewReader(f))
+	r.Comma = i.csv.Delimiter
+	r.FieldsPerRecord = -1
+
+	header, annotation, err := readCSVHeader(r)
+	if err != nil {
+		return err
+	}
+
+	// Read the first rows to infer the types of the fields.
+	var sample [][]string
+	for len(sample) < csvSampleSize {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		sample = append(sample, rec)
+	}
+
+	schema, err := newCSVSchema(header, annotation, i.csv, sample)
+	if err != nil {
+		return err
+	}
+
+	if i.csv.DryRun {
+		return i.printSchema(schema, sample)
+	}
+
+	if i.csv.Database == "" {
+		return errors.New("database is required")
+	}
+
+	cl, err := client.NewHTTPClient(*i.config.ClientConfig)
+	if err != nil {
+		return fmt.Errorf("could not create client: %s", err)
+	}
+	defer cl.Close()
+	if _, _, e := cl.Ping(client.DEFAULT_TIMEOUT); e != nil {
+		return fmt.Errorf("failed to connect to %s\n", i.config.ClientConfig.Addr)
+	}
+
+	if i.csv.RejectsPath != "" {
+		rf, err := os.Create(i.csv.RejectsPath)
+		if err != nil {
+			return err
+		}
+		defer rf.Close()
+		i.rejects = csv.NewWriter(rf)
+		i.rejects.Comma = i.csv.Delimiter
+		if err := i.rejects.Write(append(header[:len(header):len(header)], "error")); err != nil {
+			return err
+		}
+		defer i.rejects.Flush()
+	}
+
+	err = i.importRows(r, schema, sample)
+
+	fmt.Fprintf(i.stdout, "Processed %d rows, wrote %d points, rejected %d rows\n", i.rows, i.written, i.rejected)
+	if err != nil {
+		return err
+	}
+	if i.rejected > 0 {
+		return fmt.Errorf("%d rows were not imported", i.rejected)
+	}
+	return nil
+}
+
+// csvBatch is a batch of points along with the rows they were converted from.
+type csvBatch struct {
+	lines []string
+	rows  [][]string
+}
+
+// importRows converts the rows read from r, after the rows of sample, and
+// writes them in batches with the configured number of workers.
+func (i *CSVImporter) importRows(r *csv.Reader, schema *csvSchema, sample [][]string) error {
+	batches := make(chan *csvBatch, i.csv.Workers)
+	var wg sync.WaitGroup
+	for n := 0; n < i.csv.Workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range batches {
+				i.writeBatch(b)
+			}
+		}()
+	}
+
+	start, lastLog, queued := time.Now(), 0, 0
+	b := &csvBatch{}
+	flush := func() {
+		if len(b.lines) > 0 {
+			queued += len(b.lines)
+			i.throttle(start, queued)
+			batches <- b
+			b = &csvBatch{}
+		}
+		if i.rows-lastLog >= 100000 {
+			lastLog = i.rows
+			since := time.Since(start)
+			fmt.Fprintf(i.stdout, "Processed %d rows.  Time elapsed: %s.  Rows per second: %d\n",
+				i.rows, since.String(), int64(float64(i.rows)/since.Seconds()))
+		}
+	}
+
+	add := func(rec []string) {
+		i.rows++
+
+		line, err := schema.convert(rec, time.Now())
+		if err != nil {
+			i.reject(rec, err)
+			return
+		}
+		b.lines = append(b.lines, line)
+		b.rows = append(b.rows, rec)
+		if len(b.lines) == batchSize {
+			flush()
+		}
+	}
+
+	var err error
+	for _, rec := range sample {
+		add(rec)
+	}
+	for {
+		rec, e := r.Read()
+		if e == io.EOF {
+			break
+		} else if e != nil {
+			err = e
+			break
+		}
+		add(rec)
+	}
+	flush()
+
+	close(batches)
+	wg.Wait()
+	return err
+}
+
+// throttle waits until writing n points since start keeps the import under
+// the configured points per second.
+func (i *CSVImporter) throttle(start time.Time, n int) {
+	if i.config.PPS <= 0 {
+		return
+	}
+	if d := time.Duration(float64(n)/float64(i.config.PPS)*float64(time.Second)) - time.Since(start); d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// writeBatch writes the points of b, and rejects its rows if it fails.
+func (i *CSVImporter) writeBatch(b *csvBatch) {
+	_, err := i.importer.WriteLineProtocol(strings.Join(b.lines, "\n"), i.csv.Database, i.csv.RetentionPolicy, i.config.Precision, i.config.WriteConsistency)
+	if err != nil {
+		for _, rec := range b.rows {
+			i.reject(rec, err)
+		}
+		return
+	}
+
+	i.mu.Lock()
+	i.written += len(b.lines)
+	i.mu.Unlock()
+}
+
+// reject counts rec as rejected and writes it to the rejects file.
+func (i *CSVImporter) reject(rec []string, err error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.rejected++
+	if i.rejects != nil {
+		i.rejects.Write(append(rec[:len(rec):len(rec)], err.Error()))
+	}
+}
+
+// printSchema writes the schema of the file, and the conversion errors of
+// the sample rows, to stdout.
+func (i *CSVImporter) printSchema(schema *csvSchema, sample [][]string) error {
+	w := i.stdout
+	if schema.measurement != "" {
+		fmt.Fprintf(w, "measurement: %s\n", schema.measurement)
+	}
+	for _, col := range schema.columns {
+		switch {
+		case col.kind == CSVColumnField && col.inferred:
+			fmt.Fprintf(w, "%s: %s %s (inferred)\n", col.name, col.kind, col.typ)
+		case col.typ != "":
+			fmt.Fprintf(w, "%s: %s %s\n", col.name, col.kind, col.typ)
+		default:
+			fmt.Fprintf(w, "%s: %s\n", col.name, col.kind)
+		}
+	}
+
+	now, rejected := time.Now(), 0
+	for n, rec := range sample {
+		if _, err := schema.convert(rec, now); err != nil {
+			if rejected < 10 {
+				fmt.Fprintf(w, "row %d: %s\n", n+1, err)
+			}
+			rejected++
+		}
+	}
+	fmt.Fprintf(w, "%d of the first %d rows would be rejected\n", rejected, len(sample))
+	return nil
+}
+
+// readCSVHeader reads the annotation and header rows of a CSV file.
+func readCSVHeader(r *csv.Reader) (header, annotation []string, err error) {
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return nil, nil, errors.New("missing CSV header")
+		} else if err != nil {
+			return nil, nil, err
+		}
+
+		if len(rec) == 0 || !strings.HasPrefix(rec[0], "#") {
+			return rec, annotation, nil
+		}
+		if strings.HasPrefix(rec[0], csvDatatypeAnnotation) {
+			annotation = append([]string{strings.TrimSpace(strings.TrimPrefix(rec[0], csvDatatypeAnnotation))}, rec[1:]...)
+		}
+		// Other rows starting with # are comments.
+	}
+}
+
+// newCSVSchema resolves the kind of each column of header.
+func newCSVSchema(header, annotation []string, c CSVConfig, sample [][]string) (*csvSchema, error) {
+	s := &csvSchema{
+		columns:     make([]csvColumn, len(header)),
+		measurement: c.Measurement,
+		loc:         time.UTC,
+	}
+	if c.Timezone != "" {
+		loc, err := time.LoadLocation(c.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		s.loc = loc
+	}
+
+	// Options, by column name.
+	declared := make(map[string]csvColumn)
+	for _, name := range c.Tags {
+		declared[name] = csvColumn{kind: CSVColumnTag}
+	}
+	for _, spec := range c.Fields {
+		name, typ := spec, ""
+		if i := strings.LastIndexByte(spec, ':'); i >= 0 {
+			name, typ = spec[:i], spec[i+1:]
+		}
+		declared[name] = csvColumn{kind: CSVColumnField, typ: typ}
+	}
+	for _, name := range c.Ignore {
+		declared[name] = csvColumn{kind: CSVColumnIgnore}
+	}
+	if c.MeasurementColumn != "" {
+		declared[c.MeasurementColumn] = csvColumn{kind: CSVColumnMeasurement}
+	}
+	if c.Timestamp != "" {
+		declared[c.Timestamp] = csvColumn{kind: CSVColumnTime, typ: c.TimestampFormat}
+	}
+
+	var hasTime, hasMeasurement bool
+	for n, name := range header {
+		col, ok := declared[name]
+		if !ok && n < len(annotation) && annotation[n] != "" {
+			var err error
+			if col, err = parseCSVDatatype(annotation[n]); err != nil {
+				return nil, fmt.Errorf("column %s: %s", name, err)
+			}
+		} else if !ok && c.Timestamp == "" && name == "time" {
+			col = csvColumn{kind: CSVColumnTime}
+		} else if !ok {
+			col = csvColumn{kind: CSVColumnField}
+		}
+		col.name = name
+
+		switch col.kind {
+		case CSVColumnTime:
+			if hasTime {
+				return nil, fmt.Errorf("more than one time column: %s", name)
+			}
+			hasTime = true
+			if col.typ == "" {
+				col.typ = c.TimestampFormat
+			}
+			if col.typ == "" {
+				col.typ = CSVTimeRFC3339
+			}
+		case CSVColumnMeasurement:
+			if hasMeasurement {
+				return nil, fmt.Errorf("more than one measurement column: %s", name)
+			}
+			hasMeasurement = true
+		case CSVColumnField:
+			if col.typ == "" {
+				col.typ, col.inferred = inferCSVFieldType(sample, n), true
+			}
+			if _, err := csvFieldValue(col.typ, ""); err == errInvalidCSVFieldType {
+				return nil, fmt.Errorf("column %s: invalid field type %q", name, col.typ)
+			}
+		}
+		s.columns[n] = col
+	}
+	for name := range declared {
+		if !containsString(header, name) {
+			return nil, fmt.Errorf("no column named %s", name)
+		}
+	}
+
+	if !hasMeasurement && s.measurement == "" {
+		return nil, errors.New("measurement is required when no column holds it")
+	}
+	return s, nil
+}
+
+// parseCSVDatatype parses the kind of a column declared in a #datatype
+// annotation. The types of InfluxDB annotated CSV files are accepted too.
+func parseCSVDatatype(s string) (csvColumn, error) {
+	typ := s
+	var format string
+	if i := strings.IndexByte(s, ':'); i >= 0 {
+		typ, format = s[:i], s[i+1:]
+	}
+
+	switch typ {
+	case CSVColumnMeasurement, CSVColumnTag:
+		return csvColumn{kind: typ}, nil
+	case CSVColumnIgnore, "ignored":
+		return csvColumn{kind: CSVColumnIgnore}, nil
+	case CSVColumnTime, "dateTime":
+		return csvColumn{kind: CSVColumnTime, typ: format}, nil
+	case CSVColumnField:
+		return csvColumn{kind: CSVColumnField, typ: format}, nil
+	case "float", "double":
+		return csvColumn{kind: CSVColumnField, typ: "float"}, nil
+	case "integer", "long":
+		return csvColumn{kind: CSVColumnField, typ: "integer"}, nil
+	case "unsigned", "unsignedLong":
+		return csvColumn{kind: CSVColumnField, typ: "unsigned"}, nil
+	case "string", "boolean":
+		return csvColumn{kind: CSVColumnField, typ: typ}, nil
+	}
+	return csvColumn{}, fmt.Errorf("invalid datatype %q", s)
+}
+
+// inferCSVFieldType returns the type of the non-empty values of column n of
+// rows: boolean if they are all booleans, float if they are all numbers and
+// string otherwise.
+func inferCSVFieldType(rows [][]string, n int) string {
+	isBool, isFloat := true, true
+	for _, rec := range rows {
+		if n >= len(rec) || rec[n] == "" {
+			continue
+		}
+		v := rec[n]
+		if isBool && !(strings.EqualFold(v, "true") || strings.EqualFold(v, "false")) {
+			isBool = false
+		}
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			isFloat = false
+		}
+		if !isBool && !isFloat {
+			return "string"
+		}
+	}
+	if isBool && !isFloat {
+		return "boolean"
+	}
+	return "float"
+}
+
+var errInvalidCSVFieldType = errors.New("invalid field type")
+
+// csvFieldValue converts v into a field value of type typ.
+func csvFieldValue(typ, v string) (interface{}, error) {
+	switch typ {
+	case "float":
+		return strconv.ParseFloat(v, 64)
+	case "integer":
+		return strconv.ParseInt(v, 10, 64)
+	case "unsigned":
+		return strconv.ParseUint(v, 10, 64)
+	case "string":
+		return v, nil
+	case "boolean":
+		return strconv.ParseBool(v)
+	}
+	return nil, errInvalidCSVFieldType
+}
+
+// parseCSVTime converts v into a time according to format. Times without a
+// zone are in loc.
+func parseCSVTime(v, format string, loc *time.Location) (time.Time, error) {
+	var unit time.Duration
+	switch format {
+	case CSVTimeRFC3339:
+		return time.ParseInLocation(time.RFC3339Nano, v, loc)
+	case CSVTimeUnix:
+		unit = time.Second
+	case CSVTimeUnixMs:
+		unit = time.Millisecond
+	case CSVTimeUnixUs:
+		unit = time.Microsecond
+	case CSVTimeUnixNs:
+		unit = time.Nanosecond
+	default:
+		return time.ParseInLocation(format, v, loc)
+	}
+
+	if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+		if n > math.MaxInt64/int64(unit) || n < math.MinInt64/int64(unit) {
+			return time.Time{}, fmt.Errorf("time %q out of range", v)
+		}
+		return time.Unix(0, n*int64(unit)), nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q", v)
+	}
+	ns := f * float64(unit)
+	if ns > math.MaxInt64 || ns < math.MinInt64 {
+		return time.Time{}, fmt.Errorf("time %q out of range", v)
+	}
+	return time.Unix(0, int64(ns)), nil
+}
+
+// convert converts a row into a point in line protocol. The time of rows
+// with no time column is now.
+func (s *csvSchema) convert(rec []string, now time.Time) (string, error) {
+	if len(rec) != len(s.columns) {
+		return "", fmt.Errorf("expected %d columns, got %d", len(s.columns), len(rec))
+	}
+
+	var (
+		name   = s.measurement
+		tags   = make(map[string]string)
+		fields = make(models.Fields)
+		ts     = now
+	)
+	for n, col := range s.columns {
+		v := rec[n]
+		switch col.kind {
+		case CSVColumnMeasurement:
+			if v != "" {
+				name = v
+			}
+		case CSVColumnTag:
+			if v != "" {
+				tags[col.name] = v
+			}
+		case CSVColumnTime:
+			if v == "" {
+				return "", fmt.Errorf("empty time in column %s", col.name)
+			}
+			t, err := parseCSVTime(v, col.typ, s.loc)
+			if err != nil {
+				return "", fmt.Errorf("column %s: %s", col.name, err)
+			}
+			ts = t
+		case CSVColumnField:
+			if v == "" {
+				continue
+			}
+			fv, err := csvFieldValue(col.typ, v)
+			if err != nil {
+				return "", fmt.Errorf("column %s: invalid %s %q", col.name, col.typ, v)
+			}
+			fields[col.name] = fv
+		}
+	}
+
+	if name == "" {
+		return "", errors.New("empty measurement")
+	} else if len(fields) == 0 {
+		return "", errors.New("no field values")
+	}
+
+	pt, err := models.NewPoint(name, models.NewTags(tags), fields, ts)
+	if err != nil {
+		return "", err
+	}
+	if strings.ContainsAny(string(pt.Key()), "\r\n") {
+		return "", errors.New("measurement or tag contains a line break")
+	}
+	return pt.String(), nil
+}
+
+func containsString(a []string, s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
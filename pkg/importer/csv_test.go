@@ -0,0 +1,483 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cnosdb/cnosdb/client"
+)
+
+func TestReadCSVHeader(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		s          string
+		header     []string
+		annotation []string
+		err        string
+	}{
+		{
+			name:   "header",
+			s:      "time,host,value\n1,a,2\n",
+			header: []string{"time", "host", "value"},
+		},
+		{
+			name:       "annotation and comments",
+			s:          "# exported from somewhere\n#datatype measurement,tag,double\n#group,false,false\nm,host,value\n",
+			header:     []string{"m", "host", "value"},
+			annotation: []string{"measurement", "tag", "double"},
+		},
+		{
+			name: "empty",
+			s:    "#datatype tag\n",
+			err:  "missing CSV header",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := csv.NewReader(strings.NewReader(tt.s))
+			r.FieldsPerRecord = -1
+			header, annotation, err := readCSVHeader(r)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("unexpected error: got=%v exp=%s", err, tt.err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(header, tt.header) {
+				t.Fatalf("unexpected header: got=%q exp=%q", header, tt.header)
+			} else if !reflect.DeepEqual(annotation, tt.annotation) {
+				t.Fatalf("unexpected annotation: got=%q exp=%q", annotation, tt.annotation)
+			}
+		})
+	}
+}
+
+func TestNewCSVSchema(t *testing.T) {
+	sample := [][]string{
+		{"2000-01-01T00:00:00Z", "cpu", "a", "1", "1.5", "true", "ok", "x"},
+		{"2000-01-01T00:00:01Z", "cpu", "b", "2", "", "FALSE", "3", "y"},
+	}
+	header := []string{"time", "name", "host", "count", "usage", "up", "status", "note"}
+
+	for _, tt := range []struct {
+		name        string
+		header      []string
+		annotation  []string
+		config      CSVConfig
+		columns     []csvColumn
+		measurement string
+		err         string
+	}{
+		{
+			name:   "inferred",
+			header: header,
+			config: CSVConfig{Measurement: "m"},
+			columns: []csvColumn{
+				{name: "time", kind: CSVColumnTime, typ: CSVTimeRFC3339},
+				{name: "name", kind: CSVColumnField, typ: "string", inferred: true},
+				{name: "host", kind: CSVColumnField, typ: "string", inferred: true},
+				{name: "count", kind: CSVColumnField, typ: "float", inferred: true},
+				{name: "usage", kind: CSVColumnField, typ: "float", inferred: true},
+				{name: "up", kind: CSVColumnField, typ: "boolean", inferred: true},
+				{name: "status", kind: CSVColumnField, typ: "string", inferred: true},
+				{name: "note", kind: CSVColumnField, typ: "string", inferred: true},
+			},
+			measurement: "m",
+		},
+		{
+			name:   "options",
+			header: header,
+			config: CSVConfig{
+				MeasurementColumn: "name",
+				Tags:              []string{"host"},
+				Fields:            []string{"count:integer", "status:string"},
+				Ignore:            []string{"note"},
+				Timestamp:         "time",
+				TimestampFormat:   "2006-01-02T15:04:05Z07:00",
+			},
+			columns: []csvColumn{
+				{name: "time", kind: CSVColumnTime, typ: "2006-01-02T15:04:05Z07:00"},
+				{name: "name", kind: CSVColumnMeasurement},
+				{name: "host", kind: CSVColumnTag},
+				{name: "count", kind: CSVColumnField, typ: "integer"},
+				{name: "usage", kind: CSVColumnField, typ: "float", inferred: true},
+				{name: "up", kind: CSVColumnField, typ: "boolean", inferred: true},
+				{name: "status", kind: CSVColumnField, typ: "string"},
+				{name: "note", kind: CSVColumnIgnore},
+			},
+		},
+		{
+			name:       "annotation",
+			header:     header,
+			annotation: []string{"dateTime:unix_ms", "measurement", "tag", "long", "double", "", "field:string", "ignored"},
+			config:     CSVConfig{Tags: []string{"status"}},
+			columns: []csvColumn{
+				{name: "time", kind: CSVColumnTime, typ: CSVTimeUnixMs},
+				{name: "name", kind: CSVColumnMeasurement},
+				{name: "host", kind: CSVColumnTag},
+				{name: "count", kind: CSVColumnField, typ: "integer"},
+				{name: "usage", kind: CSVColumnField, typ: "float"},
+				{name: "up", kind: CSVColumnField, typ: "boolean", inferred: true},
+				{name: "status", kind: CSVColumnTag},
+				{name: "note", kind: CSVColumnIgnore},
+			},
+		},
+		{
+			name:   "time format option",
+			header: []string{"ts", "value"},
+			config: CSVConfig{Measurement: "m", Timestamp: "ts", TimestampFormat: CSVTimeUnix},
+			columns: []csvColumn{
+				{name: "ts", kind: CSVColumnTime, typ: CSVTimeUnix},
+				// Inferred from the second column of the sample.
+				{name: "value", kind: CSVColumnField, typ: "string", inferred: true},
+			},
+			measurement: "m",
+		},
+		{
+			name:   "no time column",
+			header: []string{"value"},
+			config: CSVConfig{Measurement: "m", Fields: []string{"value:unsigned"}},
+			columns: []csvColumn{
+				{name: "value", kind: CSVColumnField, typ: "unsigned"},
+			},
+			measurement: "m",
+		},
+		{
+			name:       "two time columns",
+			header:     []string{"time", "ts", "value"},
+			annotation: []string{"", "time", "float"},
+			config:     CSVConfig{Measurement: "m"},
+			err:        "more than one time column: ts",
+		},
+		{
+			name:       "two measurement columns",
+			header:     []string{"a", "b", "value"},
+			annotation: []string{"", "measurement", "float"},
+			config:     CSVConfig{MeasurementColumn: "a"},
+			err:        "more than one measurement column: b",
+		},
+		{
+			name:   "unknown column",
+			header: []string{"time", "value"},
+			config: CSVConfig{Measurement: "m", Tags: []string{"host"}},
+			err:    "no column named host",
+		},
+		{
+			name:   "invalid field type",
+			header: []string{"time", "value"},
+			config: CSVConfig{Measurement: "m", Fields: []string{"value:decimal"}},
+			err:    `column value: invalid field type "decimal"`,
+		},
+		{
+			name:       "invalid datatype",
+			header:     []string{"time", "value"},
+			annotation: []string{"", "duration"},
+			config:     CSVConfig{Measurement: "m"},
+			err:        `column value: invalid datatype "duration"`,
+		},
+		{
+			name:   "no measurement",
+			header: []string{"time", "value"},
+			err:    "measurement is required when no column holds it",
+		},
+		{
+			name:   "invalid timezone",
+			header: []string{"time", "value"},
+			config: CSVConfig{Measurement: "m", Timezone: "Nowhere/Nothing"},
+			err:    "unknown time zone Nowhere/Nothing",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := newCSVSchema(tt.header, tt.annotation, tt.config, sample)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("unexpected error: got=%v exp=%s", err, tt.err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(s.columns, tt.columns) {
+				t.Fatalf("unexpected columns:\ngot=%+v\nexp=%+v", s.columns, tt.columns)
+			} else if s.measurement != tt.measurement {
+				t.Fatalf("unexpected measurement: got=%s exp=%s", s.measurement, tt.measurement)
+			}
+		})
+	}
+}
+
+func TestInferCSVFieldType(t *testing.T) {
+	for _, tt := range []struct {
+		values []string
+		exp    string
+	}{
+		{values: []string{"1", "-2.5", "3e3"}, exp: "float"},
+		{values: []string{"true", "False", "TRUE"}, exp: "boolean"},
+		{values: []string{"1", "true"}, exp: "string"},
+		{values: []string{"1", "", "2"}, exp: "float"},
+		{values: []string{"", ""}, exp: "float"},
+		{values: []string{"NaN", "Inf"}, exp: "float"},
+		{values: []string{"a", "1"}, exp: "string"},
+	} {
+		rows := make([][]string, len(tt.values))
+		for i, v := range tt.values {
+			rows[i] = []string{"x", v}
+		}
+		if got := inferCSVFieldType(rows, 1); got != tt.exp {
+			t.Errorf("unexpected type of %q: got=%s exp=%s", tt.values, got, tt.exp)
+		}
+	}
+
+	// Short rows are ignored.
+	if got := inferCSVFieldType([][]string{{"x"}, {"x", "true"}}, 1); got != "boolean" {
+		t.Fatalf("unexpected type: %s", got)
+	}
+}
+
+func TestParseCSVTime(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("no time zone database: %s", err)
+	}
+
+	for _, tt := range []struct {
+		v      string
+		format string
+		loc    *time.Location
+		exp    int64
+		err    bool
+	}{
+		{v: "2000-01-01T00:00:00Z", format: CSVTimeRFC3339, exp: 946684800000000000},
+		{v: "2000-01-01T09:00:00.5+09:00", format: CSVTimeRFC3339, exp: 946684800500000000},
+		{v: "2000-01-01 00:00:00", format: CSVTimeRFC3339, err: true},
+		{v: "946684800", format: CSVTimeUnix, exp: 946684800000000000},
+		{v: "946684800.25", format: CSVTimeUnix, exp: 946684800250000000},
+		{v: "-1", format: CSVTimeUnix, exp: -1000000000},
+		{v: "946684800123", format: CSVTimeUnixMs, exp: 946684800123000000},
+		{v: "946684800123456", format: CSVTimeUnixUs, exp: 946684800123456000},
+		{v: "946684800123456789", format: CSVTimeUnixNs, exp: 946684800123456789},
+		{v: "99999999999999999", format: CSVTimeUnix, err: true},
+		{v: "1e300", format: CSVTimeUnixMs, err: true},
+		{v: "yesterday", format: CSVTimeUnix, err: true},
+		{v: "2000-01-01 09:00:00", format: "2006-01-02 15:04:05", loc: tokyo, exp: 946684800000000000},
+		{v: "2000-01-01T09:00:00", format: "2006-01-02T15:04:05", loc: tokyo, exp: 946684800000000000},
+		{v: "2000-01-01T00:00:00Z", format: CSVTimeRFC3339, loc: tokyo, exp: 946684800000000000},
+		{v: "01/02/2000", format: "01/02/2006", exp: 946771200000000000},
+		{v: "2000-13-01", format: "2006-01-02", err: true},
+	} {
+		loc := tt.loc
+		if loc == nil {
+			loc = time.UTC
+		}
+		ts, err := parseCSVTime(tt.v, tt.format, loc)
+		if tt.err {
+			if err == nil {
+				t.Errorf("expected error parsing %q as %s, got %s", tt.v, tt.format, ts)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("unexpected error parsing %q as %s: %s", tt.v, tt.format, err)
+			continue
+		}
+		if ts.UnixNano() != tt.exp {
+			t.Errorf("unexpected time of %q as %s: got=%d exp=%d", tt.v, tt.format, ts.UnixNano(), tt.exp)
+		}
+	}
+}
+
+func TestCSVSchema_Convert(t *testing.T) {
+	s, err := newCSVSchema(
+		[]string{"time", "name", "host", "region", "count", "usage", "up", "note", "skip"},
+		nil,
+		CSVConfig{
+			Measurement:       "default",
+			MeasurementColumn: "name",
+			Tags:              []string{"host", "region"},
+			Fields:            []string{"count:integer", "usage:float", "up:boolean", "note:string"},
+			Ignore:            []string{"skip"},
+			TimestampFormat:   CSVTimeUnix,
+		},
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	now := time.Unix(100, 0)
+
+	for _, tt := range []struct {
+		name string
+		rec  []string
+		exp  string
+		err  string
+	}{
+		{
+			name: "all columns",
+			rec:  []string{"1", "cpu", "a", "east", "3", "1.5", "true", `say "hi"`, "x"},
+			exp:  `cpu,host=a,region=east count=3i,note="say \"hi\"",up=true,usage=1.5 1000000000`,
+		},
+		{
+			name: "default measurement and empty tag",
+			rec:  []string{"2", "", "a b", "", "4", "", "", "", ""},
+			exp:  `default,host=a\ b count=4i 2000000000`,
+		},
+		{
+			name: "empty fields",
+			rec:  []string{"3", "cpu", "a", "", "", "", "F", "", ""},
+			exp:  `cpu,host=a up=false 3000000000`,
+		},
+		{name: "too few columns", rec: []string{"1", "cpu"}, err: "expected 9 columns, got 2"},
+		{name: "too many columns", rec: []string{"1", "cpu", "a", "east", "3", "1.5", "true", "", "", ""}, err: "expected 9 columns, got 10"},
+		{name: "empty time", rec: []string{"", "cpu", "a", "", "1", "", "", "", ""}, err: "empty time in column time"},
+		{name: "invalid time", rec: []string{"soon", "cpu", "a", "", "1", "", "", "", ""}, err: `column time: invalid time "soon"`},
+		{name: "invalid integer", rec: []string{"1", "cpu", "a", "", "1.5", "", "", "", ""}, err: `column count: invalid integer "1.5"`},
+		{name: "invalid boolean", rec: []string{"1", "cpu", "a", "", "", "", "yes", "", ""}, err: `column up: invalid boolean "yes"`},
+		{name: "no fields", rec: []string{"1", "cpu", "a", "", "", "", "", "", "x"}, err: "no field values"},
+		{name: "line break in tag", rec: []string{"1", "cpu", "a\nb", "", "1", "", "", "", ""}, err: "measurement or tag contains a line break"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			line, err := s.convert(tt.rec, now)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("unexpected error: got=%v exp=%s", err, tt.err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if line != tt.exp {
+				t.Fatalf("unexpected line:\ngot=%s\nexp=%s", line, tt.exp)
+			}
+		})
+	}
+
+	// Rows have the time of the import when there is no time column.
+	s, err = newCSVSchema([]string{"value"}, nil, CSVConfig{Measurement: "m"}, [][]string{{"1"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if line, err := s.convert([]string{"1"}, now); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if exp := "m value=1 100000000000"; line != exp {
+		t.Fatalf("unexpected line: got=%s exp=%s", line, exp)
+	}
+}
+
+// newCSVTestServer returns a server accepting writes, and the bodies of the
+// writes it received.
+func newCSVTestServer(t *testing.T) (*httptest.Server, func() []string) {
+	var (
+		mu     sync.Mutex
+		writes []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ping":
+			w.WriteHeader(http.StatusNoContent)
+		case "/write":
+			if db := r.URL.Query().Get("db"); db != "db0" {
+				t.Errorf("unexpected database: %s", db)
+			} else if precision := r.URL.Query().Get("precision"); precision != "ns" {
+				t.Errorf("unexpected precision: %s", precision)
+			}
+			body, _ := ioutil.ReadAll(r.Body)
+			mu.Lock()
+			writes = append(writes, string(body))
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), writes...)
+	}
+}
+
+func TestCSVImporter_Import(t *testing.T) {
+	srv, writes := newCSVTestServer(t)
+	defer srv.Close()
+
+	dir, err := os.MkdirTemp("", "cnosdb-csv-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	u, _ := url.Parse(srv.URL)
+	config := Config{URL: *u, ClientConfig: &client.HTTPConfig{Addr: srv.URL}}
+	i := NewCSVImporter(config, CSVConfig{
+		Database:    "db0",
+		Tags:        []string{"host"},
+		RejectsPath: filepath.Join(dir, "rejects.csv"),
+	})
+	var stdout bytes.Buffer
+	i.stdout = &stdout
+
+	err = i.Import(strings.NewReader(`#datatype measurement,tag,double,time:unix_ms
+name,host,value,time
+cpu,a,1,1000
+cpu,b,oops,2000
+mem,a,2,3000
+cpu,a
+`))
+	if err == nil || err.Error() != "2 rows were not imported" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, exp := writes(), []string{"cpu,host=a value=1 1000000000\nmem,host=a value=2 3000000000"}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected writes:\ngot=%q\nexp=%q", got, exp)
+	}
+	if got, exp := stdout.String(), "Processed 4 rows, wrote 2 points, rejected 2 rows\n"; got != exp {
+		t.Fatalf("unexpected output:\ngot=%s\nexp=%s", got, exp)
+	}
+
+	rejects, err := os.ReadFile(filepath.Join(dir, "rejects.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := string(rejects), `name,host,value,time,error
+cpu,b,oops,2000,"column value: invalid float ""oops"""
+cpu,a,"expected 4 columns, got 2"
+`; got != exp {
+		t.Fatalf("unexpected rejects:\ngot=%s\nexp=%s", got, exp)
+	}
+}
+
+func TestCSVImporter_Import_DryRun(t *testing.T) {
+	i := NewCSVImporter(Config{ClientConfig: &client.HTTPConfig{}}, CSVConfig{
+		Measurement: "m",
+		Tags:        []string{"host"},
+		Delimiter:   ';',
+		DryRun:      true,
+	})
+	var stdout bytes.Buffer
+	i.stdout = &stdout
+
+	if err := i.Import(strings.NewReader("time;host;value;ok\n2000-01-01T00:00:00Z;a;1;true\nnow;b;2;false\n")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, exp := stdout.String(), `measurement: m
+time: time RFC3339
+host: tag
+value: field float (inferred)
+ok: field boolean (inferred)
+row 2: column time: parsing time "now" as "2006-01-02T15:04:05.999999999Z07:00": cannot parse "now" as "2006"
+1 of the first 2 rows would be rejected
+`; got != exp {
+		t.Fatalf("unexpected output:\ngot=%s\nexp=%s", got, exp)
+	}
+}
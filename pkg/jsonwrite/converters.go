@@ -0,0 +1,276 @@
+// Package jsonwrite converts JSON documents into points according to the
+// JSON write mapping of a database.
+package jsonwrite
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cnosdb/cnosdb/meta"
+	"github.com/cnosdb/cnosdb/vend/db/models"
+)
+
+// Timestamp formats of numeric timestamps.
+const (
+	FormatUnix   = "unix"
+	FormatUnixMs = "unix_ms"
+	FormatUnixUs = "unix_us"
+	FormatUnixNs = "unix_ns"
+)
+
+// pathSeparator separates the keys of a path into a document, keySeparator
+// joins them into the key of a tag or field.
+const (
+	pathSeparator = "."
+	keySeparator  = "_"
+)
+
+// DocumentsToPoints converts a JSON array of documents, or a single document,
+// into points. Numeric timestamps are interpreted with precision unless the
+// mapping specifies a timestamp format, and documents without a timestamp are
+// written at defaultTime.
+//
+// Like models.ParsePointsWithPrecision, the points of all the documents that
+// could be converted are returned along with an error describing the others.
+func DocumentsToPoints(buf []byte, m *meta.JSONWriteMappingInfo, defaultTime time.Time, precision string) ([]models.Point, error) {
+	docs, err := decodeDocuments(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]models.Point, 0, len(docs))
+	var failed []string
+	for i, doc := range docs {
+		pt, err := documentToPoint(doc, m, defaultTime, precision)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("unable to convert document %d: %v", i, err))
+			continue
+		}
+		points = append(points, pt)
+	}
+
+	if len(failed) > 0 {
+		return points, errors.New(strings.Join(failed, "\n"))
+	}
+	return points, nil
+}
+
+func decodeDocuments(buf []byte) ([]map[string]interface{}, error) {
+	buf = bytes.TrimSpace(buf)
+	if len(buf) == 0 {
+		return nil, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+
+	var docs []map[string]interface{}
+	if buf[0] == '{' {
+		var doc map[string]interface{}
+		if err := dec.Decode(&doc); err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	} else if err := dec.Decode(&docs); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+func documentToPoint(doc map[string]interface{}, m *meta.JSONWriteMappingInfo, defaultTime time.Time, precision string) (models.Point, error) {
+	name := m.DefaultMeasurement
+	if m.Measurement != "" {
+		if v, ok := lookup(doc, m.Measurement); ok && v != nil {
+			s, err := toString(v)
+			if err != nil {
+				return nil, fmt.Errorf("measurement: %v", err)
+			}
+			name = s
+		}
+	}
+	if name == "" {
+		return nil, fmt.Errorf("missing measurement at %q", m.Measurement)
+	}
+
+	tags := make(map[string]string)
+	for _, path := range m.Tags {
+		if v, ok := lookup(doc, path); ok {
+			for k, v := range flatten(pathKey(path), v) {
+				s, err := toString(v)
+				if err != nil {
+					return nil, fmt.Errorf("tag %q: %v", k, err)
+				}
+				if s != "" {
+					tags[k] = s
+				}
+			}
+		}
+	}
+
+	fields := make(models.Fields)
+	for _, path := range m.Fields {
+		if v, ok := lookup(doc, path); ok {
+			for k, v := range flatten(pathKey(path), v) {
+				fv, err := toFieldValue(v)
+				if err != nil {
+					return nil, fmt.Errorf("field %q: %v", k, err)
+				}
+				fields[k] = fv
+			}
+		}
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("no fields")
+	}
+
+	t := defaultTime
+	if m.Timestamp != "" {
+		if v, ok := lookup(doc, m.Timestamp); ok && v != nil {
+			var err error
+			if t, err = parseTime(v, m.TimestampFormat, precision); err != nil {
+				return nil, fmt.Errorf("timestamp: %v", err)
+			}
+		}
+	}
+
+	return models.NewPoint(name, models.NewTags(tags), fields, t)
+}
+
+// lookup returns the value at the dot-separated path of doc.
+func lookup(doc map[string]interface{}, path string) (interface{}, bool) {
+	var v interface{} = doc
+	for _, key := range strings.Split(path, pathSeparator) {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if v, ok = obj[key]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// pathKey returns the tag or field key of the value at path.
+func pathKey(path string) string {
+	return strings.Replace(path, pathSeparator, keySeparator, -1)
+}
+
+// flatten returns the scalar values nested in v, keyed by the keys of their
+// parents joined by keySeparator. Elements of arrays are keyed by their index.
+// Null values are dropped.
+func flatten(key string, v interface{}) map[string]interface{} {
+	m := make(map[string]interface{})
+	var walk func(key string, v interface{})
+	walk = func(key string, v interface{}) {
+		switch v := v.(type) {
+		case nil:
+		case map[string]interface{}:
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				walk(key+keySeparator+k, v[k])
+			}
+		case []interface{}:
+			for i, e := range v {
+				walk(key+keySeparator+strconv.Itoa(i), e)
+			}
+		default:
+			m[key] = v
+		}
+	}
+	walk(key, v)
+	return m
+}
+
+func toString(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		return "", fmt.Errorf("unsupported value type %T", v)
+	}
+}
+
+// toFieldValue converts a JSON value into a field value. All numbers are
+// written as floats, so that a field does not conflict with itself when a
+// document omits the decimal point.
+func toFieldValue(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case string, bool:
+		return v, nil
+	case json.Number:
+		return v.Float64()
+	default:
+		return nil, fmt.Errorf("unsupported value type %T", v)
+	}
+}
+
+func parseTime(v interface{}, format, precision string) (time.Time, error) {
+	switch format {
+	case FormatUnix:
+		precision = "s"
+	case FormatUnixMs:
+		precision = "ms"
+	case FormatUnixUs:
+		precision = "u"
+	case FormatUnixNs:
+		precision = "n"
+	case "":
+		if s, ok := v.(string); ok {
+			return time.Parse(time.RFC3339Nano, s)
+		}
+	default:
+		s, ok := v.(string)
+		if !ok {
+			return time.Time{}, fmt.Errorf("expected a string in the format %q", format)
+		}
+		return time.Parse(format, s)
+	}
+
+	var n json.Number
+	switch v := v.(type) {
+	case json.Number:
+		n = v
+	case string:
+		n = json.Number(v)
+	default:
+		return time.Time{}, fmt.Errorf("unsupported value type %T", v)
+	}
+
+	if ts, err := n.Int64(); err == nil {
+		return models.SafeCalcTime(ts, precision)
+	}
+
+	// Fractional timestamps, such as seconds with a millisecond part. The
+	// whole part is scaled apart from the fraction, whose precision would be
+	// lost in a float of nanoseconds.
+	f, err := n.Float64()
+	if err != nil {
+		return time.Time{}, err
+	}
+	whole, frac := math.Modf(f)
+	if whole < math.MinInt64 || whole >= math.MaxInt64 {
+		return time.Time{}, models.ErrTimeOutOfRange
+	}
+	t, err := models.SafeCalcTime(int64(whole), precision)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t = t.Add(time.Duration(math.Round(frac * float64(models.GetPrecisionMultiplier(precision)))))
+	return t, models.CheckTime(t)
+}
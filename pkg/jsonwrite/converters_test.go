@@ -0,0 +1,153 @@
+package jsonwrite
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cnosdb/cnosdb/meta"
+)
+
+func TestFlatten(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		key  string
+		v    interface{}
+		exp  map[string]interface{}
+	}{
+		{name: "scalar", key: "temp", v: json.Number("21"), exp: map[string]interface{}{"temp": json.Number("21")}},
+		{name: "null", key: "temp", v: nil, exp: map[string]interface{}{}},
+		{
+			name: "nested objects",
+			key:  "readings",
+			v: map[string]interface{}{
+				"temp":     json.Number("21"),
+				"humidity": map[string]interface{}{"rel": json.Number("40.5"), "abs": nil},
+			},
+			exp: map[string]interface{}{"readings_temp": json.Number("21"), "readings_humidity_rel": json.Number("40.5")},
+		},
+		{
+			name: "arrays",
+			key:  "probes",
+			v:    []interface{}{"a", map[string]interface{}{"ok": true}, []interface{}{json.Number("1")}},
+			exp:  map[string]interface{}{"probes_0": "a", "probes_1_ok": true, "probes_2_0": json.Number("1")},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := flatten(tt.key, tt.v); !reflect.DeepEqual(got, tt.exp) {
+				t.Fatalf("unexpected values:\ngot=%v\nexp=%v", got, tt.exp)
+			}
+		})
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		v         interface{}
+		format    string
+		precision string
+		exp       time.Time
+		err       string
+	}{
+		{name: "rfc3339", v: "2020-09-13T12:26:40.5Z", exp: time.Date(2020, 9, 13, 12, 26, 40, 5e8, time.UTC)},
+		{name: "default precision", v: json.Number("1600000000000000000"), exp: time.Unix(1600000000, 0).UTC()},
+		{name: "request precision", v: json.Number("1600000000"), precision: "s", exp: time.Unix(1600000000, 0).UTC()},
+		{name: "request precision ms", v: json.Number("1600000000123"), precision: "ms", exp: time.Unix(1600000000, 123e6).UTC()},
+		{name: "unix", v: json.Number("1600000000"), format: FormatUnix, precision: "ms", exp: time.Unix(1600000000, 0).UTC()},
+		{name: "unix fraction", v: json.Number("1600000000.25"), format: FormatUnix, exp: time.Unix(1600000000, 25e7).UTC()},
+		{name: "unix_ms fraction", v: json.Number("1600000000123.5"), format: FormatUnixMs, exp: time.Unix(1600000000, 123500e3).UTC()},
+		{name: "unix_ms", v: json.Number("1600000000123"), format: FormatUnixMs, exp: time.Unix(1600000000, 123e6).UTC()},
+		{name: "unix_us", v: json.Number("1600000000123456"), format: FormatUnixUs, exp: time.Unix(1600000000, 123456e3).UTC()},
+		{name: "unix_ns", v: json.Number("1600000000123456789"), format: FormatUnixNs, exp: time.Unix(1600000000, 123456789).UTC()},
+		{name: "unix string", v: "1600000000", format: FormatUnix, exp: time.Unix(1600000000, 0).UTC()},
+		{name: "layout", v: "2020-09-13 12:26:40", format: "2006-01-02 15:04:05", exp: time.Date(2020, 9, 13, 12, 26, 40, 0, time.UTC)},
+		{name: "layout number", v: json.Number("1"), format: "2006-01-02", err: `expected a string in the format "2006-01-02"`},
+		{name: "unknown format", v: "2020-09-13", format: "iso8601", err: `cannot parse`},
+		{name: "not a number", v: "abc", format: FormatUnixMs, err: `invalid syntax`},
+		{name: "bool", v: true, format: FormatUnix, err: `unsupported value type bool`},
+		{name: "out of range", v: json.Number("9223372036854775807"), format: FormatUnix, err: `time outside range`},
+		{name: "fraction out of range", v: json.Number("1e300"), format: FormatUnix, err: `time outside range`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTime(tt.v, tt.format, tt.precision)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("unexpected error: got=%v exp=%s", err, tt.err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !got.Equal(tt.exp) {
+				t.Fatalf("unexpected time: got=%s exp=%s", got, tt.exp)
+			}
+		})
+	}
+}
+
+func TestDocumentsToPoints(t *testing.T) {
+	mapping := &meta.JSONWriteMappingInfo{
+		Measurement:        "type",
+		DefaultMeasurement: "sensor",
+		Tags:               []string{"device"},
+		Fields:             []string{"readings"},
+		Timestamp:          "ts",
+		TimestampFormat:    FormatUnix,
+	}
+	now := time.Unix(10, 0).UTC()
+
+	for _, tt := range []struct {
+		name string
+		docs string
+		exp  []string
+		err  string
+	}{
+		{
+			name: "single document",
+			docs: `{"type":"climate","device":{"id":"d1","site":"north"},"readings":{"temp":21,"ok":true},"ts":1}`,
+			exp:  []string{"climate,device_id=d1,device_site=north readings_ok=true,readings_temp=21 1000000000"},
+		},
+		{
+			name: "array of documents",
+			docs: `[{"readings":{"temp":1.5}}, {"type":"x","readings":{"name":"a"},"ts":2}]`,
+			exp:  []string{"sensor readings_temp=1.5 10000000000", `x readings_name="a" 2000000000`},
+		},
+		{name: "empty", docs: ` `},
+		{
+			// Numbers are floats whether or not they have a decimal point, so
+			// that the field does not conflict with itself.
+			name: "numbers",
+			docs: `[{"readings":{"v":1},"ts":1},{"readings":{"v":1.5},"ts":2}]`,
+			exp:  []string{"sensor readings_v=1 1000000000", "sensor readings_v=1.5 2000000000"},
+		},
+		{
+			name: "unsupported values",
+			docs: `[{"type":{"a":1},"readings":{"v":1}},{"device":true,"readings":{"v":1}},{"readings":{"v":1},"ts":"x"},{"readings":{"v":2}}]`,
+			exp:  []string{"sensor,device=true readings_v=1 10000000000", "sensor readings_v=2 10000000000"},
+			err: "unable to convert document 0: measurement: unsupported value type map[string]interface {}\n" +
+				"unable to convert document 2: timestamp: strconv.ParseFloat: parsing \"x\": invalid syntax",
+		},
+		{name: "no fields", docs: `{"readings":{}}`, err: "unable to convert document 0: no fields"},
+		{name: "invalid json", docs: `[{"readings":`, err: "unexpected EOF"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			points, err := DocumentsToPoints([]byte(tt.docs), mapping, now, "")
+			if tt.err == "" && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			} else if tt.err != "" && (err == nil || err.Error() != tt.err) {
+				t.Fatalf("unexpected error:\ngot=%v\nexp=%s", err, tt.err)
+			}
+
+			var got []string
+			for _, p := range points {
+				got = append(got, p.String())
+			}
+			if !reflect.DeepEqual(got, tt.exp) {
+				t.Fatalf("unexpected points:\ngot=%q\nexp=%q", got, tt.exp)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+var errInvalidPage = errors.New("parquet: invalid page")
+
+// appendPlain appends the non-null values of v to buf with the plain
+// encoding of t.
+func appendPlain(buf []byte, t Type, v *Values) []byte {
+	n := v.Len(t)
+	switch t {
+	case Boolean:
+		var b byte
+		var k uint
+		for i := 0; i < n; i++ {
+			if v.IsNull(i) {
+				continue
+			}
+			if v.Booleans[i] {
+				b |= 1 << k
+			}
+			if k++; k == 8 {
+				buf, b, k = append(buf, b), 0, 0
+			}
+		}
+		if k > 0 {
+			buf = append(buf, b)
+		}
+	case Int32:
+		for i := 0; i < n; i++ {
+			if !v.IsNull(i) {
+				buf = appendUint32(buf, uint32(v.Int32s[i]))
+			}
+		}
+	case Int64:
+		for i := 0; i < n; i++ {
+			if !v.IsNull(i) {
+				buf = appendUint64(buf, uint64(v.Int64s[i]))
+			}
+		}
+	case Float:
+		for i := 0; i < n; i++ {
+			if !v.IsNull(i) {
+				buf = appendUint32(buf, math.Float32bits(v.Floats[i]))
+			}
+		}
+	case Double:
+		for i := 0; i < n; i++ {
+			if !v.IsNull(i) {
+				buf = appendUint64(buf, math.Float64bits(v.Doubles[i]))
+			}
+		}
+	case ByteArray:
+		for i := 0; i < n; i++ {
+			if !v.IsNull(i) {
+				buf = appendUint32(buf, uint32(len(v.Bytes[i])))
+				buf = append(buf, v.Bytes[i]...)
+			}
+		}
+	}
+	return buf
+}
+
+func appendUint32(buf []byte, v uint32) []byte {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	return append(buf, b[:]...)
+}
+
+func appendUint64(buf []byte, v uint64) []byte {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	return append(buf, b[:]...)
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	return append(buf, b[:binary.PutUvarint(b[:], v)]...)
+}
+
+// decodePlain decodes n values of type t from buf into the given rows of v.
+// It returns the number of bytes read.
+func decodePlain(buf []byte, t Type, n int, v *Values, rows []int) (int, error) {
+	pos := 0
+	switch t {
+	case Boolean:
+		if (n+7)/8 > len(buf) {
+			return 0, errInvalidPage
+		}
+		for i := 0; i < n; i++ {
+			v.Booleans[rows[i]] = buf[i/8]&(1<<(uint(i)%8)) != 0
+		}
+		pos = (n + 7) / 8
+	case Int32, Float:
+		if 4*n > len(buf) {
+			return 0, errInvalidPage
+		}
+		for i := 0; i < n; i++ {
+			u := binary.LittleEndian.Uint32(buf[4*i:])
+			if t == Int32 {
+				v.Int32s[rows[i]] = int32(u)
+			} else {
+				v.Floats[rows[i]] = math.Float32frombits(u)
+			}
+		}
+		pos = 4 * n
+	case Int64, Double:
+		if 8*n > len(buf) {
+			return 0, errInvalidPage
+		}
+		for i := 0; i < n; i++ {
+			u := binary.LittleEndian.Uint64(buf[8*i:])
+			if t == Int64 {
+				v.Int64s[rows[i]] = int64(u)
+			} else {
+				v.Doubles[rows[i]] = math.Float64frombits(u)
+			}
+		}
+		pos = 8 * n
+	case ByteArray:
+		for i := 0; i < n; i++ {
+			if pos+4 > len(buf) {
+				return 0, errInvalidPage
+			}
+			l := int(binary.LittleEndian.Uint32(buf[pos:]))
+			pos += 4
+			if l < 0 || pos+l > len(buf) {
+				return 0, errInvalidPage
+			}
+			v.Bytes[rows[i]] = buf[pos : pos+l : pos+l]
+			pos += l
+		}
+	default:
+		return 0, fmt.Errorf("parquet: unsupported type %s", t)
+	}
+	return pos, nil
+}
+
+// appendLevels appends the definition levels of v, 1 for values and 0 for
+// nulls, with the RLE encoding.
+func appendLevels(buf []byte, v *Values, n int) []byte {
+	for i := 0; i < n; {
+		null := v.IsNull(i)
+		j := i + 1
+		for j < n && v.IsNull(j) == null {
+			j++
+		}
+		buf = appendUvarint(buf, uint64(j-i)<<1)
+		if null {
+			buf = append(buf, 0)
+		} else {
+			buf = append(buf, 1)
+		}
+		i = j
+	}
+	return buf
+}
+
+// decodeHybrid decodes n values of bitWidth bits encoded with the hybrid
+// RLE and bit-packing encoding into dst.
+func decodeHybrid(buf []byte, bitWidth uint, dst []uint32) error {
+	if bitWidth > 32 {
+		return errInvalidPage
+	}
+	byteWidth := int(bitWidth+7) / 8
+	pos, n := 0, 0
+	for n < len(dst) {
+		h, k := binary.Uvarint(buf[pos:])
+		if k <= 0 {
+			return errInvalidPage
+		}
+		pos += k
+
+		if h&1 == 0 {
+			// A run of a repeated value.
+			count := int(h >> 1)
+			if pos+byteWidth > len(buf) || count > len(dst)-n {
+				return errInvalidPage
+			}
+			var v uint32
+			for i := 0; i < byteWidth; i++ {
+				v |= uint32(buf[pos+i]) << (8 * uint(i))
+			}
+			pos += byteWidth
+			for i := 0; i < count; i++ {
+				dst[n+i] = v
+			}
+			n += count
+			continue
+		}
+
+		// Groups of 8 bit-packed values.
+		count := int(h>>1) * 8
+		size := int(h>>1) * int(bitWidth)
+		if pos+size > len(buf) {
+			return errInvalidPage
+		}
+		var acc uint64
+		var accBits uint
+		p := pos
+		for i := 0; i < count; i++ {
+			for accBits < bitWidth {
+				acc |= uint64(buf[p]) << accBits
+				accBits += 8
+				p++
+			}
+			if n < len(dst) {
+				dst[n] = uint32(acc & (1<<bitWidth - 1))
+				n++
+			}
+			acc >>= bitWidth
+			accBits -= bitWidth
+		}
+		pos += size
+	}
+	return nil
+}
+
+func compress(codec Compression, buf []byte) ([]byte, error) {
+	switch codec {
+	case Uncompressed:
+		return buf, nil
+	case Snappy:
+		return snappy.Encode(nil, buf), nil
+	case Gzip:
+		var b bytes.Buffer
+		w := gzip.NewWriter(&b)
+		if _, err := w.Write(buf); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	case Zstd:
+		enc, err := zstd.NewWriter(nil)
+		if err != nil {
+			return nil, err
+		}
+		defer enc.Close()
+		return enc.EncodeAll(buf, nil), nil
+	}
+	return nil, fmt.Errorf("parquet: unsupported compression %d", codec)
+}
+
+func decompress(codec Compression, buf []byte, size int) ([]byte, error) {
+	switch codec {
+	case Uncompressed:
+		return buf, nil
+	case Snappy:
+		return snappy.Decode(make([]byte, size), buf)
+	case Gzip:
+		r, err := gzip.NewReader(bytes.NewReader(buf))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return ioutil.ReadAll(r)
+	case Zstd:
+		dec, err := zstd.NewReader(nil)
+		if err != nil {
+			return nil, err
+		}
+		defer dec.Close()
+		return dec.DecodeAll(buf, make([]byte, 0, size))
+	}
+	return nil, fmt.Errorf("parquet: unsupported compression %d", codec)
+}
@@ -0,0 +1,155 @@
+// Package parquet reads and writes Apache Parquet files with flat schemas
+// of optional columns, the layout used to export measurements.
+//
+// Only the features needed for such files are implemented: data pages of
+// version 1 and 2 with plain or dictionary encoded values, and uncompressed,
+// Snappy, gzip or zstd compressed pages.
+package parquet
+
+import (
+	"errors"
+)
+
+var magic = []byte("PAR1")
+
+// Type is the physical type of the values of a column.
+type Type int32
+
+const (
+	Boolean   Type = 0
+	Int32     Type = 1
+	Int64     Type = 2
+	Float     Type = 4
+	Double    Type = 5
+	ByteArray Type = 6
+)
+
+func (t Type) String() string {
+	switch t {
+	case Boolean:
+		return "BOOLEAN"
+	case Int32:
+		return "INT32"
+	case Int64:
+		return "INT64"
+	case Float:
+		return "FLOAT"
+	case Double:
+		return "DOUBLE"
+	case ByteArray:
+		return "BYTE_ARRAY"
+	}
+	return "UNKNOWN"
+}
+
+// Logical is the logical type of the values of a column.
+type Logical int
+
+const (
+	// None stores values as their physical type.
+	None Logical = iota
+	// String stores UTF-8 strings in byte arrays.
+	String
+	// TimestampNanos stores nanoseconds since the Unix epoch in UTC.
+	TimestampNanos
+	// Uint64 stores unsigned integers in Int64 values.
+	Uint64
+)
+
+// Column describes a column of a file.
+type Column struct {
+	Name     string
+	Type     Type
+	Logical  Logical
+	Optional bool
+}
+
+// Values holds the values of a column for a number of rows. Only the slice
+// matching the type of the column is used, and it holds a value for every
+// row, null or not. Valid reports which rows are not null, all are if it
+// is nil.
+type Values struct {
+	Valid []bool
+
+	Booleans []bool
+	Int32s   []int32
+	Int64s   []int64
+	Floats   []float32
+	Doubles  []float64
+	Bytes    [][]byte
+}
+
+// Len returns the number of rows of v, for a column of type t.
+func (v *Values) Len(t Type) int {
+	switch t {
+	case Boolean:
+		return len(v.Booleans)
+	case Int32:
+		return len(v.Int32s)
+	case Int64:
+		return len(v.Int64s)
+	case Float:
+		return len(v.Floats)
+	case Double:
+		return len(v.Doubles)
+	case ByteArray:
+		return len(v.Bytes)
+	}
+	return 0
+}
+
+// IsNull returns true if row i of v is null.
+func (v *Values) IsNull(i int) bool { return v.Valid != nil && !v.Valid[i] }
+
+// Compression is the codec compressing the pages of a file.
+type Compression int32
+
+const (
+	Uncompressed Compression = 0
+	Snappy       Compression = 1
+	Gzip         Compression = 2
+	Zstd         Compression = 6
+)
+
+// The page types.
+const (
+	pageData       = 0
+	pageDictionary = 2
+	pageDataV2     = 3
+)
+
+// The value encodings.
+const (
+	encodingPlain           = 0
+	encodingPlainDictionary = 2
+	encodingRLE             = 3
+	encodingRLEDictionary   = 8
+)
+
+// The repetitions of columns.
+const (
+	repetitionRequired = 0
+	repetitionOptional = 1
+)
+
+// The converted types of columns, the predecessors of logical types.
+const (
+	convertedUTF8            = 0
+	convertedTimestampMicros = 10
+	convertedTimestampMillis = 9
+	convertedUint64          = 14
+)
+
+// KeyValue is an entry of the metadata of a file.
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+var (
+	// ErrInvalidFile is returned when a file is not a Parquet file.
+	ErrInvalidFile = errors.New("parquet: invalid file")
+
+	// ErrNestedSchema is returned when reading a file with nested columns.
+	ErrNestedSchema = errors.New("parquet: nested columns are not supported")
+)
@@ -0,0 +1,422 @@
+package parquet
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files of testdata")
+
+// allColumns has a column of each type, and optional columns of each type
+// holding nulls.
+var allColumns = []Column{
+	{Name: "time", Type: Int64, Logical: TimestampNanos},
+	{Name: "bool", Type: Boolean, Optional: true},
+	{Name: "int32", Type: Int32, Optional: true},
+	{Name: "int64", Type: Int64, Optional: true},
+	{Name: "uint64", Type: Int64, Logical: Uint64, Optional: true},
+	{Name: "float", Type: Float, Optional: true},
+	{Name: "double", Type: Double, Optional: true},
+	{Name: "bytes", Type: ByteArray, Optional: true},
+	{Name: "string", Type: ByteArray, Logical: String, Optional: true},
+	{Name: "required", Type: ByteArray, Logical: String},
+}
+
+// allValues returns the values of a row group of allColumns. Null values
+// are zero.
+func allValues() []Values {
+	valid := []bool{true, false, true, true, false, true, true, true, true, false}
+	return []Values{
+		{Int64s: []int64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}},
+		{Valid: valid, Booleans: []bool{true, false, false, true, false, true, true, false, true, false}},
+		{Valid: valid, Int32s: []int32{math.MinInt32, 0, -1, 0, 0, 1, math.MaxInt32, 2, 3, 0}},
+		{Valid: valid, Int64s: []int64{math.MinInt64, 0, -1, 0, 0, 1, math.MaxInt64, 2, 3, 0}},
+		{Valid: valid, Int64s: []int64{0, 0, 1, -1, 0, 2, 3, 4, 5, 0}},
+		{Valid: valid, Floats: []float32{-1.5, 0, float32(math.Inf(1)), 0, 0, 2.25, math.MaxFloat32, -0, 1e-10, 0}},
+		{Valid: valid, Doubles: []float64{-1.5, 0, math.Inf(-1), 0, 0, 2.25, math.MaxFloat64, math.SmallestNonzeroFloat64, 1e100, 0}},
+		{Valid: valid, Bytes: [][]byte{{0, 1, 2}, nil, {}, {0xff}, nil, {'a'}, {'b', 'c'}, {0}, {1, 2, 3, 4}, nil}},
+		{Valid: valid, Bytes: [][]byte{[]byte("a"), nil, []byte(""), []byte("é"), nil, []byte("x y"), []byte("\n"), []byte("z"), []byte("日本"), nil}},
+		{Bytes: [][]byte{[]byte("0"), []byte("1"), []byte("2"), []byte("3"), []byte("4"), []byte("5"), []byte("6"), []byte("7"), []byte("8"), []byte("9")}},
+	}
+}
+
+// writeFile writes row groups of allColumns.
+func writeFile(t *testing.T, config WriterConfig, groups ...[]Values) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := NewWriter(&buf, allColumns, config)
+	for _, values := range groups {
+		if err := w.WriteRowGroup(values); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return buf.Bytes()
+}
+
+// readFile reads the row groups of a file.
+func readFile(t *testing.T, buf []byte) (*Reader, [][]Values) {
+	t.Helper()
+	r, err := NewReader(bytes.NewReader(buf), int64(len(buf)))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var groups [][]Values
+	for i := 0; i < r.NumRowGroups(); i++ {
+		values, err := r.ReadRowGroup(i)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		groups = append(groups, values)
+	}
+	return r, groups
+}
+
+// equalValues reports whether the values of a column are equal, comparing
+// floats by their bits.
+func equalValues(a, b Values) bool {
+	if len(a.Floats) != len(b.Floats) || len(a.Doubles) != len(b.Doubles) {
+		return false
+	}
+	for i := range a.Floats {
+		if math.Float32bits(a.Floats[i]) != math.Float32bits(b.Floats[i]) {
+			return false
+		}
+	}
+	for i := range a.Doubles {
+		if math.Float64bits(a.Doubles[i]) != math.Float64bits(b.Doubles[i]) {
+			return false
+		}
+	}
+	a.Floats, b.Floats, a.Doubles, b.Doubles = nil, nil, nil, nil
+	return reflect.DeepEqual(a, b)
+}
+
+func TestWriter_RoundTrip(t *testing.T) {
+	for _, codec := range []Compression{Uncompressed, Snappy, Gzip, Zstd} {
+		config := WriterConfig{
+			Compression: codec,
+			Metadata:    []KeyValue{{Key: "k0", Value: "v0"}, {Key: "k1", Value: ""}},
+			CreatedBy:   "test",
+		}
+		values := allValues()
+		buf := writeFile(t, config, values, values[:len(values):len(values)])
+
+		r, groups := readFile(t, buf)
+		if !reflect.DeepEqual(r.Columns(), allColumns) {
+			t.Fatalf("%d: unexpected columns: %+v", codec, r.Columns())
+		} else if !reflect.DeepEqual(r.Metadata(), config.Metadata) {
+			t.Fatalf("%d: unexpected metadata: %+v", codec, r.Metadata())
+		} else if v, ok := r.Lookup("k1"); !ok || v != "" {
+			t.Fatalf("%d: unexpected lookup: %q, %v", codec, v, ok)
+		} else if _, ok := r.Lookup("k2"); ok {
+			t.Fatalf("%d: unexpected key", codec)
+		} else if r.NumRows() != 20 || len(groups) != 2 {
+			t.Fatalf("%d: unexpected rows: %d in %d row groups", codec, r.NumRows(), len(groups))
+		}
+		for i, group := range groups {
+			if n := r.RowGroupNumRows(i); n != 10 {
+				t.Fatalf("%d: unexpected rows in row group %d: %d", codec, i, n)
+			}
+			for j := range group {
+				if !equalValues(group[j], values[j]) {
+					t.Fatalf("%d: unexpected values of column %s:\ngot=%+v\nexp=%+v", codec, allColumns[j].Name, group[j], values[j])
+				}
+			}
+		}
+	}
+}
+
+func TestWriter_Nulls(t *testing.T) {
+	// A row group of nulls only, and one of nulls then values.
+	n := 20
+	nulls := make([]Values, len(allColumns))
+	mixed := make([]Values, len(allColumns))
+	for i, col := range allColumns {
+		allocValues(&nulls[i], col.Type, n)
+		allocValues(&mixed[i], col.Type, n)
+		if col.Optional {
+			nulls[i].Valid = make([]bool, n)
+			mixed[i].Valid = make([]bool, n)
+			for j := n / 2; j < n; j++ {
+				mixed[i].Valid[j] = true
+			}
+		}
+		if col.Type == ByteArray {
+			for j := range nulls[i].Bytes {
+				if !col.Optional {
+					nulls[i].Bytes[j] = []byte{}
+				}
+				if !mixed[i].IsNull(j) {
+					mixed[i].Bytes[j] = []byte{}
+				}
+			}
+		}
+	}
+
+	r, groups := readFile(t, writeFile(t, WriterConfig{}, nulls, mixed))
+	for i, exp := range [][]Values{nulls, mixed} {
+		for j := range exp {
+			if !equalValues(groups[i][j], exp[j]) {
+				t.Fatalf("unexpected values of column %s in row group %d:\ngot=%+v\nexp=%+v", allColumns[j].Name, i, groups[i][j], exp[j])
+			}
+		}
+	}
+	if _, _, ok := r.Int64Range(0, 3); ok {
+		t.Fatal("unexpected range of a column of nulls")
+	}
+}
+
+func TestWriter_Statistics(t *testing.T) {
+	r, _ := readFile(t, writeFile(t, WriterConfig{}, allValues()))
+	if min, max, ok := r.Int64Range(0, 0); !ok || min != 0 || max != 9 {
+		t.Fatalf("unexpected time range: %d, %d, %v", min, max, ok)
+	} else if min, max, ok := r.Int64Range(0, 3); !ok || min != math.MinInt64 || max != math.MaxInt64 {
+		t.Fatalf("unexpected int64 range: %d, %d, %v", min, max, ok)
+	} else if _, _, ok := r.Int64Range(0, 6); ok {
+		t.Fatal("unexpected range of a double column")
+	}
+
+	// Unsigned statistics are ordered as unsigned integers.
+	if min, max, ok := r.Int64Range(0, 4); !ok || min != 0 || uint64(max) != math.MaxUint64 {
+		t.Fatalf("unexpected uint64 range: %d, %d, %v", min, max, ok)
+	}
+}
+
+func TestWriter_Errors(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		values func([]Values) []Values
+		err    string
+	}{
+		{
+			name:   "missing column",
+			values: func(v []Values) []Values { return v[:len(v)-1] },
+			err:    "parquet: 10 columns, got values of 9",
+		},
+		{
+			name:   "short column",
+			values: func(v []Values) []Values { v[1].Booleans = v[1].Booleans[:9]; return v },
+			err:    "parquet: column bool has 9 rows, expected 10",
+		},
+		{
+			name:   "short validity",
+			values: func(v []Values) []Values { v[1].Valid = v[1].Valid[:9]; return v },
+			err:    "parquet: column bool has 9 validity flags, expected 10",
+		},
+		{
+			name:   "null required",
+			values: func(v []Values) []Values { v[9].Valid = make([]bool, 10); return v },
+			err:    "parquet: column required is required",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWriter(ioutil.Discard, allColumns, WriterConfig{})
+			if err := w.WriteRowGroup(tt.values(allValues())); err == nil || err.Error() != tt.err {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+
+	w := NewWriter(ioutil.Discard, allColumns, WriterConfig{})
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if err := w.WriteRowGroup(allValues()); err == nil || err.Error() != "parquet: writer closed" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestWriter_Empty(t *testing.T) {
+	r, groups := readFile(t, writeFile(t, WriterConfig{}))
+	if r.NumRows() != 0 || len(groups) != 0 {
+		t.Fatalf("unexpected rows: %d in %d row groups", r.NumRows(), len(groups))
+	} else if !reflect.DeepEqual(r.Columns(), allColumns) {
+		t.Fatalf("unexpected columns: %+v", r.Columns())
+	}
+}
+
+func TestNewReader_Invalid(t *testing.T) {
+	buf := writeFile(t, WriterConfig{}, allValues())
+	for _, b := range [][]byte{
+		nil,
+		[]byte("PAR1PAR1"),
+		[]byte("PAR1\x00\x00\x00\x00PAR2"),
+		append(append([]byte{}, buf[:len(buf)-8]...), 0xff, 0xff, 0xff, 0x00, 'P', 'A', 'R', '1'),
+	} {
+		if _, err := NewReader(bytes.NewReader(b), int64(len(b))); err == nil {
+			t.Fatalf("expected error reading %q", b)
+		}
+	}
+}
+
+// TestReader_Pages reads the pages other writers write: dictionary pages,
+// dictionary encoded data pages, and pages of version 2 with their levels
+// outside of the compressed data.
+func TestReader_Pages(t *testing.T) {
+	columns := []Column{
+		{Name: "s", Type: ByteArray, Logical: String, Optional: true},
+		{Name: "n", Type: Int64},
+	}
+
+	pageHeader := func(typ int32, size int, header func(h *thriftWriter)) []byte {
+		var h thriftWriter
+		h.structBegin()
+		h.fieldI32(1, typ)
+		h.fieldI32(2, int32(size))
+		h.fieldI32(3, int32(size))
+		header(&h)
+		h.structEnd()
+		return h.buf
+	}
+	dictionaryPage := func(n int, data []byte) []byte {
+		return append(pageHeader(pageDictionary, len(data), func(h *thriftWriter) {
+			h.fieldStructBegin(7)
+			h.fieldI32(1, int32(n))
+			h.fieldI32(2, encodingPlain)
+			h.structEnd()
+		}), data...)
+	}
+	dataPage := func(n int, encoding int32, data []byte) []byte {
+		return append(pageHeader(pageData, len(data), func(h *thriftWriter) {
+			h.fieldStructBegin(5)
+			h.fieldI32(1, int32(n))
+			h.fieldI32(2, encoding)
+			h.fieldI32(3, encodingRLE)
+			h.fieldI32(4, encodingRLE)
+			h.structEnd()
+		}), data...)
+	}
+	dataPageV2 := func(n, nulls int, encoding int32, levels, data []byte) []byte {
+		return append(pageHeader(pageDataV2, len(levels)+len(data), func(h *thriftWriter) {
+			h.fieldStructBegin(8)
+			h.fieldI32(1, int32(n))
+			h.fieldI32(2, int32(nulls))
+			h.fieldI32(3, int32(n))
+			h.fieldI32(4, encoding)
+			h.fieldI32(5, int32(len(levels)))
+			h.fieldI32(6, 0)
+			h.fieldBool(7, false)
+			h.structEnd()
+		}), append(levels, data...)...)
+	}
+
+	// Column s holds ["a", null, "b", "a"] in a dictionary encoded page with
+	// bit-packed levels and indexes, then ["b", null] in a page of version 2
+	// with run-length encoded levels and indexes.
+	var s []byte
+	s = append(s, dictionaryPage(2, []byte("\x01\x00\x00\x00a\x01\x00\x00\x00b"))...)
+	s = append(s, dataPage(4, encodingRLEDictionary, []byte{2, 0, 0, 0, 0x03, 0x0d, 1, 0x03, 0x02})...)
+	s = append(s, dataPageV2(2, 1, encodingRLEDictionary, []byte{0x02, 1, 0x02, 0}, []byte{1, 0x02, 1})...)
+
+	// Column n holds [20, 20, 10, 20] in a dictionary encoded page, then
+	// [30, 40] in a plain encoded page of version 2.
+	var n []byte
+	n = append(n, dictionaryPage(2, appendUint64(appendUint64(nil, 10), 20))...)
+	n = append(n, dataPage(4, encodingPlainDictionary, []byte{1, 0x03, 0x0b})...)
+	n = append(n, dataPageV2(2, 0, encodingPlain, nil, appendUint64(appendUint64(nil, 30), 40))...)
+
+	w := &Writer{columns: columns, pos: int64(len(magic))}
+	rg := rowGroupInfo{numRows: 6}
+	for _, chunk := range [][]byte{s, n} {
+		rg.chunks = append(rg.chunks, chunkInfo{offset: w.pos, numValues: 6, uncompressed: int64(len(chunk)), compressed: int64(len(chunk))})
+		w.pos += int64(len(chunk))
+	}
+	w.rowGroups, w.numRows = []rowGroupInfo{rg}, 6
+
+	buf := append(append(append([]byte{}, magic...), s...), n...)
+	footer := w.footer()
+	buf = append(buf, footer...)
+	buf = appendUint32(buf, uint32(len(footer)))
+	buf = append(buf, magic...)
+
+	_, groups := readFile(t, buf)
+	exp := []Values{
+		{Valid: []bool{true, false, true, true, true, false}, Bytes: [][]byte{[]byte("a"), nil, []byte("b"), []byte("a"), []byte("b"), nil}},
+		{Int64s: []int64{20, 20, 10, 20, 30, 40}},
+	}
+	if len(groups) != 1 || !reflect.DeepEqual(groups[0], exp) {
+		t.Fatalf("unexpected values:\ngot=%+v\nexp=%+v", groups, exp)
+	}
+}
+
+// TestWriter_Golden compares the files written with files of testdata. The
+// files are checked in so that changes of the output are reviewed, and are
+// rewritten with the -update flag.
+func TestWriter_Golden(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		codec Compression
+	}{
+		{name: "uncompressed.parquet", codec: Uncompressed},
+		{name: "snappy.parquet", codec: Snappy},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			config := WriterConfig{
+				Compression: tt.codec,
+				Metadata:    []KeyValue{{Key: "k", Value: "v"}},
+				CreatedBy:   "cnosdb",
+			}
+			buf := writeFile(t, config, allValues())
+
+			path := filepath.Join("testdata", tt.name)
+			if *update {
+				if err := ioutil.WriteFile(path, buf, 0666); err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+			}
+			golden, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			} else if !bytes.Equal(buf, golden) {
+				t.Fatalf("output differs from %s", path)
+			}
+
+			_, groups := readFile(t, golden)
+			values := allValues()
+			for j := range values {
+				if !equalValues(groups[0][j], values[j]) {
+					t.Fatalf("unexpected values of column %s", allColumns[j].Name)
+				}
+			}
+		})
+	}
+}
+
+// TestReader_ArrowGo reads the files of testdata written by the Go
+// implementation of Apache Arrow with the values of allValues: dictionary
+// encoded pages of version 1, dictionary encoded pages of version 2, and
+// plain encoded pages of version 2, with Snappy, zstd and gzip. The files
+// are rewritten by the tests of tests/reference.
+func TestReader_ArrowGo(t *testing.T) {
+	for _, name := range []string{"arrow-go-v1.parquet", "arrow-go-v2.parquet", "arrow-go-plain.parquet"} {
+		t.Run(name, func(t *testing.T) {
+			buf, err := ioutil.ReadFile(filepath.Join("testdata", name))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			r, groups := readFile(t, buf)
+			if !reflect.DeepEqual(r.Columns(), allColumns) {
+				t.Fatalf("unexpected columns: %+v", r.Columns())
+			} else if len(groups) != 1 {
+				t.Fatalf("unexpected row groups: %d", len(groups))
+			}
+			values := allValues()
+			for j := range values {
+				if !equalValues(groups[0][j], values[j]) {
+					t.Fatalf("unexpected values of column %s:\ngot=%+v\nexp=%+v", allColumns[j].Name, groups[0][j], values[j])
+				}
+			}
+			if min, max, ok := r.Int64Range(0, 0); !ok || min != 0 || max != 9 {
+				t.Fatalf("unexpected time range: %d, %d, %v", min, max, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,406 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// The logical types only found in files written by other applications.
+const (
+	// TimestampMillis stores milliseconds since the Unix epoch.
+	TimestampMillis Logical = iota + 100
+	// TimestampMicros stores microseconds since the Unix epoch.
+	TimestampMicros
+)
+
+// maxFooterSize limits the size of the metadata of the files read.
+const maxFooterSize = 64 << 20
+
+// Reader reads the row groups of a Parquet file.
+type Reader struct {
+	r        io.ReaderAt
+	columns  []Column
+	groups   []thriftStructValue
+	numRows  int64
+	metadata []KeyValue
+}
+
+// NewReader reads the metadata of the file of the given size read from r.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	if size < 12 {
+		return nil, ErrInvalidFile
+	}
+	var tail [8]byte
+	if _, err := r.ReadAt(tail[:], size-8); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(tail[4:], magic) {
+		return nil, ErrInvalidFile
+	}
+	n := int64(binary.LittleEndian.Uint32(tail[:4]))
+	if n > size-12 || n > maxFooterSize {
+		return nil, ErrInvalidFile
+	}
+
+	buf := make([]byte, n)
+	if _, err := r.ReadAt(buf, size-8-n); err != nil {
+		return nil, err
+	}
+	meta, err := (&thriftReader{buf: buf}).readStruct()
+	if err != nil {
+		return nil, err
+	}
+
+	pr := &Reader{r: r, numRows: meta.i64(3)}
+	if pr.columns, err = readSchema(meta.list(2)); err != nil {
+		return nil, err
+	}
+	for _, v := range meta.list(4) {
+		rg, _ := v.(thriftStructValue)
+		if len(rg.list(1)) != len(pr.columns) {
+			return nil, ErrInvalidFile
+		}
+		pr.groups = append(pr.groups, rg)
+	}
+	for _, v := range meta.list(5) {
+		kv, _ := v.(thriftStructValue)
+		pr.metadata = append(pr.metadata, KeyValue{Key: kv.str(1), Value: kv.str(2)})
+	}
+	return pr, nil
+}
+
+// readSchema returns the columns of a flat schema.
+func readSchema(elems []interface{}) ([]Column, error) {
+	if len(elems) == 0 {
+		return nil, ErrInvalidFile
+	}
+	root, _ := elems[0].(thriftStructValue)
+	if int(root.i64(5)) != len(elems)-1 {
+		return nil, ErrNestedSchema
+	}
+
+	columns := make([]Column, 0, len(elems)-1)
+	for _, v := range elems[1:] {
+		e, _ := v.(thriftStructValue)
+		if e.i64(5) > 0 || !e.has(1) {
+			return nil, ErrNestedSchema
+		}
+		col := Column{Name: e.str(4), Type: Type(e.i64(1))}
+		switch e.i64(3) {
+		case repetitionRequired:
+		case repetitionOptional:
+			col.Optional = true
+		default:
+			return nil, ErrNestedSchema
+		}
+
+		if lt := e.strct(10); lt != nil {
+			switch {
+			case lt.has(1):
+				col.Logical = String
+			case lt.has(8):
+				switch unit := lt.strct(8).strct(2); {
+				case unit.has(1):
+					col.Logical = TimestampMillis
+				case unit.has(2):
+					col.Logical = TimestampMicros
+				case unit.has(3):
+					col.Logical = TimestampNanos
+				}
+			case lt.has(10):
+				if it := lt.strct(10); !it.bool(2) && it.i64(1) == 64 {
+					col.Logical = Uint64
+				}
+			}
+		} else if e.has(6) {
+			switch e.i64(6) {
+			case convertedUTF8:
+				col.Logical = String
+			case convertedTimestampMillis:
+				col.Logical = TimestampMillis
+			case convertedTimestampMicros:
+				col.Logical = TimestampMicros
+			case convertedUint64:
+				col.Logical = Uint64
+			}
+		}
+		columns = append(columns, col)
+	}
+	return columns, nil
+}
+
+// Columns returns the columns of the file.
+func (r *Reader) Columns() []Column { return r.columns }
+
+// Metadata returns the key-value metadata of the file.
+func (r *Reader) Metadata() []KeyValue { return r.metadata }
+
+// Lookup returns the metadata value of key.
+func (r *Reader) Lookup(key string) (string, bool) {
+	for _, kv := range r.metadata {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return "", false
+}
+
+// NumRows returns the number of rows of the file.
+func (r *Reader) NumRows() int64 { return r.numRows }
+
+// NumRowGroups returns the number of row groups of the file.
+func (r *Reader) NumRowGroups() int { return len(r.groups) }
+
+// RowGroupNumRows returns the number of rows of row group i.
+func (r *Reader) RowGroupNumRows(i int) int64 { return r.groups[i].i64(3) }
+
+func (r *Reader) chunkMeta(rg, col int) thriftStructValue {
+	c, _ := r.groups[rg].list(1)[col].(thriftStructValue)
+	return c.strct(3)
+}
+
+// Int64Range returns the minimum and maximum of the Int64 column col in
+// row group rg, if the file has statistics for it.
+func (r *Reader) Int64Range(rg, col int) (min, max int64, ok bool) {
+	if r.columns[col].Type != Int64 {
+		return 0, 0, false
+	}
+	stats := r.chunkMeta(rg, col).strct(12)
+	lo, hi := stats.binary(6), stats.binary(5)
+	if len(lo) != 8 || len(hi) != 8 {
+		return 0, 0, false
+	}
+	return int64(binary.LittleEndian.Uint64(lo)), int64(binary.LittleEndian.Uint64(hi)), true
+}
+
+// ReadRowGroup reads the values of all columns of row group i.
+func (r *Reader) ReadRowGroup(i int) ([]Values, error) {
+	values := make([]Values, len(r.columns))
+	for j := range r.columns {
+		v, err := r.ReadColumn(i, j)
+		if err != nil {
+			return nil, err
+		}
+		values[j] = v
+	}
+	return values, nil
+}
+
+// ReadColumn reads the values of column col of row group rg.
+func (r *Reader) ReadColumn(rg, col int) (Values, error) {
+	n := r.RowGroupNumRows(rg)
+	if n < 0 || n > math.MaxInt32 {
+		return Values{}, ErrInvalidFile
+	}
+
+	var v Values
+	c := r.columns[col]
+	allocValues(&v, c.Type, int(n))
+	if c.Optional {
+		v.Valid = make([]bool, n)
+	}
+	if err := r.readChunk(r.chunkMeta(rg, col), c, &v); err != nil {
+		return Values{}, fmt.Errorf("parquet: column %s: %s", c.Name, err)
+	}
+	return v, nil
+}
+
+func allocValues(v *Values, t Type, n int) {
+	switch t {
+	case Boolean:
+		v.Booleans = make([]bool, n)
+	case Int32:
+		v.Int32s = make([]int32, n)
+	case Int64:
+		v.Int64s = make([]int64, n)
+	case Float:
+		v.Floats = make([]float32, n)
+	case Double:
+		v.Doubles = make([]float64, n)
+	case ByteArray:
+		v.Bytes = make([][]byte, n)
+	}
+}
+
+// readChunk reads the pages of a column chunk into v.
+func (r *Reader) readChunk(meta thriftStructValue, col Column, v *Values) error {
+	if meta == nil {
+		return ErrInvalidFile
+	}
+	codec := Compression(meta.i64(4))
+	start := meta.i64(9)
+	if off := meta.i64(11); meta.has(11) && off > 0 && off < start {
+		start = off
+	}
+	size := meta.i64(7)
+	if start < 0 || size < 0 || size > math.MaxInt32 {
+		return ErrInvalidFile
+	}
+	buf := make([]byte, size)
+	if _, err := r.r.ReadAt(buf, start); err != nil {
+		return err
+	}
+
+	rows := v.Len(col.Type)
+	var dict Values
+	var dictLen int
+	row := 0
+	for pos := 0; row < rows && pos < len(buf); {
+		tr := &thriftReader{buf: buf[pos:]}
+		h, err := tr.readStruct()
+		if err != nil {
+			return err
+		}
+		pos += tr.pos
+		compressed := int(h.i64(3))
+		if compressed < 0 || pos+compressed > len(buf) {
+			return errInvalidPage
+		}
+		data := buf[pos : pos+compressed]
+		pos += compressed
+
+		switch h.i64(1) {
+		case pageDictionary:
+			dh := h.strct(7)
+			if data, err = decompress(codec, data, int(h.i64(2))); err != nil {
+				return err
+			}
+			dictLen = int(dh.i64(1))
+			if dictLen < 0 || dictLen > len(data)*8+1 {
+				return errInvalidPage
+			}
+			allocValues(&dict, col.Type, dictLen)
+			if _, err := decodePlain(data, col.Type, dictLen, &dict, identity(dictLen)); err != nil {
+				return err
+			}
+		case pageData:
+			dh := h.strct(5)
+			if data, err = decompress(codec, data, int(h.i64(2))); err != nil {
+				return err
+			}
+			n := int(dh.i64(1))
+			if n < 0 || n > rows-row {
+				return errInvalidPage
+			}
+			var levels []byte
+			if col.Optional {
+				if len(data) < 4 {
+					return errInvalidPage
+				}
+				l := int(binary.LittleEndian.Uint32(data))
+				if l < 0 || 4+l > len(data) {
+					return errInvalidPage
+				}
+				levels, data = data[4:4+l], data[4+l:]
+			}
+			if err := readPage(col, v, row, n, levels, data, int(dh.i64(2)), &dict, dictLen); err != nil {
+				return err
+			}
+			row += n
+		case pageDataV2:
+			dh := h.strct(8)
+			n := int(dh.i64(1))
+			defLen, repLen := int(dh.i64(5)), int(dh.i64(6))
+			if n < 0 || n > rows-row || defLen < 0 || repLen < 0 || repLen+defLen > len(data) {
+				return errInvalidPage
+			}
+			levels := data[repLen : repLen+defLen]
+			data = data[repLen+defLen:]
+			if !dh.has(7) || dh.bool(7) {
+				if data, err = decompress(codec, data, int(h.i64(2))-repLen-defLen); err != nil {
+					return err
+				}
+			}
+			if !col.Optional {
+				levels = nil
+			}
+			if err := readPage(col, v, row, n, levels, data, int(dh.i64(4)), &dict, dictLen); err != nil {
+				return err
+			}
+			row += n
+		}
+	}
+	if row != rows {
+		return errInvalidPage
+	}
+	return nil
+}
+
+// readPage decodes the n values of a data page into v, starting at row.
+func readPage(col Column, v *Values, row, n int, levels, data []byte, encoding int, dict *Values, dictLen int) error {
+	rows := make([]int, 0, n)
+	if levels != nil {
+		defs := make([]uint32, n)
+		if err := decodeHybrid(levels, 1, defs); err != nil {
+			return err
+		}
+		for i, d := range defs {
+			if d == 1 {
+				v.Valid[row+i] = true
+				rows = append(rows, row+i)
+			}
+		}
+	} else {
+		for i := 0; i < n; i++ {
+			rows = append(rows, row+i)
+		}
+		if v.Valid != nil {
+			for i := 0; i < n; i++ {
+				v.Valid[row+i] = true
+			}
+		}
+	}
+
+	switch encoding {
+	case encodingPlain:
+		_, err := decodePlain(data, col.Type, len(rows), v, rows)
+		return err
+	case encodingPlainDictionary, encodingRLEDictionary:
+		if len(data) < 1 {
+			if len(rows) == 0 {
+				return nil
+			}
+			return errInvalidPage
+		}
+		idx := make([]uint32, len(rows))
+		if err := decodeHybrid(data[1:], uint(data[0]), idx); err != nil {
+			return err
+		}
+		for i, k := range idx {
+			if int(k) >= dictLen {
+				return errInvalidPage
+			}
+			copyValue(col.Type, v, rows[i], dict, int(k))
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported encoding %d", encoding)
+}
+
+func copyValue(t Type, dst *Values, i int, src *Values, j int) {
+	switch t {
+	case Boolean:
+		dst.Booleans[i] = src.Booleans[j]
+	case Int32:
+		dst.Int32s[i] = src.Int32s[j]
+	case Int64:
+		dst.Int64s[i] = src.Int64s[j]
+	case Float:
+		dst.Floats[i] = src.Floats[j]
+	case Double:
+		dst.Doubles[i] = src.Doubles[j]
+	case ByteArray:
+		dst.Bytes[i] = src.Bytes[j]
+	}
+}
+
+func identity(n int) []int {
+	a := make([]int, n)
+	for i := range a {
+		a[i] = i
+	}
+	return a
+}
@@ -0,0 +1,373 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// WriterConfig configures a Writer.
+type WriterConfig struct {
+	// Compression is the codec compressing the pages.
+	Compression Compression
+	// Metadata is stored in the footer of the file.
+	Metadata []KeyValue
+	// CreatedBy names the application writing the file.
+	CreatedBy string
+}
+
+// Writer writes a Parquet file, a row group at a time.
+type Writer struct {
+	w       io.Writer
+	pos     int64
+	columns []Column
+	config  WriterConfig
+
+	rowGroups []rowGroupInfo
+	numRows   int64
+	closed    bool
+	err       error
+}
+
+type rowGroupInfo struct {
+	chunks  []chunkInfo
+	numRows int64
+	size    int64
+}
+
+type chunkInfo struct {
+	offset       int64
+	numValues    int64
+	uncompressed int64
+	compressed   int64
+
+	nulls    int64
+	min, max []byte
+}
+
+// NewWriter returns a writer of a file with the given columns to w.
+func NewWriter(w io.Writer, columns []Column, config WriterConfig) *Writer {
+	return &Writer{w: w, columns: columns, config: config}
+}
+
+// Columns returns the columns of the file.
+func (w *Writer) Columns() []Column { return w.columns }
+
+func (w *Writer) write(buf []byte) error {
+	if w.err != nil {
+		return w.err
+	}
+	n, err := w.w.Write(buf)
+	w.pos += int64(n)
+	w.err = err
+	return err
+}
+
+// WriteRowGroup writes the values of a row group, one Values per column.
+func (w *Writer) WriteRowGroup(values []Values) error {
+	if w.closed {
+		return errors.New("parquet: writer closed")
+	}
+	if len(values) != len(w.columns) {
+		return fmt.Errorf("parquet: %d columns, got values of %d", len(w.columns), len(values))
+	}
+	n := 0
+	for i, col := range w.columns {
+		l := values[i].Len(col.Type)
+		if i == 0 {
+			n = l
+		} else if l != n {
+			return fmt.Errorf("parquet: column %s has %d rows, expected %d", col.Name, l, n)
+		}
+		if values[i].Valid != nil && len(values[i].Valid) != n {
+			return fmt.Errorf("parquet: column %s has %d validity flags, expected %d", col.Name, len(values[i].Valid), n)
+		}
+		if !col.Optional && values[i].Valid != nil {
+			for _, ok := range values[i].Valid {
+				if !ok {
+					return fmt.Errorf("parquet: column %s is required", col.Name)
+				}
+			}
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+
+	if w.pos == 0 {
+		if err := w.write(magic); err != nil {
+			return err
+		}
+	}
+
+	rg := rowGroupInfo{numRows: int64(n)}
+	for i, col := range w.columns {
+		c, err := w.writeChunk(col, &values[i], n)
+		if err != nil {
+			return err
+		}
+		rg.chunks = append(rg.chunks, c)
+		rg.size += c.uncompressed
+	}
+	w.rowGroups = append(w.rowGroups, rg)
+	w.numRows += int64(n)
+	return nil
+}
+
+// writeChunk writes a column chunk made of a single data page.
+func (w *Writer) writeChunk(col Column, v *Values, n int) (chunkInfo, error) {
+	var page []byte
+	if col.Optional {
+		page = append(page, 0, 0, 0, 0)
+		page = appendLevels(page, v, n)
+		binary.LittleEndian.PutUint32(page, uint32(len(page)-4))
+	}
+	page = appendPlain(page, col.Type, v)
+
+	data, err := compress(w.config.Compression, page)
+	if err != nil {
+		return chunkInfo{}, err
+	}
+	if len(page) > math.MaxInt32 || len(data) > math.MaxInt32 {
+		return chunkInfo{}, fmt.Errorf("parquet: page of column %s too large", col.Name)
+	}
+
+	var h thriftWriter
+	h.structBegin()
+	h.fieldI32(1, pageData)
+	h.fieldI32(2, int32(len(page)))
+	h.fieldI32(3, int32(len(data)))
+	h.fieldStructBegin(5)
+	h.fieldI32(1, int32(n))
+	h.fieldI32(2, encodingPlain)
+	h.fieldI32(3, encodingRLE)
+	h.fieldI32(4, encodingRLE)
+	h.structEnd()
+	h.structEnd()
+
+	c := chunkInfo{
+		offset:       w.pos,
+		numValues:    int64(n),
+		uncompressed: int64(len(h.buf) + len(page)),
+		compressed:   int64(len(h.buf) + len(data)),
+	}
+	c.nulls, c.min, c.max = statistics(col, v, n)
+
+	if err := w.write(h.buf); err != nil {
+		return chunkInfo{}, err
+	}
+	if err := w.write(data); err != nil {
+		return chunkInfo{}, err
+	}
+	return c, nil
+}
+
+// statistics returns the number of nulls and the plain encoded minimum and
+// maximum of the values of a column.
+func statistics(col Column, v *Values, n int) (nulls int64, min, max []byte) {
+	lo, hi := -1, -1
+	for i := 0; i < n; i++ {
+		if v.IsNull(i) {
+			nulls++
+			continue
+		}
+		if col.Type == Double && math.IsNaN(v.Doubles[i]) || col.Type == Float && math.IsNaN(float64(v.Floats[i])) {
+			continue
+		}
+		if lo < 0 {
+			lo, hi = i, i
+			continue
+		}
+		if less(col, v, i, lo) {
+			lo = i
+		}
+		if less(col, v, hi, i) {
+			hi = i
+		}
+	}
+	if lo < 0 {
+		return nulls, nil, nil
+	}
+	return nulls, plainValue(col.Type, v, lo), plainValue(col.Type, v, hi)
+}
+
+// less returns true if value i of v sorts before value j.
+func less(col Column, v *Values, i, j int) bool {
+	switch col.Type {
+	case Boolean:
+		return !v.Booleans[i] && v.Booleans[j]
+	case Int32:
+		return v.Int32s[i] < v.Int32s[j]
+	case Int64:
+		if col.Logical == Uint64 {
+			return uint64(v.Int64s[i]) < uint64(v.Int64s[j])
+		}
+		return v.Int64s[i] < v.Int64s[j]
+	case Float:
+		return v.Floats[i] < v.Floats[j]
+	case Double:
+		return v.Doubles[i] < v.Doubles[j]
+	case ByteArray:
+		return bytes.Compare(v.Bytes[i], v.Bytes[j]) < 0
+	}
+	return false
+}
+
+// plainValue returns value i of v, plain encoded.
+func plainValue(t Type, v *Values, i int) []byte {
+	switch t {
+	case Boolean:
+		if v.Booleans[i] {
+			return []byte{1}
+		}
+		return []byte{0}
+	case Int32:
+		return appendUint32(nil, uint32(v.Int32s[i]))
+	case Int64:
+		return appendUint64(nil, uint64(v.Int64s[i]))
+	case Float:
+		return appendUint32(nil, math.Float32bits(v.Floats[i]))
+	case Double:
+		return appendUint64(nil, math.Float64bits(v.Doubles[i]))
+	case ByteArray:
+		return v.Bytes[i]
+	}
+	return nil
+}
+
+// Close writes the footer of the file. It does not close the underlying
+// writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return w.err
+	}
+	w.closed = true
+
+	if w.pos == 0 {
+		if err := w.write(magic); err != nil {
+			return err
+		}
+	}
+
+	footer := w.footer()
+	footer = appendUint32(footer, uint32(len(footer)))
+	footer = append(footer, magic...)
+	return w.write(footer)
+}
+
+// footer encodes the FileMetaData of the file.
+func (w *Writer) footer() []byte {
+	var t thriftWriter
+	t.structBegin()
+	t.fieldI32(1, 1)
+
+	// The schema is a root group of all the columns.
+	t.fieldList(2, thriftStruct, len(w.columns)+1)
+	t.structBegin()
+	t.fieldBinary(4, []byte("schema"))
+	t.fieldI32(5, int32(len(w.columns)))
+	t.structEnd()
+	for _, col := range w.columns {
+		t.structBegin()
+		t.fieldI32(1, int32(col.Type))
+		if col.Optional {
+			t.fieldI32(3, repetitionOptional)
+		} else {
+			t.fieldI32(3, repetitionRequired)
+		}
+		t.fieldBinary(4, []byte(col.Name))
+		switch col.Logical {
+		case String:
+			t.fieldI32(6, convertedUTF8)
+			t.fieldStructBegin(10)
+			t.fieldStructBegin(1) // STRING
+			t.structEnd()
+			t.structEnd()
+		case TimestampNanos:
+			t.fieldStructBegin(10)
+			t.fieldStructBegin(8) // TIMESTAMP
+			t.fieldBool(1, true)
+			t.fieldStructBegin(2)
+			t.fieldStructBegin(3) // NANOS
+			t.structEnd()
+			t.structEnd()
+			t.structEnd()
+			t.structEnd()
+		case Uint64:
+			t.fieldI32(6, convertedUint64)
+			t.fieldStructBegin(10)
+			t.fieldStructBegin(10) // INTEGER
+			t.field(1, thriftByte)
+			t.buf = append(t.buf, 64)
+			t.fieldBool(2, false)
+			t.structEnd()
+			t.structEnd()
+		}
+		t.structEnd()
+	}
+
+	t.fieldI64(3, w.numRows)
+
+	t.fieldList(4, thriftStruct, len(w.rowGroups))
+	for _, rg := range w.rowGroups {
+		t.structBegin()
+		t.fieldList(1, thriftStruct, len(rg.chunks))
+		for i, c := range rg.chunks {
+			col := w.columns[i]
+			t.structBegin()
+			t.fieldI64(2, c.offset)
+			t.fieldStructBegin(3)
+			t.fieldI32(1, int32(col.Type))
+			t.fieldList(2, thriftI32, 2)
+			t.zigzag(encodingPlain)
+			t.zigzag(encodingRLE)
+			t.fieldList(3, thriftBinary, 1)
+			t.binary([]byte(col.Name))
+			t.fieldI32(4, int32(w.config.Compression))
+			t.fieldI64(5, c.numValues)
+			t.fieldI64(6, c.uncompressed)
+			t.fieldI64(7, c.compressed)
+			t.fieldI64(9, c.offset)
+			t.fieldStructBegin(12)
+			t.fieldI64(3, c.nulls)
+			if c.min != nil {
+				t.fieldBinary(5, c.max)
+				t.fieldBinary(6, c.min)
+			}
+			t.structEnd()
+			t.structEnd()
+			t.structEnd()
+		}
+		t.fieldI64(2, rg.size)
+		t.fieldI64(3, rg.numRows)
+		t.structEnd()
+	}
+
+	if len(w.config.Metadata) > 0 {
+		t.fieldList(5, thriftStruct, len(w.config.Metadata))
+		for _, kv := range w.config.Metadata {
+			t.structBegin()
+			t.fieldBinary(1, []byte(kv.Key))
+			t.fieldBinary(2, []byte(kv.Value))
+			t.structEnd()
+		}
+	}
+	if w.config.CreatedBy != "" {
+		t.fieldBinary(6, []byte(w.config.CreatedBy))
+	}
+
+	// The statistics follow the order of the types of the columns.
+	t.fieldList(7, thriftStruct, len(w.columns))
+	for range w.columns {
+		t.structBegin()
+		t.fieldStructBegin(1) // TYPE_ORDER
+		t.structEnd()
+		t.structEnd()
+	}
+
+	t.structEnd()
+	return t.buf
+}
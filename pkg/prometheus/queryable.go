@@ -0,0 +1,322 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/cnosdb/cnosdb/storage/reads"
+	"github.com/cnosdb/cnosdb/storage/reads/datatypes"
+	"github.com/cnosdb/cnosdb/vend/db/models"
+	"github.com/cnosdb/cnosdb/vend/db/tsdb/cursors"
+	"github.com/cnosdb/cnosdb/vend/storage"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/prometheus/prometheus/pkg/labels"
+	remote "github.com/prometheus/prometheus/prompb"
+	promstorage "github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+)
+
+// Store is the subset of the storage read API required to evaluate PromQL.
+type Store interface {
+	ReadFilter(ctx context.Context, req *datatypes.ReadFilterRequest) (reads.ResultSet, error)
+	ReadGroup(ctx context.Context, req *datatypes.ReadGroupRequest) (reads.GroupResultSet, error)
+}
+
+// NewQueryable returns a Prometheus Queryable that reads the series written
+// through the Prometheus remote write protocol into the given database and
+// retention policy.
+func NewQueryable(s Store, db, rp string) promstorage.Queryable {
+	return promstorage.QueryableFunc(func(ctx context.Context, mint, maxt int64) (promstorage.Querier, error) {
+		src, err := types.MarshalAny(&storage.ReadSource{Database: db, RetentionPolicy: rp})
+		if err != nil {
+			return nil, err
+		}
+		return &querier{ctx: ctx, store: s, source: src, mint: mint, maxt: maxt}, nil
+	})
+}
+
+// querier evaluates Prometheus label matchers against the storage engine.
+// Timestamps handed to and returned by the querier are in milliseconds.
+type querier struct {
+	ctx    context.Context
+	store  Store
+	source *types.Any
+	mint   int64
+	maxt   int64
+}
+
+// timeRange converts a range in milliseconds into a storage time range,
+// clamped to the times representable in nanoseconds.
+func (q *querier) timeRange(mint, maxt int64) datatypes.TimestampRange {
+	const ms = int64(time.Millisecond)
+	tr := datatypes.TimestampRange{Start: models.MinNanoTime, End: models.MaxNanoTime}
+	if mint > models.MinNanoTime/ms {
+		tr.Start = mint * ms
+	}
+	if maxt < models.MaxNanoTime/ms {
+		tr.End = maxt * ms
+	}
+	return tr
+}
+
+// Select returns the series matching all matchers.
+func (q *querier) Select(sortSeries bool, hints *promstorage.SelectHints, matchers ...*labels.Matcher) (promstorage.SeriesSet, promstorage.Warnings, error) {
+	mint, maxt := q.mint, q.maxt
+	if hints != nil {
+		mint, maxt = hints.Start, hints.End
+	}
+
+	ms, err := labelMatchersToRemote(matchers)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pred, err := predicateFromMatchers(ms)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rs, err := q.store.ReadFilter(q.ctx, &datatypes.ReadFilterRequest{
+		ReadSource: q.source,
+		Range:      q.timeRange(mint, maxt),
+		Predicate:  pred,
+	})
+	if err != nil {
+		return nil, nil, err
+	} else if rs == nil {
+		return promstorage.EmptySeriesSet(), nil, nil
+	}
+	defer rs.Close()
+
+	var set []*series
+	for rs.Next() {
+		cur := rs.Cursor()
+		if cur == nil {
+			// no data for series key + field combination
+			continue
+		}
+
+		s := &series{labels: TagsToLabels(rs.Tags())}
+		readCursor(s, cur)
+		cur.Close()
+
+		if s.n > 0 {
+			set = append(set, s)
+		}
+	}
+	if err := rs.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if sortSeries {
+		sort.Slice(set, func(i, j int) bool {
+			return labels.Compare(set[i].labels, set[j].labels) < 0
+		})
+	}
+	return &seriesSet{series: set, i: -1}, nil, nil
+}
+
+// LabelValues returns the distinct values of the label name across all
+// Prometheus series in the time range of the querier.
+func (q *querier) LabelValues(name string) ([]string, promstorage.Warnings, error) {
+	rs, err := q.store.ReadGroup(q.ctx, &datatypes.ReadGroupRequest{
+		ReadSource: q.source,
+		Range:      q.timeRange(q.mint, q.maxt),
+		Predicate:  &datatypes.Predicate{Root: fieldNode()},
+		Group:      datatypes.GroupBy,
+		GroupKeys:  []string{labelNameToTagKey(name)},
+	})
+	if err != nil {
+		return nil, nil, err
+	} else if rs == nil {
+		return nil, nil, nil
+	}
+	defer rs.Close()
+
+	var values []string
+	for gc := rs.Next(); gc != nil; gc = rs.Next() {
+		if vals := gc.PartitionKeyVals(); len(vals) > 0 && len(vals[0]) > 0 {
+			values = append(values, string(vals[0]))
+		}
+		gc.Close()
+	}
+	return values, nil, rs.Err()
+}
+
+// LabelNames returns the sorted union of the label names of all Prometheus
+// series in the time range of the querier.
+func (q *querier) LabelNames() ([]string, promstorage.Warnings, error) {
+	rs, err := q.store.ReadGroup(q.ctx, &datatypes.ReadGroupRequest{
+		ReadSource: q.source,
+		Range:      q.timeRange(q.mint, q.maxt),
+		Predicate:  &datatypes.Predicate{Root: fieldNode()},
+		Group:      datatypes.GroupNone,
+	})
+	if err != nil {
+		return nil, nil, err
+	} else if rs == nil {
+		return nil, nil, nil
+	}
+	defer rs.Close()
+
+	names := make(map[string]struct{})
+	for gc := rs.Next(); gc != nil; gc = rs.Next() {
+		for _, k := range gc.Keys() {
+			switch string(k) {
+			case fieldTagKey:
+			case measurementTagKey:
+				names[prometheusNameTag] = struct{}{}
+			default:
+				names[string(k)] = struct{}{}
+			}
+		}
+		gc.Close()
+	}
+
+	a := make([]string, 0, len(names))
+	for name := range names {
+		a = append(a, name)
+	}
+	sort.Strings(a)
+	return a, nil, rs.Err()
+}
+
+func (q *querier) Close() error { return nil }
+
+// TagsToLabels converts the tags of a stored series into Prometheus labels.
+// CnosDB system tags are removed and the metric name is taken from the
+// measurement when the series was not written with a __name__ label.
+func TagsToLabels(tags models.Tags) labels.Labels {
+	b := labels.NewBuilder(nil)
+	for _, t := range RemoveCnosDBSystemTags(tags) {
+		if len(t.Value) == 0 {
+			continue
+		}
+		b.Set(string(t.Key), string(t.Value))
+	}
+
+	lbls := b.Labels()
+	if !lbls.Has(prometheusNameTag) {
+		if name := tags.Get([]byte(measurementTagKey)); len(name) > 0 {
+			lbls = b.Set(prometheusNameTag, string(name)).Labels()
+		}
+	}
+	return lbls
+}
+
+// labelNameToTagKey maps a Prometheus label name onto the tag key it is
+// stored under.
+func labelNameToTagKey(name string) string {
+	if name == prometheusNameTag {
+		return measurementTagKey
+	}
+	return name
+}
+
+func labelMatchersToRemote(matchers []*labels.Matcher) ([]*remote.LabelMatcher, error) {
+	a := make([]*remote.LabelMatcher, 0, len(matchers))
+	for _, m := range matchers {
+		var typ remote.LabelMatcher_Type
+		switch m.Type {
+		case labels.MatchEqual:
+			typ = remote.LabelMatcher_EQ
+		case labels.MatchNotEqual:
+			typ = remote.LabelMatcher_NEQ
+		case labels.MatchRegexp:
+			typ = remote.LabelMatcher_RE
+		case labels.MatchNotRegexp:
+			typ = remote.LabelMatcher_NRE
+		default:
+			return nil, errors.New("invalid matcher type")
+		}
+		a = append(a, &remote.LabelMatcher{Type: typ, Name: m.Name, Value: m.Value})
+	}
+	return a, nil
+}
+
+// readCursor appends all numeric values of cur to s. Boolean and string
+// values have no representation in Prometheus and are skipped.
+func readCursor(s *series, cur cursors.Cursor) {
+	switch cur := cur.(type) {
+	case cursors.FloatArrayCursor:
+		for a := cur.Next(); a.Len() > 0; a = cur.Next() {
+			for i, ts := range a.Timestamps {
+				s.append(ts, a.Values[i])
+			}
+		}
+	case cursors.IntegerArrayCursor:
+		for a := cur.Next(); a.Len() > 0; a = cur.Next() {
+			for i, ts := range a.Timestamps {
+				s.append(ts, float64(a.Values[i]))
+			}
+		}
+	case cursors.UnsignedArrayCursor:
+		for a := cur.Next(); a.Len() > 0; a = cur.Next() {
+			for i, ts := range a.Timestamps {
+				s.append(ts, float64(a.Values[i]))
+			}
+		}
+	}
+}
+
+// maxSamplesPerChunk limits the number of samples encoded in a single chunk
+// of a series.
+const maxSamplesPerChunk = 1 << 15
+
+// series is a fully materialized Prometheus series, with its samples
+// compressed into XOR chunks.
+type series struct {
+	labels labels.Labels
+	chunks []chunkenc.Chunk
+	app    chunkenc.Appender
+	n      int
+}
+
+func (s *series) append(ts int64, v float64) {
+	if s.app == nil || s.chunks[len(s.chunks)-1].NumSamples() >= maxSamplesPerChunk {
+		c := chunkenc.NewXORChunk()
+		s.app, _ = c.Appender()
+		s.chunks = append(s.chunks, c)
+	}
+	s.app.Append(ts/int64(time.Millisecond), v)
+	s.n++
+}
+
+func (s *series) Labels() labels.Labels { return s.labels }
+
+func (s *series) Iterator() chunkenc.Iterator {
+	if len(s.chunks) == 1 {
+		return s.chunks[0].Iterator(nil)
+	}
+
+	a := make([]promstorage.Series, 0, len(s.chunks))
+	for _, c := range s.chunks {
+		a = append(a, &chunkSeries{labels: s.labels, chunk: c})
+	}
+	return promstorage.ChainedSeriesMerge(a...).Iterator()
+}
+
+// chunkSeries is a single chunk of a series.
+type chunkSeries struct {
+	labels labels.Labels
+	chunk  chunkenc.Chunk
+}
+
+func (s *chunkSeries) Labels() labels.Labels       { return s.labels }
+func (s *chunkSeries) Iterator() chunkenc.Iterator { return s.chunk.Iterator(nil) }
+
+type seriesSet struct {
+	series []*series
+	i      int
+}
+
+func (s *seriesSet) Next() bool {
+	s.i++
+	return s.i < len(s.series)
+}
+
+func (s *seriesSet) At() promstorage.Series { return s.series[s.i] }
+func (s *seriesSet) Err() error             { return nil }
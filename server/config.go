@@ -18,6 +18,7 @@ import (
 	"github.com/cnosdb/cnosdb/server/hh"
 	"github.com/cnosdb/cnosdb/server/precreator"
 	"github.com/cnosdb/cnosdb/server/rp"
+	"github.com/cnosdb/cnosdb/server/storage_service"
 	"github.com/cnosdb/cnosdb/server/subscriber"
 	itoml "github.com/cnosdb/cnosdb/vend/common/pkg/toml"
 	"github.com/cnosdb/cnosdb/vend/db/tsdb"
@@ -55,6 +56,7 @@ type Config struct {
 	Log             *logger.Config
 	ContinuousQuery continuous_querier.Config
 	HintedHandoff   hh.Config
+	StorageService  storage_service.Config
 	TLS             tlsconfig.Config
 }
 
@@ -75,6 +77,7 @@ func NewConfig() *Config {
 
 	c.ContinuousQuery = continuous_querier.NewConfig()
 	c.RetentionPolicy = rp.NewConfig()
+	c.StorageService = storage_service.NewConfig()
 
 	return c
 }
@@ -141,6 +144,10 @@ func (c *Config) Validate() error {
 		return err
 	}
 
+	if err := c.Coordinator.Validate(); err != nil {
+		return err
+	}
+
 	if err := c.Monitor.Validate(); err != nil {
 		return err
 	}
@@ -165,6 +172,10 @@ func (c *Config) Validate() error {
 		return err
 	}
 
+	if err := c.StorageService.Validate(); err != nil {
+		return err
+	}
+
 	if err := c.TLS.Validate(); err != nil {
 		return err
 	}
@@ -314,6 +314,25 @@ func (s *Service) ExecuteContinuousQuery(dbi *meta.DatabaseInfo, cqi *meta.Conti
 		return false, err
 	}
 
+	// Calendar intervals are scheduled on the calendar of the query's time zone.
+	cal, err := cq.q.GroupByCalendar()
+	if err != nil {
+		return false, err
+	} else if !cal.IsZero() || cq.Resample.EveryMonths != 0 || cq.Resample.ForMonths != 0 {
+		run, startTime, endTime, err := cq.calendarTimeRange(now, period{d: interval, offset: offset, cal: cal})
+		if err != nil {
+			return false, err
+		} else if !run {
+			return false, nil
+		}
+		s.lastRuns[id] = cq.LastRun
+
+		if !endTime.After(startTime) {
+			return false, nil
+		}
+		return s.runContinuousQuery(cq, startTime, endTime)
+	}
+
 	// See if this query needs to be run.
 	run, nextRun, err := cq.shouldRunContinuousQuery(now, interval)
 	if err != nil {
@@ -356,7 +375,12 @@ func (s *Service) ExecuteContinuousQuery(dbi *meta.DatabaseInfo, cqi *meta.Conti
 		// Exit early since there is no time interval.
 		return false, nil
 	}
+	return s.runContinuousQuery(cq, startTime, endTime)
+}
 
+// runContinuousQuery executes the continuous query for the time range
+// [startTime, endTime) and writes its results.
+func (s *Service) runContinuousQuery(cq *ContinuousQuery, startTime, endTime time.Time) (bool, error) {
 	if err := cq.q.SetTimeRange(startTime, endTime); err != nil {
 		return false, fmt.Errorf("unable to set time range: %s", err)
 	}
@@ -410,7 +434,7 @@ func (s *Service) ExecuteContinuousQuery(dbi *meta.DatabaseInfo, cqi *meta.Conti
 	}
 
 	if s.queryStatsEnabled && s.Monitor.Enabled() {
-		tags := map[string]string{"db": dbi.Name, "cq": cq.Info.Name}
+		tags := map[string]string{"db": cq.Database, "cq": cq.Info.Name}
 		fields := map[string]interface{}{"durationNs": int64(execDuration), "pointsWrittenOK": written, "startTime": startTime.UnixNano(), "endTime": endTime.UnixNano()}
 		p, _ := models.NewPoint("cq_query", models.NewTags(tags), fields, time.Now())
 		s.Monitor.WritePoints(models.Points{p})
@@ -468,6 +492,11 @@ type ResampleOptions struct {
 	// so a 40m resample duration with a group by interval of 10m will resample
 	// the bucket 4 times (using the default time interval).
 	For time.Duration
+
+	// The resample interval and duration in calendar months. These are used
+	// instead of Every and For when the options are given in months or years.
+	EveryMonths int
+	ForMonths   int
 }
 
 // NewContinuousQuery returns a ContinuousQuery object with a parsed cnosql.CreateContinuousQueryStatement.
@@ -486,8 +515,10 @@ func NewContinuousQuery(database string, cqi *meta.ContinuousQueryInfo) (*Contin
 		Database: database,
 		Info:     cqi,
 		Resample: ResampleOptions{
-			Every: q.ResampleEvery,
-			For:   q.ResampleFor,
+			Every:       q.ResampleEvery,
+			For:         q.ResampleFor,
+			EveryMonths: q.ResampleEveryMonths,
+			ForMonths:   q.ResampleForMonths,
 		},
 		q: q.Source,
 	}
@@ -538,6 +569,107 @@ func (cq *ContinuousQuery) shouldRunContinuousQuery(now time.Time, interval time
 	return false, cq.LastRun, nil
 }
 
+// calendarTimeRange determines if a continuous query with a calendar group by
+// interval or calendar resample options should run, and returns the time
+// range to compute. It updates the last run time of the query if it runs.
+// Calendar intervals are stepped on the calendar of the time zone of now.
+func (cq *ContinuousQuery) calendarTimeRange(now time.Time, interval period) (bool, time.Time, time.Time, error) {
+	// If it's not aggregated, do not run the query.
+	if cq.q.IsRawQuery {
+		return false, time.Time{}, time.Time{}, errors.New("continuous queries must be aggregate queries")
+	}
+
+	// Override the query's default run interval with the resample options.
+	resampleEvery := interval
+	if cq.Resample.EveryMonths != 0 {
+		resampleEvery = period{cal: cnosql.CalendarInterval{Months: cq.Resample.EveryMonths}}
+	} else if cq.Resample.Every != 0 {
+		resampleEvery = period{d: cq.Resample.Every}
+	}
+
+	resampleFor := interval
+	if cq.Resample.ForMonths != 0 {
+		resampleFor = period{cal: cnosql.CalendarInterval{Months: cq.Resample.ForMonths}}
+	} else if cq.Resample.For != 0 {
+		resampleFor = period{d: cq.Resample.For}
+	} else if interval.max() < resampleEvery.max() {
+		resampleFor = resampleEvery
+	}
+
+	// Determine if we should run the continuous query based on the last time it ran.
+	// If the query never ran, execute it using the current time.
+	nextRun := now
+	if cq.HasRun {
+		if nextRun = resampleEvery.next(cq.LastRun); nextRun.After(now) {
+			return false, time.Time{}, time.Time{}, nil
+		}
+	}
+	cq.LastRun = resampleEvery.truncate(now)
+
+	// If the resample interval is greater than the interval of the query, use the
+	// query interval instead.
+	if interval.max() < resampleEvery.max() {
+		resampleEvery = interval
+	}
+
+	// Start with the oldest interval that ends within the resample duration
+	// before the next run, and end with the last interval that has started at
+	// least the resample interval ago.
+	startTime := interval.ceil(resampleFor.sub(nextRun))
+	endTime := interval.next(interval.truncate(resampleEvery.sub(now)))
+	return true, startTime, endTime, nil
+}
+
+// period is the interval of a continuous query or one of its resample
+// options. It is either a fixed duration with an optional offset or an
+// interval on the calendar of the query's time zone.
+type period struct {
+	d      time.Duration
+	offset time.Duration
+	cal    cnosql.CalendarInterval
+}
+
+// max returns the longest duration of the period.
+func (p period) max() time.Duration {
+	if !p.cal.IsZero() {
+		return p.cal.MaxDuration()
+	}
+	return p.d
+}
+
+// truncate returns the start of the period that t falls within.
+func (p period) truncate(t time.Time) time.Time {
+	if !p.cal.IsZero() {
+		return p.cal.Truncate(t)
+	}
+	return truncate(t.Add(-p.offset), p.d).Add(p.offset)
+}
+
+// ceil returns the start of the first period that starts at or after t.
+func (p period) ceil(t time.Time) time.Time {
+	start := p.truncate(t)
+	if start.Before(t) {
+		return p.next(start)
+	}
+	return start
+}
+
+// next returns the start of the period following the one starting at start.
+func (p period) next(start time.Time) time.Time {
+	if !p.cal.IsZero() {
+		return p.cal.Next(start)
+	}
+	return start.Add(p.d)
+}
+
+// sub returns the time one period before t.
+func (p period) sub(t time.Time) time.Time {
+	if !p.cal.IsZero() {
+		return t.AddDate(0, -p.cal.Months, -7*p.cal.Weeks)
+	}
+	return t.Add(-p.d)
+}
+
 // assert will panic with a given formatted message if the given condition is false.
 func assert(condition bool, msg string, v ...interface{}) {
 	if !condition {
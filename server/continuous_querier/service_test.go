@@ -186,6 +186,78 @@ func TestContinuousQueryService_ResampleOptions(t *testing.T) {
 	}
 }
 
+func TestContinuousQueryService_CalendarResampleOptions(t *testing.T) {
+	s := NewTestService(t)
+	mc := NewMetaClient(t)
+	mc.CreateDatabase("db", "")
+	mc.CreateContinuousQuery("db", "cq", `CREATE CONTINUOUS QUERY cq ON db RESAMPLE EVERY 1d FOR 2mo BEGIN SELECT mean(value) INTO cpu_mean FROM cpu GROUP BY time(1mo) TZ('America/New_York') END`)
+	s.MetaClient = mc
+
+	db := s.MetaClient.Database("db")
+
+	cq, err := NewContinuousQuery(db.Name, &db.ContinuousQueries[0])
+	if err != nil {
+		t.Fatal(err)
+	} else if cq.Resample.Every != 24*time.Hour {
+		t.Errorf("expected resample every to be 1d, got %s", cnosql.FormatDuration(cq.Resample.Every))
+	} else if cq.Resample.ForMonths != 2 {
+		t.Errorf("expected resample for 2mo, got %s", cnosql.FormatCalendarDuration(cq.Resample.ForMonths))
+	}
+
+	// Set RunInterval high so we can trigger using Run method.
+	s.RunInterval = 10 * time.Minute
+
+	done := make(chan struct{})
+	var expected struct {
+		min time.Time
+		max time.Time
+	}
+
+	// Set a callback for ExecuteStatement.
+	s.QueryExecutor.StatementExecutor = &StatementExecutor{
+		ExecuteStatementFn: func(stmt cnosql.Statement, ctx *query.ExecutionContext) error {
+			s := stmt.(*cnosql.SelectStatement)
+			valuer := &cnosql.NowValuer{Location: s.Location}
+			_, timeRange, err := cnosql.ConditionExpr(s.Condition, valuer)
+			if err != nil {
+				t.Errorf("unexpected error parsing time range: %s", err)
+			} else if !expected.min.Equal(timeRange.Min) || !expected.max.Equal(timeRange.Max) {
+				t.Errorf("mismatched time range: got=(%s, %s) exp=(%s, %s)", timeRange.Min, timeRange.Max, expected.min, expected.max)
+			}
+			done <- struct{}{}
+			ctx.Results <- &query.Result{}
+			return nil
+		},
+	}
+
+	s.Open()
+	defer s.Close()
+
+	// The first run resamples the months that end within the last two months,
+	// including the current month.
+	expected.min = mustParseTime(t, "2000-02-01T00:00:00-05:00")
+	expected.max = mustParseTime(t, "2000-04-01T00:00:00-05:00").Add(-1)
+	s.RunCh <- &RunRequest{Now: mustParseTime(t, "2000-03-15T12:00:00-05:00")}
+
+	if err := wait(done, 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing runs until a day has passed.
+	s.RunCh <- &RunRequest{Now: mustParseTime(t, "2000-03-15T23:00:00-05:00")}
+	if err := wait(done, 100*time.Millisecond); err == nil {
+		t.Fatal("unexpected query executed")
+	}
+
+	// The next month started after the clocks moved forward.
+	expected.max = mustParseTime(t, "2000-05-01T00:00:00-04:00").Add(-1)
+	s.RunCh <- &RunRequest{Now: mustParseTime(t, "2000-04-02T12:00:00-04:00")}
+
+	if err := wait(done, 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestContinuousQueryService_EveryHigherThanInterval(t *testing.T) {
 	s := NewTestService(t)
 	ms := NewMetaClient(t)
@@ -536,6 +608,32 @@ func TestExecuteContinuousQuery_TimeZone(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:    "DaylightSavingsStart/1mo",
+			d:       "1mo",
+			initial: mustParseTime(t, "2000-03-01T00:00:00-05:00"),
+			tests: []test{
+				{
+					start: mustParseTime(t, "2000-03-01T00:00:00-05:00"),
+					end:   mustParseTime(t, "2000-04-01T00:00:00-05:00"),
+				},
+				{
+					start: mustParseTime(t, "2000-04-01T00:00:00-05:00"),
+					end:   mustParseTime(t, "2000-05-01T00:00:00-04:00"),
+				},
+			},
+		},
+		{
+			name:    "DaylightSavingsEnd/1w",
+			d:       "1w, monday",
+			initial: mustParseTime(t, "2000-10-23T00:00:00-04:00"),
+			tests: []test{
+				{
+					start: mustParseTime(t, "2000-10-23T00:00:00-04:00"),
+					end:   mustParseTime(t, "2000-10-30T00:00:00-05:00"),
+				},
+			},
+		},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
 			s := NewTestService(t)
@@ -3,6 +3,7 @@
 package coordinator
 
 import (
+	"errors"
 	"time"
 
 	"github.com/cnosdb/cnosdb/vend/common/monitor/diagnostics"
@@ -24,6 +25,23 @@ const (
 	// that will be available for remote writes to another host.
 	DefaultMaxRemoteWriteConnections = 3
 
+	// DefaultWriteIDTTL is the default time the ID of a write is remembered
+	// to discard retries of the write.
+	DefaultWriteIDTTL = 10 * time.Minute
+
+	// DefaultMaxWriteIDs is the default maximum number of write IDs remembered.
+	// A value of zero disables the deduplication of writes.
+	DefaultMaxWriteIDs = 100000
+
+	// DefaultGeoLatField and DefaultGeoLonField are the default fields holding
+	// the location of points.
+	DefaultGeoLatField = "lat"
+	DefaultGeoLonField = "lon"
+
+	// DefaultGeoCellLevel is the default level of the S2 cells written into the
+	// s2_cell tag of points. A value of zero disables the tag.
+	DefaultGeoCellLevel = 0
+
 	// DefaultMaxConcurrentQueries is the maximum number of running queries.
 	// A value of zero will make the maximum query limit unlimited.
 	DefaultMaxConcurrentQueries = 0
@@ -35,6 +53,18 @@ const (
 	// DefaultMaxSelectSeriesN is the maximum number of series a SELECT can run.
 	// A value of zero will make the maximum series count unlimited.
 	DefaultMaxSelectSeriesN = 0
+
+	// DefaultMaxSelectMemory is the maximum number of bytes buffered by a SELECT.
+	// A value of zero will make the memory of a SELECT unlimited.
+	DefaultMaxSelectMemory = 0
+
+	// DefaultPlanCacheSize is the maximum number of compiled SELECT statements
+	// that are cached. A value of zero disables the cache.
+	DefaultPlanCacheSize = query.DefaultPlanCacheSize
+
+	// DefaultResultCacheMaxMemorySize is the maximum memory used by the cache
+	// of partial aggregates. A value of zero disables the cache.
+	DefaultResultCacheMaxMemorySize = 0
 )
 
 // Config represents the configuration for the coordinator service.
@@ -44,6 +74,12 @@ type Config struct {
 	ShardWriterTimeout        toml.Duration `toml:"shard-writer-timeout"`
 	MaxRemoteWriteConnections int           `toml:"max-remote-write-connections"`
 	ShardMapperTimeout        toml.Duration `toml:"shard-mapper-timeout"`
+	WriteIDTTL                toml.Duration `toml:"write-id-ttl"`
+	MaxWriteIDs               int           `toml:"max-write-ids"`
+
+	GeoLatField  string `toml:"geo-lat-field"`
+	GeoLonField  string `toml:"geo-lon-field"`
+	GeoCellLevel int    `toml:"geo-cell-level"`
 
 	MaxConcurrentQueries int           `toml:"max-concurrent-queries"`
 	QueryTimeout         toml.Duration `toml:"query-timeout"`
@@ -51,6 +87,11 @@ type Config struct {
 	MaxSelectPointN      int           `toml:"max-select-point"`
 	MaxSelectSeriesN     int           `toml:"max-select-series"`
 	MaxSelectBucketsN    int           `toml:"max-select-buckets"`
+	MaxSelectMemory      toml.Size     `toml:"max-select-memory"`
+	QuerySpillDir        string        `toml:"query-spill-dir"`
+	PlanCacheSize        int           `toml:"plan-cache-size"`
+
+	ResultCacheMaxMemorySize toml.Size `toml:"result-cache-max-memory-size"`
 }
 
 // NewConfig returns an instance of Config with defaults.
@@ -60,23 +101,63 @@ func NewConfig() Config {
 		ShardWriterTimeout:        toml.Duration(DefaultShardWriterTimeout),
 		ShardMapperTimeout:        toml.Duration(DefaultShardMapperTimeout),
 		MaxRemoteWriteConnections: DefaultMaxRemoteWriteConnections,
+		WriteIDTTL:                toml.Duration(DefaultWriteIDTTL),
+		MaxWriteIDs:               DefaultMaxWriteIDs,
+
+		GeoLatField:  DefaultGeoLatField,
+		GeoLonField:  DefaultGeoLonField,
+		GeoCellLevel: DefaultGeoCellLevel,
 
 		QueryTimeout:         toml.Duration(query.DefaultQueryTimeout),
 		MaxConcurrentQueries: DefaultMaxConcurrentQueries,
 		MaxSelectPointN:      DefaultMaxSelectPointN,
 		MaxSelectSeriesN:     DefaultMaxSelectSeriesN,
+		MaxSelectMemory:      toml.Size(DefaultMaxSelectMemory),
+		PlanCacheSize:        DefaultPlanCacheSize,
+
+		ResultCacheMaxMemorySize: toml.Size(DefaultResultCacheMaxMemorySize),
+	}
+}
+
+// Validate returns an error if the config is invalid.
+func (c Config) Validate() error {
+	if c.GeoCellLevel < 0 || c.GeoCellLevel > 30 {
+		return errors.New("geo-cell-level must be between 0 and 30")
+	} else if c.GeoCellLevel > 0 && (c.GeoLatField == "" || c.GeoLonField == "") {
+		return errors.New("geo-lat-field and geo-lon-field are required when geo-cell-level is set")
+	} else if c.PlanCacheSize < 0 {
+		return errors.New("plan-cache-size must not be negative")
+	} else if c.ResultCacheMaxMemorySize < 0 {
+		return errors.New("result-cache-max-memory-size must not be negative")
+	}
+	return nil
+}
+
+// GeoIndex returns the location of points described by the config.
+func (c Config) GeoIndex() query.GeoIndex {
+	return query.GeoIndex{
+		LatField:  c.GeoLatField,
+		LonField:  c.GeoLonField,
+		CellLevel: c.GeoCellLevel,
 	}
 }
 
 // Diagnostics returns a diagnostics representation of a subset of the Config.
 func (c Config) Diagnostics() (*diagnostics.Diagnostics, error) {
 	return diagnostics.RowFromMap(map[string]interface{}{
-		"write-timeout":          c.WriteTimeout,
-		"max-concurrent-queries": c.MaxConcurrentQueries,
-		"query-timeout":          c.QueryTimeout,
-		"log-queries-after":      c.LogQueriesAfter,
-		"max-select-point":       c.MaxSelectPointN,
-		"max-select-series":      c.MaxSelectSeriesN,
-		"max-select-buckets":     c.MaxSelectBucketsN,
+		"write-timeout":                c.WriteTimeout,
+		"write-id-ttl":                 c.WriteIDTTL,
+		"max-write-ids":                c.MaxWriteIDs,
+		"geo-cell-level":               c.GeoCellLevel,
+		"max-concurrent-queries":       c.MaxConcurrentQueries,
+		"query-timeout":                c.QueryTimeout,
+		"log-queries-after":            c.LogQueriesAfter,
+		"max-select-point":             c.MaxSelectPointN,
+		"max-select-series":            c.MaxSelectSeriesN,
+		"max-select-buckets":           c.MaxSelectBucketsN,
+		"max-select-memory":            c.MaxSelectMemory,
+		"query-spill-dir":              c.QuerySpillDir,
+		"plan-cache-size":              c.PlanCacheSize,
+		"result-cache-max-memory-size": c.ResultCacheMaxMemorySize,
 	}), nil
 }
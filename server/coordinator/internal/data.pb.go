@@ -25,6 +25,7 @@ type WriteShardRequest struct {
 	Points               [][]byte `protobuf:"bytes,2,rep,name=Points" json:"Points,omitempty"`
 	Database             *string  `protobuf:"bytes,3,opt,name=Database" json:"Database,omitempty"`
 	RetentionPolicy      *string  `protobuf:"bytes,4,opt,name=RetentionPolicy" json:"RetentionPolicy,omitempty"`
+	WriteID              *string  `protobuf:"bytes,5,opt,name=WriteID" json:"WriteID,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -82,6 +83,13 @@ func (m *WriteShardRequest) GetRetentionPolicy() string {
 	return ""
 }
 
+func (m *WriteShardRequest) GetWriteID() string {
+	if m != nil && m.WriteID != nil {
+		return *m.WriteID
+	}
+	return ""
+}
+
 type WriteShardResponse struct {
 	Code                 *int32   `protobuf:"varint,1,req,name=Code" json:"Code,omitempty"`
 	Message              *string  `protobuf:"bytes,2,opt,name=Message" json:"Message,omitempty"`
@@ -466,31 +474,31 @@ func init() {
 func init() { proto.RegisterFile("internal/data.proto", fileDescriptor_7438786364df21e1) }
 
 var fileDescriptor_7438786364df21e1 = []byte{
-	// 406 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x53, 0xc1, 0x6e, 0xd4, 0x30,
-	0x10, 0x55, 0x36, 0x9b, 0xb2, 0x19, 0x56, 0xa2, 0x18, 0x91, 0x5a, 0x15, 0x42, 0x51, 0x4e, 0x39,
-	0xc1, 0x3f, 0xd0, 0x2d, 0xa2, 0x87, 0x86, 0xca, 0x8b, 0xe0, 0x6c, 0x36, 0x23, 0xb0, 0xb4, 0x6b,
-	0x07, 0x7b, 0x56, 0x6a, 0xbf, 0x81, 0xef, 0xe1, 0xff, 0x50, 0xa6, 0x71, 0x1a, 0xd2, 0x03, 0x88,
-	0xdb, 0xbc, 0xe7, 0xd1, 0xe8, 0xbd, 0x97, 0x17, 0x78, 0x61, 0x2c, 0xa1, 0xb7, 0x7a, 0xff, 0xb6,
-	0xd5, 0xa4, 0xdf, 0x74, 0xde, 0x91, 0x13, 0xab, 0x48, 0x56, 0x3f, 0x13, 0x78, 0xfe, 0xc5, 0x1b,
-	0xc2, 0xed, 0x77, 0xed, 0x5b, 0x85, 0x3f, 0x8e, 0x18, 0x48, 0x48, 0x78, 0xc2, 0xf8, 0x6a, 0x23,
-	0x93, 0x72, 0x51, 0x2f, 0x55, 0x84, 0xa2, 0x80, 0x93, 0x1b, 0x67, 0x2c, 0x05, 0xb9, 0x28, 0xd3,
-	0x7a, 0xad, 0x06, 0x24, 0xce, 0x61, 0xb5, 0xd1, 0xa4, 0xbf, 0xea, 0x80, 0x32, 0x2d, 0x93, 0x3a,
-	0x57, 0x23, 0x16, 0x35, 0x3c, 0x53, 0x48, 0x68, 0xc9, 0x38, 0x7b, 0xe3, 0xf6, 0x66, 0x77, 0x27,
-	0x97, 0xbc, 0x32, 0xa7, 0xab, 0x77, 0x20, 0xa6, 0x62, 0x42, 0xe7, 0x6c, 0x40, 0x21, 0x60, 0x79,
-	0xe1, 0x5a, 0x64, 0x29, 0x99, 0xe2, 0xb9, 0x57, 0x78, 0x8d, 0x21, 0xe8, 0x6f, 0x28, 0x17, 0x7c,
-	0x2b, 0xc2, 0x6a, 0x0b, 0x67, 0x97, 0xb7, 0xb8, 0x3b, 0x12, 0x6e, 0x49, 0x13, 0x1e, 0xd0, 0x52,
-	0xb4, 0xf5, 0x0a, 0xf2, 0x91, 0xe3, 0x6b, 0xb9, 0x7a, 0x20, 0xfe, 0xb0, 0xb0, 0xe0, 0xc7, 0x11,
-	0x57, 0x1f, 0x40, 0x3e, 0x3e, 0xfa, 0x5f, 0xf2, 0x7e, 0x25, 0xf0, 0xf2, 0xc2, 0xa3, 0x26, 0xbc,
-	0x22, 0xf4, 0x9a, 0x9c, 0x8f, 0xea, 0xce, 0x61, 0x35, 0xa4, 0x1c, 0x64, 0x52, 0xa6, 0xf5, 0x52,
-	0x8d, 0x58, 0x9c, 0x42, 0xfa, 0xb1, 0x23, 0x96, 0xb5, 0x56, 0xfd, 0x38, 0x0b, 0xbc, 0xa7, 0xff,
-	0x12, 0x78, 0xbf, 0x32, 0xa7, 0xfb, 0xcd, 0x6b, 0xd4, 0xe1, 0xe8, 0xd9, 0x52, 0xa3, 0x0f, 0x28,
-	0xb3, 0xfb, 0xcd, 0x19, 0x5d, 0xdd, 0x42, 0x31, 0x97, 0x3d, 0xf8, 0x3f, 0x85, 0xf4, 0xd2, 0x7b,
-	0x99, 0xb0, 0xcf, 0x7e, 0x8c, 0xda, 0x3e, 0xdd, 0x75, 0xf7, 0xf6, 0x33, 0x35, 0x62, 0xae, 0x16,
-	0x7a, 0x83, 0xa1, 0xe1, 0x9e, 0x64, 0x2a, 0xc2, 0xb1, 0x5a, 0x0d, 0xb7, 0x23, 0x1b, 0xaa, 0xd5,
-	0x54, 0x9f, 0xa1, 0x78, 0x6f, 0x70, 0xdf, 0x6e, 0xcc, 0x01, 0x6d, 0x30, 0xce, 0x86, 0x7f, 0x49,
-	0xac, 0x84, 0xa7, 0x13, 0x0b, 0x43, 0x72, 0x53, 0xaa, 0xda, 0xc1, 0xd9, 0xa3, 0xbb, 0x83, 0xa5,
-	0x02, 0x4e, 0xf8, 0x29, 0xf0, 0x47, 0x5d, 0xab, 0x01, 0x89, 0xd7, 0x00, 0x0f, 0xdb, 0xfc, 0x07,
-	0xe4, 0x6a, 0xc2, 0xc4, 0x28, 0xd2, 0x31, 0x8a, 0xdf, 0x01, 0x00, 0x00, 0xff, 0xff, 0xe5, 0x27,
-	0x44, 0xac, 0x7f, 0x03, 0x00, 0x00,
+	// 415 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x53, 0xc1, 0x6e, 0xd3, 0x40,
+	0x10, 0x95, 0xe3, 0xb8, 0x24, 0x43, 0xa4, 0x96, 0x45, 0xa4, 0xab, 0x0a, 0x21, 0xcb, 0x27, 0x9f,
+	0xe0, 0x1f, 0x68, 0x8a, 0xc8, 0xa1, 0xa1, 0xda, 0x20, 0x38, 0x2f, 0xc9, 0x08, 0x56, 0x4a, 0x76,
+	0xcd, 0xee, 0x44, 0x6a, 0x3f, 0x88, 0x4f, 0xe0, 0xff, 0x90, 0x27, 0xbb, 0xae, 0x71, 0x0e, 0xa0,
+	0xde, 0xe6, 0xbd, 0x1d, 0x8d, 0xdf, 0x9b, 0x79, 0x86, 0x97, 0xc6, 0x12, 0x7a, 0xab, 0x77, 0xef,
+	0xb6, 0x9a, 0xf4, 0xdb, 0xc6, 0x3b, 0x72, 0x62, 0x92, 0xc8, 0xea, 0x57, 0x06, 0x2f, 0xbe, 0x7a,
+	0x43, 0xb8, 0xfe, 0xa1, 0xfd, 0x56, 0xe1, 0xcf, 0x03, 0x06, 0x12, 0x12, 0x9e, 0x31, 0x5e, 0x2e,
+	0x64, 0x56, 0x8e, 0xea, 0xb1, 0x4a, 0x50, 0xcc, 0xe1, 0xec, 0xce, 0x19, 0x4b, 0x41, 0x8e, 0xca,
+	0xbc, 0x9e, 0xa9, 0x88, 0xc4, 0x15, 0x4c, 0x16, 0x9a, 0xf4, 0x37, 0x1d, 0x50, 0xe6, 0x65, 0x56,
+	0x4f, 0x55, 0x87, 0x45, 0x0d, 0xe7, 0x0a, 0x09, 0x2d, 0x19, 0x67, 0xef, 0xdc, 0xce, 0x6c, 0x1e,
+	0xe4, 0x98, 0x5b, 0x86, 0x74, 0xfb, 0x5d, 0x16, 0xb3, 0x5c, 0xc8, 0x82, 0x3b, 0x12, 0xac, 0xde,
+	0x83, 0xe8, 0xcb, 0x0c, 0x8d, 0xb3, 0x01, 0x85, 0x80, 0xf1, 0xb5, 0xdb, 0x22, 0x8b, 0x2c, 0x14,
+	0xd7, 0xed, 0x8c, 0x5b, 0x0c, 0x41, 0x7f, 0x47, 0x39, 0x3a, 0xce, 0x88, 0xb0, 0x5a, 0xc3, 0xe5,
+	0xcd, 0x3d, 0x6e, 0x0e, 0x84, 0x6b, 0xd2, 0x84, 0x7b, 0xb4, 0x94, 0x0c, 0xbf, 0x86, 0x69, 0xc7,
+	0xf1, 0xb4, 0xa9, 0x7a, 0x24, 0xfe, 0x32, 0x37, 0xe2, 0xc7, 0x0e, 0x57, 0x1f, 0x41, 0x9e, 0x0e,
+	0x7d, 0x92, 0xbc, 0xdf, 0x19, 0xbc, 0xba, 0xf6, 0xa8, 0x09, 0x97, 0x84, 0x5e, 0x93, 0xf3, 0x49,
+	0xdd, 0x15, 0x4c, 0xe2, 0xfe, 0x83, 0xcc, 0xca, 0xbc, 0x1e, 0xab, 0x0e, 0x8b, 0x0b, 0xc8, 0x3f,
+	0x35, 0xc4, 0xb2, 0x66, 0xaa, 0x2d, 0x07, 0xa7, 0x68, 0xe9, 0x7f, 0x9c, 0xa2, 0x6d, 0x39, 0x39,
+	0x45, 0x0d, 0xe7, 0xb7, 0xa8, 0xc3, 0xc1, 0xb3, 0xa5, 0x95, 0xde, 0xa3, 0x2c, 0x8e, 0x9d, 0x03,
+	0xba, 0xba, 0x87, 0xf9, 0x50, 0x76, 0xf4, 0x7f, 0x01, 0xf9, 0x8d, 0xf7, 0x32, 0x63, 0x9f, 0x6d,
+	0x99, 0xb4, 0x7d, 0x7e, 0x68, 0x8e, 0xf6, 0x0b, 0xd5, 0x61, 0x0e, 0x1d, 0x7a, 0x83, 0x61, 0xc5,
+	0x09, 0x2a, 0x54, 0x82, 0x5d, 0xe8, 0x56, 0x9c, 0x9b, 0x22, 0x86, 0x6e, 0x55, 0x7d, 0x81, 0xf9,
+	0x07, 0x83, 0xbb, 0xed, 0xc2, 0xec, 0xd1, 0x06, 0xe3, 0x6c, 0xf8, 0x9f, 0x8d, 0x95, 0xf0, 0xbc,
+	0x67, 0x21, 0x6e, 0xae, 0x4f, 0x55, 0x1b, 0xb8, 0x3c, 0x99, 0x1b, 0x2d, 0xcd, 0xe1, 0x8c, 0x9f,
+	0x02, 0x1f, 0x75, 0xa6, 0x22, 0x12, 0x6f, 0x00, 0x1e, 0xbb, 0xf9, 0xdf, 0x98, 0xaa, 0x1e, 0x93,
+	0x56, 0x91, 0x77, 0xab, 0xf8, 0x33, 0x00, 0x04, 0x02, 0xa3, 0x4d, 0x99, 0x03, 0x00, 0x00,
 }
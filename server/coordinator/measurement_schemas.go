@@ -0,0 +1,75 @@
+package coordinator
+
+import (
+	"sync"
+
+	"github.com/cnosdb/cnosdb/meta"
+	"github.com/cnosdb/cnosdb/vend/db/tsdb"
+)
+
+// MeasurementSchemas provides the measurement write schemas stored in the
+// meta store to the shards of the local TSDB store.
+type MeasurementSchemas struct {
+	MetaClient interface {
+		Database(name string) *meta.DatabaseInfo
+	}
+
+	mu    sync.RWMutex
+	cache map[string]measurementSchemasEntry
+}
+
+// measurementSchemasEntry holds the schemas of a database converted from
+// the meta store, along with the meta data they were converted from.
+type measurementSchemasEntry struct {
+	src     *meta.MeasurementSchemaInfo
+	n       int
+	schemas map[string]*tsdb.MeasurementSchema
+}
+
+// MeasurementSchemas returns the schemas declared in the database, keyed by
+// measurement name.
+func (s *MeasurementSchemas) MeasurementSchemas(database string) map[string]*tsdb.MeasurementSchema {
+	dbi := s.MetaClient.Database(database)
+	if dbi == nil || len(dbi.MeasurementSchemas) == 0 {
+		return nil
+	}
+
+	// The meta data is copied on every change, so the converted schemas are
+	// still valid as long as they refer to the same meta data.
+	src, n := &dbi.MeasurementSchemas[0], len(dbi.MeasurementSchemas)
+
+	s.mu.RLock()
+	e, ok := s.cache[database]
+	s.mu.RUnlock()
+	if ok && e.src == src && e.n == n {
+		return e.schemas
+	}
+
+	e = measurementSchemasEntry{
+		src:     src,
+		n:       n,
+		schemas: make(map[string]*tsdb.MeasurementSchema, n),
+	}
+	for _, si := range dbi.MeasurementSchemas {
+		schema := &tsdb.MeasurementSchema{
+			Tags:   make(map[string]struct{}, len(si.Tags)),
+			Fields: make(map[string]tsdb.MeasurementSchemaField, len(si.Fields)),
+		}
+		for _, tag := range si.Tags {
+			schema.Tags[tag] = struct{}{}
+		}
+		for _, f := range si.Fields {
+			schema.Fields[f.Name] = tsdb.MeasurementSchemaField{Type: f.Type, Required: f.Required}
+		}
+		e.schemas[si.Name] = schema
+	}
+
+	s.mu.Lock()
+	if s.cache == nil {
+		s.cache = make(map[string]measurementSchemasEntry)
+	}
+	s.cache[database] = e
+	s.mu.Unlock()
+
+	return e.schemas
+}
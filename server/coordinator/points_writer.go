@@ -11,6 +11,7 @@ import (
 	"github.com/cnosdb/cnosdb"
 	"github.com/cnosdb/cnosdb/meta"
 	"github.com/cnosdb/cnosdb/vend/db/models"
+	"github.com/cnosdb/cnosdb/vend/db/query"
 	"github.com/cnosdb/cnosdb/vend/db/tsdb"
 
 	"go.uber.org/zap"
@@ -27,6 +28,7 @@ const (
 	statWriteDrop           = "writeDrop"
 	statWriteTimeout        = "writeTimeout"
 	statWriteErr            = "writeError"
+	statWriteDedupe         = "writeDedupe"
 	statWritePointReqHH     = "pointReqHH"
 	statSubWriteOK          = "subWriteOk"
 	statSubWriteDrop        = "subWriteDrop"
@@ -55,6 +57,16 @@ type PointsWriter struct {
 	WriteTimeout time.Duration
 	Logger       *zap.Logger
 
+	// Retries of a write with the same ID within WriteIDTTL are discarded.
+	// At most MaxWriteIDs IDs are remembered, zero disables the deduplication.
+	WriteIDTTL  time.Duration
+	MaxWriteIDs int
+	writeIDs    *writeIDWindow
+
+	// Points with a location are tagged with the S2 cell containing it when
+	// GeoIndex.CellLevel is set.
+	GeoIndex query.GeoIndex
+
 	Node *cnosdb.Node
 
 	MetaClient interface {
@@ -69,11 +81,11 @@ type PointsWriter struct {
 	}
 
 	ShardWriter interface {
-		WriteShard(shardID, ownerID uint64, points []models.Point) error
+		WriteShard(shardID, ownerID uint64, writeID string, points []models.Point) error
 	}
 
 	HintedHandoff interface {
-		WriteShard(shardID, ownerID uint64, points []models.Point) error
+		WriteShard(shardID, ownerID uint64, writeID string, points []models.Point) error
 	}
 
 	Subscriber interface {
@@ -89,6 +101,8 @@ func NewPointsWriter() *PointsWriter {
 	return &PointsWriter{
 		closing:      make(chan struct{}),
 		WriteTimeout: DefaultWriteTimeout,
+		WriteIDTTL:   DefaultWriteIDTTL,
+		MaxWriteIDs:  DefaultMaxWriteIDs,
 		Logger:       zap.NewNop(),
 		stats:        &WriteStatistics{},
 	}
@@ -125,6 +139,7 @@ func (w *PointsWriter) Open() error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 	w.closing = make(chan struct{})
+	w.writeIDs = newWriteIDWindow(w.MaxWriteIDs, w.WriteIDTTL)
 	return nil
 }
 
@@ -164,6 +179,7 @@ type WriteStatistics struct {
 	WriteDropped        int64
 	WriteTimeout        int64
 	WriteErr            int64
+	WriteDedupe         int64
 	WritePointReqHH     int64
 	SubWriteOK          int64
 	SubWriteDrop        int64
@@ -184,6 +200,7 @@ func (w *PointsWriter) Statistics(tags map[string]string) []models.Statistic {
 			statWriteDrop:           atomic.LoadInt64(&w.stats.WriteDropped),
 			statWriteTimeout:        atomic.LoadInt64(&w.stats.WriteTimeout),
 			statWriteErr:            atomic.LoadInt64(&w.stats.WriteErr),
+			statWriteDedupe:         atomic.LoadInt64(&w.stats.WriteDedupe),
 			statWritePointReqHH:     atomic.LoadInt64(&w.stats.WritePointReqHH),
 			statSubWriteOK:          atomic.LoadInt64(&w.stats.SubWriteOK),
 			statSubWriteDrop:        atomic.LoadInt64(&w.stats.SubWriteDrop),
@@ -346,9 +363,40 @@ func (w *PointsWriter) WritePoints(database, retentionPolicy string, consistency
 	return w.WritePointsPrivileged(database, retentionPolicy, consistencyLevel, points)
 }
 
+// WritePointsWithID writes data to the underlying storage like WritePoints.
+// A write with the same non-empty writeID as a write to the database
+// completed within the write ID window is a retry, it is discarded and
+// reported as successful. A retry of a write still in flight waits for it.
+func (w *PointsWriter) WritePointsWithID(writeID, database, retentionPolicy string, consistencyLevel models.ConsistencyLevel, user meta.User, points []models.Point) error {
+	if writeID == "" {
+		return w.WritePointsPrivileged(database, retentionPolicy, consistencyLevel, points)
+	}
+
+	key := database + "\x00" + writeID
+	if !w.writeIDs.Add(key) {
+		atomic.AddInt64(&w.stats.WriteDedupe, 1)
+		return nil
+	}
+
+	// Forget the ID of a write that failed so that it can be retried. The ID
+	// of a partial write is kept since a retry would write the same points
+	// again, and a write that timed out may still complete on the shard owners.
+	err := w.writePointsPrivileged(writeID, database, retentionPolicy, consistencyLevel, points)
+	if _, ok := err.(tsdb.PartialWriteError); err != nil && !ok && err != ErrTimeout && err != ErrPartialWrite {
+		w.writeIDs.Remove(key)
+	} else {
+		w.writeIDs.Done(key)
+	}
+	return err
+}
+
 // WritePointsPrivileged writes the data to the underlying storage,
 // consistencyLevel is only used for clustered scenarios
 func (w *PointsWriter) WritePointsPrivileged(database, retentionPolicy string, consistencyLevel models.ConsistencyLevel, points []models.Point) error {
+	return w.writePointsPrivileged("", database, retentionPolicy, consistencyLevel, points)
+}
+
+func (w *PointsWriter) writePointsPrivileged(writeID, database, retentionPolicy string, consistencyLevel models.ConsistencyLevel, points []models.Point) error {
 	atomic.AddInt64(&w.stats.WriteReq, 1)
 	atomic.AddInt64(&w.stats.PointWriteReq, int64(len(points)))
 
@@ -360,6 +408,12 @@ func (w *PointsWriter) WritePointsPrivileged(database, retentionPolicy string, c
 		retentionPolicy = db.DefaultRetentionPolicy
 	}
 
+	// The tag changes the series of a point, so it must be added before the
+	// points are mapped to shards.
+	if w.GeoIndex.CellLevel > 0 {
+		w.tagGeoCells(points)
+	}
+
 	shardMappings, err := w.MapShards(&WritePointsRequest{Database: database, RetentionPolicy: retentionPolicy, Points: points})
 	if err != nil {
 		return err
@@ -369,7 +423,7 @@ func (w *PointsWriter) WritePointsPrivileged(database, retentionPolicy string, c
 	ch := make(chan error, len(shardMappings.Points))
 	for shardID, points := range shardMappings.Points {
 		go func(shard *meta.ShardInfo, database, retentionPolicy string, points []models.Point) {
-			err := w.writeToShard(shard, database, retentionPolicy, consistencyLevel, points)
+			err := w.writeToShard(shard, writeID, database, retentionPolicy, consistencyLevel, points)
 			if err == tsdb.ErrShardDeletion {
 				err = tsdb.PartialWriteError{Reason: fmt.Sprintf("shard %d is pending deletion", shard.ID), Dropped: len(points)}
 			}
@@ -423,9 +477,45 @@ func (w *PointsWriter) WritePointsPrivileged(database, retentionPolicy string, c
 	return err
 }
 
+// tagGeoCells tags the points that have a location with the S2 cell that
+// contains it.
+func (w *PointsWriter) tagGeoCells(points []models.Point) {
+	for _, p := range points {
+		fields, err := p.Fields()
+		if err != nil {
+			continue
+		}
+		lat, ok := geoCoordinate(fields[w.GeoIndex.LatField])
+		if !ok {
+			continue
+		}
+		lon, ok := geoCoordinate(fields[w.GeoIndex.LonField])
+		if !ok {
+			continue
+		}
+		if token, ok := w.GeoIndex.CellToken(lat, lon); ok {
+			tags := p.Tags().Clone()
+			tags.SetString(query.GeoCellTag, token)
+			p.SetTags(tags)
+		}
+	}
+}
+
+func geoCoordinate(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
 // writeToShard writes points to a shard and ensures a write consistency level has been met.  If the write
 // partially succeeds, ErrPartialWrite is returned.
-func (w *PointsWriter) writeToShard(shard *meta.ShardInfo, database, retentionPolicy string, consistency models.ConsistencyLevel, points []models.Point) error {
+func (w *PointsWriter) writeToShard(shard *meta.ShardInfo, writeID, database, retentionPolicy string, consistency models.ConsistencyLevel, points []models.Point) error {
 	// The required number of writes to achieve the requested consistency level
 	required := len(shard.Owners)
 	switch consistency {
@@ -460,11 +550,11 @@ func (w *PointsWriter) writeToShard(shard *meta.ShardInfo, database, retentionPo
 				ch <- &AsyncWriteResult{owner, err}
 			} else {
 				atomic.AddInt64(&w.stats.PointWriteReqRemote, int64(len(points)))
-				err := w.ShardWriter.WriteShard(shardID, owner.NodeID, points)
+				err := w.ShardWriter.WriteShard(shardID, owner.NodeID, writeID, points)
 				if err != nil && tsdb.IsRetryable(err) {
 					// The remote write failed so queue it via hinted handoff
 					atomic.AddInt64(&w.stats.WritePointReqHH, int64(len(points)))
-					hherr := w.HintedHandoff.WriteShard(shardID, owner.NodeID, points)
+					hherr := w.HintedHandoff.WriteShard(shardID, owner.NodeID, writeID, points)
 					if hherr != nil {
 						ch <- &AsyncWriteResult{owner, hherr}
 						return
@@ -0,0 +1,598 @@
+package coordinator
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+	"github.com/cnosdb/cnosdb/vend/db/models"
+	"github.com/cnosdb/cnosdb/vend/db/query"
+	"github.com/cnosdb/cnosdb/vend/db/tsdb"
+)
+
+// maxResultCacheWindows is the maximum number of windows of a shard group
+// looked up in the result cache for an iterator. Iterators over more windows
+// are created without the cache.
+const maxResultCacheWindows = 100000
+
+// resultCacheEntryOverhead is the approximate memory used by an entry of the
+// result cache in addition to its points.
+const resultCacheEntryOverhead = 128
+
+// Statistics for the result cache.
+const (
+	statResultCacheHits          = "hits"          // Number of windows read from the cache.
+	statResultCacheMisses        = "misses"        // Number of closed windows that were computed.
+	statResultCacheEvictions     = "evictions"     // Number of windows removed to free memory.
+	statResultCacheInvalidations = "invalidations" // Number of windows removed by writes and deletes.
+	statResultCacheEntries       = "entries"       // Number of windows in the cache.
+	statResultCacheMemoryBytes   = "memBytes"      // Approximate memory used by the cache.
+)
+
+// ResultCache caches the partial aggregates computed by the local shards of
+// a shard group for each window of GROUP BY time() queries. Only the windows
+// that are closed, that is which end before the query is executed and are
+// within its time range, are cached. Later queries only compute the windows
+// that are not in the cache.
+//
+// The cache observes the shards so the windows are removed when points are
+// written to or deleted from them. The windows are cached for the set of
+// local shards of the group, so they are not used once a local shard is
+// created or deleted.
+type ResultCache struct {
+	mu      sync.Mutex
+	maxSize int64
+	size    int64
+	ll      *list.List
+	entries map[resultCacheKey]*list.Element
+	groups  map[resultCacheGroupKey]*resultCacheGroup
+	shards  map[uint64]map[resultCacheGroupKey]struct{}
+
+	stats *ResultCacheStatistics
+}
+
+// ResultCacheStatistics keeps statistics related to the ResultCache.
+type ResultCacheStatistics struct {
+	Hits          int64
+	Misses        int64
+	Evictions     int64
+	Invalidations int64
+}
+
+type resultCacheKey struct {
+	group  resultCacheGroupKey
+	query  string
+	window int64
+}
+
+// resultCacheGroupKey identifies the local shards of a shard group. The
+// shards are part of the key so that the windows computed before a local
+// shard of the group was created or deleted are not used.
+type resultCacheGroupKey struct {
+	id     uint64
+	shards string
+}
+
+// newResultCacheGroupKey returns the key of the local shards of a group.
+func newResultCacheGroupKey(g *localShardGroup) resultCacheGroupKey {
+	ids := append([]uint64(nil), g.ShardIDs...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var buf strings.Builder
+	for i, id := range ids {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(strconv.FormatUint(id, 10))
+	}
+	return resultCacheGroupKey{id: g.ID, shards: buf.String()}
+}
+
+// resultCacheEntry holds the encoded points of a window.
+type resultCacheEntry struct {
+	key resultCacheKey
+	end int64
+	typ cnosql.DataType
+	buf []byte
+}
+
+func (e *resultCacheEntry) size() int64 {
+	return int64(len(e.key.group.shards)+len(e.key.query)+len(e.buf)) + resultCacheEntryOverhead
+}
+
+// resultCacheGroup tracks the windows cached for the local shards of a
+// shard group.
+type resultCacheGroup struct {
+	shardIDs []uint64
+	entries  map[resultCacheKey]struct{}
+
+	// maxEnd is the end of the last window cached for the group. Changes
+	// after it do not remove any windows.
+	maxEnd int64
+
+	// recordings are the time ranges being computed for the group.
+	recordings map[*resultCacheRecording]struct{}
+}
+
+// resultCacheRecording is a time range being computed. The windows are not
+// cached if the data changes while they are computed.
+type resultCacheRecording struct {
+	min, max int64
+	stale    bool
+}
+
+// resultCacheWindow is a window of an iterator. Its points are either read
+// from the cache or computed by the shards.
+type resultCacheWindow struct {
+	start, end int64
+	closed     bool
+	entry      *resultCacheEntry
+
+	typ cnosql.DataType
+	buf *bytes.Buffer
+}
+
+// NewResultCache returns a ResultCache using at most maxSize bytes.
+func NewResultCache(maxSize int64) *ResultCache {
+	return &ResultCache{
+		maxSize: maxSize,
+		ll:      list.New(),
+		entries: make(map[resultCacheKey]*list.Element),
+		groups:  make(map[resultCacheGroupKey]*resultCacheGroup),
+		shards:  make(map[uint64]map[resultCacheGroupKey]struct{}),
+		stats:   &ResultCacheStatistics{},
+	}
+}
+
+// Statistics returns statistics for periodic monitoring.
+func (c *ResultCache) Statistics(tags map[string]string) []models.Statistic {
+	c.mu.Lock()
+	entries, size := c.ll.Len(), c.size
+	c.mu.Unlock()
+
+	return []models.Statistic{{
+		Name: "resultCache",
+		Tags: tags,
+		Values: map[string]interface{}{
+			statResultCacheHits:          atomic.LoadInt64(&c.stats.Hits),
+			statResultCacheMisses:        atomic.LoadInt64(&c.stats.Misses),
+			statResultCacheEvictions:     atomic.LoadInt64(&c.stats.Evictions),
+			statResultCacheInvalidations: atomic.LoadInt64(&c.stats.Invalidations),
+			statResultCacheEntries:       int64(entries),
+			statResultCacheMemoryBytes:   size,
+		},
+	}}
+}
+
+// DataChanged removes the windows of a shard between min and max from the
+// cache. It implements tsdb.ShardObserver.
+func (c *ResultCache) DataChanged(shardID uint64, min, max int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for groupKey := range c.shards[shardID] {
+		g := c.groups[groupKey]
+		for rec := range g.recordings {
+			if rec.min <= max && min <= rec.max {
+				rec.stale = true
+			}
+		}
+		if min >= g.maxEnd {
+			continue
+		}
+
+		for key := range g.entries {
+			e := c.entries[key]
+			if entry := e.Value.(*resultCacheEntry); key.window <= max && min < entry.end {
+				c.remove(e)
+				atomic.AddInt64(&c.stats.Invalidations, 1)
+			}
+		}
+	}
+}
+
+// cacheable returns true if the partial aggregates of an iterator can be
+// cached by window.
+func (c *ResultCache) cacheable(m *cnosql.Measurement, opt query.IteratorOptions) bool {
+	call, ok := opt.Expr.(*cnosql.Call)
+	if !ok || opt.Interval.IsZero() || m.SystemIterator != "" {
+		return false
+	}
+
+	switch call.Name {
+	case "count", "sum", "mean", "min", "max", "first", "last":
+	default:
+		return false
+	}
+
+	// Limits and series authorization change the points of a window
+	// depending on the query.
+	return opt.Limit == 0 && opt.Offset == 0 &&
+		opt.SLimit == 0 && opt.SOffset == 0 &&
+		query.AuthorizerIsOpen(opt.Authorizer)
+}
+
+// CreateIterator creates an iterator over the local shards of a shard group.
+// The closed windows are read from the cache and the others are computed by
+// the shards.
+func (c *ResultCache) CreateIterator(ctx context.Context, g *localShardGroup, m *cnosql.Measurement, opt query.IteratorOptions) (query.Iterator, error) {
+	start, end := opt.StartTime, opt.EndTime
+	if t := g.StartTime.UnixNano(); start < t {
+		start = t
+	}
+	if t := g.EndTime.UnixNano() - 1; end > t {
+		end = t
+	}
+	if start > end {
+		return nil, nil
+	}
+
+	// Find the windows of the shard group.
+	now := time.Now().UnixNano()
+	var windows []*resultCacheWindow
+	for t := start; t <= end; {
+		if len(windows) == maxResultCacheWindows {
+			return g.Shards.CreateIterator(ctx, m, opt)
+		}
+		ws, we := opt.Window(t)
+		windows = append(windows, &resultCacheWindow{
+			start:  ws,
+			end:    we,
+			closed: ws >= opt.StartTime && we-1 <= opt.EndTime && we <= now,
+		})
+		if we <= t {
+			break
+		}
+		t = we
+	}
+
+	key := resultCacheKey{group: newResultCacheGroupKey(g), query: resultCacheQuery(m, opt)}
+	rec := c.lookup(g, key, windows)
+
+	// Compute the runs of windows that are not cached.
+	var stats query.IteratorStats
+	for i := 0; i < len(windows); {
+		if windows[i].entry != nil {
+			i++
+			continue
+		}
+		j := i
+		for j < len(windows) && windows[j].entry == nil {
+			j++
+		}
+
+		ok, err := c.compute(ctx, g, m, opt, windows[i:j], &stats)
+		if err != nil || !ok {
+			c.finish(g, key, rec, nil)
+			if err != nil {
+				return nil, err
+			}
+			return g.Shards.CreateIterator(ctx, m, opt)
+		}
+		i = j
+	}
+	c.finish(g, key, rec, windows)
+
+	// Merge the windows so the points are sorted by series, then by window.
+	inputs := make([]query.Iterator, 0, len(windows))
+	typ := cnosql.Unknown
+	for _, w := range windows {
+		wtyp, b := w.points()
+		if len(b) == 0 {
+			continue
+		} else if typ != cnosql.Unknown && wtyp != typ {
+			// The type of the field changed since the window was cached.
+			query.Iterators(inputs).Close()
+			return g.Shards.CreateIterator(ctx, m, opt)
+		}
+		typ = wtyp
+
+		// Only the first iterator reports the stats of the shards.
+		var itrStats query.IteratorStats
+		if len(inputs) == 0 {
+			itrStats = stats
+		}
+		inputs = append(inputs, query.NewReaderIterator(ctx, bytes.NewReader(b), typ, itrStats))
+	}
+	return query.NewMergeIterator(inputs, opt), nil
+}
+
+// lookup reads the cached windows and starts recording the time range of
+// the closed windows that are not cached.
+func (c *ResultCache) lookup(g *localShardGroup, key resultCacheKey, windows []*resultCacheWindow) *resultCacheRecording {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var rec *resultCacheRecording
+	for _, w := range windows {
+		if !w.closed {
+			continue
+		}
+
+		key.window = w.start
+		if e, ok := c.entries[key]; ok {
+			c.ll.MoveToFront(e)
+			w.entry = e.Value.(*resultCacheEntry)
+			atomic.AddInt64(&c.stats.Hits, 1)
+			continue
+		}
+		atomic.AddInt64(&c.stats.Misses, 1)
+
+		if rec == nil {
+			rec = &resultCacheRecording{min: w.start}
+		}
+		rec.max = w.end - 1
+	}
+
+	if rec != nil {
+		c.group(g, key.group).recordings[rec] = struct{}{}
+	}
+	return rec
+}
+
+// finish stops a recording and caches the closed windows that were computed
+// unless the data changed in the meantime.
+func (c *ResultCache) finish(g *localShardGroup, key resultCacheKey, rec *resultCacheRecording, windows []*resultCacheWindow) {
+	if rec == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	group := c.groups[key.group]
+	delete(group.recordings, rec)
+	if rec.stale {
+		c.removeGroupIfEmpty(key.group)
+		return
+	}
+
+	for _, w := range windows {
+		if !w.closed || w.buf == nil {
+			continue
+		}
+		key.window = w.start
+		if _, ok := c.entries[key]; ok {
+			continue
+		}
+
+		typ, b := w.points()
+		entry := &resultCacheEntry{key: key, end: w.end, typ: typ, buf: append([]byte(nil), b...)}
+		c.entries[key] = c.ll.PushFront(entry)
+		c.size += entry.size()
+		group.entries[key] = struct{}{}
+		if entry.end > group.maxEnd {
+			group.maxEnd = entry.end
+		}
+	}
+
+	// Remove the least recently used windows until the cache fits in memory.
+	for c.size > c.maxSize && c.ll.Len() > 0 {
+		c.remove(c.ll.Back())
+		atomic.AddInt64(&c.stats.Evictions, 1)
+	}
+	c.removeGroupIfEmpty(key.group)
+}
+
+// compute reads the windows from the shards. It returns false if the
+// shards return points outside of the windows.
+func (c *ResultCache) compute(ctx context.Context, g *localShardGroup, m *cnosql.Measurement, opt query.IteratorOptions, windows []*resultCacheWindow, stats *query.IteratorStats) (bool, error) {
+	bufs := make(map[int64]*bytes.Buffer, len(windows))
+	for _, w := range windows {
+		w.buf = &bytes.Buffer{}
+		bufs[w.start] = w.buf
+	}
+
+	if first := windows[0].start; first > opt.StartTime {
+		opt.StartTime = first
+	}
+	if last := windows[len(windows)-1].end - 1; last < opt.EndTime {
+		opt.EndTime = last
+	}
+
+	itr, err := g.Shards.CreateIterator(ctx, m, opt)
+	if err != nil {
+		return false, err
+	} else if itr == nil {
+		return true, nil
+	}
+	defer itr.Close()
+
+	typ, ok, err := encodeWindows(itr, opt, bufs)
+	if err != nil || !ok {
+		return false, err
+	}
+	for _, w := range windows {
+		w.typ = typ
+	}
+	stats.Add(itr.Stats())
+	return true, nil
+}
+
+// points returns the type and the encoded points of a window.
+func (w *resultCacheWindow) points() (cnosql.DataType, []byte) {
+	if w.entry != nil {
+		return w.entry.typ, w.entry.buf
+	} else if w.buf == nil {
+		return cnosql.Unknown, nil
+	}
+	return w.typ, w.buf.Bytes()
+}
+
+// group returns the windows cached for the local shards of a shard group.
+func (c *ResultCache) group(g *localShardGroup, key resultCacheGroupKey) *resultCacheGroup {
+	group, ok := c.groups[key]
+	if !ok {
+		group = &resultCacheGroup{
+			shardIDs:   g.ShardIDs,
+			entries:    make(map[resultCacheKey]struct{}),
+			recordings: make(map[*resultCacheRecording]struct{}),
+		}
+		c.groups[key] = group
+		for _, id := range g.ShardIDs {
+			if c.shards[id] == nil {
+				c.shards[id] = make(map[resultCacheGroupKey]struct{})
+			}
+			c.shards[id][key] = struct{}{}
+		}
+	}
+	return group
+}
+
+func (c *ResultCache) remove(e *list.Element) {
+	entry := c.ll.Remove(e).(*resultCacheEntry)
+	delete(c.entries, entry.key)
+	c.size -= entry.size()
+
+	if group, ok := c.groups[entry.key.group]; ok {
+		delete(group.entries, entry.key)
+		c.removeGroupIfEmpty(entry.key.group)
+	}
+}
+
+func (c *ResultCache) removeGroupIfEmpty(key resultCacheGroupKey) {
+	group, ok := c.groups[key]
+	if !ok || len(group.entries) > 0 || len(group.recordings) > 0 {
+		return
+	}
+	delete(c.groups, key)
+	for _, shardID := range group.shardIDs {
+		delete(c.shards[shardID], key)
+		if len(c.shards[shardID]) == 0 {
+			delete(c.shards, shardID)
+		}
+	}
+}
+
+// resultCacheQuery returns the text identifying the points computed by an
+// iterator within a window.
+func resultCacheQuery(m *cnosql.Measurement, opt query.IteratorOptions) string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "%s\x00%s\x00%s\x00%s\x00", m.Database, m.RetentionPolicy, m.Name, query.CacheKey(opt.Expr))
+	for _, ref := range opt.Aux {
+		buf.WriteString(ref.String())
+		buf.WriteByte(',')
+	}
+	buf.WriteByte(0)
+
+	dims := make([]string, 0, len(opt.GroupBy))
+	for d := range opt.GroupBy {
+		dims = append(dims, d)
+	}
+	sort.Strings(dims)
+	buf.WriteString(strings.Join(dims, ","))
+	buf.WriteByte(0)
+	buf.WriteString(strings.Join(opt.Dimensions, ","))
+	buf.WriteByte(0)
+
+	if opt.Location != nil {
+		buf.WriteString(opt.Location.String())
+	}
+	fmt.Fprintf(&buf, "\x00%d %d %v %t %t %t %t %d\x00",
+		opt.Interval.Duration, opt.Interval.Offset, opt.Interval.Calendar,
+		opt.Ascending, opt.Ordered, opt.StripName, opt.Dedupe, opt.Fill)
+	if opt.Condition != nil {
+		buf.WriteString(query.CacheKey(opt.Condition))
+	}
+	return buf.String()
+}
+
+// encodeWindows encodes the points of an iterator into the buffer of their
+// window. It returns false if a point is not within one of the windows.
+func encodeWindows(itr query.Iterator, opt query.IteratorOptions, bufs map[int64]*bytes.Buffer) (cnosql.DataType, bool, error) {
+	window := func(t int64) *bytes.Buffer {
+		start, _ := opt.Window(t)
+		return bufs[start]
+	}
+
+	switch itr := itr.(type) {
+	case query.FloatIterator:
+		for {
+			p, err := itr.Next()
+			if err != nil || p == nil {
+				return cnosql.Float, true, err
+			}
+			buf := window(p.Time)
+			if buf == nil {
+				return cnosql.Float, false, nil
+			}
+			if err := query.NewFloatPointEncoder(buf).EncodeFloatPoint(p); err != nil {
+				return cnosql.Float, false, err
+			}
+		}
+	case query.IntegerIterator:
+		for {
+			p, err := itr.Next()
+			if err != nil || p == nil {
+				return cnosql.Integer, true, err
+			}
+			buf := window(p.Time)
+			if buf == nil {
+				return cnosql.Integer, false, nil
+			}
+			if err := query.NewIntegerPointEncoder(buf).EncodeIntegerPoint(p); err != nil {
+				return cnosql.Integer, false, err
+			}
+		}
+	case query.UnsignedIterator:
+		for {
+			p, err := itr.Next()
+			if err != nil || p == nil {
+				return cnosql.Unsigned, true, err
+			}
+			buf := window(p.Time)
+			if buf == nil {
+				return cnosql.Unsigned, false, nil
+			}
+			if err := query.NewUnsignedPointEncoder(buf).EncodeUnsignedPoint(p); err != nil {
+				return cnosql.Unsigned, false, err
+			}
+		}
+	case query.StringIterator:
+		for {
+			p, err := itr.Next()
+			if err != nil || p == nil {
+				return cnosql.String, true, err
+			}
+			buf := window(p.Time)
+			if buf == nil {
+				return cnosql.String, false, nil
+			}
+			if err := query.NewStringPointEncoder(buf).EncodeStringPoint(p); err != nil {
+				return cnosql.String, false, err
+			}
+		}
+	case query.BooleanIterator:
+		for {
+			p, err := itr.Next()
+			if err != nil || p == nil {
+				return cnosql.Boolean, true, err
+			}
+			buf := window(p.Time)
+			if buf == nil {
+				return cnosql.Boolean, false, nil
+			}
+			if err := query.NewBooleanPointEncoder(buf).EncodeBooleanPoint(p); err != nil {
+				return cnosql.Boolean, false, err
+			}
+		}
+	default:
+		return cnosql.Unknown, false, fmt.Errorf("unsupported iterator type for the result cache: %T", itr)
+	}
+}
+
+// localShardGroup is the local shards of a shard group.
+type localShardGroup struct {
+	ID                 uint64
+	ShardIDs           []uint64
+	StartTime, EndTime time.Time
+	Shards             tsdb.ShardGroup
+}
@@ -0,0 +1,115 @@
+package coordinator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+	"github.com/cnosdb/cnosdb/vend/db/query"
+	"github.com/cnosdb/cnosdb/vend/db/tsdb"
+)
+
+// resultCacheTestShardGroup returns the partial counts of its points.
+type resultCacheTestShardGroup struct {
+	tsdb.ShardGroup
+	points []query.FloatPoint
+}
+
+func (sg *resultCacheTestShardGroup) CreateIterator(ctx context.Context, m *cnosql.Measurement, opt query.IteratorOptions) (query.Iterator, error) {
+	var points []query.FloatPoint
+	for _, p := range sg.points {
+		if p.Time >= opt.StartTime && p.Time <= opt.EndTime {
+			points = append(points, p)
+		}
+	}
+	return &resultCacheTestIterator{points: points}, nil
+}
+
+type resultCacheTestIterator struct {
+	points []query.FloatPoint
+}
+
+func (itr *resultCacheTestIterator) Stats() query.IteratorStats { return query.IteratorStats{} }
+func (itr *resultCacheTestIterator) Close() error               { return nil }
+
+func (itr *resultCacheTestIterator) Next() (*query.FloatPoint, error) {
+	if len(itr.points) == 0 {
+		return nil, nil
+	}
+	p := itr.points[0]
+	itr.points = itr.points[1:]
+	return &p, nil
+}
+
+// TestResultCache_Shards checks that the windows computed by the local
+// shards of a group are not used once a local shard is created or deleted.
+func TestResultCache_Shards(t *testing.T) {
+	c := NewResultCache(1 << 20)
+	m := &cnosql.Measurement{Database: "db0", RetentionPolicy: "rp0", Name: "cpu"}
+	opt := query.IteratorOptions{
+		Expr:      &cnosql.Call{Name: "count", Args: []cnosql.Expr{&cnosql.VarRef{Val: "value"}}},
+		Interval:  query.Interval{Duration: time.Minute},
+		StartTime: 0,
+		EndTime:   int64(2*time.Minute) - 1,
+		Ascending: true,
+	}
+
+	count := func(shardIDs []uint64, values ...float64) []float64 {
+		t.Helper()
+		sg := &resultCacheTestShardGroup{}
+		for i, v := range values {
+			sg.points = append(sg.points, query.FloatPoint{Name: "cpu", Time: int64(i) * int64(time.Minute), Value: v})
+		}
+		g := &localShardGroup{ID: 1, ShardIDs: shardIDs, StartTime: time.Unix(0, 0), EndTime: time.Unix(3600, 0), Shards: sg}
+
+		itr, err := c.CreateIterator(context.Background(), g, m, opt)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		defer itr.Close()
+		var got []float64
+		for {
+			p, err := itr.(query.FloatIterator).Next()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			} else if p == nil {
+				return got
+			}
+			got = append(got, p.Value)
+		}
+	}
+	check := func(got []float64, exp ...float64) {
+		t.Helper()
+		if len(got) != len(exp) {
+			t.Fatalf("unexpected counts: %v, expected %v", got, exp)
+		}
+		for i := range exp {
+			if got[i] != exp[i] {
+				t.Fatalf("unexpected counts: %v, expected %v", got, exp)
+			}
+		}
+	}
+
+	check(count([]uint64{1}, 1, 2), 1, 2)
+	check(count([]uint64{1}, 0, 0), 1, 2)
+
+	// A local shard is created in the group.
+	check(count([]uint64{2, 1}, 3, 4), 3, 4)
+	check(count([]uint64{1, 2}, 0, 0), 3, 4)
+
+	// A local shard is deleted from the group.
+	check(count([]uint64{2}, 5, 6), 5, 6)
+	if hits := c.stats.Hits; hits != 4 {
+		t.Fatalf("unexpected hits: %d", hits)
+	}
+
+	// The windows of every set of shards holding a shard are removed when
+	// its data changes.
+	c.DataChanged(2, 0, 0)
+	if n := c.stats.Invalidations; n != 2 {
+		t.Fatalf("unexpected invalidations: %d", n)
+	}
+	check(count([]uint64{1}, 0, 0), 1, 2)
+	check(count([]uint64{1, 2}, 7, 0), 7, 4)
+}
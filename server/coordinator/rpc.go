@@ -59,6 +59,16 @@ func (w *WriteShardRequest) Database() string { return w.pb.GetDatabase() }
 
 func (w *WriteShardRequest) RetentionPolicy() string { return w.pb.GetRetentionPolicy() }
 
+// SetWriteID sets the ID of the write the points belong to.
+func (w *WriteShardRequest) SetWriteID(id string) {
+	if id != "" {
+		w.pb.WriteID = &id
+	}
+}
+
+// WriteID returns the ID of the write the points belong to, if any.
+func (w *WriteShardRequest) WriteID() string { return w.pb.GetWriteID() }
+
 // Points returns the time series Points
 func (w *WriteShardRequest) Points() []models.Point { return w.unmarshalPoints() }
 
@@ -10,6 +10,7 @@ import (
 	"net"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/cnosdb/cnosdb/meta"
 	"github.com/cnosdb/cnosdb/vend/cnosql"
@@ -31,6 +32,7 @@ const (
 	writeShardReq       = "writeShardReq"
 	writeShardPointsReq = "writeShardPointsReq"
 	writeShardFail      = "writeShardFail"
+	writeShardDedupe    = "writeShardDedupe"
 
 	createIteratorReq  = "createIteratorReq"
 	createIteratorResp = "createIteratorResp"
@@ -59,14 +61,27 @@ type Service struct {
 
 	Logger  *zap.Logger
 	statMap *expvar.Map
+
+	// writeIDs holds the IDs of the writes to the local shards, keyed by
+	// shard, to discard replays of the writes.
+	writeIDs *writeIDWindow
+
+	// maxSelectMemory and spillDir set the memory budget of the iterators
+	// created for the queries of other nodes.
+	maxSelectMemory int64
+	spillDir        string
 }
 
 // NewService returns a new instance of Service.
 func NewService(c Config) *Service {
 	return &Service{
-		closing: make(chan struct{}),
-		Logger:  zap.NewNop(),
-		statMap: common.NewStatistics("coordinator", "coordinator", nil),
+		closing:  make(chan struct{}),
+		Logger:   zap.NewNop(),
+		statMap:  common.NewStatistics("coordinator", "coordinator", nil),
+		writeIDs: newWriteIDWindow(c.MaxWriteIDs, time.Duration(c.WriteIDTTL)),
+
+		maxSelectMemory: int64(c.MaxSelectMemory),
+		spillDir:        c.QuerySpillDir,
 	}
 }
 
@@ -234,6 +249,28 @@ func (s *Service) processWriteShardRequest(buf []byte) error {
 		return err
 	}
 
+	// Discard replays of a write, e.g. from hinted handoff, that has already
+	// been written to the shard.
+	if id := req.WriteID(); id != "" {
+		key := fmt.Sprintf("%d:%s", req.ShardID(), id)
+		if !s.writeIDs.Add(key) {
+			s.statMap.Add(writeShardDedupe, 1)
+			return nil
+		}
+
+		if err := s.writeShard(&req); err != nil {
+			s.writeIDs.Remove(key)
+			return err
+		}
+		s.writeIDs.Done(key)
+		return nil
+	}
+
+	return s.writeShard(&req)
+}
+
+// writeShard writes the points of the request to the local shard.
+func (s *Service) writeShard(req *WriteShardRequest) error {
 	points := req.Points()
 	s.statMap.Add(writeShardPointsReq, int64(len(points)))
 	err := s.TSDBStore.WriteToShard(req.ShardID(), points)
@@ -304,15 +341,25 @@ func (s *Service) processCreateIteratorRequest(conn net.Conn) {
 		if err := DecodeLV(conn, &req); err != nil {
 			return err
 		}
+		// The memory budget of the query is not sent with its iterator
+		// options, so the iterators are limited by the budget of this node.
+		ctx := context.Background()
+		if s.maxSelectMemory > 0 {
+			ctx = query.NewContextWithMemoryBudget(ctx, query.NewMemoryBudget(s.maxSelectMemory, s.spillDir))
+		}
+		req.Opt.Memory = query.MemoryBudgetFromContext(ctx)
+
 		sg := s.TSDBStore.ShardGroup(req.ShardIDs)
-		ic, err := sg.CreateIterator(context.Background(), &req.Measurement, req.Opt)
+		ic, err := sg.CreateIterator(ctx, &req.Measurement, req.Opt)
 		if err != nil {
 			return err
 		}
 		itr = ic
 		return nil
 	}(); err != nil {
-		itr.Close()
+		if itr != nil {
+			itr.Close()
+		}
 		//s.Logger.Printf("error reading CreateIterator request: %s", err)
 		EncodeTLV(conn, createIteratorResponseMessage, &CreateIteratorResponse{Err: err})
 		return
@@ -322,6 +369,7 @@ func (s *Service) processCreateIteratorRequest(conn net.Conn) {
 	if itr == nil {
 		return
 	}
+	defer itr.Close()
 
 	var typ cnosql.DataType
 	switch itr.(type) {
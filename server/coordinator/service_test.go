@@ -0,0 +1,68 @@
+package coordinator
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+	"github.com/cnosdb/cnosdb/vend/common/pkg/toml"
+	"github.com/cnosdb/cnosdb/vend/db/query"
+	"github.com/cnosdb/cnosdb/vend/db/tsdb"
+)
+
+type serviceTestStore struct {
+	TSDBStore
+	sg tsdb.ShardGroup
+}
+
+func (s *serviceTestStore) ShardGroup(ids []uint64) tsdb.ShardGroup { return s.sg }
+
+type serviceTestShardGroup struct {
+	tsdb.ShardGroup
+	ctx context.Context
+	opt query.IteratorOptions
+}
+
+func (sg *serviceTestShardGroup) CreateIterator(ctx context.Context, m *cnosql.Measurement, opt query.IteratorOptions) (query.Iterator, error) {
+	sg.ctx, sg.opt = ctx, opt
+	return nil, nil
+}
+
+func TestService_CreateIterator_MemoryBudget(t *testing.T) {
+	c := NewConfig()
+	c.MaxSelectMemory = toml.Size(4096)
+	s := NewService(c)
+	sg := &serviceTestShardGroup{}
+	s.TSDBStore = &serviceTestStore{sg: sg}
+
+	client, server := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.processCreateIteratorRequest(server)
+	}()
+
+	req := &CreateIteratorRequest{
+		ShardIDs:    []uint64{1},
+		Measurement: cnosql.Measurement{Name: "cpu"},
+		Opt:         query.IteratorOptions{Expr: &cnosql.VarRef{Val: "value"}},
+	}
+	if err := EncodeLV(client, req); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+	client.Close()
+
+	b := query.MemoryBudgetFromContext(sg.ctx)
+	if b == nil {
+		t.Fatal("expected a memory budget in the context")
+	} else if sg.opt.Memory != b {
+		t.Fatal("expected the iterator options to use the memory budget of the context")
+	}
+	if err := b.Reserve(4096); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if err := b.Reserve(1); err == nil {
+		t.Fatal("expected the memory budget to be limited to max-select-memory")
+	}
+}
@@ -31,13 +31,18 @@ type LocalShardMapper struct {
 		Shards(ids []uint64) []*tsdb.Shard
 		CreateShard(database, retentionPolicy string, shardID uint64, enabled bool) error
 	}
+
+	// ResultCache caches the partial aggregates of the local shard groups.
+	ResultCache *ResultCache
 }
 
 // MapShards maps the sources to the appropriate shards into an IteratorCreator.
 func (e *LocalShardMapper) MapShards(sources cnosql.Sources, t cnosql.TimeRange, opt query.SelectOptions) (query.ShardGroup, error) {
 	a := &LocalShardMapping{
-		ShardMap:  make(map[Source]tsdb.ShardGroup),
-		RemoteICs: make(map[Source][]remoteIteratorCreator),
+		ShardMap:    make(map[Source]tsdb.ShardGroup),
+		RemoteICs:   make(map[Source][]remoteIteratorCreator),
+		Groups:      make(map[Source][]*localShardGroup),
+		ResultCache: e.ResultCache,
 	}
 
 	tmin := time.Unix(0, t.MinTimeNano())
@@ -76,6 +81,7 @@ func (e *LocalShardMapper) mapShards(a *LocalShardMapping, sources cnosql.Source
 
 				shardIDs := make([]uint64, 0, len(groups[0].Shards)*len(groups))
 				for _, g := range groups {
+					n := len(shardIDs)
 					for _, si := range g.Shards {
 						var nodeID uint64
 						if si.OwnedBy(a.LocalNodeID) {
@@ -101,6 +107,17 @@ func (e *LocalShardMapper) mapShards(a *LocalShardMapping, sources cnosql.Source
 
 						}
 					}
+
+					// Keep the local shards of each group for the result cache.
+					if e.ResultCache != nil && len(shardIDs) > n {
+						ids := shardIDs[n:len(shardIDs):len(shardIDs)]
+						a.Groups[source] = append(a.Groups[source], &localShardGroup{
+							ID:        g.ID,
+							ShardIDs:  ids,
+							StartTime: g.StartTime,
+							EndTime:   g.EndTime,
+						})
+					}
 				}
 				shards := e.TSDBStore.Shards(shardIDs)
 				if len(shards) != len(shardIDs) {
@@ -111,11 +128,18 @@ func (e *LocalShardMapper) mapShards(a *LocalShardMapping, sources cnosql.Source
 
 				}
 				a.ShardMap[source] = e.TSDBStore.ShardGroup(shardIDs)
+				for _, g := range a.Groups[source] {
+					g.Shards = e.TSDBStore.ShardGroup(g.ShardIDs)
+				}
 			}
 		case *cnosql.SubQuery:
 			if err := e.mapShards(a, s.Statement.Sources, tmin, tmax); err != nil {
 				return err
 			}
+		case *cnosql.Join:
+			if err := e.mapShards(a, s.Sources(), tmin, tmax); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
@@ -127,6 +151,12 @@ type LocalShardMapping struct {
 
 	RemoteICs map[Source][]remoteIteratorCreator
 
+	// Groups are the local shards of each shard group used with the
+	// ResultCache.
+	Groups map[Source][]*localShardGroup
+
+	ResultCache *ResultCache
+
 	// MinTime is the minimum time that this shard mapper will allow.
 	// Any attempt to use a time before this one will automatically result in using
 	// this time instead.
@@ -253,7 +283,7 @@ func (a *LocalShardMapping) CreateIterator(ctx context.Context, m *cnosql.Measur
 			for _, measurement := range measurements {
 				mm := m.Clone()
 				mm.Name = measurement // Set the name to this matching regex value.
-				input, err := rg.CreateIterator(ctx, mm, opt)
+				input, err := a.createLocalIterator(ctx, source, rg, mm, opt)
 				if err != nil {
 					return err
 				}
@@ -275,7 +305,7 @@ func (a *LocalShardMapping) CreateIterator(ctx context.Context, m *cnosql.Measur
 
 	} else {
 
-		input, err := rg.CreateIterator(ctx, m, opt)
+		input, err := a.createLocalIterator(ctx, source, rg, m, opt)
 		if err != nil {
 			return nil, err
 		}
@@ -293,6 +323,27 @@ func (a *LocalShardMapping) CreateIterator(ctx context.Context, m *cnosql.Measur
 	return query.Iterators(inputs).Merge(opt)
 }
 
+// createLocalIterator creates an iterator over the local shards. The partial
+// aggregates of each shard group are read from the result cache if possible.
+func (a *LocalShardMapping) createLocalIterator(ctx context.Context, source Source, rg tsdb.ShardGroup, m *cnosql.Measurement, opt query.IteratorOptions) (query.Iterator, error) {
+	groups := a.Groups[source]
+	if len(groups) == 0 || !a.ResultCache.cacheable(m, opt) {
+		return rg.CreateIterator(ctx, m, opt)
+	}
+
+	inputs := make([]query.Iterator, 0, len(groups))
+	for _, g := range groups {
+		input, err := a.ResultCache.CreateIterator(ctx, g, m, opt)
+		if err != nil {
+			query.Iterators(inputs).Close()
+			return nil, err
+		} else if input != nil {
+			inputs = append(inputs, input)
+		}
+	}
+	return query.Iterators(inputs).Merge(opt)
+}
+
 // remoteIteratorCreator creates iterators for remote shards.
 type remoteIteratorCreator struct {
 	dialer   *NodeDialer
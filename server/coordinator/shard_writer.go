@@ -45,8 +45,9 @@ func NewShardWriter(timeout time.Duration, maxConnections int) *ShardWriter {
 	}
 }
 
-// WriteShard writes time series points to a shard
-func (w *ShardWriter) WriteShard(shardID, ownerID uint64, points []models.Point) error {
+// WriteShard writes time series points to a shard. A non-empty writeID lets
+// the owner discard retries of the write.
+func (w *ShardWriter) WriteShard(shardID, ownerID uint64, writeID string, points []models.Point) error {
 	c, err := w.dial(ownerID)
 	if err != nil {
 		return err
@@ -74,6 +75,7 @@ func (w *ShardWriter) WriteShard(shardID, ownerID uint64, points []models.Point)
 	request.SetShardID(shardID)
 	request.SetDatabase(db)
 	request.SetRetentionPolicy(rp)
+	request.SetWriteID(writeID)
 	request.AddPoints(points)
 
 	// Marshal into protocol buffers.
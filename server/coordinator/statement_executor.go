@@ -55,6 +55,9 @@ type StatementExecutor struct {
 	MaxSelectPointN   int
 	MaxSelectSeriesN  int
 	MaxSelectBucketsN int
+
+	// GeoIndex describes the location of points for the geo functions.
+	GeoIndex query.GeoIndex
 }
 
 // ExecuteStatement executes the given statement with the given execution context.
@@ -83,6 +86,11 @@ func (e *StatementExecutor) ExecuteStatement(ctx *query.ExecutionContext, stmt c
 			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
 		}
 		err = e.executeCreateDatabaseStatement(stmt)
+	case *cnosql.CreateMeasurementStatement:
+		if ctx.ReadOnly {
+			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
+		}
+		err = e.executeCreateMeasurementStatement(stmt, ctx.Database)
 	case *cnosql.CreateRetentionPolicyStatement:
 		if ctx.ReadOnly {
 			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
@@ -156,6 +164,11 @@ func (e *StatementExecutor) ExecuteStatement(ctx *query.ExecutionContext, stmt c
 			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
 		}
 		err = e.executeGrantAdminStatement(stmt)
+	case *cnosql.InsertStatement:
+		if ctx.ReadOnly {
+			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
+		}
+		rows, err = e.executeInsertStatement(ctx, stmt)
 	case *cnosql.RevokeStatement:
 		if ctx.ReadOnly {
 			messages = append(messages, query.ReadOnlyWarning(stmt.String()))
@@ -288,6 +301,26 @@ func (e *StatementExecutor) executeCreateDatabaseStatement(stmt *cnosql.CreateDa
 	return err
 }
 
+func (e *StatementExecutor) executeCreateMeasurementStatement(stmt *cnosql.CreateMeasurementStatement, database string) error {
+	if dbi := e.MetaClient.Database(database); dbi == nil {
+		return query.ErrDatabaseNotFound(database)
+	}
+
+	schema := &meta.MeasurementSchemaInfo{
+		Name:   stmt.Name,
+		Tags:   stmt.Tags,
+		Fields: make([]meta.MeasurementSchemaFieldInfo, len(stmt.Fields)),
+	}
+	for i, f := range stmt.Fields {
+		schema.Fields[i] = meta.MeasurementSchemaFieldInfo{
+			Name:     f.Name,
+			Type:     f.Type,
+			Required: f.Required,
+		}
+	}
+	return e.MetaClient.CreateMeasurementSchema(database, schema)
+}
+
 func (e *StatementExecutor) executeCreateRetentionPolicyStatement(stmt *cnosql.CreateRetentionPolicyStatement) error {
 	if !meta.ValidName(stmt.Name) {
 		// TODO This should probably be in `(*meta.Data).CreateRetentionPolicy`
@@ -352,6 +385,10 @@ func (e *StatementExecutor) executeDropDatabaseStatement(stmt *cnosql.DropDataba
 func (e *StatementExecutor) executeDropMeasurementStatement(stmt *cnosql.DropMeasurementStatement, database string) error {
 	if dbi := e.MetaClient.Database(database); dbi == nil {
 		return query.ErrDatabaseNotFound(database)
+	} else if dbi.MeasurementSchema(stmt.Name) != nil {
+		if err := e.MetaClient.DropMeasurementSchema(database, stmt.Name); err != nil {
+			return err
+		}
 	}
 
 	// Locally drop the measurement
@@ -422,7 +459,7 @@ func (e *StatementExecutor) executeExplainStatement(ctx *query.ExecutionContext,
 
 	// Prepare the query for execution, but do not actually execute it.
 	// This should perform any needed substitutions.
-	p, err := query.Prepare(q.Statement, e.ShardMapper, opt)
+	p, err := e.prepare(ctx, q.Statement, opt)
 	if err != nil {
 		return nil, err
 	}
@@ -452,7 +489,7 @@ func (e *StatementExecutor) executeExplainAnalyzeStatement(ectx *query.Execution
 	ctx = query.NewContextWithIterators(ctx, &aux)
 	start := time.Now()
 
-	cur, err := e.createIterators(ctx, stmt, ectx.ExecutionOptions)
+	cur, err := e.createIterators(ctx, ectx, stmt)
 	if err != nil {
 		return nil, err
 	}
@@ -518,6 +555,232 @@ func (e *StatementExecutor) executeGrantAdminStatement(stmt *cnosql.GrantAdminSt
 	return e.MetaClient.SetAdminPrivilege(stmt.User, true)
 }
 
+// executeInsertStatement writes the points of an INSERT statement. The
+// consecutive INSERT statements of a query are validated before any of them
+// is written, and their points are written together by the first of them.
+func (e *StatementExecutor) executeInsertStatement(ctx *query.ExecutionContext, stmt *cnosql.InsertStatement) (models.Rows, error) {
+	stmts := insertStatements(ctx, stmt)
+	if stmts == nil {
+		// The points were written with the previous INSERT statements.
+		return insertResult(len(stmt.Values)), nil
+	}
+
+	now := time.Now().UTC()
+	fieldTypes := make(map[string]cnosql.DataType)
+	var reqs []*IntoWriteRequest
+	for i, s := range stmts {
+		points, err := e.insertPoints(ctx, s, i > 0, now, fieldTypes)
+		if err != nil {
+			if i > 0 {
+				return nil, fmt.Errorf("statement %d: %s", ctx.StatementID()+i, err)
+			}
+			return nil, err
+		}
+
+		m := s.Measurement
+		if n := len(reqs); n > 0 && reqs[n-1].Database == m.Database && reqs[n-1].RetentionPolicy == m.RetentionPolicy {
+			reqs[n-1].Points = append(reqs[n-1].Points, points...)
+			continue
+		}
+		reqs = append(reqs, &IntoWriteRequest{
+			Database:        m.Database,
+			RetentionPolicy: m.RetentionPolicy,
+			Points:          points,
+		})
+	}
+
+	for _, req := range reqs {
+		if err := e.PointsWriter.WritePointsInto(req); err != nil {
+			return nil, err
+		}
+	}
+	return insertResult(len(stmt.Values)), nil
+}
+
+// insertStatements returns the consecutive INSERT statements of the query
+// that start with stmt. It returns nil if stmt follows an INSERT statement.
+func insertStatements(ctx *query.ExecutionContext, stmt *cnosql.InsertStatement) []*cnosql.InsertStatement {
+	stmts := []*cnosql.InsertStatement{stmt}
+	id := ctx.StatementID()
+	if ctx.Query == nil || id >= len(ctx.Query.Statements) || ctx.Query.Statements[id] != stmt {
+		return stmts
+	}
+	if id > 0 {
+		if _, ok := ctx.Query.Statements[id-1].(*cnosql.InsertStatement); ok {
+			return nil
+		}
+	}
+	for _, s := range ctx.Query.Statements[id+1:] {
+		s, ok := s.(*cnosql.InsertStatement)
+		if !ok {
+			break
+		}
+		stmts = append(stmts, s)
+	}
+	return stmts
+}
+
+// insertPoints returns the points of an INSERT statement. The statements that
+// are not executed yet are normalized first. The types of the fields are
+// recorded in fieldTypes, so that a field has a single type in the points of
+// the statements that are written together.
+func (e *StatementExecutor) insertPoints(ctx *query.ExecutionContext, stmt *cnosql.InsertStatement, normalize bool, now time.Time, fieldTypes map[string]cnosql.DataType) ([]models.Point, error) {
+	if normalize {
+		if err := e.NormalizeStatement(stmt, ctx.Database, ctx.RetentionPolicy); err != nil {
+			return nil, err
+		}
+	}
+
+	m := stmt.Measurement
+	dbi := e.MetaClient.Database(m.Database)
+	if dbi == nil {
+		return nil, query.ErrDatabaseNotFound(m.Database)
+	}
+
+	// Columns without a type are typed after the schema of the measurement,
+	// or else after their values.
+	types := make([]cnosql.DataType, len(stmt.Columns))
+	timeIndex := -1
+	for i, c := range stmt.Columns {
+		types[i] = c.Type
+		if c.Type == cnosql.Unknown && strings.EqualFold(c.Val, "time") {
+			timeIndex = i
+		}
+	}
+	if schema := dbi.MeasurementSchema(m.Name); schema != nil {
+		for i, c := range stmt.Columns {
+			if c.Type != cnosql.Unknown || i == timeIndex {
+				continue
+			}
+			for _, tag := range schema.Tags {
+				if tag == c.Val {
+					types[i] = cnosql.Tag
+				}
+			}
+			for _, f := range schema.Fields {
+				if f.Name == c.Val {
+					types[i] = f.Type
+				}
+			}
+		}
+	}
+
+	points := make([]models.Point, 0, len(stmt.Values))
+	for _, values := range stmt.Values {
+		var tags models.Tags
+		fields := make(models.Fields, len(values))
+		t := now
+		for i, v := range values {
+			c := stmt.Columns[i]
+			switch {
+			case i == timeIndex:
+				var err error
+				if t, err = insertTime(v, now); err != nil {
+					return nil, err
+				}
+			case types[i] == cnosql.Tag:
+				s, ok := v.(*cnosql.StringLiteral)
+				if !ok {
+					return nil, fmt.Errorf("tag %s must be a string", c.Val)
+				}
+				tags = append(tags, models.NewTag([]byte(c.Val), []byte(s.Val)))
+			default:
+				value, err := insertFieldValue(v, types[i])
+				if err != nil {
+					return nil, fmt.Errorf("field %s: %s", c.Val, err)
+				}
+				key := m.Database + "." + m.RetentionPolicy + "." + m.Name + "\x00" + c.Val
+				typ := cnosql.InspectDataType(value)
+				if exp, ok := fieldTypes[key]; !ok {
+					fieldTypes[key] = typ
+				} else if typ != exp {
+					return nil, fmt.Errorf("field %s: %s value conflicts with %s values", c.Val, typ, exp)
+				}
+				fields[c.Val] = value
+			}
+		}
+
+		sort.Sort(tags)
+		p, err := models.NewPoint(m.Name, tags, fields, t)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+
+	return points, nil
+}
+
+// insertResult returns the result of an INSERT statement.
+func insertResult(written int) models.Rows {
+	return []*models.Row{{
+		Name:    "result",
+		Columns: []string{"time", "written"},
+		Values:  [][]interface{}{{time.Unix(0, 0).UTC(), int64(written)}},
+	}}
+}
+
+// insertTime returns the time of a point of an INSERT statement.
+func insertTime(expr cnosql.Expr, now time.Time) (time.Time, error) {
+	switch expr := expr.(type) {
+	case *cnosql.IntegerLiteral:
+		return time.Unix(0, expr.Val).UTC(), nil
+	case *cnosql.StringLiteral:
+		t, err := expr.ToTimeLiteral(time.UTC)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return t.Val, nil
+	case *cnosql.Call:
+		if expr.Name == "now" {
+			return now, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %s", expr)
+}
+
+// insertFieldValue converts the literal of a field of an INSERT statement to
+// the type of the field. Fields without a type take the type of the literal,
+// numbers being floats as in the line protocol.
+func insertFieldValue(expr cnosql.Expr, typ cnosql.DataType) (interface{}, error) {
+	switch expr := expr.(type) {
+	case *cnosql.NumberLiteral:
+		switch typ {
+		case cnosql.Unknown, cnosql.AnyField, cnosql.Float:
+			return expr.Val, nil
+		}
+	case *cnosql.IntegerLiteral:
+		switch typ {
+		case cnosql.Unknown, cnosql.AnyField, cnosql.Float:
+			return float64(expr.Val), nil
+		case cnosql.Integer:
+			return expr.Val, nil
+		case cnosql.Unsigned:
+			if expr.Val >= 0 {
+				return uint64(expr.Val), nil
+			}
+		}
+	case *cnosql.UnsignedLiteral:
+		switch typ {
+		case cnosql.Unknown, cnosql.AnyField, cnosql.Float:
+			return float64(expr.Val), nil
+		case cnosql.Unsigned:
+			return expr.Val, nil
+		}
+	case *cnosql.StringLiteral:
+		switch typ {
+		case cnosql.Unknown, cnosql.AnyField, cnosql.String:
+			return expr.Val, nil
+		}
+	case *cnosql.BooleanLiteral:
+		switch typ {
+		case cnosql.Unknown, cnosql.AnyField, cnosql.Boolean:
+			return expr.Val, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid %s value %s", typ, expr)
+}
+
 func (e *StatementExecutor) executeRevokeStatement(stmt *cnosql.RevokeStatement) error {
 	priv := cnosql.NoPrivileges
 
@@ -543,7 +806,7 @@ func (e *StatementExecutor) executeSetPasswordUserStatement(q *cnosql.SetPasswor
 }
 
 func (e *StatementExecutor) executeSelectStatement(ctx *query.ExecutionContext, stmt *cnosql.SelectStatement) error {
-	cur, err := e.createIterators(ctx, stmt, ctx.ExecutionOptions)
+	cur, err := e.createIterators(ctx, ctx, stmt)
 	if err != nil {
 		return err
 	}
@@ -629,7 +892,8 @@ func (e *StatementExecutor) executeSelectStatement(ctx *query.ExecutionContext,
 	return nil
 }
 
-func (e *StatementExecutor) createIterators(ctx context.Context, stmt *cnosql.SelectStatement, opt query.ExecutionOptions) (query.Cursor, error) {
+func (e *StatementExecutor) createIterators(ctx context.Context, ectx *query.ExecutionContext, stmt *cnosql.SelectStatement) (query.Cursor, error) {
+	opt := ectx.ExecutionOptions
 	sopt := query.SelectOptions{
 		NodeID:      opt.NodeID,
 		MaxSeriesN:  e.MaxSelectSeriesN,
@@ -639,11 +903,26 @@ func (e *StatementExecutor) createIterators(ctx context.Context, stmt *cnosql.Se
 	}
 
 	// Create a set of iterators from a selection.
-	cur, err := query.Select(ctx, stmt, e.ShardMapper, sopt)
+	p, err := e.prepare(ectx, stmt, sopt)
+	if err != nil {
+		return nil, err
+	}
+	// Must be deferred so it runs after Select.
+	defer p.Close()
+	return p.Select(ctx)
+}
+
+// prepare compiles and prepares a SELECT statement for execution. The
+// compiled statement is reused from the plan cache of the executor.
+func (e *StatementExecutor) prepare(ctx *query.ExecutionContext, stmt *cnosql.SelectStatement, opt query.SelectOptions) (query.PreparedStatement, error) {
+	c, err := ctx.Compile(stmt, query.CompileOptions{
+		GeoIndex: e.GeoIndex,
+		Params:   ctx.Params,
+	})
 	if err != nil {
 		return nil, err
 	}
-	return cur, nil
+	return c.Prepare(e.ShardMapper, opt)
 }
 
 func (e *StatementExecutor) executeShowContinuousQueriesStatement(stmt *cnosql.ShowContinuousQueriesStatement) (models.Rows, error) {
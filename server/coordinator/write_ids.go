@@ -0,0 +1,154 @@
+package coordinator
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// writeIDWindow remembers the IDs of the writes completed within a time
+// window. It holds at most n IDs and forgets the oldest ones first when full.
+// The IDs of the writes in flight are held apart, and never forgotten, until
+// the writes are marked done or removed.
+type writeIDWindow struct {
+	mu  sync.Mutex
+	ttl time.Duration
+	n   int
+
+	ids   map[string]*list.Element
+	order *list.List // of *writeIDEntry, oldest first
+
+	// inflight maps the IDs of the writes in flight to a channel closed
+	// when the write is done or removed.
+	inflight map[string]chan struct{}
+
+	now func() time.Time
+}
+
+type writeIDEntry struct {
+	id   string
+	seen time.Time
+}
+
+// newWriteIDWindow returns a window remembering up to n write IDs for ttl.
+// It returns nil, which never reports duplicates, if n or ttl is not positive.
+func newWriteIDWindow(n int, ttl time.Duration) *writeIDWindow {
+	if n <= 0 || ttl <= 0 {
+		return nil
+	}
+	return &writeIDWindow{
+		ttl:      ttl,
+		n:        n,
+		ids:      make(map[string]*list.Element),
+		order:    list.New(),
+		inflight: make(map[string]chan struct{}),
+		now:      time.Now,
+	}
+}
+
+// Add records id as in flight and returns true, or returns false if a write
+// with id has completed within the window. If a write with id is in flight,
+// Add waits for it to be done or removed first, so a retry racing with the
+// original write is discarded if the original succeeds and performed if it
+// fails. Every call returning true must be followed by a call to Done or
+// Remove.
+func (w *writeIDWindow) Add(id string) bool {
+	if w == nil {
+		return true
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for {
+		w.expire(w.now())
+
+		if _, ok := w.ids[id]; ok {
+			return false
+		}
+
+		ch, ok := w.inflight[id]
+		if !ok {
+			break
+		}
+		w.mu.Unlock()
+		<-ch
+		w.mu.Lock()
+	}
+
+	w.inflight[id] = make(chan struct{})
+	return true
+}
+
+// Done records that the write with id completed, so that its retries within
+// the window are discarded.
+func (w *writeIDWindow) Done(id string) {
+	if w == nil {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.now()
+	w.expire(now)
+
+	if ch, ok := w.inflight[id]; ok {
+		delete(w.inflight, id)
+		close(ch)
+	}
+	if _, ok := w.ids[id]; ok {
+		return
+	}
+
+	if w.order.Len() >= w.n {
+		w.remove(w.order.Front())
+	}
+	w.ids[id] = w.order.PushBack(&writeIDEntry{id: id, seen: now})
+}
+
+// Remove forgets id, so that a retry of a failed write is not discarded.
+func (w *writeIDWindow) Remove(id string) {
+	if w == nil {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if ch, ok := w.inflight[id]; ok {
+		delete(w.inflight, id)
+		close(ch)
+	}
+	if e, ok := w.ids[id]; ok {
+		w.remove(e)
+	}
+}
+
+// Len returns the number of the IDs of completed writes in the window.
+func (w *writeIDWindow) Len() int {
+	if w == nil {
+		return 0
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.expire(w.now())
+	return w.order.Len()
+}
+
+// expire removes the IDs seen before the window. This method assumes w's
+// mutex is already locked.
+func (w *writeIDWindow) expire(now time.Time) {
+	for e := w.order.Front(); e != nil; e = w.order.Front() {
+		if now.Sub(e.Value.(*writeIDEntry).seen) < w.ttl {
+			return
+		}
+		w.remove(e)
+	}
+}
+
+func (w *writeIDWindow) remove(e *list.Element) {
+	delete(w.ids, e.Value.(*writeIDEntry).id)
+	w.order.Remove(e)
+}
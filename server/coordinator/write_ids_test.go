@@ -0,0 +1,201 @@
+package coordinator
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestWriteIDWindow(n int, ttl time.Duration) (*writeIDWindow, *time.Time) {
+	now := time.Unix(0, 0)
+	w := newWriteIDWindow(n, ttl)
+	w.now = func() time.Time { return now }
+	return w, &now
+}
+
+func TestWriteIDWindow_Disabled(t *testing.T) {
+	for _, w := range []*writeIDWindow{newWriteIDWindow(0, time.Minute), newWriteIDWindow(1, 0)} {
+		if w != nil {
+			t.Fatalf("expected nil window, got %v", w)
+		}
+		for i := 0; i < 2; i++ {
+			if !w.Add("a") {
+				t.Fatal("expected nil window not to report duplicates")
+			}
+			w.Done("a")
+		}
+		w.Remove("a")
+		if n := w.Len(); n != 0 {
+			t.Fatalf("unexpected len: %d", n)
+		}
+	}
+}
+
+func TestWriteIDWindow_Expire(t *testing.T) {
+	w, now := newTestWriteIDWindow(10, time.Minute)
+
+	if !w.Add("a") {
+		t.Fatal("expected a to be added")
+	}
+	w.Done("a")
+
+	*now = now.Add(30 * time.Second)
+	if !w.Add("b") {
+		t.Fatal("expected b to be added")
+	}
+	w.Done("b")
+	if w.Add("a") {
+		t.Fatal("expected a to be a duplicate")
+	}
+
+	*now = now.Add(30 * time.Second)
+	if n := w.Len(); n != 1 {
+		t.Fatalf("unexpected len: %d", n)
+	}
+	if w.Add("b") {
+		t.Fatal("expected b to be a duplicate")
+	}
+	if !w.Add("a") {
+		t.Fatal("expected a to have expired")
+	}
+	w.Done("a")
+
+	*now = now.Add(time.Minute)
+	if n := w.Len(); n != 0 {
+		t.Fatalf("unexpected len: %d", n)
+	}
+}
+
+func TestWriteIDWindow_Capacity(t *testing.T) {
+	w, _ := newTestWriteIDWindow(2, time.Minute)
+
+	for _, id := range []string{"a", "b", "c"} {
+		if !w.Add(id) {
+			t.Fatalf("expected %s to be added", id)
+		}
+		w.Done(id)
+	}
+	if n := w.Len(); n != 2 {
+		t.Fatalf("unexpected len: %d", n)
+	}
+	if w.Add("b") || w.Add("c") {
+		t.Fatal("expected b and c to be duplicates")
+	}
+	if !w.Add("a") {
+		t.Fatal("expected a to have been evicted")
+	}
+	w.Done("a")
+	if !w.Add("b") {
+		t.Fatal("expected b to have been evicted")
+	}
+
+	w.Done("b")
+
+	// A write in flight is not evicted by the writes completing meanwhile.
+	w, _ = newTestWriteIDWindow(2, time.Minute)
+	if !w.Add("x") {
+		t.Fatal("expected x to be added")
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		if !w.Add(id) {
+			t.Fatalf("expected %s to be added", id)
+		}
+		w.Done(id)
+	}
+	w.Done("x")
+	if w.Add("x") {
+		t.Fatal("expected x to be a duplicate")
+	}
+	if !w.Add("b") {
+		t.Fatal("expected b to have been evicted")
+	}
+	w.Done("b")
+}
+
+func TestWriteIDWindow_Remove(t *testing.T) {
+	w, _ := newTestWriteIDWindow(10, time.Minute)
+
+	// A failed write is removed while in flight.
+	if !w.Add("a") {
+		t.Fatal("expected a to be added")
+	}
+	w.Remove("a")
+	if !w.Add("a") {
+		t.Fatal("expected a to have been removed")
+	}
+	w.Done("a")
+
+	// A completed write is removed.
+	w.Remove("a")
+	if n := w.Len(); n != 0 {
+		t.Fatalf("unexpected len: %d", n)
+	}
+	if !w.Add("a") {
+		t.Fatal("expected a to have been removed")
+	}
+	w.Done("a")
+
+	w.Remove("unknown")
+	if n := w.Len(); n != 1 {
+		t.Fatalf("unexpected len: %d", n)
+	}
+}
+
+// Ensure a retry of a write in flight waits for the write, and is discarded
+// if it succeeds or performed if it fails.
+func TestWriteIDWindow_ConcurrentRetry(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		fail bool
+	}{
+		{name: "succeeded", fail: false},
+		{name: "failed", fail: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newWriteIDWindow(10, time.Minute)
+			if !w.Add("a") {
+				t.Fatal("expected a to be added")
+			}
+
+			const retries = 8
+			var wg sync.WaitGroup
+			added := make(chan bool, retries)
+			for i := 0; i < retries; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					ok := w.Add("a")
+					if ok {
+						// The retry that performs the write succeeds.
+						w.Done("a")
+					}
+					added <- ok
+				}()
+			}
+
+			select {
+			case <-added:
+				t.Fatal("expected retries to wait for the write in flight")
+			case <-time.After(50 * time.Millisecond):
+			}
+
+			if tt.fail {
+				w.Remove("a")
+			} else {
+				w.Done("a")
+			}
+			wg.Wait()
+			close(added)
+
+			var n int
+			for ok := range added {
+				if ok {
+					n++
+				}
+			}
+			if exp := map[bool]int{false: 0, true: 1}[tt.fail]; n != exp {
+				t.Fatalf("unexpected number of retries performed: got=%d exp=%d", n, exp)
+			}
+		})
+	}
+}
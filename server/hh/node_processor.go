@@ -1,6 +1,7 @@
 package hh
 
 import (
+	"bytes"
 	"encoding/binary"
 	"expvar"
 	"fmt"
@@ -127,7 +128,7 @@ func (n *NodeProcessor) Purge() error {
 
 // WriteShard writes hinted-handoff data for the given shard and node. Since it may manipulate
 // hinted-handoff queues, and be called concurrently, it takes a lock during queue access.
-func (n *NodeProcessor) WriteShard(shardID uint64, points []models.Point) error {
+func (n *NodeProcessor) WriteShard(shardID uint64, writeID string, points []models.Point) error {
 	n.mu.RLock()
 	defer n.mu.RUnlock()
 
@@ -138,7 +139,7 @@ func (n *NodeProcessor) WriteShard(shardID uint64, points []models.Point) error
 	n.statMap.Add(writeShardReq, 1)
 	n.statMap.Add(writeShardReqPoints, int64(len(points)))
 
-	b := marshalWrite(shardID, points)
+	b := marshalWrite(shardID, writeID, points)
 	return n.queue.Append(b)
 }
 
@@ -222,8 +223,8 @@ func (n *NodeProcessor) SendWrite() (int, error) {
 		return 0, err
 	}
 
-	// unmarshal the byte slice back to shard ID and points
-	shardID, points, err := unmarshalWrite(buf)
+	// unmarshal the byte slice back to shard ID, write ID and points
+	shardID, writeID, points, err := unmarshalWrite(buf)
 	if err != nil {
 		n.Logger.Printf("unmarshal write failed: %v", err)
 		// Try to skip it.
@@ -233,7 +234,7 @@ func (n *NodeProcessor) SendWrite() (int, error) {
 		return 0, err
 	}
 
-	if err := n.writer.WriteShard(shardID, n.nodeID, points); err != nil {
+	if err := n.writer.WriteShard(shardID, n.nodeID, writeID, points); err != nil {
 		n.statMap.Add(writeNodeReqFail, 1)
 		return 0, err
 	}
@@ -275,9 +276,18 @@ func (n *NodeProcessor) Active() (bool, error) {
 	return nio != nil, nil
 }
 
-func marshalWrite(shardID uint64, points []models.Point) []byte {
+// writeIDPrefix starts the line carrying the write ID of a queued write. It is
+// a line protocol comment, so it is skipped when parsing the points.
+const writeIDPrefix = "# write-id "
+
+func marshalWrite(shardID uint64, writeID string, points []models.Point) []byte {
 	b := make([]byte, 8)
 	binary.BigEndian.PutUint64(b, shardID)
+	if writeID != "" && !strings.ContainsAny(writeID, "\r\n") {
+		b = append(b, writeIDPrefix...)
+		b = append(b, writeID...)
+		b = append(b, '\n')
+	}
 	for _, p := range points {
 		b = append(b, []byte(p.String())...)
 		b = append(b, '\n')
@@ -285,11 +295,19 @@ func marshalWrite(shardID uint64, points []models.Point) []byte {
 	return b
 }
 
-func unmarshalWrite(b []byte) (uint64, []models.Point, error) {
+func unmarshalWrite(b []byte) (uint64, string, []models.Point, error) {
 	if len(b) < 8 {
-		return 0, nil, fmt.Errorf("too short: len = %d", len(b))
+		return 0, "", nil, fmt.Errorf("too short: len = %d", len(b))
 	}
 	ownerID := binary.BigEndian.Uint64(b[:8])
+
+	var writeID string
+	if bytes.HasPrefix(b[8:], []byte(writeIDPrefix)) {
+		if i := bytes.IndexByte(b[8:], '\n'); i >= 0 {
+			writeID = string(b[8+len(writeIDPrefix) : 8+i])
+		}
+	}
+
 	points, err := models.ParsePoints(b[8:])
-	return ownerID, points, err
+	return ownerID, writeID, points, err
 }
@@ -53,7 +53,7 @@ type Service struct {
 }
 
 type shardWriter interface {
-	WriteShard(shardID, ownerID uint64, points []models.Point) error
+	WriteShard(shardID, ownerID uint64, writeID string, points []models.Point) error
 }
 
 type metaClient interface {
@@ -155,7 +155,7 @@ func (s *Service) WithLogger(l *zap.Logger) {
 }
 
 // WriteShard queues the points write for shardID to node ownerID to handoff queue
-func (s *Service) WriteShard(shardID, ownerID uint64, points []models.Point) error {
+func (s *Service) WriteShard(shardID, ownerID uint64, writeID string, points []models.Point) error {
 	if !s.cfg.Enabled {
 		return ErrHintedHandoffDisabled
 	}
@@ -185,7 +185,7 @@ func (s *Service) WriteShard(shardID, ownerID uint64, points []models.Point) err
 		}
 	}
 
-	if err := processor.WriteShard(shardID, points); err != nil {
+	if err := processor.WriteShard(shardID, writeID, points); err != nil {
 		return err
 	}
 
@@ -28,6 +28,15 @@ const (
 
 	// DefaultEnqueuedWriteTimeout is the maximum time a write request can wait to be processed.
 	DefaultEnqueuedWriteTimeout = 30 * time.Second
+
+	// DefaultPromQLMaxSamples is the default maximum number of samples a single PromQL query can load.
+	DefaultPromQLMaxSamples = 50000000
+
+	// DefaultPromQLTimeout is the default maximum time a PromQL query may take.
+	DefaultPromQLTimeout = 2 * time.Minute
+
+	// DefaultPromQLLookbackDelta is the default delta to look back for samples when evaluating PromQL.
+	DefaultPromQLLookbackDelta = 5 * time.Minute
 )
 
 type HTTPConfig struct {
@@ -56,6 +65,9 @@ type HTTPConfig struct {
 	MaxConcurrentWriteLimit int            `toml:"max-concurrent-write-limit"`
 	MaxEnqueuedWriteLimit   int            `toml:"max-enqueued-write-limit"`
 	EnqueuedWriteTimeout    time.Duration  `toml:"enqueued-write-timeout"`
+	PromQLMaxSamples        int            `toml:"promql-max-samples"`
+	PromQLTimeout           time.Duration  `toml:"promql-timeout"`
+	PromQLLookbackDelta     time.Duration  `toml:"promql-lookback-delta"`
 	TLS                     *tls.Config    `toml:"-"`
 }
 
@@ -75,6 +87,9 @@ func NewHTTPConfig() HTTPConfig {
 		BindSocket:            DefaultBindSocket,
 		MaxBodySize:           DefaultMaxBodySize,
 		EnqueuedWriteTimeout:  DefaultEnqueuedWriteTimeout,
+		PromQLMaxSamples:      DefaultPromQLMaxSamples,
+		PromQLTimeout:         DefaultPromQLTimeout,
+		PromQLLookbackDelta:   DefaultPromQLLookbackDelta,
 	}
 }
 
@@ -20,6 +20,7 @@ import (
 	"github.com/cnosdb/cnosdb"
 	"github.com/cnosdb/cnosdb/meta"
 	"github.com/cnosdb/cnosdb/monitor"
+	"github.com/cnosdb/cnosdb/pkg/arrow"
 	"github.com/cnosdb/cnosdb/pkg/logger"
 	"github.com/cnosdb/cnosdb/pkg/prometheus"
 	"github.com/cnosdb/cnosdb/pkg/uuid"
@@ -34,6 +35,7 @@ import (
 	"github.com/golang/snappy"
 	"github.com/gorilla/mux"
 	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/promql"
 	"go.uber.org/zap"
 )
 
@@ -93,6 +95,8 @@ const (
 	statRecoveredPanics              = "recoveredPanics"      // Number of panics recovered by HTTP Handler.
 	statPromWriteRequest             = "promWriteReq"         // Number of write requests to the prometheus endpoint.
 	statPromReadRequest              = "promReadReq"          // Number of read requests to the prometheus endpoint.
+	statPromQueryRequest             = "promQueryReq"         // Number of PromQL query requests served.
+	statJSONWriteRequest             = "jsonWriteReq"         // Number of write requests to the JSON write endpoint.
 )
 
 // 如果环境变量 CNOSDB_PANIC_CRASH 值已设置，并且为 true
@@ -152,6 +156,8 @@ type Handler struct {
 
 	StorageStore *storage.Store
 
+	promEngine *promql.Engine
+
 	Monitor interface {
 		Statistics(tags map[string]string) ([]*monitor.Statistic, error)
 		Diagnostics() (map[string]*diagnostics.Diagnostics, error)
@@ -159,11 +165,14 @@ type Handler struct {
 
 	PointsWriter interface {
 		WritePoints(database, retentionPolicy string, consistencyLevel models.ConsistencyLevel, user meta.User, points []models.Point) error
+		WritePointsWithID(writeID, database, retentionPolicy string, consistencyLevel models.ConsistencyLevel, user meta.User, points []models.Point) error
 	}
 
 	requestTracker *RequestTracker
 	writeThrottler *Throttler
 
+	prepared *preparedStatements
+
 	logger       *zap.Logger
 	accessLogger *log.Logger
 }
@@ -177,6 +186,8 @@ func NewHandler(conf *HTTPConfig) *Handler {
 		requestTracker: NewRequestTracker(),
 		logger:         zap.NewNop(),
 		accessLogger:   log.New(os.Stderr, "[httpd] ", 0),
+		promEngine:     newPromEngine(conf),
+		prepared:       newPreparedStatements(maxPreparedStatements),
 	}
 
 	if metaConfig, err := meta.NewDemoConfig(); err == nil {
@@ -209,6 +220,18 @@ func NewHandler(conf *HTTPConfig) *Handler {
 			"query", http.MethodGet, "/query", false, true,
 			h.serveQuery,
 		},
+		{
+			"query-prepare", http.MethodPost, "/query/prepare", false, true,
+			h.serveQueryPrepare,
+		},
+		{
+			"query-execute", http.MethodPost, "/query/execute", false, true,
+			h.serveQueryExecute,
+		},
+		{
+			"query-execute", http.MethodGet, "/query/execute", false, true,
+			h.serveQueryExecute,
+		},
 		{
 			"ping", http.MethodGet, "/ping", false, true,
 			h.servePing,
@@ -233,6 +256,58 @@ func NewHandler(conf *HTTPConfig) *Handler {
 			"prometheus-write", // Prometheus remote write
 			"POST", "/api/v1/prom/write", false, true, h.servePromWrite,
 		},
+		{
+			"prometheus-query", // Prometheus instant query
+			"GET", "/api/v1/query", true, true, h.servePromQuery,
+		},
+		{
+			"prometheus-query", // Prometheus instant query
+			"POST", "/api/v1/query", true, true, h.servePromQuery,
+		},
+		{
+			"prometheus-query-range", // Prometheus range query
+			"GET", "/api/v1/query_range", true, true, h.servePromQueryRange,
+		},
+		{
+			"prometheus-query-range", // Prometheus range query
+			"POST", "/api/v1/query_range", true, true, h.servePromQueryRange,
+		},
+		{
+			"prometheus-series", // Prometheus series metadata
+			"GET", "/api/v1/series", true, true, h.servePromSeries,
+		},
+		{
+			"prometheus-series", // Prometheus series metadata
+			"POST", "/api/v1/series", true, true, h.servePromSeries,
+		},
+		{
+			"prometheus-labels", // Prometheus label names
+			"GET", "/api/v1/labels", true, true, h.servePromLabels,
+		},
+		{
+			"prometheus-labels", // Prometheus label names
+			"POST", "/api/v1/labels", true, true, h.servePromLabels,
+		},
+		{
+			"prometheus-label-values", // Prometheus label values
+			"GET", "/api/v1/label/{name}/values", true, true, h.servePromLabelValues,
+		},
+		{
+			"json-write", // JSON documents write
+			"POST", "/api/v1/write/json", false, true, h.serveJSONWrite,
+		},
+		{
+			"json-write-mapping", // JSON write mapping of a database
+			"GET", "/api/v1/write/json/mapping", true, true, h.serveJSONWriteMapping,
+		},
+		{
+			"json-write-mapping-set",
+			"POST", "/api/v1/write/json/mapping", false, true, h.serveSetJSONWriteMapping,
+		},
+		{
+			"json-write-mapping-drop",
+			"DELETE", "/api/v1/write/json/mapping", false, true, h.serveDropJSONWriteMapping,
+		},
 	}...)
 
 	return h
@@ -291,9 +366,6 @@ func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user meta.U
 		rw = NewResponseWriter(w, r)
 	}
 
-	// Retrieve the node id the query should be executed on.
-	nodeID, _ := strconv.ParseUint(r.FormValue("node_id"), 10, 64)
-
 	var qr io.Reader
 	// Attempt to read the form value from the "q" form value.
 	if qp := strings.TrimSpace(r.FormValue("q")); qp != "" {
@@ -316,42 +388,19 @@ func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user meta.U
 		return
 	}
 
-	epoch := strings.TrimSpace(r.FormValue("epoch"))
-
 	p := cnosql.NewParser(qr)
-	db := r.FormValue("db")
 
 	// Sanitize the request query params so it doesn't show up in the response logger.
 	// Do this before anything else so a parsing error doesn't leak passwords.
 	sanitize(r)
 
 	// Parse the parameters
-	rawParams := r.FormValue("params")
-	if rawParams != "" {
-		var params map[string]interface{}
-		decoder := json.NewDecoder(strings.NewReader(rawParams))
-		decoder.UseNumber()
-		if err := decoder.Decode(&params); err != nil {
-			writeError(rw, fmt.Sprintf("error parsing query parameters: %s", err.Error()))
-			return
-		}
-
-		// Convert json.Number into int64 and float64 values
-		for k, v := range params {
-			if v, ok := v.(json.Number); ok {
-				var err error
-				if strings.Contains(string(v), ".") {
-					params[k], err = v.Float64()
-				} else {
-					params[k], err = v.Int64()
-				}
-
-				if err != nil {
-					writeError(rw, fmt.Sprintf("error parsing json value: %s", err.Error()))
-					return
-				}
-			}
-		}
+	params, err := parseQueryParams(r.FormValue("params"))
+	if err != nil {
+		writeError(rw, err.Error())
+		return
+	}
+	if params != nil {
 		p.SetParams(params)
 	}
 
@@ -362,6 +411,26 @@ func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user meta.U
 		return
 	}
 
+	h.executeQuery(w, rw, r, user, q, nil, r.FormValue("db"), r.FormValue("rp"))
+}
+
+// executeQuery executes a parsed query and writes its results.
+func (h *Handler) executeQuery(w http.ResponseWriter, rw ResponseWriter, r *http.Request, user meta.User, q *cnosql.Query, params map[string]interface{}, db, rp string) {
+	// Retrieve the node id the query should be executed on.
+	nodeID, _ := strconv.ParseUint(r.FormValue("node_id"), 10, 64)
+
+	epoch := strings.TrimSpace(r.FormValue("epoch"))
+	if r.Header.Get("Accept") == arrow.ContentType {
+		// Arrow columns of timestamps are always in nanoseconds.
+		epoch = ""
+
+		// The response is a single stream of the columns of a statement.
+		if len(q.Statements) > 1 {
+			writeError(rw, "Arrow responses hold the result of a single statement")
+			return
+		}
+	}
+
 	// Check authorization.
 	var fineAuthorizer query.FineAuthorizer
 	if h.config.AuthEnabled {
@@ -396,11 +465,12 @@ func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user meta.U
 
 	opts := query.ExecutionOptions{
 		Database:        db,
-		RetentionPolicy: r.FormValue("rp"),
+		RetentionPolicy: rp,
 		ChunkSize:       chunkSize,
 		ReadOnly:        r.Method == "GET",
 		NodeID:          nodeID,
 		Authorizer:      fineAuthorizer,
+		Params:          params,
 	}
 
 	if h.config.AuthEnabled {
@@ -573,6 +643,40 @@ func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user meta.U
 		n, _ := rw.WriteResponse(resp)
 		atomic.AddInt64(&h.stats.QueryRequestBytesTransmitted, int64(n))
 	}
+	n, _ := rw.EndResponse()
+	atomic.AddInt64(&h.stats.QueryRequestBytesTransmitted, int64(n))
+}
+
+// parseQueryParams parses the JSON object of the values of the bound
+// parameters of a query. It returns nil if there are no parameters.
+func parseQueryParams(rawParams string) (map[string]interface{}, error) {
+	if rawParams == "" {
+		return nil, nil
+	}
+
+	var params map[string]interface{}
+	decoder := json.NewDecoder(strings.NewReader(rawParams))
+	decoder.UseNumber()
+	if err := decoder.Decode(&params); err != nil {
+		return nil, fmt.Errorf("error parsing query parameters: %s", err.Error())
+	}
+
+	// Convert json.Number into int64 and float64 values
+	for k, v := range params {
+		if v, ok := v.(json.Number); ok {
+			var err error
+			if strings.Contains(string(v), ".") {
+				params[k], err = v.Float64()
+			} else {
+				params[k], err = v.Int64()
+			}
+
+			if err != nil {
+				return nil, fmt.Errorf("error parsing json value: %s", err.Error())
+			}
+		}
+	}
+	return params, nil
 }
 
 // servePing returns a simple response to let the client know the server is running.
@@ -626,31 +730,64 @@ func (h *Handler) serveWrite(w http.ResponseWriter, r *http.Request, user meta.U
 		return
 	}
 
-	database := r.URL.Query().Get("db")
-	retentionPolicy := r.URL.Query().Get("rp")
+	database, retentionPolicy, ok := h.authorizeWrite(w, r, user)
+	if !ok {
+		return
+	}
+
+	buf, ok := h.readWriteBody(w, r)
+	if !ok {
+		return
+	}
+
+	points, parseError := models.ParsePointsWithPrecision(buf, time.Now().UTC(), precision)
+	// Not points parsed correctly so return the error now
+	if parseError != nil && len(points) == 0 {
+		if parseError.Error() == "EOF" {
+			writeHeader(w, http.StatusOK)
+			return
+		}
+		writeError(w, parseError.Error())
+		return
+	}
+
+	h.writePoints(w, r, database, retentionPolicy, user, points, parseError)
+}
+
+// authorizeWrite returns the database and retention policy of a write
+// request, after checking that the database exists and the user may write to
+// it. An error is written to w when ok is false.
+func (h *Handler) authorizeWrite(w http.ResponseWriter, r *http.Request, user meta.User) (database, retentionPolicy string, ok bool) {
+	database = r.URL.Query().Get("db")
+	retentionPolicy = r.URL.Query().Get("rp")
 
 	if database == "" {
 		writeError(w, "database is required")
-		return
+		return "", "", false
 	}
 
 	if di := h.metaClient.Database(database); di == nil {
 		writeErrorWithCode(w, fmt.Sprintf("database not found: %q", database), http.StatusNotFound)
-		return
+		return "", "", false
 	}
 
 	if h.config.AuthEnabled {
 		if user == nil {
 			writeErrorWithCode(w, fmt.Sprintf("user is required to write to database %q", database), http.StatusForbidden)
-			return
+			return "", "", false
 		}
 
 		if err := h.WriteAuthorizer.AuthorizeWrite(user.ID(), database); err != nil {
 			writeErrorWithCode(w, fmt.Sprintf("%q user is not authorized to write to database %q", user.ID(), database), http.StatusForbidden)
-			return
+			return "", "", false
 		}
 	}
+	return database, retentionPolicy, true
+}
 
+// readWriteBody reads the, possibly gzipped, body of a write request. An
+// error is written to w when ok is false.
+func (h *Handler) readWriteBody(w http.ResponseWriter, r *http.Request) (_ []byte, ok bool) {
 	body := r.Body
 	if h.config.MaxBodySize > 0 {
 		body = truncateReader(body, int64(h.config.MaxBodySize))
@@ -661,7 +798,7 @@ func (h *Handler) serveWrite(w http.ResponseWriter, r *http.Request, user meta.U
 		b, err := gzip.NewReader(r.Body)
 		if err != nil {
 			writeError(w, err.Error())
-			return
+			return nil, false
 		}
 		defer b.Close()
 		body = b
@@ -671,7 +808,7 @@ func (h *Handler) serveWrite(w http.ResponseWriter, r *http.Request, user meta.U
 	if r.ContentLength > 0 {
 		if h.config.MaxBodySize > 0 && r.ContentLength > int64(h.config.MaxBodySize) {
 			writeErrorWithCode(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
-			return
+			return nil, false
 		}
 
 		// This will just be an initial hint for the gzip reader, as the
@@ -684,32 +821,27 @@ func (h *Handler) serveWrite(w http.ResponseWriter, r *http.Request, user meta.U
 	if err != nil {
 		if err == errTruncated {
 			writeErrorWithCode(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
-			return
+			return nil, false
 		}
 
 		if h.config.WriteTracing {
 			h.logger.Info("Write Handler unable to read bytes from request body")
 		}
 		writeError(w, err.Error())
-		return
+		return nil, false
 	}
 	atomic.AddInt64(&h.stats.WriteRequestBytesReceived, int64(buf.Len()))
 
 	if h.config.WriteTracing {
 		h.logger.Info("Write body received by Handler", zap.ByteString("body", buf.Bytes()))
 	}
+	return buf.Bytes(), true
+}
 
-	points, parseError := models.ParsePointsWithPrecision(buf.Bytes(), time.Now().UTC(), precision)
-	// Not points parsed correctly so return the error now
-	if parseError != nil && len(points) == 0 {
-		if parseError.Error() == "EOF" {
-			writeHeader(w, http.StatusOK)
-			return
-		}
-		writeError(w, parseError.Error())
-		return
-	}
-
+// writePoints writes the points of a write request with the requested
+// consistency level, and responds with the outcome. parseError reports the
+// part of the request which could not be converted into points.
+func (h *Handler) writePoints(w http.ResponseWriter, r *http.Request, database, retentionPolicy string, user meta.User, points []models.Point, parseError error) {
 	// Determine required consistency level.
 	level := r.URL.Query().Get("consistency")
 	consistency := models.ConsistencyLevelOne
@@ -722,8 +854,14 @@ func (h *Handler) serveWrite(w http.ResponseWriter, r *http.Request, user meta.U
 		}
 	}
 
+	// Retries of a write carrying an ID are discarded.
+	writeID := r.Header.Get("X-Write-ID")
+	if writeID == "" {
+		writeID = r.URL.Query().Get("writeId")
+	}
+
 	// Write points.
-	if err := h.PointsWriter.WritePoints(database, retentionPolicy, consistency, user, points); cnosdb.IsClientError(err) {
+	if err := h.PointsWriter.WritePointsWithID(writeID, database, retentionPolicy, consistency, user, points); cnosdb.IsClientError(err) {
 		atomic.AddInt64(&h.stats.PointsWrittenFail, int64(len(points)))
 		writeError(w, err.Error())
 		return
@@ -778,6 +916,8 @@ type Statistics struct {
 	RecoveredPanics              int64
 	PromWriteRequests            int64
 	PromReadRequests             int64
+	PromQueryRequests            int64
+	JSONWriteRequests            int64
 }
 
 // Statistics returns statistics for periodic monitoring.
@@ -808,6 +948,8 @@ func (h *Handler) Statistics(tags map[string]string) []models.Statistic {
 			statRecoveredPanics:              atomic.LoadInt64(&h.stats.RecoveredPanics),
 			statPromWriteRequest:             atomic.LoadInt64(&h.stats.PromWriteRequests),
 			statPromReadRequest:              atomic.LoadInt64(&h.stats.PromReadRequests),
+			statPromQueryRequest:             atomic.LoadInt64(&h.stats.PromQueryRequests),
+			statJSONWriteRequest:             atomic.LoadInt64(&h.stats.JSONWriteRequests),
 		},
 	}}
 }
@@ -828,7 +970,7 @@ func (h *Handler) AddRoutes(routes ...route) {
 
 		if r.Method == http.MethodPost {
 			switch r.Path {
-			case "/write", "/api/v1/prom/write":
+			case "/write", "/api/v1/prom/write", "/api/v1/write/json":
 				handler = h.writeThrottler.WrapWithThrottler(handler)
 			}
 		}
@@ -871,6 +1013,7 @@ func WrapWithCors(inner http.Handler) http.Handler {
 				`Content-Type`,
 				`X-CSRF-Token`,
 				`X-HTTPD-Method-Override`,
+				`X-Write-ID`,
 			}, ", "))
 
 			w.Header().Set(`Access-Control-Expose-Headers`, strings.Join([]string{
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/cnosdb/cnosdb/meta"
+	"github.com/cnosdb/cnosdb/pkg/jsonwrite"
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+)
+
+// serveJSONWrite writes the JSON documents of the request body into the
+// database, converted into points by the JSON write mapping of the database.
+func (h *Handler) serveJSONWrite(w http.ResponseWriter, r *http.Request, user meta.User) {
+	atomic.AddInt64(&h.stats.WriteRequests, 1)
+	atomic.AddInt64(&h.stats.ActiveWriteRequests, 1)
+	atomic.AddInt64(&h.stats.JSONWriteRequests, 1)
+	defer func(start time.Time) {
+		atomic.AddInt64(&h.stats.ActiveWriteRequests, -1)
+		atomic.AddInt64(&h.stats.WriteRequestDuration, time.Since(start).Nanoseconds())
+	}(time.Now())
+	h.requestTracker.Add(r, user)
+
+	precision := r.URL.Query().Get("precision")
+	switch precision {
+	case "", "n", "ns", "u", "ms", "s", "m", "h":
+		// it's valid
+	default:
+		writeError(w, fmt.Sprintf("invalid precision %q (use n, u, ms, s, m or h)", precision))
+		return
+	}
+
+	database, retentionPolicy, ok := h.authorizeWrite(w, r, user)
+	if !ok {
+		return
+	}
+
+	di := h.metaClient.Database(database)
+	if di == nil {
+		// The database was dropped after the request was authorized.
+		writeErrorWithCode(w, fmt.Sprintf("database not found: %q", database), http.StatusNotFound)
+		return
+	}
+	mapping := di.JSONWriteMapping
+	if mapping == nil {
+		writeError(w, fmt.Sprintf("json write mapping not found for database %q", database))
+		return
+	}
+
+	buf, ok := h.readWriteBody(w, r)
+	if !ok {
+		return
+	}
+
+	points, parseError := jsonwrite.DocumentsToPoints(buf, mapping, time.Now().UTC(), precision)
+	// Not points converted correctly so return the error now
+	if parseError != nil && len(points) == 0 {
+		writeError(w, parseError.Error())
+		return
+	} else if len(points) == 0 {
+		writeHeader(w, http.StatusOK)
+		return
+	}
+
+	h.writePoints(w, r, database, retentionPolicy, user, points, parseError)
+}
+
+// serveJSONWriteMapping responds with the JSON write mapping of a database.
+func (h *Handler) serveJSONWriteMapping(w http.ResponseWriter, r *http.Request, user meta.User) {
+	database, ok := h.authorizeJSONWriteMapping(w, r, user, cnosql.ReadPrivilege)
+	if !ok {
+		return
+	}
+
+	di := h.metaClient.Database(database)
+	if di == nil {
+		writeErrorWithCode(w, fmt.Sprintf("database not found: %q", database), http.StatusNotFound)
+		return
+	}
+	mapping := di.JSONWriteMapping
+	if mapping == nil {
+		writeErrorWithCode(w, fmt.Sprintf("json write mapping not found for database %q", database), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	writeHeader(w, http.StatusOK)
+	_ = json.NewEncoder(w).Encode(mapping)
+}
+
+// serveSetJSONWriteMapping sets the JSON write mapping of a database to the
+// mapping in the request body.
+func (h *Handler) serveSetJSONWriteMapping(w http.ResponseWriter, r *http.Request, user meta.User) {
+	database, ok := h.authorizeJSONWriteMapping(w, r, user, cnosql.AllPrivileges)
+	if !ok {
+		return
+	}
+
+	body := r.Body
+	if h.config.MaxBodySize > 0 {
+		body = truncateReader(body, int64(h.config.MaxBodySize))
+	}
+
+	var mapping meta.JSONWriteMappingInfo
+	if err := json.NewDecoder(body).Decode(&mapping); err == errTruncated {
+		writeErrorWithCode(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return
+	} else if err != nil {
+		writeError(w, fmt.Sprintf("invalid json write mapping: %s", err))
+		return
+	}
+
+	if err := h.metaClient.SetJSONWriteMapping(database, &mapping); err != nil {
+		writeError(w, err.Error())
+		return
+	}
+	writeHeader(w, http.StatusNoContent)
+}
+
+// serveDropJSONWriteMapping removes the JSON write mapping of a database.
+func (h *Handler) serveDropJSONWriteMapping(w http.ResponseWriter, r *http.Request, user meta.User) {
+	database, ok := h.authorizeJSONWriteMapping(w, r, user, cnosql.AllPrivileges)
+	if !ok {
+		return
+	}
+
+	if err := h.metaClient.DropJSONWriteMapping(database); err != nil {
+		writeError(w, err.Error())
+		return
+	}
+	writeHeader(w, http.StatusNoContent)
+}
+
+// authorizeJSONWriteMapping returns the database of a JSON write mapping
+// request, after checking that the database exists and the user has the
+// privilege on it. An error is written to w when ok is false.
+func (h *Handler) authorizeJSONWriteMapping(w http.ResponseWriter, r *http.Request, user meta.User, priv cnosql.Privilege) (database string, ok bool) {
+	database = r.URL.Query().Get("db")
+	if database == "" {
+		writeError(w, "database is required")
+		return "", false
+	}
+
+	if di := h.metaClient.Database(database); di == nil {
+		writeErrorWithCode(w, fmt.Sprintf("database not found: %q", database), http.StatusNotFound)
+		return "", false
+	}
+
+	if h.config.AuthEnabled {
+		if user == nil {
+			writeErrorWithCode(w, fmt.Sprintf("user is required to access database %q", database), http.StatusForbidden)
+			return "", false
+		}
+
+		if err := h.QueryAuthorizer.AuthorizeDatabase(user, priv, database); err != nil {
+			writeErrorWithCode(w, fmt.Sprintf("%q user is not authorized to access database %q", user.ID(), database), http.StatusForbidden)
+			return "", false
+		}
+	}
+	return database, true
+}
@@ -0,0 +1,212 @@
+package server
+
+import (
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cnosdb/cnosdb/meta"
+	"github.com/cnosdb/cnosdb/pkg/logger"
+	"github.com/cnosdb/cnosdb/pkg/uuid"
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+	"go.uber.org/zap"
+)
+
+// maxPreparedStatements is the maximum number of prepared statements kept by
+// the handler. The least recently used statements are removed first.
+const maxPreparedStatements = 10000
+
+// preparedStatement is a query prepared by /query/prepare that is executed
+// by /query/execute with the values of its bound parameters.
+type preparedStatement struct {
+	handle   string
+	query    string
+	parsed   *cnosql.Query
+	params   []string
+	database string
+	rp       string
+	user     string
+}
+
+// bind returns the statements of the prepared query to execute with the
+// values of its bound parameters. The SELECT statements are copied with their
+// parameters kept, so the executions of the query share the compiled plan of
+// the statements, and the parameters are bound when they are compiled. The
+// queries with other statements are parsed again with the values.
+func (s *preparedStatement) bind(params map[string]interface{}) (*cnosql.Query, error) {
+	for _, name := range s.params {
+		v, ok := params[name]
+		if !ok {
+			return nil, fmt.Errorf("missing parameter: %s", name)
+		} else if v, ok := cnosql.BindValue(v).(cnosql.ErrorValue); ok {
+			return nil, errors.New(v.Value())
+		}
+	}
+
+	q := &cnosql.Query{Statements: make(cnosql.Statements, 0, len(s.parsed.Statements))}
+	for _, stmt := range s.parsed.Statements {
+		sel, ok := stmt.(*cnosql.SelectStatement)
+		if !ok {
+			p := cnosql.NewParser(strings.NewReader(s.query))
+			p.SetParams(params)
+			return p.ParseQuery()
+		}
+		q.Statements = append(q.Statements, sel.Clone())
+	}
+	return q, nil
+}
+
+// preparedStatements is a least recently used set of prepared statements.
+type preparedStatements struct {
+	mu    sync.Mutex
+	max   int
+	ll    *list.List
+	stmts map[string]*list.Element
+}
+
+func newPreparedStatements(max int) *preparedStatements {
+	return &preparedStatements{
+		max:   max,
+		ll:    list.New(),
+		stmts: make(map[string]*list.Element),
+	}
+}
+
+func (s *preparedStatements) add(stmt *preparedStatement) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stmts[stmt.handle] = s.ll.PushFront(stmt)
+	for s.ll.Len() > s.max {
+		e := s.ll.Back()
+		s.ll.Remove(e)
+		delete(s.stmts, e.Value.(*preparedStatement).handle)
+	}
+}
+
+func (s *preparedStatements) get(handle string) (*preparedStatement, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.stmts[handle]
+	if !ok {
+		return nil, false
+	}
+	s.ll.MoveToFront(e)
+	return e.Value.(*preparedStatement), true
+}
+
+// userID returns the ID of the user owning a prepared statement.
+func userID(user meta.User) string {
+	if user == nil {
+		return ""
+	}
+	return user.ID()
+}
+
+// serveQueryPrepare parses a query whose bound parameters are given their
+// values later and returns the handle used to execute it.
+func (h *Handler) serveQueryPrepare(w http.ResponseWriter, r *http.Request, user meta.User) {
+	q := strings.TrimSpace(r.FormValue("q"))
+	if q == "" {
+		writeError(w, `missing required parameter "q"`)
+		return
+	}
+	db, rp := r.FormValue("db"), r.FormValue("rp")
+
+	// Sanitize the request query params so it doesn't show up in the response logger.
+	sanitize(r)
+
+	// Parse the query without the values of its parameters to validate it.
+	p := cnosql.NewParser(strings.NewReader(q))
+	p.KeepParams()
+	parsed, err := p.ParseQuery()
+	if err != nil {
+		writeError(w, fmt.Sprintf("error parsing query: %s", err.Error()))
+		return
+	}
+
+	if h.config.AuthEnabled {
+		if _, err := h.QueryAuthorizer.AuthorizeQuery(user, parsed, db); err != nil {
+			if authErr, ok := err.(meta.ErrAuthorize); ok {
+				h.logger.Info("Unauthorized request",
+					zap.String("user", authErr.User),
+					zap.Stringer("query", authErr.Query),
+					logger.Database(authErr.Database))
+			}
+			writeErrorWithCode(w, "error authorizing query: "+err.Error(), http.StatusForbidden)
+			return
+		}
+	}
+
+	stmt := &preparedStatement{
+		handle:   uuid.TimeUUID().String(),
+		query:    q,
+		parsed:   parsed,
+		params:   cnosql.BoundParameters(parsed),
+		database: db,
+		rp:       rp,
+		user:     userID(user),
+	}
+	if stmt.params == nil {
+		stmt.params = []string{}
+	}
+	h.prepared.add(stmt)
+
+	w.Header().Add(headerContentType, contentTypeJSON)
+	writeHeader(w, http.StatusOK)
+	b, _ := json.Marshal(map[string]interface{}{
+		"handle": stmt.handle,
+		"params": stmt.params,
+	})
+	w.Write(b)
+}
+
+// serveQueryExecute executes a prepared statement with the values of its
+// bound parameters.
+func (h *Handler) serveQueryExecute(w http.ResponseWriter, r *http.Request, user meta.User) {
+	atomic.AddInt64(&h.stats.QueryRequests, 1)
+	defer func(start time.Time) {
+		atomic.AddInt64(&h.stats.QueryRequestDuration, time.Since(start).Nanoseconds())
+	}(time.Now())
+	h.requestTracker.Add(r, user)
+
+	// Retrieve the underlying ResponseWriter or initialize our own.
+	rw, ok := w.(ResponseWriter)
+	if !ok {
+		rw = NewResponseWriter(w, r)
+	}
+
+	handle := r.FormValue("handle")
+	if handle == "" {
+		writeError(rw, `missing required parameter "handle"`)
+		return
+	}
+
+	// Statements prepared by other users are not visible.
+	stmt, ok := h.prepared.get(handle)
+	if !ok || (h.config.AuthEnabled && stmt.user != userID(user)) {
+		writeErrorWithCode(rw, fmt.Sprintf("prepared statement not found: %s", handle), http.StatusNotFound)
+		return
+	}
+
+	params, err := parseQueryParams(r.FormValue("params"))
+	if err != nil {
+		writeError(rw, err.Error())
+		return
+	}
+
+	q, err := stmt.bind(params)
+	if err != nil {
+		writeError(rw, fmt.Sprintf("error parsing query: %s", err.Error()))
+		return
+	}
+
+	h.executeQuery(w, rw, r, user, q, params, stmt.database, stmt.rp)
+}
@@ -0,0 +1,444 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/cnosdb/cnosdb/meta"
+	"github.com/cnosdb/cnosdb/pkg/prometheus"
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	promstorage "github.com/prometheus/prometheus/storage"
+	"go.uber.org/zap"
+)
+
+// Error types of the Prometheus HTTP API.
+const (
+	promErrorBadData  = "bad_data"
+	promErrorExec     = "execution"
+	promErrorCanceled = "canceled"
+	promErrorTimeout  = "timeout"
+	promErrorInternal = "internal"
+)
+
+var (
+	promMinTime = time.Unix(math.MinInt64/1000+62135596801, 0).UTC()
+	promMaxTime = time.Unix(math.MaxInt64/1000-62135596801, 999999999).UTC()
+)
+
+// promResponse is the envelope of every response of the Prometheus HTTP API.
+type promResponse struct {
+	Status    string      `json:"status"`
+	Data      interface{} `json:"data,omitempty"`
+	ErrorType string      `json:"errorType,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	Warnings  []string    `json:"warnings,omitempty"`
+}
+
+// promQueryData is the payload of the query and query_range endpoints.
+type promQueryData struct {
+	ResultType parser.ValueType `json:"resultType"`
+	Result     parser.Value     `json:"result"`
+}
+
+// newPromEngine returns the PromQL engine used by the query endpoints.
+func newPromEngine(conf *HTTPConfig) *promql.Engine {
+	return promql.NewEngine(promql.EngineOpts{
+		MaxSamples:    conf.PromQLMaxSamples,
+		Timeout:       conf.PromQLTimeout,
+		LookbackDelta: conf.PromQLLookbackDelta,
+	})
+}
+
+// promQueryable authorizes the request and returns a Queryable over the
+// database and retention policy given by the "db" and "rp" parameters.
+func (h *Handler) promQueryable(w http.ResponseWriter, r *http.Request, user meta.User) (promstorage.Queryable, bool) {
+	db := r.FormValue("db")
+	if db == "" {
+		h.promError(w, promErrorBadData, "database is required", http.StatusBadRequest)
+		return nil, false
+	}
+
+	if di := h.metaClient.Database(db); di == nil {
+		h.promError(w, promErrorBadData, fmt.Sprintf("database not found: %q", db), http.StatusNotFound)
+		return nil, false
+	}
+
+	if h.config.AuthEnabled {
+		if user == nil {
+			h.promError(w, promErrorBadData, fmt.Sprintf("user is required to read from database %q", db), http.StatusForbidden)
+			return nil, false
+		}
+
+		if err := h.QueryAuthorizer.AuthorizeDatabase(user, cnosql.ReadPrivilege, db); err != nil {
+			h.promError(w, promErrorBadData, fmt.Sprintf("%q user is not authorized to read from database %q", user.ID(), db), http.StatusForbidden)
+			return nil, false
+		}
+	}
+
+	return prometheus.NewQueryable(h.StorageStore, db, r.FormValue("rp")), true
+}
+
+// servePromQuery evaluates an instant PromQL query.
+func (h *Handler) servePromQuery(w http.ResponseWriter, r *http.Request, user meta.User) {
+	atomic.AddInt64(&h.stats.QueryRequests, 1)
+	atomic.AddInt64(&h.stats.PromQueryRequests, 1)
+	defer func(start time.Time) {
+		atomic.AddInt64(&h.stats.QueryRequestDuration, time.Since(start).Nanoseconds())
+	}(time.Now())
+	h.requestTracker.Add(r, user)
+
+	ts, err := parsePromTimeParam(r, "time", time.Now())
+	if err != nil {
+		h.promError(w, promErrorBadData, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel, err := promRequestContext(r)
+	if err != nil {
+		h.promError(w, promErrorBadData, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer cancel()
+
+	queryable, ok := h.promQueryable(w, r, user)
+	if !ok {
+		return
+	}
+
+	qry, err := h.promEngine.NewInstantQuery(queryable, r.FormValue("query"), ts)
+	if err != nil {
+		h.promError(w, promErrorBadData, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.promExec(ctx, w, qry)
+}
+
+// servePromQueryRange evaluates a PromQL query over a range of time.
+func (h *Handler) servePromQueryRange(w http.ResponseWriter, r *http.Request, user meta.User) {
+	atomic.AddInt64(&h.stats.QueryRequests, 1)
+	atomic.AddInt64(&h.stats.PromQueryRequests, 1)
+	defer func(start time.Time) {
+		atomic.AddInt64(&h.stats.QueryRequestDuration, time.Since(start).Nanoseconds())
+	}(time.Now())
+	h.requestTracker.Add(r, user)
+
+	start, err := parsePromTime(r.FormValue("start"))
+	if err != nil {
+		h.promError(w, promErrorBadData, fmt.Sprintf("invalid parameter \"start\": %s", err), http.StatusBadRequest)
+		return
+	}
+	end, err := parsePromTime(r.FormValue("end"))
+	if err != nil {
+		h.promError(w, promErrorBadData, fmt.Sprintf("invalid parameter \"end\": %s", err), http.StatusBadRequest)
+		return
+	}
+	if end.Before(start) {
+		h.promError(w, promErrorBadData, "end timestamp must not be before start time", http.StatusBadRequest)
+		return
+	}
+
+	step, err := parsePromDuration(r.FormValue("step"))
+	if err != nil {
+		h.promError(w, promErrorBadData, fmt.Sprintf("invalid parameter \"step\": %s", err), http.StatusBadRequest)
+		return
+	}
+	if step <= 0 {
+		h.promError(w, promErrorBadData, "zero or negative query resolution step widths are not accepted. Try a positive integer", http.StatusBadRequest)
+		return
+	}
+
+	// For safety, limit the number of returned points per timeseries.
+	// This is sufficient for 60s resolution for a week or 1h resolution for a year.
+	if end.Sub(start)/step > 11000 {
+		h.promError(w, promErrorBadData, "exceeded maximum resolution of 11,000 points per timeseries. Try decreasing the query resolution (?step=XX)", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel, err := promRequestContext(r)
+	if err != nil {
+		h.promError(w, promErrorBadData, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer cancel()
+
+	queryable, ok := h.promQueryable(w, r, user)
+	if !ok {
+		return
+	}
+
+	qry, err := h.promEngine.NewRangeQuery(queryable, r.FormValue("query"), start, end, step)
+	if err != nil {
+		h.promError(w, promErrorBadData, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.promExec(ctx, w, qry)
+}
+
+// promExec executes qry and writes its result to w.
+func (h *Handler) promExec(ctx context.Context, w http.ResponseWriter, qry promql.Query) {
+	defer qry.Close()
+
+	res := qry.Exec(ctx)
+	if res.Err != nil {
+		switch res.Err.(type) {
+		case promql.ErrQueryCanceled:
+			h.promError(w, promErrorCanceled, res.Err.Error(), http.StatusServiceUnavailable)
+		case promql.ErrQueryTimeout:
+			h.promError(w, promErrorTimeout, res.Err.Error(), http.StatusServiceUnavailable)
+		case promql.ErrStorage:
+			h.promError(w, promErrorInternal, res.Err.Error(), http.StatusInternalServerError)
+		default:
+			h.promError(w, promErrorExec, res.Err.Error(), http.StatusUnprocessableEntity)
+		}
+		return
+	}
+
+	h.promRespond(w, &promQueryData{
+		ResultType: res.Value.Type(),
+		Result:     res.Value,
+	}, res.Warnings)
+}
+
+// servePromSeries returns the label sets of the series matching any of the
+// "match[]" selectors.
+func (h *Handler) servePromSeries(w http.ResponseWriter, r *http.Request, user meta.User) {
+	if err := r.ParseForm(); err != nil {
+		h.promError(w, promErrorBadData, fmt.Sprintf("error parsing form values: %s", err), http.StatusBadRequest)
+		return
+	}
+	if len(r.Form["match[]"]) == 0 {
+		h.promError(w, promErrorBadData, "no match[] parameter provided", http.StatusBadRequest)
+		return
+	}
+
+	var matcherSets [][]*labels.Matcher
+	for _, s := range r.Form["match[]"] {
+		matchers, err := parser.ParseMetricSelector(s)
+		if err != nil {
+			h.promError(w, promErrorBadData, err.Error(), http.StatusBadRequest)
+			return
+		}
+		matcherSets = append(matcherSets, matchers)
+	}
+
+	q, ok := h.promQuerier(w, r, user)
+	if !ok {
+		return
+	}
+	defer q.Close()
+
+	var (
+		seen     = make(map[uint64]struct{})
+		metrics  = []labels.Labels{}
+		warnings promstorage.Warnings
+	)
+	for _, matchers := range matcherSets {
+		set, wrn, err := q.Select(false, nil, matchers...)
+		if err != nil {
+			h.promError(w, promErrorExec, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		warnings = append(warnings, wrn...)
+
+		for set.Next() {
+			lbls := set.At().Labels()
+			if _, ok := seen[lbls.Hash()]; ok {
+				continue
+			}
+			seen[lbls.Hash()] = struct{}{}
+			metrics = append(metrics, lbls)
+		}
+		if err := set.Err(); err != nil {
+			h.promError(w, promErrorExec, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+	}
+
+	sort.Slice(metrics, func(i, j int) bool {
+		return labels.Compare(metrics[i], metrics[j]) < 0
+	})
+	h.promRespond(w, metrics, warnings)
+}
+
+// servePromLabels returns the names of all labels.
+func (h *Handler) servePromLabels(w http.ResponseWriter, r *http.Request, user meta.User) {
+	q, ok := h.promQuerier(w, r, user)
+	if !ok {
+		return
+	}
+	defer q.Close()
+
+	names, warnings, err := q.LabelNames()
+	if err != nil {
+		h.promError(w, promErrorExec, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if names == nil {
+		names = []string{}
+	}
+	h.promRespond(w, names, warnings)
+}
+
+// servePromLabelValues returns the values of the label given in the path.
+func (h *Handler) servePromLabelValues(w http.ResponseWriter, r *http.Request, user meta.User) {
+	name := mux.Vars(r)["name"]
+	if !model.LabelNameRE.MatchString(name) {
+		h.promError(w, promErrorBadData, fmt.Sprintf("invalid label name: %q", name), http.StatusBadRequest)
+		return
+	}
+
+	q, ok := h.promQuerier(w, r, user)
+	if !ok {
+		return
+	}
+	defer q.Close()
+
+	values, warnings, err := q.LabelValues(name)
+	if err != nil {
+		h.promError(w, promErrorExec, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if values == nil {
+		values = []string{}
+	}
+	sort.Strings(values)
+	h.promRespond(w, values, warnings)
+}
+
+// promQuerier returns a Querier over the "start" and "end" parameters of
+// the request. Both are optional and default to the whole time range.
+func (h *Handler) promQuerier(w http.ResponseWriter, r *http.Request, user meta.User) (promstorage.Querier, bool) {
+	start, err := parsePromTimeParam(r, "start", promMinTime)
+	if err != nil {
+		h.promError(w, promErrorBadData, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	end, err := parsePromTimeParam(r, "end", promMaxTime)
+	if err != nil {
+		h.promError(w, promErrorBadData, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	queryable, ok := h.promQueryable(w, r, user)
+	if !ok {
+		return nil, false
+	}
+
+	q, err := queryable.Querier(r.Context(), timestampMillis(start), timestampMillis(end))
+	if err != nil {
+		h.promError(w, promErrorExec, err.Error(), http.StatusUnprocessableEntity)
+		return nil, false
+	}
+	return q, true
+}
+
+func (h *Handler) promRespond(w http.ResponseWriter, data interface{}, warnings promstorage.Warnings) {
+	resp := &promResponse{Status: "success", Data: data}
+	for _, wrn := range warnings {
+		resp.Warnings = append(resp.Warnings, wrn.Error())
+	}
+
+	b, err := json.Marshal(resp)
+	if err != nil {
+		h.logger.Error("error marshaling prometheus response", zap.Error(err))
+		h.promError(w, promErrorInternal, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(headerContentType, contentTypeJSON)
+	writeHeader(w, http.StatusOK)
+	n, _ := w.Write(b)
+	atomic.AddInt64(&h.stats.QueryRequestBytesTransmitted, int64(n))
+}
+
+func (h *Handler) promError(w http.ResponseWriter, typ, errmsg string, code int) {
+	h.writeHeader(w, code)
+	if code/100 != 2 {
+		sz := math.Min(float64(len(errmsg)), 1024.0)
+		w.Header().Set(headerErrorMsg, errmsg[:int(sz)])
+	}
+
+	b, _ := json.Marshal(&promResponse{
+		Status:    "error",
+		ErrorType: typ,
+		Error:     errmsg,
+	})
+	w.Header().Set(headerContentType, contentTypeJSON)
+	writeHeader(w, code)
+	_, _ = w.Write(b)
+}
+
+// promRequestContext returns the context of the request, bounded by the
+// optional "timeout" parameter.
+func promRequestContext(r *http.Request) (context.Context, context.CancelFunc, error) {
+	ctx := r.Context()
+	if to := r.FormValue("timeout"); to != "" {
+		timeout, err := parsePromDuration(to)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid parameter \"timeout\": %s", err)
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		return ctx, cancel, nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return ctx, cancel, nil
+}
+
+func parsePromTimeParam(r *http.Request, name string, def time.Time) (time.Time, error) {
+	v := r.FormValue(name)
+	if v == "" {
+		return def, nil
+	}
+	t, err := parsePromTime(v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid parameter %q: %s", name, err)
+	}
+	return t, nil
+}
+
+// parsePromTime parses a timestamp given either as a (fractional) unix
+// timestamp in seconds or in RFC 3339 format.
+func parsePromTime(s string) (time.Time, error) {
+	if t, err := strconv.ParseFloat(s, 64); err == nil {
+		s, ns := math.Modf(t)
+		ns = math.Round(ns*1000) / 1000
+		return time.Unix(int64(s), int64(ns*float64(time.Second))), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q to a valid timestamp", s)
+}
+
+// parsePromDuration parses a duration given either as a (fractional) number
+// of seconds or in the Prometheus duration format, such as "5m".
+func parsePromDuration(s string) (time.Duration, error) {
+	if d, err := strconv.ParseFloat(s, 64); err == nil {
+		ts := d * float64(time.Second)
+		if ts > float64(math.MaxInt64) || ts < float64(math.MinInt64) {
+			return 0, fmt.Errorf("cannot parse %q to a valid duration. It overflows int64", s)
+		}
+		return time.Duration(ts), nil
+	}
+	if d, err := model.ParseDuration(strings.TrimSpace(s)); err == nil {
+		return time.Duration(d), nil
+	}
+	return 0, fmt.Errorf("cannot parse %q to a valid duration", s)
+}
+
+func timestampMillis(t time.Time) int64 {
+	return t.Unix()*1000 + int64(t.Nanosecond())/int64(time.Millisecond)
+}
@@ -3,11 +3,14 @@ package server
 import (
 	"encoding/csv"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/cnosdb/cnosdb/pkg/arrow"
+	"github.com/cnosdb/cnosdb/pkg/columnar"
 	"github.com/cnosdb/cnosdb/vend/db/models"
 
 	"github.com/tinylib/msgp/msgp"
@@ -18,6 +21,10 @@ type ResponseWriter interface {
 	// WriteResponse writes a response.
 	WriteResponse(resp Response) (int, error)
 
+	// EndResponse ends the responses written, for the formats writing them
+	// as a single stream.
+	EndResponse() (int, error)
+
 	http.ResponseWriter
 }
 
@@ -33,6 +40,9 @@ func NewResponseWriter(w http.ResponseWriter, r *http.Request) ResponseWriter {
 	case "application/x-msgpack":
 		w.Header().Add("Content-Type", "application/x-msgpack")
 		rw.formatter = &msgpackFormatter{}
+	case arrow.ContentType:
+		w.Header().Add("Content-Type", arrow.ContentType)
+		rw.formatter = &arrowFormatter{}
 	case "application/json":
 		fallthrough
 	default:
@@ -68,6 +78,20 @@ func (w *responseWriter) WriteResponse(resp Response) (int, error) {
 	return writer.n, err
 }
 
+// EndResponse ends the responses written if the formatter writes them as a
+// single stream.
+func (w *responseWriter) EndResponse() (int, error) {
+	f, ok := w.formatter.(interface {
+		EndResponse(w io.Writer) error
+	})
+	if !ok {
+		return 0, nil
+	}
+	writer := bytesCountWriter{w: w.ResponseWriter}
+	err := f.EndResponse(&writer)
+	return writer.n, err
+}
+
 // Flush flushes the ResponseWriter if it has a Flush() method.
 func (w *responseWriter) Flush() {
 	if w, ok := w.ResponseWriter.(http.Flusher); ok {
@@ -314,6 +338,174 @@ func (f *msgpackFormatter) WriteResponse(w io.Writer, resp Response) (err error)
 	return nil
 }
 
+// arrowStatementID is the key of the metadata of Arrow streams holding the
+// statement the series of the stream are the result of.
+const arrowStatementID = "cnosdb.statement_id"
+
+// arrowFormatter writes the responses of a request as a single Arrow IPC
+// stream, which is ended by EndResponse. The columns of the stream are taken
+// from the series of the first response: the name of the series, the time, a
+// column per tag and per field, then an "error" column. Errors are written as
+// rows holding only the error. Queries of several statements are rejected
+// by the handler, so that the stream holds the result of one statement.
+//
+// The records are built from the rows of the results rather than from the
+// iterators of the statement, so that the format shares the limits, the
+// chunking and the conversions of the results with the other formats.
+type arrowFormatter struct {
+	out    arrowOutput
+	sw     *arrow.Writer
+	schema *columnar.Schema
+}
+
+// arrowOutput writes the stream to the writer of the current response.
+type arrowOutput struct {
+	w io.Writer
+}
+
+func (o *arrowOutput) Write(p []byte) (int, error) { return o.w.Write(p) }
+
+func (f *arrowFormatter) WriteResponse(w io.Writer, resp Response) error {
+	f.out.w = w
+	if f.sw == nil {
+		// The columns are taken from the first response with series or
+		// errors.
+		empty := resp.Err == nil
+		for _, result := range resp.Results {
+			if result.Err != nil || len(result.Series) > 0 {
+				empty = false
+			}
+		}
+		if empty {
+			return nil
+		}
+		f.start(resp)
+	}
+
+	// An error of the request ends the response.
+	if resp.Err != nil {
+		if err := f.write("", columnar.NewBatch(f.schema), resp.Err); err != nil {
+			return err
+		}
+		return f.sw.Close()
+	}
+
+	for _, result := range resp.Results {
+		if result.Err != nil {
+			if err := f.write("", columnar.NewBatch(f.schema), result.Err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, row := range result.Series {
+			rows := []*models.Row{row}
+			s := *f.schema
+			s.Measurement = row.Name
+			if !s.Accepts(rows) {
+				err := fmt.Errorf("columns of series %s differ from the columns of the stream", row.Name)
+				if err := f.write("", columnar.NewBatch(f.schema), err); err != nil {
+					return err
+				}
+				continue
+			}
+
+			b := columnar.NewBatch(f.schema)
+			if err := b.AppendRows(rows); err != nil {
+				return err
+			} else if err := f.write(row.Name, b, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// EndResponse ends the stream. A stream without columns is written if no
+// response was written.
+func (f *arrowFormatter) EndResponse(w io.Writer) error {
+	f.out.w = w
+	if f.sw == nil {
+		f.start(Response{})
+	}
+	return f.sw.Close()
+}
+
+// start starts the stream with the columns of the series of resp.
+func (f *arrowFormatter) start(resp Response) {
+	var rows []*models.Row
+	for _, result := range resp.Results {
+		rows = append(rows, result.Series...)
+	}
+	f.schema = columnar.RowsSchema(rows)
+	f.schema.Measurement = ""
+
+	as := columnar.ArrowSchema(f.schema)
+	used := map[string]bool{"name": true, "error": true}
+	fields := make([]arrow.Field, 0, len(as.Fields)+2)
+	fields = append(fields, arrow.Field{Name: "name", Type: arrow.Utf8, Nullable: true})
+	for _, af := range as.Fields {
+		name := af.Name
+		for i := 1; used[name]; i++ {
+			name = af.Name + "_" + strconv.Itoa(i)
+		}
+		used[name] = true
+		af.Name = name
+		fields = append(fields, af)
+	}
+	as.Fields = append(fields, arrow.Field{Name: "error", Type: arrow.Utf8, Nullable: true})
+
+	metadata := as.Metadata[:0]
+	for _, kv := range as.Metadata {
+		if kv.Key != columnar.MetaMeasurement {
+			metadata = append(metadata, kv)
+		}
+	}
+	as.Metadata = metadata
+	if len(resp.Results) > 0 {
+		as.Metadata = append(as.Metadata, arrow.KeyValue{Key: arrowStatementID, Value: strconv.Itoa(resp.Results[0].StatementID)})
+	}
+	f.sw = arrow.NewStreamWriter(&f.out, as)
+}
+
+// write writes the rows of b as records of the series name. A batch without
+// rows is written as a single row holding only err.
+func (f *arrowFormatter) write(name string, b *columnar.Batch, err error) error {
+	if b.Len() == 0 {
+		if err == nil {
+			return nil
+		}
+		if err := b.Append(0, make([]string, len(f.schema.Tags)), make([]interface{}, len(f.schema.Fields))); err != nil {
+			return err
+		}
+	}
+
+	for start := 0; start < b.Len(); start += columnar.RowGroupSize {
+		end := start + columnar.RowGroupSize
+		if end > b.Len() {
+			end = b.Len()
+		}
+		n := end - start
+
+		names := arrow.Column{Valid: make([]bool, n), Strings: make([]string, n)}
+		errs := arrow.Column{Valid: make([]bool, n), Strings: make([]string, n)}
+		for i := 0; i < n; i++ {
+			if name != "" {
+				names.Valid[i], names.Strings[i] = true, name
+			}
+			if err != nil {
+				errs.Valid[i], errs.Strings[i] = true, err.Error()
+			}
+		}
+
+		columns := append([]arrow.Column{names}, columnar.ArrowColumns(b, start, end)...)
+		if err := f.sw.Write(append(columns, errs)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func stringsEqual(a, b []string) bool {
 	if len(a) != len(b) {
 		return false
@@ -3,6 +3,7 @@ package server
 import (
 	"archive/tar"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -25,6 +26,7 @@ import (
 	"github.com/cnosdb/cnosdb/server/coordinator"
 	"github.com/cnosdb/cnosdb/server/hh"
 	"github.com/cnosdb/cnosdb/server/snapshotter"
+	"github.com/cnosdb/cnosdb/server/storage_service"
 	"github.com/cnosdb/cnosdb/server/subscriber"
 	"github.com/cnosdb/cnosdb/usage_client"
 	"github.com/cnosdb/cnosdb/vend/db/models"
@@ -71,6 +73,7 @@ type Server struct {
 
 	TSDBStore                *tsdb.Store
 	queryExecutor            *query.Executor
+	resultCache              *coordinator.ResultCache
 	PointsWriter             *coordinator.PointsWriter
 	shardWriter              *coordinator.ShardWriter
 	hintedHandoff            *hh.Service
@@ -79,6 +82,7 @@ type Server struct {
 
 	coordinatorService *coordinator.Service
 	snapshotterService *snapshotter.Service
+	storageService     *storage_service.Service
 
 	services []interface {
 		WithLogger(log *zap.Logger)
@@ -151,6 +155,9 @@ func (s *Server) Open() error {
 	if !s.reportingDisabled {
 		go s.startServerReporting()
 	}
+	if s.queryExecutor.PlanCache != nil {
+		go s.purgePlanCache()
+	}
 	go s.startHTTPServer()
 
 	return nil
@@ -185,6 +192,10 @@ func (s *Server) Close() {
 		_ = s.continuousQuerierService.Close()
 	}
 
+	if s.storageService != nil {
+		_ = s.storageService.Close()
+	}
+
 	close(s.closing)
 }
 
@@ -217,6 +228,11 @@ func (s *Server) initTSDBStore() error {
 
 	s.TSDBStore.EngineOptions.EngineVersion = s.Config.Data.Engine
 	s.TSDBStore.EngineOptions.IndexVersion = s.Config.Data.Index
+	s.TSDBStore.EngineOptions.MeasurementSchemas = &coordinator.MeasurementSchemas{MetaClient: s.MetaClient}
+	if size := s.Config.Coordinator.ResultCacheMaxMemorySize; size > 0 {
+		s.resultCache = coordinator.NewResultCache(int64(size))
+		s.TSDBStore.EngineOptions.ShardObserver = s.resultCache
+	}
 
 	s.shardWriter = coordinator.NewShardWriter(time.Duration(s.Config.Coordinator.ShardWriterTimeout),
 		s.Config.Coordinator.MaxRemoteWriteConnections)
@@ -229,6 +245,9 @@ func (s *Server) initTSDBStore() error {
 	s.PointsWriter = coordinator.NewPointsWriter()
 	s.PointsWriter.WithLogger(s.Logger)
 	s.PointsWriter.WriteTimeout = time.Duration(s.Config.Coordinator.WriteTimeout)
+	s.PointsWriter.WriteIDTTL = time.Duration(s.Config.Coordinator.WriteIDTTL)
+	s.PointsWriter.MaxWriteIDs = s.Config.Coordinator.MaxWriteIDs
+	s.PointsWriter.GeoIndex = s.Config.Coordinator.GeoIndex()
 	s.PointsWriter.MetaClient = s.MetaClient
 	s.PointsWriter.HintedHandoff = s.hintedHandoff
 	s.PointsWriter.TSDBStore = s.TSDBStore
@@ -250,16 +269,23 @@ func (s *Server) initTSDBStore() error {
 			TSDBStore: coordinator.LocalTSDBStore{
 				Store: s.TSDBStore,
 			},
+			ResultCache: s.resultCache,
 		},
 		Monitor:           s.monitor,
 		PointsWriter:      s.PointsWriter,
 		MaxSelectPointN:   s.Config.Coordinator.MaxSelectPointN,
 		MaxSelectSeriesN:  s.Config.Coordinator.MaxSelectSeriesN,
 		MaxSelectBucketsN: s.Config.Coordinator.MaxSelectBucketsN,
+		GeoIndex:          s.Config.Coordinator.GeoIndex(),
 	}
 	s.queryExecutor.TaskManager.QueryTimeout = time.Duration(s.Config.Coordinator.QueryTimeout)
 	s.queryExecutor.TaskManager.LogQueriesAfter = time.Duration(s.Config.Coordinator.LogQueriesAfter)
 	s.queryExecutor.TaskManager.MaxConcurrentQueries = s.Config.Coordinator.MaxConcurrentQueries
+	s.queryExecutor.MaxSelectMemory = int64(s.Config.Coordinator.MaxSelectMemory)
+	s.queryExecutor.SpillDir = s.Config.Coordinator.QuerySpillDir
+	if s.Config.Coordinator.PlanCacheSize > 0 {
+		s.queryExecutor.PlanCache = query.NewPlanCache(s.Config.Coordinator.PlanCacheSize)
+	}
 
 	s.coordinatorService = coordinator.NewService(s.Config.Coordinator)
 	s.coordinatorService.WithLogger(s.Logger)
@@ -361,6 +387,25 @@ func (s *Server) openServices() error {
 		return fmt.Errorf("open continuous query service: %s", err)
 	}
 
+	if s.Config.StorageService.Enabled {
+		s.storageService = storage_service.NewService(s.Config.StorageService)
+		s.storageService.WithLogger(s.Logger)
+		s.storageService.Store = storage.NewStore(s.TSDBStore, s.MetaClient)
+		s.storageService.AuthEnabled = s.Config.HTTPD.AuthEnabled
+		s.storageService.MetaClient = s.MetaClient
+		s.storageService.Authorizer = meta.NewQueryAuthorizer(s.MetaClient)
+		if s.Config.HTTPD.HTTPSEnabled {
+			tlsConfig, err := httpsConfig(s.Config.HTTPD)
+			if err != nil {
+				return fmt.Errorf("open storage service: %s", err)
+			}
+			s.storageService.TLS = tlsConfig
+		}
+		if err := s.storageService.Open(); err != nil {
+			return fmt.Errorf("open storage service: %s", err)
+		}
+	}
+
 	return nil
 }
 
@@ -693,6 +738,43 @@ func (s *Server) URL() string {
 	return "http://" + s.httpListener.Addr().String()
 }
 
+// httpsConfig returns the TLS configuration of the HTTPS certificate of c.
+// The private key is read from the certificate file if it is not set.
+func httpsConfig(c HTTPConfig) (*tls.Config, error) {
+	key := c.HTTPSPrivateKey
+	if key == "" {
+		key = c.HTTPSCertificate
+	}
+	cert, err := tls.LoadX509KeyPair(c.HTTPSCertificate, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var tlsConfig *tls.Config
+	if c.TLS != nil {
+		if tlsConfig, err = c.TLS.Parse(); err != nil {
+			return nil, err
+		}
+	}
+	if tlsConfig == nil {
+		tlsConfig = new(tls.Config)
+	}
+	tlsConfig.Certificates = []tls.Certificate{cert}
+	return tlsConfig, nil
+}
+
+// StorageServiceAddr returns the address of the gRPC Storage service, or an
+// empty string when the service is disabled.
+func (s *Server) StorageServiceAddr() string {
+	if s.storageService == nil {
+		return ""
+	}
+	if addr := s.storageService.Addr(); addr != nil {
+		return addr.String()
+	}
+	return ""
+}
+
 // HTTPAddr returns the HTTP address used by other nodes for HTTP queries and writes.
 //todo: Get dynamic address
 func (s *Server) HTTPAddr() string {
@@ -717,6 +799,9 @@ func (s *Server) Statistics(tags map[string]string) []models.Statistic {
 	var statistics []models.Statistic
 	statistics = append(statistics, s.queryExecutor.Statistics(tags)...)
 	statistics = append(statistics, s.TSDBStore.Statistics(tags)...)
+	if s.resultCache != nil {
+		statistics = append(statistics, s.resultCache.Statistics(tags)...)
+	}
 	statistics = append(statistics, s.PointsWriter.Statistics(tags)...)
 	for _, srv := range s.services {
 		if m, ok := srv.(monitor.Reporter); ok {
@@ -754,6 +839,19 @@ func (s *Server) startServerReporting() {
 	}
 }
 
+// purgePlanCache empties the cache of compiled statements whenever the meta
+// data changes, so statements are compiled again against the new schema.
+func (s *Server) purgePlanCache() {
+	for {
+		select {
+		case <-s.MetaClient.WaitForDataChanged():
+			s.queryExecutor.PlanCache.Purge()
+		case <-s.closing:
+			return
+		}
+	}
+}
+
 func (s *Server) reportServer() {
 	dbs := s.MetaClient.Databases()
 	numDatabases := len(dbs)
@@ -0,0 +1,120 @@
+package storage_service
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+
+	"github.com/cnosdb/cnosdb/meta"
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+	"github.com/cnosdb/cnosdb/vend/db/query"
+	"github.com/cnosdb/cnosdb/vend/storage"
+
+	"github.com/gogo/protobuf/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// MetaClient authenticates the users of the service.
+type MetaClient interface {
+	AdminUserExists() bool
+	Authenticate(username, password string) (meta.User, error)
+}
+
+// Authorizer authorizes the users of the service to read databases.
+type Authorizer interface {
+	AuthorizeDatabase(u meta.User, priv cnosql.Privilege, database string) error
+}
+
+type userKey struct{}
+
+// authenticate returns the user of the Basic credentials of the
+// "authorization" metadata of a request.
+func (s *Service) authenticate(ctx context.Context) (meta.User, error) {
+	if !s.MetaClient.AdminUserExists() {
+		return nil, status.Error(codes.Unauthenticated, "create admin user first or disable authentication")
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "unable to parse authentication credentials")
+	}
+
+	const prefix = "Basic "
+	if !strings.HasPrefix(values[0], prefix) {
+		return nil, status.Error(codes.Unauthenticated, "unsupported authentication")
+	}
+	b, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(values[0], prefix))
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "unable to parse authentication credentials")
+	}
+	username, password, ok := strings.Cut(string(b), ":")
+	if !ok || username == "" {
+		return nil, status.Error(codes.Unauthenticated, "username required")
+	}
+
+	user, err := s.MetaClient.Authenticate(username, password)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "authorization failed")
+	}
+	return user, nil
+}
+
+// unaryInterceptor authenticates the user of unary requests.
+func (s *Service) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	user, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(context.WithValue(ctx, userKey{}, user), req)
+}
+
+// streamInterceptor authenticates the user of streaming requests.
+func (s *Service) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	user, err := s.authenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{
+		ServerStream: ss,
+		ctx:          context.WithValue(ss.Context(), userKey{}, user),
+	})
+}
+
+// authenticatedStream is a stream whose context carries its user.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context { return s.ctx }
+
+// authorizeRead returns a context carrying the authorizer of the series a
+// request reads from the database of its source. Every series may be read
+// when authentication is disabled.
+func (r *rpcService) authorizeRead(ctx context.Context, source *types.Any) (context.Context, error) {
+	if r.Authorizer == nil {
+		return ctx, nil
+	} else if source == nil {
+		return nil, status.Error(codes.InvalidArgument, "missing read source")
+	}
+
+	src, err := storage.GetReadSource(*source)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	user, _ := ctx.Value(userKey{}).(meta.User)
+	if err := r.Authorizer.AuthorizeDatabase(user, cnosql.ReadPrivilege, src.Database); err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	var auth query.FineAuthorizer = user
+	if user.AuthorizeUnrestricted() {
+		auth = query.OpenAuthorizer
+	}
+	return storage.NewContextWithAuthorizer(ctx, auth), nil
+}
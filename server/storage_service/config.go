@@ -0,0 +1,56 @@
+package storage_service
+
+import (
+	"errors"
+
+	"github.com/cnosdb/cnosdb/vend/common/monitor/diagnostics"
+)
+
+const (
+	// DefaultBindAddress is the default address the gRPC storage service binds to.
+	DefaultBindAddress = ":8082"
+)
+
+// Config represents the configuration for the gRPC storage service.
+type Config struct {
+	// Enabled determines whether the storage reads of this node are exposed over gRPC.
+	Enabled bool `toml:"enabled"`
+
+	// BindAddress is the address the gRPC server listens on.
+	BindAddress string `toml:"bind-address"`
+}
+
+// NewConfig returns a new Config with defaults.
+func NewConfig() Config {
+	return Config{
+		Enabled:     false,
+		BindAddress: DefaultBindAddress,
+	}
+}
+
+// Validate returns an error if the Config is invalid.
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.BindAddress == "" {
+		return errors.New("bind-address must be specified")
+	}
+
+	return nil
+}
+
+// Diagnostics returns a diagnostics representation of a subset of the Config.
+func (c Config) Diagnostics() (*diagnostics.Diagnostics, error) {
+	if !c.Enabled {
+		return diagnostics.RowFromMap(map[string]interface{}{
+			"enabled": false,
+		}), nil
+	}
+
+	return diagnostics.RowFromMap(map[string]interface{}{
+		"enabled":      true,
+		"bind-address": c.BindAddress,
+	}), nil
+}
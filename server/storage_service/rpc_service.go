@@ -0,0 +1,172 @@
+package storage_service
+
+import (
+	"context"
+
+	"github.com/cnosdb/cnosdb/storage/reads"
+	"github.com/cnosdb/cnosdb/storage/reads/datatypes"
+	"github.com/cnosdb/cnosdb/vend/db/tsdb/cursors"
+
+	"github.com/gogo/protobuf/types"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxStringValuesBatch is the maximum number of values sent in a single
+// StringValuesResponse.
+const maxStringValuesBatch = 1000
+
+// rpcService implements datatypes.StorageServer on top of a reads.Store.
+type rpcService struct {
+	Store reads.Store
+
+	// Authorizer authorizes the users of requests. Requests are not
+	// authorized if it is nil.
+	Authorizer Authorizer
+
+	Logger *zap.Logger
+}
+
+func (r *rpcService) Capabilities(context.Context, *types.Empty) (*datatypes.CapabilitiesResponse, error) {
+	return &datatypes.CapabilitiesResponse{
+		Caps: map[string]string{
+			"ReadFilter":       "1.0",
+			"ReadGroup":        "1.0",
+			"TagKeys":          "1.0",
+			"TagValues":        "1.0",
+			"MeasurementNames": "1.0",
+		},
+	}, nil
+}
+
+func (r *rpcService) ReadFilter(req *datatypes.ReadFilterRequest, stream datatypes.Storage_ReadFilterServer) error {
+	ctx, err := r.authorizeRead(stream.Context(), req.ReadSource)
+	if err != nil {
+		return err
+	}
+	rs, err := r.Store.ReadFilter(ctx, req)
+	if err != nil {
+		return r.error("ReadFilter", err)
+	}
+	if rs == nil {
+		return nil
+	}
+	defer rs.Close()
+
+	w := reads.NewResponseWriter(stream, 0)
+	if err := w.WriteResultSet(rs); err != nil {
+		return r.error("ReadFilter", err)
+	}
+	w.Flush()
+	if err := w.Err(); err != nil {
+		return r.error("ReadFilter", err)
+	}
+	return r.error("ReadFilter", rs.Err())
+}
+
+func (r *rpcService) ReadGroup(req *datatypes.ReadGroupRequest, stream datatypes.Storage_ReadGroupServer) error {
+	ctx, err := r.authorizeRead(stream.Context(), req.ReadSource)
+	if err != nil {
+		return err
+	}
+	rs, err := r.Store.ReadGroup(ctx, req)
+	if err != nil {
+		return r.error("ReadGroup", err)
+	}
+	if rs == nil {
+		return nil
+	}
+	defer rs.Close()
+
+	w := reads.NewResponseWriter(stream, req.Hints)
+	if err := w.WriteGroupResultSet(rs); err != nil {
+		return r.error("ReadGroup", err)
+	}
+	w.Flush()
+	if err := w.Err(); err != nil {
+		return r.error("ReadGroup", err)
+	}
+	return r.error("ReadGroup", rs.Err())
+}
+
+func (r *rpcService) TagKeys(req *datatypes.TagKeysRequest, stream datatypes.Storage_TagKeysServer) error {
+	ctx, err := r.authorizeRead(stream.Context(), req.TagsSource)
+	if err != nil {
+		return err
+	}
+	itr, err := r.Store.TagKeys(ctx, req)
+	if err != nil {
+		return r.error("TagKeys", err)
+	}
+	return r.error("TagKeys", sendStringValues(stream, itr))
+}
+
+func (r *rpcService) TagValues(req *datatypes.TagValuesRequest, stream datatypes.Storage_TagValuesServer) error {
+	ctx, err := r.authorizeRead(stream.Context(), req.TagsSource)
+	if err != nil {
+		return err
+	}
+	itr, err := r.Store.TagValues(ctx, req)
+	if err != nil {
+		return r.error("TagValues", err)
+	}
+	return r.error("TagValues", sendStringValues(stream, itr))
+}
+
+func (r *rpcService) MeasurementNames(req *datatypes.MeasurementNamesRequest, stream datatypes.Storage_MeasurementNamesServer) error {
+	ctx, err := r.authorizeRead(stream.Context(), req.Source)
+	if err != nil {
+		return err
+	}
+	itr, err := r.Store.MeasurementNames(ctx, req)
+	if err != nil {
+		return r.error("MeasurementNames", err)
+	}
+	return r.error("MeasurementNames", sendStringValues(stream, itr))
+}
+
+// error logs err and converts it into a gRPC status error.
+func (r *rpcService) error(method string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	r.Logger.Info("Storage request failed", zap.String("method", method), zap.Error(err))
+	if err == context.Canceled {
+		return status.Error(codes.Canceled, err.Error())
+	}
+	return status.Error(codes.Unknown, err.Error())
+}
+
+// stringValuesStream is the stream of the RPCs that respond with strings.
+type stringValuesStream interface {
+	Send(*datatypes.StringValuesResponse) error
+}
+
+// sendStringValues sends the values of itr to stream in batches of
+// maxStringValuesBatch values.
+func sendStringValues(stream stringValuesStream, itr cursors.StringIterator) error {
+	if itr == nil {
+		return nil
+	}
+
+	res := &datatypes.StringValuesResponse{}
+	for itr.Next() {
+		res.Values = append(res.Values, []byte(itr.Value()))
+		if len(res.Values) >= maxStringValuesBatch {
+			if err := stream.Send(res); err != nil {
+				return err
+			}
+			res.Values = res.Values[:0]
+		}
+	}
+
+	if len(res.Values) > 0 {
+		return stream.Send(res)
+	}
+	return nil
+}
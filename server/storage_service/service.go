@@ -0,0 +1,129 @@
+// Package storage_service exposes the storage reads of a data node as the
+// gRPC Storage service, for use by external compute engines.
+package storage_service
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/cnosdb/cnosdb/storage/reads"
+	"github.com/cnosdb/cnosdb/storage/reads/datatypes"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// Service serves the gRPC Storage service.
+type Service struct {
+	addr string
+
+	Store reads.Store
+
+	// AuthEnabled requires the users of the service to authenticate with
+	// MetaClient and to be authorized by Authorizer to read a database.
+	AuthEnabled bool
+	MetaClient  MetaClient
+	Authorizer  Authorizer
+
+	// TLS is the configuration of the connections of the service. The
+	// connections are not encrypted if it is nil.
+	TLS *tls.Config
+
+	Logger *zap.Logger
+
+	mu     sync.Mutex
+	ln     net.Listener
+	server *grpc.Server
+	wg     sync.WaitGroup
+}
+
+// NewService returns a new instance of Service.
+func NewService(c Config) *Service {
+	return &Service{
+		addr:   c.BindAddress,
+		Logger: zap.NewNop(),
+	}
+}
+
+// WithLogger sets the logger for the service.
+func (s *Service) WithLogger(log *zap.Logger) {
+	s.Logger = log.With(zap.String("service", "storage"))
+}
+
+// Open starts the gRPC server.
+func (s *Service) Open() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.server != nil {
+		return nil
+	}
+
+	// Credentials must not be sent over unencrypted connections.
+	if s.AuthEnabled && s.TLS == nil {
+		return errors.New("storage service requires https-enabled when auth-enabled is set")
+	}
+
+	ln, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	s.ln = ln
+
+	var opts []grpc.ServerOption
+	if s.TLS != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(s.TLS)))
+	}
+	rpc := &rpcService{Store: s.Store, Logger: s.Logger}
+	if s.AuthEnabled {
+		opts = append(opts,
+			grpc.UnaryInterceptor(s.unaryInterceptor),
+			grpc.StreamInterceptor(s.streamInterceptor),
+		)
+		rpc.Authorizer = s.Authorizer
+	}
+
+	s.server = grpc.NewServer(opts...)
+	datatypes.RegisterStorageServer(s.server, rpc)
+
+	s.Logger.Info("Starting storage service", zap.Stringer("addr", ln.Addr()))
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.server.Serve(ln); err != nil {
+			s.Logger.Info("Storage service stopped", zap.Error(err))
+		}
+	}()
+	return nil
+}
+
+// Close stops the gRPC server, waiting for pending requests to complete.
+func (s *Service) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.server == nil {
+		return nil
+	}
+
+	s.server.GracefulStop()
+	s.wg.Wait()
+	s.server, s.ln = nil, nil
+	return nil
+}
+
+// Addr returns the address the service is listening on, or nil when the
+// service is not open.
+func (s *Service) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ln == nil {
+		return nil
+	}
+	return s.ln.Addr()
+}
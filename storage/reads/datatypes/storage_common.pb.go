@@ -955,7 +955,7 @@ func (m *TagValuesRequest) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_TagValuesRequest proto.InternalMessageInfo
 
-// Response message for Storage.TagKeys and Storage.TagValues.
+// Response message for Storage.TagKeys, Storage.TagValues and Storage.MeasurementNames.
 type StringValuesResponse struct {
 	Values [][]byte `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
 }
@@ -993,6 +993,46 @@ func (m *StringValuesResponse) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_StringValuesResponse proto.InternalMessageInfo
 
+// MeasurementNamesRequest is the request message for Storage.MeasurementNames.
+type MeasurementNamesRequest struct {
+	Source    *types.Any     `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	Range     TimestampRange `protobuf:"bytes,2,opt,name=range,proto3" json:"range"`
+	Predicate *Predicate     `protobuf:"bytes,3,opt,name=predicate,proto3" json:"predicate,omitempty"`
+}
+
+func (m *MeasurementNamesRequest) Reset()         { *m = MeasurementNamesRequest{} }
+func (m *MeasurementNamesRequest) String() string { return proto.CompactTextString(m) }
+func (*MeasurementNamesRequest) ProtoMessage()    {}
+func (*MeasurementNamesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_715e4bf4cdf1f73d, []int{10}
+}
+func (m *MeasurementNamesRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *MeasurementNamesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_MeasurementNamesRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *MeasurementNamesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MeasurementNamesRequest.Merge(m, src)
+}
+func (m *MeasurementNamesRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *MeasurementNamesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_MeasurementNamesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MeasurementNamesRequest proto.InternalMessageInfo
+
 func init() {
 	proto.RegisterEnum("cnosdb.platform.storage.ReadGroupRequest_Group", ReadGroupRequest_Group_name, ReadGroupRequest_Group_value)
 	proto.RegisterEnum("cnosdb.platform.storage.ReadGroupRequest_HintFlags", ReadGroupRequest_HintFlags_name, ReadGroupRequest_HintFlags_value)
@@ -1018,106 +1058,110 @@ func init() {
 	proto.RegisterType((*TagKeysRequest)(nil), "cnosdb.platform.storage.TagKeysRequest")
 	proto.RegisterType((*TagValuesRequest)(nil), "cnosdb.platform.storage.TagValuesRequest")
 	proto.RegisterType((*StringValuesResponse)(nil), "cnosdb.platform.storage.StringValuesResponse")
+	proto.RegisterType((*MeasurementNamesRequest)(nil), "cnosdb.platform.storage.MeasurementNamesRequest")
 }
 
 func init() { proto.RegisterFile("storage_common.proto", fileDescriptor_715e4bf4cdf1f73d) }
 
 var fileDescriptor_715e4bf4cdf1f73d = []byte{
-	// 1490 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xdc, 0x57, 0x3b, 0x6f, 0x1b, 0xc7,
-	0x16, 0xe6, 0x8a, 0x2f, 0xed, 0xe1, 0x43, 0xab, 0x31, 0xaf, 0x2c, 0xaf, 0x6d, 0x72, 0x2f, 0x71,
-	0xef, 0xb5, 0x6e, 0x62, 0x53, 0x8a, 0x02, 0xd8, 0x4e, 0xaa, 0x88, 0x32, 0x25, 0x32, 0x92, 0x28,
-	0x61, 0x49, 0x19, 0x48, 0x1a, 0x62, 0x44, 0x8e, 0xd6, 0x84, 0xc9, 0xdd, 0xcd, 0xee, 0xd2, 0x30,
-	0xff, 0x41, 0xc0, 0x2a, 0x48, 0x1d, 0x56, 0x29, 0xd3, 0xa7, 0xca, 0x0f, 0x30, 0x90, 0xc6, 0x65,
-	0x2a, 0x22, 0xa6, 0xab, 0x00, 0xa9, 0xd2, 0x25, 0x55, 0x30, 0x33, 0x3b, 0xe4, 0x52, 0x2f, 0x53,
-	0x5d, 0xe0, 0x6e, 0xe6, 0x3c, 0xbe, 0x33, 0x67, 0xf6, 0x3b, 0xe7, 0xec, 0x40, 0xc6, 0xf5, 0x2c,
-	0x07, 0x1b, 0xa4, 0xd1, 0xb4, 0xba, 0x5d, 0xcb, 0x2c, 0xd8, 0x8e, 0xe5, 0x59, 0xe8, 0x66, 0xd3,
-	0xb4, 0xdc, 0xd6, 0x49, 0xc1, 0xee, 0x60, 0xef, 0xd4, 0x72, 0xba, 0x05, 0xdf, 0x4a, 0xcd, 0x18,
-	0x96, 0x61, 0x31, 0x9b, 0x75, 0xba, 0xe2, 0xe6, 0xea, 0x6d, 0xc3, 0xb2, 0x8c, 0x0e, 0x59, 0x67,
-	0xbb, 0x93, 0xde, 0xe9, 0x3a, 0xe9, 0xda, 0x5e, 0xdf, 0x57, 0xde, 0x3a, 0xab, 0xc4, 0xa6, 0x50,
-	0x2d, 0xd9, 0x0e, 0x69, 0xb5, 0x9b, 0xd8, 0x23, 0x5c, 0x90, 0x7f, 0x23, 0xc1, 0xb2, 0x4e, 0x70,
-	0x6b, 0xa7, 0xdd, 0xf1, 0x88, 0xa3, 0x93, 0xaf, 0x7a, 0xc4, 0xf5, 0x50, 0x09, 0x12, 0x0e, 0xc1,
-	0xad, 0x86, 0x6b, 0xf5, 0x9c, 0x26, 0x59, 0x95, 0x34, 0x69, 0x2d, 0xb1, 0x99, 0x29, 0x70, 0xdc,
-	0x82, 0xc0, 0x2d, 0x6c, 0x99, 0xfd, 0x62, 0x7a, 0x3c, 0xca, 0x01, 0x45, 0xa8, 0x31, 0x5b, 0x1d,
-	0x9c, 0xc9, 0x1a, 0x6d, 0x43, 0xd4, 0xc1, 0xa6, 0x41, 0x56, 0x17, 0x18, 0xc0, 0xbd, 0xc2, 0x25,
-	0x49, 0x16, 0xea, 0xed, 0x2e, 0x71, 0x3d, 0xdc, 0xb5, 0x75, 0x6a, 0x5e, 0x8c, 0xbc, 0x1a, 0xe5,
-	0x42, 0x3a, 0xf7, 0x45, 0x9f, 0x81, 0x3c, 0x39, 0xf4, 0x6a, 0x98, 0x01, 0xe5, 0x2f, 0x05, 0x3a,
-	0x12, 0x96, 0xfa, 0xd4, 0x29, 0xff, 0x53, 0x14, 0x14, 0x7a, 0xc2, 0x5d, 0xc7, 0xea, 0xd9, 0xef,
-	0x65, 0x8a, 0xe8, 0x3e, 0x80, 0x41, 0xb3, 0x6b, 0x3c, 0x27, 0x7d, 0x77, 0x35, 0xa2, 0x85, 0xd7,
-	0xe4, 0x62, 0x6a, 0x3c, 0xca, 0xc9, 0x2c, 0xe7, 0x3d, 0xd2, 0x77, 0x75, 0xd9, 0x10, 0x4b, 0x54,
-	0x82, 0x28, 0xdb, 0xac, 0x46, 0x35, 0x69, 0x2d, 0xbd, 0xb9, 0x7e, 0x69, 0xac, 0xb3, 0xb7, 0x56,
-	0xe0, 0x1b, 0xee, 0x4d, 0x8f, 0x8d, 0x0d, 0xc3, 0x21, 0x06, 0x3d, 0x76, 0xec, 0x1d, 0xc7, 0xde,
-	0x12, 0x96, 0xfa, 0xd4, 0x09, 0xdd, 0x87, 0xe8, 0xb3, 0xb6, 0xe9, 0xb9, 0xab, 0x71, 0x4d, 0x5a,
-	0x8b, 0x17, 0x57, 0xc6, 0xa3, 0x5c, 0xb4, 0x4c, 0x05, 0x7f, 0x8d, 0x72, 0x32, 0x5d, 0xec, 0x74,
-	0xb0, 0xe1, 0xea, 0xdc, 0x28, 0xbf, 0x0b, 0x51, 0x16, 0x1f, 0xdd, 0x05, 0xd8, 0xd5, 0x0f, 0x8f,
-	0x8f, 0x1a, 0xd5, 0xc3, 0x6a, 0x49, 0x09, 0xa9, 0xa9, 0xc1, 0x50, 0xe3, 0x99, 0x56, 0x2d, 0x93,
-	0xa0, 0x5b, 0xb0, 0xc8, 0xd5, 0xc5, 0x2f, 0x94, 0x05, 0x35, 0x31, 0x18, 0x6a, 0x71, 0xa6, 0x2c,
-	0xf6, 0xd5, 0xc8, 0xd7, 0xdf, 0x67, 0x43, 0xf9, 0x1f, 0x24, 0x98, 0xa2, 0xa3, 0xdb, 0x20, 0x97,
-	0x2b, 0xd5, 0xba, 0x00, 0x4b, 0x0e, 0x86, 0xda, 0x22, 0xd5, 0x32, 0xac, 0xff, 0x40, 0xda, 0x57,
-	0x36, 0x8e, 0x0e, 0x2b, 0xd5, 0x7a, 0x4d, 0x91, 0x54, 0x65, 0x30, 0xd4, 0x92, 0xdc, 0xe2, 0xc8,
-	0xa2, 0x27, 0x0b, 0x5a, 0xd5, 0x4a, 0x7a, 0xa5, 0x54, 0x53, 0x16, 0x82, 0x56, 0x35, 0xe2, 0xb4,
-	0x89, 0x8b, 0xd6, 0x21, 0xc3, 0xac, 0x6a, 0xdb, 0xe5, 0xd2, 0xc1, 0x56, 0x63, 0x6b, 0x7f, 0xbf,
-	0x51, 0xaf, 0x1c, 0x94, 0x94, 0x88, 0xfa, 0xaf, 0xc1, 0x50, 0x5b, 0xa6, 0xb6, 0xb5, 0xe6, 0x33,
-	0xd2, 0xc5, 0x5b, 0x9d, 0x0e, 0xa5, 0x8b, 0x7f, 0xda, 0x9f, 0x25, 0x90, 0x27, 0xb7, 0x87, 0x9e,
-	0x40, 0xc4, 0xeb, 0xdb, 0x9c, 0xb0, 0xe9, 0xcd, 0x8d, 0x77, 0xdf, 0xf7, 0x74, 0x55, 0xef, 0xdb,
-	0x44, 0x67, 0xde, 0xf9, 0x97, 0x90, 0x9a, 0x11, 0xa3, 0x1c, 0x44, 0xfc, 0xfc, 0xd9, 0x59, 0x66,
-	0x94, 0xec, 0x22, 0xee, 0x42, 0xb8, 0x76, 0x7c, 0xa0, 0x48, 0x6a, 0x66, 0x30, 0xd4, 0x94, 0x19,
-	0x7d, 0xad, 0xd7, 0x45, 0xff, 0x86, 0xe8, 0xf6, 0xe1, 0x71, 0xb5, 0xae, 0x2c, 0xa8, 0x2b, 0x83,
-	0xa1, 0x86, 0x66, 0x0c, 0xb6, 0xad, 0x9e, 0xe9, 0xf9, 0xd9, 0x3c, 0x80, 0x70, 0x1d, 0x1b, 0x48,
-	0x81, 0xf0, 0x73, 0xd2, 0x67, 0x59, 0x24, 0x75, 0xba, 0x44, 0x19, 0x88, 0xbe, 0xc0, 0x9d, 0x1e,
-	0xaf, 0xa4, 0xa4, 0xce, 0x37, 0xf9, 0xdf, 0x52, 0x90, 0xa4, 0x2c, 0xd4, 0x89, 0x6b, 0x5b, 0xa6,
-	0x4b, 0x50, 0x05, 0x62, 0xa7, 0x0e, 0xee, 0x12, 0x77, 0x55, 0xd2, 0xc2, 0x6b, 0x89, 0xcd, 0x0f,
-	0xaf, 0x24, 0xaf, 0x70, 0x2b, 0xec, 0x50, 0x1f, 0xbf, 0xea, 0x7c, 0x00, 0xf5, 0xf7, 0x28, 0x44,
-	0x99, 0x1c, 0x95, 0x45, 0x41, 0xc4, 0x19, 0x8b, 0x37, 0xe6, 0xc3, 0x64, 0xa4, 0x62, 0x00, 0xe5,
-	0x90, 0xa8, 0x89, 0x3d, 0x88, 0xb9, 0xec, 0x6b, 0xfb, 0x1d, 0xe5, 0xa3, 0xf9, 0xa0, 0x38, 0x43,
-	0x04, 0x96, 0x0f, 0x81, 0x3a, 0x90, 0x3c, 0xed, 0x58, 0xd8, 0x6b, 0xd8, 0x8c, 0x66, 0x7e, 0x8f,
-	0x79, 0x38, 0x67, 0xc6, 0xd4, 0x93, 0xf3, 0x93, 0x27, 0xbf, 0x34, 0x1e, 0xe5, 0x12, 0x01, 0x69,
-	0x39, 0xa4, 0x27, 0x4e, 0xa7, 0x5b, 0xe4, 0x41, 0xba, 0x6d, 0x7a, 0xc4, 0x20, 0x8e, 0x88, 0xc7,
-	0x5b, 0xd1, 0xe3, 0xf9, 0xe2, 0x55, 0xb8, 0x6f, 0x30, 0xe2, 0xf2, 0x78, 0x94, 0x4b, 0xcd, 0xc8,
-	0xcb, 0x21, 0x3d, 0xd5, 0x0e, 0x0a, 0xd0, 0x4b, 0x58, 0xea, 0x99, 0x6e, 0xdb, 0x30, 0x49, 0x4b,
-	0x84, 0x8d, 0xb0, 0xb0, 0x9f, 0xcc, 0x17, 0xf6, 0xd8, 0x77, 0x0e, 0xc6, 0x45, 0xe3, 0x51, 0x2e,
-	0x3d, 0xab, 0x28, 0x87, 0xf4, 0x74, 0x6f, 0x46, 0x42, 0xf3, 0x3d, 0xb1, 0xac, 0x0e, 0xc1, 0xa6,
-	0x08, 0x1c, 0xbd, 0x4e, 0xbe, 0x45, 0xee, 0x7b, 0x2e, 0xdf, 0x19, 0x39, 0xcd, 0xf7, 0x24, 0x28,
-	0x40, 0x36, 0xa4, 0x5c, 0xcf, 0x69, 0x9b, 0x86, 0x08, 0xca, 0x1b, 0xe7, 0xa3, 0x39, 0x79, 0xc2,
-	0x5c, 0x83, 0x31, 0x95, 0xf1, 0x28, 0x97, 0x0c, 0x8a, 0xcb, 0x21, 0x3d, 0xe9, 0x06, 0xf6, 0xc5,
-	0x18, 0x44, 0x5a, 0xd8, 0xc3, 0xea, 0x4b, 0x80, 0x29, 0x63, 0xd1, 0xff, 0x60, 0xd1, 0xc3, 0x06,
-	0x9f, 0x17, 0xb4, 0x92, 0x92, 0xc5, 0xc4, 0x78, 0x94, 0x8b, 0xd7, 0xb1, 0xc1, 0xa6, 0x45, 0xdc,
-	0xe3, 0x0b, 0x54, 0x04, 0x64, 0x63, 0xc7, 0x6b, 0x7b, 0x6d, 0xcb, 0xa4, 0xd6, 0x8d, 0x17, 0xb8,
-	0x43, 0x99, 0x48, 0x3d, 0x32, 0xe3, 0x51, 0x4e, 0x39, 0x12, 0xda, 0x3d, 0xd2, 0x7f, 0x8a, 0x3b,
-	0xae, 0xae, 0xd8, 0x67, 0x24, 0xea, 0xb7, 0x12, 0x24, 0x02, 0x0c, 0x47, 0x0f, 0x21, 0xe2, 0x61,
-	0x43, 0x54, 0xf0, 0x9d, 0xcb, 0x67, 0x26, 0x36, 0xfc, 0x92, 0x65, 0xf6, 0x68, 0x0f, 0x64, 0x9a,
-	0x49, 0x83, 0x35, 0xc0, 0x05, 0xd6, 0x00, 0x0b, 0xf3, 0xdd, 0xdb, 0x13, 0xec, 0x61, 0xd6, 0xfe,
-	0x16, 0x5b, 0xfe, 0x4a, 0xfd, 0x1c, 0x94, 0xb3, 0x25, 0x82, 0xb2, 0x00, 0x9e, 0x98, 0xd3, 0xfc,
-	0x78, 0x8a, 0x1e, 0x90, 0xa0, 0x15, 0x88, 0xb1, 0xb6, 0xc4, 0x2f, 0x40, 0xd2, 0xfd, 0x9d, 0xba,
-	0x0f, 0xe8, 0x3c, 0xfd, 0xaf, 0x89, 0x16, 0x9e, 0xa0, 0x1d, 0xc0, 0x8d, 0x0b, 0x58, 0x7d, 0x4d,
-	0xb8, 0x48, 0xf0, 0x70, 0xe7, 0xb9, 0x7a, 0x4d, 0xb4, 0xc5, 0x09, 0xda, 0x1e, 0x2c, 0x9f, 0x23,
-	0xe1, 0x35, 0xc1, 0x64, 0x01, 0x96, 0xaf, 0x81, 0xcc, 0x00, 0xfc, 0x11, 0x14, 0xf3, 0x87, 0x67,
-	0x48, 0xbd, 0x31, 0x18, 0x6a, 0x4b, 0x13, 0x95, 0x3f, 0x3f, 0x73, 0x10, 0x9b, 0xcc, 0xe0, 0x59,
-	0x03, 0x7e, 0x16, 0x7f, 0xc2, 0xfc, 0x28, 0xc1, 0xa2, 0xf8, 0xde, 0xe8, 0x0e, 0x44, 0x77, 0xf6,
-	0x0f, 0xb7, 0xea, 0x4a, 0x48, 0x5d, 0x1e, 0x0c, 0xb5, 0x94, 0x50, 0xb0, 0x4f, 0x8f, 0x34, 0x88,
-	0x57, 0xaa, 0xf5, 0xd2, 0x6e, 0x49, 0x17, 0x90, 0x42, 0xef, 0x7f, 0x4e, 0x94, 0x87, 0xc5, 0xe3,
-	0x6a, 0xad, 0xb2, 0x5b, 0x2d, 0x3d, 0x51, 0x16, 0xf8, 0xec, 0x13, 0x26, 0xe2, 0x1b, 0x51, 0x94,
-	0xe2, 0xe1, 0xe1, 0x7e, 0x69, 0xab, 0xaa, 0x84, 0x67, 0x51, 0xfc, 0x7b, 0x47, 0x59, 0x88, 0xd5,
-	0xea, 0x7a, 0xa5, 0xba, 0xab, 0x44, 0x54, 0x34, 0x18, 0x6a, 0x69, 0x61, 0xc0, 0xaf, 0xd2, 0x3f,
-	0xf8, 0x77, 0x12, 0x64, 0xb6, 0xb1, 0x8d, 0x4f, 0xda, 0x9d, 0xb6, 0xd7, 0x26, 0xee, 0x64, 0xe6,
-	0xed, 0x41, 0xa4, 0x89, 0x6d, 0x51, 0x2f, 0x97, 0xb7, 0x8a, 0x8b, 0x9c, 0xa9, 0xd0, 0x2d, 0x99,
-	0x9e, 0xd3, 0xd7, 0x19, 0x88, 0xfa, 0x08, 0xe4, 0x89, 0x28, 0x38, 0x86, 0xe5, 0x0b, 0xc6, 0xb0,
-	0xec, 0x8f, 0xe1, 0x4f, 0x17, 0x1e, 0x4b, 0xf9, 0xc7, 0x90, 0x9e, 0xfd, 0x89, 0xa5, 0xb6, 0xae,
-	0x87, 0x1d, 0x8f, 0xf9, 0x87, 0x75, 0xbe, 0xa1, 0x98, 0xc4, 0x6c, 0x31, 0xff, 0xb0, 0x4e, 0x97,
-	0xf9, 0x91, 0x04, 0x69, 0xd1, 0x58, 0xa6, 0xbf, 0xdf, 0xb4, 0xa4, 0xe7, 0xfe, 0xfd, 0xae, 0x63,
-	0xc3, 0x15, 0xbf, 0xdf, 0xde, 0x64, 0xfd, 0x4f, 0x79, 0x61, 0xfc, 0x29, 0x81, 0x52, 0xc7, 0xc6,
-	0x53, 0xc6, 0xea, 0xf7, 0x32, 0x45, 0x74, 0x13, 0xe2, 0xfe, 0xbc, 0x60, 0xf3, 0x59, 0xd6, 0x63,
-	0x7c, 0x42, 0xe4, 0x0b, 0x90, 0xe1, 0x2c, 0x16, 0xd9, 0xfb, 0xa4, 0x9d, 0xd6, 0x3c, 0x1b, 0x2f,
-	0xa2, 0xe6, 0x37, 0xff, 0x08, 0x43, 0xbc, 0xc6, 0x23, 0x21, 0x0c, 0x30, 0x7d, 0x7c, 0xa2, 0x0f,
-	0xae, 0xec, 0xe5, 0x33, 0x2f, 0x54, 0xf5, 0xbf, 0x73, 0xf5, 0xfd, 0x0d, 0x09, 0x35, 0x40, 0x9e,
-	0xbc, 0x62, 0xd0, 0xff, 0xe7, 0x7e, 0xe9, 0xcc, 0x1f, 0xa0, 0x09, 0x62, 0x68, 0xa2, 0x7b, 0x57,
-	0x4d, 0xb2, 0x00, 0xfb, 0xd5, 0x07, 0x97, 0x1a, 0x5e, 0x74, 0x95, 0x1b, 0x12, 0x32, 0x40, 0x9e,
-	0xf0, 0xeb, 0x8a, 0x2c, 0xce, 0x72, 0xf0, 0xfa, 0x81, 0x8e, 0x21, 0x19, 0xec, 0x22, 0x68, 0xe5,
-	0x1c, 0x5f, 0x4b, 0x5d, 0xdb, 0xeb, 0x5f, 0x01, 0x7c, 0x51, 0x13, 0x2a, 0xde, 0x7b, 0xf5, 0x26,
-	0x1b, 0x7a, 0x35, 0xce, 0x4a, 0xaf, 0xc7, 0x59, 0xe9, 0xd7, 0x71, 0x56, 0xfa, 0xe6, 0x6d, 0x36,
-	0xf4, 0xfa, 0x6d, 0x36, 0xf4, 0xcb, 0xdb, 0x6c, 0xe8, 0x4b, 0x36, 0xd5, 0xe9, 0x50, 0x77, 0x4f,
-	0x62, 0x2c, 0xce, 0xc7, 0x7f, 0x07, 0x00, 0x00, 0xff, 0xff, 0x64, 0x15, 0xbe, 0x5e, 0x26, 0x11,
-	0x00, 0x00,
+	// 1537 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xdc, 0x58, 0x4f, 0x6f, 0xdb, 0x46,
+	0x16, 0x17, 0xad, 0x7f, 0xe6, 0xd3, 0x1f, 0xd3, 0x13, 0xad, 0xe3, 0x30, 0x89, 0xc4, 0x15, 0x76,
+	0x37, 0xde, 0xdd, 0x44, 0xf6, 0x7a, 0x81, 0xc4, 0xbb, 0xa7, 0xb5, 0x1c, 0xd9, 0xd2, 0xda, 0x96,
+	0x0d, 0x4a, 0x0e, 0xd0, 0x5e, 0x84, 0xb1, 0x35, 0x66, 0x84, 0x48, 0x24, 0x4b, 0x52, 0x41, 0xf4,
+	0x0d, 0x0a, 0x9d, 0x8a, 0x9e, 0xab, 0x53, 0x8f, 0xbd, 0xf7, 0xd4, 0x0f, 0x10, 0xa0, 0x97, 0x1c,
+	0x8b, 0x1e, 0x84, 0x46, 0x39, 0x05, 0xe8, 0x17, 0x68, 0x4f, 0xc5, 0xcc, 0x70, 0x24, 0xca, 0xb6,
+	0x1c, 0xf9, 0x16, 0xe4, 0x36, 0xf3, 0xfe, 0xfc, 0xde, 0xbc, 0xd1, 0xef, 0xbd, 0x37, 0x14, 0x64,
+	0x5c, 0xcf, 0x72, 0xb0, 0x41, 0x1a, 0x67, 0x56, 0xa7, 0x63, 0x99, 0x05, 0xdb, 0xb1, 0x3c, 0x0b,
+	0xdd, 0x3e, 0x33, 0x2d, 0xb7, 0x79, 0x5a, 0xb0, 0xdb, 0xd8, 0x3b, 0xb7, 0x9c, 0x4e, 0xc1, 0xb7,
+	0x52, 0x33, 0x86, 0x65, 0x58, 0xcc, 0x66, 0x9d, 0xae, 0xb8, 0xb9, 0x7a, 0xd7, 0xb0, 0x2c, 0xa3,
+	0x4d, 0xd6, 0xd9, 0xee, 0xb4, 0x7b, 0xbe, 0x4e, 0x3a, 0xb6, 0xd7, 0xf3, 0x95, 0x77, 0x2e, 0x2a,
+	0xb1, 0x29, 0x54, 0x4b, 0xb6, 0x43, 0x9a, 0xad, 0x33, 0xec, 0x11, 0x2e, 0xc8, 0xbf, 0x95, 0x60,
+	0x59, 0x27, 0xb8, 0xb9, 0xdb, 0x6a, 0x7b, 0xc4, 0xd1, 0xc9, 0x17, 0x5d, 0xe2, 0x7a, 0xa8, 0x04,
+	0x09, 0x87, 0xe0, 0x66, 0xc3, 0xb5, 0xba, 0xce, 0x19, 0x59, 0x95, 0x34, 0x69, 0x2d, 0xb1, 0x99,
+	0x29, 0x70, 0xdc, 0x82, 0xc0, 0x2d, 0x6c, 0x9b, 0xbd, 0x62, 0x7a, 0x34, 0xcc, 0x01, 0x45, 0xa8,
+	0x31, 0x5b, 0x1d, 0x9c, 0xf1, 0x1a, 0xed, 0x40, 0xd4, 0xc1, 0xa6, 0x41, 0x56, 0x17, 0x18, 0xc0,
+	0x83, 0xc2, 0x8c, 0x24, 0x0b, 0xf5, 0x56, 0x87, 0xb8, 0x1e, 0xee, 0xd8, 0x3a, 0x35, 0x2f, 0x46,
+	0x5e, 0x0f, 0x73, 0x21, 0x9d, 0xfb, 0xa2, 0xff, 0x81, 0x3c, 0x3e, 0xf4, 0x6a, 0x98, 0x01, 0xe5,
+	0x67, 0x02, 0x1d, 0x0b, 0x4b, 0x7d, 0xe2, 0x94, 0xff, 0x21, 0x0a, 0x0a, 0x3d, 0xe1, 0x9e, 0x63,
+	0x75, 0xed, 0x4f, 0x32, 0x45, 0xf4, 0x10, 0xc0, 0xa0, 0xd9, 0x35, 0x5e, 0x90, 0x9e, 0xbb, 0x1a,
+	0xd1, 0xc2, 0x6b, 0x72, 0x31, 0x35, 0x1a, 0xe6, 0x64, 0x96, 0xf3, 0x3e, 0xe9, 0xb9, 0xba, 0x6c,
+	0x88, 0x25, 0x2a, 0x41, 0x94, 0x6d, 0x56, 0xa3, 0x9a, 0xb4, 0x96, 0xde, 0x5c, 0x9f, 0x19, 0xeb,
+	0xe2, 0xad, 0x15, 0xf8, 0x86, 0x7b, 0xd3, 0x63, 0x63, 0xc3, 0x70, 0x88, 0x41, 0x8f, 0x1d, 0xfb,
+	0xc0, 0xb1, 0xb7, 0x85, 0xa5, 0x3e, 0x71, 0x42, 0x0f, 0x21, 0xfa, 0xbc, 0x65, 0x7a, 0xee, 0x6a,
+	0x5c, 0x93, 0xd6, 0xe2, 0xc5, 0x95, 0xd1, 0x30, 0x17, 0x2d, 0x53, 0xc1, 0xef, 0xc3, 0x9c, 0x4c,
+	0x17, 0xbb, 0x6d, 0x6c, 0xb8, 0x3a, 0x37, 0xca, 0xef, 0x41, 0x94, 0xc5, 0x47, 0xf7, 0x01, 0xf6,
+	0xf4, 0xa3, 0x93, 0xe3, 0x46, 0xf5, 0xa8, 0x5a, 0x52, 0x42, 0x6a, 0xaa, 0x3f, 0xd0, 0x78, 0xa6,
+	0x55, 0xcb, 0x24, 0xe8, 0x0e, 0x2c, 0x72, 0x75, 0xf1, 0x33, 0x65, 0x41, 0x4d, 0xf4, 0x07, 0x5a,
+	0x9c, 0x29, 0x8b, 0x3d, 0x35, 0xf2, 0xe5, 0xb7, 0xd9, 0x50, 0xfe, 0x3b, 0x09, 0x26, 0xe8, 0xe8,
+	0x2e, 0xc8, 0xe5, 0x4a, 0xb5, 0x2e, 0xc0, 0x92, 0xfd, 0x81, 0xb6, 0x48, 0xb5, 0x0c, 0xeb, 0x2f,
+	0x90, 0xf6, 0x95, 0x8d, 0xe3, 0xa3, 0x4a, 0xb5, 0x5e, 0x53, 0x24, 0x55, 0xe9, 0x0f, 0xb4, 0x24,
+	0xb7, 0x38, 0xb6, 0xe8, 0xc9, 0x82, 0x56, 0xb5, 0x92, 0x5e, 0x29, 0xd5, 0x94, 0x85, 0xa0, 0x55,
+	0x8d, 0x38, 0x2d, 0xe2, 0xa2, 0x75, 0xc8, 0x30, 0xab, 0xda, 0x4e, 0xb9, 0x74, 0xb8, 0xdd, 0xd8,
+	0x3e, 0x38, 0x68, 0xd4, 0x2b, 0x87, 0x25, 0x25, 0xa2, 0xfe, 0xa9, 0x3f, 0xd0, 0x96, 0xa9, 0x6d,
+	0xed, 0xec, 0x39, 0xe9, 0xe0, 0xed, 0x76, 0x9b, 0xd2, 0xc5, 0x3f, 0xed, 0x8f, 0x12, 0xc8, 0xe3,
+	0xdb, 0x43, 0x4f, 0x21, 0xe2, 0xf5, 0x6c, 0x4e, 0xd8, 0xf4, 0xe6, 0xc6, 0x87, 0xef, 0x7b, 0xb2,
+	0xaa, 0xf7, 0x6c, 0xa2, 0x33, 0xef, 0xfc, 0x2b, 0x48, 0x4d, 0x89, 0x51, 0x0e, 0x22, 0x7e, 0xfe,
+	0xec, 0x2c, 0x53, 0x4a, 0x76, 0x11, 0xf7, 0x21, 0x5c, 0x3b, 0x39, 0x54, 0x24, 0x35, 0xd3, 0x1f,
+	0x68, 0xca, 0x94, 0xbe, 0xd6, 0xed, 0xa0, 0x3f, 0x43, 0x74, 0xe7, 0xe8, 0xa4, 0x5a, 0x57, 0x16,
+	0xd4, 0x95, 0xfe, 0x40, 0x43, 0x53, 0x06, 0x3b, 0x56, 0xd7, 0xf4, 0xfc, 0x6c, 0x1e, 0x41, 0xb8,
+	0x8e, 0x0d, 0xa4, 0x40, 0xf8, 0x05, 0xe9, 0xb1, 0x2c, 0x92, 0x3a, 0x5d, 0xa2, 0x0c, 0x44, 0x5f,
+	0xe2, 0x76, 0x97, 0x57, 0x52, 0x52, 0xe7, 0x9b, 0xfc, 0xfb, 0x14, 0x24, 0x29, 0x0b, 0x75, 0xe2,
+	0xda, 0x96, 0xe9, 0x12, 0x54, 0x81, 0xd8, 0xb9, 0x83, 0x3b, 0xc4, 0x5d, 0x95, 0xb4, 0xf0, 0x5a,
+	0x62, 0xf3, 0x9f, 0xd7, 0x92, 0x57, 0xb8, 0x15, 0x76, 0xa9, 0x8f, 0x5f, 0x75, 0x3e, 0x80, 0xfa,
+	0x6b, 0x14, 0xa2, 0x4c, 0x8e, 0xca, 0xa2, 0x20, 0xe2, 0x8c, 0xc5, 0x1b, 0xf3, 0x61, 0x32, 0x52,
+	0x31, 0x80, 0x72, 0x48, 0xd4, 0xc4, 0x3e, 0xc4, 0x5c, 0xf6, 0x6b, 0xfb, 0x1d, 0xe5, 0x5f, 0xf3,
+	0x41, 0x71, 0x86, 0x08, 0x2c, 0x1f, 0x02, 0xb5, 0x21, 0x79, 0xde, 0xb6, 0xb0, 0xd7, 0xb0, 0x19,
+	0xcd, 0xfc, 0x1e, 0xf3, 0x78, 0xce, 0x8c, 0xa9, 0x27, 0xe7, 0x27, 0x4f, 0x7e, 0x69, 0x34, 0xcc,
+	0x25, 0x02, 0xd2, 0x72, 0x48, 0x4f, 0x9c, 0x4f, 0xb6, 0xc8, 0x83, 0x74, 0xcb, 0xf4, 0x88, 0x41,
+	0x1c, 0x11, 0x8f, 0xb7, 0xa2, 0xad, 0xf9, 0xe2, 0x55, 0xb8, 0x6f, 0x30, 0xe2, 0xf2, 0x68, 0x98,
+	0x4b, 0x4d, 0xc9, 0xcb, 0x21, 0x3d, 0xd5, 0x0a, 0x0a, 0xd0, 0x2b, 0x58, 0xea, 0x9a, 0x6e, 0xcb,
+	0x30, 0x49, 0x53, 0x84, 0x8d, 0xb0, 0xb0, 0xff, 0x99, 0x2f, 0xec, 0x89, 0xef, 0x1c, 0x8c, 0x8b,
+	0x46, 0xc3, 0x5c, 0x7a, 0x5a, 0x51, 0x0e, 0xe9, 0xe9, 0xee, 0x94, 0x84, 0xe6, 0x7b, 0x6a, 0x59,
+	0x6d, 0x82, 0x4d, 0x11, 0x38, 0x7a, 0x93, 0x7c, 0x8b, 0xdc, 0xf7, 0x52, 0xbe, 0x53, 0x72, 0x9a,
+	0xef, 0x69, 0x50, 0x80, 0x6c, 0x48, 0xb9, 0x9e, 0xd3, 0x32, 0x0d, 0x11, 0x94, 0x37, 0xce, 0x27,
+	0x73, 0xf2, 0x84, 0xb9, 0x06, 0x63, 0x2a, 0xa3, 0x61, 0x2e, 0x19, 0x14, 0x97, 0x43, 0x7a, 0xd2,
+	0x0d, 0xec, 0x8b, 0x31, 0x88, 0x34, 0xb1, 0x87, 0xd5, 0x57, 0x00, 0x13, 0xc6, 0xa2, 0xbf, 0xc1,
+	0xa2, 0x87, 0x0d, 0x3e, 0x2f, 0x68, 0x25, 0x25, 0x8b, 0x89, 0xd1, 0x30, 0x17, 0xaf, 0x63, 0x83,
+	0x4d, 0x8b, 0xb8, 0xc7, 0x17, 0xa8, 0x08, 0xc8, 0xc6, 0x8e, 0xd7, 0xf2, 0x5a, 0x96, 0x49, 0xad,
+	0x1b, 0x2f, 0x71, 0x9b, 0x32, 0x91, 0x7a, 0x64, 0x46, 0xc3, 0x9c, 0x72, 0x2c, 0xb4, 0xfb, 0xa4,
+	0xf7, 0x0c, 0xb7, 0x5d, 0x5d, 0xb1, 0x2f, 0x48, 0xd4, 0xaf, 0x25, 0x48, 0x04, 0x18, 0x8e, 0x1e,
+	0x43, 0xc4, 0xc3, 0x86, 0xa8, 0xe0, 0x7b, 0xb3, 0x67, 0x26, 0x36, 0xfc, 0x92, 0x65, 0xf6, 0x68,
+	0x1f, 0x64, 0x9a, 0x49, 0x83, 0x35, 0xc0, 0x05, 0xd6, 0x00, 0x0b, 0xf3, 0xdd, 0xdb, 0x53, 0xec,
+	0x61, 0xd6, 0xfe, 0x16, 0x9b, 0xfe, 0x4a, 0xfd, 0x3f, 0x28, 0x17, 0x4b, 0x04, 0x65, 0x01, 0x3c,
+	0x31, 0xa7, 0xf9, 0xf1, 0x14, 0x3d, 0x20, 0x41, 0x2b, 0x10, 0x63, 0x6d, 0x89, 0x5f, 0x80, 0xa4,
+	0xfb, 0x3b, 0xf5, 0x00, 0xd0, 0x65, 0xfa, 0xdf, 0x10, 0x2d, 0x3c, 0x46, 0x3b, 0x84, 0x5b, 0x57,
+	0xb0, 0xfa, 0x86, 0x70, 0x91, 0xe0, 0xe1, 0x2e, 0x73, 0xf5, 0x86, 0x68, 0x8b, 0x63, 0xb4, 0x7d,
+	0x58, 0xbe, 0x44, 0xc2, 0x1b, 0x82, 0xc9, 0x02, 0x2c, 0x5f, 0x03, 0x99, 0x01, 0xf8, 0x23, 0x28,
+	0xe6, 0x0f, 0xcf, 0x90, 0x7a, 0xab, 0x3f, 0xd0, 0x96, 0xc6, 0x2a, 0x7f, 0x7e, 0xe6, 0x20, 0x36,
+	0x9e, 0xc1, 0xd3, 0x06, 0xfc, 0x2c, 0xfe, 0x84, 0xf9, 0x5e, 0x82, 0x45, 0xf1, 0x7b, 0xa3, 0x7b,
+	0x10, 0xdd, 0x3d, 0x38, 0xda, 0xae, 0x2b, 0x21, 0x75, 0xb9, 0x3f, 0xd0, 0x52, 0x42, 0xc1, 0x7e,
+	0x7a, 0xa4, 0x41, 0xbc, 0x52, 0xad, 0x97, 0xf6, 0x4a, 0xba, 0x80, 0x14, 0x7a, 0xff, 0xe7, 0x44,
+	0x79, 0x58, 0x3c, 0xa9, 0xd6, 0x2a, 0x7b, 0xd5, 0xd2, 0x53, 0x65, 0x81, 0xcf, 0x3e, 0x61, 0x22,
+	0x7e, 0x23, 0x8a, 0x52, 0x3c, 0x3a, 0x3a, 0x28, 0x6d, 0x57, 0x95, 0xf0, 0x34, 0x8a, 0x7f, 0xef,
+	0x28, 0x0b, 0xb1, 0x5a, 0x5d, 0xaf, 0x54, 0xf7, 0x94, 0x88, 0x8a, 0xfa, 0x03, 0x2d, 0x2d, 0x0c,
+	0xf8, 0x55, 0xfa, 0x07, 0xff, 0x46, 0x82, 0xcc, 0x0e, 0xb6, 0xf1, 0x69, 0xab, 0xdd, 0xf2, 0x5a,
+	0xc4, 0x1d, 0xcf, 0xbc, 0x7d, 0x88, 0x9c, 0x61, 0x5b, 0xd4, 0xcb, 0xec, 0x56, 0x71, 0x95, 0x33,
+	0x15, 0xba, 0x25, 0xd3, 0x73, 0x7a, 0x3a, 0x03, 0x51, 0x9f, 0x80, 0x3c, 0x16, 0x05, 0xc7, 0xb0,
+	0x7c, 0xc5, 0x18, 0x96, 0xfd, 0x31, 0xfc, 0xdf, 0x85, 0x2d, 0x29, 0xbf, 0x05, 0xe9, 0xe9, 0x47,
+	0x2c, 0xb5, 0x75, 0x3d, 0xec, 0x78, 0xcc, 0x3f, 0xac, 0xf3, 0x0d, 0xc5, 0x24, 0x66, 0x93, 0xf9,
+	0x87, 0x75, 0xba, 0xcc, 0x0f, 0x25, 0x48, 0x8b, 0xc6, 0x32, 0x79, 0x7e, 0xd3, 0x92, 0x9e, 0xfb,
+	0xf9, 0x5d, 0xc7, 0x86, 0x2b, 0x9e, 0xdf, 0xde, 0x78, 0xfd, 0xb1, 0x7c, 0x61, 0xfc, 0x26, 0x81,
+	0x52, 0xc7, 0xc6, 0x33, 0xc6, 0xea, 0x4f, 0x32, 0x45, 0x74, 0x1b, 0xe2, 0xfe, 0xbc, 0x60, 0xf3,
+	0x59, 0xd6, 0x63, 0x7c, 0x42, 0xe4, 0x0b, 0x90, 0xe1, 0x2c, 0x16, 0xd9, 0xfb, 0xa4, 0x9d, 0xd4,
+	0x3c, 0x1b, 0x2f, 0xe3, 0x9a, 0xff, 0x59, 0x82, 0xdb, 0x87, 0x04, 0xbb, 0x5d, 0x87, 0x74, 0x88,
+	0xe9, 0x55, 0x71, 0x67, 0x72, 0x65, 0x5b, 0x10, 0x9b, 0xe3, 0xb6, 0x60, 0x34, 0xcc, 0xc5, 0xfc,
+	0x9b, 0x8a, 0xb9, 0x1f, 0xd3, 0x2d, 0x6d, 0xbe, 0x8f, 0x40, 0xbc, 0xc6, 0x0d, 0x10, 0x06, 0x98,
+	0x7c, 0x59, 0xa3, 0x7f, 0x5c, 0x3b, 0xa8, 0xa6, 0x3e, 0xbf, 0xd5, 0xbf, 0xce, 0x35, 0xd4, 0x36,
+	0x24, 0xd4, 0x00, 0x79, 0xfc, 0x89, 0x86, 0xfe, 0x3e, 0xf7, 0x67, 0xdc, 0xfc, 0x01, 0xce, 0x40,
+	0xbc, 0x08, 0xd0, 0x83, 0xeb, 0xc6, 0x74, 0xa0, 0xb4, 0xd5, 0x47, 0x33, 0x0d, 0xaf, 0xe2, 0xc9,
+	0x86, 0x84, 0x0c, 0x90, 0xc7, 0xc5, 0x73, 0x4d, 0x16, 0x17, 0x0b, 0xec, 0xe6, 0x81, 0xba, 0xa0,
+	0x5c, 0x64, 0x1e, 0x9a, 0xfd, 0xd6, 0x9f, 0x41, 0xd2, 0x9b, 0x87, 0x3d, 0x81, 0x64, 0xb0, 0x33,
+	0xa3, 0x95, 0x4b, 0xac, 0x2e, 0xd1, 0x7f, 0x6f, 0xae, 0x01, 0xbe, 0xaa, 0xb1, 0x17, 0x1f, 0xbc,
+	0x7e, 0x9b, 0x0d, 0xbd, 0x1e, 0x65, 0xa5, 0x37, 0xa3, 0xac, 0xf4, 0xcb, 0x28, 0x2b, 0x7d, 0xf5,
+	0x2e, 0x1b, 0x7a, 0xf3, 0x2e, 0x1b, 0xfa, 0xe9, 0x5d, 0x36, 0xf4, 0x39, 0x7b, 0x29, 0xd1, 0x87,
+	0x92, 0x7b, 0x1a, 0x63, 0x71, 0xfe, 0xfd, 0xc7, 0x00, 0xdf, 0x90, 0xd9, 0x56, 0x7a, 0x12, 0x00,
+	0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -1140,6 +1184,8 @@ type StorageClient interface {
 	TagKeys(ctx context.Context, in *TagKeysRequest, opts ...grpc.CallOption) (Storage_TagKeysClient, error)
 	// TagValues performs a read operation for tag values
 	TagValues(ctx context.Context, in *TagValuesRequest, opts ...grpc.CallOption) (Storage_TagValuesClient, error)
+	// MeasurementNames performs a read operation for measurement names
+	MeasurementNames(ctx context.Context, in *MeasurementNamesRequest, opts ...grpc.CallOption) (Storage_MeasurementNamesClient, error)
 	// Capabilities returns a map of keys and values identifying the capabilities supported by the storage engine
 	Capabilities(ctx context.Context, in *types.Empty, opts ...grpc.CallOption) (*CapabilitiesResponse, error)
 }
@@ -1280,6 +1326,38 @@ func (x *storageTagValuesClient) Recv() (*StringValuesResponse, error) {
 	return m, nil
 }
 
+func (c *storageClient) MeasurementNames(ctx context.Context, in *MeasurementNamesRequest, opts ...grpc.CallOption) (Storage_MeasurementNamesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Storage_serviceDesc.Streams[4], "/cnosdb.platform.storage.Storage/MeasurementNames", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &storageMeasurementNamesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Storage_MeasurementNamesClient interface {
+	Recv() (*StringValuesResponse, error)
+	grpc.ClientStream
+}
+
+type storageMeasurementNamesClient struct {
+	grpc.ClientStream
+}
+
+func (x *storageMeasurementNamesClient) Recv() (*StringValuesResponse, error) {
+	m := new(StringValuesResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *storageClient) Capabilities(ctx context.Context, in *types.Empty, opts ...grpc.CallOption) (*CapabilitiesResponse, error) {
 	out := new(CapabilitiesResponse)
 	err := c.cc.Invoke(ctx, "/cnosdb.platform.storage.Storage/Capabilities", in, out, opts...)
@@ -1299,6 +1377,8 @@ type StorageServer interface {
 	TagKeys(*TagKeysRequest, Storage_TagKeysServer) error
 	// TagValues performs a read operation for tag values
 	TagValues(*TagValuesRequest, Storage_TagValuesServer) error
+	// MeasurementNames performs a read operation for measurement names
+	MeasurementNames(*MeasurementNamesRequest, Storage_MeasurementNamesServer) error
 	// Capabilities returns a map of keys and values identifying the capabilities supported by the storage engine
 	Capabilities(context.Context, *types.Empty) (*CapabilitiesResponse, error)
 }
@@ -1319,6 +1399,9 @@ func (*UnimplementedStorageServer) TagKeys(req *TagKeysRequest, srv Storage_TagK
 func (*UnimplementedStorageServer) TagValues(req *TagValuesRequest, srv Storage_TagValuesServer) error {
 	return status.Errorf(codes.Unimplemented, "method TagValues not implemented")
 }
+func (*UnimplementedStorageServer) MeasurementNames(req *MeasurementNamesRequest, srv Storage_MeasurementNamesServer) error {
+	return status.Errorf(codes.Unimplemented, "method MeasurementNames not implemented")
+}
 func (*UnimplementedStorageServer) Capabilities(ctx context.Context, req *types.Empty) (*CapabilitiesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Capabilities not implemented")
 }
@@ -1411,6 +1494,27 @@ func (x *storageTagValuesServer) Send(m *StringValuesResponse) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Storage_MeasurementNames_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(MeasurementNamesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StorageServer).MeasurementNames(m, &storageMeasurementNamesServer{stream})
+}
+
+type Storage_MeasurementNamesServer interface {
+	Send(*StringValuesResponse) error
+	grpc.ServerStream
+}
+
+type storageMeasurementNamesServer struct {
+	grpc.ServerStream
+}
+
+func (x *storageMeasurementNamesServer) Send(m *StringValuesResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _Storage_Capabilities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(types.Empty)
 	if err := dec(in); err != nil {
@@ -1459,6 +1563,11 @@ var _Storage_serviceDesc = grpc.ServiceDesc{
 			Handler:       _Storage_TagValues_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "MeasurementNames",
+			Handler:       _Storage_MeasurementNames_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "storage_common.proto",
 }
@@ -2430,6 +2539,63 @@ func (m *StringValuesResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *MeasurementNamesRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *MeasurementNamesRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *MeasurementNamesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Predicate != nil {
+		{
+			size, err := m.Predicate.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintStorageCommon(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	{
+		size, err := m.Range.MarshalToSizedBuffer(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = encodeVarintStorageCommon(dAtA, i, uint64(size))
+	}
+	i--
+	dAtA[i] = 0x12
+	if m.Source != nil {
+		{
+			size, err := m.Source.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintStorageCommon(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintStorageCommon(dAtA []byte, offset int, v uint64) int {
 	offset -= sovStorageCommon(v)
 	base := offset
@@ -2849,6 +3015,25 @@ func (m *StringValuesResponse) Size() (n int) {
 	return n
 }
 
+func (m *MeasurementNamesRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Source != nil {
+		l = m.Source.Size()
+		n += 1 + l + sovStorageCommon(uint64(l))
+	}
+	l = m.Range.Size()
+	n += 1 + l + sovStorageCommon(uint64(l))
+	if m.Predicate != nil {
+		l = m.Predicate.Size()
+		n += 1 + l + sovStorageCommon(uint64(l))
+	}
+	return n
+}
+
 func sovStorageCommon(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -5552,6 +5737,161 @@ func (m *StringValuesResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *MeasurementNamesRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowStorageCommon
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: MeasurementNamesRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: MeasurementNamesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Source", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStorageCommon
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStorageCommon
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStorageCommon
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Source == nil {
+				m.Source = &types.Any{}
+			}
+			if err := m.Source.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Range", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStorageCommon
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStorageCommon
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStorageCommon
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Range.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Predicate", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStorageCommon
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStorageCommon
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStorageCommon
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Predicate == nil {
+				m.Predicate = &Predicate{}
+			}
+			if err := m.Predicate.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipStorageCommon(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthStorageCommon
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipStorageCommon(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
@@ -0,0 +1,104 @@
+package reference
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/ipc"
+	"github.com/cnosdb/cnosdb/tests"
+)
+
+// TestServer_Query_Arrow decodes the Arrow responses of the server, chunked
+// or not, with the IPC reader of Apache Arrow, which stops at the end of the
+// first stream of a response.
+func TestServer_Query_Arrow(t *testing.T) {
+	s := tests.OpenServer(tests.NewConfig())
+	defer s.Close()
+
+	if err := s.CreateDatabaseAndRetentionPolicy("db0", tests.NewRetentionPolicySpec("rp0", 1, 0), true); err != nil {
+		t.Fatal(err)
+	}
+	points := "cpu,host=a value=1,ok=true 1000000000\n" +
+		"cpu,host=a value=2,ok=false 2000000000\n" +
+		"cpu,host=b value=3 1000000000\n" +
+		"cpu,host=b value=4 2000000000\n" +
+		"cpu,host=b value=5 3000000000"
+	if _, err := s.Write("db0", "rp0", points, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := [][]interface{}{
+		{"cpu", int64(1000000000), "a", 1.0, true, nil},
+		{"cpu", int64(2000000000), "a", 2.0, false, nil},
+		{"cpu", int64(1000000000), "b", 3.0, nil, nil},
+		{"cpu", int64(2000000000), "b", 4.0, nil, nil},
+		{"cpu", int64(3000000000), "b", 5.0, nil, nil},
+	}
+	for _, params := range []string{"", "&chunked=true&chunk_size=1", "&chunked=true&chunk_size=2"} {
+		q := url.QueryEscape(`SELECT value, ok FROM db0.rp0.cpu GROUP BY host`)
+		req, err := http.NewRequest("GET", s.URL()+"/query?q="+q+params, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept", "application/vnd.apache.arrow.stream")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := ipc.NewReader(resp.Body)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %s", params, err)
+		}
+		names := make([]string, 0, len(r.Schema().Fields()))
+		for _, f := range r.Schema().Fields() {
+			names = append(names, f.Name)
+		}
+		if exp := []string{"name", "time", "host", "value", "ok", "error"}; !reflect.DeepEqual(names, exp) {
+			t.Fatalf("%q: unexpected columns: %v", params, names)
+		} else if typ := r.Schema().Field(1).Type; !arrow.TypeEqual(typ, &arrow.TimestampType{Unit: arrow.Nanosecond, TimeZone: "UTC"}) {
+			t.Fatalf("%q: unexpected time type: %s", params, typ)
+		} else if md := r.Schema().Metadata(); md.FindKey("cnosdb.statement_id") < 0 || md.Values()[md.FindKey("cnosdb.statement_id")] != "0" {
+			t.Fatalf("%q: unexpected metadata: %v", params, md)
+		}
+
+		var rows [][]interface{}
+		for r.Next() {
+			rows = append(rows, records(r.Record())...)
+		}
+		if err := r.Err(); err != nil {
+			t.Fatalf("%q: unexpected error: %s", params, err)
+		}
+		r.Release()
+
+		// Nothing follows the end of the stream.
+		rest, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		} else if len(rest) != 0 {
+			t.Fatalf("%q: %d bytes after the end of the stream", params, len(rest))
+		}
+		if !reflect.DeepEqual(rows, exp) {
+			t.Fatalf("%q: unexpected rows:\ngot=%v\nexp=%v", params, rows, exp)
+		}
+	}
+}
+
+// records returns the values of the rows of rec, nil for null values.
+func records(rec arrow.Record) [][]interface{} {
+	rows := make([][]interface{}, rec.NumRows())
+	for i := range rows {
+		rows[i] = make([]interface{}, rec.NumCols())
+	}
+	for j, col := range rec.Columns() {
+		for i, v := range values(col) {
+			rows[i][j] = v
+		}
+	}
+	return rows
+}
@@ -0,0 +1,243 @@
+package reference
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+	"github.com/apache/arrow/go/v10/arrow/ipc"
+	"github.com/apache/arrow/go/v10/arrow/memory"
+)
+
+var update = flag.Bool("update", false, "rewrite the files written by Apache Arrow in the testdata of the packages")
+
+// arrowSchema and arrowColumns are the schema and the values of the files
+// of pkg/arrow/testdata, as declared by allSchema and allColumns of the
+// tests of pkg/arrow.
+var arrowSchema = arrow.NewSchema([]arrow.Field{
+	{Name: "time", Type: &arrow.TimestampType{Unit: arrow.Nanosecond, TimeZone: "UTC"}},
+	{Name: "int64", Type: arrow.PrimitiveTypes.Int64, Nullable: true},
+	{Name: "uint64", Type: arrow.PrimitiveTypes.Uint64, Nullable: true},
+	{Name: "float64", Type: arrow.PrimitiveTypes.Float64, Nullable: true},
+	{Name: "bool", Type: arrow.FixedWidthTypes.Boolean, Nullable: true},
+	{Name: "utf8", Type: arrow.BinaryTypes.String, Nullable: true},
+	{Name: "required", Type: arrow.BinaryTypes.String},
+}, func() *arrow.Metadata {
+	md := arrow.NewMetadata([]string{"k0", "k1"}, []string{"v0", ""})
+	return &md
+}())
+
+var arrowColumns = [][]interface{}{
+	{int64(0), int64(1), int64(2), int64(3), int64(4), int64(5), int64(6), int64(7), int64(8), int64(9)},
+	{int64(math.MinInt64), nil, int64(-1), int64(0), nil, int64(1), int64(math.MaxInt64), int64(2), int64(3), nil},
+	{uint64(0), nil, uint64(1), uint64(math.MaxUint64), nil, uint64(2), uint64(3), uint64(4), uint64(5), nil},
+	{-1.5, nil, math.Inf(-1), 0.0, nil, 2.25, math.MaxFloat64, math.SmallestNonzeroFloat64, 1e100, nil},
+	{true, nil, false, true, nil, true, true, false, true, nil},
+	{"a", nil, "", "é", nil, "x y", "\n", "z", "日本", nil},
+	{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"},
+}
+
+// newRecord returns a record of schema holding columns.
+func newRecord(schema *arrow.Schema, columns [][]interface{}) arrow.Record {
+	b := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer b.Release()
+	for i, values := range columns {
+		for _, v := range values {
+			if v == nil {
+				b.Field(i).AppendNull()
+				continue
+			}
+			switch b := b.Field(i).(type) {
+			case *array.TimestampBuilder:
+				b.Append(arrow.Timestamp(v.(int64)))
+			case *array.Int64Builder:
+				b.Append(v.(int64))
+			case *array.Uint64Builder:
+				b.Append(v.(uint64))
+			case *array.Float64Builder:
+				b.Append(v.(float64))
+			case *array.BooleanBuilder:
+				b.Append(v.(bool))
+			case *array.StringBuilder:
+				b.Append(v.(string))
+			}
+		}
+	}
+	return b.NewRecord()
+}
+
+// values returns the values of a column, nil for null values.
+func values(col arrow.Array) []interface{} {
+	values := make([]interface{}, col.Len())
+	for i := range values {
+		if col.IsNull(i) {
+			continue
+		}
+		switch col := col.(type) {
+		case *array.String:
+			values[i] = col.Value(i)
+		case *array.Timestamp:
+			values[i] = int64(col.Value(i))
+		case *array.Int64:
+			values[i] = col.Value(i)
+		case *array.Uint64:
+			values[i] = col.Value(i)
+		case *array.Float64:
+			values[i] = col.Value(i)
+		case *array.Boolean:
+			values[i] = col.Value(i)
+		}
+	}
+	return values
+}
+
+// checkRecords checks that records hold arrowColumns once, under
+// arrowSchema.
+func checkRecords(t *testing.T, schema *arrow.Schema, records []arrow.Record) {
+	t.Helper()
+	if !schema.Equal(arrowSchema) {
+		t.Fatalf("unexpected schema: %s", schema)
+	} else if md := schema.Metadata(); !reflect.DeepEqual(md.Keys(), []string{"k0", "k1"}) || !reflect.DeepEqual(md.Values(), []string{"v0", ""}) {
+		t.Fatalf("unexpected metadata: %s", md)
+	} else if len(records) != 1 {
+		t.Fatalf("unexpected records: %d", len(records))
+	}
+	for i, col := range records[0].Columns() {
+		if got := values(col); !reflect.DeepEqual(got, arrowColumns[i]) {
+			t.Fatalf("unexpected values of column %s:\ngot=%v\nexp=%v", schema.Field(i).Name, got, arrowColumns[i])
+		}
+	}
+}
+
+// readArrow reads the records of an IPC stream or file with the readers of
+// Apache Arrow.
+func readArrow(t *testing.T, file bool, buf []byte) (*arrow.Schema, []arrow.Record) {
+	t.Helper()
+	var records []arrow.Record
+	if file {
+		r, err := ipc.NewFileReader(bytes.NewReader(buf))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		defer r.Close()
+		for i := 0; i < r.NumRecords(); i++ {
+			rec, err := r.Record(i)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			rec.Retain()
+			records = append(records, rec)
+		}
+		return r.Schema(), records
+	}
+
+	r, err := ipc.NewReader(bytes.NewReader(buf))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer r.Release()
+	for r.Next() {
+		r.Record().Retain()
+		records = append(records, r.Record())
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return r.Schema(), records
+}
+
+// writeArrow writes a record of arrowColumns to an IPC stream or file with
+// the writers of Apache Arrow.
+func writeArrow(t *testing.T, file bool, path string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer f.Close()
+
+	rec := newRecord(arrowSchema, arrowColumns)
+	defer rec.Release()
+	if file {
+		w, err := ipc.NewFileWriter(f, ipc.WithSchema(arrowSchema))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		} else if err := w.Write(rec); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		} else if err := w.Close(); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	} else {
+		w := ipc.NewWriter(f, ipc.WithSchema(arrowSchema))
+		if err := w.Write(rec); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		} else if err := w.Close(); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+}
+
+// TestArrow_Golden reads the golden files of pkg/arrow, written by its
+// writers, with the readers of Apache Arrow.
+func TestArrow_Golden(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		file bool
+	}{
+		{name: "stream.arrows", file: false},
+		{name: "file.arrow", file: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			buf, err := ioutil.ReadFile(filepath.Join("..", "..", "pkg", "arrow", "testdata", tt.name))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			schema, records := readArrow(t, tt.file, buf)
+			checkRecords(t, schema, records)
+		})
+	}
+}
+
+// TestArrow_Fixtures checks the files of pkg/arrow/testdata written by the
+// writers of Apache Arrow, which the tests of pkg/arrow read. The files are
+// rewritten with the -update flag.
+func TestArrow_Fixtures(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		file bool
+	}{
+		{name: "arrow-go.arrows", file: false},
+		{name: "arrow-go.arrow", file: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join("..", "..", "pkg", "arrow", "testdata", tt.name)
+			tmp := filepath.Join(t.TempDir(), tt.name)
+			writeArrow(t, tt.file, tmp)
+			buf, err := ioutil.ReadFile(tmp)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if *update {
+				if err := ioutil.WriteFile(path, buf, 0666); err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+			}
+			fixture, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			} else if !bytes.Equal(buf, fixture) {
+				t.Fatalf("output differs from %s", path)
+			}
+
+			schema, records := readArrow(t, tt.file, fixture)
+			checkRecords(t, schema, records)
+		})
+	}
+}
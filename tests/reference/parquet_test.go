@@ -0,0 +1,453 @@
+package reference
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/apache/arrow/go/v10/parquet"
+	"github.com/apache/arrow/go/v10/parquet/compress"
+	"github.com/apache/arrow/go/v10/parquet/file"
+	"github.com/apache/arrow/go/v10/parquet/schema"
+	cnosparquet "github.com/cnosdb/cnosdb/pkg/parquet"
+)
+
+// parquetColumn is a column of a Parquet file and its values, of the Go
+// type of its physical type, strings for byte arrays and nil for nulls.
+type parquetColumn struct {
+	node   schema.Node
+	values []interface{}
+}
+
+// parquetColumns are the columns and the values of the files of
+// pkg/parquet/testdata, as declared by allColumns and allValues of the
+// tests of pkg/parquet.
+var parquetColumns = []parquetColumn{
+	{
+		node:   schema.MustPrimitive(schema.NewPrimitiveNodeLogical("time", parquet.Repetitions.Required, schema.NewTimestampLogicalType(true, schema.TimeUnitNanos), parquet.Types.Int64, 0, -1)),
+		values: []interface{}{int64(0), int64(1), int64(2), int64(3), int64(4), int64(5), int64(6), int64(7), int64(8), int64(9)},
+	},
+	{
+		node:   schema.NewBooleanNode("bool", parquet.Repetitions.Optional, -1),
+		values: []interface{}{true, nil, false, true, nil, true, true, false, true, nil},
+	},
+	{
+		node:   schema.NewInt32Node("int32", parquet.Repetitions.Optional, -1),
+		values: []interface{}{int32(math.MinInt32), nil, int32(-1), int32(0), nil, int32(1), int32(math.MaxInt32), int32(2), int32(3), nil},
+	},
+	{
+		node:   schema.NewInt64Node("int64", parquet.Repetitions.Optional, -1),
+		values: []interface{}{int64(math.MinInt64), nil, int64(-1), int64(0), nil, int64(1), int64(math.MaxInt64), int64(2), int64(3), nil},
+	},
+	{
+		node:   schema.MustPrimitive(schema.NewPrimitiveNodeLogical("uint64", parquet.Repetitions.Optional, schema.NewIntLogicalType(64, false), parquet.Types.Int64, 0, -1)),
+		values: []interface{}{int64(0), nil, int64(1), int64(-1), nil, int64(2), int64(3), int64(4), int64(5), nil},
+	},
+	{
+		node:   schema.NewFloat32Node("float", parquet.Repetitions.Optional, -1),
+		values: []interface{}{float32(-1.5), nil, float32(math.Inf(1)), float32(0), nil, float32(2.25), float32(math.MaxFloat32), float32(0), float32(1e-10), nil},
+	},
+	{
+		node:   schema.NewFloat64Node("double", parquet.Repetitions.Optional, -1),
+		values: []interface{}{-1.5, nil, math.Inf(-1), 0.0, nil, 2.25, math.MaxFloat64, math.SmallestNonzeroFloat64, 1e100, nil},
+	},
+	{
+		node:   schema.NewByteArrayNode("bytes", parquet.Repetitions.Optional, -1),
+		values: []interface{}{"\x00\x01\x02", nil, "", "\xff", nil, "a", "bc", "\x00", "\x01\x02\x03\x04", nil},
+	},
+	{
+		node:   schema.MustPrimitive(schema.NewPrimitiveNodeLogical("string", parquet.Repetitions.Optional, schema.StringLogicalType{}, parquet.Types.ByteArray, 0, -1)),
+		values: []interface{}{"a", nil, "", "é", nil, "x y", "\n", "z", "日本", nil},
+	},
+	{
+		node:   schema.MustPrimitive(schema.NewPrimitiveNodeLogical("required", parquet.Repetitions.Required, schema.StringLogicalType{}, parquet.Types.ByteArray, 0, -1)),
+		values: []interface{}{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"},
+	},
+}
+
+// columnarColumns are the columns and the values of the file of
+// pkg/columnar/testdata, which has no metadata of the columnar package and
+// columns of types it does not write.
+var columnarColumns = []parquetColumn{
+	{
+		node:   schema.NewFloat32Node("value", parquet.Repetitions.Optional, -1),
+		values: []interface{}{float32(0.5), nil},
+	},
+	{
+		node:   schema.NewInt64Node("time", parquet.Repetitions.Required, -1),
+		values: []interface{}{int64(2000), int64(1000)},
+	},
+	{
+		node:   schema.NewInt32Node("count", parquet.Repetitions.Required, -1),
+		values: []interface{}{int32(-1), int32(7)},
+	},
+	{
+		node:   schema.MustPrimitive(schema.NewPrimitiveNodeLogical("name", parquet.Repetitions.Required, schema.StringLogicalType{}, parquet.Types.ByteArray, 0, -1)),
+		values: []interface{}{"a", "b"},
+	},
+}
+
+// writeParquet writes a row group of columns with the writer of Apache
+// Arrow.
+func writeParquet(t *testing.T, columns []parquetColumn, props *parquet.WriterProperties) []byte {
+	t.Helper()
+	fields := make(schema.FieldList, len(columns))
+	for i, col := range columns {
+		fields[i] = col.node
+	}
+	root := schema.MustGroup(schema.NewGroupNode("schema", parquet.Repetitions.Required, fields, -1))
+
+	var buf bytes.Buffer
+	w := file.NewParquetWriter(&buf, root, file.WithWriterProps(props))
+	rg := w.AppendRowGroup()
+	for _, col := range columns {
+		cw, err := rg.NextColumn()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		var def []int16
+		var values []interface{}
+		for _, v := range col.values {
+			if col.node.RepetitionType() == parquet.Repetitions.Optional {
+				if v == nil {
+					def = append(def, 0)
+					continue
+				}
+				def = append(def, 1)
+			}
+			values = append(values, v)
+		}
+
+		switch cw := cw.(type) {
+		case *file.BooleanColumnChunkWriter:
+			v := make([]bool, len(values))
+			for i := range values {
+				v[i] = values[i].(bool)
+			}
+			_, err = cw.WriteBatch(v, def, nil)
+		case *file.Int32ColumnChunkWriter:
+			v := make([]int32, len(values))
+			for i := range values {
+				v[i] = values[i].(int32)
+			}
+			_, err = cw.WriteBatch(v, def, nil)
+		case *file.Int64ColumnChunkWriter:
+			v := make([]int64, len(values))
+			for i := range values {
+				v[i] = values[i].(int64)
+			}
+			_, err = cw.WriteBatch(v, def, nil)
+		case *file.Float32ColumnChunkWriter:
+			v := make([]float32, len(values))
+			for i := range values {
+				v[i] = values[i].(float32)
+			}
+			_, err = cw.WriteBatch(v, def, nil)
+		case *file.Float64ColumnChunkWriter:
+			v := make([]float64, len(values))
+			for i := range values {
+				v[i] = values[i].(float64)
+			}
+			_, err = cw.WriteBatch(v, def, nil)
+		case *file.ByteArrayColumnChunkWriter:
+			v := make([]parquet.ByteArray, len(values))
+			for i := range values {
+				v[i] = parquet.ByteArray(values[i].(string))
+			}
+			_, err = cw.WriteBatch(v, def, nil)
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		} else if err := cw.Close(); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	if err := rg.Close(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return buf.Bytes()
+}
+
+// readParquet reads the values of the columns of a file with the reader of
+// Apache Arrow.
+func readParquet(t *testing.T, buf []byte) (*file.Reader, [][]interface{}) {
+	t.Helper()
+	r, err := file.NewParquetReader(bytes.NewReader(buf))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	columns := make([][]interface{}, r.MetaData().Schema.NumColumns())
+	for i := 0; i < r.NumRowGroups(); i++ {
+		rg := r.RowGroup(i)
+		n := rg.NumRows()
+		for j := range columns {
+			cr, err := rg.Column(j)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			def := make([]int16, n)
+			var values []interface{}
+			switch cr := cr.(type) {
+			case *file.BooleanColumnChunkReader:
+				v := make([]bool, n)
+				_, m, err := cr.ReadBatch(n, v, def, nil)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				for _, v := range v[:m] {
+					values = append(values, v)
+				}
+			case *file.Int32ColumnChunkReader:
+				v := make([]int32, n)
+				_, m, err := cr.ReadBatch(n, v, def, nil)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				for _, v := range v[:m] {
+					values = append(values, v)
+				}
+			case *file.Int64ColumnChunkReader:
+				v := make([]int64, n)
+				_, m, err := cr.ReadBatch(n, v, def, nil)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				for _, v := range v[:m] {
+					values = append(values, v)
+				}
+			case *file.Float32ColumnChunkReader:
+				v := make([]float32, n)
+				_, m, err := cr.ReadBatch(n, v, def, nil)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				for _, v := range v[:m] {
+					values = append(values, v)
+				}
+			case *file.Float64ColumnChunkReader:
+				v := make([]float64, n)
+				_, m, err := cr.ReadBatch(n, v, def, nil)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				for _, v := range v[:m] {
+					values = append(values, v)
+				}
+			case *file.ByteArrayColumnChunkReader:
+				v := make([]parquet.ByteArray, n)
+				_, m, err := cr.ReadBatch(n, v, def, nil)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				for _, v := range v[:m] {
+					values = append(values, string(v))
+				}
+			default:
+				t.Fatalf("unexpected column reader: %T", cr)
+			}
+
+			optional := r.MetaData().Schema.Column(j).MaxDefinitionLevel() > 0
+			for k := int64(0); k < n; k++ {
+				if optional && def[k] == 0 {
+					columns[j] = append(columns[j], nil)
+					continue
+				}
+				columns[j] = append(columns[j], values[0])
+				values = values[1:]
+			}
+		}
+	}
+	return r, columns
+}
+
+// checkParquet checks that a file holds the values of columns, with the
+// same types.
+func checkParquet(t *testing.T, r *file.Reader, got [][]interface{}, columns []parquetColumn) {
+	t.Helper()
+	s := r.MetaData().Schema
+	if s.NumColumns() != len(columns) {
+		t.Fatalf("unexpected columns: %d", s.NumColumns())
+	}
+	for i, col := range columns {
+		c, exp := s.Column(i), col.node.(*schema.PrimitiveNode)
+		if c.Name() != exp.Name() || c.PhysicalType() != exp.PhysicalType() || !c.LogicalType().Equals(exp.LogicalType()) || c.SchemaNode().RepetitionType() != exp.RepetitionType() {
+			t.Fatalf("unexpected column %d: %s", i, c)
+		} else if !reflect.DeepEqual(got[i], col.values) {
+			t.Fatalf("unexpected values of column %s:\ngot=%v\nexp=%v", c.Name(), got[i], col.values)
+		}
+	}
+}
+
+// cnosValues returns the values of the columns for the writer of
+// pkg/parquet, zero for nulls.
+func cnosValues(columns []parquetColumn) []cnosparquet.Values {
+	values := make([]cnosparquet.Values, len(columns))
+	for i, col := range columns {
+		v := &values[i]
+		if col.node.RepetitionType() == parquet.Repetitions.Optional {
+			v.Valid = make([]bool, len(col.values))
+		}
+		for j, x := range col.values {
+			if x != nil && v.Valid != nil {
+				v.Valid[j] = true
+			}
+			switch col.node.(*schema.PrimitiveNode).PhysicalType() {
+			case parquet.Types.Boolean:
+				b, _ := x.(bool)
+				v.Booleans = append(v.Booleans, b)
+			case parquet.Types.Int32:
+				n, _ := x.(int32)
+				v.Int32s = append(v.Int32s, n)
+			case parquet.Types.Int64:
+				n, _ := x.(int64)
+				v.Int64s = append(v.Int64s, n)
+			case parquet.Types.Float:
+				f, _ := x.(float32)
+				v.Floats = append(v.Floats, f)
+			case parquet.Types.Double:
+				f, _ := x.(float64)
+				v.Doubles = append(v.Doubles, f)
+			case parquet.Types.ByteArray:
+				var b []byte
+				if x != nil {
+					b = []byte(x.(string))
+				}
+				v.Bytes = append(v.Bytes, b)
+			}
+		}
+	}
+	return values
+}
+
+// TestParquet_Golden reads the files written by pkg/parquet, the golden
+// files of its testdata and files of the other codecs, with the reader of
+// Apache Arrow.
+func TestParquet_Golden(t *testing.T) {
+	columns := []cnosparquet.Column{
+		{Name: "time", Type: cnosparquet.Int64, Logical: cnosparquet.TimestampNanos},
+		{Name: "bool", Type: cnosparquet.Boolean, Optional: true},
+		{Name: "int32", Type: cnosparquet.Int32, Optional: true},
+		{Name: "int64", Type: cnosparquet.Int64, Optional: true},
+		{Name: "uint64", Type: cnosparquet.Int64, Logical: cnosparquet.Uint64, Optional: true},
+		{Name: "float", Type: cnosparquet.Float, Optional: true},
+		{Name: "double", Type: cnosparquet.Double, Optional: true},
+		{Name: "bytes", Type: cnosparquet.ByteArray, Optional: true},
+		{Name: "string", Type: cnosparquet.ByteArray, Logical: cnosparquet.String, Optional: true},
+		{Name: "required", Type: cnosparquet.ByteArray, Logical: cnosparquet.String},
+	}
+	for _, tt := range []struct {
+		name  string
+		codec cnosparquet.Compression
+	}{
+		{name: "uncompressed.parquet"},
+		{name: "snappy.parquet"},
+		{name: "gzip", codec: cnosparquet.Gzip},
+		{name: "zstd", codec: cnosparquet.Zstd},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf []byte
+			if filepath.Ext(tt.name) == ".parquet" {
+				var err error
+				if buf, err = ioutil.ReadFile(filepath.Join("..", "..", "pkg", "parquet", "testdata", tt.name)); err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+			} else {
+				var w bytes.Buffer
+				pw := cnosparquet.NewWriter(&w, columns, cnosparquet.WriterConfig{
+					Compression: tt.codec,
+					Metadata:    []cnosparquet.KeyValue{{Key: "k", Value: "v"}},
+					CreatedBy:   "cnosdb",
+				})
+				if err := pw.WriteRowGroup(cnosValues(parquetColumns)); err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				} else if err := pw.Close(); err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				buf = w.Bytes()
+			}
+
+			r, got := readParquet(t, buf)
+			defer r.Close()
+			checkParquet(t, r, got, parquetColumns)
+			if md := r.MetaData(); md.GetCreatedBy() != "cnosdb" {
+				t.Fatalf("unexpected created by: %q", md.GetCreatedBy())
+			} else if v := md.KeyValueMetadata().FindValue("k"); v == nil || *v != "v" {
+				t.Fatalf("unexpected metadata: %v", md.KeyValueMetadata())
+			}
+		})
+	}
+}
+
+// TestParquet_Fixtures checks the files of pkg/parquet/testdata and
+// pkg/columnar/testdata written by the writer of Apache Arrow, which the
+// tests of these packages read: data pages of version 1 and 2, dictionary
+// and plain encoded, with each codec. The files are rewritten with the
+// -update flag.
+func TestParquet_Fixtures(t *testing.T) {
+	for _, tt := range []struct {
+		path    string
+		columns []parquetColumn
+		props   []parquet.WriterProperty
+	}{
+		{
+			path:    filepath.Join("..", "..", "pkg", "parquet", "testdata", "arrow-go-v1.parquet"),
+			columns: parquetColumns,
+			props: []parquet.WriterProperty{
+				parquet.WithVersion(parquet.V1_0),
+				parquet.WithDataPageVersion(parquet.DataPageV1),
+				parquet.WithCompression(compress.Codecs.Snappy),
+			},
+		},
+		{
+			path:    filepath.Join("..", "..", "pkg", "parquet", "testdata", "arrow-go-v2.parquet"),
+			columns: parquetColumns,
+			props: []parquet.WriterProperty{
+				parquet.WithVersion(parquet.V2_LATEST),
+				parquet.WithDataPageVersion(parquet.DataPageV2),
+				parquet.WithCompression(compress.Codecs.Zstd),
+			},
+		},
+		{
+			path:    filepath.Join("..", "..", "pkg", "parquet", "testdata", "arrow-go-plain.parquet"),
+			columns: parquetColumns,
+			props: []parquet.WriterProperty{
+				parquet.WithDataPageVersion(parquet.DataPageV2),
+				parquet.WithDictionaryDefault(false),
+				parquet.WithCompression(compress.Codecs.Gzip),
+			},
+		},
+		{
+			path:    filepath.Join("..", "..", "pkg", "columnar", "testdata", "arrow-go.parquet"),
+			columns: columnarColumns,
+		},
+	} {
+		t.Run(filepath.Base(tt.path), func(t *testing.T) {
+			buf := writeParquet(t, tt.columns, parquet.NewWriterProperties(tt.props...))
+			if *update {
+				if err := ioutil.WriteFile(tt.path, buf, 0666); err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+			}
+			fixture, err := ioutil.ReadFile(tt.path)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			} else if !bytes.Equal(buf, fixture) {
+				t.Fatalf("output differs from %s", tt.path)
+			}
+
+			r, got := readParquet(t, fixture)
+			defer r.Close()
+			checkParquet(t, r, got, tt.columns)
+			if md := r.MetaData(); md.KeyValueMetadata().Len() != 0 {
+				t.Fatalf("unexpected metadata: %v", md.KeyValueMetadata())
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+// Package reference checks the files and responses written by CnosDB with
+// the Go implementation of Apache Arrow and Parquet. It is a module of its
+// own so that the reference implementation is not a dependency of CnosDB.
+package reference
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/cnosdb/cnosdb/pkg/logger"
+	"github.com/cnosdb/cnosdb/tests"
+	"github.com/cnosdb/cnosdb/vend/db/tsdb"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestMain(m *testing.M) {
+	c := logger.NewDefaultLogConfig()
+	c.Level = zapcore.ErrorLevel
+	if err := logger.InitZapLogger(c); err != nil {
+		fmt.Printf("parse log config: %s\n", err)
+	}
+	tests.IndexType = tsdb.DefaultIndex
+	os.Exit(m.Run())
+}
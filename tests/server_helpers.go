@@ -47,6 +47,9 @@ type Server interface {
 	Query(query string) (results string, err error)
 	QueryWithParams(query string, values url.Values) (results string, err error)
 
+	HTTPGet(url string) (results string, err error)
+	HTTPPost(url string, content []byte) (results string, err error)
+
 	Write(db, rp, body string, params url.Values) (results string, err error)
 	MustWrite(db, rp, body string, params url.Values) string
 	WritePoints(database, retentionPolicy string, consistencyLevel models.ConsistencyLevel, user meta.User, points []models.Point) error
@@ -1,12 +1,29 @@
 package tests
 
 import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	crand "crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
 	"flag"
 	"fmt"
+	"io"
+	"math"
+	"math/big"
 	"math/rand"
+	"net"
+	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
@@ -15,9 +32,20 @@ import (
 
 	"github.com/cnosdb/cnosdb/pkg/logger"
 	"github.com/cnosdb/cnosdb/server/coordinator"
+	"github.com/cnosdb/cnosdb/storage/reads/datatypes"
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+	"github.com/cnosdb/cnosdb/vend/common/pkg/toml"
 	"github.com/cnosdb/cnosdb/vend/db/models"
+	"github.com/cnosdb/cnosdb/vend/db/pkg/geo"
 	"github.com/cnosdb/cnosdb/vend/db/tsdb"
+	"github.com/cnosdb/cnosdb/vend/storage"
+	"github.com/gogo/protobuf/types"
 	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 // Global server used by benchmarks
@@ -555,6 +583,210 @@ func TestServer_Write_LineProtocol_Partial(t *testing.T) {
 	}
 }
 
+func TestServer_Write_WriteID(t *testing.T) {
+	t.Parallel()
+	s := OpenServer(NewConfig())
+	defer s.Close()
+
+	// A write that fails is not remembered, its retry is written.
+	params := url.Values{"writeId": []string{"batch-1"}}
+	if _, err := s.Write("db0", "rp0", `cpu value=1 1000000000`, params); err == nil {
+		t.Fatal("expected error writing to a missing database")
+	}
+
+	if err := s.CreateDatabaseAndRetentionPolicy("db0", NewRetentionPolicySpec("rp0", 1, 0), true); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Write("db0", "rp0", `cpu value=1 1000000000`, params); err != nil {
+		t.Fatal(err)
+	}
+
+	// Retries of a write with the same ID are discarded.
+	if _, err := s.Write("db0", "rp0", `cpu value=2 2000000000`, params); err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest("POST", s.URL()+"/write?db=db0&rp=rp0", strings.NewReader(`cpu value=3 3000000000`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Write-ID", "batch-1")
+	if resp, err := http.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	} else if resp.Body.Close(); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	// Writes with other IDs or without an ID are written.
+	if _, err := s.Write("db0", "rp0", `cpu value=4 4000000000`, url.Values{"writeId": []string{"batch-2"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Write("db0", "rp0", `cpu value=5 5000000000`, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if res, err := s.Query(`SELECT value FROM db0.rp0.cpu`); err != nil {
+		t.Fatal(err)
+	} else if exp := `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value"],"values":[["1970-01-01T00:00:01Z",1],["1970-01-01T00:00:04Z",4],["1970-01-01T00:00:05Z",5]]}]}]}`; exp != res {
+		t.Fatalf("unexpected results\nexp: %s\ngot: %s\n", exp, res)
+	}
+}
+
+func TestServer_Write_MeasurementSchema(t *testing.T) {
+	t.Parallel()
+	s := OpenServer(NewConfig())
+	defer s.Close()
+
+	if err := s.CreateDatabaseAndRetentionPolicy("db0", NewRetentionPolicySpec("rp0", 1, 1*time.Hour), true); err != nil {
+		t.Fatal(err)
+	}
+
+	params := url.Values{"db": []string{"db0"}}
+	if _, err := s.QueryWithParams(`CREATE MEASUREMENT cpu (TAGS host, region; FIELDS usage FLOAT REQUIRED, cores INTEGER)`, params); err != nil {
+		t.Fatal(err)
+	}
+
+	now := now()
+	ts := strconv.FormatInt(now.UnixNano(), 10)
+	for _, tt := range []struct {
+		line   string
+		reason string
+	}{
+		{line: `cpu,host=a,zone=z usage=1 ` + ts, reason: `input tag \"zone\" on measurement \"cpu\" is not declared`},
+		{line: `cpu,host=a usage=1,usge=2 ` + ts, reason: `input field \"usge\" on measurement \"cpu\" is not declared`},
+		{line: `cpu,host=a usage="high" ` + ts, reason: `input field \"usage\" on measurement \"cpu\" is type string, declared as type float`},
+		{line: `cpu,host=a cores=4i ` + ts, reason: `required field \"usage\" on measurement \"cpu\" is missing`},
+	} {
+		if _, err := s.Write("db0", "rp0", tt.line, nil); err == nil {
+			t.Fatalf("expected error writing %q", tt.line)
+		} else if exp := "partial write: schema violation: " + tt.reason; !strings.Contains(err.Error(), exp) {
+			t.Fatalf("unexpected error writing %q\nexp: %s\ngot: %v", tt.line, exp, err)
+		}
+	}
+
+	// Points conforming to the schema are written, other measurements are not restricted.
+	if _, err := s.Write("db0", "rp0", "cpu,host=a,region=r usage=1,cores=4i "+ts+"\nmem,zone=z free=1i "+ts, nil); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := s.Query(`SELECT * FROM db0.rp0.cpu`); err != nil {
+		t.Fatal(err)
+	} else if exp := fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cores","host","region","usage"],"values":[["%s",4,"a","r",1]]}]}]}`, now.Format(time.RFC3339Nano)); exp != res {
+		t.Fatalf("unexpected results\nexp: %s\ngot: %s\n", exp, res)
+	}
+
+	// Dropping the measurement removes its schema.
+	if _, err := s.QueryWithParams(`DROP MEASUREMENT cpu`, params); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Write("db0", "rp0", `cpu,zone=z usage=1 `+ts, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServer_Write_MeasurementSchema_Geo(t *testing.T) {
+	t.Parallel()
+	c := NewConfig()
+	c.Coordinator.GeoCellLevel = 12
+	s := OpenServer(c)
+	defer s.Close()
+
+	if err := s.CreateDatabaseAndRetentionPolicy("db0", NewRetentionPolicySpec("rp0", 1, 0), true); err != nil {
+		t.Fatal(err)
+	}
+	params := url.Values{"db": []string{"db0"}}
+	if _, err := s.QueryWithParams(`CREATE MEASUREMENT gps (TAGS vehicle; FIELDS lat FLOAT, lon FLOAT)`, params); err != nil {
+		t.Fatal(err)
+	}
+
+	// The cell tag added to the points with a location is not declared by
+	// the schema.
+	if _, err := s.Write("db0", "rp0", "gps,vehicle=v1 lat=52.52,lon=13.405 1000000000\ngps,vehicle=v2 lat=1 1000000000", nil); err != nil {
+		t.Fatal(err)
+	}
+	exp := fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"gps","tags":{"s2_cell":"%s"},"columns":["time","vehicle","lat"],"values":[["1970-01-01T00:00:01Z","v1",52.52]]}]}]}`, geo.CellIDFromLatLon(52.52, 13.405, 12).Token())
+	if res, err := s.Query(`SELECT vehicle, lat FROM db0.rp0.gps WHERE geo_within_radius(52.52, 13.405, 1000) GROUP BY s2_cell`); err != nil {
+		t.Fatal(err)
+	} else if res != exp {
+		t.Fatalf("unexpected results\nexp: %s\ngot: %s", exp, res)
+	}
+
+	// Other tags are still rejected.
+	if _, err := s.Write("db0", "rp0", "gps,vehicle=v1,zone=z lat=52.52,lon=13.405 2000000000", nil); err == nil || !strings.Contains(err.Error(), `input tag \"zone\" on measurement \"gps\" is not declared`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestServer_Query_Insert(t *testing.T) {
+	t.Parallel()
+	s := OpenServer(NewConfig())
+	defer s.Close()
+
+	if err := s.CreateDatabaseAndRetentionPolicy("db0", NewRetentionPolicySpec("rp0", 1, 0), true); err != nil {
+		t.Fatal(err)
+	}
+
+	params := url.Values{"db": []string{"db0"}}
+	if res, err := s.QueryWithParams(`INSERT INTO cpu (host::tag, value, time) VALUES ('a', 1, 1000000000), ('b', 2.5, '1970-01-01T00:00:02Z'); `+
+		`INSERT INTO db0.rp0.cpu (host::tag, value, count::integer, time) VALUES ('a', 3, 1, 3000000000)`, params); err != nil {
+		t.Fatal(err)
+	} else if exp := `{"results":[{"statement_id":0,"series":[{"name":"result","columns":["time","written"],"values":[["1970-01-01T00:00:00Z",2]]}]},` +
+		`{"statement_id":1,"series":[{"name":"result","columns":["time","written"],"values":[["1970-01-01T00:00:00Z",1]]}]}]}`; exp != res {
+		t.Fatalf("unexpected results\nexp: %s\ngot: %s\n", exp, res)
+	}
+
+	if res, err := s.Query(`SELECT host, value, count FROM db0.rp0.cpu`); err != nil {
+		t.Fatal(err)
+	} else if exp := `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","host","value","count"],"values":[["1970-01-01T00:00:01Z","a",1,null],["1970-01-01T00:00:02Z","b",2.5,null],["1970-01-01T00:00:03Z","a",3,1]]}]}]}`; exp != res {
+		t.Fatalf("unexpected results\nexp: %s\ngot: %s\n", exp, res)
+	}
+
+	// Numbers without a type are floats, as in the line protocol.
+	if res, err := s.Query(`SHOW FIELD KEYS FROM db0.rp0.cpu`); err != nil {
+		t.Fatal(err)
+	} else if exp := `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["fieldKey","fieldType"],"values":[["count","integer"],["value","float"]]}]}]}`; exp != res {
+		t.Fatalf("unexpected results\nexp: %s\ngot: %s\n", exp, res)
+	}
+
+	// Columns are typed after the schema of the measurement.
+	if _, err := s.QueryWithParams(`CREATE MEASUREMENT mem (TAGS host; FIELDS free INTEGER, total UNSIGNED)`, params); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.QueryWithParams(`INSERT INTO mem (host, free, total, time) VALUES ('a', 1, 2, 1000000000)`, params); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := s.Query(`SHOW FIELD KEYS FROM db0.rp0.mem`); err != nil {
+		t.Fatal(err)
+	} else if exp := `{"results":[{"statement_id":0,"series":[{"name":"mem","columns":["fieldKey","fieldType"],"values":[["free","integer"],["total","unsigned"]]}]}]}`; exp != res {
+		t.Fatalf("unexpected results\nexp: %s\ngot: %s\n", exp, res)
+	}
+	if res, err := s.QueryWithParams(`INSERT INTO mem (host, free) VALUES ('a', 'high')`, params); err != nil {
+		t.Fatal(err)
+	} else if exp := `{"results":[{"statement_id":0,"error":"field free: invalid integer value 'high'"}]}`; exp != res {
+		t.Fatalf("unexpected results\nexp: %s\ngot: %s\n", exp, res)
+	}
+
+	// The consecutive INSERT statements of a query are written only if all of
+	// them are valid.
+	if res, err := s.QueryWithParams(`INSERT INTO mem (host, free, time) VALUES ('b', 1, 2000000000); `+
+		`INSERT INTO mem (host, free, time) VALUES ('b', 'high', 3000000000)`, params); err != nil {
+		t.Fatal(err)
+	} else if exp := `{"results":[{"statement_id":0,"error":"statement 1: field free: invalid integer value 'high'"},{"statement_id":1,"error":"not executed"}]}`; exp != res {
+		t.Fatalf("unexpected results\nexp: %s\ngot: %s\n", exp, res)
+	}
+	if res, err := s.QueryWithParams(`INSERT INTO cpu (host::tag, value, time) VALUES ('c', 4, 4000000000); `+
+		`INSERT INTO cpu (host::tag, value, time) VALUES ('c', 'x', 5000000000)`, params); err != nil {
+		t.Fatal(err)
+	} else if exp := `{"results":[{"statement_id":0,"error":"statement 1: field value: string value conflicts with float values"},{"statement_id":1,"error":"not executed"}]}`; exp != res {
+		t.Fatalf("unexpected results\nexp: %s\ngot: %s\n", exp, res)
+	}
+	if res, err := s.Query(`SELECT count(free) FROM db0.rp0.mem; SELECT count(value) FROM db0.rp0.cpu`); err != nil {
+		t.Fatal(err)
+	} else if exp := `{"results":[{"statement_id":0,"series":[{"name":"mem","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]}]},` +
+		`{"statement_id":1,"series":[{"name":"cpu","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",3]]}]}]}`; exp != res {
+		t.Fatalf("unexpected results\nexp: %s\ngot: %s\n", exp, res)
+	}
+}
+
 // Ensure the server can query with default databases (via param) and default retention policy
 func TestServer_Query_DefaultDBAndRP(t *testing.T) {
 
@@ -5139,7 +5371,7 @@ func TestServer_Query_SubqueryMath(t *testing.T) {
 	}
 }
 
-func TestServer_Query_PercentileDerivative(t *testing.T) {
+func TestServer_Query_Join(t *testing.T) {
 	t.Parallel()
 	s := OpenServer(NewConfig())
 	defer s.Close()
@@ -5149,11 +5381,14 @@ func TestServer_Query_PercentileDerivative(t *testing.T) {
 	}
 
 	writes := []string{
-		fmt.Sprintf(`air temperature=12 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
-		fmt.Sprintf(`air temperature=34 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
-		fmt.Sprintf(`air temperature=78 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:20Z").UnixNano()),
-		fmt.Sprintf(`air temperature=89 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:30Z").UnixNano()),
-		fmt.Sprintf(`air temperature=101 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:40Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=a,core=0 usage=50 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=a,core=0 usage=80 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=b,core=0 usage=30 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=c,core=0 usage=10 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu_limits,host=a max=100 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu_limits,host=a max=200 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+		fmt.Sprintf(`cpu_limits,host=b max=60 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu_limits,host=d max=40 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
 	}
 	test := NewTest("db0", "rp0")
 	test.writes = Writes{
@@ -5162,10 +5397,64 @@ func TestServer_Query_PercentileDerivative(t *testing.T) {
 
 	test.addQueries([]*Query{
 		&Query{
-			name:    "nth percentile of derivative",
+			name:    "inner join grouped by key",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT percentile(derivative, 95) FROM (SELECT derivative(temperature, 1s) FROM air) WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:00:50Z'`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"air","columns":["time","percentile"],"values":[["2000-01-01T00:00:20Z",4.4]]}]}]}`,
+			command: `SELECT c.usage / l.max AS ratio FROM cpu AS c JOIN cpu_limits AS l ON host GROUP BY host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"tags":{"host":"a"},"columns":["time","ratio"],"values":[["2000-01-01T00:00:00Z",0.5],["2000-01-01T00:00:10Z",0.4]]},{"tags":{"host":"b"},"columns":["time","ratio"],"values":[["2000-01-01T00:00:00Z",0.5]]}]}]}`,
+		},
+		&Query{
+			name:    "inner join",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT host, c.usage, l.max FROM cpu AS c INNER JOIN cpu_limits AS l ON host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"columns":["time","host","c.usage","l.max"],"values":[["2000-01-01T00:00:00Z","a",50,100],["2000-01-01T00:00:00Z","b",30,60],["2000-01-01T00:00:10Z","a",80,200]]}]}]}`,
+		},
+		&Query{
+			name:    "left join",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT c.usage, l.max FROM cpu AS c LEFT JOIN cpu_limits AS l ON host GROUP BY host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"tags":{"host":"a"},"columns":["time","c.usage","l.max"],"values":[["2000-01-01T00:00:00Z",50,100],["2000-01-01T00:00:10Z",80,200]]},{"tags":{"host":"b"},"columns":["time","c.usage","l.max"],"values":[["2000-01-01T00:00:00Z",30,60]]},{"tags":{"host":"c"},"columns":["time","c.usage","l.max"],"values":[["2000-01-01T00:00:00Z",10,null]]}]}]}`,
+		},
+		&Query{
+			name:    "full join",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT c.host, l.host, c.usage, l.max FROM cpu AS c FULL OUTER JOIN cpu_limits AS l ON host WHERE time = '2000-01-01T00:00:00Z'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"columns":["time","c.host","l.host","c.usage","l.max"],"values":[["2000-01-01T00:00:00Z","a","a",50,100],["2000-01-01T00:00:00Z","b","b",30,60],["2000-01-01T00:00:00Z","c",null,10,null],["2000-01-01T00:00:00Z",null,"d",null,40]]}]}]}`,
+		},
+		&Query{
+			name:    "wildcard",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT * FROM cpu JOIN cpu_limits ON host WHERE host = 'a'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"columns":["time","cpu.core","cpu.usage","cpu_limits.max","host"],"values":[["2000-01-01T00:00:00Z","0",50,100,"a"],["2000-01-01T00:00:10Z","0",80,200,"a"]]}]}]}`,
+		},
+		&Query{
+			name:    "condition on both sides",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT c.usage FROM cpu AS c JOIN cpu_limits AS l ON host WHERE c.usage >= l.max / 2`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"columns":["time","c.usage"],"values":[["2000-01-01T00:00:00Z",50],["2000-01-01T00:00:00Z",30]]}]}]}`,
+		},
+		&Query{
+			name:    "aggregate",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT sum(c.usage), max(l.max) FROM cpu AS c JOIN cpu_limits AS l ON host WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:00:20Z' GROUP BY time(10s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"columns":["time","sum","max"],"values":[["2000-01-01T00:00:00Z",80,100],["2000-01-01T00:00:10Z",80,200]]}]}]}`,
+		},
+		&Query{
+			name:    "join subqueries on time buckets",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT c.mean / l.max AS ratio FROM (SELECT mean(usage) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:00:20Z' GROUP BY time(20s), host) AS c JOIN cpu_limits AS l ON host GROUP BY host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"tags":{"host":"a"},"columns":["time","ratio"],"values":[["2000-01-01T00:00:00Z",0.65]]},{"tags":{"host":"b"},"columns":["time","ratio"],"values":[["2000-01-01T00:00:00Z",0.5]]}]}]}`,
+		},
+		&Query{
+			name:    "descending",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT c.usage, l.max FROM cpu AS c RIGHT JOIN cpu_limits AS l ON host GROUP BY host ORDER BY time DESC`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"tags":{"host":"d"},"columns":["time","c.usage","l.max"],"values":[["2000-01-01T00:00:00Z",null,40]]},{"tags":{"host":"b"},"columns":["time","c.usage","l.max"],"values":[["2000-01-01T00:00:00Z",30,60]]},{"tags":{"host":"a"},"columns":["time","c.usage","l.max"],"values":[["2000-01-01T00:00:10Z",80,200],["2000-01-01T00:00:00Z",50,100]]}]}]}`,
+		},
+		&Query{
+			name:    "group by a tag that is not a join key",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT c.usage FROM cpu AS c JOIN cpu_limits AS l ON host GROUP BY core`,
+			exp:     `{"results":[{"statement_id":0,"error":"cannot group a join by core, it is not a join key"}]}`,
 		},
 	}...)
 
@@ -5187,7 +5476,7 @@ func TestServer_Query_PercentileDerivative(t *testing.T) {
 	}
 }
 
-func TestServer_Query_UnderscoreMeasurement(t *testing.T) {
+func TestServer_Query_OrderByValues(t *testing.T) {
 	t.Parallel()
 	s := OpenServer(NewConfig())
 	defer s.Close()
@@ -5197,7 +5486,13 @@ func TestServer_Query_UnderscoreMeasurement(t *testing.T) {
 	}
 
 	writes := []string{
-		fmt.Sprintf(`_air temperature=1i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=a usage=10 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=a usage=90 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=b usage=50 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=b usage=60 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=c usage=70 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=c usage=20 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=d usage=30 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
 	}
 	test := NewTest("db0", "rp0")
 	test.writes = Writes{
@@ -5206,10 +5501,52 @@ func TestServer_Query_UnderscoreMeasurement(t *testing.T) {
 
 	test.addQueries([]*Query{
 		&Query{
-			name:    "select underscore with underscore prefix",
+			name:    "top groups by aggregate",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT * FROM _air`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"_air","columns":["time","temperature"],"values":[["2000-01-01T00:00:00Z",1]]}]}]}`,
+			command: `SELECT max(usage) FROM cpu GROUP BY host ORDER BY max(usage) DESC LIMIT 2`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"a"},"columns":["time","max"],"values":[["2000-01-01T00:00:10Z",90]]},{"name":"cpu","tags":{"host":"c"},"columns":["time","max"],"values":[["2000-01-01T00:00:00Z",70]]}]}]}`,
+		},
+		&Query{
+			name:    "aggregate alias",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT max(usage) AS peak FROM cpu GROUP BY host ORDER BY peak LIMIT 1`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"d"},"columns":["time","peak"],"values":[["2000-01-01T00:00:00Z",30]]}]}]}`,
+		},
+		&Query{
+			name:    "raw field with offset",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT host, usage FROM cpu ORDER BY usage LIMIT 3 OFFSET 1`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","host","usage"],"values":[["2000-01-01T00:00:10Z","c",20],["2000-01-01T00:00:00Z","d",30],["2000-01-01T00:00:00Z","b",50]]}]}]}`,
+		},
+		&Query{
+			name:    "tag descending",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT last(usage) FROM cpu GROUP BY host ORDER BY host DESC`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"d"},"columns":["time","last"],"values":[["2000-01-01T00:00:00Z",30]]},{"name":"cpu","tags":{"host":"c"},"columns":["time","last"],"values":[["2000-01-01T00:00:10Z",20]]},{"name":"cpu","tags":{"host":"b"},"columns":["time","last"],"values":[["2000-01-01T00:00:10Z",60]]},{"name":"cpu","tags":{"host":"a"},"columns":["time","last"],"values":[["2000-01-01T00:00:10Z",90]]}]}]}`,
+		},
+		&Query{
+			name:    "series limit",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT usage FROM cpu GROUP BY host ORDER BY usage DESC LIMIT 1 SLIMIT 2 SOFFSET 1`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"c"},"columns":["time","usage"],"values":[["2000-01-01T00:00:00Z",70]]},{"name":"cpu","tags":{"host":"b"},"columns":["time","usage"],"values":[["2000-01-01T00:00:10Z",60]]}]}]}`,
+		},
+		&Query{
+			name:    "series limit without limit",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT usage FROM cpu GROUP BY host ORDER BY usage DESC SLIMIT 2`,
+			exp:     `{"results":[{"statement_id":0,"error":"ORDER BY a field or a tag with SLIMIT or SOFFSET requires LIMIT and SLIMIT"}]}`,
+		},
+		&Query{
+			name:    "unknown field",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT usage FROM cpu ORDER BY idle`,
+			exp:     `{"results":[{"statement_id":0,"error":"ORDER BY idle must be a selected field or a GROUP BY tag"}]}`,
+		},
+		&Query{
+			name:    "subquery",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT max FROM (SELECT max(usage) FROM cpu GROUP BY host ORDER BY max DESC)`,
+			exp:     `{"results":[{"statement_id":0,"error":"subqueries can only be ordered by time"}]}`,
 		},
 	}...)
 
@@ -5231,66 +5568,1231 @@ func TestServer_Query_UnderscoreMeasurement(t *testing.T) {
 	}
 }
 
-func TestServer_Write_Precision(t *testing.T) {
+func TestServer_Query_MaxSelectMemory(t *testing.T) {
 	t.Parallel()
-	s := OpenServer(NewConfig())
+	c := NewConfig()
+	c.Coordinator.MaxSelectMemory = toml.Size(4096)
+	c.Coordinator.QuerySpillDir = t.TempDir()
+	s := OpenServer(c)
 	defer s.Close()
 
 	if err := s.CreateDatabaseAndRetentionPolicy("db0", NewRetentionPolicySpec("rp0", 1, 0), true); err != nil {
 		t.Fatal(err)
 	}
 
-	writes := []struct {
-		write  string
-		params url.Values
-	}{
-		{
-			write: fmt.Sprintf("air_n0_precision temperature=1 %d", mustParseTime(time.RFC3339Nano, "2000-01-01T12:34:56.789012345Z").UnixNano()),
-		},
-		{
-			write:  fmt.Sprintf("air_n1_precision temperature=1.1 %d", mustParseTime(time.RFC3339Nano, "2000-01-01T12:34:56.789012345Z").UnixNano()),
-			params: url.Values{"precision": []string{"n"}},
-		},
-		{
-			write:  fmt.Sprintf("air_u_precision temperature=100 %d", mustParseTime(time.RFC3339Nano, "2000-01-01T12:34:56.789012345Z").Truncate(time.Microsecond).UnixNano()/int64(time.Microsecond)),
-			params: url.Values{"precision": []string{"u"}},
-		},
-		{
-			write:  fmt.Sprintf("air_ms_precision temperature=200 %d", mustParseTime(time.RFC3339Nano, "2000-01-01T12:34:56.789012345Z").Truncate(time.Millisecond).UnixNano()/int64(time.Millisecond)),
-			params: url.Values{"precision": []string{"ms"}},
-		},
-		{
-			write:  fmt.Sprintf("air_s_precision temperature=300 %d", mustParseTime(time.RFC3339Nano, "2000-01-01T12:34:56.789012345Z").Truncate(time.Second).UnixNano()/int64(time.Second)),
-			params: url.Values{"precision": []string{"s"}},
-		},
-		{
-			write:  fmt.Sprintf("air_m_precision temperature=400 %d", mustParseTime(time.RFC3339Nano, "2000-01-01T12:34:56.789012345Z").Truncate(time.Minute).UnixNano()/int64(time.Minute)),
-			params: url.Values{"precision": []string{"m"}},
-		},
-		{
-			write:  fmt.Sprintf("air_h_precision temperature=500 %d", mustParseTime(time.RFC3339Nano, "2000-01-01T12:34:56.789012345Z").Truncate(time.Hour).UnixNano()/int64(time.Hour)),
-			params: url.Values{"precision": []string{"h"}},
-		},
+	// Write more rows than fit in the memory of a query, with unique values.
+	start := mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z")
+	writes := make([]string, 100)
+	values := make([]string, 100)
+	for i := range writes {
+		host, usage := string(rune('a'+i%2)), i*7%100
+		ts := start.Add(time.Duration(i) * time.Second)
+		writes[i] = fmt.Sprintf(`cpu,host=%s usage=%d %d`, host, usage, ts.UnixNano())
+		values[99-usage] = fmt.Sprintf(`["%s","%s",%d]`, ts.Format(time.RFC3339Nano), host, usage)
+	}
+	if _, err := s.Write("db0", "rp0", strings.Join(writes, "\n"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	query := func(q, exp string) {
+		t.Helper()
+		if res, err := s.Query(q); err != nil {
+			t.Fatal(err)
+		} else if res != exp {
+			t.Fatalf("unexpected results\nexp: %s\ngot: %s", exp, res)
+		}
+	}
+
+	// Sorting all of the rows spills them to temporary files.
+	query(`SELECT host, usage FROM db0.rp0.cpu ORDER BY usage DESC`,
+		`{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","host","usage"],"values":[`+strings.Join(values, ",")+`]}]}]}`)
+	query(`SELECT host, usage FROM db0.rp0.cpu ORDER BY usage DESC LIMIT 2 OFFSET 1`,
+		`{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","host","usage"],"values":[`+strings.Join(values[1:3], ",")+`]}]}]}`)
+	if files, err := os.ReadDir(c.Coordinator.QuerySpillDir); err != nil {
+		t.Fatal(err)
+	} else if len(files) != 0 {
+		t.Fatalf("unexpected spill files after the query: %d", len(files))
+	}
+
+	// Only the rows of the series within the series limits are kept.
+	series := make([]string, 40)
+	for i := range series {
+		series[i] = fmt.Sprintf(`mem,host=h%02d used=%d %d`, i, i*7%100, start.UnixNano())
+	}
+	if _, err := s.Write("db0", "rp0", strings.Join(series, "\n"), nil); err != nil {
+		t.Fatal(err)
+	}
+	query(`SELECT used FROM db0.rp0.mem GROUP BY host ORDER BY used DESC LIMIT 1 SLIMIT 2 SOFFSET 1`,
+		`{"results":[{"statement_id":0,"series":[{"name":"mem","tags":{"host":"h28"},"columns":["time","used"],"values":[["2000-01-01T00:00:00Z",96]]},{"name":"mem","tags":{"host":"h13"},"columns":["time","used"],"values":[["2000-01-01T00:00:00Z",91]]}]}]}`)
+
+	// Aggregates buffering the points fail.
+	if res, err := s.Query(`SELECT median(usage) FROM db0.rp0.cpu`); err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(res, `"error":"max-select-memory limit exceeded: (`) {
+		t.Fatalf("unexpected results: %s", res)
+	}
+
+	// Aggregates with few points in a window are within the limit.
+	query(`SELECT median(usage) FROM db0.rp0.cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:00:04Z'`,
+		`{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","median"],"values":[["2000-01-01T00:00:00Z",10.5]]}]}]}`)
+}
+
+func TestServer_Query_StringFunctions(t *testing.T) {
+	t.Parallel()
+	s := OpenServer(NewConfig())
+	defer s.Close()
+
+	if err := s.CreateDatabaseAndRetentionPolicy("db0", NewRetentionPolicySpec("rp0", 1, 0), true); err != nil {
+		t.Fatal(err)
 	}
 
+	writes := []string{
+		fmt.Sprintf(`logs,host=Server01.east msg=" disk full ",code="42" %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`logs,host=server02.west msg="cpu hot",code="7.5" %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+	}
 	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
 
 	test.addQueries([]*Query{
 		&Query{
-			name:    "point with nanosecond precision time - no precision specified on write",
-			command: `SELECT * FROM air_n0_precision`,
+			name:    "case and trim",
 			params:  url.Values{"db": []string{"db0"}},
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"air_n0_precision","columns":["time","temperature"],"values":[["2000-01-01T12:34:56.789012345Z",1]]}]}]}`,
+			command: `SELECT lower(host), upper(trim(msg)), strlen(msg) FROM logs`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"logs","columns":["time","lower","upper","strlen"],"values":[["2000-01-01T00:00:00Z","server01.east","DISK FULL",11],["2000-01-01T00:00:10Z","server02.west","CPU HOT",7]]}]}]}`,
 		},
 		&Query{
-			name:    "point with nanosecond precision time",
-			command: `SELECT * FROM air_n1_precision`,
+			name:    "substr concat replace split_part",
 			params:  url.Values{"db": []string{"db0"}},
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"air_n1_precision","columns":["time","temperature"],"values":[["2000-01-01T12:34:56.789012345Z",1.1]]}]}]}`,
+			command: `SELECT substr(host, 1, 8) AS s, concat(split_part(host, '.', 2), ':', code) AS c, replace(msg, ' ', '_') AS r FROM logs`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"logs","columns":["time","s","c","r"],"values":[["2000-01-01T00:00:00Z","Server01","east:42","_disk_full_"],["2000-01-01T00:00:10Z","server02","west:7.5","cpu_hot"]]}]}]}`,
 		},
 		&Query{
-			name:    "point with microsecond precision time",
-			command: `SELECT * FROM air_u_precision`,
+			name:    "regexp and conversion",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT regexp_extract(host, '([0-9]+)', 1) AS n, regexp_match(msg, 'hot') AS hot, str_to_float(code) AS f, str_to_int(code) AS i FROM logs`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"logs","columns":["time","n","hot","f","i"],"values":[["2000-01-01T00:00:00Z","01",false,42,42],["2000-01-01T00:00:10Z","02",true,7.5,null]]}]}]}`,
+		},
+		&Query{
+			name:    "where predicate",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT msg FROM logs WHERE lower(host) = 'server01.east' OR regexp_match(msg, '^cpu') = true`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"logs","columns":["time","msg"],"values":[["2000-01-01T00:00:00Z"," disk full "],["2000-01-01T00:00:10Z","cpu hot"]]}]}]}`,
+		},
+		&Query{
+			name:    "where boolean function",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT msg FROM logs WHERE regexp_match(msg, '^cpu')`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"logs","columns":["time","msg"],"values":[["2000-01-01T00:00:10Z","cpu hot"]]}]}]}`,
+		},
+		&Query{
+			name:    "where boolean function on tag",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT msg FROM logs WHERE regexp_match(host, 'east$') OR code = '7.5'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"logs","columns":["time","msg"],"values":[["2000-01-01T00:00:00Z"," disk full "],["2000-01-01T00:00:10Z","cpu hot"]]}]}]}`,
+		},
+		&Query{
+			name:    "where non-boolean function",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT msg FROM logs WHERE lower(host)`,
+			exp:     `{"results":[{"statement_id":0,"error":"invalid condition expression: lower(host)"}]}`,
+		},
+		&Query{
+			name:    "where predicate on tag",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT msg FROM logs WHERE split_part(host, '.', 2) = 'west'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"logs","columns":["time","msg"],"values":[["2000-01-01T00:00:10Z","cpu hot"]]}]}]}`,
+		},
+		&Query{
+			name:    "invalid argument type",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT substr(host, msg) FROM logs`,
+			exp:     `{"results":[{"statement_id":0,"error":"invalid argument type for the second argument in substr(): string"}]}`,
+		},
+		&Query{
+			name:    "invalid regular expression",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT regexp_match(msg, '(') FROM logs`,
+			exp:     `{"results":[{"statement_id":0,"error":"invalid regular expression for regexp_match: error parsing regexp: missing closing ): ` + "`(`" + `"}]}`,
+		},
+	}...)
+
+	if err := test.init(s); err != nil {
+		t.Fatalf("test init failed: %s", err)
+	}
+
+	for _, query := range test.queries {
+		t.Run(query.name, func(t *testing.T) {
+			if query.skip {
+				t.Skipf("SKIP:: %s", query.name)
+			}
+			if err := query.Execute(s); err != nil {
+				t.Error(query.Error(err))
+			} else if !query.success() {
+				t.Error(query.failureMessage())
+			}
+		})
+	}
+}
+
+func TestServer_Query_ConditionalExpressions(t *testing.T) {
+	t.Parallel()
+	s := OpenServer(NewConfig())
+	defer s.Close()
+
+	if err := s.CreateDatabaseAndRetentionPolicy("db0", NewRetentionPolicySpec("rp0", 1, 0), true); err != nil {
+		t.Fatal(err)
+	}
+
+	writes := []string{
+		fmt.Sprintf(`air,host=server01 temp=85,hum=40i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`air,host=server01 temp=60 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+		fmt.Sprintf(`air,host=server02 temp=90,hum=0i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:20Z").UnixNano()),
+		fmt.Sprintf(`air,host=server02 temp=20,hum=70i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:30Z").UnixNano()),
+	}
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		&Query{
+			name:    "case in projection",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT CASE WHEN temp > 80 THEN 'hot' WHEN temp > 50 THEN 'warm' ELSE 'ok' END AS status FROM air`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"air","columns":["time","status"],"values":[["2000-01-01T00:00:00Z","hot"],["2000-01-01T00:00:10Z","warm"],["2000-01-01T00:00:20Z","hot"],["2000-01-01T00:00:30Z","ok"]]}]}]}`,
+		},
+		&Query{
+			name:    "case without else on tag",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT temp, CASE WHEN host = 'server01' AND temp > 80 THEN temp * 2 END AS double FROM air`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"air","columns":["time","temp","double"],"values":[["2000-01-01T00:00:00Z",85,170],["2000-01-01T00:00:10Z",60,null],["2000-01-01T00:00:20Z",90,null],["2000-01-01T00:00:30Z",20,null]]}]}]}`,
+		},
+		&Query{
+			name:    "coalesce and nullif",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT temp, coalesce(hum, -1) AS hum, nullif(hum, 0) AS nonzero FROM air`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"air","columns":["time","temp","hum","nonzero"],"values":[["2000-01-01T00:00:00Z",85,40,40],["2000-01-01T00:00:10Z",60,-1,null],["2000-01-01T00:00:20Z",90,0,null],["2000-01-01T00:00:30Z",20,70,70]]}]}]}`,
+		},
+		&Query{
+			name:    "conditional sum",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT sum(CASE WHEN temp > 80 THEN 1 ELSE 0 END) AS hot, count(CASE WHEN temp > 50 THEN temp END) AS warm FROM air`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"air","columns":["time","hot","warm"],"values":[["1970-01-01T00:00:00Z",2,3]]}]}]}`,
+		},
+		&Query{
+			name:    "aggregates of conditional functions grouped by tag",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT mean(coalesce(hum, temp)) AS hum, min(nullif(hum, 0)) AS lowest FROM air GROUP BY host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"air","tags":{"host":"server01"},"columns":["time","hum","lowest"],"values":[["1970-01-01T00:00:00Z",50,40]]},{"name":"air","tags":{"host":"server02"},"columns":["time","hum","lowest"],"values":[["1970-01-01T00:00:00Z",35,70]]}]}]}`,
+		},
+		&Query{
+			name:    "conditional sum by interval",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT sum(CASE WHEN host = 'server02' THEN temp END) FROM air WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:00:40Z' GROUP BY time(20s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"air","columns":["time","sum"],"values":[["2000-01-01T00:00:00Z",null],["2000-01-01T00:00:20Z",110]]}]}]}`,
+		},
+		&Query{
+			name:    "conditional aggregates of a subquery",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT sum(CASE WHEN t > 80 THEN 1 ELSE 0 END) AS hot, median(CASE WHEN t > 50 THEN t END) AS warm FROM (SELECT temp AS t FROM air)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"air","columns":["time","hot","warm"],"values":[["1970-01-01T00:00:00Z",2,85]]}]}]}`,
+		},
+		&Query{
+			name:    "case over aggregates",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT CASE WHEN max(temp) > 80 THEN 'alert' ELSE 'ok' END AS status FROM air WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:00:40Z' GROUP BY time(10s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"air","columns":["time","status"],"values":[["2000-01-01T00:00:00Z","alert"],["2000-01-01T00:00:10Z","ok"],["2000-01-01T00:00:20Z","alert"],["2000-01-01T00:00:30Z","ok"]]}]}]}`,
+		},
+		&Query{
+			name:    "incompatible result types",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT CASE WHEN temp > 80 THEN 'hot' ELSE temp END FROM air`,
+			exp:     `{"results":[{"statement_id":0,"error":"type error: CASE WHEN temp::float \u003e 80 THEN 'hot' ELSE temp::float END: incompatible types: string and float"}]}`,
+		},
+	}...)
+
+	if err := test.init(s); err != nil {
+		t.Fatalf("test init failed: %s", err)
+	}
+
+	for _, query := range test.queries {
+		t.Run(query.name, func(t *testing.T) {
+			if query.skip {
+				t.Skipf("SKIP:: %s", query.name)
+			}
+			if err := query.Execute(s); err != nil {
+				t.Error(query.Error(err))
+			} else if !query.success() {
+				t.Error(query.failureMessage())
+			}
+		})
+	}
+}
+
+func TestServer_Query_Having(t *testing.T) {
+	t.Parallel()
+	s := OpenServer(NewConfig())
+	defer s.Close()
+
+	if err := s.CreateDatabaseAndRetentionPolicy("db0", NewRetentionPolicySpec("rp0", 1, 0), true); err != nil {
+		t.Fatal(err)
+	}
+
+	writes := []string{
+		fmt.Sprintf(`cpu,host=server01 value=95 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server01 value=70 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server01 value=92 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:20Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server02 value=99 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server02 value=91 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server02 value=50 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:20Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server03 value=10 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server03 value=20 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+	}
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		&Query{
+			name:    "having on an aggregate",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT mean(value) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:00:30Z' GROUP BY time(10s), host HAVING mean(value) > 90`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","mean"],"values":[["2000-01-01T00:00:00Z",95],["2000-01-01T00:00:20Z",92]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","mean"],"values":[["2000-01-01T00:00:00Z",99],["2000-01-01T00:00:10Z",91]]}]}]}`,
+		},
+		&Query{
+			name:    "having on an alias and an aggregate that is not selected",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT max(value) AS peak FROM cpu GROUP BY host HAVING peak > 90 AND count(value) >= 3`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","peak"],"values":[["1970-01-01T00:00:00Z",95]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","peak"],"values":[["1970-01-01T00:00:00Z",99]]}]}]}`,
+		},
+		&Query{
+			name:    "limits are applied after having",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT mean(value) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:00:30Z' GROUP BY time(10s), host HAVING mean(value) > 90 LIMIT 1 OFFSET 1 SLIMIT 1 SOFFSET 1`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server02"},"columns":["time","mean"],"values":[["2000-01-01T00:00:10Z",91]]}]}]}`,
+		},
+		&Query{
+			name:    "having in a subquery",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT count(mean) FROM (SELECT mean(value) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:00:30Z' GROUP BY time(10s), host HAVING mean(value) > 90)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",4]]}]}]}`,
+		},
+		&Query{
+			name:    "having without an aggregate",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT value FROM cpu HAVING value > 90`,
+			exp:     `{"results":[{"statement_id":0,"error":"HAVING requires at least one aggregate function"}]}`,
+		},
+		&Query{
+			name:    "having that is not a boolean",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT mean(value) FROM cpu GROUP BY host HAVING mean(value) + 1`,
+			exp:     `{"results":[{"statement_id":0,"error":"HAVING must be a boolean expression, got float"}]}`,
+		},
+	}...)
+
+	if err := test.init(s); err != nil {
+		t.Fatalf("test init failed: %s", err)
+	}
+
+	for _, query := range test.queries {
+		t.Run(query.name, func(t *testing.T) {
+			if query.skip {
+				t.Skipf("SKIP:: %s", query.name)
+			}
+			if err := query.Execute(s); err != nil {
+				t.Error(query.Error(err))
+			} else if !query.success() {
+				t.Error(query.failureMessage())
+			}
+		})
+	}
+}
+
+func TestServer_Query_TimeShift(t *testing.T) {
+	t.Parallel()
+	s := OpenServer(NewConfig())
+	defer s.Close()
+
+	if err := s.CreateDatabaseAndRetentionPolicy("db0", NewRetentionPolicySpec("rp0", 1, 0), true); err != nil {
+		t.Fatal(err)
+	}
+
+	writes := []string{
+		fmt.Sprintf(`cpu,host=server01 value=10 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server01 value=20 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server01 value=40 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:40Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server02 value=50 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:20Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server01 value=15 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T01:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server01 value=35 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T01:00:10Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server02 value=70 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T01:00:50Z").UnixNano()),
+	}
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		&Query{
+			name:    "shifted aggregate in a math expression",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT mean(value), timeshift(mean(value), 1h) AS prev, mean(value) - timeshift(mean(value), 1h) AS diff FROM cpu WHERE host = 'server01' AND time >= '2000-01-01T01:00:00Z' AND time < '2000-01-01T01:01:00Z' GROUP BY time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","mean","prev","diff"],"values":[["2000-01-01T01:00:00Z",25,15,10],["2000-01-01T01:00:30Z",null,40,null]]}]}]}`,
+		},
+		&Query{
+			name:    "shift that is not a multiple of the interval",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT timeshift(sum(value), 59m40s) FROM cpu WHERE host = 'server01' AND time >= '2000-01-01T00:59:40Z' AND time < '2000-01-01T01:00:40Z' GROUP BY time(30s) fill(none)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","timeshift"],"values":[["2000-01-01T00:59:30Z",30],["2000-01-01T01:00:00Z",40]]}]}]}`,
+		},
+		&Query{
+			name:    "shifted aggregate grouped by tag",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT max(value) AS cur, timeshift(max(value), 1h) AS prev FROM cpu WHERE time >= '2000-01-01T01:00:00Z' AND time < '2000-01-01T01:01:00Z' GROUP BY time(1m), host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","cur","prev"],"values":[["2000-01-01T01:00:00Z",35,40]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","cur","prev"],"values":[["2000-01-01T01:00:00Z",70,50]]}]}]}`,
+		},
+		&Query{
+			name:    "shifted aggregate of a subquery",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT timeshift(sum(v), 1h) FROM (SELECT value AS v FROM cpu) WHERE time >= '2000-01-01T01:00:00Z' AND time < '2000-01-01T01:01:00Z' GROUP BY time(1m)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","timeshift"],"values":[["2000-01-01T01:00:00Z",120]]}]}]}`,
+		},
+		&Query{
+			name:    "shift without an interval",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT timeshift(mean(value), 1h) FROM cpu`,
+			exp:     `{"results":[{"statement_id":0,"error":"timeshift aggregate requires a GROUP BY interval"}]}`,
+		},
+	}...)
+
+	if err := test.init(s); err != nil {
+		t.Fatalf("test init failed: %s", err)
+	}
+
+	for _, query := range test.queries {
+		t.Run(query.name, func(t *testing.T) {
+			if query.skip {
+				t.Skipf("SKIP:: %s", query.name)
+			}
+			if err := query.Execute(s); err != nil {
+				t.Error(query.Error(err))
+			} else if !query.success() {
+				t.Error(query.failureMessage())
+			}
+		})
+	}
+}
+
+func TestServer_Query_Geo(t *testing.T) {
+	t.Parallel()
+	c := NewConfig()
+	c.Coordinator.GeoCellLevel = 12
+	s := OpenServer(c)
+	defer s.Close()
+
+	if err := s.CreateDatabaseAndRetentionPolicy("db0", NewRetentionPolicySpec("rp0", 1, 0), true); err != nil {
+		t.Fatal(err)
+	}
+
+	writes := []string{
+		fmt.Sprintf(`gps,vehicle=v1 lat=52.52,lon=13.405 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`gps,vehicle=v1 lat=52.53,lon=13.41 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:00Z").UnixNano()),
+		fmt.Sprintf(`gps,vehicle=v2 lat=48.8566,lon=2.3522 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`gps,vehicle=v3 lat=0.01,lon=179.99 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`gps,vehicle=v4 speed=10 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+	}
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	berlin := geo.CellIDFromLatLon(52.52, 13.405, 12).Token()
+	test.addQueries([]*Query{
+		&Query{
+			name:    "points are tagged with their cell",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SHOW TAG VALUES FROM gps WITH KEY = s2_cell WHERE vehicle = 'v1' OR vehicle = 'v4'`,
+			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"gps","columns":["key","value"],"values":[["s2_cell","%s"],["s2_cell","%s"]]}]}]}`, berlin, geo.CellIDFromLatLon(52.53, 13.41, 12).Token()),
+		},
+		&Query{
+			name:    "within radius",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT vehicle, lat, lon FROM gps WHERE geo_within_radius(52.52, 13.405, 2000)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"gps","columns":["time","vehicle","lat","lon"],"values":[["2000-01-01T00:00:00Z","v1",52.52,13.405],["2000-01-01T00:01:00Z","v1",52.53,13.41]]}]}]}`,
+		},
+		&Query{
+			name:    "within a smaller radius",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT count(lat) FROM gps WHERE geo_within_radius(52.52, 13.405, 500) GROUP BY s2_cell`,
+			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"gps","tags":{"s2_cell":"%s"},"columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]}]}]}`, berlin),
+		},
+		&Query{
+			name:    "bounding box across the antimeridian",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT vehicle, lat, lon FROM gps WHERE geo_bbox(-1, 179, 1, -179)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"gps","columns":["time","vehicle","lat","lon"],"values":[["2000-01-01T00:00:00Z","v3",0.01,179.99]]}]}]}`,
+		},
+		&Query{
+			name:    "distance",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT round(geo_distance(lat, lon, 52.52, 13.405)) FROM gps WHERE vehicle = 'v2'`,
+			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"gps","columns":["time","round"],"values":[["2000-01-01T00:00:00Z",%v]]}]}]}`, math.Round(geo.Distance(48.8566, 2.3522, 52.52, 13.405))),
+		},
+		&Query{
+			name:    "invalid radius",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT lat FROM gps WHERE geo_within_radius(52.52, 13.405, -1)`,
+			exp:     `{"results":[{"statement_id":0,"error":"radius must be greater than 0 in geo_within_radius(), got -1"}]}`,
+		},
+	}...)
+
+	if err := test.init(s); err != nil {
+		t.Fatalf("test init failed: %s", err)
+	}
+
+	for _, query := range test.queries {
+		t.Run(query.name, func(t *testing.T) {
+			if query.skip {
+				t.Skipf("SKIP:: %s", query.name)
+			}
+			if err := query.Execute(s); err != nil {
+				t.Error(query.Error(err))
+			} else if !query.success() {
+				t.Error(query.failureMessage())
+			}
+		})
+	}
+}
+
+// Ensure the points written before they were tagged with their cell, or
+// while they were tagged with cells of another level, are found by the geo
+// functions.
+func TestServer_Query_Geo_UntaggedPoints(t *testing.T) {
+	t.Parallel()
+	c := NewConfig()
+	c.Coordinator.GeoCellLevel = 12
+	s := OpenServer(c)
+	defer s.Close()
+
+	if err := s.CreateDatabaseAndRetentionPolicy("db0", NewRetentionPolicySpec("rp0", 1, 0), true); err != nil {
+		t.Fatal(err)
+	}
+
+	pw := s.(*LocalServer).PointsWriter
+	for _, w := range []struct {
+		level int
+		data  string
+	}{
+		{level: 0, data: `gps,vehicle=v1 lat=52.52,lon=13.405 946684800000000000`},
+		{level: 10, data: `gps,vehicle=v2 lat=52.521,lon=13.406 946684801000000000`},
+		{level: 14, data: `gps,vehicle=v3 lat=52.522,lon=13.407 946684802000000000`},
+		{level: 0, data: `gps,vehicle=v4 lat=48.8566,lon=2.3522 946684803000000000`},
+		{level: 12, data: `gps,vehicle=v5 lat=52.523,lon=13.408 946684804000000000`},
+	} {
+		pw.GeoIndex.CellLevel = w.level
+		if _, err := s.Write("db0", "rp0", w.data, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pw.GeoIndex.CellLevel = 12
+
+	test := NewTest("db0", "rp0")
+	test.addQueries([]*Query{
+		&Query{
+			name:    "within radius",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT vehicle, lat, lon FROM gps WHERE geo_within_radius(52.52, 13.405, 2000)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"gps","columns":["time","vehicle","lat","lon"],"values":[["2000-01-01T00:00:00Z","v1",52.52,13.405],["2000-01-01T00:00:01Z","v2",52.521,13.406],["2000-01-01T00:00:02Z","v3",52.522,13.407],["2000-01-01T00:00:04Z","v5",52.523,13.408]]}]}]}`,
+		},
+		&Query{
+			name:    "bounding box",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT count(lat) FROM gps WHERE geo_bbox(48, 2, 49, 3)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"gps","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]}]}]}`,
+		},
+	}...)
+
+	for _, query := range test.queries {
+		t.Run(query.name, func(t *testing.T) {
+			if query.skip {
+				t.Skipf("SKIP:: %s", query.name)
+			}
+			if err := query.Execute(s); err != nil {
+				t.Error(query.Error(err))
+			} else if !query.success() {
+				t.Error(query.failureMessage())
+			}
+		})
+	}
+}
+
+func TestServer_Query_Prepared(t *testing.T) {
+	t.Parallel()
+	s := OpenServer(NewConfig())
+	defer s.Close()
+
+	if err := s.CreateDatabaseAndRetentionPolicy("db0", NewRetentionPolicySpec("rp0", 1, 0), true); err != nil {
+		t.Fatal(err)
+	}
+
+	writes := []string{
+		fmt.Sprintf(`cpu,host=a value=1 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=a value=2 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=b value=3 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:02:00Z").UnixNano()),
+	}
+	if _, err := s.Write("db0", "rp0", strings.Join(writes, "\n"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	v := url.Values{
+		"db": []string{"db0"},
+		"q":  []string{`SELECT value FROM cpu WHERE host = $host AND time >= $start AND time < $end`},
+	}
+	res, err := s.HTTPPost(s.URL()+"/query/prepare?"+v.Encode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var prepared struct {
+		Handle string   `json:"handle"`
+		Params []string `json:"params"`
+	}
+	if err := json.Unmarshal([]byte(res), &prepared); err != nil {
+		t.Fatalf("unexpected response: %s", res)
+	} else if prepared.Handle == "" || !reflect.DeepEqual(prepared.Params, []string{"host", "start", "end"}) {
+		t.Fatalf("unexpected response: %s", res)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		params string
+		exp    string
+	}{
+		{
+			name:   "first range",
+			params: `{"host": "a", "start": "2000-01-01T00:00:00Z", "end": "2000-01-01T00:01:30Z"}`,
+			exp:    `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:00Z",1],["2000-01-01T00:01:00Z",2]]}]}]}`,
+		},
+		{
+			name:   "second range",
+			params: `{"host": "a", "start": "2000-01-01T00:00:30Z", "end": "2000-01-01T00:02:30Z"}`,
+			exp:    `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:01:00Z",2]]}]}]}`,
+		},
+		{
+			name:   "missing parameter",
+			params: `{"host": "a", "start": "2000-01-01T00:00:30Z"}`,
+			exp:    `{"error":"error parsing query: missing parameter: end"}`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			v := url.Values{"handle": []string{prepared.Handle}, "params": []string{tt.params}}
+			if res, err := s.HTTPGet(s.URL() + "/query/execute?" + v.Encode()); err != nil {
+				t.Fatal(err)
+			} else if res != tt.exp {
+				t.Fatalf("unexpected results\nexp: %s\ngot: %s", tt.exp, res)
+			}
+		})
+	}
+
+	if _, err := s.HTTPGet(s.URL() + "/query/execute?handle=unknown"); err == nil || !strings.Contains(err.Error(), "prepared statement not found: unknown") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestServer_Query_ResultCache(t *testing.T) {
+	t.Parallel()
+	c := NewConfig()
+	c.Coordinator.ResultCacheMaxMemorySize = toml.Size(1 << 20)
+	s := OpenServer(c)
+	defer s.Close()
+
+	if err := s.CreateDatabaseAndRetentionPolicy("db0", NewRetentionPolicySpec("rp0", 1, 0), true); err != nil {
+		t.Fatal(err)
+	}
+
+	writes := []string{
+		fmt.Sprintf(`cpu,host=a value=1 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=a value=3 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:30Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=b value=4 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=a value=5 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:02:00Z").UnixNano()),
+	}
+	if _, err := s.Write("db0", "rp0", strings.Join(writes, "\n"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	const q = `SELECT mean(value), count(value) FROM db0.rp0.cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:03:00Z' GROUP BY time(1m) fill(none)`
+	query := func(exp string) {
+		t.Helper()
+		if res, err := s.Query(q); err != nil {
+			t.Fatal(err)
+		} else if res != exp {
+			t.Fatalf("unexpected results\nexp: %s\ngot: %s", exp, res)
+		}
+	}
+
+	// The second query reads the windows from the cache.
+	exp := `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","mean","count"],"values":[["2000-01-01T00:00:00Z",2,2],["2000-01-01T00:01:00Z",4,1],["2000-01-01T00:02:00Z",5,1]]}]}]}`
+	query(exp)
+	query(exp)
+
+	res, err := s.Query(`SHOW STATS FOR 'resultCache'`)
+	if err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(res, `"columns":["entries","evictions","hits","invalidations","memBytes","misses"],"values":[[6,0,6,0,`) {
+		t.Fatalf("unexpected statistics: %s", res)
+	}
+
+	// Writes remove the windows they change.
+	if _, err := s.Write("db0", "rp0", fmt.Sprintf(`cpu,host=b value=8 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:30Z").UnixNano()), nil); err != nil {
+		t.Fatal(err)
+	}
+	query(`{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","mean","count"],"values":[["2000-01-01T00:00:00Z",2,2],["2000-01-01T00:01:00Z",6,2],["2000-01-01T00:02:00Z",5,1]]}]}]}`)
+
+	// So do deletes.
+	if res, err := s.QueryWithParams(`DELETE FROM cpu WHERE host = 'a'`, url.Values{"db": []string{"db0"}}); err != nil {
+		t.Fatal(err)
+	} else if exp := `{"results":[{"statement_id":0}]}`; res != exp {
+		t.Fatalf("unexpected results\nexp: %s\ngot: %s", exp, res)
+	}
+	query(`{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","mean","count"],"values":[["2000-01-01T00:01:00Z",6,2]]}]}]}`)
+}
+
+func TestServer_Query_LastValueCache(t *testing.T) {
+	t.Parallel()
+	c := NewConfig()
+	c.Data.LastValueCacheEnabled = true
+	s := OpenServer(c)
+	defer s.Close()
+
+	if err := s.CreateDatabaseAndRetentionPolicy("db0", NewRetentionPolicySpec("rp0", 1, 0), true); err != nil {
+		t.Fatal(err)
+	}
+
+	writes := []string{
+		fmt.Sprintf(`cpu,host=a,region=east value=1,status="ok" %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=a,region=east value=2,status="warn" %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=b,region=east value=3 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:30Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=c,region=west value=4 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+	}
+	if _, err := s.Write("db0", "rp0", strings.Join(writes, "\n"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	test := NewTest("db0", "rp0")
+	test.addQueries([]*Query{
+		&Query{
+			name:    "last of all fields by series",
+			command: `SELECT last(*) FROM db0.rp0.cpu GROUP BY *`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"a","region":"east"},"columns":["time","last_status","last_value"],"values":[["1970-01-01T00:00:00Z","warn",2]]},{"name":"cpu","tags":{"host":"b","region":"east"},"columns":["time","last_status","last_value"],"values":[["1970-01-01T00:00:00Z",null,3]]},{"name":"cpu","tags":{"host":"c","region":"west"},"columns":["time","last_status","last_value"],"values":[["1970-01-01T00:00:00Z",null,4]]}]}]}`,
+		},
+		&Query{
+			name:    "last by region",
+			command: `SELECT last(value) FROM db0.rp0.cpu GROUP BY region`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"region":"east"},"columns":["time","last"],"values":[["2000-01-01T00:01:00Z",2]]},{"name":"cpu","tags":{"region":"west"},"columns":["time","last"],"values":[["2000-01-01T00:00:10Z",4]]}]}]}`,
+		},
+		&Query{
+			name:    "first with a tag condition",
+			command: `SELECT first(value) FROM db0.rp0.cpu WHERE region = 'east'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","first"],"values":[["2000-01-01T00:00:00Z",1]]}]}]}`,
+		},
+		&Query{
+			name:    "last with a field condition",
+			command: `SELECT last(value) FROM db0.rp0.cpu WHERE value < 2`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","last"],"values":[["2000-01-01T00:00:00Z",1]]}]}]}`,
+		},
+	}...)
+
+	for _, query := range test.queries {
+		t.Run(query.name, func(t *testing.T) {
+			if err := query.Execute(s); err != nil {
+				t.Error(query.Error(err))
+			} else if !query.success() {
+				t.Error(query.failureMessage())
+			}
+		})
+	}
+
+	res, err := s.Query(`SHOW STATS FOR 'shard'`)
+	if err != nil {
+		t.Fatal(err)
+	} else if !regexp.MustCompile(`"columns":\["diskBytes","fieldsCreate","lastValueCacheHits","lastValueCacheMemBytes",[^\]]*\],"values":\[\[\d+,\d+,4,[1-9]\d*,`).MatchString(res) {
+		t.Fatalf("unexpected statistics: %s", res)
+	}
+
+	// Deletes reload the measurements from the stored values.
+	if _, err := s.QueryWithParams(`DELETE FROM cpu WHERE time >= '2000-01-01T00:01:00Z'`, url.Values{"db": []string{"db0"}}); err != nil {
+		t.Fatal(err)
+	}
+	exp := `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","last"],"values":[["2000-01-01T00:00:30Z",3]]}]}]}`
+	if res, err := s.Query(`SELECT last(value) FROM db0.rp0.cpu`); err != nil {
+		t.Fatal(err)
+	} else if res != exp {
+		t.Fatalf("unexpected results\nexp: %s\ngot: %s", exp, res)
+	}
+
+	// Writes update it.
+	if _, err := s.Write("db0", "rp0", fmt.Sprintf(`cpu,host=c,region=west value=5 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:02:00Z").UnixNano()), nil); err != nil {
+		t.Fatal(err)
+	}
+	exp = `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","last"],"values":[["2000-01-01T00:02:00Z",5]]}]}]}`
+	if res, err := s.Query(`SELECT last(value) FROM db0.rp0.cpu`); err != nil {
+		t.Fatal(err)
+	} else if res != exp {
+		t.Fatalf("unexpected results\nexp: %s\ngot: %s", exp, res)
+	}
+}
+
+// TestServer_Query_LastValueCache_Uncached compares the results of queries
+// answered by the last value cache with the results of the engine.
+func TestServer_Query_LastValueCache_Uncached(t *testing.T) {
+	t.Parallel()
+	var servers [2]Server
+	for i, enabled := range []bool{true, false} {
+		c := NewConfig()
+		c.Data.LastValueCacheEnabled = enabled
+		servers[i] = OpenServer(c)
+		defer servers[i].Close()
+
+		if err := servers[i].CreateDatabaseAndRetentionPolicy("db0", NewRetentionPolicySpec("rp0", 1, 0), true); err != nil {
+			t.Fatal(err)
+		}
+		writes := []string{
+			`cpu,host=a,dc=x v=1 1000000000`,
+			`cpu,host=a,dc=x v=2 2000000000`,
+			`cpu,host=b,dc=y v=3 1000000000`,
+			`cpu,dc=y v=4 3000000000`,
+			`cpu v=5 2000000000`,
+			`mem,host=a v=7i 1000000000`,
+			`mem v=8i 4000000000`,
+		}
+		if _, err := servers[i].Write("db0", "rp0", strings.Join(writes, "\n"), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, command := range []string{
+		`SELECT last(v) FROM db0.rp0.cpu WHERE host = ''`,
+		`SELECT first(v) FROM db0.rp0.cpu WHERE host = '' GROUP BY dc`,
+		`SELECT last(v) FROM db0.rp0.cpu WHERE dc !~ /x/`,
+		`SELECT last(v) FROM db0.rp0.cpu WHERE dc != 'x' GROUP BY *`,
+		`SELECT last(v) FROM db0.rp0./.*/`,
+		`SELECT first(v) FROM db0.rp0./.*/ GROUP BY *`,
+		`SELECT last(v) FROM db0.rp0.cpu, db0.rp0.mem WHERE host = ''`,
+		`SELECT last(v) FROM db0.rp0.mem GROUP BY host`,
+	} {
+		cached, err := servers[0].Query(command)
+		if err != nil {
+			t.Fatal(err)
+		}
+		uncached, err := servers[1].Query(command)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(uncached, `"series"`) {
+			t.Fatalf("no series for %s: %s", command, uncached)
+		} else if cached != uncached {
+			t.Errorf("unexpected results for %s\nexp: %s\ngot: %s", command, uncached, cached)
+		}
+	}
+
+	// The queries were answered by the cache.
+	res, err := servers[0].Query(`SHOW STATS FOR 'shard'`)
+	if err != nil {
+		t.Fatal(err)
+	} else if !regexp.MustCompile(`"columns":\["diskBytes","fieldsCreate","lastValueCacheHits",[^\]]*\],"values":\[\[\d+,\d+,[1-9]\d*,`).MatchString(res) {
+		t.Fatalf("unexpected statistics: %s", res)
+	}
+}
+
+func TestServer_Query_State(t *testing.T) {
+	t.Parallel()
+	s := OpenServer(NewConfig())
+	defer s.Close()
+
+	if err := s.CreateDatabaseAndRetentionPolicy("db0", NewRetentionPolicySpec("rp0", 1, 0), true); err != nil {
+		t.Fatal(err)
+	}
+
+	writes := []string{
+		fmt.Sprintf(`pipe,host=a valve="closed" %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`pipe,host=a valve="open" %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:00Z").UnixNano()),
+		fmt.Sprintf(`pipe,host=a valve="open" %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:02:00Z").UnixNano()),
+		fmt.Sprintf(`pipe,host=a valve="open" %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:04:00Z").UnixNano()),
+		fmt.Sprintf(`pipe,host=a valve="closed" %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:05:00Z").UnixNano()),
+		fmt.Sprintf(`pipe,host=a valve="open" %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:06:00Z").UnixNano()),
+		fmt.Sprintf(`pipe,host=b valve="open" %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`pipe,host=b valve="open" %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:03:00Z").UnixNano()),
+		fmt.Sprintf(`cpu value=5 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu value=15 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:30Z").UnixNano()),
+		fmt.Sprintf(`cpu value=20 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:00Z").UnixNano()),
+		fmt.Sprintf(`cpu value=25 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:02:00Z").UnixNano()),
+		fmt.Sprintf(`cpu value=5 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:03:00Z").UnixNano()),
+		fmt.Sprintf(`mem value=1 %d`, mustParseTime(time.RFC3339Nano, "2000-01-05T23:59:00Z").UnixNano()),
+		fmt.Sprintf(`mem value=2 %d`, mustParseTime(time.RFC3339Nano, "2000-01-06T00:01:00Z").UnixNano()),
+	}
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		&Query{
+			name:    "state_duration of a string field",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT state_duration(valve, valve = 'open', 1m) FROM pipe WHERE host = 'a'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"pipe","columns":["time","state_duration"],"values":[["2000-01-01T00:00:00Z",-1],["2000-01-01T00:01:00Z",0],["2000-01-01T00:02:00Z",1],["2000-01-01T00:04:00Z",3],["2000-01-01T00:05:00Z",-1],["2000-01-01T00:06:00Z",0]]}]}]}`,
+		},
+		&Query{
+			name:    "state_count per series",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT state_count(valve, valve = 'open') FROM pipe GROUP BY host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"pipe","tags":{"host":"a"},"columns":["time","state_count"],"values":[["2000-01-01T00:00:00Z",-1],["2000-01-01T00:01:00Z",1],["2000-01-01T00:02:00Z",2],["2000-01-01T00:04:00Z",3],["2000-01-01T00:05:00Z",-1],["2000-01-01T00:06:00Z",1]]},{"name":"pipe","tags":{"host":"b"},"columns":["time","state_count"],"values":[["2000-01-01T00:00:00Z",1],["2000-01-01T00:03:00Z",2]]}]}]}`,
+		},
+		&Query{
+			name:    "state_count of an aggregate across windows",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT state_count(mean(value), value > 9) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:04:00Z' GROUP BY time(1m)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","state_count"],"values":[["2000-01-01T00:00:00Z",1],["2000-01-01T00:01:00Z",2],["2000-01-01T00:02:00Z",3],["2000-01-01T00:03:00Z",-1]]}]}]}`,
+		},
+		&Query{
+			name:    "state_duration across shards",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT state_duration(value, value > 0, 1m) FROM mem`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"mem","columns":["time","state_duration"],"values":[["2000-01-05T23:59:00Z",0],["2000-01-06T00:01:00Z",2]]}]}]}`,
+		},
+	}...)
+
+	if err := test.init(s); err != nil {
+		t.Fatalf("test init failed: %s", err)
+	}
+
+	for _, query := range test.queries {
+		t.Run(query.name, func(t *testing.T) {
+			if query.skip {
+				t.Skipf("SKIP:: %s", query.name)
+			}
+			if err := query.Execute(s); err != nil {
+				t.Error(query.Error(err))
+			} else if !query.success() {
+				t.Error(query.failureMessage())
+			}
+		})
+	}
+}
+
+func TestServer_Query_Histogram(t *testing.T) {
+	t.Parallel()
+	s := OpenServer(NewConfig())
+	defer s.Close()
+
+	if err := s.CreateDatabaseAndRetentionPolicy("db0", NewRetentionPolicySpec("rp0", 1, 0), true); err != nil {
+		t.Fatal(err)
+	}
+
+	writes := []string{
+		fmt.Sprintf(`cpu,host=server01 value=1 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server01 value=7 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server01 value=12 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:20Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server02 value=3 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server02 value=25 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:30Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server01 value=5 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:00Z").UnixNano()),
+	}
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		&Query{
+			name:    "linear buckets by interval",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT histogram(value, 5, 10, 2) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:02:00Z' GROUP BY time(1m)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","histogram","le"],"values":[["2000-01-01T00:00:00Z",2,"5"],["2000-01-01T00:00:00Z",4,"15"],["2000-01-01T00:00:00Z",5,"+Inf"],["2000-01-01T00:01:00Z",1,"5"],["2000-01-01T00:01:00Z",1,"15"],["2000-01-01T00:01:00Z",1,"+Inf"]]}]}]}`,
+		},
+		&Query{
+			name:    "explicit buckets by tag",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT histogram_buckets(value, '2.5,10') AS h FROM cpu GROUP BY host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","h","le"],"values":[["1970-01-01T00:00:00Z",1,"2.5"],["1970-01-01T00:00:00Z",3,"10"],["1970-01-01T00:00:00Z",4,"+Inf"]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","h","le"],"values":[["1970-01-01T00:00:00Z",0,"2.5"],["1970-01-01T00:00:00Z",1,"10"],["1970-01-01T00:00:00Z",2,"+Inf"]]}]}]}`,
+		},
+		&Query{
+			name:    "write buckets into a measurement",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT histogram(value, 5, 10, 2) INTO cpu_hist FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:01:00Z' GROUP BY time(1m)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"result","columns":["time","written"],"values":[["1970-01-01T00:00:00Z",3]]}]}]}`,
+		},
+		&Query{
+			name:    "read buckets written into a measurement",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT histogram FROM cpu_hist GROUP BY le`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu_hist","tags":{"le":"+Inf"},"columns":["time","histogram"],"values":[["2000-01-01T00:00:00Z",5]]},{"name":"cpu_hist","tags":{"le":"15"},"columns":["time","histogram"],"values":[["2000-01-01T00:00:00Z",4]]},{"name":"cpu_hist","tags":{"le":"5"},"columns":["time","histogram"],"values":[["2000-01-01T00:00:00Z",2]]}]}]}`,
+		},
+		&Query{
+			name:    "histogram of a tag",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT histogram(host, 0, 1, 2) FROM cpu`,
+			exp:     `{"results":[{"statement_id":0,"error":"invalid argument type for the first argument in histogram(): tag"}]}`,
+		},
+	}...)
+
+	if err := test.init(s); err != nil {
+		t.Fatalf("test init failed: %s", err)
+	}
+
+	for _, query := range test.queries {
+		t.Run(query.name, func(t *testing.T) {
+			if query.skip {
+				t.Skipf("SKIP:: %s", query.name)
+			}
+			if err := query.Execute(s); err != nil {
+				t.Error(query.Error(err))
+			} else if !query.success() {
+				t.Error(query.failureMessage())
+			}
+		})
+	}
+}
+
+func TestServer_Query_ApproximateAggregates(t *testing.T) {
+	t.Parallel()
+	s := OpenServer(NewConfig())
+	defer s.Close()
+
+	if err := s.CreateDatabaseAndRetentionPolicy("db0", NewRetentionPolicySpec("rp0", 1, 0), true); err != nil {
+		t.Fatal(err)
+	}
+
+	// Spread the points across two shard groups so the sketches are merged.
+	var writes []string
+	for i := 0; i < 200; i++ {
+		ts := mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").Add(time.Duration(i) * time.Minute)
+		if i >= 100 {
+			ts = ts.Add(14 * 24 * time.Hour)
+		}
+		writes = append(writes, fmt.Sprintf(`req,host=h%d visitor="v%d",latency=%d %d`, i%2, i%50, i+1, ts.UnixNano()))
+	}
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		&Query{
+			name:    "count_distinct_approx across shards",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT count_distinct_approx(visitor) FROM req`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"req","columns":["time","count_distinct_approx"],"values":[["1970-01-01T00:00:00Z",50]]}]}]}`,
+		},
+		&Query{
+			name:    "count_distinct_approx grouped by tag",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT count_distinct_approx(visitor) FROM req GROUP BY host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"req","tags":{"host":"h0"},"columns":["time","count_distinct_approx"],"values":[["1970-01-01T00:00:00Z",25]]},{"name":"req","tags":{"host":"h1"},"columns":["time","count_distinct_approx"],"values":[["1970-01-01T00:00:00Z",25]]}]}]}`,
+		},
+		&Query{
+			name:    "quantile_approx across shards",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT quantile_approx(latency, 0) AS q0, quantile_approx(latency, 1) AS q100 FROM req`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"req","columns":["time","q0","q100"],"values":[["1970-01-01T00:00:00Z",1,200]]}]}]}`,
+		},
+		&Query{
+			name:    "quantile_approx grouped by time",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT quantile_approx(latency, 1) FROM req WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T02:00:00Z' GROUP BY time(1h)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"req","columns":["time","quantile_approx"],"values":[["2000-01-01T00:00:00Z",60],["2000-01-01T01:00:00Z",100]]}]}]}`,
+		},
+		&Query{
+			name:    "quantile_approx uses the rank of percentile",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT quantile_approx(latency, 0.9) AS q, percentile(latency, 90) AS p FROM req WHERE host = 'h0' AND time < '2000-01-01T00:04:00Z'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"req","columns":["time","q","p"],"values":[["1970-01-01T00:00:00Z",3,3]]}]}]}`,
+		},
+		&Query{
+			name:    "quantile_approx out of range",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT quantile_approx(latency, 50) FROM req`,
+			exp:     `{"results":[{"statement_id":0,"error":"quantile_approx() quantile must be between 0 and 1, got 50"}]}`,
+		},
+		&Query{
+			name:    "quantile_approx on a string field",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT quantile_approx(visitor, 0.5) FROM req`,
+			exp:     `{"results":[{"statement_id":0,"error":"invalid argument type for the first argument in quantile_approx(): string"}]}`,
+		},
+	}...)
+
+	if err := test.init(s); err != nil {
+		t.Fatalf("test init failed: %s", err)
+	}
+
+	for _, query := range test.queries {
+		t.Run(query.name, func(t *testing.T) {
+			if query.skip {
+				t.Skipf("SKIP:: %s", query.name)
+			}
+			if err := query.Execute(s); err != nil {
+				t.Error(query.Error(err))
+			} else if !query.success() {
+				t.Error(query.failureMessage())
+			}
+		})
+	}
+}
+
+func TestServer_Query_PercentileDerivative(t *testing.T) {
+	t.Parallel()
+	s := OpenServer(NewConfig())
+	defer s.Close()
+
+	if err := s.CreateDatabaseAndRetentionPolicy("db0", NewRetentionPolicySpec("rp0", 1, 0), true); err != nil {
+		t.Fatal(err)
+	}
+
+	writes := []string{
+		fmt.Sprintf(`air temperature=12 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`air temperature=34 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+		fmt.Sprintf(`air temperature=78 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:20Z").UnixNano()),
+		fmt.Sprintf(`air temperature=89 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:30Z").UnixNano()),
+		fmt.Sprintf(`air temperature=101 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:40Z").UnixNano()),
+	}
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		&Query{
+			name:    "nth percentile of derivative",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT percentile(derivative, 95) FROM (SELECT derivative(temperature, 1s) FROM air) WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:00:50Z'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"air","columns":["time","percentile"],"values":[["2000-01-01T00:00:20Z",4.4]]}]}]}`,
+		},
+	}...)
+
+	if err := test.init(s); err != nil {
+		t.Fatalf("test init failed: %s", err)
+	}
+
+	for _, query := range test.queries {
+		t.Run(query.name, func(t *testing.T) {
+			if query.skip {
+				t.Skipf("SKIP:: %s", query.name)
+			}
+			if err := query.Execute(s); err != nil {
+				t.Error(query.Error(err))
+			} else if !query.success() {
+				t.Error(query.failureMessage())
+			}
+		})
+	}
+}
+
+func TestServer_Query_UnderscoreMeasurement(t *testing.T) {
+	t.Parallel()
+	s := OpenServer(NewConfig())
+	defer s.Close()
+
+	if err := s.CreateDatabaseAndRetentionPolicy("db0", NewRetentionPolicySpec("rp0", 1, 0), true); err != nil {
+		t.Fatal(err)
+	}
+
+	writes := []string{
+		fmt.Sprintf(`_air temperature=1i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+	}
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		&Query{
+			name:    "select underscore with underscore prefix",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT * FROM _air`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"_air","columns":["time","temperature"],"values":[["2000-01-01T00:00:00Z",1]]}]}]}`,
+		},
+	}...)
+
+	if err := test.init(s); err != nil {
+		t.Fatalf("test init failed: %s", err)
+	}
+
+	for _, query := range test.queries {
+		t.Run(query.name, func(t *testing.T) {
+			if query.skip {
+				t.Skipf("SKIP:: %s", query.name)
+			}
+			if err := query.Execute(s); err != nil {
+				t.Error(query.Error(err))
+			} else if !query.success() {
+				t.Error(query.failureMessage())
+			}
+		})
+	}
+}
+
+func TestServer_Write_Precision(t *testing.T) {
+	t.Parallel()
+	s := OpenServer(NewConfig())
+	defer s.Close()
+
+	if err := s.CreateDatabaseAndRetentionPolicy("db0", NewRetentionPolicySpec("rp0", 1, 0), true); err != nil {
+		t.Fatal(err)
+	}
+
+	writes := []struct {
+		write  string
+		params url.Values
+	}{
+		{
+			write: fmt.Sprintf("air_n0_precision temperature=1 %d", mustParseTime(time.RFC3339Nano, "2000-01-01T12:34:56.789012345Z").UnixNano()),
+		},
+		{
+			write:  fmt.Sprintf("air_n1_precision temperature=1.1 %d", mustParseTime(time.RFC3339Nano, "2000-01-01T12:34:56.789012345Z").UnixNano()),
+			params: url.Values{"precision": []string{"n"}},
+		},
+		{
+			write:  fmt.Sprintf("air_u_precision temperature=100 %d", mustParseTime(time.RFC3339Nano, "2000-01-01T12:34:56.789012345Z").Truncate(time.Microsecond).UnixNano()/int64(time.Microsecond)),
+			params: url.Values{"precision": []string{"u"}},
+		},
+		{
+			write:  fmt.Sprintf("air_ms_precision temperature=200 %d", mustParseTime(time.RFC3339Nano, "2000-01-01T12:34:56.789012345Z").Truncate(time.Millisecond).UnixNano()/int64(time.Millisecond)),
+			params: url.Values{"precision": []string{"ms"}},
+		},
+		{
+			write:  fmt.Sprintf("air_s_precision temperature=300 %d", mustParseTime(time.RFC3339Nano, "2000-01-01T12:34:56.789012345Z").Truncate(time.Second).UnixNano()/int64(time.Second)),
+			params: url.Values{"precision": []string{"s"}},
+		},
+		{
+			write:  fmt.Sprintf("air_m_precision temperature=400 %d", mustParseTime(time.RFC3339Nano, "2000-01-01T12:34:56.789012345Z").Truncate(time.Minute).UnixNano()/int64(time.Minute)),
+			params: url.Values{"precision": []string{"m"}},
+		},
+		{
+			write:  fmt.Sprintf("air_h_precision temperature=500 %d", mustParseTime(time.RFC3339Nano, "2000-01-01T12:34:56.789012345Z").Truncate(time.Hour).UnixNano()/int64(time.Hour)),
+			params: url.Values{"precision": []string{"h"}},
+		},
+	}
+
+	test := NewTest("db0", "rp0")
+
+	test.addQueries([]*Query{
+		&Query{
+			name:    "point with nanosecond precision time - no precision specified on write",
+			command: `SELECT * FROM air_n0_precision`,
+			params:  url.Values{"db": []string{"db0"}},
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"air_n0_precision","columns":["time","temperature"],"values":[["2000-01-01T12:34:56.789012345Z",1]]}]}]}`,
+		},
+		&Query{
+			name:    "point with nanosecond precision time",
+			command: `SELECT * FROM air_n1_precision`,
+			params:  url.Values{"db": []string{"db0"}},
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"air_n1_precision","columns":["time","temperature"],"values":[["2000-01-01T12:34:56.789012345Z",1.1]]}]}]}`,
+		},
+		&Query{
+			name:    "point with microsecond precision time",
+			command: `SELECT * FROM air_u_precision`,
 			params:  url.Values{"db": []string{"db0"}},
 			exp:     `{"results":[{"statement_id":0,"series":[{"name":"air_u_precision","columns":["time","temperature"],"values":[["2000-01-01T12:34:56.789012Z",100]]}]}]}`,
 		},
@@ -6363,7 +7865,103 @@ func TestServer_Query_ImplicitFill(t *testing.T) {
 	}
 }
 
-func TestServer_Query_TimeZone(t *testing.T) {
+func TestServer_Query_TimeZone(t *testing.T) {
+	t.Parallel()
+	s := OpenServer(NewConfig())
+	defer s.Close()
+
+	if err := s.CreateDatabaseAndRetentionPolicy("db0", NewRetentionPolicySpec("rp0", 1, 0), true); err != nil {
+		t.Fatal(err)
+	}
+
+	var writes []string
+	for _, start := range []time.Time{
+		// One day before DST starts.
+		time.Date(2000, 4, 1, 0, 0, 0, 0, LosAngeles),
+		// Middle of DST. No change.
+		time.Date(2000, 6, 1, 0, 0, 0, 0, LosAngeles),
+		// One day before DST ends.
+		time.Date(2000, 10, 28, 0, 0, 0, 0, LosAngeles),
+	} {
+		ts := start
+		// Write every hour for 4 days.
+		for i := 0; i < 24*4; i++ {
+			writes = append(writes, fmt.Sprintf(`air,interval=daily temperature=0 %d`, ts.UnixNano()))
+			ts = ts.Add(time.Hour)
+		}
+
+		// Write every 5 minutes for 3 hours. Start at 1 on the day with DST.
+		ts = start.Add(25 * time.Hour)
+		for i := 0; i < 12*3; i++ {
+			writes = append(writes, fmt.Sprintf(`air,interval=hourly temperature=0 %d`, ts.UnixNano()))
+			ts = ts.Add(5 * time.Minute)
+		}
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		&Query{
+			name:    "timezone offset - dst start - daily",
+			command: `SELECT count(temperature) FROM air WHERE time >= '2000-04-02T00:00:00-08:00' AND time < '2000-04-04T00:00:00-07:00' AND interval = 'daily' GROUP BY time(1d) TZ('America/Los_Angeles')`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"air","columns":["time","count"],"values":[["2000-04-02T00:00:00-08:00",23],["2000-04-03T00:00:00-07:00",24]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		&Query{
+			name:    "timezone offset - no change - daily",
+			command: `SELECT count(temperature) FROM air WHERE time >= '2000-06-01T00:00:00-07:00' AND time < '2000-06-03T00:00:00-07:00' AND interval = 'daily' GROUP BY time(1d) TZ('America/Los_Angeles')`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"air","columns":["time","count"],"values":[["2000-06-01T00:00:00-07:00",24],["2000-06-02T00:00:00-07:00",24]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		&Query{
+			name:    "timezone offset - dst end - daily",
+			command: `SELECT count(temperature) FROM air WHERE time >= '2000-10-29T00:00:00-07:00' AND time < '2000-10-31T00:00:00-08:00' AND interval = 'daily' GROUP BY time(1d) TZ('America/Los_Angeles')`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"air","columns":["time","count"],"values":[["2000-10-29T00:00:00-07:00",25],["2000-10-30T00:00:00-08:00",24]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		&Query{
+			name:    "timezone offset - dst start - hourly",
+			command: `SELECT count(temperature) FROM air WHERE time >= '2000-04-02T01:00:00-08:00' AND time < '2000-04-02T04:00:00-07:00' AND interval = 'hourly' GROUP BY time(1h) TZ('America/Los_Angeles')`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"air","columns":["time","count"],"values":[["2000-04-02T01:00:00-08:00",12],["2000-04-02T03:00:00-07:00",12]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		&Query{
+			name:    "timezone offset - no change - hourly",
+			command: `SELECT count(temperature) FROM air WHERE time >= '2000-06-02T01:00:00-07:00' AND time < '2000-06-02T03:00:00-07:00' AND interval = 'hourly' GROUP BY time(1h) TZ('America/Los_Angeles')`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"air","columns":["time","count"],"values":[["2000-06-02T01:00:00-07:00",12],["2000-06-02T02:00:00-07:00",12]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		&Query{
+			name:    "timezone offset - dst end - hourly",
+			command: `SELECT count(temperature) FROM air WHERE time >= '2000-10-29T01:00:00-07:00' AND time < '2000-10-29T02:00:00-08:00' AND interval = 'hourly' GROUP BY time(1h) TZ('America/Los_Angeles')`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"air","columns":["time","count"],"values":[["2000-10-29T01:00:00-07:00",12],["2000-10-29T01:00:00-08:00",12]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+	}...)
+
+	for i, query := range test.queries {
+		t.Run(query.name, func(t *testing.T) {
+			if i == 0 {
+				if err := test.init(s); err != nil {
+					t.Fatalf("test init failed: %s", err)
+				}
+			}
+			if query.skip {
+				t.Skipf("SKIP:: %s", query.name)
+			}
+			if err := query.Execute(s); err != nil {
+				t.Error(query.Error(err))
+			} else if !query.success() {
+				t.Error(query.failureMessage())
+			}
+		})
+	}
+}
+
+func TestServer_Query_CalendarIntervals(t *testing.T) {
 	t.Parallel()
 	s := OpenServer(NewConfig())
 	defer s.Close()
@@ -6373,29 +7971,16 @@ func TestServer_Query_TimeZone(t *testing.T) {
 	}
 
 	var writes []string
-	for _, start := range []time.Time{
-		// One day before DST starts.
-		time.Date(2000, 4, 1, 0, 0, 0, 0, LosAngeles),
-		// Middle of DST. No change.
-		time.Date(2000, 6, 1, 0, 0, 0, 0, LosAngeles),
-		// One day before DST ends.
-		time.Date(2000, 10, 28, 0, 0, 0, 0, LosAngeles),
+	for i, ts := range []time.Time{
+		time.Date(2000, 1, 15, 12, 0, 0, 0, LosAngeles),
+		// The last hour of January is in February in UTC.
+		time.Date(2000, 1, 31, 23, 0, 0, 0, LosAngeles),
+		time.Date(2000, 3, 10, 0, 0, 0, 0, LosAngeles),
+		// The last week of March before DST starts.
+		time.Date(2000, 3, 31, 22, 0, 0, 0, LosAngeles),
 	} {
-		ts := start
-		// Write every hour for 4 days.
-		for i := 0; i < 24*4; i++ {
-			writes = append(writes, fmt.Sprintf(`air,interval=daily temperature=0 %d`, ts.UnixNano()))
-			ts = ts.Add(time.Hour)
-		}
-
-		// Write every 5 minutes for 3 hours. Start at 1 on the day with DST.
-		ts = start.Add(25 * time.Hour)
-		for i := 0; i < 12*3; i++ {
-			writes = append(writes, fmt.Sprintf(`air,interval=hourly temperature=0 %d`, ts.UnixNano()))
-			ts = ts.Add(5 * time.Minute)
-		}
+		writes = append(writes, fmt.Sprintf(`cpu value=%d %d`, i+1, ts.UnixNano()))
 	}
-
 	test := NewTest("db0", "rp0")
 	test.writes = Writes{
 		&Write{data: strings.Join(writes, "\n")},
@@ -6403,50 +7988,49 @@ func TestServer_Query_TimeZone(t *testing.T) {
 
 	test.addQueries([]*Query{
 		&Query{
-			name:    "timezone offset - dst start - daily",
-			command: `SELECT count(temperature) FROM air WHERE time >= '2000-04-02T00:00:00-08:00' AND time < '2000-04-04T00:00:00-07:00' AND interval = 'daily' GROUP BY time(1d) TZ('America/Los_Angeles')`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"air","columns":["time","count"],"values":[["2000-04-02T00:00:00-08:00",23],["2000-04-03T00:00:00-07:00",24]]}]}]}`,
+			name:    "months in UTC",
 			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-05-01T00:00:00Z' GROUP BY time(1mo)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["2000-01-01T00:00:00Z",1],["2000-02-01T00:00:00Z",1],["2000-03-01T00:00:00Z",1],["2000-04-01T00:00:00Z",1]]}]}]}`,
 		},
 		&Query{
-			name:    "timezone offset - no change - daily",
-			command: `SELECT count(temperature) FROM air WHERE time >= '2000-06-01T00:00:00-07:00' AND time < '2000-06-03T00:00:00-07:00' AND interval = 'daily' GROUP BY time(1d) TZ('America/Los_Angeles')`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"air","columns":["time","count"],"values":[["2000-06-01T00:00:00-07:00",24],["2000-06-02T00:00:00-07:00",24]]}]}]}`,
+			name:    "months in time zone",
 			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-01-01T00:00:00-08:00' AND time < '2000-05-01T00:00:00-07:00' GROUP BY time(1mo) TZ('America/Los_Angeles')`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["2000-01-01T00:00:00-08:00",2],["2000-02-01T00:00:00-08:00",0],["2000-03-01T00:00:00-08:00",2],["2000-04-01T00:00:00-08:00",0]]}]}]}`,
 		},
 		&Query{
-			name:    "timezone offset - dst end - daily",
-			command: `SELECT count(temperature) FROM air WHERE time >= '2000-10-29T00:00:00-07:00' AND time < '2000-10-31T00:00:00-08:00' AND interval = 'daily' GROUP BY time(1d) TZ('America/Los_Angeles')`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"air","columns":["time","count"],"values":[["2000-10-29T00:00:00-07:00",25],["2000-10-30T00:00:00-08:00",24]]}]}]}`,
+			name:    "months with fill previous",
 			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT mean(value) FROM cpu WHERE time >= '2000-01-01T00:00:00-08:00' AND time < '2000-05-01T00:00:00-07:00' GROUP BY time(1mo) fill(previous) TZ('America/Los_Angeles')`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","mean"],"values":[["2000-01-01T00:00:00-08:00",1.5],["2000-02-01T00:00:00-08:00",1.5],["2000-03-01T00:00:00-08:00",3.5],["2000-04-01T00:00:00-08:00",3.5]]}]}]}`,
 		},
 		&Query{
-			name:    "timezone offset - dst start - hourly",
-			command: `SELECT count(temperature) FROM air WHERE time >= '2000-04-02T01:00:00-08:00' AND time < '2000-04-02T04:00:00-07:00' AND interval = 'hourly' GROUP BY time(1h) TZ('America/Los_Angeles')`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"air","columns":["time","count"],"values":[["2000-04-02T01:00:00-08:00",12],["2000-04-02T03:00:00-07:00",12]]}]}]}`,
+			name:    "years",
 			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT sum(value) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2001-01-01T00:00:00Z' GROUP BY time(1y)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","sum"],"values":[["2000-01-01T00:00:00Z",10]]}]}]}`,
 		},
 		&Query{
-			name:    "timezone offset - no change - hourly",
-			command: `SELECT count(temperature) FROM air WHERE time >= '2000-06-02T01:00:00-07:00' AND time < '2000-06-02T03:00:00-07:00' AND interval = 'hourly' GROUP BY time(1h) TZ('America/Los_Angeles')`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"air","columns":["time","count"],"values":[["2000-06-02T01:00:00-07:00",12],["2000-06-02T02:00:00-07:00",12]]}]}]}`,
+			name:    "weeks starting on monday across DST",
 			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-03-27T00:00:00-08:00' AND time < '2000-04-10T00:00:00-07:00' GROUP BY time(1w, monday) TZ('America/Los_Angeles')`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["2000-03-27T00:00:00-08:00",1],["2000-04-03T00:00:00-07:00",0]]}]}]}`,
 		},
 		&Query{
-			name:    "timezone offset - dst end - hourly",
-			command: `SELECT count(temperature) FROM air WHERE time >= '2000-10-29T01:00:00-07:00' AND time < '2000-10-29T02:00:00-08:00' AND interval = 'hourly' GROUP BY time(1h) TZ('America/Los_Angeles')`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"air","columns":["time","count"],"values":[["2000-10-29T01:00:00-07:00",12],["2000-10-29T01:00:00-08:00",12]]}]}]}`,
+			name:    "calendar interval with offset",
 			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-05-01T00:00:00Z' GROUP BY time(1mo, 1d)`,
+			exp:     `{"results":[{"statement_id":0,"error":"time dimension 1mo does not support an offset"}]}`,
 		},
 	}...)
 
-	for i, query := range test.queries {
+	if err := test.init(s); err != nil {
+		t.Fatalf("test init failed: %s", err)
+	}
+
+	for _, query := range test.queries {
 		t.Run(query.name, func(t *testing.T) {
-			if i == 0 {
-				if err := test.init(s); err != nil {
-					t.Fatalf("test init failed: %s", err)
-				}
-			}
 			if query.skip {
 				t.Skipf("SKIP:: %s", query.name)
 			}
@@ -8881,6 +10465,478 @@ func TestServer_Prometheus_Write(t *testing.T) {
 	//TODO: Prometheus is not yet
 }
 
+func TestServer_Prometheus_Query(t *testing.T) {
+	t.Parallel()
+	s := OpenServer(NewConfig())
+	defer s.Close()
+
+	if err := s.CreateDatabaseAndRetentionPolicy("db0", NewRetentionPolicySpec("rp0", 1, 0), true); err != nil {
+		t.Fatal(err)
+	}
+
+	writes := []string{
+		`up,job=node,instance=a value=1 10000000000`,
+		`up,job=node,instance=b value=0 10000000000`,
+		`up,job=node,instance=a value=1 20000000000`,
+		`up,job=node,instance=b value=1 20000000000`,
+		`air,station=XiaoMaiDao visibility=50 10000000000`,
+	}
+	if _, err := s.Write("db0", "rp0", strings.Join(writes, "\n"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name string
+		path string
+		exp  string
+	}{
+		{
+			name: "instant query",
+			path: `/api/v1/query?db=db0&time=20&query=up`,
+			exp:  `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"__name__":"up","instance":"a","job":"node"},"value":[20,"1"]},{"metric":{"__name__":"up","instance":"b","job":"node"},"value":[20,"1"]}]}}`,
+		},
+		{
+			name: "instant query with aggregation",
+			path: `/api/v1/query?db=db0&time=10&query=sum(up)+by+(job)`,
+			exp:  `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"job":"node"},"value":[10,"1"]}]}}`,
+		},
+		{
+			name: "range query",
+			path: `/api/v1/query_range?db=db0&start=10&end=20&step=10&query=up{instance="b"}`,
+			exp:  `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"__name__":"up","instance":"b","job":"node"},"values":[[10,"0"],[20,"1"]]}]}}`,
+		},
+		{
+			name: "series",
+			path: `/api/v1/series?db=db0&match[]=up{instance="a"}`,
+			exp:  `{"status":"success","data":[{"__name__":"up","instance":"a","job":"node"}]}`,
+		},
+		{
+			name: "labels",
+			path: `/api/v1/labels?db=db0`,
+			exp:  `{"status":"success","data":["__name__","instance","job"]}`,
+		},
+		{
+			name: "label values",
+			path: `/api/v1/label/instance/values?db=db0`,
+			exp:  `{"status":"success","data":["a","b"]}`,
+		},
+		{
+			name: "metric names",
+			path: `/api/v1/label/__name__/values?db=db0`,
+			exp:  `{"status":"success","data":["up"]}`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if res, err := s.HTTPGet(s.URL() + tt.path); err != nil {
+				t.Fatal(err)
+			} else if res != tt.exp {
+				t.Fatalf("unexpected results\nexp: %s\ngot: %s\n", tt.exp, res)
+			}
+		})
+	}
+}
+
+func TestServer_StorageService(t *testing.T) {
+	t.Parallel()
+	if RemoteEnabled() {
+		t.Skip("storage service address is not known for remote servers")
+	}
+
+	c := NewConfig()
+	c.StorageService.Enabled = true
+	c.StorageService.BindAddress = "127.0.0.1:0"
+	s := OpenServer(c)
+	defer s.Close()
+
+	if err := s.CreateDatabaseAndRetentionPolicy("db0", NewRetentionPolicySpec("rp0", 1, 0), true); err != nil {
+		t.Fatal(err)
+	}
+
+	writes := []string{
+		`cpu,host=serverA,region=east usage=1 10000000000`,
+		`cpu,host=serverB,region=west usage=2 10000000000`,
+		`mem,host=serverA free=10i 10000000000`,
+	}
+	if _, err := s.Write("db0", "rp0", strings.Join(writes, "\n"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := grpc.Dial(s.(*LocalServer).StorageServiceAddr(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := datatypes.NewStorageClient(conn)
+	ctx := context.Background()
+
+	src, err := types.MarshalAny(&storage.ReadSource{Database: "db0", RetentionPolicy: "rp0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := datatypes.TimestampRange{Start: 0, End: 20000000000}
+
+	readStrings := func(stream interface {
+		Recv() (*datatypes.StringValuesResponse, error)
+	}) []string {
+		var a []string
+		for {
+			res, err := stream.Recv()
+			if err == io.EOF {
+				return a
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			for _, v := range res.Values {
+				a = append(a, string(v))
+			}
+		}
+	}
+
+	t.Run("read filter", func(t *testing.T) {
+		stream, err := client.ReadFilter(ctx, &datatypes.ReadFilterRequest{ReadSource: src, Range: tr})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var series, points int
+		for {
+			res, err := stream.Recv()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			for _, f := range res.Frames {
+				switch d := f.Data.(type) {
+				case *datatypes.ReadResponse_Frame_Series:
+					series++
+				case *datatypes.ReadResponse_Frame_FloatPoints:
+					points += len(d.FloatPoints.Values)
+				case *datatypes.ReadResponse_Frame_IntegerPoints:
+					points += len(d.IntegerPoints.Values)
+				}
+			}
+		}
+		if series != 3 || points != 3 {
+			t.Fatalf("unexpected frames: series=%d points=%d", series, points)
+		}
+	})
+
+	t.Run("tag keys", func(t *testing.T) {
+		stream, err := client.TagKeys(ctx, &datatypes.TagKeysRequest{TagsSource: src, Range: tr})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, exp := readStrings(stream), []string{"_field", "_measurement", "host", "region"}; !reflect.DeepEqual(got, exp) {
+			t.Fatalf("unexpected tag keys\nexp: %v\ngot: %v", exp, got)
+		}
+	})
+
+	t.Run("tag values", func(t *testing.T) {
+		stream, err := client.TagValues(ctx, &datatypes.TagValuesRequest{TagsSource: src, Range: tr, TagKey: "host"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, exp := readStrings(stream), []string{"serverA", "serverB"}; !reflect.DeepEqual(got, exp) {
+			t.Fatalf("unexpected tag values\nexp: %v\ngot: %v", exp, got)
+		}
+	})
+
+	t.Run("measurement names", func(t *testing.T) {
+		stream, err := client.MeasurementNames(ctx, &datatypes.MeasurementNamesRequest{Source: src, Range: tr})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, exp := readStrings(stream), []string{"cpu", "mem"}; !reflect.DeepEqual(got, exp) {
+			t.Fatalf("unexpected measurement names\nexp: %v\ngot: %v", exp, got)
+		}
+	})
+}
+
+func TestServer_StorageService_Auth(t *testing.T) {
+	t.Parallel()
+	if RemoteEnabled() {
+		t.Skip("storage service address is not known for remote servers")
+	}
+
+	// The service does not start without TLS when authentication is enabled.
+	c := NewConfig()
+	c.HTTPD.AuthEnabled = true
+	c.StorageService.Enabled = true
+	c.StorageService.BindAddress = "127.0.0.1:0"
+	if s := NewServer(c); s.Open() == nil {
+		s.Close()
+		t.Fatal("expected error opening the storage service without TLS")
+	} else {
+		s.Close()
+	}
+
+	c = NewConfig()
+	c.HTTPD.AuthEnabled = true
+	c.HTTPD.HTTPSEnabled = true
+	c.HTTPD.HTTPSCertificate = writeSelfSignedCertificate(t)
+	c.StorageService.Enabled = true
+	c.StorageService.BindAddress = "127.0.0.1:0"
+	s := OpenServer(c)
+	defer s.Close()
+
+	if err := s.CreateDatabaseAndRetentionPolicy("db0", NewRetentionPolicySpec("rp0", 1, 0), true); err != nil {
+		t.Fatal(err)
+	}
+	metaClient := s.(*LocalServer).MetaClient
+	for _, u := range []struct {
+		name  string
+		admin bool
+	}{{"admin", true}, {"reader", false}, {"stranger", false}} {
+		if _, err := metaClient.CreateUser(u.name, "password", u.admin); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := metaClient.SetPrivilege("reader", "db0", cnosql.ReadPrivilege); err != nil {
+		t.Fatal(err)
+	}
+	points, err := models.ParsePointsString(`cpu,host=serverA usage=1 10000000000`)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := s.(*LocalServer).PointsWriter.WritePoints("db0", "rp0", models.ConsistencyLevelAny, nil, points); err != nil {
+		t.Fatal(err)
+	}
+
+	creds := credentials.NewTLS(&tls.Config{InsecureSkipVerify: true})
+	conn, err := grpc.Dial(s.(*LocalServer).StorageServiceAddr(), grpc.WithTransportCredentials(creds))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := datatypes.NewStorageClient(conn)
+
+	src, err := types.MarshalAny(&storage.ReadSource{Database: "db0", RetentionPolicy: "rp0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	measurementNames := func(username string) ([]string, error) {
+		ctx := context.Background()
+		if username != "" {
+			auth := base64.StdEncoding.EncodeToString([]byte(username + ":password"))
+			ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Basic "+auth)
+		}
+		stream, err := client.MeasurementNames(ctx, &datatypes.MeasurementNamesRequest{
+			Source: src,
+			Range:  datatypes.TimestampRange{Start: 0, End: 20000000000},
+		})
+		if err != nil {
+			return nil, err
+		}
+		var a []string
+		for {
+			res, err := stream.Recv()
+			if err == io.EOF {
+				return a, nil
+			} else if err != nil {
+				return nil, err
+			}
+			for _, v := range res.Values {
+				a = append(a, string(v))
+			}
+		}
+	}
+
+	for _, tt := range []struct {
+		username string
+		code     codes.Code
+	}{
+		{username: "", code: codes.Unauthenticated},
+		{username: "stranger", code: codes.PermissionDenied},
+		{username: "reader", code: codes.OK},
+		{username: "admin", code: codes.OK},
+	} {
+		names, err := measurementNames(tt.username)
+		if code := status.Code(err); code != tt.code {
+			t.Fatalf("%q: unexpected status code %s: %v", tt.username, code, err)
+		} else if err == nil && !reflect.DeepEqual(names, []string{"cpu"}) {
+			t.Fatalf("%q: unexpected measurement names: %v", tt.username, names)
+		}
+	}
+}
+
+// writeSelfSignedCertificate writes a certificate and its private key for
+// localhost to a file and returns the path of the file.
+func writeSelfSignedCertificate(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), crand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(crand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: der})
+	pem.Encode(&buf, &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	path := filepath.Join(t.TempDir(), "cnosdb.pem")
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestServer_Write_JSON(t *testing.T) {
+	t.Parallel()
+	s := OpenServer(NewConfig())
+	defer s.Close()
+
+	if err := s.CreateDatabaseAndRetentionPolicy("db0", NewRetentionPolicySpec("rp0", 1, 0), true); err != nil {
+		t.Fatal(err)
+	}
+
+	// Writing JSON requires a mapping.
+	if _, err := s.HTTPPost(s.URL()+"/api/v1/write/json?db=db0", []byte(`[]`)); err == nil {
+		t.Fatal("expected error writing JSON without a mapping")
+	}
+
+	mapping := `{"measurement":"type","default_measurement":"sensor","tags":["device.id","site"],"fields":["readings","ok"],"timestamp":"ts","timestamp_format":"unix_ms"}`
+	if _, err := s.HTTPPost(s.URL()+"/api/v1/write/json/mapping?db=db0", []byte(mapping)); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := s.HTTPGet(s.URL() + "/api/v1/write/json/mapping?db=db0"); err != nil {
+		t.Fatal(err)
+	} else if res != mapping {
+		t.Fatalf("unexpected mapping\nexp: %s\ngot: %s\n", mapping, res)
+	}
+
+	docs := `[
+		{"type":"climate","device":{"id":"d1"},"site":"north","readings":{"temp":21,"humidity":{"rel":40.5}},"ok":true,"ts":1600000000000},
+		{"device":{"id":"d2"},"readings":{"temp":19.5},"ts":1600000001000}
+	]`
+	if _, err := s.HTTPPost(s.URL()+"/api/v1/write/json?db=db0&rp=rp0", []byte(docs)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		query string
+		exp   string
+	}{
+		{
+			query: `SELECT * FROM db0.rp0.climate`,
+			exp:   `{"results":[{"statement_id":0,"series":[{"name":"climate","columns":["time","device_id","ok","readings_humidity_rel","readings_temp","site"],"values":[["2020-09-13T12:26:40Z","d1",true,40.5,21,"north"]]}]}]}`,
+		},
+		{
+			query: `SELECT * FROM db0.rp0.sensor`,
+			exp:   `{"results":[{"statement_id":0,"series":[{"name":"sensor","columns":["time","device_id","readings_temp"],"values":[["2020-09-13T12:26:41Z","d2",19.5]]}]}]}`,
+		},
+	} {
+		if res, err := s.Query(tt.query); err != nil {
+			t.Fatal(err)
+		} else if res != tt.exp {
+			t.Fatalf("unexpected results for %s\nexp: %s\ngot: %s\n", tt.query, tt.exp, res)
+		}
+	}
+
+	// Documents that cannot be converted are reported, the others are written.
+	docs = `[{"readings":{"temp":1},"ts":1600000002000},{"device":{"id":"d3"},"ts":1600000003000}]`
+	if _, err := s.HTTPPost(s.URL()+"/api/v1/write/json?db=db0&rp=rp0", []byte(docs)); err == nil || !strings.Contains(err.Error(), "unable to convert document 1: no fields") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A mapping with an unknown timestamp format is rejected.
+	mapping = `{"measurement":"type","fields":["readings"],"timestamp":"ts","timestamp_format":"iso8601"}`
+	if _, err := s.HTTPPost(s.URL()+"/api/v1/write/json/mapping?db=db0", []byte(mapping)); err == nil || !strings.Contains(err.Error(), `invalid json write mapping timestamp format: \"iso8601\"`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestServer_Write_JSON_MappingBodySize(t *testing.T) {
+	t.Parallel()
+	c := NewConfig()
+	c.HTTPD.MaxBodySize = 64
+	s := OpenServer(c)
+	defer s.Close()
+
+	if err := s.CreateDatabaseAndRetentionPolicy("db0", NewRetentionPolicySpec("rp0", 1, 0), true); err != nil {
+		t.Fatal(err)
+	}
+
+	mapping := `{"measurement":"type","fields":["readings"]}`
+	if _, err := s.HTTPPost(s.URL()+"/api/v1/write/json/mapping?db=db0", []byte(mapping)); err != nil {
+		t.Fatal(err)
+	}
+	mapping = `{"measurement":"type","tags":["device.id","site"],"fields":["readings","ok"],"timestamp":"ts"}`
+	if _, err := s.HTTPPost(s.URL()+"/api/v1/write/json/mapping?db=db0", []byte(mapping)); err == nil || !strings.Contains(err.Error(), "code=413") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestServer_Query_Arrow(t *testing.T) {
+	t.Parallel()
+	s := OpenServer(NewConfig())
+	defer s.Close()
+
+	if err := s.CreateDatabaseAndRetentionPolicy("db0", NewRetentionPolicySpec("rp0", 1, 0), true); err != nil {
+		t.Fatal(err)
+	}
+	points := "cpu,host=a value=1 1000000000\ncpu,host=a value=2 2000000000\ncpu,host=b value=3 1000000000\ncpu,host=b value=4 2000000000"
+	if _, err := s.Write("db0", "rp0", points, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	eos := []byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0}
+	for _, tt := range []struct {
+		command string
+		params  string
+		status  int
+	}{
+		{command: `SELECT value FROM db0.rp0.cpu GROUP BY host`, status: http.StatusOK},
+		// The chunks of the response share the stream.
+		{command: `SELECT value FROM db0.rp0.cpu GROUP BY host`, params: "&chunked=true&chunk_size=1", status: http.StatusOK},
+		// A response without series is a stream without records.
+		{command: `SELECT value FROM db0.rp0.mem`, params: "&chunked=true", status: http.StatusOK},
+		// The results of several statements do not share a stream.
+		{command: `SELECT value FROM db0.rp0.cpu; SELECT value FROM db0.rp0.cpu`, status: http.StatusBadRequest},
+	} {
+		req, err := http.NewRequest("GET", s.URL()+"/query?q="+url.QueryEscape(tt.command)+tt.params, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept", "application/vnd.apache.arrow.stream")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body := MustReadAll(resp.Body)
+		if resp.StatusCode != tt.status {
+			t.Fatalf("unexpected status code for %q: %d", tt.command, resp.StatusCode)
+		} else if tt.status != http.StatusOK {
+			continue
+		} else if ct := resp.Header.Get("Content-Type"); ct != "application/vnd.apache.arrow.stream" {
+			t.Fatalf("unexpected content type: %s", ct)
+		}
+		if !bytes.HasPrefix(body, []byte{0xff, 0xff, 0xff, 0xff}) || !bytes.HasSuffix(body, eos) {
+			t.Fatalf("response is not an Arrow stream: %x", body)
+		}
+		if n := bytes.Count(body, eos); n != 1 {
+			t.Fatalf("unexpected number of streams for %q%s: %d", tt.command, tt.params, n)
+		}
+		for _, name := range []string{"name", "time", "error"} {
+			if !bytes.Contains(body, []byte(name)) {
+				t.Fatalf("column %s missing from response", name)
+			}
+		}
+	}
+}
+
 // support for uint
 func init() {
 	models.EnableUintSupport()
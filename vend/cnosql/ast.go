@@ -210,6 +210,7 @@ func (Statements) node() {}
 func (*AlterRetentionPolicyStatement) node()       {}
 func (*CreateContinuousQueryStatement) node()      {}
 func (*CreateDatabaseStatement) node()             {}
+func (*CreateMeasurementStatement) node()          {}
 func (*CreateRetentionPolicyStatement) node()      {}
 func (*CreateSubscriptionStatement) node()         {}
 func (*CreateUserStatement) node()                 {}
@@ -227,6 +228,7 @@ func (*DropUserStatement) node()                   {}
 func (*ExplainStatement) node()                    {}
 func (*GrantStatement) node()                      {}
 func (*GrantAdminStatement) node()                 {}
+func (*InsertStatement) node()                     {}
 func (*KillQueryStatement) node()                  {}
 func (*RevokeStatement) node()                     {}
 func (*RevokeAdminStatement) node()                {}
@@ -254,33 +256,36 @@ func (*ShowTagValuesCardinalityStatement) node()   {}
 func (*ShowTagValuesStatement) node()              {}
 func (*ShowUsersStatement) node()                  {}
 
-func (*BinaryExpr) node()      {}
-func (*BooleanLiteral) node()  {}
-func (*BoundParameter) node()  {}
-func (*Call) node()            {}
-func (*Dimension) node()       {}
-func (Dimensions) node()       {}
-func (*DurationLiteral) node() {}
-func (*IntegerLiteral) node()  {}
-func (*UnsignedLiteral) node() {}
-func (*Field) node()           {}
-func (Fields) node()           {}
-func (*Measurement) node()     {}
-func (Measurements) node()     {}
-func (*NilLiteral) node()      {}
-func (*NumberLiteral) node()   {}
-func (*ParenExpr) node()       {}
-func (*RegexLiteral) node()    {}
-func (*ListLiteral) node()     {}
-func (*SortField) node()       {}
-func (SortFields) node()       {}
-func (Sources) node()          {}
-func (*StringLiteral) node()   {}
-func (*SubQuery) node()        {}
-func (*Target) node()          {}
-func (*TimeLiteral) node()     {}
-func (*VarRef) node()          {}
-func (*Wildcard) node()        {}
+func (*BinaryExpr) node()              {}
+func (*BooleanLiteral) node()          {}
+func (*BoundParameter) node()          {}
+func (*CalendarDurationLiteral) node() {}
+func (*Call) node()                    {}
+func (*CaseExpr) node()                {}
+func (*Dimension) node()               {}
+func (Dimensions) node()               {}
+func (*DurationLiteral) node()         {}
+func (*IntegerLiteral) node()          {}
+func (*UnsignedLiteral) node()         {}
+func (*Field) node()                   {}
+func (Fields) node()                   {}
+func (*Measurement) node()             {}
+func (Measurements) node()             {}
+func (*NilLiteral) node()              {}
+func (*NumberLiteral) node()           {}
+func (*ParenExpr) node()               {}
+func (*RegexLiteral) node()            {}
+func (*ListLiteral) node()             {}
+func (*SortField) node()               {}
+func (SortFields) node()               {}
+func (Sources) node()                  {}
+func (*StringLiteral) node()           {}
+func (*SubQuery) node()                {}
+func (*Join) node()                    {}
+func (*Target) node()                  {}
+func (*TimeLiteral) node()             {}
+func (*VarRef) node()                  {}
+func (*Wildcard) node()                {}
 
 // Query represents a collection of ordered statements.
 type Query struct {
@@ -339,6 +344,7 @@ type ExecutionPrivileges []ExecutionPrivilege
 func (*AlterRetentionPolicyStatement) stmt()       {}
 func (*CreateContinuousQueryStatement) stmt()      {}
 func (*CreateDatabaseStatement) stmt()             {}
+func (*CreateMeasurementStatement) stmt()          {}
 func (*CreateRetentionPolicyStatement) stmt()      {}
 func (*CreateSubscriptionStatement) stmt()         {}
 func (*CreateUserStatement) stmt()                 {}
@@ -354,6 +360,7 @@ func (*DropUserStatement) stmt()                   {}
 func (*ExplainStatement) stmt()                    {}
 func (*GrantStatement) stmt()                      {}
 func (*GrantAdminStatement) stmt()                 {}
+func (*InsertStatement) stmt()                     {}
 func (*KillQueryStatement) stmt()                  {}
 func (*ShowContinuousQueriesStatement) stmt()      {}
 func (*ShowGrantsForUserStatement) stmt()          {}
@@ -390,23 +397,25 @@ type Expr interface {
 	expr()
 }
 
-func (*BinaryExpr) expr()      {}
-func (*BooleanLiteral) expr()  {}
-func (*BoundParameter) expr()  {}
-func (*Call) expr()            {}
-func (*Distinct) expr()        {}
-func (*DurationLiteral) expr() {}
-func (*IntegerLiteral) expr()  {}
-func (*UnsignedLiteral) expr() {}
-func (*NilLiteral) expr()      {}
-func (*NumberLiteral) expr()   {}
-func (*ParenExpr) expr()       {}
-func (*RegexLiteral) expr()    {}
-func (*ListLiteral) expr()     {}
-func (*StringLiteral) expr()   {}
-func (*TimeLiteral) expr()     {}
-func (*VarRef) expr()          {}
-func (*Wildcard) expr()        {}
+func (*BinaryExpr) expr()              {}
+func (*BooleanLiteral) expr()          {}
+func (*BoundParameter) expr()          {}
+func (*CalendarDurationLiteral) expr() {}
+func (*Call) expr()                    {}
+func (*CaseExpr) expr()                {}
+func (*Distinct) expr()                {}
+func (*DurationLiteral) expr()         {}
+func (*IntegerLiteral) expr()          {}
+func (*UnsignedLiteral) expr()         {}
+func (*NilLiteral) expr()              {}
+func (*NumberLiteral) expr()           {}
+func (*ParenExpr) expr()               {}
+func (*RegexLiteral) expr()            {}
+func (*ListLiteral) expr()             {}
+func (*StringLiteral) expr()           {}
+func (*TimeLiteral) expr()             {}
+func (*VarRef) expr()                  {}
+func (*Wildcard) expr()                {}
 
 // Literal represents a static literal.
 type Literal interface {
@@ -416,17 +425,18 @@ type Literal interface {
 	literal()
 }
 
-func (*BooleanLiteral) literal()  {}
-func (*BoundParameter) literal()  {}
-func (*DurationLiteral) literal() {}
-func (*IntegerLiteral) literal()  {}
-func (*UnsignedLiteral) literal() {}
-func (*NilLiteral) literal()      {}
-func (*NumberLiteral) literal()   {}
-func (*RegexLiteral) literal()    {}
-func (*ListLiteral) literal()     {}
-func (*StringLiteral) literal()   {}
-func (*TimeLiteral) literal()     {}
+func (*BooleanLiteral) literal()          {}
+func (*BoundParameter) literal()          {}
+func (*CalendarDurationLiteral) literal() {}
+func (*DurationLiteral) literal()         {}
+func (*IntegerLiteral) literal()          {}
+func (*UnsignedLiteral) literal()         {}
+func (*NilLiteral) literal()              {}
+func (*NumberLiteral) literal()           {}
+func (*RegexLiteral) literal()            {}
+func (*ListLiteral) literal()             {}
+func (*StringLiteral) literal()           {}
+func (*TimeLiteral) literal()             {}
 
 // Source represents a source of data for a statement.
 type Source interface {
@@ -438,6 +448,7 @@ type Source interface {
 
 func (*Measurement) source() {}
 func (*SubQuery) source()    {}
+func (*Join) source()        {}
 
 // Sources represents a list of sources.
 type Sources []Source
@@ -466,6 +477,8 @@ func (a Sources) Measurements() []*Measurement {
 			mms = append(mms, src)
 		case *SubQuery:
 			mms = append(mms, src.Statement.Sources.Measurements()...)
+		case *Join:
+			mms = append(mms, src.Sources().Measurements()...)
 		}
 	}
 	return mms
@@ -514,6 +527,12 @@ func (a Sources) RequiredPrivileges() (ExecutionPrivileges, error) {
 				return nil, err
 			}
 			ep = append(ep, privs...)
+		case *Join:
+			privs, err := source.Sources().RequiredPrivileges()
+			if err != nil {
+				return nil, err
+			}
+			ep = append(ep, privs...)
 		default:
 			return nil, fmt.Errorf("invalid source: %s", source)
 		}
@@ -539,7 +558,7 @@ func IsSystemName(name string) bool {
 
 // SortField represents a field to sort results by.
 type SortField struct {
-	// Name of the field.
+	// Name of the field, tag or call to sort by. An empty name sorts by time.
 	Name string
 
 	// Sort order.
@@ -561,9 +580,24 @@ func (field *SortField) String() string {
 	return buf.String()
 }
 
+// IsTime returns true if the field sorts by time.
+func (field *SortField) IsTime() bool {
+	return field.Name == "" || field.Name == "time"
+}
+
 // SortFields represents an ordered list of ORDER BY fields.
 type SortFields []*SortField
 
+// ByTime returns true if the fields only sort by time.
+func (a SortFields) ByTime() bool {
+	for _, field := range a {
+		if !field.IsTime() {
+			return false
+		}
+	}
+	return true
+}
+
 // String returns a string representation of sort fields.
 func (a SortFields) String() string {
 	fields := make([]string, 0, len(a))
@@ -809,6 +843,53 @@ func (s *GrantAdminStatement) RequiredPrivileges() (ExecutionPrivileges, error)
 	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}, nil
 }
 
+// InsertStatement represents a command for writing points.
+type InsertStatement struct {
+	// Measurement to write the points to.
+	Measurement *Measurement
+
+	// Columns of the values. Columns typed as tags are tags, the column
+	// named time is the time of the points and the others are fields.
+	Columns []*VarRef
+
+	// Values holds a literal per column for each point.
+	Values [][]Expr
+}
+
+// String returns a string representation of the insert statement.
+func (s *InsertStatement) String() string {
+	var buf strings.Builder
+	_, _ = buf.WriteString("INSERT INTO ")
+	_, _ = buf.WriteString(s.Measurement.String())
+	_, _ = buf.WriteString(" (")
+	for i, c := range s.Columns {
+		if i != 0 {
+			_, _ = buf.WriteString(", ")
+		}
+		_, _ = buf.WriteString(c.String())
+	}
+	_, _ = buf.WriteString(") VALUES ")
+	for i, values := range s.Values {
+		if i != 0 {
+			_, _ = buf.WriteString(", ")
+		}
+		_, _ = buf.WriteString("(")
+		for j, v := range values {
+			if j != 0 {
+				_, _ = buf.WriteString(", ")
+			}
+			_, _ = buf.WriteString(v.String())
+		}
+		_, _ = buf.WriteString(")")
+	}
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege required to execute an InsertStatement.
+func (s *InsertStatement) RequiredPrivileges() (ExecutionPrivileges, error) {
+	return ExecutionPrivileges{{Admin: false, Name: s.Measurement.Database, Privilege: WritePrivilege}}, nil
+}
+
 // KillQueryStatement represents a command for killing a query.
 type KillQueryStatement struct {
 	// The query to kill.
@@ -1058,6 +1139,9 @@ type SelectStatement struct {
 	// An expression evaluated on data point.
 	Condition Expr
 
+	// An expression over aggregates evaluated on each row of the result.
+	Having Expr
+
 	// Fields to sort results by.
 	SortFields SortFields
 
@@ -1106,7 +1190,12 @@ type SelectStatement struct {
 
 // TimeAscending returns true if the time field is sorted in chronological order.
 func (s *SelectStatement) TimeAscending() bool {
-	return len(s.SortFields) == 0 || s.SortFields[0].Ascending
+	for _, f := range s.SortFields {
+		if f.IsTime() {
+			return f.Ascending
+		}
+	}
+	return true
 }
 
 // TimeFieldName returns the name of the time field.
@@ -1125,6 +1214,7 @@ func (s *SelectStatement) Clone() *SelectStatement {
 	clone.Sources = cloneSources(s.Sources)
 	clone.SortFields = make(SortFields, 0, len(s.SortFields))
 	clone.Condition = CloneExpr(s.Condition)
+	clone.Having = CloneExpr(s.Having)
 
 	if s.Target != nil {
 		clone.Target = &Target{
@@ -1166,6 +1256,8 @@ func cloneSource(s Source) Source {
 		return s.Clone()
 	case *SubQuery:
 		return &SubQuery{Statement: s.Statement.Clone()}
+	case *Join:
+		return s.Clone()
 	default:
 		panic("unreachable")
 	}
@@ -1195,6 +1287,16 @@ func (s *SelectStatement) RewriteFields(m FieldMapper) (*SelectStatement, error)
 				return nil, err
 			}
 			src.Statement = stmt
+		case *Join:
+			for _, side := range []*JoinSource{src.Left, src.Right} {
+				if sq, ok := side.Source.(*SubQuery); ok {
+					stmt, err := sq.Statement.RewriteFields(m)
+					if err != nil {
+						return nil, err
+					}
+					sq.Statement = stmt
+				}
+			}
 		}
 	}
 
@@ -1214,6 +1316,7 @@ func (s *SelectStatement) RewriteFields(m FieldMapper) (*SelectStatement, error)
 	}
 	WalkFunc(other.Fields, rewrite)
 	WalkFunc(other.Condition, rewrite)
+	WalkFunc(other.Having, rewrite)
 
 	// Ignore if there are no wildcards.
 	hasFieldWildcard := other.HasFieldWildcard()
@@ -1320,7 +1423,7 @@ func (s *SelectStatement) RewriteFields(m FieldMapper) (*SelectStatement, error)
 
 				// Add additional types for certain functions.
 				switch call.Name {
-				case "count", "first", "last", "distinct", "elapsed", "mode", "sample":
+				case "count", "first", "last", "distinct", "elapsed", "mode", "sample", "count_distinct_approx":
 					supportedTypes[String] = struct{}{}
 					fallthrough
 				case "min", "max":
@@ -1663,6 +1766,9 @@ func (s *SelectStatement) ColumnNames() []string {
 						columnFields = append(columnFields, &Field{Expr: ref})
 					}
 				}
+			} else if s.Target == nil && (f.Name == "histogram" || f.Name == "histogram_buckets") {
+				// Each row of a histogram has the upper bound of its bucket.
+				columnFields = append(columnFields, &Field{Expr: &VarRef{Val: "le"}})
 			}
 		}
 	}
@@ -1750,6 +1856,12 @@ func (s *SelectStatement) Reduce(valuer Valuer) *SelectStatement {
 		switch source := source.(type) {
 		case *SubQuery:
 			source.Statement = source.Statement.Reduce(valuer)
+		case *Join:
+			for _, side := range []*JoinSource{source.Left, source.Right} {
+				if sq, ok := side.Source.(*SubQuery); ok {
+					sq.Statement = sq.Statement.Reduce(valuer)
+				}
+			}
 		}
 	}
 	return stmt
@@ -1787,6 +1899,10 @@ func (s *SelectStatement) String() string {
 	case PreviousFill:
 		_, _ = buf.WriteString(" fill(previous)")
 	}
+	if s.Having != nil {
+		_, _ = buf.WriteString(" HAVING ")
+		_, _ = buf.WriteString(s.Having.String())
+	}
 	if len(s.SortFields) > 0 {
 		_, _ = buf.WriteString(" ORDER BY ")
 		_, _ = buf.WriteString(s.SortFields.String())
@@ -1878,6 +1994,14 @@ func (s *SelectStatement) GroupByInterval() (time.Duration, error) {
 				return 0, errors.New("time dimension expected 1 or 2 arguments")
 			}
 
+			// Calendar intervals are approximated by their longest duration.
+			if cal, err := CalendarTimeDimension(call); err != nil {
+				return 0, err
+			} else if !cal.IsZero() {
+				s.groupByInterval = cal.MaxDuration()
+				return s.groupByInterval, nil
+			}
+
 			// Ensure the argument is a duration.
 			lit, ok := call.Args[0].(*DurationLiteral)
 			if !ok {
@@ -1904,6 +2028,13 @@ func (s *SelectStatement) GroupByOffset() (time.Duration, error) {
 
 	for _, d := range s.Dimensions {
 		if call, ok := d.Expr.(*Call); ok && call.Name == "time" {
+			// Calendar intervals always start at local midnight.
+			if cal, err := CalendarTimeDimension(call); err != nil {
+				return 0, err
+			} else if !cal.IsZero() {
+				return 0, nil
+			}
+
 			if len(call.Args) == 2 {
 				switch expr := call.Args[1].(type) {
 				case *DurationLiteral:
@@ -1920,6 +2051,17 @@ func (s *SelectStatement) GroupByOffset() (time.Duration, error) {
 	return 0, nil
 }
 
+// GroupByCalendar extracts the calendar interval of the time dimension, if
+// it groups by calendar months or by weeks starting on a weekday.
+func (s *SelectStatement) GroupByCalendar() (CalendarInterval, error) {
+	for _, d := range s.Dimensions {
+		if call, ok := d.Expr.(*Call); ok && call.Name == "time" {
+			return CalendarTimeDimension(call)
+		}
+	}
+	return CalendarInterval{}, nil
+}
+
 // SetTimeRange sets the start and end time of the select statement to [start, end). i.e. start inclusive, end exclusive.
 // This is used commonly for continuous queries so the start and end are in buckets.
 func (s *SelectStatement) SetTimeRange(start, end time.Time) error {
@@ -2026,10 +2168,14 @@ func walkRefs(exp Expr) []VarRef {
 			refs[*expr] = struct{}{}
 		case *Call:
 			for _, expr := range expr.Args {
-				if ref, ok := expr.(*VarRef); ok {
-					refs[*ref] = struct{}{}
-				}
+				walk(expr)
+			}
+		case *CaseExpr:
+			for _, w := range expr.WhenClauses {
+				walk(w.Cond)
+				walk(w.Result)
 			}
+			walk(expr.Else)
 		case *BinaryExpr:
 			walk(expr.LHS)
 			walk(expr.RHS)
@@ -2426,6 +2572,12 @@ type CreateContinuousQueryStatement struct {
 
 	// Maximum duration to resample previous queries.
 	ResampleFor time.Duration
+
+	// Resample interval and maximum duration in calendar months. These are
+	// set instead of ResampleEvery and ResampleFor when the durations are
+	// given in months or years.
+	ResampleEveryMonths int
+	ResampleForMonths   int
 }
 
 // String returns a string representation of the statement.
@@ -2433,13 +2585,13 @@ func (s *CreateContinuousQueryStatement) String() string {
 	var buf strings.Builder
 	fmt.Fprintf(&buf, "CREATE CONTINUOUS QUERY %s ON %s ", QuoteIdent(s.Name), QuoteIdent(s.Database))
 
-	if s.ResampleEvery > 0 || s.ResampleFor > 0 {
+	if s.ResampleEvery > 0 || s.ResampleFor > 0 || s.ResampleEveryMonths > 0 || s.ResampleForMonths > 0 {
 		buf.WriteString("RESAMPLE ")
-		if s.ResampleEvery > 0 {
-			fmt.Fprintf(&buf, "EVERY %s ", FormatDuration(s.ResampleEvery))
+		if _, every := s.resampleEvery(); every != "" {
+			fmt.Fprintf(&buf, "EVERY %s ", every)
 		}
-		if s.ResampleFor > 0 {
-			fmt.Fprintf(&buf, "FOR %s ", FormatDuration(s.ResampleFor))
+		if _, d := s.resampleFor(); d != "" {
+			fmt.Fprintf(&buf, "FOR %s ", d)
 		}
 	}
 	fmt.Fprintf(&buf, "BEGIN %s END", s.Source.String())
@@ -2472,18 +2624,45 @@ func (s *CreateContinuousQueryStatement) RequiredPrivileges() (ExecutionPrivileg
 	return ep, nil
 }
 
+// resampleEvery returns the resample interval and its string representation.
+// Calendar months are compared as their longest duration.
+func (s *CreateContinuousQueryStatement) resampleEvery() (time.Duration, string) {
+	if s.ResampleEveryMonths > 0 {
+		return CalendarInterval{Months: s.ResampleEveryMonths}.MaxDuration(), FormatCalendarDuration(s.ResampleEveryMonths)
+	} else if s.ResampleEvery > 0 {
+		return s.ResampleEvery, FormatDuration(s.ResampleEvery)
+	}
+	return 0, ""
+}
+
+// resampleFor returns the maximum resample duration and its string representation.
+func (s *CreateContinuousQueryStatement) resampleFor() (time.Duration, string) {
+	if s.ResampleForMonths > 0 {
+		return CalendarInterval{Months: s.ResampleForMonths}.MaxDuration(), FormatCalendarDuration(s.ResampleForMonths)
+	} else if s.ResampleFor > 0 {
+		return s.ResampleFor, FormatDuration(s.ResampleFor)
+	}
+	return 0, ""
+}
+
 func (s *CreateContinuousQueryStatement) validate() error {
 	interval, err := s.Source.GroupByInterval()
 	if err != nil {
 		return err
 	}
+	minimum := FormatDuration(interval)
+	if cal, err := s.Source.GroupByCalendar(); err != nil {
+		return err
+	} else if !cal.IsZero() {
+		minimum = cal.String()
+	}
 
-	if s.ResampleFor != 0 {
-		if s.ResampleEvery != 0 && s.ResampleEvery > interval {
-			interval = s.ResampleEvery
+	if resampleFor, forStr := s.resampleFor(); resampleFor != 0 {
+		if every, everyStr := s.resampleEvery(); every != 0 && every > interval {
+			interval, minimum = every, everyStr
 		}
-		if interval > s.ResampleFor {
-			return fmt.Errorf("FOR duration must be >= GROUP BY time duration: must be a minimum of %s, got %s", FormatDuration(interval), FormatDuration(s.ResampleFor))
+		if interval > resampleFor {
+			return fmt.Errorf("FOR duration must be >= GROUP BY time duration: must be a minimum of %s, got %s", minimum, forStr)
 		}
 	}
 	return nil
@@ -2654,6 +2833,70 @@ func (s *ShowMeasurementsStatement) DefaultDatabase() string {
 	return s.Database
 }
 
+// CreateMeasurementStatement represents a command to declare the write
+// schema of a measurement.
+type CreateMeasurementStatement struct {
+	// Name of the measurement.
+	Name string
+
+	// Tag keys points written to the measurement may use.
+	Tags []string
+
+	// Fields points written to the measurement may use.
+	Fields []*MeasurementField
+}
+
+// MeasurementField declares a field of a measurement schema.
+type MeasurementField struct {
+	Name     string
+	Type     DataType
+	Required bool
+}
+
+// String returns a string representation of the field declaration.
+func (f *MeasurementField) String() string {
+	var buf strings.Builder
+	_, _ = buf.WriteString(QuoteIdent(f.Name))
+	_, _ = buf.WriteString(" ")
+	_, _ = buf.WriteString(strings.ToUpper(f.Type.String()))
+	if f.Required {
+		_, _ = buf.WriteString(" REQUIRED")
+	}
+	return buf.String()
+}
+
+// String returns a string representation of the create measurement statement.
+func (s *CreateMeasurementStatement) String() string {
+	var buf strings.Builder
+	_, _ = buf.WriteString("CREATE MEASUREMENT ")
+	_, _ = buf.WriteString(QuoteIdent(s.Name))
+	_, _ = buf.WriteString(" (")
+	if len(s.Tags) > 0 {
+		_, _ = buf.WriteString("TAGS ")
+		for i, tag := range s.Tags {
+			if i != 0 {
+				_, _ = buf.WriteString(", ")
+			}
+			_, _ = buf.WriteString(QuoteIdent(tag))
+		}
+		_, _ = buf.WriteString("; ")
+	}
+	_, _ = buf.WriteString("FIELDS ")
+	for i, f := range s.Fields {
+		if i != 0 {
+			_, _ = buf.WriteString(", ")
+		}
+		_, _ = buf.WriteString(f.String())
+	}
+	_, _ = buf.WriteString(")")
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege(s) required to execute a CreateMeasurementStatement.
+func (s *CreateMeasurementStatement) RequiredPrivileges() (ExecutionPrivileges, error) {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}, nil
+}
+
 // DropMeasurementStatement represents a command to drop a measurement.
 type DropMeasurementStatement struct {
 	// Name of the measurement to be dropped.
@@ -3338,6 +3581,8 @@ func (f *Field) Name() string {
 	case *ParenExpr:
 		f := Field{Expr: expr.Expr}
 		return f.Name()
+	case *CaseExpr:
+		return "case"
 	case *VarRef:
 		return expr.Val
 	}
@@ -3386,8 +3631,12 @@ func (a Dimensions) Normalize() (time.Duration, []string) {
 	for _, dim := range a {
 		switch expr := dim.Expr.(type) {
 		case *Call:
-			lit, _ := expr.Args[0].(*DurationLiteral)
-			dur = lit.Val
+			switch lit := expr.Args[0].(type) {
+			case *DurationLiteral:
+				dur = lit.Val
+			case *CalendarDurationLiteral:
+				dur = CalendarInterval{Months: lit.Months}.MaxDuration()
+			}
 		case *VarRef:
 			tags = append(tags, expr.Val)
 		}
@@ -3506,6 +3755,145 @@ func (s *SubQuery) String() string {
 	return fmt.Sprintf("(%s)", s.Statement.String())
 }
 
+// JoinType represents the rows returned by a join.
+type JoinType int
+
+const (
+	// InnerJoin returns the rows of both sides matching each other.
+	InnerJoin JoinType = iota
+	// LeftJoin also returns the rows of the left side without a match.
+	LeftJoin
+	// RightJoin also returns the rows of the right side without a match.
+	RightJoin
+	// FullJoin also returns the rows of both sides without a match.
+	FullJoin
+)
+
+// String returns a string representation of the join type.
+func (t JoinType) String() string {
+	switch t {
+	case LeftJoin:
+		return "LEFT JOIN"
+	case RightJoin:
+		return "RIGHT JOIN"
+	case FullJoin:
+		return "FULL JOIN"
+	default:
+		return "INNER JOIN"
+	}
+}
+
+// JoinSource represents a side of a join: a measurement or a subquery.
+type JoinSource struct {
+	Source Source
+	Alias  string
+}
+
+// Name returns the name qualifying the columns of the side: its alias, or
+// the name of the measurement if it has none.
+func (s *JoinSource) Name() string {
+	if s.Alias != "" {
+		return s.Alias
+	}
+	if m, ok := s.Source.(*Measurement); ok {
+		return m.Name
+	}
+	return ""
+}
+
+// String returns a string representation of the join side.
+func (s *JoinSource) String() string {
+	if s.Alias == "" {
+		return s.Source.String()
+	}
+	return fmt.Sprintf("%s AS %s", s.Source.String(), QuoteIdent(s.Alias))
+}
+
+// Join is a source matching the rows of two sources with the same time and
+// the same values of the tag keys of On. The columns of each side are
+// referenced by the name of the side and the column, such as cpu.usage, and
+// the tag keys of On by their key.
+type Join struct {
+	Type  JoinType
+	Left  *JoinSource
+	Right *JoinSource
+	On    []string
+}
+
+// Sources returns the sources of both sides of the join.
+func (j *Join) Sources() Sources {
+	return Sources{j.Left.Source, j.Right.Source}
+}
+
+// Side returns the side of the join named name, or nil if there is none.
+func (j *Join) Side(name string) *JoinSource {
+	for _, side := range []*JoinSource{j.Left, j.Right} {
+		if side.Name() == name {
+			return side
+		}
+	}
+	return nil
+}
+
+// IsKey returns true if the join matches the rows on tag key k.
+func (j *Join) IsKey(k string) bool {
+	for _, key := range j.On {
+		if key == k {
+			return true
+		}
+	}
+	return false
+}
+
+// Clone returns a deep clone of the join.
+func (j *Join) Clone() *Join {
+	return &Join{
+		Type:  j.Type,
+		Left:  &JoinSource{Source: cloneSource(j.Left.Source), Alias: j.Left.Alias},
+		Right: &JoinSource{Source: cloneSource(j.Right.Source), Alias: j.Right.Alias},
+		On:    append([]string(nil), j.On...),
+	}
+}
+
+// String returns a string representation of the join.
+func (j *Join) String() string {
+	var buf strings.Builder
+	_, _ = buf.WriteString(j.Left.String())
+	_, _ = buf.WriteString(" ")
+	_, _ = buf.WriteString(j.Type.String())
+	_, _ = buf.WriteString(" ")
+	_, _ = buf.WriteString(j.Right.String())
+	if len(j.On) > 0 {
+		_, _ = buf.WriteString(" ON ")
+		for i, k := range j.On {
+			if i > 0 {
+				_, _ = buf.WriteString(", ")
+			}
+			_, _ = buf.WriteString(QuoteIdent(k))
+		}
+	}
+	return buf.String()
+}
+
+// Column returns the side of the join qualifying the column name and the
+// name of the column in that side, or nil if name is not qualified by the
+// name of a side.
+func (j *Join) Column(name string) (*JoinSource, string) {
+	var side *JoinSource
+	for _, s := range []*JoinSource{j.Left, j.Right} {
+		prefix := s.Name() + "."
+		if s.Name() != "" && len(name) > len(prefix) && strings.HasPrefix(name, prefix) {
+			if side == nil || len(s.Name()) > len(side.Name()) {
+				side = s
+			}
+		}
+	}
+	if side == nil {
+		return nil, ""
+	}
+	return side, name[len(side.Name())+1:]
+}
+
 // VarRef represents a reference to a variable.
 type VarRef struct {
 	Val  string
@@ -3717,6 +4105,16 @@ type DurationLiteral struct {
 // String returns a string representation of the literal.
 func (l *DurationLiteral) String() string { return FormatDuration(l.Val) }
 
+// CalendarDurationLiteral represents a duration in calendar months, such as
+// 1mo or 1y. Unlike a DurationLiteral, its length depends on the date it is
+// applied to.
+type CalendarDurationLiteral struct {
+	Months int
+}
+
+// String returns a string representation of the literal.
+func (l *CalendarDurationLiteral) String() string { return FormatCalendarDuration(l.Months) }
+
 // NilLiteral represents a nil literal.
 // This is not available to the query language itself. It's only used internally.
 type NilLiteral struct{}
@@ -3779,6 +4177,38 @@ type ParenExpr struct {
 // String returns a string representation of the parenthesized expression.
 func (e *ParenExpr) String() string { return fmt.Sprintf("(%s)", e.Expr.String()) }
 
+// CaseExpr represents a CASE expression. The result of the first WHEN clause
+// with a true condition is returned. If no condition is true, the ELSE result
+// is returned, or null if there is no ELSE clause.
+type CaseExpr struct {
+	WhenClauses []*WhenClause
+	Else        Expr
+}
+
+// WhenClause represents a WHEN ... THEN ... clause of a CASE expression.
+type WhenClause struct {
+	Cond   Expr
+	Result Expr
+}
+
+// String returns a string representation of the CASE expression.
+func (e *CaseExpr) String() string {
+	var buf strings.Builder
+	_, _ = buf.WriteString("CASE")
+	for _, w := range e.WhenClauses {
+		_, _ = buf.WriteString(" WHEN ")
+		_, _ = buf.WriteString(w.Cond.String())
+		_, _ = buf.WriteString(" THEN ")
+		_, _ = buf.WriteString(w.Result.String())
+	}
+	if e.Else != nil {
+		_, _ = buf.WriteString(" ELSE ")
+		_, _ = buf.WriteString(e.Else.String())
+	}
+	_, _ = buf.WriteString(" END")
+	return buf.String()
+}
+
 // RegexLiteral represents a regular expression.
 type RegexLiteral struct {
 	Val *regexp.Regexp
@@ -3833,12 +4263,22 @@ func CloneExpr(expr Expr) Expr {
 		return &BinaryExpr{Op: expr.Op, LHS: CloneExpr(expr.LHS), RHS: CloneExpr(expr.RHS)}
 	case *BooleanLiteral:
 		return &BooleanLiteral{Val: expr.Val}
+	case *BoundParameter:
+		return &BoundParameter{Name: expr.Name}
 	case *Call:
 		args := make([]Expr, len(expr.Args))
 		for i, arg := range expr.Args {
 			args[i] = CloneExpr(arg)
 		}
 		return &Call{Name: expr.Name, Args: args}
+	case *CalendarDurationLiteral:
+		return &CalendarDurationLiteral{Months: expr.Months}
+	case *CaseExpr:
+		clauses := make([]*WhenClause, len(expr.WhenClauses))
+		for i, w := range expr.WhenClauses {
+			clauses[i] = &WhenClause{Cond: CloneExpr(w.Cond), Result: CloneExpr(w.Result)}
+		}
+		return &CaseExpr{WhenClauses: clauses, Else: CloneExpr(expr.Else)}
 	case *Distinct:
 		return &Distinct{Val: expr.Val}
 	case *DurationLiteral:
@@ -3910,6 +4350,13 @@ func Walk(v Visitor, node Node) {
 			Walk(v, expr)
 		}
 
+	case *CaseExpr:
+		for _, w := range n.WhenClauses {
+			Walk(v, w.Cond)
+			Walk(v, w.Result)
+		}
+		Walk(v, n.Else)
+
 	case *CreateContinuousQueryStatement:
 		Walk(v, n.Source)
 
@@ -3935,6 +4382,9 @@ func Walk(v Visitor, node Node) {
 	case *Field:
 		Walk(v, n.Expr)
 
+	case *InsertStatement:
+		Walk(v, n.Measurement)
+
 	case Fields:
 		for _, c := range n {
 			Walk(v, c)
@@ -3952,6 +4402,7 @@ func Walk(v Visitor, node Node) {
 		Walk(v, n.Dimensions)
 		Walk(v, n.Sources)
 		Walk(v, n.Condition)
+		Walk(v, n.Having)
 		Walk(v, n.SortFields)
 
 	case *ShowFieldKeyCardinalityStatement:
@@ -4005,6 +4456,10 @@ func Walk(v Visitor, node Node) {
 	case *SubQuery:
 		Walk(v, n.Statement)
 
+	case *Join:
+		Walk(v, n.Left.Source)
+		Walk(v, n.Right.Source)
+
 	case Statements:
 		for _, s := range n {
 			Walk(v, s)
@@ -4057,10 +4512,19 @@ func Rewrite(r Rewriter, node Node) Node {
 		} else {
 			n.Condition = nil
 		}
+		if having := Rewrite(r, n.Having); having != nil {
+			n.Having = having.(Expr)
+		} else {
+			n.Having = nil
+		}
 
 	case *SubQuery:
 		n.Statement = Rewrite(r, n.Statement).(*SelectStatement)
 
+	case *Join:
+		n.Left.Source = Rewrite(r, n.Left.Source).(Source)
+		n.Right.Source = Rewrite(r, n.Right.Source).(Source)
+
 	case Fields:
 		for i, f := range n {
 			n[i] = Rewrite(r, f).(*Field)
@@ -4088,6 +4552,15 @@ func Rewrite(r Rewriter, node Node) Node {
 		for i, expr := range n.Args {
 			n.Args[i] = Rewrite(r, expr).(Expr)
 		}
+
+	case *CaseExpr:
+		for _, w := range n.WhenClauses {
+			w.Cond = Rewrite(r, w.Cond).(Expr)
+			w.Result = Rewrite(r, w.Result).(Expr)
+		}
+		if n.Else != nil {
+			n.Else = Rewrite(r, n.Else).(Expr)
+		}
 	}
 
 	return r.Rewrite(node)
@@ -4127,6 +4600,15 @@ func RewriteExpr(expr Expr, fn func(Expr) Expr) Expr {
 		for i, expr := range e.Args {
 			e.Args[i] = RewriteExpr(expr, fn)
 		}
+
+	case *CaseExpr:
+		for _, w := range e.WhenClauses {
+			w.Cond = RewriteExpr(w.Cond, fn)
+			w.Result = RewriteExpr(w.Result, fn)
+		}
+		if e.Else != nil {
+			e.Else = RewriteExpr(e.Else, fn)
+		}
 	}
 
 	return fn(expr)
@@ -4192,6 +4674,13 @@ func (v *ValuerEval) Eval(expr Expr) interface{} {
 			return val
 		}
 		return nil
+	case *CaseExpr:
+		for _, w := range expr.WhenClauses {
+			if v.EvalBool(w.Cond) {
+				return v.Eval(w.Result)
+			}
+		}
+		return v.Eval(expr.Else)
 	case *VarRef:
 		val, _ := v.Valuer.Value(expr.Val)
 		return val
@@ -4655,6 +5144,8 @@ func (v *TypeValuerEval) EvalType(expr Expr) (DataType, error) {
 		return v.evalCallExprType(expr)
 	case *BinaryExpr:
 		return v.evalBinaryExprType(expr)
+	case *CaseExpr:
+		return v.evalCaseExprType(expr)
 	case *ParenExpr:
 		return v.EvalType(expr.Expr)
 	case *NumberLiteral:
@@ -4706,12 +5197,35 @@ func (v *TypeValuerEval) evalVarRefExprType(expr *VarRef) (DataType, error) {
 						}
 					}
 				}
+			case *Join:
+				if t, err := v.evalJoinRefType(src, expr.Val); err != nil {
+					return Unknown, err
+				} else if typ.LessThan(t) {
+					typ = t
+				}
 			}
 		}
 	}
 	return typ, nil
 }
 
+// evalJoinRefType returns the type of a column of a join: a tag key the join
+// matches rows on, or a column qualified by the name of one of its sides.
+func (v *TypeValuerEval) evalJoinRefType(j *Join, name string) (DataType, error) {
+	if j.IsKey(name) {
+		return Tag, nil
+	}
+	side, column := j.Column(name)
+	if side == nil {
+		return Unknown, nil
+	}
+	valuer := TypeValuerEval{
+		TypeMapper: v.TypeMapper,
+		Sources:    Sources{side.Source},
+	}
+	return valuer.evalVarRefExprType(&VarRef{Val: column})
+}
+
 func (v *TypeValuerEval) evalCallExprType(expr *Call) (DataType, error) {
 	typmap, ok := v.TypeMapper.(CallTypeMapper)
 	if !ok {
@@ -4803,6 +5317,58 @@ func (v *TypeValuerEval) evalBinaryExprType(expr *BinaryExpr) (DataType, error)
 	return typ, nil
 }
 
+func (v *TypeValuerEval) evalCaseExprType(expr *CaseExpr) (DataType, error) {
+	// The conditions must be boolean and all of the results must have a
+	// compatible type. Numeric results of different types are floats.
+	results := make([]Expr, 0, len(expr.WhenClauses)+1)
+	for _, w := range expr.WhenClauses {
+		typ, err := v.EvalType(w.Cond)
+		if err != nil {
+			return Unknown, err
+		} else if typ != Unknown && typ != Boolean {
+			return Unknown, &TypeError{
+				Expr:    w.Cond,
+				Message: fmt.Sprintf("CASE condition must be a boolean, got %s", typ),
+			}
+		}
+		results = append(results, w.Result)
+	}
+	if expr.Else != nil {
+		results = append(results, expr.Else)
+	}
+
+	var typ DataType
+	for _, result := range results {
+		other, err := v.EvalType(result)
+		if err != nil {
+			return Unknown, err
+		} else if other == Unknown {
+			continue
+		}
+
+		switch {
+		case typ == Unknown:
+			typ = other
+		case typ == other:
+		case isNumericType(typ) && isNumericType(other):
+			typ = Float
+		case (typ == String || typ == Tag) && (other == String || other == Tag):
+			typ = String
+		default:
+			return Unknown, &TypeError{
+				Expr:    expr,
+				Message: fmt.Sprintf("incompatible types: %s and %s", typ, other),
+			}
+		}
+	}
+	return typ, nil
+}
+
+// isNumericType returns true if the type is a numeric type.
+func isNumericType(typ DataType) bool {
+	return typ == Float || typ == Integer || typ == Unsigned
+}
+
 // TypeError is an error when two types are incompatible.
 type TypeError struct {
 	// Expr contains the expression that generated the type error.
@@ -4864,6 +5430,29 @@ func FieldDimensions(sources Sources, m FieldMapper) (fields map[string]DataType
 					dimensions[expr.Val] = struct{}{}
 				}
 			}
+		case *Join:
+			// The fields and the tags of the sides other than the keys
+			// are columns qualified by the name of their side.
+			for _, side := range []*JoinSource{src.Left, src.Right} {
+				f, d, err := FieldDimensions(Sources{side.Source}, m)
+				if err != nil {
+					return nil, nil, err
+				}
+				for k, typ := range f {
+					k = side.Name() + "." + k
+					if fields[k].LessThan(typ) {
+						fields[k] = typ
+					}
+				}
+				for k := range d {
+					if !src.IsKey(k) {
+						fields[side.Name()+"."+k] = Tag
+					}
+				}
+			}
+			for _, k := range src.On {
+				dimensions[k] = struct{}{}
+			}
 		}
 	}
 	return
@@ -4891,6 +5480,8 @@ func reduce(expr Expr, valuer Valuer) Expr {
 		return reduceBinaryExpr(expr, valuer)
 	case *Call:
 		return reduceCall(expr, valuer)
+	case *CaseExpr:
+		return reduceCaseExpr(expr, valuer)
 	case *ParenExpr:
 		return reduceParenExpr(expr, valuer)
 	case *VarRef:
@@ -5456,6 +6047,32 @@ func reduceCall(expr *Call, valuer Valuer) Expr {
 	return &Call{Name: expr.Name, Args: args}
 }
 
+func reduceCaseExpr(expr *CaseExpr, valuer Valuer) Expr {
+	// Reduce each clause and remove the clauses that can never match.
+	clauses := make([]*WhenClause, 0, len(expr.WhenClauses))
+	for _, w := range expr.WhenClauses {
+		cond := reduce(w.Cond, valuer)
+		if lit, ok := cond.(*BooleanLiteral); ok {
+			if !lit.Val {
+				continue
+			} else if len(clauses) == 0 {
+				// The first remaining condition is always true.
+				return reduce(w.Result, valuer)
+			}
+		}
+		clauses = append(clauses, &WhenClause{Cond: cond, Result: reduce(w.Result, valuer)})
+	}
+
+	elseExpr := reduce(expr.Else, valuer)
+	if len(clauses) == 0 {
+		if elseExpr == nil {
+			return &NilLiteral{}
+		}
+		return elseExpr
+	}
+	return &CaseExpr{WhenClauses: clauses, Else: elseExpr}
+}
+
 func reduceParenExpr(expr *ParenExpr, valuer Valuer) Expr {
 	subexpr := reduce(expr.Expr, valuer)
 	if subexpr, ok := subexpr.(*BinaryExpr); ok {
@@ -5802,6 +6419,11 @@ func conditionExpr(cond Expr, valuer Valuer) (Expr, TimeRange, error) {
 		return reduce(&ParenExpr{Expr: expr}, nil), timeRange, nil
 	case *BooleanLiteral:
 		return cond, TimeRange{}, nil
+	case *Call:
+		// A function returning a boolean, such as regexp_match(), is a
+		// condition by itself. The type of the function is validated by
+		// the query engine.
+		return reduce(cond, valuer), TimeRange{}, nil
 	default:
 		return nil, TimeRange{}, fmt.Errorf("invalid condition expression: %s", cond)
 	}
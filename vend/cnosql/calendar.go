@@ -0,0 +1,125 @@
+package cnosql
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// week is the length of a week without a daylight saving time transition.
+const week = 7 * 24 * time.Hour
+
+// CalendarInterval represents an interval measured on the calendar of a time
+// zone instead of in a fixed number of nanoseconds. Calendar intervals start
+// at local midnight, so their length varies with the number of days in a
+// month and with daylight saving time transitions.
+type CalendarInterval struct {
+	// Months is the number of months in the interval. A year is 12 months.
+	Months int
+
+	// Weeks is the number of weeks in the interval. Each week starts on Weekday.
+	Weeks   int
+	Weekday time.Weekday
+}
+
+// IsZero returns true if the interval is not a calendar interval.
+func (c CalendarInterval) IsZero() bool { return c.Months == 0 && c.Weeks == 0 }
+
+// MaxDuration returns the longest duration of the interval. It is used
+// wherever a calendar interval has to be compared with, or approximated by,
+// a fixed duration.
+func (c CalendarInterval) MaxDuration() time.Duration {
+	if c.IsZero() {
+		return 0
+	}
+	// Allow for an hour gained by a daylight saving time transition.
+	return time.Duration(c.Months)*31*24*time.Hour + time.Duration(c.Weeks)*week + time.Hour
+}
+
+// Truncate returns the start of the interval that t falls within. The
+// interval is computed in the location of t. Months are aligned to January
+// 1970 and weeks to the first Weekday on or after January 1, 1970.
+func (c CalendarInterval) Truncate(t time.Time) time.Time {
+	y, m, d := t.Date()
+	switch {
+	case c.Months > 0:
+		months := floorDiv((y-1970)*12+int(m-time.January), c.Months) * c.Months
+		return time.Date(1970, time.January+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	case c.Weeks > 0:
+		days := int(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / int64(24*time.Hour/time.Second))
+		// January 1, 1970 was a Thursday.
+		first := (int(c.Weekday) - int(time.Thursday) + 7) % 7
+		days = first + floorDiv(days-first, 7*c.Weeks)*7*c.Weeks
+		return time.Date(1970, time.January, 1+days, 0, 0, 0, 0, t.Location())
+	}
+	return t
+}
+
+// Next returns the start of the interval following the interval that
+// starts at start.
+func (c CalendarInterval) Next(start time.Time) time.Time {
+	y, m, d := start.Date()
+	return time.Date(y, m+time.Month(c.Months), d+7*c.Weeks, 0, 0, 0, 0, start.Location())
+}
+
+// String returns a string representation of the interval.
+func (c CalendarInterval) String() string {
+	if c.Weeks > 0 {
+		return fmt.Sprintf("%dw, %s", c.Weeks, strings.ToLower(c.Weekday.String()))
+	}
+	return FormatCalendarDuration(c.Months)
+}
+
+// CalendarTimeDimension returns the calendar interval of a time() dimension.
+// Intervals in months or years, such as time(1mo), and intervals in weeks
+// starting on a weekday, such as time(1w, monday), are calendar intervals.
+// A zero interval is returned for fixed duration dimensions.
+func CalendarTimeDimension(call *Call) (CalendarInterval, error) {
+	if len(call.Args) == 0 {
+		return CalendarInterval{}, nil
+	}
+
+	switch lit := call.Args[0].(type) {
+	case *CalendarDurationLiteral:
+		if len(call.Args) > 1 {
+			return CalendarInterval{}, fmt.Errorf("time dimension %s does not support an offset", lit)
+		}
+		return CalendarInterval{Months: lit.Months}, nil
+	case *DurationLiteral:
+		if len(call.Args) != 2 {
+			return CalendarInterval{}, nil
+		}
+		ref, ok := call.Args[1].(*VarRef)
+		if !ok {
+			return CalendarInterval{}, nil
+		}
+		weekday, ok := parseWeekday(ref.Val)
+		if !ok {
+			return CalendarInterval{}, nil
+		} else if lit.Val <= 0 || lit.Val%week != 0 {
+			return CalendarInterval{}, errors.New("time dimension with a weekday must be a multiple of 1w")
+		}
+		return CalendarInterval{Weeks: int(lit.Val / week), Weekday: weekday}, nil
+	}
+	return CalendarInterval{}, nil
+}
+
+// parseWeekday returns the weekday with the given name.
+func parseWeekday(s string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(s, d.String()) {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// floorDiv returns x divided by y, rounded towards negative infinity.
+func floorDiv(x, y int) int {
+	q := x / y
+	if x%y != 0 && (x < 0) != (y < 0) {
+		q--
+	}
+	return q
+}
@@ -0,0 +1,100 @@
+package cnosql_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+)
+
+func TestCalendarInterval_Truncate(t *testing.T) {
+	newYork := mustLoadLocation("America/New_York")
+	for _, tt := range []struct {
+		cal        cnosql.CalendarInterval
+		t          string
+		start, end string
+	}{
+		{
+			cal:   cnosql.CalendarInterval{Months: 1},
+			t:     "2000-02-15T12:00:00Z",
+			start: "2000-02-01T00:00:00Z",
+			end:   "2000-03-01T00:00:00Z",
+		},
+		{
+			cal:   cnosql.CalendarInterval{Months: 1},
+			t:     "2000-04-10T12:00:00-04:00",
+			start: "2000-04-01T00:00:00-05:00",
+			end:   "2000-05-01T00:00:00-04:00",
+		},
+		{
+			cal:   cnosql.CalendarInterval{Months: 3},
+			t:     "2000-05-31T23:59:59-04:00",
+			start: "2000-04-01T00:00:00-05:00",
+			end:   "2000-07-01T00:00:00-04:00",
+		},
+		{
+			cal:   cnosql.CalendarInterval{Months: 12},
+			t:     "1969-07-20T20:17:00Z",
+			start: "1969-01-01T00:00:00Z",
+			end:   "1970-01-01T00:00:00Z",
+		},
+		{
+			cal:   cnosql.CalendarInterval{Weeks: 1, Weekday: time.Monday},
+			t:     "2000-10-29T12:00:00-05:00",
+			start: "2000-10-23T00:00:00-04:00",
+			end:   "2000-10-30T00:00:00-05:00",
+		},
+		{
+			cal:   cnosql.CalendarInterval{Weeks: 1, Weekday: time.Sunday},
+			t:     "2000-10-29T00:00:00-04:00",
+			start: "2000-10-29T00:00:00-04:00",
+			end:   "2000-11-05T00:00:00-05:00",
+		},
+	} {
+		t.Run(tt.cal.String()+"/"+tt.t, func(t *testing.T) {
+			ts := mustParseTime(tt.t)
+			if !strings.HasSuffix(tt.t, "Z") {
+				ts = ts.In(newYork)
+			}
+
+			start := tt.cal.Truncate(ts)
+			if exp := mustParseTime(tt.start); !start.Equal(exp) {
+				t.Errorf("unexpected start: got=%s exp=%s", start, exp)
+			}
+			if end, exp := tt.cal.Next(start), mustParseTime(tt.end); !end.Equal(exp) {
+				t.Errorf("unexpected end: got=%s exp=%s", end, exp)
+			}
+		})
+	}
+}
+
+func TestSelectStatement_GroupByCalendar(t *testing.T) {
+	for _, tt := range []struct {
+		s   string
+		cal cnosql.CalendarInterval
+		err string
+	}{
+		{s: `SELECT mean(value) FROM cpu GROUP BY time(1mo)`, cal: cnosql.CalendarInterval{Months: 1}},
+		{s: `SELECT mean(value) FROM cpu GROUP BY time(2y)`, cal: cnosql.CalendarInterval{Months: 24}},
+		{s: `SELECT mean(value) FROM cpu GROUP BY time(2w, Monday)`, cal: cnosql.CalendarInterval{Weeks: 2, Weekday: time.Monday}},
+		{s: `SELECT mean(value) FROM cpu GROUP BY time(1w)`},
+		{s: `SELECT mean(value) FROM cpu GROUP BY time(1d, 1h)`},
+		{s: `SELECT mean(value) FROM cpu GROUP BY time(1mo, 1d)`, err: `time dimension 1mo does not support an offset`},
+		{s: `SELECT mean(value) FROM cpu GROUP BY time(1d, monday)`, err: `time dimension with a weekday must be a multiple of 1w`},
+	} {
+		t.Run(tt.s, func(t *testing.T) {
+			stmt, err := cnosql.ParseStatement(tt.s)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cal, err := stmt.(*cnosql.SelectStatement).GroupByCalendar()
+			if errstring(err) != tt.err {
+				t.Fatalf("unexpected error: got=%v exp=%s", err, tt.err)
+			} else if cal != tt.cal {
+				t.Fatalf("unexpected interval: got=%#v exp=%#v", cal, tt.cal)
+			}
+		})
+	}
+}
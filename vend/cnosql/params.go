@@ -2,7 +2,9 @@ package cnosql
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -140,6 +142,79 @@ func bindObjectValue(m map[string]interface{}) Value {
 	}
 }
 
+// BoundParameters returns the names of the bound parameters that were kept in
+// the AST by a parser, in the order they first appear.
+func BoundParameters(node Node) []string {
+	var names []string
+	seen := make(map[string]struct{})
+	WalkFunc(node, func(n Node) {
+		if bp, ok := n.(*BoundParameter); ok {
+			if _, ok := seen[bp.Name]; !ok {
+				seen[bp.Name] = struct{}{}
+				names = append(names, bp.Name)
+			}
+		}
+	})
+	return names
+}
+
+// BindParameters replaces the bound parameters kept in the AST of node by a
+// parser with the literals of their values in params. The node is modified in
+// place, so a statement shared with other queries must be cloned first.
+func BindParameters(node Node, params map[string]interface{}) error {
+	var err error
+	RewriteFunc(node, func(n Node) Node {
+		bp, ok := n.(*BoundParameter)
+		if !ok || err != nil {
+			return n
+		}
+
+		v, ok := params[bp.Name]
+		if !ok {
+			err = fmt.Errorf("missing parameter: %s", bp.Name)
+			return n
+		}
+		lit, e := boundLiteral(BindValue(v))
+		if e != nil {
+			err = e
+			return n
+		}
+		return lit
+	})
+	return err
+}
+
+// boundLiteral returns the literal a parser reads for the value of a bound
+// parameter.
+func boundLiteral(v Value) (Expr, error) {
+	switch v := v.(type) {
+	case Identifier:
+		return &VarRef{Val: string(v)}, nil
+	case StringValue:
+		return &StringLiteral{Val: string(v)}, nil
+	case RegexValue:
+		re, err := regexp.Compile(string(v))
+		if err != nil {
+			return nil, err
+		}
+		return &RegexLiteral{Val: re}, nil
+	case NumberValue:
+		return &NumberLiteral{Val: float64(v)}, nil
+	case IntegerValue:
+		return &IntegerLiteral{Val: int64(v)}, nil
+	case BooleanValue:
+		return &BooleanLiteral{Val: bool(v)}, nil
+	case DurationValue:
+		d, err := ParseDuration(string(v))
+		if err != nil {
+			return nil, err
+		}
+		return &DurationLiteral{Val: d}, nil
+	default:
+		return nil, errors.New(v.Value())
+	}
+}
+
 func (v Identifier) TokenType() Token   { return IDENT }
 func (v Identifier) Value() string      { return string(v) }
 func (v StringValue) TokenType() Token  { return STRING }
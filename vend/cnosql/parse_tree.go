@@ -106,6 +106,9 @@ func init() {
 	Language.Handle(DELETE, func(p *Parser) (Statement, error) {
 		return p.parseDeleteStatement()
 	})
+	Language.Handle(INSERT, func(p *Parser) (Statement, error) {
+		return p.parseInsertStatement()
+	})
 	Language.Group(SHOW).With(func(show *ParseTree) {
 		show.Group(CONTINUOUS).Handle(QUERIES, func(p *Parser) (Statement, error) {
 			return p.parseShowContinuousQueriesStatement()
@@ -179,6 +182,9 @@ func init() {
 		create.Handle(DATABASE, func(p *Parser) (Statement, error) {
 			return p.parseCreateDatabaseStatement()
 		})
+		create.Handle(MEASUREMENT, func(p *Parser) (Statement, error) {
+			return p.parseCreateMeasurementStatement()
+		})
 		create.Handle(USER, func(p *Parser) (Statement, error) {
 			return p.parseCreateUserStatement()
 		})
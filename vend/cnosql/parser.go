@@ -23,6 +23,10 @@ const (
 type Parser struct {
 	s      *bufScanner
 	params map[string]Value
+
+	// keepParams is set when the bound parameters without a value are kept
+	// in the AST.
+	keepParams bool
 }
 
 // NewParser returns a new instance of Parser.
@@ -38,6 +42,13 @@ func (p *Parser) SetParams(params map[string]interface{}) {
 	}
 }
 
+// KeepParams makes the parser keep the bound parameters that have no value as
+// BoundParameter literals instead of returning an error. It is used to parse
+// prepared statements before the values of their parameters are known.
+func (p *Parser) KeepParams() {
+	p.keepParams = true
+}
+
 // ParseQuery parses a query string and returns its AST representation.
 func ParseQuery(s string) (*Query, error) { return NewParser(strings.NewReader(s)).ParseQuery() }
 
@@ -755,8 +766,13 @@ func (p *Parser) parseSelectStatement(tr targetRequirement) (*SelectStatement, e
 		return nil, err
 	}
 
+	// Parse having: "HAVING EXPR".
+	if stmt.Having, err = p.parseHaving(); err != nil {
+		return nil, err
+	}
+
 	// Parse sort: "ORDER BY FIELD+".
-	if stmt.SortFields, err = p.parseOrderBy(); err != nil {
+	if stmt.SortFields, err = p.parseOrderBy(true); err != nil {
 		return nil, err
 	}
 
@@ -898,6 +914,147 @@ func (p *Parser) parseDeleteStatement() (Statement, error) {
 	return stmt, nil
 }
 
+// parseInsertStatement parses a string and returns an InsertStatement.
+// This function assumes the INSERT token has already been consumed.
+func (p *Parser) parseInsertStatement() (*InsertStatement, error) {
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != INTO {
+		return nil, newParseError(tokstr(tok, lit), []string{"INTO"}, pos)
+	}
+
+	// db, rp, and / or measurement
+	idents, err := p.parseSegmentedIdents()
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := &InsertStatement{Measurement: &Measurement{}}
+	switch len(idents) {
+	case 1:
+		stmt.Measurement.Name = idents[0]
+	case 2:
+		stmt.Measurement.RetentionPolicy = idents[0]
+		stmt.Measurement.Name = idents[1]
+	case 3:
+		stmt.Measurement.Database = idents[0]
+		stmt.Measurement.RetentionPolicy = idents[1]
+		stmt.Measurement.Name = idents[2]
+	}
+
+	// Parse the column list.
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != LPAREN {
+		return nil, newParseError(tokstr(tok, lit), []string{"("}, pos)
+	}
+	seen := make(map[string]struct{})
+	fields := 0
+	for {
+		_, pos, _ := p.ScanIgnoreWhitespace()
+		p.Unscan()
+		c, err := p.ParseVarRef()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := seen[c.Val]; ok {
+			return nil, &ParseError{Message: fmt.Sprintf("duplicate column %s", c.Val), Pos: pos}
+		}
+		seen[c.Val] = struct{}{}
+		if strings.EqualFold(c.Val, "time") {
+			if c.Type != Unknown {
+				return nil, &ParseError{Message: "time column cannot be typed", Pos: pos}
+			}
+		} else if c.Type != Tag {
+			fields++
+		}
+		stmt.Columns = append(stmt.Columns, c)
+
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != COMMA {
+			p.Unscan()
+			break
+		}
+	}
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != RPAREN {
+		return nil, newParseError(tokstr(tok, lit), []string{")"}, pos)
+	} else if fields == 0 {
+		return nil, &ParseError{Message: "at least one field column required", Pos: pos}
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != VALUES {
+		return nil, newParseError(tokstr(tok, lit), []string{"VALUES"}, pos)
+	}
+
+	// Parse a tuple of values per point.
+	for {
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != LPAREN {
+			return nil, newParseError(tokstr(tok, lit), []string{"("}, pos)
+		}
+		values := make([]Expr, 0, len(stmt.Columns))
+		for {
+			_, pos, _ := p.ScanIgnoreWhitespace()
+			p.Unscan()
+			if len(values) == len(stmt.Columns) {
+				return nil, &ParseError{Message: fmt.Sprintf("more values than the %d columns", len(stmt.Columns)), Pos: pos}
+			}
+			expr, err := p.ParseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if err := validateInsertValue(stmt.Columns[len(values)], expr); err != nil {
+				return nil, &ParseError{Message: err.Error(), Pos: pos}
+			}
+			values = append(values, expr)
+
+			if tok, _, _ := p.ScanIgnoreWhitespace(); tok != COMMA {
+				p.Unscan()
+				break
+			}
+		}
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != RPAREN {
+			return nil, newParseError(tokstr(tok, lit), []string{")"}, pos)
+		} else if len(values) != len(stmt.Columns) {
+			return nil, &ParseError{Message: fmt.Sprintf("%d values for %d columns", len(values), len(stmt.Columns)), Pos: pos}
+		}
+		stmt.Values = append(stmt.Values, values)
+
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != COMMA {
+			p.Unscan()
+			break
+		}
+	}
+
+	return stmt, nil
+}
+
+// validateInsertValue returns an error if expr is not a valid value of the
+// column c of an INSERT statement.
+func validateInsertValue(c *VarRef, expr Expr) error {
+	if strings.EqualFold(c.Val, "time") && c.Type == Unknown {
+		switch expr := expr.(type) {
+		case *IntegerLiteral:
+			return nil
+		case *StringLiteral:
+			if _, err := expr.ToTimeLiteral(time.UTC); err != nil {
+				return fmt.Errorf("invalid time %s", expr)
+			}
+			return nil
+		case *Call:
+			if expr.Name == "now" && len(expr.Args) == 0 {
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid time %s", expr)
+	}
+
+	switch expr.(type) {
+	case *StringLiteral:
+		return nil
+	case *IntegerLiteral, *UnsignedLiteral, *NumberLiteral, *BooleanLiteral:
+		if c.Type != Tag {
+			return nil
+		}
+		return fmt.Errorf("tag %s must be a string", c.Val)
+	}
+	return fmt.Errorf("invalid value %s for column %s", expr, c.Val)
+}
+
 // parseShowSeriesStatement parses a string and returns a Statement.
 // This function assumes the "SHOW SERIES" tokens have already been consumed.
 func (p *Parser) parseShowSeriesStatement() (Statement, error) {
@@ -944,7 +1101,7 @@ func (p *Parser) parseShowSeriesStatement() (Statement, error) {
 	}
 
 	// Parse sort: "ORDER BY FIELD+".
-	if stmt.SortFields, err = p.parseOrderBy(); err != nil {
+	if stmt.SortFields, err = p.parseOrderBy(false); err != nil {
 		return nil, err
 	}
 
@@ -1118,7 +1275,7 @@ func (p *Parser) parseShowMeasurementsStatement() (*ShowMeasurementsStatement, e
 	}
 
 	// Parse sort: "ORDER BY FIELD+".
-	if stmt.SortFields, err = p.parseOrderBy(); err != nil {
+	if stmt.SortFields, err = p.parseOrderBy(false); err != nil {
 		return nil, err
 	}
 
@@ -1260,7 +1417,7 @@ func (p *Parser) parseShowTagKeysStatement() (*ShowTagKeysStatement, error) {
 	}
 
 	// Parse sort: "ORDER BY FIELD+".
-	if stmt.SortFields, err = p.parseOrderBy(); err != nil {
+	if stmt.SortFields, err = p.parseOrderBy(false); err != nil {
 		return nil, err
 	}
 
@@ -1331,7 +1488,7 @@ func (p *Parser) parseShowTagValuesStatement() (Statement, error) {
 	}
 
 	// Parse sort: "ORDER BY FIELD+".
-	if stmt.SortFields, err = p.parseOrderBy(); err != nil {
+	if stmt.SortFields, err = p.parseOrderBy(false); err != nil {
 		return nil, err
 	}
 
@@ -1549,7 +1706,7 @@ func (p *Parser) parseShowFieldKeysStatement() (*ShowFieldKeysStatement, error)
 	}
 
 	// Parse sort: "ORDER BY FIELD+".
-	if stmt.SortFields, err = p.parseOrderBy(); err != nil {
+	if stmt.SortFields, err = p.parseOrderBy(false); err != nil {
 		return nil, err
 	}
 
@@ -1566,6 +1723,90 @@ func (p *Parser) parseShowFieldKeysStatement() (*ShowFieldKeysStatement, error)
 	return stmt, nil
 }
 
+// parseCreateMeasurementStatement parses a string and returns a CreateMeasurementStatement.
+// This function assumes the "CREATE MEASUREMENT" tokens have already been consumed.
+func (p *Parser) parseCreateMeasurementStatement() (*CreateMeasurementStatement, error) {
+	stmt := &CreateMeasurementStatement{}
+
+	// Parse the name of the measurement.
+	lit, err := p.ParseIdent()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Name = lit
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != LPAREN {
+		return nil, newParseError(tokstr(tok, lit), []string{"("}, pos)
+	}
+
+	// Parse the optional tag key list, terminated by a semicolon.
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok == IDENT && strings.EqualFold(lit, "TAGS") {
+		if stmt.Tags, err = p.ParseIdentList(); err != nil {
+			return nil, err
+		}
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != SEMICOLON {
+			return nil, newParseError(tokstr(tok, lit), []string{";"}, pos)
+		}
+		tok, pos, lit = p.ScanIgnoreWhitespace()
+	}
+
+	// Parse the field list.
+	if tok != IDENT || !strings.EqualFold(lit, "FIELDS") {
+		return nil, newParseError(tokstr(tok, lit), []string{"TAGS", "FIELDS"}, pos)
+	}
+	for {
+		f, err := p.parseMeasurementField()
+		if err != nil {
+			return nil, err
+		}
+		stmt.Fields = append(stmt.Fields, f)
+
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != COMMA {
+			p.Unscan()
+			break
+		}
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != RPAREN {
+		return nil, newParseError(tokstr(tok, lit), []string{")"}, pos)
+	}
+
+	return stmt, nil
+}
+
+// parseMeasurementField parses a field declaration of a measurement schema:
+// a field name, its type and the optional REQUIRED modifier.
+func (p *Parser) parseMeasurementField() (*MeasurementField, error) {
+	f := &MeasurementField{}
+
+	lit, err := p.ParseIdent()
+	if err != nil {
+		return nil, err
+	}
+	f.Name = lit
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok == IDENT {
+		switch f.Type = DataTypeFromString(strings.ToLower(lit)); f.Type {
+		case Float, Integer, Unsigned, String, Boolean:
+		default:
+			f.Type = Unknown
+		}
+	}
+	if f.Type == Unknown {
+		return nil, newParseError(tokstr(tok, lit), []string{"FLOAT", "INTEGER", "UNSIGNED", "STRING", "BOOLEAN"}, pos)
+	}
+
+	if tok, _, lit := p.ScanIgnoreWhitespace(); tok == IDENT && strings.EqualFold(lit, "REQUIRED") {
+		f.Required = true
+	} else {
+		p.Unscan()
+	}
+
+	return f, nil
+}
+
 // parseDropMeasurementStatement parses a string and returns a DropMeasurementStatement.
 // This function assumes the "DROP MEASUREMENT" tokens have already been consumed.
 func (p *Parser) parseDropMeasurementStatement() (*DropMeasurementStatement, error) {
@@ -1694,8 +1935,7 @@ func (p *Parser) parseCreateContinuousQueryStatement() (*CreateContinuousQuerySt
 	stmt.Database = ident
 
 	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == RESAMPLE {
-		stmt.ResampleEvery, stmt.ResampleFor, err = p.parseResample()
-		if err != nil {
+		if err := p.parseResample(stmt); err != nil {
 			return nil, err
 		}
 	} else {
@@ -2109,6 +2349,25 @@ type validateField struct {
 }
 
 func (c *validateField) Visit(n Node) Visitor {
+	// Comparisons are allowed in the conditions of a CASE expression.
+	if e, ok := n.(*CaseExpr); ok {
+		for _, w := range e.WhenClauses {
+			Walk(c, w.Result)
+		}
+		Walk(c, e.Else)
+		return nil
+	}
+
+	// Comparisons are allowed in the condition of a state function.
+	if e, ok := n.(*Call); ok && (e.Name == "state_duration" || e.Name == "state_count") {
+		for i, arg := range e.Args {
+			if i != 1 {
+				Walk(c, arg)
+			}
+		}
+		return nil
+	}
+
 	e, ok := n.(*BinaryExpr)
 	if !ok {
 		return c
@@ -2125,7 +2384,8 @@ func (c *validateField) Visit(n Node) Visitor {
 	return c
 }
 
-// parseAlias parses the "AS IDENT" alias for fields and dimensions.
+// parseAlias parses the "AS IDENT" alias for fields, dimensions and the sides
+// of joins.
 func (p *Parser) parseAlias() (string, error) {
 	// Check if the next token is "AS". If not, then Unscan and exit.
 	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != AS {
@@ -2150,6 +2410,25 @@ func (p *Parser) parseSources(subqueries bool) (Sources, error) {
 		if err != nil {
 			return nil, err
 		}
+
+		// Sources of queries allowing subqueries may be joined.
+		if subqueries {
+			_, pos, _ := p.ScanIgnoreWhitespace()
+			p.Unscan()
+			join, err := p.parseJoin(s)
+			if err != nil {
+				return nil, err
+			} else if join != nil {
+				if len(sources) > 0 {
+					return nil, &ParseError{Message: "a join must be the only source", Pos: pos}
+				}
+				if tok, pos, _ := p.ScanIgnoreWhitespace(); tok == COMMA {
+					return nil, &ParseError{Message: "a join must be the only source", Pos: pos}
+				}
+				p.Unscan()
+				return Sources{join}, nil
+			}
+		}
 		sources = append(sources, s)
 
 		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != COMMA {
@@ -2161,6 +2440,92 @@ func (p *Parser) parseSources(subqueries bool) (Sources, error) {
 	return sources, nil
 }
 
+// parseJoin parses the join of the source left with another source if left
+// is followed by an alias or a JOIN clause, and returns nil otherwise.
+//
+//	source [AS alias] [INNER | {LEFT | RIGHT | FULL} [OUTER]] JOIN source [AS alias] [ON key [, key]...]
+func (p *Parser) parseJoin(left Source) (*Join, error) {
+	j := &Join{Left: &JoinSource{Source: left}}
+
+	var err error
+	if j.Left.Alias, err = p.parseAlias(); err != nil {
+		return nil, err
+	}
+
+	// Parse the type of the join, which is an inner join by default.
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	typed := false
+	if tok == IDENT {
+		switch strings.ToUpper(lit) {
+		case "INNER":
+			j.Type, typed = InnerJoin, true
+		case "LEFT":
+			j.Type, typed = LeftJoin, true
+		case "RIGHT":
+			j.Type, typed = RightJoin, true
+		case "FULL":
+			j.Type, typed = FullJoin, true
+		}
+	}
+	if typed {
+		if j.Type != InnerJoin {
+			if tok, _, lit := p.ScanIgnoreWhitespace(); tok != IDENT || !strings.EqualFold(lit, "OUTER") {
+				p.Unscan()
+			}
+		}
+		tok, pos, lit = p.ScanIgnoreWhitespace()
+	}
+	if tok != IDENT || !strings.EqualFold(lit, "JOIN") {
+		if typed || j.Left.Alias != "" {
+			return nil, newParseError(tokstr(tok, lit), []string{"JOIN"}, pos)
+		}
+		p.Unscan()
+		return nil, nil
+	}
+
+	right, err := p.parseSource(true)
+	if err != nil {
+		return nil, err
+	}
+	j.Right = &JoinSource{Source: right}
+	if j.Right.Alias, err = p.parseAlias(); err != nil {
+		return nil, err
+	}
+
+	// The columns of each side are qualified by the name of the side.
+	for _, side := range []*JoinSource{j.Left, j.Right} {
+		if side.Name() == "" {
+			return nil, &ParseError{Message: fmt.Sprintf("join source %s requires an alias", side.Source), Pos: pos}
+		}
+	}
+	if j.Left.Name() == j.Right.Name() {
+		return nil, &ParseError{Message: fmt.Sprintf("duplicate join source name %s", j.Right.Name()), Pos: pos}
+	}
+
+	// Parse the tag keys matching the rows of both sides.
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != ON {
+		p.Unscan()
+		return j, nil
+	}
+	for {
+		_, pos, _ := p.ScanIgnoreWhitespace()
+		p.Unscan()
+		key, err := p.ParseIdent()
+		if err != nil {
+			return nil, err
+		} else if j.IsKey(key) {
+			return nil, &ParseError{Message: fmt.Sprintf("duplicate join key %s", key), Pos: pos}
+		}
+		j.On = append(j.On, key)
+
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != COMMA {
+			p.Unscan()
+			break
+		}
+	}
+	return j, nil
+}
+
 // peekRune returns the next rune that would be read by the scanner.
 func (p *Parser) peekRune() rune {
 	r, _, _ := p.s.s.r.ReadRune()
@@ -2261,6 +2626,16 @@ func (p *Parser) parseCondition() (Expr, error) {
 	return expr, nil
 }
 
+// parseHaving parses the "HAVING" clause of the query, if it exists.
+func (p *Parser) parseHaving() (Expr, error) {
+	// HAVING is not a reserved keyword so it is scanned as an identifier.
+	if tok, _, lit := p.ScanIgnoreWhitespace(); tok != IDENT || !strings.EqualFold(lit, "having") {
+		p.Unscan()
+		return nil, nil
+	}
+	return p.ParseExpr()
+}
+
 // parseDimensions parses the "GROUP BY" clause of the query, if it exists.
 func (p *Parser) parseDimensions() (Dimensions, error) {
 	// If the next token is not GROUP then exit.
@@ -2414,7 +2789,8 @@ func (p *Parser) ParseOptionalTokenAndInt(t Token) (int, error) {
 }
 
 // parseOrderBy parses the "ORDER BY" clause of a query, if it exists.
-func (p *Parser) parseOrderBy() (SortFields, error) {
+// Fields other than time are only allowed if values is set.
+func (p *Parser) parseOrderBy(values bool) (SortFields, error) {
 	// Return nil result and nil error if no ORDER token at this position.
 	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != ORDER {
 		p.Unscan()
@@ -2427,7 +2803,7 @@ func (p *Parser) parseOrderBy() (SortFields, error) {
 	}
 
 	// Parse the ORDER BY fields.
-	fields, err := p.parseSortFields()
+	fields, err := p.parseSortFields(values)
 	if err != nil {
 		return nil, err
 	}
@@ -2436,7 +2812,7 @@ func (p *Parser) parseOrderBy() (SortFields, error) {
 }
 
 // parseSortFields parses the sort fields for an ORDER BY clause.
-func (p *Parser) parseSortFields() (SortFields, error) {
+func (p *Parser) parseSortFields(values bool) (SortFields, error) {
 	var fields SortFields
 
 	tok, pos, lit := p.ScanIgnoreWhitespace()
@@ -2448,12 +2824,12 @@ func (p *Parser) parseSortFields() (SortFields, error) {
 	// If it's a token, parse it as a sort field.  At least one is required.
 	case IDENT:
 		p.Unscan()
-		field, err := p.parseSortField()
+		field, err := p.parseSortField(values)
 		if err != nil {
 			return nil, err
 		}
 
-		if lit != "time" {
+		if !values && lit != "time" {
 			return nil, errors.New("only ORDER BY time supported at this time")
 		}
 
@@ -2472,7 +2848,7 @@ func (p *Parser) parseSortFields() (SortFields, error) {
 			break
 		}
 
-		field, err := p.parseSortField()
+		field, err := p.parseSortField(values)
 		if err != nil {
 			return nil, err
 		}
@@ -2480,15 +2856,16 @@ func (p *Parser) parseSortFields() (SortFields, error) {
 		fields = append(fields, field)
 	}
 
-	if len(fields) > 1 {
+	if !values && len(fields) > 1 {
 		return nil, errors.New("only ORDER BY time supported at this time")
 	}
 
 	return fields, nil
 }
 
-// parseSortField parses one field of an ORDER BY clause.
-func (p *Parser) parseSortField() (*SortField, error) {
+// parseSortField parses one field of an ORDER BY clause. If values is set,
+// the field may also be a call such as max(value).
+func (p *Parser) parseSortField(values bool) (*SortField, error) {
 	field := &SortField{}
 
 	// Parse sort field name.
@@ -2498,6 +2875,19 @@ func (p *Parser) parseSortField() (*SortField, error) {
 	}
 	field.Name = ident
 
+	// Parse the call if the name is followed by a left parenthesis.
+	if values {
+		if tok, _, _ := p.Scan(); tok == LPAREN {
+			call, err := p.parseCall(ident)
+			if err != nil {
+				return nil, err
+			}
+			field.Name = call.String()
+		} else {
+			p.Unscan()
+		}
+	}
+
 	// Check for optional ASC or DESC clause. Default is ASC.
 	tok, _, _ := p.ScanIgnoreWhitespace()
 	if tok != ASC && tok != DESC {
@@ -2713,6 +3103,14 @@ func (p *Parser) parseUnaryExpr() (Expr, error) {
 	tok, pos, lit := p.ScanIgnoreWhitespace()
 	switch tok {
 	case IDENT:
+		// CASE is only recognized when it is followed by WHEN so it can
+		// still be used as an identifier.
+		if strings.EqualFold(lit, "CASE") {
+			if expr, err := p.parseCaseExpr(); expr != nil || err != nil {
+				return expr, err
+			}
+		}
+
 		// If the next immediate token is a left parentheses, parse as function call.
 		// Otherwise parse as a variable reference.
 		if tok0, _, _ := p.Scan(); tok0 == LPAREN {
@@ -2798,6 +3196,9 @@ func (p *Parser) parseUnaryExpr() (Expr, error) {
 
 		v, ok := p.params[k]
 		if !ok {
+			if p.keepParams {
+				return &BoundParameter{Name: k}, nil
+			}
 			return nil, fmt.Errorf("missing parameter: %s", k)
 		}
 
@@ -2900,6 +3301,54 @@ func (p *Parser) parseRegex() (*RegexLiteral, error) {
 	return &RegexLiteral{Val: re}, nil
 }
 
+// parseCaseExpr parses a CASE expression after the CASE keyword. It returns
+// nil without consuming any tokens if CASE is not followed by WHEN.
+func (p *Parser) parseCaseExpr() (*CaseExpr, error) {
+	if tok, _, _ := p.Scan(); tok != WS {
+		p.Unscan()
+		return nil, nil
+	}
+	if tok, _, lit := p.Scan(); tok != IDENT || !strings.EqualFold(lit, "WHEN") {
+		p.Unscan()
+		p.Unscan()
+		return nil, nil
+	}
+
+	expr := &CaseExpr{}
+	for {
+		cond, err := p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != IDENT || !strings.EqualFold(lit, "THEN") {
+			return nil, newParseError(tokstr(tok, lit), []string{"THEN"}, pos)
+		}
+		result, err := p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+		expr.WhenClauses = append(expr.WhenClauses, &WhenClause{Cond: cond, Result: result})
+
+		// Parse another WHEN clause, the ELSE clause or the end of the expression.
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		if tok == IDENT && strings.EqualFold(lit, "WHEN") {
+			continue
+		} else if tok == IDENT && strings.EqualFold(lit, "ELSE") {
+			if expr.Else, err = p.ParseExpr(); err != nil {
+				return nil, err
+			}
+			tok, pos, lit = p.ScanIgnoreWhitespace()
+			if tok != END {
+				return nil, newParseError(tokstr(tok, lit), []string{"END"}, pos)
+			}
+			return expr, nil
+		} else if tok != END {
+			return nil, newParseError(tokstr(tok, lit), []string{"WHEN", "ELSE", "END"}, pos)
+		}
+		return expr, nil
+	}
+}
+
 // parseCall parses a function call.
 // This function assumes the function name and LPAREN have been consumed.
 func (p *Parser) parseCall(name string) (*Call, error) {
@@ -2919,11 +3368,20 @@ func (p *Parser) parseCall(name string) (*Call, error) {
 		}
 		p.Unscan()
 
-		arg, err := p.ParseExpr()
-		if err != nil {
-			return nil, err
+		// The interval of time() may be a calendar duration.
+		if name == "time" {
+			if lit := p.parseCalendarDuration(); lit != nil {
+				args = append(args, lit)
+			}
+		}
+
+		if len(args) == 0 {
+			arg, err := p.ParseExpr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
 		}
-		args = append(args, arg)
 	}
 
 	// Parse additional function arguments if there is a comma.
@@ -2958,49 +3416,70 @@ func (p *Parser) parseCall(name string) (*Call, error) {
 	return &Call{Name: name, Args: args}, nil
 }
 
+// parseCalendarDuration parses a duration in calendar months, such as 1mo
+// or 1y. It returns nil if the next token is not a calendar duration.
+func (p *Parser) parseCalendarDuration() *CalendarDurationLiteral {
+	if tok, _, lit := p.ScanIgnoreWhitespace(); tok == DURATIONVAL {
+		if months, err := ParseCalendarDuration(lit); err == nil {
+			return &CalendarDurationLiteral{Months: months}
+		}
+	}
+	p.Unscan()
+	return nil
+}
+
 // parseResample parses a RESAMPLE [EVERY <duration>] [FOR <duration>].
 // This function assumes RESAMPLE has already been consumed.
 // EVERY and FOR are optional, but at least one of the two has to be used.
-func (p *Parser) parseResample() (time.Duration, time.Duration, error) {
-	var interval time.Duration
+func (p *Parser) parseResample(stmt *CreateContinuousQueryStatement) error {
+	var found bool
 	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == EVERY {
-		tok, pos, lit := p.ScanIgnoreWhitespace()
-		if tok != DURATIONVAL {
-			return 0, 0, newParseError(tokstr(tok, lit), []string{"duration"}, pos)
-		}
-
-		d, err := ParseDuration(lit)
+		d, months, err := p.parseResampleDuration()
 		if err != nil {
-			return 0, 0, &ParseError{Message: err.Error(), Pos: pos}
+			return err
 		}
-		interval = d
+		stmt.ResampleEvery, stmt.ResampleEveryMonths = d, months
+		found = true
 	} else {
 		p.Unscan()
 	}
 
-	var maxDuration time.Duration
 	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == FOR {
-		tok, pos, lit := p.ScanIgnoreWhitespace()
-		if tok != DURATIONVAL {
-			return 0, 0, newParseError(tokstr(tok, lit), []string{"duration"}, pos)
-		}
-
-		d, err := ParseDuration(lit)
+		d, months, err := p.parseResampleDuration()
 		if err != nil {
-			return 0, 0, &ParseError{Message: err.Error(), Pos: pos}
+			return err
 		}
-		maxDuration = d
+		stmt.ResampleFor, stmt.ResampleForMonths = d, months
+		found = true
 	} else {
 		p.Unscan()
 	}
 
 	// Neither EVERY or FOR were read, so read the next token again
 	// so we can return a suitable error message.
-	if interval == 0 && maxDuration == 0 {
+	if !found {
 		tok, pos, lit := p.ScanIgnoreWhitespace()
-		return 0, 0, newParseError(tokstr(tok, lit), []string{"EVERY", "FOR"}, pos)
+		return newParseError(tokstr(tok, lit), []string{"EVERY", "FOR"}, pos)
+	}
+	return nil
+}
+
+// parseResampleDuration parses the duration of a RESAMPLE EVERY or FOR clause.
+// The duration is either a fixed duration or a number of calendar months.
+func (p *Parser) parseResampleDuration() (time.Duration, int, error) {
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok != DURATIONVAL {
+		return 0, 0, newParseError(tokstr(tok, lit), []string{"duration"}, pos)
+	}
+
+	d, err := ParseDuration(lit)
+	if err != nil {
+		if months, cerr := ParseCalendarDuration(lit); cerr == nil {
+			return 0, months, nil
+		}
+		return 0, 0, &ParseError{Message: err.Error(), Pos: pos}
 	}
-	return interval, maxDuration, nil
+	return d, 0, nil
 }
 
 // Scan returns the next token from the underlying scanner.
@@ -3148,6 +3627,44 @@ func ParseDuration(s string) (time.Duration, error) {
 	return d, nil
 }
 
+// ParseCalendarDuration parses a duration in calendar months from a string.
+// The duration is a positive number of months, such as 3mo, or years, such as 1y.
+func ParseCalendarDuration(s string) (int, error) {
+	var num string
+	var unit int64
+	switch {
+	case strings.HasSuffix(s, "mo"):
+		num, unit = s[:len(s)-2], 1
+	case strings.HasSuffix(s, "y"):
+		num, unit = s[:len(s)-1], 12
+	default:
+		return 0, ErrInvalidDuration
+	}
+
+	// Reject signs and anything else that is not a plain number.
+	if num == "" || strings.TrimLeft(num, "0123456789") != "" {
+		return 0, ErrInvalidDuration
+	}
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || n == 0 {
+		return 0, ErrInvalidDuration
+	}
+
+	// The longest duration of the interval must fit in a time.Duration.
+	if n > MaxTime/int64(31*24*time.Hour)/unit {
+		return 0, fmt.Errorf("overflowed duration %s: choose a smaller duration or INF", s)
+	}
+	return int(n * unit), nil
+}
+
+// FormatCalendarDuration formats a duration in calendar months to a string.
+func FormatCalendarDuration(months int) string {
+	if months%12 == 0 {
+		return fmt.Sprintf("%dy", months/12)
+	}
+	return fmt.Sprintf("%dmo", months)
+}
+
 // FormatDuration formats a duration to a string.
 func FormatDuration(d time.Duration) string {
 	if d == 0 {
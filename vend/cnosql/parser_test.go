@@ -652,21 +652,38 @@ func TestParser_ParseStatement(t *testing.T) {
 
 		// SELECT statement with multiple ORDER BY fields
 		{
-			skip: true,
-			s:    `SELECT field1 FROM myseries ORDER BY ASC, field1, field2 DESC LIMIT 10`,
+			s: `SELECT field1 FROM myseries ORDER BY ASC, field1, field2 DESC LIMIT 10`,
 			stmt: &cnosql.SelectStatement{
 				IsRawQuery: true,
 				Fields:     []*cnosql.Field{{Expr: &cnosql.VarRef{Val: "field1"}}},
 				Sources:    []cnosql.Source{&cnosql.Measurement{Name: "myseries"}},
 				SortFields: []*cnosql.SortField{
 					{Ascending: true},
-					{Name: "field1"},
+					{Name: "field1", Ascending: true},
 					{Name: "field2"},
 				},
 				Limit: 10,
 			},
 		},
 
+		// SELECT statement ordered by an aggregate and a tag
+		{
+			s: `SELECT max(value) FROM cpu GROUP BY host ORDER BY max(value) DESC, host LIMIT 20`,
+			stmt: &cnosql.SelectStatement{
+				Fields: []*cnosql.Field{{Expr: &cnosql.Call{
+					Name: "max",
+					Args: []cnosql.Expr{&cnosql.VarRef{Val: "value"}},
+				}}},
+				Sources:    []cnosql.Source{&cnosql.Measurement{Name: "cpu"}},
+				Dimensions: []*cnosql.Dimension{{Expr: &cnosql.VarRef{Val: "host"}}},
+				SortFields: []*cnosql.SortField{
+					{Name: "max(value)"},
+					{Name: "host", Ascending: true},
+				},
+				Limit: 20,
+			},
+		},
+
 		// SELECT statement with SLIMIT and SOFFSET
 		{
 			s: `SELECT field1 FROM myseries SLIMIT 10 SOFFSET 5`,
@@ -702,6 +719,115 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// select CASE expressions
+		{
+			s: `SELECT CASE WHEN temp > 80 THEN 'hot' WHEN temp > 50 THEN 'warm' ELSE 'ok' END AS status FROM cpu`,
+			stmt: &cnosql.SelectStatement{
+				IsRawQuery: true,
+				Fields: []*cnosql.Field{
+					{
+						Expr: &cnosql.CaseExpr{
+							WhenClauses: []*cnosql.WhenClause{
+								{
+									Cond:   &cnosql.BinaryExpr{Op: cnosql.GT, LHS: &cnosql.VarRef{Val: "temp"}, RHS: &cnosql.IntegerLiteral{Val: 80}},
+									Result: &cnosql.StringLiteral{Val: "hot"},
+								},
+								{
+									Cond:   &cnosql.BinaryExpr{Op: cnosql.GT, LHS: &cnosql.VarRef{Val: "temp"}, RHS: &cnosql.IntegerLiteral{Val: 50}},
+									Result: &cnosql.StringLiteral{Val: "warm"},
+								},
+							},
+							Else: &cnosql.StringLiteral{Val: "ok"},
+						},
+						Alias: "status",
+					},
+				},
+				Sources: []cnosql.Source{&cnosql.Measurement{Name: "cpu"}},
+			},
+		},
+
+		{
+			s: `SELECT sum(CASE WHEN host = 'a' THEN value END), "case" FROM cpu`,
+			stmt: &cnosql.SelectStatement{
+				IsRawQuery: false,
+				Fields: []*cnosql.Field{
+					{Expr: &cnosql.Call{Name: "sum", Args: []cnosql.Expr{
+						&cnosql.CaseExpr{
+							WhenClauses: []*cnosql.WhenClause{
+								{
+									Cond:   &cnosql.BinaryExpr{Op: cnosql.EQ, LHS: &cnosql.VarRef{Val: "host"}, RHS: &cnosql.StringLiteral{Val: "a"}},
+									Result: &cnosql.VarRef{Val: "value"},
+								},
+							},
+						},
+					}}},
+					{Expr: &cnosql.VarRef{Val: "case"}},
+				},
+				Sources: []cnosql.Source{&cnosql.Measurement{Name: "cpu"}},
+			},
+		},
+
+		// select state function with a condition
+		{
+			s: `SELECT state_duration(valve, valve = 'open', 1m) FROM pipe`,
+			stmt: &cnosql.SelectStatement{
+				IsRawQuery: false,
+				Fields: []*cnosql.Field{
+					{Expr: &cnosql.Call{Name: "state_duration", Args: []cnosql.Expr{
+						&cnosql.VarRef{Val: "valve"},
+						&cnosql.BinaryExpr{Op: cnosql.EQ, LHS: &cnosql.VarRef{Val: "valve"}, RHS: &cnosql.StringLiteral{Val: "open"}},
+						&cnosql.DurationLiteral{Val: time.Minute},
+					}}},
+				},
+				Sources: []cnosql.Source{&cnosql.Measurement{Name: "pipe"}},
+			},
+		},
+
+		// select HAVING clause
+		{
+			s: `SELECT mean(value) AS m FROM cpu GROUP BY host HAVING m > 90 AND count(value) >= 3 LIMIT 10`,
+			stmt: &cnosql.SelectStatement{
+				IsRawQuery: false,
+				Fields: []*cnosql.Field{
+					{Expr: &cnosql.Call{Name: "mean", Args: []cnosql.Expr{&cnosql.VarRef{Val: "value"}}}, Alias: "m"},
+				},
+				Sources:    []cnosql.Source{&cnosql.Measurement{Name: "cpu"}},
+				Dimensions: []*cnosql.Dimension{{Expr: &cnosql.VarRef{Val: "host"}}},
+				Having: &cnosql.BinaryExpr{
+					Op:  cnosql.AND,
+					LHS: &cnosql.BinaryExpr{Op: cnosql.GT, LHS: &cnosql.VarRef{Val: "m"}, RHS: &cnosql.IntegerLiteral{Val: 90}},
+					RHS: &cnosql.BinaryExpr{
+						Op:  cnosql.GTE,
+						LHS: &cnosql.Call{Name: "count", Args: []cnosql.Expr{&cnosql.VarRef{Val: "value"}}},
+						RHS: &cnosql.IntegerLiteral{Val: 3},
+					},
+				},
+				Limit: 10,
+			},
+		},
+
+		{
+			s: `SELECT max(value) FROM cpu GROUP BY time(1h) fill(none) having max(value) < 10 ORDER BY time DESC`,
+			stmt: &cnosql.SelectStatement{
+				IsRawQuery: false,
+				Fields: []*cnosql.Field{
+					{Expr: &cnosql.Call{Name: "max", Args: []cnosql.Expr{&cnosql.VarRef{Val: "value"}}}},
+				},
+				Sources: []cnosql.Source{&cnosql.Measurement{Name: "cpu"}},
+				Dimensions: []*cnosql.Dimension{{Expr: &cnosql.Call{
+					Name: "time",
+					Args: []cnosql.Expr{&cnosql.DurationLiteral{Val: time.Hour}},
+				}}},
+				Fill: cnosql.NoFill,
+				Having: &cnosql.BinaryExpr{
+					Op:  cnosql.LT,
+					LHS: &cnosql.Call{Name: "max", Args: []cnosql.Expr{&cnosql.VarRef{Val: "value"}}},
+					RHS: &cnosql.IntegerLiteral{Val: 10},
+				},
+				SortFields: []*cnosql.SortField{{Name: "time", Ascending: false}},
+			},
+		},
+
 		// select percentile statements
 		{
 			s: `select percentile("field1", 2.0) from cpu`,
@@ -1669,6 +1795,75 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// SELECT statement with a join
+		{
+			s: `SELECT c.usage / l.max FROM cpu AS c JOIN cpu_limits AS l ON host, region`,
+			stmt: &cnosql.SelectStatement{
+				IsRawQuery: true,
+				Fields: []*cnosql.Field{{
+					Expr: &cnosql.BinaryExpr{
+						Op:  cnosql.DIV,
+						LHS: &cnosql.VarRef{Val: "c.usage"},
+						RHS: &cnosql.VarRef{Val: "l.max"},
+					},
+				}},
+				Sources: []cnosql.Source{
+					&cnosql.Join{
+						Type:  cnosql.InnerJoin,
+						Left:  &cnosql.JoinSource{Source: &cnosql.Measurement{Name: "cpu"}, Alias: "c"},
+						Right: &cnosql.JoinSource{Source: &cnosql.Measurement{Name: "cpu_limits"}, Alias: "l"},
+						On:    []string{"host", "region"},
+					},
+				},
+			},
+		},
+
+		// SELECT statement with an outer join of a subquery
+		{
+			s: `SELECT * FROM db0.rp0.cpu LEFT OUTER JOIN (SELECT max(value) FROM mem GROUP BY time(1m)) AS m WHERE time > now() - 1h`,
+			stmt: &cnosql.SelectStatement{
+				IsRawQuery: true,
+				Fields: []*cnosql.Field{
+					{Expr: &cnosql.Wildcard{}},
+				},
+				Sources: []cnosql.Source{
+					&cnosql.Join{
+						Type: cnosql.LeftJoin,
+						Left: &cnosql.JoinSource{Source: &cnosql.Measurement{Database: "db0", RetentionPolicy: "rp0", Name: "cpu"}},
+						Right: &cnosql.JoinSource{
+							Source: &cnosql.SubQuery{
+								Statement: &cnosql.SelectStatement{
+									Fields: []*cnosql.Field{{
+										Expr: &cnosql.Call{
+											Name: "max",
+											Args: []cnosql.Expr{&cnosql.VarRef{Val: "value"}},
+										},
+									}},
+									Sources: []cnosql.Source{&cnosql.Measurement{Name: "mem"}},
+									Dimensions: []*cnosql.Dimension{{
+										Expr: &cnosql.Call{
+											Name: "time",
+											Args: []cnosql.Expr{&cnosql.DurationLiteral{Val: time.Minute}},
+										},
+									}},
+								},
+							},
+							Alias: "m",
+						},
+					},
+				},
+				Condition: &cnosql.BinaryExpr{
+					Op:  cnosql.GT,
+					LHS: &cnosql.VarRef{Val: "time"},
+					RHS: &cnosql.BinaryExpr{
+						Op:  cnosql.SUB,
+						LHS: &cnosql.Call{Name: "now"},
+						RHS: &cnosql.DurationLiteral{Val: time.Hour},
+					},
+				},
+			},
+		},
+
 		// select statements with intertwined comments
 		{
 			s: `SELECT "user" /*, system, idle */ FROM cpu`,
@@ -2898,6 +3093,32 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		{
+			s: `CREATE CONTINUOUS QUERY myquery ON testdb RESAMPLE EVERY 1d FOR 1y BEGIN SELECT count(field1) INTO measure1 FROM myseries GROUP BY time(1mo) TZ('America/Los_Angeles') END`,
+			stmt: &cnosql.CreateContinuousQueryStatement{
+				Name:     "myquery",
+				Database: "testdb",
+				Source: &cnosql.SelectStatement{
+					Fields:  []*cnosql.Field{{Expr: &cnosql.Call{Name: "count", Args: []cnosql.Expr{&cnosql.VarRef{Val: "field1"}}}}},
+					Target:  &cnosql.Target{Measurement: &cnosql.Measurement{Name: "measure1", IsTarget: true}},
+					Sources: []cnosql.Source{&cnosql.Measurement{Name: "myseries"}},
+					Dimensions: []*cnosql.Dimension{
+						{
+							Expr: &cnosql.Call{
+								Name: "time",
+								Args: []cnosql.Expr{
+									&cnosql.CalendarDurationLiteral{Months: 1},
+								},
+							},
+						},
+					},
+					Location: LosAngeles,
+				},
+				ResampleEvery:     24 * time.Hour,
+				ResampleForMonths: 12,
+			},
+		},
+
 		{
 			s: `create continuous query "this.is-a.test" on segments begin select * into measure1 from cpu_load_short end`,
 			stmt: &cnosql.CreateContinuousQueryStatement{
@@ -3103,6 +3324,65 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// CREATE MEASUREMENT statement
+		{
+			s: `CREATE MEASUREMENT cpu (TAGS host, region; FIELDS usage FLOAT REQUIRED, "idle time" integer)`,
+			stmt: &cnosql.CreateMeasurementStatement{
+				Name: "cpu",
+				Tags: []string{"host", "region"},
+				Fields: []*cnosql.MeasurementField{
+					{Name: "usage", Type: cnosql.Float, Required: true},
+					{Name: "idle time", Type: cnosql.Integer},
+				},
+			},
+		},
+		{
+			s: `CREATE MEASUREMENT cpu (FIELDS up BOOLEAN)`,
+			stmt: &cnosql.CreateMeasurementStatement{
+				Name:   "cpu",
+				Fields: []*cnosql.MeasurementField{{Name: "up", Type: cnosql.Boolean}},
+			},
+		},
+
+		// INSERT statement
+		{
+			s: `INSERT INTO cpu (host::tag, value, time) VALUES ('server01', 0.5, 1000000000), ('server02', -2, '2000-01-01T00:00:00Z')`,
+			stmt: &cnosql.InsertStatement{
+				Measurement: &cnosql.Measurement{Name: "cpu"},
+				Columns: []*cnosql.VarRef{
+					{Val: "host", Type: cnosql.Tag},
+					{Val: "value"},
+					{Val: "time"},
+				},
+				Values: [][]cnosql.Expr{
+					{&cnosql.StringLiteral{Val: "server01"}, &cnosql.NumberLiteral{Val: 0.5}, &cnosql.IntegerLiteral{Val: 1000000000}},
+					{&cnosql.StringLiteral{Val: "server02"}, &cnosql.IntegerLiteral{Val: -2}, &cnosql.StringLiteral{Val: "2000-01-01T00:00:00Z"}},
+				},
+			},
+		},
+		{
+			s: `INSERT INTO db0.rp0.cpu (up::boolean, load::unsigned, time) VALUES (true, 18446744073709551615, now())`,
+			stmt: &cnosql.InsertStatement{
+				Measurement: &cnosql.Measurement{Database: "db0", RetentionPolicy: "rp0", Name: "cpu"},
+				Columns: []*cnosql.VarRef{
+					{Val: "up", Type: cnosql.Boolean},
+					{Val: "load", Type: cnosql.Unsigned},
+					{Val: "time"},
+				},
+				Values: [][]cnosql.Expr{
+					{&cnosql.BooleanLiteral{Val: true}, &cnosql.UnsignedLiteral{Val: 18446744073709551615}, &cnosql.Call{Name: "now"}},
+				},
+			},
+		},
+		{
+			s: `INSERT INTO rp0.cpu (value) VALUES (1)`,
+			stmt: &cnosql.InsertStatement{
+				Measurement: &cnosql.Measurement{RetentionPolicy: "rp0", Name: "cpu"},
+				Columns:     []*cnosql.VarRef{{Val: "value"}},
+				Values:      [][]cnosql.Expr{{&cnosql.IntegerLiteral{Val: 1}}},
+			},
+		},
+
 		// DROP MEASUREMENT statement
 		{
 			s:    `DROP MEASUREMENT cpu`,
@@ -3424,9 +3704,9 @@ func TestParser_ParseStatement(t *testing.T) {
 		},
 
 		// Errors
-		{s: ``, err: `found EOF, expected SELECT, DELETE, SHOW, CREATE, DROP, EXPLAIN, GRANT, REVOKE, ALTER, SET, KILL at line 1, char 1`},
+		{s: ``, err: `found EOF, expected SELECT, DELETE, INSERT, SHOW, CREATE, DROP, EXPLAIN, GRANT, REVOKE, ALTER, SET, KILL at line 1, char 1`},
 		{s: `SELECT`, err: `found EOF, expected identifier, string, number, bool at line 1, char 8`},
-		{s: `blah blah`, err: `found blah, expected SELECT, DELETE, SHOW, CREATE, DROP, EXPLAIN, GRANT, REVOKE, ALTER, SET, KILL at line 1, char 1`},
+		{s: `blah blah`, err: `found blah, expected SELECT, DELETE, INSERT, SHOW, CREATE, DROP, EXPLAIN, GRANT, REVOKE, ALTER, SET, KILL at line 1, char 1`},
 		{s: `SELECT field1 X`, err: `found X, expected FROM at line 1, char 15`},
 		{s: `SELECT field1 FROM "series" WHERE X +;`, err: `found ;, expected identifier, string, number, bool at line 1, char 38`},
 		{s: `SELECT field1 FROM myseries GROUP`, err: `found EOF, expected BY at line 1, char 35`},
@@ -3439,7 +3719,8 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `SELECT field1 FROM myseries ORDER BY /`, err: `found /, expected identifier, ASC, DESC at line 1, char 38`},
 		{s: `SELECT field1 FROM myseries ORDER BY 1`, err: `found 1, expected identifier, ASC, DESC at line 1, char 38`},
 		{s: `SELECT field1 FROM myseries ORDER BY time ASC,`, err: `found EOF, expected identifier at line 1, char 47`},
-		{s: `SELECT field1 FROM myseries ORDER BY time, field1`, err: `only ORDER BY time supported at this time`},
+		{s: `SELECT field1 FROM myseries ORDER BY max(field1`, err: `found EOF, expected ) at line 1, char 49`},
+		{s: `SHOW FIELD KEYS FROM myseries ORDER BY time, field1`, err: `only ORDER BY time supported at this time`},
 		{s: `SELECT field1 AS`, err: `found EOF, expected identifier at line 1, char 18`},
 		{s: `SELECT field1 FROM 12`, err: `found 12, expected identifier at line 1, char 20`},
 		{s: `SELECT 1000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000 FROM myseries`, err: `unable to parse integer at line 1, char 8`},
@@ -3450,6 +3731,12 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `SELECT value > 2 FROM cpu`, err: `invalid operator > in SELECT clause at line 1, char 8; operator is intended for WHERE clause`},
 		{s: `SELECT value = 2 FROM cpu`, err: `invalid operator = in SELECT clause at line 1, char 8; operator is intended for WHERE clause`},
 		{s: `SELECT s =~ /foo/ FROM cpu`, err: `invalid operator =~ in SELECT clause at line 1, char 8; operator is intended for WHERE clause`},
+		{s: `SELECT CASE WHEN value > 1 THEN value > 2 END FROM cpu`, err: `invalid operator > in SELECT clause at line 1, char 8; operator is intended for WHERE clause`},
+		{s: `SELECT state_count(value > 1, value > 1) FROM cpu`, err: `invalid operator > in SELECT clause at line 1, char 8; operator is intended for WHERE clause`},
+		{s: `SELECT CASE WHEN value > 1 value END FROM cpu`, err: `found value, expected THEN at line 1, char 28`},
+		{s: `SELECT CASE WHEN value > 1 THEN 1 FROM cpu`, err: `found FROM, expected WHEN, ELSE, END at line 1, char 35`},
+		{s: `SELECT CASE WHEN value > 1 THEN 1 ELSE 2 FROM cpu`, err: `found FROM, expected END at line 1, char 42`},
+		{s: `SELECT mean(value) FROM cpu GROUP BY host HAVING`, err: `found EOF, expected identifier, string, number, bool at line 1, char 50`},
 		{s: `SELECT mean(value) FROM cpu FILL + value`, err: `fill must be a function call`},
 		{s: `DELETE`, err: `found EOF, expected FROM, WHERE at line 1, char 8`},
 		{s: `DELETE FROM`, err: `found EOF, expected identifier at line 1, char 13`},
@@ -3480,9 +3767,34 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `CREATE CONTINUOUS QUERY`, err: `found EOF, expected identifier at line 1, char 25`},
 		{s: `CREATE CONTINUOUS QUERY cq ON db RESAMPLE FOR 5s BEGIN SELECT mean(value) INTO cpu_mean FROM cpu GROUP BY time(10s) END`, err: `FOR duration must be >= GROUP BY time duration: must be a minimum of 10s, got 5s`},
 		{s: `CREATE CONTINUOUS QUERY cq ON db RESAMPLE EVERY 10s FOR 5s BEGIN SELECT mean(value) INTO cpu_mean FROM cpu GROUP BY time(5s) END`, err: `FOR duration must be >= GROUP BY time duration: must be a minimum of 10s, got 5s`},
+		{s: `CREATE CONTINUOUS QUERY cq ON db RESAMPLE FOR 1mo BEGIN SELECT mean(value) INTO cpu_mean FROM cpu GROUP BY time(1y) END`, err: `FOR duration must be >= GROUP BY time duration: must be a minimum of 1y, got 1mo`},
+		{s: `CREATE CONTINUOUS QUERY cq ON db RESAMPLE FOR 30d BEGIN SELECT mean(value) INTO cpu_mean FROM cpu GROUP BY time(1mo) END`, err: `FOR duration must be >= GROUP BY time duration: must be a minimum of 1mo, got 30d`},
 		{s: `DROP FOO`, err: `found FOO, expected CONTINUOUS, DATABASE, MEASUREMENT, RETENTION, SERIES, SHARD, SUBSCRIPTION, USER at line 1, char 6`},
-		{s: `CREATE FOO`, err: `found FOO, expected CONTINUOUS, DATABASE, USER, RETENTION, SUBSCRIPTION at line 1, char 8`},
+		{s: `CREATE FOO`, err: `found FOO, expected CONTINUOUS, DATABASE, MEASUREMENT, USER, RETENTION, SUBSCRIPTION at line 1, char 8`},
 		{s: `CREATE DATABASE`, err: `found EOF, expected identifier at line 1, char 17`},
+		{s: `INSERT cpu (value) VALUES (1)`, err: `found cpu, expected INTO at line 1, char 8`},
+		{s: `INSERT INTO cpu VALUES (1)`, err: `found VALUES, expected ( at line 1, char 17`},
+		{s: `INSERT INTO cpu (value) (1)`, err: `found (, expected VALUES at line 1, char 25`},
+		{s: `INSERT INTO cpu (host::tag) VALUES ('a')`, err: `at least one field column required at line 1, char 27`},
+		{s: `INSERT INTO cpu (value, value) VALUES (1, 2)`, err: `duplicate column value at line 1, char 25`},
+		{s: `INSERT INTO cpu (value, time) VALUES (1)`, err: `1 values for 2 columns at line 1, char 40`},
+		{s: `INSERT INTO cpu (value) VALUES (1, 2)`, err: `more values than the 1 columns at line 1, char 36`},
+		{s: `INSERT INTO cpu (value, time) VALUES (1, 'yesterday')`, err: `invalid time 'yesterday' at line 1, char 41`},
+		{s: `INSERT INTO cpu (host::tag, value) VALUES (1, 1)`, err: `tag host must be a string at line 1, char 44`},
+		{s: `INSERT INTO cpu (value) VALUES (value + 1)`, err: `invalid value value + 1 for column value at line 1, char 33`},
+		{s: `SELECT * FROM cpu AS c`, err: `found EOF, expected JOIN at line 1, char 24`},
+		{s: `SELECT * FROM cpu LEFT mem`, err: `found mem, expected JOIN at line 1, char 24`},
+		{s: `SELECT * FROM cpu JOIN cpu`, err: `duplicate join source name cpu at line 1, char 19`},
+		{s: `SELECT * FROM cpu JOIN (SELECT value FROM mem)`, err: `join source (SELECT value FROM mem) requires an alias at line 1, char 19`},
+		{s: `SELECT * FROM cpu JOIN mem ON host, host`, err: `duplicate join key host at line 1, char 37`},
+		{s: `SELECT * FROM cpu, mem JOIN disk`, err: `a join must be the only source at line 1, char 24`},
+		{s: `SELECT * FROM cpu JOIN mem, disk`, err: `a join must be the only source at line 1, char 27`},
+		{s: `CREATE MEASUREMENT cpu`, err: `found EOF, expected ( at line 1, char 24`},
+		{s: `CREATE MEASUREMENT cpu (TAGS host)`, err: `found ), expected ; at line 1, char 34`},
+		{s: `CREATE MEASUREMENT cpu (TAGS host;)`, err: `found ), expected TAGS, FIELDS at line 1, char 35`},
+		{s: `CREATE MEASUREMENT cpu (FIELDS value)`, err: `found ), expected FLOAT, INTEGER, UNSIGNED, STRING, BOOLEAN at line 1, char 37`},
+		{s: `CREATE MEASUREMENT cpu (FIELDS value TIME)`, err: `found TIME, expected FLOAT, INTEGER, UNSIGNED, STRING, BOOLEAN at line 1, char 38`},
+		{s: `CREATE MEASUREMENT cpu (FIELDS value FLOAT`, err: `found EOF, expected ) at line 1, char 44`},
 		{s: `CREATE DATABASE "testdb" WITH`, err: `found EOF, expected DURATION, NAME, REPLICATION, SHARD at line 1, char 31`},
 		{s: `CREATE DATABASE "testdb" WITH DURATION`, err: `found EOF, expected duration at line 1, char 40`},
 		{s: `CREATE DATABASE "testdb" WITH REPLICATION`, err: `found EOF, expected integer at line 1, char 43`},
@@ -3607,7 +3919,7 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `SET PASSWORD FOR dejan`, err: `found EOF, expected = at line 1, char 24`},
 		{s: `SET PASSWORD FOR dejan =`, err: `found EOF, expected string at line 1, char 25`},
 		{s: `SET PASSWORD FOR dejan = bla`, err: `found bla, expected string at line 1, char 26`},
-		{s: `$SHOW$DATABASES`, err: `found $SHOW, expected SELECT, DELETE, SHOW, CREATE, DROP, EXPLAIN, GRANT, REVOKE, ALTER, SET, KILL at line 1, char 1`},
+		{s: `$SHOW$DATABASES`, err: `found $SHOW, expected SELECT, DELETE, INSERT, SHOW, CREATE, DROP, EXPLAIN, GRANT, REVOKE, ALTER, SET, KILL at line 1, char 1`},
 		{s: `SELECT * FROM cpu WHERE "tagkey" = $$`, err: `empty bound parameter`},
 
 		// Create a database with a bound parameter.
@@ -3720,6 +4032,57 @@ func TestParser_ParseStatement(t *testing.T) {
 	}
 }
 
+// Ensure the parser can keep the bound parameters without a value.
+func TestParser_KeepParams(t *testing.T) {
+	p := cnosql.NewParser(strings.NewReader(`SELECT value FROM cpu WHERE host = $host AND time >= $start AND time < $end AND region = $host`))
+	p.SetParams(map[string]interface{}{"host": "serverA"})
+	p.KeepParams()
+	stmt, err := p.ParseStatement()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, exp := stmt.String(), `SELECT value FROM cpu WHERE host = 'serverA' AND time >= $start AND time < $"end" AND region = 'serverA'`; got != exp {
+		t.Fatalf("unexpected statement:\ngot=%s\nexp=%s", got, exp)
+	}
+	if got, exp := cnosql.BoundParameters(stmt), []string{"start", "end"}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected parameters: got=%v exp=%v", got, exp)
+	}
+
+	// Parameters can only be kept in place of literals.
+	p = cnosql.NewParser(strings.NewReader(`SELECT value FROM $m`))
+	p.KeepParams()
+	if _, err := p.ParseStatement(); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// Ensure the kept bound parameters are replaced by the literals of their values.
+func TestBindParameters(t *testing.T) {
+	p := cnosql.NewParser(strings.NewReader(`SELECT mean(value) FROM cpu WHERE host = $host AND time >= $start AND time < now() - $ago GROUP BY time(1m)`))
+	p.KeepParams()
+	stmt, err := p.ParseStatement()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	bound := stmt.(*cnosql.SelectStatement).Clone()
+	if err := cnosql.BindParameters(bound, map[string]interface{}{
+		"host":  "serverA",
+		"start": int64(1000),
+		"ago":   map[string]interface{}{"duration": "1h"},
+	}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, exp := bound.String(), `SELECT mean(value) FROM cpu WHERE host = 'serverA' AND time >= 1000 AND time < now() - 1h GROUP BY time(1m)`; got != exp {
+		t.Fatalf("unexpected statement:\ngot=%s\nexp=%s", got, exp)
+	}
+
+	bound = stmt.(*cnosql.SelectStatement).Clone()
+	if err := cnosql.BindParameters(bound, map[string]interface{}{"host": "serverA", "start": int64(1000)}); err == nil || err.Error() != "missing parameter: ago" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
 // Ensure the parser can parse expressions into an AST.
 func TestParser_ParseExpr(t *testing.T) {
 	var tests = []struct {
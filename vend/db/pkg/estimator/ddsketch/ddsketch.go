@@ -0,0 +1,293 @@
+// Package ddsketch contains a DDSketch implementation for estimating quantiles
+// with a relative-error guarantee, described in the following paper:
+// https://arxiv.org/abs/1908.10693
+//
+// Values are assigned to logarithmically sized bins so that every quantile
+// estimate is within the relative accuracy of the true value. Two sketches
+// with the same relative accuracy can be merged by adding their bin counts,
+// which makes the sketch suitable for combining partial results.
+package ddsketch
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Current version of the DDSketch encoding.
+const version uint8 = 1
+
+// DefaultRelativeAccuracy is the default relative accuracy of quantile estimates.
+const DefaultRelativeAccuracy = 0.01
+
+// Sketch estimates quantiles of a stream of float values.
+type Sketch struct {
+	relativeAccuracy float64
+	gamma            float64
+	logGamma         float64
+
+	positive map[int32]uint64 // bins for values greater than zero.
+	negative map[int32]uint64 // bins for the absolute value of values less than zero.
+	zero     uint64           // number of values equal to zero.
+
+	count    uint64
+	min, max float64
+}
+
+// New returns a new Sketch with the given relative accuracy, which must be
+// between 0 and 1 exclusive.
+func New(relativeAccuracy float64) (*Sketch, error) {
+	if !(relativeAccuracy > 0 && relativeAccuracy < 1) {
+		return nil, errors.New("relative accuracy must be between 0 and 1")
+	}
+	gamma := (1 + relativeAccuracy) / (1 - relativeAccuracy)
+	return &Sketch{
+		relativeAccuracy: relativeAccuracy,
+		gamma:            gamma,
+		logGamma:         math.Log(gamma),
+		positive:         make(map[int32]uint64),
+		negative:         make(map[int32]uint64),
+		min:              math.Inf(1),
+		max:              math.Inf(-1),
+	}, nil
+}
+
+// NewDefault returns a new Sketch with the default relative accuracy.
+func NewDefault() *Sketch {
+	s, _ := New(DefaultRelativeAccuracy)
+	return s
+}
+
+// Add adds a value to the sketch. NaN and infinite values are ignored.
+func (s *Sketch) Add(v float64) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return
+	}
+
+	switch {
+	case v > 0:
+		s.positive[s.key(v)]++
+	case v < 0:
+		s.negative[s.key(-v)]++
+	default:
+		s.zero++
+	}
+
+	s.count++
+	if v < s.min {
+		s.min = v
+	}
+	if v > s.max {
+		s.max = v
+	}
+}
+
+// Count returns the number of values added to the sketch.
+func (s *Sketch) Count() uint64 {
+	return s.count
+}
+
+// Quantile returns an estimate of the value at quantile q, which must be
+// between 0 and 1 inclusive. It returns false if the sketch is empty.
+//
+// The value at quantile q is the value of nearest rank round(q*n) among the
+// n values added, the definition of the percentile() function, or the
+// smallest value if the rank is 0.
+func (s *Sketch) Quantile(q float64) (float64, bool) {
+	if s.count == 0 || q < 0 || q > 1 {
+		return 0, false
+	}
+
+	// rank is the index of the value among the sorted values.
+	var rank uint64
+	if r := math.Floor(q*float64(s.count) + 0.5); r > 1 {
+		rank = uint64(r) - 1
+	}
+
+	// The smallest and largest values are tracked exactly.
+	if rank == 0 {
+		return s.min, true
+	} else if rank == s.count-1 {
+		return s.max, true
+	}
+	var n uint64
+
+	// Negative values are visited from the largest absolute value down.
+	keys := sortedKeys(s.negative)
+	for i := len(keys) - 1; i >= 0; i-- {
+		if n += s.negative[keys[i]]; n > rank {
+			return s.clamp(-s.value(keys[i])), true
+		}
+	}
+
+	if n += s.zero; n > rank {
+		return 0, true
+	}
+
+	for _, k := range sortedKeys(s.positive) {
+		if n += s.positive[k]; n > rank {
+			return s.clamp(s.value(k)), true
+		}
+	}
+	return s.max, true
+}
+
+// Merge merges another sketch into this one. Both sketches must have been
+// created with the same relative accuracy.
+func (s *Sketch) Merge(other *Sketch) error {
+	if other.gamma != s.gamma {
+		return fmt.Errorf("relative accuracies do not match: %v != %v", other.relativeAccuracy, s.relativeAccuracy)
+	}
+
+	for k, n := range other.positive {
+		s.positive[k] += n
+	}
+	for k, n := range other.negative {
+		s.negative[k] += n
+	}
+	s.zero += other.zero
+	s.count += other.count
+	if other.min < s.min {
+		s.min = other.min
+	}
+	if other.max > s.max {
+		s.max = other.max
+	}
+	return nil
+}
+
+// Bytes estimates the memory footprint of the sketch, in bytes.
+func (s *Sketch) Bytes() int {
+	return 80 + (len(s.positive)+len(s.negative))*12
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+func (s *Sketch) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 0, 1+8*3+4*binary.MaxVarintLen64+(len(s.positive)+len(s.negative))*(binary.MaxVarintLen32+binary.MaxVarintLen64))
+	buf = append(buf, version)
+	buf = appendFloat(buf, s.relativeAccuracy)
+	buf = appendFloat(buf, s.min)
+	buf = appendFloat(buf, s.max)
+	buf = appendUvarint(buf, s.zero)
+	buf = appendBins(buf, s.positive)
+	buf = appendBins(buf, s.negative)
+	return buf, nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+func (s *Sketch) UnmarshalBinary(data []byte) error {
+	if len(data) < 1+8*3 {
+		return errors.New("ddsketch: data too short")
+	} else if data[0] != version {
+		return fmt.Errorf("ddsketch: unsupported version %d", data[0])
+	}
+	data = data[1:]
+
+	relativeAccuracy := math.Float64frombits(binary.BigEndian.Uint64(data))
+	other, err := New(relativeAccuracy)
+	if err != nil {
+		return err
+	}
+	other.min = math.Float64frombits(binary.BigEndian.Uint64(data[8:]))
+	other.max = math.Float64frombits(binary.BigEndian.Uint64(data[16:]))
+	data = data[24:]
+
+	zero, n := binary.Uvarint(data)
+	if n <= 0 {
+		return errors.New("ddsketch: invalid zero count")
+	}
+	other.zero, other.count = zero, zero
+	data = data[n:]
+
+	if data, err = other.readBins(data, other.positive); err != nil {
+		return err
+	}
+	if data, err = other.readBins(data, other.negative); err != nil {
+		return err
+	} else if len(data) != 0 {
+		return errors.New("ddsketch: unexpected trailing data")
+	}
+
+	*s = *other
+	return nil
+}
+
+// readBins decodes a set of bins into m and adds their counts to the sketch.
+func (s *Sketch) readBins(data []byte, m map[int32]uint64) ([]byte, error) {
+	sz, n := binary.Uvarint(data)
+	if n <= 0 {
+		return nil, errors.New("ddsketch: invalid bin count")
+	}
+	data = data[n:]
+
+	for i := uint64(0); i < sz; i++ {
+		k, n := binary.Varint(data)
+		if n <= 0 || k < math.MinInt32 || k > math.MaxInt32 {
+			return nil, errors.New("ddsketch: invalid bin key")
+		}
+		data = data[n:]
+
+		c, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, errors.New("ddsketch: invalid bin value")
+		}
+		data = data[n:]
+
+		m[int32(k)] += c
+		s.count += c
+	}
+	return data, nil
+}
+
+// key returns the bin of a positive value.
+func (s *Sketch) key(v float64) int32 {
+	return int32(math.Ceil(math.Log(v) / s.logGamma))
+}
+
+// value returns the estimate for all values in bin k.
+func (s *Sketch) value(k int32) float64 {
+	return 2 * math.Pow(s.gamma, float64(k)) / (1 + s.gamma)
+}
+
+// clamp bounds an estimate by the smallest and largest values added.
+func (s *Sketch) clamp(v float64) float64 {
+	return math.Max(s.min, math.Min(s.max, v))
+}
+
+func appendFloat(buf []byte, v float64) []byte {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], math.Float64bits(v))
+	return append(buf, b[:]...)
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	return append(buf, b[:n]...)
+}
+
+func appendVarint(buf []byte, v int64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(b[:], v)
+	return append(buf, b[:n]...)
+}
+
+func appendBins(buf []byte, m map[int32]uint64) []byte {
+	buf = appendUvarint(buf, uint64(len(m)))
+	for _, k := range sortedKeys(m) {
+		buf = appendVarint(buf, int64(k))
+		buf = appendUvarint(buf, m[k])
+	}
+	return buf
+}
+
+func sortedKeys(m map[int32]uint64) []int32 {
+	keys := make([]int32, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
@@ -0,0 +1,126 @@
+package ddsketch
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestSketch_Quantile(t *testing.T) {
+	s := NewDefault()
+	values := make([]float64, 0, 10000)
+	rnd := rand.New(rand.NewSource(0))
+	for i := 0; i < cap(values); i++ {
+		v := rnd.ExpFloat64()*100 - 20
+		values = append(values, v)
+		s.Add(v)
+	}
+	sort.Float64s(values)
+
+	for _, q := range []float64{0, 0.01, 0.25, 0.5, 0.75, 0.9, 0.99, 1} {
+		got, ok := s.Quantile(q)
+		if !ok {
+			t.Fatalf("q=%v: expected a value", q)
+		}
+		exp := values[0]
+		if i := int(math.Floor(q*float64(len(values))+0.5)) - 1; i > 0 {
+			exp = values[i]
+		}
+		if err := math.Abs(got-exp) / math.Abs(exp); err > DefaultRelativeAccuracy+1e-9 {
+			t.Errorf("q=%v: got %v, exp %v (relative error %v)", q, got, exp, err)
+		}
+	}
+}
+
+// TestSketch_Quantile_Rank checks that the values are those of the nearest
+// rank, as returned by percentile().
+func TestSketch_Quantile_Rank(t *testing.T) {
+	s := NewDefault()
+	s.Add(3)
+	s.Add(4)
+	for _, tt := range []struct {
+		q   float64
+		exp float64
+	}{
+		{q: 0, exp: 3},
+		{q: 0.2, exp: 3},
+		{q: 0.5, exp: 3},
+		{q: 0.75, exp: 4},
+		{q: 0.9, exp: 4},
+		{q: 1, exp: 4},
+	} {
+		if got, ok := s.Quantile(tt.q); !ok || got != tt.exp {
+			t.Errorf("q=%v: got %v, exp %v", tt.q, got, tt.exp)
+		}
+	}
+}
+
+func TestSketch_Quantile_Empty(t *testing.T) {
+	if _, ok := NewDefault().Quantile(0.5); ok {
+		t.Fatal("expected no value for an empty sketch")
+	}
+}
+
+func TestSketch_Merge(t *testing.T) {
+	a, b, all := NewDefault(), NewDefault(), NewDefault()
+	for i := -500; i < 1000; i++ {
+		v := float64(i)
+		if i%2 == 0 {
+			a.Add(v)
+		} else {
+			b.Add(v)
+		}
+		all.Add(v)
+	}
+
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	} else if got, exp := a.Count(), all.Count(); got != exp {
+		t.Fatalf("got count %d, exp %d", got, exp)
+	}
+	for _, q := range []float64{0, 0.1, 0.5, 0.9, 1} {
+		got, _ := a.Quantile(q)
+		exp, _ := all.Quantile(q)
+		if got != exp {
+			t.Errorf("q=%v: got %v, exp %v", q, got, exp)
+		}
+	}
+
+	other, err := New(0.05)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := a.Merge(other); err == nil {
+		t.Fatal("expected error merging sketches with different accuracies")
+	}
+}
+
+func TestSketch_Marshal(t *testing.T) {
+	s := NewDefault()
+	for _, v := range []float64{-3.5, -1, 0, 0, 2, 17.25, 1e6, math.NaN()} {
+		s.Add(v)
+	}
+
+	data, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var other Sketch
+	if err := other.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	} else if got, exp := other.Count(), s.Count(); got != exp {
+		t.Fatalf("got count %d, exp %d", got, exp)
+	}
+	for _, q := range []float64{0, 0.3, 0.5, 0.8, 1} {
+		got, _ := other.Quantile(q)
+		exp, _ := s.Quantile(q)
+		if got != exp {
+			t.Errorf("q=%v: got %v, exp %v", q, got, exp)
+		}
+	}
+
+	if err := other.UnmarshalBinary(data[:len(data)-1]); err == nil {
+		t.Fatal("expected error decoding truncated sketch")
+	}
+}
@@ -0,0 +1,306 @@
+// Package geo implements the geodesy used to index and query the location of
+// points: great-circle distances and the S2 cells covering areas of the Earth.
+//
+// Cells are identified the same way as by the S2 geometry library. The six
+// faces of a cube are projected onto the sphere and each face is divided into
+// a quadtree of cells that is numbered along a Hilbert curve.
+package geo
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// EarthRadius is the mean radius of the Earth in meters.
+const EarthRadius = 6371008.8
+
+// MaxLevel is the level of the smallest cells.
+const MaxLevel = 30
+
+const (
+	posBits    = 2*MaxLevel + 1
+	maxSize    = 1 << MaxLevel
+	lookupBits = 4
+	swapMask   = 0x01
+	invertMask = 0x02
+)
+
+// Distance returns the great-circle distance in meters between two locations
+// given in degrees.
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	φ1, φ2 := radians(lat1), radians(lat2)
+	dφ, dλ := φ2-φ1, radians(lon2-lon1)
+	h := math.Sin(dφ/2)*math.Sin(dφ/2) + math.Cos(φ1)*math.Cos(φ2)*math.Sin(dλ/2)*math.Sin(dλ/2)
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// ValidLatLon returns true if lat and lon are a valid location in degrees.
+func ValidLatLon(lat, lon float64) bool {
+	return lat >= -90 && lat <= 90 && lon >= -180 && lon <= 180
+}
+
+// CellID identifies a cell of the S2 hierarchy.
+type CellID uint64
+
+// CellIDFromLatLon returns the cell at level that contains the location given
+// in degrees.
+func CellIDFromLatLon(lat, lon float64, level int) CellID {
+	φ, λ := radians(lat), radians(lon)
+	x, y, z := math.Cos(φ)*math.Cos(λ), math.Cos(φ)*math.Sin(λ), math.Sin(φ)
+
+	face, u, v := xyzToFaceUV(x, y, z)
+	i, j := stToIJ(uvToST(u)), stToIJ(uvToST(v))
+	return cellIDFromFaceIJ(face, i, j).Parent(level)
+}
+
+// Level returns the level of the cell.
+func (c CellID) Level() int {
+	n := 0
+	for id := uint64(c); id&1 == 0 && n < 2*MaxLevel; id >>= 1 {
+		n++
+	}
+	return MaxLevel - n/2
+}
+
+// Parent returns the cell at level that contains c.
+func (c CellID) Parent(level int) CellID {
+	lsb := uint64(1) << uint(2*(MaxLevel-level))
+	return CellID((uint64(c) & -lsb) | lsb)
+}
+
+// Token returns the compact hexadecimal representation of the cell used by
+// the S2 geometry library.
+func (c CellID) Token() string {
+	if c == 0 {
+		return "X"
+	}
+	return strings.TrimRight(fmt.Sprintf("%016x", uint64(c)), "0")
+}
+
+// Rect is a rectangle of latitudes and longitudes in degrees. A rectangle
+// whose MinLon is greater than its MaxLon crosses the antimeridian.
+type Rect struct {
+	MinLat, MinLon float64
+	MaxLat, MaxLon float64
+}
+
+// Validate returns an error if the rectangle is not valid.
+func (r Rect) Validate() error {
+	if !ValidLatLon(r.MinLat, r.MinLon) || !ValidLatLon(r.MaxLat, r.MaxLon) {
+		return fmt.Errorf("rectangle is out of range: %v", r)
+	} else if r.MinLat > r.MaxLat {
+		return fmt.Errorf("minimum latitude %v is greater than the maximum latitude %v", r.MinLat, r.MaxLat)
+	}
+	return nil
+}
+
+// CapBound returns the smallest rectangle that contains all of the
+// locations within radius meters of the location given in degrees.
+func CapBound(lat, lon, radius float64) Rect {
+	angle := degrees(radius / EarthRadius)
+	r := Rect{MinLat: lat - angle, MinLon: -180, MaxLat: lat + angle, MaxLon: 180}
+	if r.MinLat <= -90 || r.MaxLat >= 90 {
+		// The cap contains a pole, so it contains all of the longitudes.
+		r.MinLat, r.MaxLat = math.Max(r.MinLat, -90), math.Min(r.MaxLat, 90)
+		return r
+	}
+
+	dlon := degrees(math.Asin(math.Min(1, math.Sin(radius/EarthRadius)/math.Cos(radians(lat)))))
+	if dlon < 90 {
+		r.MinLon, r.MaxLon = normalizeLon(lon-dlon), normalizeLon(lon+dlon)
+	}
+	return r
+}
+
+// Covering returns the cells at level that intersect the rectangle, ordered
+// by their ID. Nil is returned if more than max cells are needed.
+func Covering(r Rect, level, max int) []CellID {
+	// Each cell that intersects the rectangle lies within the rectangle
+	// expanded by the diagonal of the cells and contains a disc wider than a
+	// third of the minimum width of the cells. Sampling the expanded
+	// rectangle with a step of a quarter of the width finds every cell.
+	margin := degrees(2.438654594434021 * math.Ldexp(1, -level))
+	step := degrees(2 * math.Sqrt2 / 3 * math.Ldexp(1, -level) / 4)
+
+	minLat, maxLat := math.Max(r.MinLat-margin, -90), math.Min(r.MaxLat+margin, 90)
+	span := r.MaxLon - r.MinLon
+	if span < 0 {
+		span += 360
+	}
+
+	// Give up before sampling more than the number of points that would be
+	// needed to find max cells.
+	samples := 0
+	maxSamples := 64 * max
+
+	cells := make(map[CellID]struct{})
+	for lat := minLat; ; lat += step {
+		if lat > maxLat {
+			lat = maxLat
+		}
+
+		// Degrees of longitude are shorter away from the equator.
+		cos := math.Cos(radians(math.Min(math.Abs(lat)+step, 90)))
+		minLon, lonSpan, lonStep := r.MinLon, span, 360.0
+		if lonMargin := margin / cos; cos == 0 || span+2*lonMargin >= 360 {
+			minLon, lonSpan = -180, 360
+		} else {
+			minLon, lonSpan = r.MinLon-lonMargin, span+2*lonMargin
+		}
+		if cos > 0 {
+			lonStep = step / cos
+		}
+
+		for d := 0.0; ; d += lonStep {
+			if d > lonSpan {
+				d = lonSpan
+			}
+			if samples++; samples > maxSamples {
+				return nil
+			}
+			cells[CellIDFromLatLon(lat, normalizeLon(minLon+d), level)] = struct{}{}
+			if len(cells) > max {
+				return nil
+			}
+			if d == lonSpan {
+				break
+			}
+		}
+
+		if lat == maxLat {
+			break
+		}
+	}
+
+	ids := make([]CellID, 0, len(cells))
+	for id := range cells {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func radians(deg float64) float64 { return deg * math.Pi / 180 }
+func degrees(rad float64) float64 { return rad * 180 / math.Pi }
+
+// normalizeLon returns the longitude in the range [-180, 180].
+func normalizeLon(lon float64) float64 {
+	for lon < -180 {
+		lon += 360
+	}
+	for lon > 180 {
+		lon -= 360
+	}
+	return lon
+}
+
+// xyzToFaceUV returns the face of the cube the point is projected onto and
+// the coordinates of the point on that face.
+func xyzToFaceUV(x, y, z float64) (face int, u, v float64) {
+	ax, ay, az := math.Abs(x), math.Abs(y), math.Abs(z)
+	switch {
+	case ax >= ay && ax >= az:
+		face = 0
+		if x < 0 {
+			face = 3
+		}
+	case ay >= az:
+		face = 1
+		if y < 0 {
+			face = 4
+		}
+	default:
+		face = 2
+		if z < 0 {
+			face = 5
+		}
+	}
+
+	switch face {
+	case 0:
+		u, v = y/x, z/x
+	case 1:
+		u, v = -x/y, z/y
+	case 2:
+		u, v = -x/z, -y/z
+	case 3:
+		u, v = z/x, y/x
+	case 4:
+		u, v = z/y, -x/y
+	default:
+		u, v = -y/z, -x/z
+	}
+	return face, u, v
+}
+
+// uvToST applies the quadratic transformation that makes the cells of a
+// level closer to the same size.
+func uvToST(u float64) float64 {
+	if u >= 0 {
+		return 0.5 * math.Sqrt(1+3*u)
+	}
+	return 1 - 0.5*math.Sqrt(1-3*u)
+}
+
+func stToIJ(s float64) int {
+	i := int(math.Floor(maxSize * s))
+	if i < 0 {
+		return 0
+	} else if i > maxSize-1 {
+		return maxSize - 1
+	}
+	return i
+}
+
+// The lookup tables convert between the (i, j) coordinates of a cell and
+// its position along the Hilbert curve lookupBits at a time.
+var (
+	lookupPos [1 << (2*lookupBits + 2)]int
+
+	posToIJ = [4][4]int{
+		{0, 1, 3, 2}, // canonical order: (0,0), (0,1), (1,1), (1,0)
+		{0, 2, 3, 1}, // axes swapped: (0,0), (1,0), (1,1), (0,1)
+		{3, 2, 0, 1}, // bits inverted: (1,1), (1,0), (0,0), (0,1)
+		{3, 1, 0, 2}, // swapped & inverted: (1,1), (0,1), (0,0), (1,0)
+	}
+	posToOrientation = [4]int{swapMask, 0, 0, invertMask | swapMask}
+)
+
+func init() {
+	initLookupCell(0, 0, 0, 0, 0, 0)
+	initLookupCell(0, 0, 0, swapMask, 0, swapMask)
+	initLookupCell(0, 0, 0, invertMask, 0, invertMask)
+	initLookupCell(0, 0, 0, swapMask|invertMask, 0, swapMask|invertMask)
+}
+
+func initLookupCell(level, i, j, origOrientation, pos, orientation int) {
+	if level == lookupBits {
+		ij := (i << lookupBits) + j
+		lookupPos[(ij<<2)+origOrientation] = (pos << 2) + orientation
+		return
+	}
+
+	level++
+	i, j, pos = i<<1, j<<1, pos<<2
+	r := posToIJ[orientation]
+	for k := 0; k < 4; k++ {
+		initLookupCell(level, i+(r[k]>>1), j+(r[k]&1), origOrientation, pos+k, orientation^posToOrientation[k])
+	}
+}
+
+// cellIDFromFaceIJ returns the leaf cell at the (i, j) coordinates of a face.
+func cellIDFromFaceIJ(face, i, j int) CellID {
+	n := uint64(face) << (posBits - 1)
+	bits := face & swapMask
+	const mask = 1<<lookupBits - 1
+	for k := 7; k >= 0; k-- {
+		bits += ((i >> uint(k*lookupBits)) & mask) << (lookupBits + 2)
+		bits += ((j >> uint(k*lookupBits)) & mask) << 2
+		bits = lookupPos[bits]
+		n |= uint64(bits>>2) << (uint(k) * 2 * lookupBits)
+		bits &= swapMask | invertMask
+	}
+	return CellID(n*2 + 1)
+}
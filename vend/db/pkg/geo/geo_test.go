@@ -0,0 +1,95 @@
+package geo_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/cnosdb/cnosdb/vend/db/pkg/geo"
+)
+
+func TestDistance(t *testing.T) {
+	// London to Paris.
+	if d := geo.Distance(51.5074, -0.1278, 48.8566, 2.3522); math.Abs(d-343.5e3) > 1e3 {
+		t.Fatalf("unexpected distance: %v", d)
+	}
+	if d := geo.Distance(10, 170, 10, -170); math.Abs(d-2190e3) > 5e3 {
+		t.Fatalf("unexpected distance across the antimeridian: %v", d)
+	}
+}
+
+func TestCellIDFromLatLon(t *testing.T) {
+	for _, tt := range []struct {
+		lat, lon float64
+		level    int
+		token    string
+	}{
+		{lat: 0, lon: 0, level: 0, token: "1"},
+		{lat: 0, lon: 90, level: 0, token: "3"},
+		{lat: 90, lon: 0, level: 0, token: "5"},
+		{lat: 0, lon: 180, level: 0, token: "7"},
+		{lat: 0, lon: -90, level: 0, token: "9"},
+		{lat: -90, lon: 0, level: 0, token: "b"},
+		{lat: 0, lon: 0, level: 30, token: "1000000000000001"},
+		{lat: 0, lon: 0, level: 1, token: "14"},
+	} {
+		id := geo.CellIDFromLatLon(tt.lat, tt.lon, tt.level)
+		if got := id.Token(); got != tt.token {
+			t.Errorf("%v,%v at level %d: unexpected token: got=%s exp=%s", tt.lat, tt.lon, tt.level, got, tt.token)
+		} else if got := id.Level(); got != tt.level {
+			t.Errorf("%v,%v at level %d: unexpected level: %d", tt.lat, tt.lon, tt.level, got)
+		}
+	}
+
+	leaf := geo.CellIDFromLatLon(52.52, 13.405, geo.MaxLevel)
+	if p, exp := leaf.Parent(12), geo.CellIDFromLatLon(52.52, 13.405, 12); p != exp {
+		t.Fatalf("unexpected parent: got=%s exp=%s", p.Token(), exp.Token())
+	}
+}
+
+func TestCapBound(t *testing.T) {
+	r := geo.CapBound(0, 179.99, 10e3)
+	if r.MinLon < r.MaxLon {
+		t.Fatalf("expected rectangle to cross the antimeridian: %+v", r)
+	}
+	if r := geo.CapBound(89.99, 0, 10e3); r.MinLon != -180 || r.MaxLon != 180 || r.MaxLat != 90 {
+		t.Fatalf("expected rectangle to contain the pole: %+v", r)
+	}
+}
+
+func TestCovering(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	for _, r := range []geo.Rect{
+		{MinLat: 52.3, MinLon: 13.1, MaxLat: 52.7, MaxLon: 13.7},
+		{MinLat: -0.2, MinLon: 179.8, MaxLat: 0.2, MaxLon: -179.8},
+		geo.CapBound(89.95, 0, 5e3),
+	} {
+		cells := geo.Covering(r, 10, 256)
+		if len(cells) == 0 {
+			t.Fatalf("no covering for %+v", r)
+		}
+		set := make(map[geo.CellID]bool, len(cells))
+		for _, id := range cells {
+			set[id] = true
+		}
+
+		span := r.MaxLon - r.MinLon
+		if span < 0 {
+			span += 360
+		}
+		for i := 0; i < 10000; i++ {
+			lat := r.MinLat + rng.Float64()*(r.MaxLat-r.MinLat)
+			lon := r.MinLon + rng.Float64()*span
+			if lon > 180 {
+				lon -= 360
+			}
+			if id := geo.CellIDFromLatLon(lat, lon, 10); !set[id] {
+				t.Fatalf("%v,%v in %+v is not covered by %s", lat, lon, r, id.Token())
+			}
+		}
+	}
+
+	if cells := geo.Covering(geo.Rect{MinLat: -60, MinLon: -120, MaxLat: 60, MaxLon: 120}, 10, 256); cells != nil {
+		t.Fatalf("expected no covering, got %d cells", len(cells))
+	}
+}
@@ -53,6 +53,18 @@ func NewCallIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
 		return newLastIterator(input, opt)
 	case "mean":
 		return newMeanIterator(input, opt)
+	case "count_distinct_approx":
+		return newCountDistinctApproxIterator(input, opt)
+	case "quantile_approx":
+		return newQuantileApproxIterator(input, opt)
+	case mergeHLLFunction:
+		return newMergeHLLIterator(input, opt)
+	case mergeDDSketchFunction:
+		return newMergeDDSketchIterator(input, opt)
+	case "histogram", "histogram_buckets":
+		return newHistogramIterator(input, opt)
+	case mergeHistogramFunction:
+		return newMergeHistogramIterator(input, opt)
 	default:
 		return nil, fmt.Errorf("unsupported function call: %s", name)
 	}
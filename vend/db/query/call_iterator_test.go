@@ -1,16 +1,165 @@
 package query_test
 
 import (
+	"bytes"
 	"context"
-	"github.com/cnosdb/cnosdb/vend/db/query"
+	"reflect"
 	"testing"
 	"time"
+
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+	"github.com/cnosdb/cnosdb/vend/db/pkg/estimator/hll"
+	"github.com/cnosdb/cnosdb/vend/db/query"
 )
 
 func TestCallIterator_Count_Float(t *testing.T) {
 
 }
 
+// Ensure the sketches emitted by count_distinct_approx() for each input can
+// be merged into a single sketch for every window.
+func TestCallIterator_CountDistinctApprox_Merge(t *testing.T) {
+	opt := query.IteratorOptions{
+		Expr:       cnosql.MustParseExpr(`count_distinct_approx("value")`),
+		Dimensions: []string{"host"},
+		Interval:   query.Interval{Duration: 5 * time.Nanosecond},
+		Ordered:    true,
+		Ascending:  true,
+	}
+
+	inputs := []*FloatIterator{
+		{Points: []query.FloatPoint{
+			{Name: "cpu", Time: 0, Value: 1, Tags: ParseTags("host=a")},
+			{Name: "cpu", Time: 1, Value: 2, Tags: ParseTags("host=a")},
+			{Name: "cpu", Time: 5, Value: 3, Tags: ParseTags("host=a")},
+		}},
+		{Points: []query.FloatPoint{
+			{Name: "cpu", Time: 2, Value: 2, Tags: ParseTags("host=a")},
+			{Name: "cpu", Time: 3, Value: 4, Tags: ParseTags("host=a")},
+			{Name: "cpu", Time: 6, Value: 3, Tags: ParseTags("host=a")},
+		}},
+	}
+
+	itrs := make([]query.Iterator, len(inputs))
+	for i, input := range inputs {
+		itr, err := query.NewCallIterator(input, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		itrs[i] = itr
+	}
+
+	itr, err := query.Iterators(itrs).Merge(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer itr.Close()
+
+	exp := []struct {
+		time  int64
+		count uint64
+	}{{0, 3}, {5, 1}}
+	sitr, ok := itr.(query.StringIterator)
+	if !ok {
+		t.Fatalf("unexpected iterator type: %T", itr)
+	}
+	for i := 0; ; i++ {
+		p, err := sitr.Next()
+		if err != nil {
+			t.Fatal(err)
+		} else if p == nil {
+			if i != len(exp) {
+				t.Fatalf("got %d points, exp %d", i, len(exp))
+			}
+			break
+		} else if i >= len(exp) {
+			t.Fatalf("unexpected point: %v", p)
+		}
+
+		var plus hll.Plus
+		if err := plus.UnmarshalBinary([]byte(p.Value)); err != nil {
+			t.Fatal(err)
+		}
+		if p.Time != exp[i].time || plus.Count() != exp[i].count {
+			t.Errorf("%d. got time=%d count=%d, exp time=%d count=%d", i, p.Time, plus.Count(), exp[i].time, exp[i].count)
+		}
+	}
+}
+
+// Ensure the histograms emitted by histogram() for each input are merged
+// after being sent through the iterator encoding used between nodes.
+func TestCallIterator_Histogram_Merge(t *testing.T) {
+	opt := query.IteratorOptions{
+		Expr:      cnosql.MustParseExpr(`histogram("value", 0, 10, 2)`),
+		Interval:  query.Interval{Duration: 5 * time.Nanosecond},
+		Ordered:   true,
+		Ascending: true,
+	}
+
+	inputs := []*FloatIterator{
+		{Points: []query.FloatPoint{
+			{Name: "cpu", Time: 0, Value: -1},
+			{Name: "cpu", Time: 1, Value: 5},
+			{Name: "cpu", Time: 5, Value: 25},
+		}},
+		{Points: []query.FloatPoint{
+			{Name: "cpu", Time: 2, Value: 10},
+			{Name: "cpu", Time: 3, Value: 11},
+			{Name: "cpu", Time: 6, Value: 0},
+		}},
+	}
+
+	itrs := make([]query.Iterator, len(inputs))
+	for i, input := range inputs {
+		itr, err := query.NewCallIterator(input, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		if err := query.NewIteratorEncoder(&buf).EncodeIterator(itr); err != nil {
+			t.Fatal(err)
+		}
+		itrs[i] = query.NewReaderIterator(context.Background(), &buf, cnosql.String, query.IteratorStats{})
+	}
+
+	itr, err := query.Iterators(itrs).Merge(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer itr.Close()
+
+	exp := []struct {
+		time   int64
+		counts []uint64
+	}{{0, []uint64{1, 2, 1}}, {5, []uint64{1, 0, 1}}}
+	sitr, ok := itr.(query.StringIterator)
+	if !ok {
+		t.Fatalf("unexpected iterator type: %T", itr)
+	}
+	for i := 0; ; i++ {
+		p, err := sitr.Next()
+		if err != nil {
+			t.Fatal(err)
+		} else if p == nil {
+			if i != len(exp) {
+				t.Fatalf("got %d points, exp %d", i, len(exp))
+			}
+			break
+		} else if i >= len(exp) {
+			t.Fatalf("unexpected point: %v", p)
+		}
+
+		var h query.Histogram
+		if err := h.UnmarshalBinary([]byte(p.Value)); err != nil {
+			t.Fatal(err)
+		}
+		if p.Time != exp[i].time || !reflect.DeepEqual(h.Counts, exp[i].counts) {
+			t.Errorf("%d. got time=%d counts=%v, exp time=%d counts=%v", i, p.Time, h.Counts, exp[i].time, exp[i].counts)
+		}
+	}
+}
+
 type FloatIterator struct {
 	Context context.Context
 	Points  []query.FloatPoint
@@ -70,3 +219,45 @@ func FloatIterators(inputs []*FloatIterator) []query.Iterator {
 	}
 	return itrs
 }
+
+// Ensure the points buffered by median() are charged to the memory budget
+// of the query.
+func TestMedianIterator_MemoryBudget(t *testing.T) {
+	newInput := func() *FloatIterator {
+		points := make([]query.FloatPoint, 100)
+		for i := range points {
+			points[i] = query.FloatPoint{Name: "cpu", Time: int64(i), Value: float64(i)}
+		}
+		return &FloatIterator{Points: points}
+	}
+	median := func(mem *query.MemoryBudget) (*query.FloatPoint, error) {
+		itr, err := query.NewMedianIterator(newInput(), query.IteratorOptions{
+			StartTime: cnosql.MinTime,
+			EndTime:   cnosql.MaxTime,
+			Ordered:   true,
+			Ascending: true,
+			Memory:    mem,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer itr.Close()
+		return itr.(query.FloatIterator).Next()
+	}
+
+	mem := query.NewMemoryBudget(1024, "")
+	if _, err := median(mem); err == nil || err.Error() != "max-select-memory limit exceeded: (1088/1024)" {
+		t.Fatalf("unexpected error: %v", err)
+	} else if n := mem.Used(); n != 0 {
+		t.Fatalf("unexpected memory used after error: %d", n)
+	}
+
+	mem = query.NewMemoryBudget(1<<20, "")
+	if p, err := median(mem); err != nil {
+		t.Fatal(err)
+	} else if p == nil || p.Value != 49.5 {
+		t.Fatalf("unexpected point: %v", p)
+	} else if n := mem.Used(); n != 0 {
+		t.Fatalf("unexpected memory used after reducing the window: %d", n)
+	}
+}
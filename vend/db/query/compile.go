@@ -13,6 +13,13 @@ import (
 // CompileOptions are the customization options for the compiler.
 type CompileOptions struct {
 	Now time.Time
+
+	// GeoIndex describes the location of points for the geo functions.
+	GeoIndex GeoIndex
+
+	// Params are the values of the bound parameters kept in the statement
+	// by the parser of a prepared query.
+	Params map[string]interface{}
 }
 
 // Statement is a compiled query statement.
@@ -49,6 +56,11 @@ type compiledStatement struct {
 	// compiled query.
 	ExtraIntervals int
 
+	// TimeShift is the longest duration that a timeshift() call reads data
+	// from before the TimeRange. The shards of the shifted time range are
+	// mapped in addition to the shards of the TimeRange.
+	TimeShift time.Duration
+
 	// Ascending is true if the time ordering is ascending.
 	Ascending bool
 
@@ -62,6 +74,10 @@ type compiledStatement struct {
 	// HasDistinct is set when the distinct() function is encountered.
 	HasDistinct bool
 
+	// HistogramFunction is set to histogram or histogram_buckets when one of
+	// those functions is used in the statement.
+	HistogramFunction string
+
 	// FillOption contains the fill option for aggregates.
 	FillOption cnosql.FillOption
 
@@ -89,6 +105,12 @@ type compiledStatement struct {
 	// Options holds the configured compiler options.
 	Options CompileOptions
 
+	// UsesNow is set when the compiled statement depends on the time it was
+	// compiled at outside of its conditions, through a call to now() in its
+	// fields or dimensions. The conditions are bound to the current time each
+	// time the statement is executed.
+	UsesNow bool
+
 	stmt *cnosql.SelectStatement
 }
 
@@ -103,9 +125,28 @@ func newCompiler(opt CompileOptions) *compiledStatement {
 	}
 }
 
-func Compile(stmt *cnosql.SelectStatement, opt CompileOptions) (_ Statement, err error) {
+func Compile(stmt *cnosql.SelectStatement, opt CompileOptions) (Statement, error) {
+	if opt.Now.IsZero() {
+		opt.Now = time.Now().UTC()
+	}
+	c, err := compileStatement(stmt, opt)
+	if err != nil {
+		return nil, err
+	}
+	return c.bind(opt)
+}
+
+// compileStatement compiles the fields and dimensions of a statement. Its
+// conditions are compiled by bind, so the compiled statement can be bound to
+// the values of different parameters and to a different time.
+func compileStatement(stmt *cnosql.SelectStatement, opt CompileOptions) (_ *compiledStatement, err error) {
 	c := newCompiler(opt)
 	c.stmt = stmt.Clone()
+	cnosql.WalkFunc(c.stmt, func(n cnosql.Node) {
+		if stmt, ok := n.(*cnosql.SelectStatement); ok && callsNow(stmt) {
+			c.UsesNow = true
+		}
+	})
 	if err := c.preprocess(c.stmt); err != nil {
 		return nil, err
 	}
@@ -113,7 +154,6 @@ func Compile(stmt *cnosql.SelectStatement, opt CompileOptions) (_ Statement, err
 		return nil, err
 	}
 	c.stmt.TimeAlias = c.TimeFieldName
-	c.stmt.Condition = c.Condition
 
 	defer func() {
 		if e := recover(); e != nil && err == nil {
@@ -131,10 +171,60 @@ func Compile(stmt *cnosql.SelectStatement, opt CompileOptions) (_ Statement, err
 
 	// Remove "time" from fields list.
 	c.stmt.RewriteTimeFields()
+	return c, nil
+}
+
+// callsNow returns true if the fields, dimensions or HAVING clause of a
+// statement call now().
+func callsNow(stmt *cnosql.SelectStatement) bool {
+	var found bool
+	fn := func(n cnosql.Node) {
+		if call, ok := n.(*cnosql.Call); ok && call.Name == "now" {
+			found = true
+		}
+	}
+	cnosql.WalkFunc(stmt.Fields, fn)
+	cnosql.WalkFunc(stmt.Dimensions, fn)
+	if stmt.Having != nil {
+		cnosql.WalkFunc(stmt.Having, fn)
+	}
+	return found
+}
+
+// bind returns a copy of the compiled statement with the values of the bound
+// parameters and the current time of opt substituted into its conditions.
+// The compiled statement itself is not modified, so it can be shared.
+func (c *compiledStatement) bind(opt CompileOptions) (_ Statement, err error) {
+	b := *c
+	b.Options.Now = opt.Now
+	b.Options.GeoIndex = opt.GeoIndex
+	b.Options.Params = opt.Params
+	b.stmt = c.stmt.Clone()
+	if err := cnosql.BindParameters(b.stmt, opt.Params); err != nil {
+		return nil, err
+	}
+	if err := b.bindCondition(b.stmt); err != nil {
+		return nil, err
+	}
+	if err := b.bindSources(b.stmt); err != nil {
+		return nil, err
+	}
+	b.stmt.Condition = b.Condition
+
+	defer func() {
+		if e := recover(); e != nil && err == nil {
+			var ok bool
+			err, ok = e.(error)
+			if !ok {
+				err = fmt.Errorf("panic: %v", e)
+			}
+			err = fmt.Errorf("likely malformed statement, unable to rewrite: %w", err)
+		}
+	}()
 
 	// Rewrite any regex conditions that could make use of the index.
-	c.stmt.RewriteRegexConditions()
-	return c, nil
+	b.stmt.RewriteRegexConditions()
+	return &b, nil
 }
 
 // preprocess retrieves and records the global attributes of the current statement.
@@ -143,27 +233,39 @@ func (c *compiledStatement) preprocess(stmt *cnosql.SelectStatement) error {
 	c.Limit = stmt.Limit
 	c.HasTarget = stmt.Target != nil
 
+	// Read the dimensions of the query, validate them, and retrieve the interval
+	// if it exists.
+	if err := c.compileDimensions(stmt); err != nil {
+		return err
+	}
+
+	// Retrieve the fill option for the statement.
+	c.FillOption = stmt.Fill
+	return nil
+}
+
+// bindCondition compiles the condition of the statement and retrieves the
+// time range selected by it.
+func (c *compiledStatement) bindCondition(stmt *cnosql.SelectStatement) error {
+	cond, err := c.Options.GeoIndex.RewriteCondition(stmt.Condition)
+	if err != nil {
+		return err
+	}
+
 	valuer := cnosql.NowValuer{Now: c.Options.Now, Location: stmt.Location}
-	cond, t, err := cnosql.ConditionExpr(stmt.Condition, &valuer)
+	cond, t, err := cnosql.ConditionExpr(cond, &valuer)
 	if err != nil {
 		return err
 	}
 	// Verify that the condition is actually ok to use.
 	if err := c.validateCondition(cond); err != nil {
 		return err
+	} else if err := validateConditionTerms(cond); err != nil {
+		return err
 	}
 	c.Condition = cond
 	c.TimeRange = t
 
-	// Read the dimensions of the query, validate them, and retrieve the interval
-	// if it exists.
-	if err := c.compileDimensions(stmt); err != nil {
-		return err
-	}
-
-	// Retrieve the fill option for the statement.
-	c.FillOption = stmt.Fill
-
 	// Resolve the min and max times now that we know if there is an interval or not.
 	if c.TimeRange.Min.IsZero() {
 		c.TimeRange.Min = time.Unix(0, cnosql.MinTime).UTC()
@@ -188,6 +290,9 @@ func (c *compiledStatement) compile(stmt *cnosql.SelectStatement) error {
 	if err := c.validateFields(); err != nil {
 		return err
 	}
+	if err := c.compileHaving(stmt); err != nil {
+		return err
+	}
 
 	// Look through the sources and compile each of the subqueries (if they exist).
 	// We do this after compiling the outside because subqueries may require
@@ -199,13 +304,17 @@ func (c *compiledStatement) compile(stmt *cnosql.SelectStatement) error {
 			if err := c.subquery(source.Statement); err != nil {
 				return err
 			}
+		case *cnosql.Join:
+			if err := c.join(stmt, source); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
 }
 
 func (c *compiledStatement) compileFields(stmt *cnosql.SelectStatement) error {
-	valuer := MathValuer{}
+	valuer := cnosql.MultiValuer(MathValuer{}, StringValuer{}, ConditionalValuer{})
 
 	c.Fields = make([]*compiledField, 0, len(stmt.Fields))
 	for _, f := range stmt.Fields {
@@ -222,7 +331,7 @@ func (c *compiledStatement) compileFields(stmt *cnosql.SelectStatement) error {
 		}
 
 		// Append this field to the list of processed fields and compile it.
-		f.Expr = cnosql.Reduce(f.Expr, &valuer)
+		f.Expr = cnosql.Reduce(f.Expr, valuer)
 		field := &compiledField{
 			global:        c,
 			Field:         f,
@@ -236,6 +345,71 @@ func (c *compiledStatement) compileFields(stmt *cnosql.SelectStatement) error {
 	return nil
 }
 
+// compileHaving validates the HAVING clause of the statement. References to
+// the name or alias of a field are replaced with the expression of that field
+// so the clause only refers to aggregates that are computed by the query.
+func (c *compiledStatement) compileHaving(stmt *cnosql.SelectStatement) error {
+	if stmt.Having == nil {
+		return nil
+	} else if len(c.FunctionCalls) == 0 {
+		return errors.New("HAVING requires at least one aggregate function")
+	}
+
+	names := make(map[string]cnosql.Expr, len(c.Fields))
+	for _, f := range c.Fields {
+		names[f.Field.Name()] = f.Field.Expr
+	}
+	calls := make(map[string]struct{}, len(c.FunctionCalls))
+	for _, call := range c.FunctionCalls {
+		calls[call.String()] = struct{}{}
+	}
+
+	valuer := cnosql.MultiValuer(MathValuer{}, StringValuer{}, ConditionalValuer{})
+	expr := cnosql.RewriteExpr(cnosql.CloneExpr(stmt.Having), func(expr cnosql.Expr) cnosql.Expr {
+		if ref, ok := expr.(*cnosql.VarRef); ok {
+			if e, ok := names[ref.Val]; ok {
+				return cnosql.CloneExpr(e)
+			}
+		}
+		return expr
+	})
+	expr = cnosql.Reduce(expr, valuer)
+
+	// Compile the clause separately so the calls that only occur in the
+	// clause do not change the fields that are returned by the query.
+	having := *c
+	having.FunctionCalls = nil
+	having.HasAuxiliaryFields = false
+	field := &compiledField{
+		global: &having,
+		Field:  &cnosql.Field{Expr: expr},
+	}
+	if _, ok := expr.(cnosql.Literal); ok {
+		return errors.New("HAVING must refer to at least one aggregate function")
+	} else if err := field.compileExpr(expr); err != nil {
+		return err
+	} else if having.HasAuxiliaryFields {
+		return errors.New("HAVING can only refer to aggregate functions and the names of fields")
+	}
+
+	for _, call := range having.FunctionCalls {
+		if _, ok := calls[call.String()]; ok {
+			continue
+		}
+		switch call.Name {
+		case "top", "bottom", "distinct", "sample", "holt_winters", "holt_winters_with_fit", "histogram", "histogram_buckets":
+			return fmt.Errorf("%s() in HAVING must also be selected by the query", call.Name)
+		}
+		if c.HasAuxiliaryFields {
+			return errors.New("mixing multiple selector functions with tags or fields is not supported")
+		}
+	}
+	c.ExtraIntervals = having.ExtraIntervals
+	c.TimeShift = having.TimeShift
+	stmt.Having = expr
+	return nil
+}
+
 type compiledField struct {
 	// This holds the global state from the compiled statement.
 	global *compiledStatement
@@ -272,6 +446,10 @@ func (c *compiledField) compileExpr(expr cnosql.Expr) error {
 	case *cnosql.Call:
 		if isMathFunction(expr) {
 			return c.compileMathFunction(expr)
+		} else if isStringFunction(expr) {
+			return c.compileStringFunction(expr)
+		} else if isConditionalFunction(expr) {
+			return c.compileConditionalFunction(expr)
 		}
 
 		// Register the function call in the list of function calls.
@@ -280,6 +458,10 @@ func (c *compiledField) compileExpr(expr cnosql.Expr) error {
 		switch expr.Name {
 		case "percentile":
 			return c.compilePercentile(expr.Args)
+		case "quantile_approx":
+			return c.compileQuantileApprox(expr.Args)
+		case "histogram", "histogram_buckets":
+			return c.compileHistogram(expr)
 		case "sample":
 			return c.compileSample(expr.Args)
 		case "distinct":
@@ -304,11 +486,15 @@ func (c *compiledField) compileExpr(expr cnosql.Expr) error {
 			return c.compileChandeMomentumOscillator(expr.Args)
 		case "elapsed":
 			return c.compileElapsed(expr.Args)
+		case "state_duration", "state_count":
+			return c.compileState(expr.Name, expr.Args)
 		case "integral":
 			return c.compileIntegral(expr.Args)
 		case "holt_winters", "holt_winters_with_fit":
 			withFit := expr.Name == "holt_winters_with_fit"
 			return c.compileHoltWinters(expr.Args, withFit)
+		case "timeshift":
+			return c.compileTimeShift(expr.Args)
 		default:
 			return c.compileFunction(expr)
 		}
@@ -339,6 +525,8 @@ func (c *compiledField) compileExpr(expr cnosql.Expr) error {
 			}
 			return nil
 		}
+	case *cnosql.CaseExpr:
+		return c.compileCaseExpr(expr)
 	case *cnosql.ParenExpr:
 		return c.compileExpr(expr.Expr)
 	case cnosql.Literal:
@@ -391,7 +579,7 @@ func (c *compiledField) compileFunction(expr *cnosql.Call) error {
 	switch expr.Name {
 	case "max", "min", "first", "last":
 		// top/bottom are not included here since they are not typical functions.
-	case "count", "sum", "mean", "median", "mode", "stddev", "spread":
+	case "count", "sum", "mean", "median", "mode", "stddev", "spread", "count_distinct_approx":
 		// These functions are not considered selectors.
 		c.global.OnlySelectors = false
 	default:
@@ -412,9 +600,43 @@ func (c *compiledField) compileFunction(expr *cnosql.Call) error {
 			return c.compileDistinct(call.Args, true)
 		}
 	}
+
+	// The aggregate may be computed over a conditional expression that is
+	// evaluated for each point.
+	if isConditionalExpr(expr.Args[0]) {
+		return c.compileConditionalArg(expr.Name, expr.Args[0])
+	}
 	return c.compileSymbol(expr.Name, expr.Args[0])
 }
 
+// compileConditionalArg validates a conditional expression passed as the
+// argument of an aggregate. The expression can only refer to the fields and
+// tags of each point.
+func (c *compiledField) compileConditionalArg(name string, expr cnosql.Expr) error {
+	var err error
+	hasRef := false
+	cnosql.WalkFunc(expr, func(n cnosql.Node) {
+		switch n := n.(type) {
+		case *cnosql.VarRef:
+			hasRef = true
+		case *cnosql.Wildcard:
+			if err == nil {
+				err = fmt.Errorf("unsupported expression with wildcard: %s()", name)
+			}
+		case *cnosql.Call:
+			if err == nil && !isMathFunction(n) && !isStringFunction(n) && !isConditionalFunction(n) {
+				err = fmt.Errorf("unsupported function call %s() in the argument of %s()", n.Name, name)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	} else if !hasRef {
+		return fmt.Errorf("expected field argument in %s()", name)
+	}
+	return c.global.validateCondition(expr)
+}
+
 func (c *compiledField) compilePercentile(args []cnosql.Expr) error {
 	if exp, got := 2, len(args); got != exp {
 		return fmt.Errorf("invalid number of arguments for percentile, expected %d, got %d", exp, got)
@@ -429,6 +651,43 @@ func (c *compiledField) compilePercentile(args []cnosql.Expr) error {
 	return c.compileSymbol("percentile", args[0])
 }
 
+func (c *compiledField) compileQuantileApprox(args []cnosql.Expr) error {
+	if exp, got := 2, len(args); got != exp {
+		return fmt.Errorf("invalid number of arguments for quantile_approx, expected %d, got %d", exp, got)
+	}
+
+	var q float64
+	switch arg1 := args[1].(type) {
+	case *cnosql.IntegerLiteral:
+		q = float64(arg1.Val)
+	case *cnosql.NumberLiteral:
+		q = arg1.Val
+	default:
+		return fmt.Errorf("expected float argument in quantile_approx()")
+	}
+	if q < 0 || q > 1 {
+		return fmt.Errorf("quantile_approx() quantile must be between 0 and 1, got %v", q)
+	}
+	c.global.OnlySelectors = false
+	return c.compileSymbol("quantile_approx", args[0])
+}
+
+func (c *compiledField) compileHistogram(expr *cnosql.Call) error {
+	if _, err := histogramBounds(expr); err != nil {
+		return err
+	}
+
+	// The rows of each bucket have their own column for the upper bound of
+	// the bucket, so the histogram must be selected on its own.
+	if c.Field.Expr != expr {
+		return fmt.Errorf("%s() cannot be used in an expression", expr.Name)
+	}
+	c.global.HistogramFunction = expr.Name
+	c.global.OnlySelectors = false
+	c.AllowWildcard = false
+	return c.compileSymbol(expr.Name, expr.Args[0])
+}
+
 func (c *compiledField) compileSample(args []cnosql.Expr) error {
 	if exp, got := 2, len(args); got != exp {
 		return fmt.Errorf("invalid number of arguments for sample, expected %d, got %d", exp, got)
@@ -486,6 +745,35 @@ func (c *compiledField) compileDerivative(args []cnosql.Expr, isNonNegative bool
 	}
 }
 
+func (c *compiledField) compileTimeShift(args []cnosql.Expr) error {
+	if exp, got := 2, len(args); got != exp {
+		return fmt.Errorf("invalid number of arguments for timeshift, expected %d, got %d", exp, got)
+	}
+
+	shift, ok := args[1].(*cnosql.DurationLiteral)
+	if !ok {
+		return fmt.Errorf("second argument to timeshift must be a duration, got %T", args[1])
+	} else if shift.Val <= 0 {
+		return fmt.Errorf("duration argument must be positive, got %s", cnosql.FormatDuration(shift.Val))
+	}
+	c.global.OnlySelectors = false
+	if shift.Val > c.global.TimeShift {
+		c.global.TimeShift = shift.Val
+	}
+
+	// The shifted data is realigned with the windows of the query, so only
+	// aggregates over fixed intervals can be shifted.
+	call, ok := args[0].(*cnosql.Call)
+	if !ok {
+		return errors.New("aggregate function required inside the call to timeshift")
+	} else if c.global.Interval.IsZero() {
+		return errors.New("timeshift aggregate requires a GROUP BY interval")
+	} else if !c.global.Interval.Calendar.IsZero() {
+		return errors.New("timeshift cannot be used with a calendar GROUP BY interval")
+	}
+	return c.compileNestedExpr(call)
+}
+
 func (c *compiledField) compileElapsed(args []cnosql.Expr) error {
 	if min, max, got := 1, 2, len(args); got > max || got < min {
 		return fmt.Errorf("invalid number of arguments for elapsed, expected at least %d but no more than %d, got %d", min, max, got)
@@ -522,6 +810,56 @@ func (c *compiledField) compileElapsed(args []cnosql.Expr) error {
 	}
 }
 
+func (c *compiledField) compileState(name string, args []cnosql.Expr) error {
+	if name == "state_duration" {
+		if min, max, got := 2, 3, len(args); got > max || got < min {
+			return fmt.Errorf("invalid number of arguments for %s, expected at least %d but no more than %d, got %d", name, min, max, got)
+		}
+	} else if got := len(args); got != 2 {
+		return fmt.Errorf("invalid number of arguments for %s, expected 2, got %d", name, got)
+	}
+
+	// Retrieve the unit from the state_duration() call, if specified.
+	if len(args) == 3 {
+		switch arg2 := args[2].(type) {
+		case *cnosql.DurationLiteral:
+			if arg2.Val <= 0 {
+				return fmt.Errorf("duration argument must be positive, got %s", cnosql.FormatDuration(arg2.Val))
+			}
+		default:
+			return fmt.Errorf("third argument to %s must be a duration, got %T", name, args[2])
+		}
+	}
+
+	// The condition refers to the values by the name of the field.
+	field := stateFieldName(args[0])
+	if field == "" {
+		return fmt.Errorf("expected field argument in %s()", name)
+	} else if err := validateStateCondition(name, field, args[1]); err != nil {
+		return err
+	}
+
+	// The state is tracked from the oldest point to the newest.
+	if !c.global.Ascending {
+		return fmt.Errorf("%s cannot be used with ORDER BY time DESC", name)
+	}
+	c.global.OnlySelectors = false
+
+	// Must be a variable reference or function.
+	switch arg0 := args[0].(type) {
+	case *cnosql.Call:
+		if c.global.Interval.IsZero() {
+			return fmt.Errorf("%s aggregate requires a GROUP BY interval", name)
+		}
+		return c.compileNestedExpr(arg0)
+	default:
+		if !c.global.Interval.IsZero() && !c.global.InheritedInterval {
+			return fmt.Errorf("aggregate function required inside the call to %s", name)
+		}
+		return c.compileSymbol(name, arg0)
+	}
+}
+
 func (c *compiledField) compileDifference(args []cnosql.Expr, isNonNegative bool) error {
 	name := "difference"
 	if isNonNegative {
@@ -885,6 +1223,8 @@ func (c *compiledField) compileMathFunction(expr *cnosql.Call) error {
 	switch expr.Name {
 	case "atan2", "pow", "log":
 		nargs = 2
+	case "geo_distance":
+		nargs = 4
 	}
 
 	// Did we get the expected number of args?
@@ -904,6 +1244,73 @@ func (c *compiledField) compileMathFunction(expr *cnosql.Call) error {
 	return nil
 }
 
+func (c *compiledField) compileStringFunction(expr *cnosql.Call) error {
+	if err := validateStringFunctionArgs(expr); err != nil {
+		return err
+	} else if err := compileRegexpPattern(expr); err != nil {
+		return err
+	}
+
+	// Compile all the argument expressions that are not just literals.
+	for _, arg := range expr.Args {
+		if _, ok := arg.(cnosql.Literal); ok {
+			continue
+		}
+		if err := c.compileExpr(arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *compiledField) compileConditionalFunction(expr *cnosql.Call) error {
+	if err := validateConditionalFunctionArgs(expr); err != nil {
+		return err
+	}
+
+	// Compile all the argument expressions that are not just literals.
+	for _, arg := range expr.Args {
+		if _, ok := arg.(cnosql.Literal); ok {
+			continue
+		}
+		if err := c.compileExpr(arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *compiledField) compileCaseExpr(expr *cnosql.CaseExpr) error {
+	// Disallow wildcards in CASE expressions for the same reason as in
+	// binary expressions.
+	c.AllowWildcard = false
+
+	exprs := make([]cnosql.Expr, 0, 2*len(expr.WhenClauses)+1)
+	for _, w := range expr.WhenClauses {
+		exprs = append(exprs, w.Cond, w.Result)
+	}
+	if expr.Else != nil {
+		exprs = append(exprs, expr.Else)
+	}
+
+	// Compile all the expressions that are not just literals. At least one
+	// of them must contain a variable.
+	compiled := false
+	for _, e := range exprs {
+		if _, ok := e.(cnosql.Literal); ok {
+			continue
+		}
+		if err := c.compileExpr(e); err != nil {
+			return err
+		}
+		compiled = true
+	}
+	if !compiled {
+		return errors.New("field must contain at least one variable")
+	}
+	return nil
+}
+
 func (c *compiledStatement) compileDimensions(stmt *cnosql.SelectStatement) error {
 	for _, d := range stmt.Dimensions {
 		// Reduce the expression before attempting anything. Do not evaluate the call.
@@ -921,6 +1328,14 @@ func (c *compiledStatement) compileDimensions(stmt *cnosql.SelectStatement) erro
 				return errors.New("only time() calls allowed in dimensions")
 			} else if got := len(expr.Args); got < 1 || got > 2 {
 				return errors.New("time dimension expected 1 or 2 arguments")
+			} else if cal, err := cnosql.CalendarTimeDimension(expr); err != nil {
+				return err
+			} else if !cal.IsZero() {
+				if c.Interval.Duration != 0 {
+					return errors.New("multiple time dimensions not allowed")
+				}
+				c.Interval.Duration = cal.MaxDuration()
+				c.Interval.Calendar = cal
 			} else if lit, ok := expr.Args[0].(*cnosql.DurationLiteral); !ok {
 				return errors.New("time dimension must have duration argument")
 			} else if c.Interval.Duration != 0 {
@@ -999,6 +1414,10 @@ func (c *compiledStatement) validateFields() error {
 	if c.HasDistinct && (len(c.FunctionCalls) != 1 || c.HasAuxiliaryFields) {
 		return errors.New("aggregate function distinct() cannot be combined with other functions or fields")
 	}
+	// The histogram functions emit a row for each bucket of a window.
+	if c.HistogramFunction != "" && (len(c.FunctionCalls) != 1 || c.HasAuxiliaryFields) {
+		return fmt.Errorf("aggregate function %s() cannot be combined with other functions or fields", c.HistogramFunction)
+	}
 	// Validate we are using a selector or raw query if auxiliary fields are required.
 	if c.HasAuxiliaryFields {
 		if !c.OnlySelectors {
@@ -1027,20 +1446,32 @@ func (c *compiledStatement) validateCondition(expr cnosql.Expr) error {
 		}
 		return nil
 	case *cnosql.Call:
-		if !isMathFunction(expr) {
+		if isStringFunction(expr) {
+			if err := validateStringFunctionArgs(expr); err != nil {
+				return err
+			} else if err := compileRegexpPattern(expr); err != nil {
+				return err
+			}
+		} else if isConditionalFunction(expr) {
+			if err := validateConditionalFunctionArgs(expr); err != nil {
+				return err
+			}
+		} else if !isMathFunction(expr) {
 			return fmt.Errorf("invalid function call in condition: %s", expr)
-		}
-
-		// How many arguments are we expecting?
-		nargs := 1
-		switch expr.Name {
-		case "atan2", "pow":
-			nargs = 2
-		}
+		} else {
+			// How many arguments are we expecting?
+			nargs := 1
+			switch expr.Name {
+			case "atan2", "pow":
+				nargs = 2
+			case "geo_distance":
+				nargs = 4
+			}
 
-		// Did we get the expected number of args?
-		if got := len(expr.Args); got != nargs {
-			return fmt.Errorf("invalid number of arguments for %s, expected %d, got %d", expr.Name, nargs, got)
+			// Did we get the expected number of args?
+			if got := len(expr.Args); got != nargs {
+				return fmt.Errorf("invalid number of arguments for %s, expected %d, got %d", expr.Name, nargs, got)
+			}
 		}
 
 		// Are all the args valid?
@@ -1050,11 +1481,42 @@ func (c *compiledStatement) validateCondition(expr cnosql.Expr) error {
 			}
 		}
 		return nil
+	case *cnosql.CaseExpr:
+		for _, w := range expr.WhenClauses {
+			if err := c.validateCondition(w.Cond); err != nil {
+				return err
+			}
+			if err := c.validateCondition(w.Result); err != nil {
+				return err
+			}
+		}
+		return c.validateCondition(expr.Else)
 	default:
 		return nil
 	}
 }
 
+// validateConditionTerms verifies that the function calls used as terms of
+// the condition, rather than as operands of a comparison, return a boolean.
+func validateConditionTerms(expr cnosql.Expr) error {
+	switch expr := expr.(type) {
+	case *cnosql.BinaryExpr:
+		if expr.Op == cnosql.AND || expr.Op == cnosql.OR {
+			if err := validateConditionTerms(expr.LHS); err != nil {
+				return err
+			}
+			return validateConditionTerms(expr.RHS)
+		}
+	case *cnosql.ParenExpr:
+		return validateConditionTerms(expr.Expr)
+	case *cnosql.Call:
+		if expr.Name != "regexp_match" {
+			return fmt.Errorf("invalid condition expression: %s", expr)
+		}
+	}
+	return nil
+}
+
 // subquery compiles and validates a compiled statement for the subquery using
 // this compiledStatement as the parent.
 func (c *compiledStatement) subquery(stmt *cnosql.SelectStatement) error {
@@ -1063,14 +1525,11 @@ func (c *compiledStatement) subquery(stmt *cnosql.SelectStatement) error {
 		return err
 	}
 
-	// Substitute now() into the subquery condition. Then use ConditionExpr to
-	// validate the expression. Do not store the results. We have no way to store
-	// and read those results at the moment.
-	valuer := cnosql.MultiValuer(
-		&cnosql.NowValuer{Now: c.Options.Now, Location: stmt.Location},
-		&MathValuer{},
-	)
-	stmt.Condition = cnosql.Reduce(stmt.Condition, valuer)
+	// The rows of a subquery are read by series and time, so they cannot be
+	// ordered by their values.
+	if !stmt.SortFields.ByTime() {
+		return errors.New("subqueries can only be ordered by time")
+	}
 
 	// If the ordering is different and the sort field was specified for the subquery,
 	// throw an error.
@@ -1079,11 +1538,6 @@ func (c *compiledStatement) subquery(stmt *cnosql.SelectStatement) error {
 	}
 	subquery.Ascending = c.Ascending
 
-	// Find the intersection between this time range and the parent.
-	// If the subquery doesn't have a time range, this causes it to
-	// inherit the parent's time range.
-	subquery.TimeRange = subquery.TimeRange.Intersect(c.TimeRange)
-
 	// If the fill option is null, set it to none so we don't waste time on
 	// null values with a redundant fill iterator.
 	if !subquery.Interval.IsZero() && subquery.FillOption == cnosql.NullFill {
@@ -1095,7 +1549,153 @@ func (c *compiledStatement) subquery(stmt *cnosql.SelectStatement) error {
 		subquery.Interval = c.Interval
 		subquery.InheritedInterval = true
 	}
-	return subquery.compile(stmt)
+	if err := subquery.compile(stmt); err != nil {
+		return err
+	}
+
+	if subquery.UsesNow {
+		c.UsesNow = true
+	}
+
+	// The shards read by a timeshift() call within the subquery are mapped
+	// with the shards of the parent.
+	if subquery.TimeShift > c.TimeShift {
+		c.TimeShift = subquery.TimeShift
+	}
+	return nil
+}
+
+// bindSources binds the conditions of the subqueries read by the statement,
+// including the subqueries of the sides of joins.
+func (c *compiledStatement) bindSources(stmt *cnosql.SelectStatement) error {
+	for _, source := range stmt.Sources {
+		switch source := source.(type) {
+		case *cnosql.SubQuery:
+			if err := c.bindSubquery(source.Statement); err != nil {
+				return err
+			}
+		case *cnosql.Join:
+			for _, side := range []*cnosql.JoinSource{source.Left, source.Right} {
+				if sub, ok := side.Source.(*cnosql.SubQuery); ok {
+					if err := c.bindSubquery(sub.Statement); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// bindSubquery validates the condition of a subquery and substitutes the
+// current time into it.
+func (c *compiledStatement) bindSubquery(stmt *cnosql.SelectStatement) error {
+	// Use ConditionExpr to validate the expression. Do not store the results.
+	// We have no way to store and read those results at the moment.
+	subquery := newCompiler(c.Options)
+	if err := subquery.bindCondition(stmt); err != nil {
+		return err
+	}
+
+	// Substitute now() into the subquery condition.
+	valuer := cnosql.MultiValuer(
+		&cnosql.NowValuer{Now: c.Options.Now, Location: stmt.Location},
+		&MathValuer{},
+		&StringValuer{},
+		&ConditionalValuer{},
+	)
+	stmt.Condition = cnosql.Reduce(stmt.Condition, valuer)
+	return subquery.bindSources(stmt)
+}
+
+// join validates the grouping of the statement reading from the join j and
+// compiles the subqueries of its sides using this compiledStatement as the
+// parent.
+func (c *compiledStatement) join(stmt *cnosql.SelectStatement, j *cnosql.Join) error {
+	// The rows of a join only have the tags it matches rows on.
+	for _, d := range stmt.Dimensions {
+		if ref, ok := d.Expr.(*cnosql.VarRef); ok && !j.IsKey(ref.Val) {
+			return fmt.Errorf("cannot group a join by %s, it is not a join key", ref.Val)
+		}
+	}
+
+	for _, side := range []*cnosql.JoinSource{j.Left, j.Right} {
+		if source, ok := side.Source.(*cnosql.SubQuery); ok {
+			source.Statement.OmitTime = true
+			if err := c.subquery(source.Statement); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// planJoin replaces the sides of the join j read by stmt with raw subqueries
+// of the columns of each side referenced by the statement, grouped by the
+// keys of the join. The rows of sides of which no column is referenced are
+// read with all of their fields, as they are still joined.
+func planJoin(stmt *cnosql.SelectStatement, j *cnosql.Join, m cnosql.FieldMapper) error {
+	fields := make(map[*cnosql.JoinSource]cnosql.Fields)
+	seen := make(map[*cnosql.JoinSource]map[string]struct{})
+	collect := func(n cnosql.Node) {
+		ref, ok := n.(*cnosql.VarRef)
+		if !ok || ref.Type == cnosql.Unknown {
+			return
+		}
+		side, column := j.Column(ref.Val)
+		if side == nil {
+			return
+		}
+		if seen[side] == nil {
+			seen[side] = make(map[string]struct{})
+		}
+		if _, ok := seen[side][column]; ok {
+			return
+		}
+		seen[side][column] = struct{}{}
+		fields[side] = append(fields[side], &cnosql.Field{
+			Expr: &cnosql.VarRef{Val: column, Type: ref.Type},
+		})
+	}
+	cnosql.WalkFunc(stmt.Fields, collect)
+	cnosql.WalkFunc(stmt.Condition, collect)
+
+	for _, side := range []*cnosql.JoinSource{j.Left, j.Right} {
+		dimensions := make(cnosql.Dimensions, len(j.On))
+		for i, k := range j.On {
+			dimensions[i] = &cnosql.Dimension{Expr: &cnosql.VarRef{Val: k}}
+		}
+
+		// The rows of both sides are merged regardless of the measurement
+		// they come from.
+		source := side.Source
+		cnosql.WalkFunc(source, func(n cnosql.Node) {
+			if stmt, ok := n.(*cnosql.SelectStatement); ok {
+				stmt.StripName = true
+			}
+		})
+
+		sub := &cnosql.SelectStatement{
+			Fields:     fields[side],
+			Sources:    cnosql.Sources{source},
+			Dimensions: dimensions,
+			Location:   stmt.Location,
+			IsRawQuery: true,
+			OmitTime:   true,
+			StripName:  true,
+		}
+		if len(sub.Fields) == 0 {
+			sub.Fields = cnosql.Fields{{Expr: &cnosql.Wildcard{Type: cnosql.FIELD}}}
+			var err error
+			if sub, err = sub.RewriteFields(m); err != nil {
+				return err
+			}
+		}
+		// Keep the name of the side, the name of its measurement.
+		side.Alias = side.Name()
+		side.Source = &cnosql.SubQuery{Statement: sub}
+	}
+	return nil
 }
 
 func (c *compiledStatement) Prepare(shardMapper ShardMapper, sopt SelectOptions) (PreparedStatement, error) {
@@ -1120,6 +1720,7 @@ func (c *compiledStatement) Prepare(shardMapper ShardMapper, sopt SelectOptions)
 				Interval: Interval{
 					Duration: interval,
 					Offset:   offset,
+					Calendar: c.Interval.Calendar,
 				},
 			}
 			last, _ := opt.Window(c.TimeRange.MaxTimeNano() - 1)
@@ -1154,6 +1755,16 @@ func (c *compiledStatement) Prepare(shardMapper ShardMapper, sopt SelectOptions)
 		}
 	}
 
+	// Include the time range read by timeshift() calls.
+	if c.TimeShift > 0 {
+		newTime := timeRange.Min.Add(-c.TimeShift)
+		if !newTime.Before(time.Unix(0, cnosql.MinTime).UTC()) {
+			timeRange.Min = newTime
+		} else {
+			timeRange.Min = time.Unix(0, cnosql.MinTime).UTC()
+		}
+	}
+
 	// Create an iterator creator based on the shards in the cluster.
 	shards, err := shardMapper.MapShards(c.stmt.Sources, timeRange, sopt)
 	if err != nil {
@@ -1174,6 +1785,25 @@ func (c *compiledStatement) Prepare(shardMapper ShardMapper, sopt SelectOptions)
 		return nil, err
 	}
 
+	// Plan the reading of the sides of joins now that the columns read from
+	// them are known.
+	for _, source := range stmt.Sources {
+		if j, ok := source.(*cnosql.Join); ok {
+			if err := planJoin(stmt, j, mapper); err != nil {
+				shards.Close()
+				return nil, err
+			}
+		}
+	}
+
+	// Resolve the fields of the ORDER BY clause to the columns and tags of
+	// the rows.
+	sortKeys, err := newSortKeys(stmt)
+	if err != nil {
+		shards.Close()
+		return nil, err
+	}
+
 	// Determine base options for iterators.
 	opt, err := newIteratorOptionsStmt(stmt, sopt)
 	if err != nil {
@@ -1183,6 +1813,13 @@ func (c *compiledStatement) Prepare(shardMapper ShardMapper, sopt SelectOptions)
 	opt.StartTime, opt.EndTime = c.TimeRange.MinTimeNano(), c.TimeRange.MaxTimeNano()
 	opt.Ascending = c.Ascending
 
+	// The limits of a query ordered by values apply to the sorted rows
+	// rather than to the iterators.
+	if sortKeys != nil {
+		opt.Limit, opt.Offset = 0, 0
+		opt.SLimit, opt.SOffset = 0, 0
+	}
+
 	if sopt.MaxBucketsN > 0 && !stmt.IsRawQuery && c.TimeRange.MinTimeNano() > cnosql.MinTime {
 		interval, err := stmt.GroupByInterval()
 		if err != nil {
@@ -1210,6 +1847,7 @@ func (c *compiledStatement) Prepare(shardMapper ShardMapper, sopt SelectOptions)
 		opt:       opt,
 		ic:        shards,
 		columns:   columns,
+		sortKeys:  sortKeys,
 		maxPointN: sopt.MaxPointN,
 		now:       c.Options.Now,
 	}, nil
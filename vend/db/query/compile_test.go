@@ -86,6 +86,12 @@ func TestCompile_Success(t *testing.T) {
 		`SELECT moving_average(distinct(value), 3) FROM air WHERE time >= now() - 5m GROUP BY time(1m)`,
 		`SELECT elapsed(distinct(value)) FROM air WHERE time >= now() - 5m GROUP BY time(1m)`,
 		`SELECT cumulative_sum(distinct(value)) FROM air WHERE time >= now() - 5m GROUP BY time(1m)`,
+		`SELECT state_count(value, value > 10) FROM air`,
+		`SELECT state_duration(valve, valve = 'open' OR valve =~ /^pending/, 1m) FROM air`,
+		`SELECT state_duration(mean(value), value >= 10 AND value < 20) FROM air WHERE time >= now() - 5m GROUP BY time(1m)`,
+		`SELECT mean(value) FROM air WHERE time >= now() - 1d GROUP BY time(1mo) TZ('America/Los_Angeles')`,
+		`SELECT sum(value) FROM air WHERE time >= now() - 1d GROUP BY time(1y), host fill(0)`,
+		`SELECT count(value) FROM air WHERE time >= now() - 1d GROUP BY time(2w, Monday)`,
 		`SELECT last(value) / (1 - 0) FROM air`,
 		`SELECT abs(value) FROM air`,
 		`SELECT sin(value) FROM air`,
@@ -110,6 +116,35 @@ func TestCompile_Success(t *testing.T) {
 		`SELECT log10(value) FROM air`,
 		`SELECT sin(value) - sin(1.3) FROM air`,
 		`SELECT value FROM air WHERE sin(value) > 0.5`,
+		`SELECT count_distinct_approx(value) FROM air`,
+		`SELECT quantile_approx(value, 0.99) FROM air WHERE time >= now() - 1h GROUP BY time(10m), host`,
+		`SELECT lower(host), upper(host) FROM air`,
+		`SELECT substr(host, 2), substr(host, 1, 3) FROM air`,
+		`SELECT concat(host, '-', region) FROM air`,
+		`SELECT replace(host, 'server', 'srv') FROM air`,
+		`SELECT regexp_extract(host, 'server([0-9]+)', 1) FROM air`,
+		`SELECT split_part(host, '.', 2), strlen(trim(host)) FROM air`,
+		`SELECT str_to_float(value) * 2 FROM air`,
+		`SELECT value FROM air WHERE lower(host) = 'server01'`,
+		`SELECT value FROM air WHERE regexp_match(host, '^server') = true`,
+		`SELECT value FROM air WHERE regexp_match(host, '^server') AND value > 1`,
+		`SELECT CASE WHEN value > 80 THEN 'hot' WHEN value > 50 THEN 'warm' ELSE 'ok' END AS status FROM air`,
+		`SELECT CASE WHEN host = 'server01' AND value > 1 THEN value * 2 END FROM air`,
+		`SELECT CASE WHEN max(value) > 80 THEN 'hot' ELSE 'ok' END FROM air GROUP BY time(10m)`,
+		`SELECT sum(CASE WHEN value > 80 THEN 1 ELSE 0 END), count(CASE WHEN value > 80 THEN value END) FROM air`,
+		`SELECT mean(coalesce(value, 0)), max(nullif(value, 0)) FROM air GROUP BY time(10m), host`,
+		`SELECT coalesce(value, 0), nullif(host, 'server01') FROM air`,
+		`SELECT value FROM air WHERE coalesce(value, 0) > 1`,
+		`SELECT mean(value) FROM air GROUP BY time(10m), host HAVING mean(value) > 90`,
+		`SELECT mean(value) AS m FROM air GROUP BY time(10m) HAVING m > 90 AND count(value) >= 3`,
+		`SELECT max(value), host FROM air GROUP BY time(10m) HAVING max(value) > 90`,
+		`SELECT mean(value) FROM air GROUP BY time(10m) HAVING derivative(mean(value)) > 0`,
+		`SELECT mean(value) - timeshift(mean(value), 7d) FROM air WHERE time >= now() - 1d GROUP BY time(1h), host`,
+		`SELECT timeshift(derivative(max(value)), 1d) FROM air WHERE time >= now() - 1d GROUP BY time(1h)`,
+		`SELECT histogram(value, 0, 10, 5) FROM air GROUP BY time(10m), host`,
+		`SELECT geo_distance(lat, lon, 52.52, 13.405) FROM gps WHERE geo_within_radius(52.52, 13.405, 5000) AND time >= now() - 1h`,
+		`SELECT count(lat) FROM gps WHERE geo_bbox(52.3, 13.1, 52.7, 13.7) OR geo_distance(lat, lon, 48.85, 2.35) < 1000 GROUP BY vehicle`,
+		`SELECT histogram_buckets(value, '0.5, 1, 2.5') AS latency INTO latency_buckets FROM air GROUP BY time(1m)`,
 		`SELECT sum("out")/sum("in") FROM (SELECT derivative("out") AS "out", derivative("in") AS "in" FROM "m0" WHERE time >= now() - 5m GROUP BY "index") GROUP BY time(1m) fill(none)`,
 	} {
 		t.Run(tt, func(t *testing.T) {
@@ -163,6 +198,9 @@ func TestCompile_Failures(t *testing.T) {
 		{s: `SELECT value FROM air GROUP BY time(5m, unexpected())`, err: `time dimension offset function must be now()`},
 		{s: `SELECT value FROM air GROUP BY time(5m, now(1m))`, err: `time dimension offset now() function requires no arguments`},
 		{s: `SELECT value FROM air GROUP BY time(5m, 'unexpected')`, err: `time dimension offset must be duration or now()`},
+		{s: `SELECT value FROM air GROUP BY time(1mo, 1d)`, err: `time dimension 1mo does not support an offset`},
+		{s: `SELECT value FROM air GROUP BY time(1d, monday)`, err: `time dimension with a weekday must be a multiple of 1w`},
+		{s: `SELECT value FROM air GROUP BY time(1y), time(1w, monday)`, err: `multiple time dimensions not allowed`},
 		{s: `SELECT value FROM air GROUP BY 'unexpected'`, err: `only time and tag dimensions allowed`},
 		{s: `SELECT top(value) FROM air`, err: `invalid number of arguments for top, expected at least 2, got 1`},
 		{s: `SELECT top('unexpected', 5) FROM air`, err: `expected first argument to be a field in top(), found 'unexpected'`},
@@ -313,6 +351,17 @@ func TestCompile_Failures(t *testing.T) {
 		{s: `SELECT cumulative_sum(max()) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for max, expected 1, got 0`},
 		{s: `SELECT cumulative_sum(percentile(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for percentile, expected 2, got 1`},
 		{s: `SELECT cumulative_sum(mean(value)) FROM myseries where time < now() and time > now() - 1d`, err: `cumulative_sum aggregate requires a GROUP BY interval`},
+		{s: `SELECT state_count(value) FROM myseries`, err: `invalid number of arguments for state_count, expected 2, got 1`},
+		{s: `SELECT state_duration(value, value > 1, 1s, 1) FROM myseries`, err: `invalid number of arguments for state_duration, expected at least 2 but no more than 3, got 4`},
+		{s: `SELECT state_duration(value, value > 1, 0s) FROM myseries`, err: `duration argument must be positive, got 0s`},
+		{s: `SELECT state_duration(value, value > 1, 10) FROM myseries`, err: `third argument to state_duration must be a duration, got *cnosql.IntegerLiteral`},
+		{s: `SELECT state_count(*, value > 1) FROM myseries`, err: `expected field argument in state_count()`},
+		{s: `SELECT state_count(value, other > 1) FROM myseries`, err: `condition of state_count() can only refer to value, got other`},
+		{s: `SELECT state_count(value, true) FROM myseries`, err: `condition of state_count() must refer to value`},
+		{s: `SELECT state_count(value, abs(value) > 1) FROM myseries`, err: `condition of state_count() cannot call abs()`},
+		{s: `SELECT state_count(value, value > 1) FROM myseries ORDER BY time DESC`, err: `state_count cannot be used with ORDER BY time DESC`},
+		{s: `SELECT state_count(value, value > 1) FROM myseries group by time(1h)`, err: `aggregate function required inside the call to state_count`},
+		{s: `SELECT state_count(mean(value), value > 1) FROM myseries where time < now() and time > now() - 1d`, err: `state_count aggregate requires a GROUP BY interval`},
 		{s: `SELECT integral() FROM myseries`, err: `invalid number of arguments for integral, expected at least 1 but no more than 2, got 0`},
 		{s: `SELECT integral(value, 10s, host) FROM myseries`, err: `invalid number of arguments for integral, expected at least 1 but no more than 2, got 3`},
 		{s: `SELECT integral(value, -10s) FROM myseries`, err: `duration argument must be positive, got -10s`},
@@ -344,6 +393,7 @@ func TestCompile_Failures(t *testing.T) {
 		{s: `SELECT value FROM myseries WHERE value OR time >= now() - 1m`, err: `invalid condition expression: value`},
 		{s: `SELECT value FROM myseries WHERE time >= now() - 1m OR value`, err: `invalid condition expression: value`},
 		{s: `SELECT value FROM (SELECT value FROM air ORDER BY time DESC) ORDER BY time ASC`, err: `subqueries must be ordered in the same direction as the query itself`},
+		{s: `SELECT max FROM (SELECT max(value) FROM air GROUP BY host ORDER BY max DESC)`, err: `subqueries can only be ordered by time`},
 		{s: `SELECT sin(value, 3) FROM air`, err: `invalid number of arguments for sin, expected 1, got 2`},
 		{s: `SELECT cos(2.3, value, 3) FROM air`, err: `invalid number of arguments for cos, expected 1, got 3`},
 		{s: `SELECT tan(value, 3) FROM air`, err: `invalid number of arguments for tan, expected 1, got 2`},
@@ -358,7 +408,51 @@ func TestCompile_Failures(t *testing.T) {
 		{s: `SELECT pow(value, 3, 3) FROM air`, err: `invalid number of arguments for pow, expected 2, got 3`},
 		{s: `SELECT atan2(value, 3, 3) FROM air`, err: `invalid number of arguments for atan2, expected 2, got 3`},
 		{s: `SELECT sin(1.3) FROM air`, err: `field must contain at least one variable`},
+		{s: `SELECT count_distinct_approx(value, 2) FROM air`, err: `invalid number of arguments for count_distinct_approx, expected 1, got 2`},
+		{s: `SELECT quantile_approx(value) FROM air`, err: `invalid number of arguments for quantile_approx, expected 2, got 1`},
+		{s: `SELECT quantile_approx(value, 'a') FROM air`, err: `expected float argument in quantile_approx()`},
+		{s: `SELECT quantile_approx(value, 1.5) FROM air`, err: `quantile_approx() quantile must be between 0 and 1, got 1.5`},
+		{s: `SELECT lower(host, 3) FROM air`, err: `invalid number of arguments for lower, expected 1, got 2`},
+		{s: `SELECT substr(host) FROM air`, err: `invalid number of arguments for substr, expected at least 2, got 1`},
+		{s: `SELECT substr(host, 1, 2, 3) FROM air`, err: `invalid number of arguments for substr, expected at most 3, got 4`},
+		{s: `SELECT concat(host) FROM air`, err: `invalid number of arguments for concat, expected at least 2, got 1`},
+		{s: `SELECT replace(host, 'a') FROM air`, err: `invalid number of arguments for replace, expected 3, got 2`},
+		{s: `SELECT upper('abc') FROM air`, err: `field must contain at least one variable`},
+		{s: `SELECT regexp_match(host, '(') FROM air`, err: "invalid regular expression for regexp_match: error parsing regexp: missing closing ): `(`"},
+		{s: `SELECT value FROM air WHERE regexp_extract(host, '[a', 0) = 'a'`, err: "invalid regular expression for regexp_extract: error parsing regexp: missing closing ]: `[a`"},
+		{s: `SELECT value FROM air WHERE split_part(host, '.') = 'a'`, err: `invalid number of arguments for split_part, expected 3, got 2`},
+		{s: `SELECT value FROM air WHERE lower(host)`, err: `invalid condition expression: lower(host)`},
+		{s: `SELECT value FROM air WHERE regexp_match(host, '[a')`, err: "invalid regular expression for regexp_match: error parsing regexp: missing closing ]: `[a`"},
+		{s: `SELECT CASE WHEN true THEN 1 ELSE 2 END FROM air`, err: `field must contain at least one variable`},
+		{s: `SELECT CASE WHEN value > 1 THEN * END FROM air`, err: `unable to use wildcard in a binary expression`},
+		{s: `SELECT sum(CASE WHEN max(value) > 1 THEN 1 END) FROM air`, err: `unsupported function call max() in the argument of sum()`},
+		{s: `SELECT sum(CASE WHEN true THEN 1 END) FROM air`, err: `expected field argument in sum()`},
+		{s: `SELECT sum(nullif(value)) FROM air`, err: `invalid number of arguments for nullif, expected 2, got 1`},
+		{s: `SELECT coalesce() FROM air`, err: `invalid number of arguments for coalesce, expected at least 1, got 0`},
+		{s: `SELECT value FROM air HAVING value > 1`, err: `HAVING requires at least one aggregate function`},
+		{s: `SELECT mean(value) FROM air GROUP BY time(10m) HAVING value > 1`, err: `HAVING can only refer to aggregate functions and the names of fields`},
+		{s: `SELECT mean(value) FROM air GROUP BY time(10m) HAVING true`, err: `HAVING must refer to at least one aggregate function`},
+		{s: `SELECT mean(value) FROM air GROUP BY time(10m) HAVING top(value, 1) > 1`, err: `top() in HAVING must also be selected by the query`},
+		{s: `SELECT max(value), host FROM air GROUP BY time(10m) HAVING min(value) > 1`, err: `mixing multiple selector functions with tags or fields is not supported`},
+		{s: `SELECT timeshift(mean(value)) FROM air GROUP BY time(1h)`, err: `invalid number of arguments for timeshift, expected 2, got 1`},
+		{s: `SELECT timeshift(mean(value), 'a') FROM air GROUP BY time(1h)`, err: `second argument to timeshift must be a duration, got *cnosql.StringLiteral`},
+		{s: `SELECT timeshift(mean(value), 0s) FROM air GROUP BY time(1h)`, err: `duration argument must be positive, got 0s`},
+		{s: `SELECT timeshift(value, 1d) FROM air`, err: `aggregate function required inside the call to timeshift`},
+		{s: `SELECT timeshift(mean(value), 1d) FROM air`, err: `timeshift aggregate requires a GROUP BY interval`},
+		{s: `SELECT timeshift(mean(value), 1d) FROM air GROUP BY time(1mo)`, err: `timeshift cannot be used with a calendar GROUP BY interval`},
+		{s: `SELECT histogram(value, 0, 10) FROM air`, err: `invalid number of arguments for histogram, expected 4, got 3`},
+		{s: `SELECT histogram(value, 0, 0, 5) FROM air`, err: `histogram() width must be greater than 0, got 0`},
+		{s: `SELECT histogram(value, 0, 10, 1.5) FROM air`, err: `expected integer argument for the count of histogram()`},
+		{s: `SELECT histogram_buckets(value, '1,x') FROM air`, err: `invalid bucket in histogram_buckets(): "x"`},
+		{s: `SELECT histogram_buckets(value, '2,1') FROM air`, err: `histogram_buckets() buckets must be in increasing order`},
+		{s: `SELECT histogram(*, 0, 10, 5) FROM air`, err: `unsupported expression with wildcard: histogram()`},
+		{s: `SELECT histogram(value, 0, 10, 5), max(value) FROM air`, err: `aggregate function histogram() cannot be combined with other functions or fields`},
+		{s: `SELECT histogram(value, 0, 10, 5) * 2 FROM air`, err: `histogram() cannot be used in an expression`},
 		{s: `SELECT nofunc(1.3) FROM air`, err: `undefined function nofunc()`},
+		{s: `SELECT geo_distance(lat, lon) FROM gps`, err: `invalid number of arguments for geo_distance, expected 4, got 2`},
+		{s: `SELECT lat FROM gps WHERE geo_distance(lat, lon, 0) < 10`, err: `invalid number of arguments for geo_distance, expected 4, got 3`},
+		{s: `SELECT lat FROM gps WHERE geo_bbox(0, 0, 1) AND time > now() - 1h`, err: `invalid number of arguments for geo_bbox, expected 4, got 3`},
+		{s: `SELECT geo_within_radius(0, 0, 10) FROM gps`, err: `undefined function geo_within_radius()`},
 	} {
 		t.Run(tt.s, func(t *testing.T) {
 			stmt, err := cnosql.ParseStatement(tt.s)
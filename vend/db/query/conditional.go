@@ -0,0 +1,116 @@
+package query
+
+import (
+	"fmt"
+
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+)
+
+func isConditionalFunction(call *cnosql.Call) bool {
+	return isConditionalFunctionName(call.Name)
+}
+
+func isConditionalFunctionName(name string) bool {
+	switch name {
+	case "coalesce", "nullif":
+		return true
+	}
+	return false
+}
+
+// isConditionalExpr returns true if the expression is a CASE expression or
+// a call to one of the conditional functions.
+func isConditionalExpr(expr cnosql.Expr) bool {
+	switch expr := expr.(type) {
+	case *cnosql.CaseExpr:
+		return true
+	case *cnosql.Call:
+		return isConditionalFunction(expr)
+	}
+	return false
+}
+
+// validateConditionalFunctionArgs verifies the number of arguments passed to
+// a conditional function.
+func validateConditionalFunctionArgs(expr *cnosql.Call) error {
+	switch got := len(expr.Args); expr.Name {
+	case "coalesce":
+		if got < 1 {
+			return fmt.Errorf("invalid number of arguments for %s, expected at least 1, got %d", expr.Name, got)
+		}
+	case "nullif":
+		if got != 2 {
+			return fmt.Errorf("invalid number of arguments for %s, expected 2, got %d", expr.Name, got)
+		}
+	}
+	return nil
+}
+
+// conditionalCallType verifies the argument types of a conditional function
+// and returns the type of its result.
+func conditionalCallType(name string, args []cnosql.DataType) (cnosql.DataType, error) {
+	// The result of nullif() is its first argument or null.
+	if name == "nullif" {
+		return args[0], nil
+	}
+
+	// The arguments of coalesce() must have a compatible type. Numeric
+	// arguments of different types are returned as floats.
+	var typ cnosql.DataType
+	for _, arg := range args {
+		switch {
+		case arg == cnosql.Unknown || arg == typ:
+		case typ == cnosql.Unknown:
+			typ = arg
+		case isNumericType(typ) && isNumericType(arg):
+			typ = cnosql.Float
+		case (typ == cnosql.String || typ == cnosql.Tag) && (arg == cnosql.String || arg == cnosql.Tag):
+			typ = cnosql.String
+		default:
+			return cnosql.Unknown, fmt.Errorf("incompatible argument types in %s(): %s and %s", name, typ, arg)
+		}
+	}
+	return typ, nil
+}
+
+func isNumericType(typ cnosql.DataType) bool {
+	return typ == cnosql.Float || typ == cnosql.Integer || typ == cnosql.Unsigned
+}
+
+// ConditionalValuer evaluates the conditional functions implemented by the
+// query engine.
+type ConditionalValuer struct{}
+
+var _ cnosql.CallValuer = ConditionalValuer{}
+
+func (ConditionalValuer) Value(key string) (interface{}, bool) {
+	return nil, false
+}
+
+func (v ConditionalValuer) Call(name string, args []interface{}) (interface{}, bool) {
+	switch name {
+	case "coalesce":
+		if len(args) == 0 {
+			return nil, false
+		}
+		for _, arg := range args {
+			if arg != nil {
+				return arg, true
+			}
+		}
+		return nil, true
+	case "nullif":
+		if len(args) != 2 {
+			return nil, false
+		}
+		if eq, ok := cnosql.Eval(&cnosql.BinaryExpr{
+			Op:  cnosql.EQ,
+			LHS: &cnosql.VarRef{Val: "lhs"},
+			RHS: &cnosql.VarRef{Val: "rhs"},
+		}, map[string]interface{}{"lhs": args[0], "rhs": args[1]}).(bool); ok && eq {
+			return nil, true
+		}
+		return args[0], true
+	}
+	return nil, false
+}
@@ -192,6 +192,8 @@ func (cur *scannerCursorBase) Scan(row *Row) bool {
 	valuer := cnosql.ValuerEval{
 		Valuer: cnosql.MultiValuer(
 			MathValuer{},
+			StringValuer{},
+			ConditionalValuer{},
 			cnosql.MapValuer(cur.m),
 		),
 		IntegerFloatDivision: true,
@@ -391,7 +393,12 @@ func (cur *filterCursor) Scan(row *Row) bool {
 		}
 
 		valuer := cnosql.ValuerEval{
-			Valuer: cnosql.MapValuer(cur.m),
+			Valuer: cnosql.MultiValuer(
+				MathValuer{},
+				StringValuer{},
+				ConditionalValuer{},
+				cnosql.MapValuer(cur.m),
+			),
 		}
 		if valuer.EvalBool(cur.filter) {
 			// Passes the filter! Return true. We no longer need to
@@ -402,6 +409,87 @@ func (cur *filterCursor) Scan(row *Row) bool {
 	return false
 }
 
+// havingCursor filters the rows of a cursor with the HAVING clause of a
+// statement. The result of the clause is read from the last column of the
+// underlying cursor, which is not returned. The limits and offsets of the
+// statement are applied to the rows that pass the filter.
+type havingCursor struct {
+	cur     Cursor
+	columns []cnosql.VarRef
+
+	limit, offset   int
+	slimit, soffset int
+
+	row    Row
+	series Series
+	// n is the number of rows of the current series that passed the filter
+	// and nseries is the number of series with at least one such row.
+	n, nseries int
+}
+
+func newHavingCursor(cur Cursor, limit, offset, slimit, soffset int) *havingCursor {
+	columns := cur.Columns()
+	return &havingCursor{
+		cur:     cur,
+		columns: columns[:len(columns)-1],
+		limit:   limit,
+		offset:  offset,
+		slimit:  slimit,
+		soffset: soffset,
+	}
+}
+
+func (cur *havingCursor) Scan(row *Row) bool {
+	for cur.cur.Scan(&cur.row) {
+		last := len(cur.row.Values) - 1
+		if ok, _ := cur.row.Values[last].(bool); !ok {
+			continue
+		}
+
+		if cur.nseries == 0 || !cur.row.Series.SameSeries(cur.series) {
+			cur.series = cur.row.Series
+			cur.nseries++
+			cur.n = 0
+		}
+		if cur.nseries <= cur.soffset {
+			continue
+		} else if cur.slimit > 0 && cur.nseries > cur.soffset+cur.slimit {
+			return false
+		}
+
+		cur.n++
+		if cur.n <= cur.offset || (cur.limit > 0 && cur.n > cur.offset+cur.limit) {
+			continue
+		}
+
+		row.Time = cur.row.Time
+		row.Series = cur.row.Series
+		if cap(row.Values) < last {
+			row.Values = make([]interface{}, last)
+		}
+		row.Values = row.Values[:last]
+		copy(row.Values, cur.row.Values)
+		return true
+	}
+	return false
+}
+
+func (cur *havingCursor) Stats() IteratorStats {
+	return cur.cur.Stats()
+}
+
+func (cur *havingCursor) Err() error {
+	return cur.cur.Err()
+}
+
+func (cur *havingCursor) Columns() []cnosql.VarRef {
+	return cur.columns
+}
+
+func (cur *havingCursor) Close() error {
+	return cur.cur.Close()
+}
+
 type nullCursor struct {
 	columns []cnosql.VarRef
 }
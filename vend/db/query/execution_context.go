@@ -3,6 +3,8 @@ package query
 import (
 	"context"
 	"sync"
+
+	"github.com/cnosdb/cnosdb/vend/cnosql"
 )
 
 // ExecutionContext contains state that the query is currently executing with.
@@ -15,6 +17,9 @@ type ExecutionContext struct {
 	// The query ID of the executing query.
 	QueryID uint64
 
+	// The executing query.
+	Query *cnosql.Query
+
 	// The query task information available to the StatementExecutor.
 	task *Task
 
@@ -24,6 +29,13 @@ type ExecutionContext struct {
 	// Options used to start this query.
 	ExecutionOptions
 
+	// The cache of compiled statements of the Executor.
+	planCache *PlanCache
+
+	// The memory budget of the iterators of the query. The memory of the
+	// query is unlimited if it is nil.
+	Memory *MemoryBudget
+
 	mu   sync.RWMutex
 	done chan struct{}
 	err  error
@@ -84,10 +96,23 @@ func (ctx *ExecutionContext) Value(key interface{}) interface{} {
 	switch key {
 	case monitorContextKey{}:
 		return ctx.task
+	case memoryBudgetContextKey{}:
+		return ctx.Memory
 	}
 	return ctx.Context.Value(key)
 }
 
+// StatementID returns the ID of the executing statement of the query.
+func (ctx *ExecutionContext) StatementID() int {
+	return ctx.statementID
+}
+
+// Compile compiles a SELECT statement or returns the plan of the statement
+// from the plan cache of the Executor.
+func (ctx *ExecutionContext) Compile(stmt *cnosql.SelectStatement, opt CompileOptions) (Statement, error) {
+	return ctx.planCache.Compile(stmt, opt)
+}
+
 // send sends a Result to the Results channel and will exit if the query has
 // been aborted.
 func (ctx *ExecutionContext) send(result *Result) error {
@@ -48,6 +48,8 @@ const (
 	statQueriesFinished        = "queriesFinished" // Number of queries that have finished.
 	statQueryExecutionDuration = "queryDurationNs" // Total (wall) time spent executing queries.
 	statRecoveredPanics        = "recoveredPanics" // Number of panics recovered by Query Executor.
+	statPlanCacheHits          = "planCacheHits"   // Number of statements whose compiled plan was cached.
+	statPlanCacheMisses        = "planCacheMisses" // Number of statements that were compiled.
 
 	// PanicCrashEnv is the environment variable that, when set, will prevent
 	// the handler from recovering any panics.
@@ -153,13 +155,18 @@ type ExecutionOptions struct {
 	// Quiet suppresses non-essential output from the query executor.
 	Quiet bool
 
+	// Params are the values of the bound parameters kept in the statements
+	// of a prepared query. They are bound when the statements are compiled.
+	Params map[string]interface{}
+
 	// AbortCh is a channel that signals when results are no longer desired by the caller.
 	AbortCh <-chan struct{}
 }
 
 type (
-	iteratorsContextKey struct{}
-	monitorContextKey   struct{}
+	iteratorsContextKey    struct{}
+	monitorContextKey      struct{}
+	memoryBudgetContextKey struct{}
 )
 
 // NewContextWithIterators returns a new context.Context with the *Iterators slice added.
@@ -168,6 +175,12 @@ func NewContextWithIterators(ctx context.Context, itr *Iterators) context.Contex
 	return context.WithValue(ctx, iteratorsContextKey{}, itr)
 }
 
+// NewContextWithMemoryBudget returns a new context.Context with the
+// *MemoryBudget charged by the iterators created with it.
+func NewContextWithMemoryBudget(ctx context.Context, b *MemoryBudget) context.Context {
+	return context.WithValue(ctx, memoryBudgetContextKey{}, b)
+}
+
 // StatementExecutor executes a statement within the Executor.
 type StatementExecutor interface {
 	// ExecuteStatement executes a statement. Results should be sent to the
@@ -190,6 +203,19 @@ type Executor struct {
 	// Used for tracking running queries.
 	TaskManager *TaskManager
 
+	// Used for reusing the compiled plans of statements. The statements
+	// are compiled every time they are executed if it is nil.
+	PlanCache *PlanCache
+
+	// The maximum number of bytes buffered by the iterators of a query.
+	// A value of zero makes the memory of queries unlimited.
+	MaxSelectMemory int64
+
+	// The directory where queries over their memory budget spill to
+	// temporary files. The default directory for temporary files is used
+	// if it is empty.
+	SpillDir string
+
 	// Logger to use for all logging.
 	// Defaults to discarding all log output.
 	Logger *zap.Logger
@@ -227,6 +253,8 @@ func (e *Executor) Statistics(tags map[string]string) []models.Statistic {
 			statQueriesFinished:        atomic.LoadInt64(&e.stats.FinishedQueries),
 			statQueryExecutionDuration: atomic.LoadInt64(&e.stats.QueryExecutionDuration),
 			statRecoveredPanics:        atomic.LoadInt64(&e.stats.RecoveredPanics),
+			statPlanCacheHits:          e.PlanCache.Hits(),
+			statPlanCacheMisses:        e.PlanCache.Misses(),
 		},
 	}}
 }
@@ -274,6 +302,11 @@ func (e *Executor) executeQuery(query *cnosql.Query, opt ExecutionOptions, closi
 
 	// Setup the execution context that will be used when executing statements.
 	ctx.Results = results
+	ctx.Query = query
+	ctx.planCache = e.PlanCache
+	if e.MaxSelectMemory > 0 {
+		ctx.Memory = NewMemoryBudget(e.MaxSelectMemory, e.SpillDir)
+	}
 
 	var i int
 LOOP:
@@ -380,7 +413,8 @@ LOOP:
 	}
 
 	// Send error results for any statements which were not executed.
-	for ; i < len(query.Statements)-1; i++ {
+	for i++; i < len(query.Statements); i++ {
+		ctx.statementID = i
 		if err := ctx.send(&Result{
 			StatementID: i,
 			Err:         ErrNotExecuted,
@@ -28,11 +28,15 @@ func (p *preparedStatement) Explain() (string, error) {
 			buf.WriteString("\n")
 		}
 
-		expr := "<nil>"
-		if node.Expr != nil {
-			expr = node.Expr.String()
+		if node.Join != nil {
+			fmt.Fprintf(&buf, "JOIN: %s\n", node.Join)
+		} else {
+			expr := "<nil>"
+			if node.Expr != nil {
+				expr = node.Expr.String()
+			}
+			fmt.Fprintf(&buf, "EXPRESSION: %s\n", expr)
 		}
-		fmt.Fprintf(&buf, "EXPRESSION: %s\n", expr)
 		if len(node.Aux) != 0 {
 			refs := make([]string, len(node.Aux))
 			for i, ref := range node.Aux {
@@ -46,6 +50,9 @@ func (p *preparedStatement) Explain() (string, error) {
 		fmt.Fprintf(&buf, "NUMBER OF FILES: %d\n", node.Cost.NumFiles)
 		fmt.Fprintf(&buf, "NUMBER OF BLOCKS: %d\n", node.Cost.BlocksRead)
 		fmt.Fprintf(&buf, "SIZE OF BLOCKS: %d\n", node.Cost.BlockSize)
+		if node.Join != nil {
+			fmt.Fprintf(&buf, "NUMBER OF JOINED SERIES: %d\n", node.Cost.JoinedSeries)
+		}
 	}
 	return buf.String(), nil
 }
@@ -54,6 +61,10 @@ type planNode struct {
 	Expr cnosql.Expr
 	Aux  []cnosql.VarRef
 	Cost IteratorCost
+
+	// Join is set for the nodes of joins, whose cost is the cost of the
+	// nodes of their sides.
+	Join *cnosql.Join
 }
 
 type explainIteratorCreator struct {
@@ -77,6 +88,22 @@ func (e *explainIteratorCreator) CreateIterator(ctx context.Context, m *cnosql.M
 	return &nilFloatIterator{}, nil
 }
 
+// explainJoin records the node of the join j of which the nodes of the left
+// side start at index start and the nodes of the right side at index mid.
+func (e *explainIteratorCreator) explainJoin(j *cnosql.Join, start, mid int) {
+	var left, right IteratorCost
+	for _, node := range e.nodes[start:mid] {
+		left = left.Combine(node.Cost)
+	}
+	for _, node := range e.nodes[mid:] {
+		right = right.Combine(node.Cost)
+	}
+	e.nodes = append(e.nodes, planNode{
+		Cost: left.Join(right),
+		Join: j,
+	})
+}
+
 func (e *explainIteratorCreator) IteratorCost(m *cnosql.Measurement, opt IteratorOptions) (IteratorCost, error) {
 	return e.ic.IteratorCost(m, opt)
 }
@@ -110,6 +110,11 @@ func (r *FloatSliceFuncReducer) Emit() []FloatPoint {
 	return r.fn(r.points)
 }
 
+// bufferedN returns the number of points in the internal slice.
+func (r *FloatSliceFuncReducer) bufferedN() int {
+	return len(r.points)
+}
+
 // FloatReduceIntegerFunc is the function called by a FloatPoint reducer.
 type FloatReduceIntegerFunc func(prev *IntegerPoint, curr *FloatPoint) (t int64, v int64, aux []interface{})
 
@@ -180,6 +185,11 @@ func (r *FloatSliceFuncIntegerReducer) Emit() []IntegerPoint {
 	return r.fn(r.points)
 }
 
+// bufferedN returns the number of points in the internal slice.
+func (r *FloatSliceFuncIntegerReducer) bufferedN() int {
+	return len(r.points)
+}
+
 // FloatReduceUnsignedFunc is the function called by a FloatPoint reducer.
 type FloatReduceUnsignedFunc func(prev *UnsignedPoint, curr *FloatPoint) (t int64, v uint64, aux []interface{})
 
@@ -250,6 +260,11 @@ func (r *FloatSliceFuncUnsignedReducer) Emit() []UnsignedPoint {
 	return r.fn(r.points)
 }
 
+// bufferedN returns the number of points in the internal slice.
+func (r *FloatSliceFuncUnsignedReducer) bufferedN() int {
+	return len(r.points)
+}
+
 // FloatReduceStringFunc is the function called by a FloatPoint reducer.
 type FloatReduceStringFunc func(prev *StringPoint, curr *FloatPoint) (t int64, v string, aux []interface{})
 
@@ -320,6 +335,11 @@ func (r *FloatSliceFuncStringReducer) Emit() []StringPoint {
 	return r.fn(r.points)
 }
 
+// bufferedN returns the number of points in the internal slice.
+func (r *FloatSliceFuncStringReducer) bufferedN() int {
+	return len(r.points)
+}
+
 // FloatReduceBooleanFunc is the function called by a FloatPoint reducer.
 type FloatReduceBooleanFunc func(prev *BooleanPoint, curr *FloatPoint) (t int64, v bool, aux []interface{})
 
@@ -390,6 +410,11 @@ func (r *FloatSliceFuncBooleanReducer) Emit() []BooleanPoint {
 	return r.fn(r.points)
 }
 
+// bufferedN returns the number of points in the internal slice.
+func (r *FloatSliceFuncBooleanReducer) bufferedN() int {
+	return len(r.points)
+}
+
 // FloatDistinctReducer returns the distinct points in a series.
 type FloatDistinctReducer struct {
 	m map[float64]FloatPoint
@@ -417,6 +442,11 @@ func (r *FloatDistinctReducer) Emit() []FloatPoint {
 	return points
 }
 
+// bufferedN returns the number of distinct points in the reducer.
+func (r *FloatDistinctReducer) bufferedN() int {
+	return len(r.m)
+}
+
 // FloatElapsedReducer calculates the elapsed of the aggregated points.
 type FloatElapsedReducer struct {
 	unitConversion int64
@@ -594,6 +624,11 @@ func (r *IntegerSliceFuncFloatReducer) Emit() []FloatPoint {
 	return r.fn(r.points)
 }
 
+// bufferedN returns the number of points in the internal slice.
+func (r *IntegerSliceFuncFloatReducer) bufferedN() int {
+	return len(r.points)
+}
+
 // IntegerReduceFunc is the function called by a IntegerPoint reducer.
 type IntegerReduceFunc func(prev *IntegerPoint, curr *IntegerPoint) (t int64, v int64, aux []interface{})
 
@@ -664,6 +699,11 @@ func (r *IntegerSliceFuncReducer) Emit() []IntegerPoint {
 	return r.fn(r.points)
 }
 
+// bufferedN returns the number of points in the internal slice.
+func (r *IntegerSliceFuncReducer) bufferedN() int {
+	return len(r.points)
+}
+
 // IntegerReduceUnsignedFunc is the function called by a IntegerPoint reducer.
 type IntegerReduceUnsignedFunc func(prev *UnsignedPoint, curr *IntegerPoint) (t int64, v uint64, aux []interface{})
 
@@ -734,6 +774,11 @@ func (r *IntegerSliceFuncUnsignedReducer) Emit() []UnsignedPoint {
 	return r.fn(r.points)
 }
 
+// bufferedN returns the number of points in the internal slice.
+func (r *IntegerSliceFuncUnsignedReducer) bufferedN() int {
+	return len(r.points)
+}
+
 // IntegerReduceStringFunc is the function called by a IntegerPoint reducer.
 type IntegerReduceStringFunc func(prev *StringPoint, curr *IntegerPoint) (t int64, v string, aux []interface{})
 
@@ -804,6 +849,11 @@ func (r *IntegerSliceFuncStringReducer) Emit() []StringPoint {
 	return r.fn(r.points)
 }
 
+// bufferedN returns the number of points in the internal slice.
+func (r *IntegerSliceFuncStringReducer) bufferedN() int {
+	return len(r.points)
+}
+
 // IntegerReduceBooleanFunc is the function called by a IntegerPoint reducer.
 type IntegerReduceBooleanFunc func(prev *BooleanPoint, curr *IntegerPoint) (t int64, v bool, aux []interface{})
 
@@ -874,6 +924,11 @@ func (r *IntegerSliceFuncBooleanReducer) Emit() []BooleanPoint {
 	return r.fn(r.points)
 }
 
+// bufferedN returns the number of points in the internal slice.
+func (r *IntegerSliceFuncBooleanReducer) bufferedN() int {
+	return len(r.points)
+}
+
 // IntegerDistinctReducer returns the distinct points in a series.
 type IntegerDistinctReducer struct {
 	m map[int64]IntegerPoint
@@ -901,6 +956,11 @@ func (r *IntegerDistinctReducer) Emit() []IntegerPoint {
 	return points
 }
 
+// bufferedN returns the number of distinct points in the reducer.
+func (r *IntegerDistinctReducer) bufferedN() int {
+	return len(r.m)
+}
+
 // IntegerElapsedReducer calculates the elapsed of the aggregated points.
 type IntegerElapsedReducer struct {
 	unitConversion int64
@@ -1078,6 +1138,11 @@ func (r *UnsignedSliceFuncFloatReducer) Emit() []FloatPoint {
 	return r.fn(r.points)
 }
 
+// bufferedN returns the number of points in the internal slice.
+func (r *UnsignedSliceFuncFloatReducer) bufferedN() int {
+	return len(r.points)
+}
+
 // UnsignedReduceIntegerFunc is the function called by a UnsignedPoint reducer.
 type UnsignedReduceIntegerFunc func(prev *IntegerPoint, curr *UnsignedPoint) (t int64, v int64, aux []interface{})
 
@@ -1148,6 +1213,11 @@ func (r *UnsignedSliceFuncIntegerReducer) Emit() []IntegerPoint {
 	return r.fn(r.points)
 }
 
+// bufferedN returns the number of points in the internal slice.
+func (r *UnsignedSliceFuncIntegerReducer) bufferedN() int {
+	return len(r.points)
+}
+
 // UnsignedReduceFunc is the function called by a UnsignedPoint reducer.
 type UnsignedReduceFunc func(prev *UnsignedPoint, curr *UnsignedPoint) (t int64, v uint64, aux []interface{})
 
@@ -1218,6 +1288,11 @@ func (r *UnsignedSliceFuncReducer) Emit() []UnsignedPoint {
 	return r.fn(r.points)
 }
 
+// bufferedN returns the number of points in the internal slice.
+func (r *UnsignedSliceFuncReducer) bufferedN() int {
+	return len(r.points)
+}
+
 // UnsignedReduceStringFunc is the function called by a UnsignedPoint reducer.
 type UnsignedReduceStringFunc func(prev *StringPoint, curr *UnsignedPoint) (t int64, v string, aux []interface{})
 
@@ -1288,6 +1363,11 @@ func (r *UnsignedSliceFuncStringReducer) Emit() []StringPoint {
 	return r.fn(r.points)
 }
 
+// bufferedN returns the number of points in the internal slice.
+func (r *UnsignedSliceFuncStringReducer) bufferedN() int {
+	return len(r.points)
+}
+
 // UnsignedReduceBooleanFunc is the function called by a UnsignedPoint reducer.
 type UnsignedReduceBooleanFunc func(prev *BooleanPoint, curr *UnsignedPoint) (t int64, v bool, aux []interface{})
 
@@ -1358,6 +1438,11 @@ func (r *UnsignedSliceFuncBooleanReducer) Emit() []BooleanPoint {
 	return r.fn(r.points)
 }
 
+// bufferedN returns the number of points in the internal slice.
+func (r *UnsignedSliceFuncBooleanReducer) bufferedN() int {
+	return len(r.points)
+}
+
 // UnsignedDistinctReducer returns the distinct points in a series.
 type UnsignedDistinctReducer struct {
 	m map[uint64]UnsignedPoint
@@ -1385,6 +1470,11 @@ func (r *UnsignedDistinctReducer) Emit() []UnsignedPoint {
 	return points
 }
 
+// bufferedN returns the number of distinct points in the reducer.
+func (r *UnsignedDistinctReducer) bufferedN() int {
+	return len(r.m)
+}
+
 // UnsignedElapsedReducer calculates the elapsed of the aggregated points.
 type UnsignedElapsedReducer struct {
 	unitConversion int64
@@ -1562,6 +1652,11 @@ func (r *StringSliceFuncFloatReducer) Emit() []FloatPoint {
 	return r.fn(r.points)
 }
 
+// bufferedN returns the number of points in the internal slice.
+func (r *StringSliceFuncFloatReducer) bufferedN() int {
+	return len(r.points)
+}
+
 // StringReduceIntegerFunc is the function called by a StringPoint reducer.
 type StringReduceIntegerFunc func(prev *IntegerPoint, curr *StringPoint) (t int64, v int64, aux []interface{})
 
@@ -1632,6 +1727,11 @@ func (r *StringSliceFuncIntegerReducer) Emit() []IntegerPoint {
 	return r.fn(r.points)
 }
 
+// bufferedN returns the number of points in the internal slice.
+func (r *StringSliceFuncIntegerReducer) bufferedN() int {
+	return len(r.points)
+}
+
 // StringReduceUnsignedFunc is the function called by a StringPoint reducer.
 type StringReduceUnsignedFunc func(prev *UnsignedPoint, curr *StringPoint) (t int64, v uint64, aux []interface{})
 
@@ -1702,6 +1802,11 @@ func (r *StringSliceFuncUnsignedReducer) Emit() []UnsignedPoint {
 	return r.fn(r.points)
 }
 
+// bufferedN returns the number of points in the internal slice.
+func (r *StringSliceFuncUnsignedReducer) bufferedN() int {
+	return len(r.points)
+}
+
 // StringReduceFunc is the function called by a StringPoint reducer.
 type StringReduceFunc func(prev *StringPoint, curr *StringPoint) (t int64, v string, aux []interface{})
 
@@ -1772,6 +1877,11 @@ func (r *StringSliceFuncReducer) Emit() []StringPoint {
 	return r.fn(r.points)
 }
 
+// bufferedN returns the number of points in the internal slice.
+func (r *StringSliceFuncReducer) bufferedN() int {
+	return len(r.points)
+}
+
 // StringReduceBooleanFunc is the function called by a StringPoint reducer.
 type StringReduceBooleanFunc func(prev *BooleanPoint, curr *StringPoint) (t int64, v bool, aux []interface{})
 
@@ -1842,6 +1952,11 @@ func (r *StringSliceFuncBooleanReducer) Emit() []BooleanPoint {
 	return r.fn(r.points)
 }
 
+// bufferedN returns the number of points in the internal slice.
+func (r *StringSliceFuncBooleanReducer) bufferedN() int {
+	return len(r.points)
+}
+
 // StringDistinctReducer returns the distinct points in a series.
 type StringDistinctReducer struct {
 	m map[string]StringPoint
@@ -1869,6 +1984,11 @@ func (r *StringDistinctReducer) Emit() []StringPoint {
 	return points
 }
 
+// bufferedN returns the number of distinct points in the reducer.
+func (r *StringDistinctReducer) bufferedN() int {
+	return len(r.m)
+}
+
 // StringElapsedReducer calculates the elapsed of the aggregated points.
 type StringElapsedReducer struct {
 	unitConversion int64
@@ -2046,6 +2166,11 @@ func (r *BooleanSliceFuncFloatReducer) Emit() []FloatPoint {
 	return r.fn(r.points)
 }
 
+// bufferedN returns the number of points in the internal slice.
+func (r *BooleanSliceFuncFloatReducer) bufferedN() int {
+	return len(r.points)
+}
+
 // BooleanReduceIntegerFunc is the function called by a BooleanPoint reducer.
 type BooleanReduceIntegerFunc func(prev *IntegerPoint, curr *BooleanPoint) (t int64, v int64, aux []interface{})
 
@@ -2116,6 +2241,11 @@ func (r *BooleanSliceFuncIntegerReducer) Emit() []IntegerPoint {
 	return r.fn(r.points)
 }
 
+// bufferedN returns the number of points in the internal slice.
+func (r *BooleanSliceFuncIntegerReducer) bufferedN() int {
+	return len(r.points)
+}
+
 // BooleanReduceUnsignedFunc is the function called by a BooleanPoint reducer.
 type BooleanReduceUnsignedFunc func(prev *UnsignedPoint, curr *BooleanPoint) (t int64, v uint64, aux []interface{})
 
@@ -2186,6 +2316,11 @@ func (r *BooleanSliceFuncUnsignedReducer) Emit() []UnsignedPoint {
 	return r.fn(r.points)
 }
 
+// bufferedN returns the number of points in the internal slice.
+func (r *BooleanSliceFuncUnsignedReducer) bufferedN() int {
+	return len(r.points)
+}
+
 // BooleanReduceStringFunc is the function called by a BooleanPoint reducer.
 type BooleanReduceStringFunc func(prev *StringPoint, curr *BooleanPoint) (t int64, v string, aux []interface{})
 
@@ -2256,6 +2391,11 @@ func (r *BooleanSliceFuncStringReducer) Emit() []StringPoint {
 	return r.fn(r.points)
 }
 
+// bufferedN returns the number of points in the internal slice.
+func (r *BooleanSliceFuncStringReducer) bufferedN() int {
+	return len(r.points)
+}
+
 // BooleanReduceFunc is the function called by a BooleanPoint reducer.
 type BooleanReduceFunc func(prev *BooleanPoint, curr *BooleanPoint) (t int64, v bool, aux []interface{})
 
@@ -2326,6 +2466,11 @@ func (r *BooleanSliceFuncReducer) Emit() []BooleanPoint {
 	return r.fn(r.points)
 }
 
+// bufferedN returns the number of points in the internal slice.
+func (r *BooleanSliceFuncReducer) bufferedN() int {
+	return len(r.points)
+}
+
 // BooleanDistinctReducer returns the distinct points in a series.
 type BooleanDistinctReducer struct {
 	m map[bool]BooleanPoint
@@ -2353,6 +2498,11 @@ func (r *BooleanDistinctReducer) Emit() []BooleanPoint {
 	return points
 }
 
+// bufferedN returns the number of distinct points in the reducer.
+func (r *BooleanDistinctReducer) bufferedN() int {
+	return len(r.m)
+}
+
 // BooleanElapsedReducer calculates the elapsed of the aggregated points.
 type BooleanElapsedReducer struct {
 	unitConversion int64
@@ -2,6 +2,7 @@ package query
 
 import (
 	"container/heap"
+	"fmt"
 	"math"
 	"sort"
 	"time"
@@ -86,14 +87,93 @@ func (m FunctionTypeMapper) CallType(name string, args []cnosql.DataType) (cnosq
 		"chande_momentum_oscillator",
 		"holt_winters", "holt_winters_with_fit":
 		return cnosql.Float, nil
-	case "elapsed":
+	case "elapsed", "state_duration", "state_count":
 		return cnosql.Integer, nil
+	case "count_distinct_approx":
+		return cnosql.Integer, nil
+	case "histogram", "histogram_buckets":
+		var arg0 cnosql.DataType
+		if len(args) > 0 {
+			arg0 = args[0]
+		}
+		switch arg0 {
+		case cnosql.Float, cnosql.Integer, cnosql.Unsigned, cnosql.Unknown:
+			return cnosql.Integer, nil
+		default:
+			return cnosql.Unknown, fmt.Errorf("invalid argument type for the first argument in %s(): %s", name, arg0)
+		}
+	case "quantile_approx":
+		var arg0 cnosql.DataType
+		if len(args) > 0 {
+			arg0 = args[0]
+		}
+		switch arg0 {
+		case cnosql.Float, cnosql.Integer, cnosql.Unsigned, cnosql.Unknown:
+			return cnosql.Float, nil
+		default:
+			return cnosql.Unknown, fmt.Errorf("invalid argument type for the first argument in %s(): %s", name, arg0)
+		}
+	case "lower", "upper", "trim", "strlen", "substr", "concat", "replace",
+		"regexp_extract", "regexp_match", "split_part", "str_to_float", "str_to_int":
+		return stringCallType(name, args)
+	case "coalesce", "nullif":
+		return conditionalCallType(name, args)
 	default:
 		// TODO: Do not use default for this.
 		return args[0], nil
 	}
 }
 
+// stringCallType verifies the argument types of a string function and returns
+// the type of its result.
+func stringCallType(name string, args []cnosql.DataType) (cnosql.DataType, error) {
+	// The concat() function formats each of its arguments so it accepts
+	// any type of value.
+	if name == "concat" {
+		return cnosql.String, nil
+	}
+
+	// Determine which arguments must be integers. All other arguments
+	// must be strings or tags.
+	var integers []bool
+	switch name {
+	case "substr":
+		integers = []bool{false, true, true}
+	case "regexp_extract", "split_part":
+		integers = []bool{false, false, true}
+	}
+
+	ordinals := [...]string{"first", "second", "third"}
+	for i, arg := range args {
+		if i >= len(ordinals) {
+			break
+		}
+		if i < len(integers) && integers[i] {
+			switch arg {
+			case cnosql.Integer, cnosql.Unsigned, cnosql.Unknown:
+				continue
+			}
+		} else {
+			switch arg {
+			case cnosql.String, cnosql.Tag, cnosql.Unknown:
+				continue
+			}
+		}
+		return cnosql.Unknown, fmt.Errorf("invalid argument type for the %s argument in %s(): %s", ordinals[i], name, arg)
+	}
+
+	switch name {
+	case "strlen", "str_to_int":
+		return cnosql.Integer, nil
+	case "str_to_float":
+		return cnosql.Float, nil
+	case "regexp_match":
+		return cnosql.Boolean, nil
+	default:
+		return cnosql.String, nil
+	}
+}
+
 // FloatMeanReducer calculates the mean of the aggregated points.
 type FloatMeanReducer struct {
 	sum   float64
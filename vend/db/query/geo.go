@@ -0,0 +1,210 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+	"github.com/cnosdb/cnosdb/vend/db/pkg/geo"
+)
+
+// GeoCellTag is the tag holding the token of the S2 cell that contains the
+// location of a point.
+const GeoCellTag = "s2_cell"
+
+// maxGeoCoveringCells is the maximum number of cells a condition is
+// rewritten into. Larger areas are filtered by the location fields alone.
+const maxGeoCoveringCells = 256
+
+// GeoIndex describes where the location of points is stored.
+type GeoIndex struct {
+	// LatField and LonField are the fields holding the latitude and the
+	// longitude of a point in degrees.
+	LatField string
+	LonField string
+
+	// CellLevel is the level of the S2 cells written into the s2_cell tag
+	// of points. It is zero when points are not tagged.
+	CellLevel int
+}
+
+// CellToken returns the value of the s2_cell tag of a point at the
+// location, or false if the location is not valid.
+func (g GeoIndex) CellToken(lat, lon float64) (string, bool) {
+	if !geo.ValidLatLon(lat, lon) {
+		return "", false
+	}
+	return geo.CellIDFromLatLon(lat, lon, g.CellLevel).Token(), true
+}
+
+// RewriteCondition replaces the calls to geo_within_radius() and geo_bbox()
+// in a condition with comparisons of the location fields. When points are
+// tagged with S2 cells, the comparisons are combined with the cells that
+// cover the area so the index can skip the series outside of the area.
+func (g GeoIndex) RewriteCondition(cond cnosql.Expr) (cnosql.Expr, error) {
+	if cond == nil {
+		return nil, nil
+	}
+
+	// The location is read from the lat and lon fields by default.
+	if g.LatField == "" {
+		g.LatField = "lat"
+	}
+	if g.LonField == "" {
+		g.LonField = "lon"
+	}
+
+	var err error
+	cond = cnosql.RewriteExpr(cond, func(expr cnosql.Expr) cnosql.Expr {
+		call, ok := expr.(*cnosql.Call)
+		if !ok || err != nil {
+			return expr
+		}
+
+		var area geo.Rect
+		switch call.Name {
+		case "geo_within_radius":
+			var args []float64
+			if args, err = geoArgs(call, 3); err != nil {
+				return expr
+			}
+			lat, lon, radius := args[0], args[1], args[2]
+			if !geo.ValidLatLon(lat, lon) {
+				err = fmt.Errorf("invalid location in %s(): %v, %v", call.Name, lat, lon)
+				return expr
+			} else if radius <= 0 {
+				err = fmt.Errorf("radius must be greater than 0 in %s(), got %v", call.Name, radius)
+				return expr
+			}
+			area = geo.CapBound(lat, lon, radius)
+			expr = &cnosql.BinaryExpr{
+				Op: cnosql.LTE,
+				LHS: &cnosql.Call{Name: "geo_distance", Args: []cnosql.Expr{
+					&cnosql.VarRef{Val: g.LatField},
+					&cnosql.VarRef{Val: g.LonField},
+					&cnosql.NumberLiteral{Val: lat},
+					&cnosql.NumberLiteral{Val: lon},
+				}},
+				RHS: &cnosql.NumberLiteral{Val: radius},
+			}
+		case "geo_bbox":
+			var args []float64
+			if args, err = geoArgs(call, 4); err != nil {
+				return expr
+			}
+			area = geo.Rect{MinLat: args[0], MinLon: args[1], MaxLat: args[2], MaxLon: args[3]}
+			if err = area.Validate(); err != nil {
+				err = fmt.Errorf("invalid bounding box in %s(): %s", call.Name, err)
+				return expr
+			}
+			expr = g.bboxExpr(area)
+		default:
+			return expr
+		}
+
+		if g.CellLevel > 0 {
+			if cells := geo.Covering(area, g.CellLevel, maxGeoCoveringCells); len(cells) > 0 {
+				expr = &cnosql.BinaryExpr{
+					Op:  cnosql.AND,
+					LHS: &cnosql.ParenExpr{Expr: expr},
+					RHS: &cnosql.ParenExpr{Expr: geoCellsExpr(cells)},
+				}
+			}
+		}
+		return &cnosql.ParenExpr{Expr: expr}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cond, nil
+}
+
+// bboxExpr returns the comparisons of the location fields with a rectangle.
+func (g GeoIndex) bboxExpr(r geo.Rect) cnosql.Expr {
+	compare := func(field string, op cnosql.Token, val float64) cnosql.Expr {
+		return &cnosql.BinaryExpr{Op: op, LHS: &cnosql.VarRef{Val: field}, RHS: &cnosql.NumberLiteral{Val: val}}
+	}
+	and := func(lhs, rhs cnosql.Expr) cnosql.Expr {
+		return &cnosql.BinaryExpr{Op: cnosql.AND, LHS: lhs, RHS: rhs}
+	}
+
+	lon := and(compare(g.LonField, cnosql.GTE, r.MinLon), compare(g.LonField, cnosql.LTE, r.MaxLon))
+	if r.MinLon > r.MaxLon {
+		// The rectangle crosses the antimeridian.
+		lon = &cnosql.ParenExpr{Expr: &cnosql.BinaryExpr{
+			Op:  cnosql.OR,
+			LHS: compare(g.LonField, cnosql.GTE, r.MinLon),
+			RHS: compare(g.LonField, cnosql.LTE, r.MaxLon),
+		}}
+	}
+	return and(and(compare(g.LatField, cnosql.GTE, r.MinLat), compare(g.LatField, cnosql.LTE, r.MaxLat)), lon)
+}
+
+// geoCellsExpr returns a condition matching the points tagged with one of
+// the cells. Points tagged while the cells were of another level are matched
+// by the cells containing the cells or contained by them, and points without
+// the tag, written before points were tagged, are matched as well.
+func geoCellsExpr(cells []geo.CellID) cnosql.Expr {
+	patterns := make(map[string]struct{})
+	for _, id := range cells {
+		patterns[geoCellPattern(id)] = struct{}{}
+		for level := 0; level < id.Level(); level++ {
+			patterns[regexp.QuoteMeta(id.Parent(level).Token())+"$"] = struct{}{}
+		}
+	}
+	alternatives := make([]string, 0, len(patterns))
+	for p := range patterns {
+		alternatives = append(alternatives, p)
+	}
+	sort.Strings(alternatives)
+
+	return &cnosql.BinaryExpr{
+		Op: cnosql.OR,
+		LHS: &cnosql.BinaryExpr{
+			Op:  cnosql.EQ,
+			LHS: &cnosql.VarRef{Val: GeoCellTag},
+			RHS: &cnosql.StringLiteral{},
+		},
+		RHS: &cnosql.BinaryExpr{
+			Op:  cnosql.EQREGEX,
+			LHS: &cnosql.VarRef{Val: GeoCellTag},
+			RHS: &cnosql.RegexLiteral{Val: regexp.MustCompile("^(?:" + strings.Join(alternatives, "|") + ")")},
+		},
+	}
+}
+
+// geoCellPattern returns a regular expression matching the tokens of a cell
+// and of the cells it contains, which share the bits of its face and of its
+// position.
+func geoCellPattern(id geo.CellID) string {
+	bits := 3 + 2*id.Level()
+	hex := fmt.Sprintf("%016x", uint64(id))
+	n, r := bits/4, bits%4
+	if r == 0 {
+		return hex[:n]
+	}
+
+	// The hexadecimal digit holding the last bits of the position.
+	mask := uint64(1)<<uint(4-r) - 1
+	v := uint64(id) >> uint(60-4*n) & 0xf
+	return fmt.Sprintf("%s[%x-%x]", hex[:n], v&^mask, v|mask)
+}
+
+// geoArgs returns the arguments of a call to a geo function. The arguments
+// must all be numbers.
+func geoArgs(call *cnosql.Call, n int) ([]float64, error) {
+	if got := len(call.Args); got != n {
+		return nil, fmt.Errorf("invalid number of arguments for %s, expected %d, got %d", call.Name, n, got)
+	}
+	args := make([]float64, n)
+	for i, arg := range call.Args {
+		v, ok := numberLiteral(arg)
+		if !ok {
+			return nil, fmt.Errorf("expected number argument in %s(), got %s", call.Name, arg)
+		}
+		args[i] = v
+	}
+	return args, nil
+}
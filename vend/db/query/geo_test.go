@@ -0,0 +1,110 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+	"github.com/cnosdb/cnosdb/vend/db/pkg/geo"
+	"github.com/cnosdb/cnosdb/vend/db/query"
+)
+
+func TestGeoIndex_RewriteCondition(t *testing.T) {
+	for _, tt := range []struct {
+		cond  string
+		level int
+		exp   string
+		cells []string
+		other []string
+		err   string
+	}{
+		{
+			cond: `geo_within_radius(52.52, 13.405, 1000) AND host = 'a'`,
+			exp:  `(geo_distance(lat, lon, 52.520, 13.405) <= 1000.000) AND host = 'a'`,
+		},
+		{
+			cond: `geo_bbox(-10, 170, 10, -170)`,
+			exp:  `(lat >= -10.000 AND lat <= 10.000 AND (lon >= 170.000 OR lon <= -170.000))`,
+		},
+		{
+			cond:  `geo_within_radius(52.52, 13.405, 1000)`,
+			level: 12,
+			cells: []string{
+				"",
+				geo.CellIDFromLatLon(52.52, 13.405, 12).Token(),
+				geo.CellIDFromLatLon(52.525, 13.41, 12).Token(),
+				// Cells of points tagged at other levels.
+				geo.CellIDFromLatLon(52.52, 13.405, 4).Token(),
+				geo.CellIDFromLatLon(52.52, 13.405, 11).Token(),
+				geo.CellIDFromLatLon(52.52, 13.405, 13).Token(),
+				geo.CellIDFromLatLon(52.52, 13.405, 30).Token(),
+			},
+			other: []string{
+				geo.CellIDFromLatLon(48.8566, 2.3522, 12).Token(),
+				geo.CellIDFromLatLon(48.8566, 2.3522, 11).Token(),
+				geo.CellIDFromLatLon(52.62, 13.405, 13).Token(),
+				geo.CellIDFromLatLon(52.62, 13.405, 30).Token(),
+			},
+		},
+		{
+			// Too many cells are needed to cover the area.
+			cond:  `geo_bbox(40, -10, 60, 30)`,
+			level: 12,
+			exp:   `(lat >= 40.000 AND lat <= 60.000 AND lon >= -10.000 AND lon <= 30.000)`,
+		},
+		{cond: `geo_within_radius(52.52, 13.405)`, err: `invalid number of arguments for geo_within_radius, expected 3, got 2`},
+		{cond: `geo_within_radius(lat, 13.405, 10)`, err: `expected number argument in geo_within_radius(), got lat`},
+		{cond: `geo_within_radius(91, 13.405, 10)`, err: `invalid location in geo_within_radius(): 91, 13.405`},
+		{cond: `geo_within_radius(52.52, 13.405, 0)`, err: `radius must be greater than 0 in geo_within_radius(), got 0`},
+		{cond: `geo_bbox(10, 0, -10, 5)`, err: `invalid bounding box in geo_bbox(): minimum latitude 10 is greater than the maximum latitude -10`},
+	} {
+		t.Run(tt.cond, func(t *testing.T) {
+			g := query.GeoIndex{LatField: "lat", LonField: "lon", CellLevel: tt.level}
+			cond, err := g.RewriteCondition(cnosql.MustParseExpr(tt.cond))
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("unexpected error: got=%v exp=%s", err, tt.err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if tt.exp != "" {
+				if got := cond.String(); got != tt.exp {
+					t.Fatalf("unexpected condition:\ngot=%s\nexp=%s", got, tt.exp)
+				}
+			}
+			for _, token := range tt.cells {
+				if !matchGeoCell(cond, token) {
+					t.Errorf("cell %q is not matched by %s", token, cond)
+				}
+			}
+			for _, token := range tt.other {
+				if matchGeoCell(cond, token) {
+					t.Errorf("cell %q is matched by %s", token, cond)
+				}
+			}
+		})
+	}
+}
+
+// matchGeoCell returns true if the comparisons of the s2_cell tag of a
+// condition match a point tagged with token.
+func matchGeoCell(cond cnosql.Expr, token string) bool {
+	var match bool
+	cnosql.WalkFunc(cond, func(n cnosql.Node) {
+		expr, ok := n.(*cnosql.BinaryExpr)
+		if !ok {
+			return
+		} else if ref, ok := expr.LHS.(*cnosql.VarRef); !ok || ref.Val != query.GeoCellTag {
+			return
+		}
+		switch rhs := expr.RHS.(type) {
+		case *cnosql.StringLiteral:
+			match = match || expr.Op == cnosql.EQ && rhs.Val == token
+		case *cnosql.RegexLiteral:
+			match = match || expr.Op == cnosql.EQREGEX && rhs.Val.MatchString(token)
+		}
+	})
+	return match
+}
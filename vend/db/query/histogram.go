@@ -0,0 +1,388 @@
+package query
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+)
+
+// The histogram aggregates are computed in the same phases as the
+// approximate aggregates in sketch.go. The call iterator for histogram() or
+// histogram_buckets() counts the raw points of each window into a Histogram
+// that is emitted as a string point. Histograms from different series,
+// shards and nodes are merged by merge_histogram(), and the buckets are only
+// emitted as rows once all of the histograms have been merged.
+const mergeHistogramFunction = "merge_histogram"
+
+// histogramBucketColumn is the name of the column, or the tag when the
+// results are written into a measurement, holding the upper bound of the
+// bucket counted by each row.
+const histogramBucketColumn = "le"
+
+// maxHistogramBuckets is the maximum number of buckets of a histogram.
+const maxHistogramBuckets = 1000
+
+func isHistogramFunction(name string) bool {
+	return name == "histogram" || name == "histogram_buckets"
+}
+
+// Histogram counts values into buckets. Each bucket counts the values that
+// are less than or equal to its upper bound and greater than the bound of
+// the previous bucket. The last count is for the values greater than all of
+// the bounds.
+type Histogram struct {
+	Bounds []float64
+	Counts []uint64
+}
+
+// NewHistogram returns an empty histogram with the upper bounds of buckets.
+func NewHistogram(bounds []float64) *Histogram {
+	return &Histogram{
+		Bounds: bounds,
+		Counts: make([]uint64, len(bounds)+1),
+	}
+}
+
+// Add counts v in its bucket. NaN values are not counted.
+func (h *Histogram) Add(v float64) {
+	if math.IsNaN(v) {
+		return
+	}
+	h.Counts[sort.SearchFloat64s(h.Bounds, v)]++
+}
+
+// Merge adds the counts of other to h. It returns an error if the bounds of
+// the histograms differ.
+func (h *Histogram) Merge(other *Histogram) error {
+	if len(other.Bounds) != len(h.Bounds) || len(other.Counts) != len(h.Counts) {
+		return errors.New("histogram bounds mismatch")
+	}
+	for i, b := range other.Bounds {
+		if b != h.Bounds[i] {
+			return errors.New("histogram bounds mismatch")
+		}
+	}
+	for i, n := range other.Counts {
+		h.Counts[i] += n
+	}
+	return nil
+}
+
+// MarshalBinary encodes the bounds and the counts of the histogram.
+func (h *Histogram) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, binary.MaxVarintLen64+8*len(h.Bounds)+binary.MaxVarintLen64*len(h.Counts))
+	i := binary.PutUvarint(buf, uint64(len(h.Bounds)))
+	for _, b := range h.Bounds {
+		binary.BigEndian.PutUint64(buf[i:], math.Float64bits(b))
+		i += 8
+	}
+	for _, n := range h.Counts {
+		i += binary.PutUvarint(buf[i:], n)
+	}
+	return buf[:i], nil
+}
+
+// UnmarshalBinary decodes a histogram encoded by MarshalBinary.
+func (h *Histogram) UnmarshalBinary(data []byte) error {
+	n, sz := binary.Uvarint(data)
+	if sz <= 0 || n > maxHistogramBuckets || uint64(len(data)-sz) < 8*n {
+		return errors.New("invalid histogram encoding")
+	}
+	data = data[sz:]
+
+	h.Bounds = make([]float64, n)
+	for i := range h.Bounds {
+		h.Bounds[i] = math.Float64frombits(binary.BigEndian.Uint64(data))
+		data = data[8:]
+	}
+	h.Counts = make([]uint64, n+1)
+	for i := range h.Counts {
+		if h.Counts[i], sz = binary.Uvarint(data); sz <= 0 {
+			return errors.New("invalid histogram encoding")
+		}
+		data = data[sz:]
+	}
+	return nil
+}
+
+// histogramBounds returns the upper bounds of the buckets of a call to
+// histogram() or histogram_buckets().
+func histogramBounds(call *cnosql.Call) ([]float64, error) {
+	switch call.Name {
+	case "histogram":
+		if exp, got := 4, len(call.Args); got != exp {
+			return nil, fmt.Errorf("invalid number of arguments for histogram, expected %d, got %d", exp, got)
+		}
+		start, ok := numberLiteral(call.Args[1])
+		if !ok {
+			return nil, fmt.Errorf("expected number argument for the start of histogram()")
+		}
+		width, ok := numberLiteral(call.Args[2])
+		if !ok {
+			return nil, fmt.Errorf("expected number argument for the width of histogram()")
+		} else if width <= 0 {
+			return nil, fmt.Errorf("histogram() width must be greater than 0, got %v", width)
+		}
+		count, ok := call.Args[3].(*cnosql.IntegerLiteral)
+		if !ok {
+			return nil, fmt.Errorf("expected integer argument for the count of histogram()")
+		} else if count.Val <= 0 || count.Val > maxHistogramBuckets {
+			return nil, fmt.Errorf("histogram() count must be between 1 and %d, got %d", maxHistogramBuckets, count.Val)
+		}
+
+		bounds := make([]float64, count.Val)
+		for i := range bounds {
+			bounds[i] = start + float64(i)*width
+		}
+		return bounds, nil
+	case "histogram_buckets":
+		if exp, got := 2, len(call.Args); got != exp {
+			return nil, fmt.Errorf("invalid number of arguments for histogram_buckets, expected %d, got %d", exp, got)
+		}
+		lit, ok := call.Args[1].(*cnosql.StringLiteral)
+		if !ok {
+			return nil, fmt.Errorf("expected string argument for the buckets of histogram_buckets()")
+		}
+
+		parts := strings.Split(lit.Val, ",")
+		if len(parts) > maxHistogramBuckets {
+			return nil, fmt.Errorf("histogram_buckets() must have at most %d buckets, got %d", maxHistogramBuckets, len(parts))
+		}
+		bounds := make([]float64, len(parts))
+		for i, s := range parts {
+			b, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			if err != nil || math.IsNaN(b) || math.IsInf(b, 0) {
+				return nil, fmt.Errorf("invalid bucket in histogram_buckets(): %q", s)
+			} else if i > 0 && b <= bounds[i-1] {
+				return nil, fmt.Errorf("histogram_buckets() buckets must be in increasing order")
+			}
+			bounds[i] = b
+		}
+		return bounds, nil
+	}
+	return nil, fmt.Errorf("unsupported histogram function: %s", call.Name)
+}
+
+func numberLiteral(expr cnosql.Expr) (float64, bool) {
+	switch lit := expr.(type) {
+	case *cnosql.NumberLiteral:
+		return lit.Val, true
+	case *cnosql.IntegerLiteral:
+		return float64(lit.Val), true
+	}
+	return 0, false
+}
+
+// formatHistogramBound formats the upper bound of a bucket the way it is
+// labelled by Prometheus.
+func formatHistogramBound(b float64) string {
+	if math.IsInf(b, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(b, 'g', -1, 64)
+}
+
+// newHistogramIterator returns an iterator that counts the values of each
+// window into a histogram.
+func newHistogramIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
+	bounds, err := histogramBounds(opt.Expr.(*cnosql.Call))
+	if err != nil {
+		return nil, err
+	}
+
+	switch input := input.(type) {
+	case FloatIterator:
+		createFn := func() (FloatPointAggregator, StringPointEmitter) {
+			fn := NewHistogramReducer(bounds)
+			return fn, fn
+		}
+		return newFloatReduceStringIterator(input, opt, createFn), nil
+	case IntegerIterator:
+		createFn := func() (IntegerPointAggregator, StringPointEmitter) {
+			fn := NewHistogramReducer(bounds)
+			return fn, fn
+		}
+		return newIntegerReduceStringIterator(input, opt, createFn), nil
+	case UnsignedIterator:
+		createFn := func() (UnsignedPointAggregator, StringPointEmitter) {
+			fn := NewHistogramReducer(bounds)
+			return fn, fn
+		}
+		return newUnsignedReduceStringIterator(input, opt, createFn), nil
+	default:
+		return nil, fmt.Errorf("unsupported histogram iterator type: %T", input)
+	}
+}
+
+// newMergeHistogramIterator returns an iterator that merges the histograms
+// of each window into a single histogram.
+func newMergeHistogramIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
+	switch input := input.(type) {
+	case StringIterator:
+		createFn := func() (StringPointAggregator, StringPointEmitter) {
+			fn := &HistogramMergeReducer{}
+			return fn, fn
+		}
+		return newStringReduceStringIterator(input, opt, createFn), nil
+	case *nilFloatIterator:
+		return input, nil
+	default:
+		return nil, fmt.Errorf("unsupported %s iterator type: %T", mergeHistogramFunction, input)
+	}
+}
+
+// newHistogramFinalIterator returns an iterator that merges the histograms
+// of each window and emits a point for each of the buckets. The upper bound
+// of the bucket is an auxiliary field of the point.
+func newHistogramFinalIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
+	switch input := input.(type) {
+	case StringIterator:
+		createFn := func() (StringPointAggregator, IntegerPointEmitter) {
+			fn := &HistogramBucketReducer{}
+			return fn, fn
+		}
+		return newStringReduceIntegerIterator(input, opt, createFn), nil
+	case *nilFloatIterator:
+		return input, nil
+	default:
+		return nil, fmt.Errorf("unsupported histogram iterator type: %T", input)
+	}
+}
+
+// HistogramReducer counts the aggregated points into a histogram and emits
+// the encoded histogram.
+type HistogramReducer struct {
+	h *Histogram
+}
+
+// NewHistogramReducer creates a new HistogramReducer.
+func NewHistogramReducer(bounds []float64) *HistogramReducer {
+	return &HistogramReducer{h: NewHistogram(bounds)}
+}
+
+// AggregateFloat aggregates a point into the reducer.
+func (r *HistogramReducer) AggregateFloat(p *FloatPoint) {
+	r.h.Add(p.Value)
+}
+
+// AggregateInteger aggregates a point into the reducer.
+func (r *HistogramReducer) AggregateInteger(p *IntegerPoint) {
+	r.h.Add(float64(p.Value))
+}
+
+// AggregateUnsigned aggregates a point into the reducer.
+func (r *HistogramReducer) AggregateUnsigned(p *UnsignedPoint) {
+	r.h.Add(float64(p.Value))
+}
+
+// Emit emits the encoded histogram as a single point.
+func (r *HistogramReducer) Emit() []StringPoint {
+	data, err := r.h.MarshalBinary()
+	if err != nil {
+		return nil
+	}
+	return []StringPoint{{Time: ZeroTime, Value: string(data)}}
+}
+
+// HistogramMergeReducer merges encoded histograms.
+type HistogramMergeReducer struct {
+	h *Histogram
+}
+
+// AggregateString merges the histogram encoded in the point into the
+// reducer. Points that do not contain a valid histogram, or that have
+// different bounds than the histograms merged before, are ignored.
+func (r *HistogramMergeReducer) AggregateString(p *StringPoint) {
+	var other Histogram
+	if err := other.UnmarshalBinary([]byte(p.Value)); err != nil {
+		return
+	}
+	if r.h == nil {
+		r.h = &other
+		return
+	}
+	_ = r.h.Merge(&other)
+}
+
+// Emit emits the encoded histogram as a single point.
+func (r *HistogramMergeReducer) Emit() []StringPoint {
+	if r.h == nil {
+		return nil
+	}
+	data, err := r.h.MarshalBinary()
+	if err != nil {
+		return nil
+	}
+	return []StringPoint{{Time: ZeroTime, Value: string(data)}}
+}
+
+// HistogramBucketReducer merges encoded histograms and emits the buckets of
+// the merged histogram.
+type HistogramBucketReducer struct {
+	HistogramMergeReducer
+}
+
+// Emit emits the cumulative count of each bucket of the histogram with the
+// upper bound of the bucket as an auxiliary field.
+func (r *HistogramBucketReducer) Emit() []IntegerPoint {
+	if r.h == nil {
+		return nil
+	}
+
+	points := make([]IntegerPoint, len(r.h.Counts))
+	var count uint64
+	for i, n := range r.h.Counts {
+		bound := math.Inf(1)
+		if i < len(r.h.Bounds) {
+			bound = r.h.Bounds[i]
+		}
+		count += n
+		points[i] = IntegerPoint{
+			Time:  ZeroTime,
+			Value: int64(count),
+			Aux:   []interface{}{formatHistogramBound(bound)},
+		}
+	}
+	return points
+}
+
+// newHistogramTagIterator returns an iterator that moves the upper bound of
+// the bucket of each point into its tags, so the buckets are written as
+// separate series when the results are written into a measurement.
+func newHistogramTagIterator(input Iterator) Iterator {
+	if input, ok := input.(IntegerIterator); ok {
+		return &histogramTagIterator{input: input}
+	}
+	return input
+}
+
+type histogramTagIterator struct {
+	input IntegerIterator
+}
+
+func (itr *histogramTagIterator) Stats() IteratorStats { return itr.input.Stats() }
+func (itr *histogramTagIterator) Close() error         { return itr.input.Close() }
+
+func (itr *histogramTagIterator) Next() (*IntegerPoint, error) {
+	p, err := itr.input.Next()
+	if p == nil || err != nil {
+		return p, err
+	} else if len(p.Aux) == 0 {
+		return p, nil
+	}
+
+	m := make(map[string]string, len(p.Tags.KeyValues())+1)
+	for k, v := range p.Tags.KeyValues() {
+		m[k] = v
+	}
+	m[histogramBucketColumn], _ = p.Aux[0].(string)
+	p.Tags = NewTags(m)
+	p.Aux = nil
+	return p, nil
+}
@@ -557,6 +557,9 @@ func (m *Measurement) GetSystemIterator() string {
 type Interval struct {
 	Duration             *int64   `protobuf:"varint,1,opt,name=Duration" json:"Duration,omitempty"`
 	Offset               *int64   `protobuf:"varint,2,opt,name=Offset" json:"Offset,omitempty"`
+	Months               *int64   `protobuf:"varint,3,opt,name=Months" json:"Months,omitempty"`
+	Weeks                *int64   `protobuf:"varint,4,opt,name=Weeks" json:"Weeks,omitempty"`
+	Weekday              *int64   `protobuf:"varint,5,opt,name=Weekday" json:"Weekday,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -600,6 +603,27 @@ func (m *Interval) GetOffset() int64 {
 	return 0
 }
 
+func (m *Interval) GetMonths() int64 {
+	if m != nil && m.Months != nil {
+		return *m.Months
+	}
+	return 0
+}
+
+func (m *Interval) GetWeeks() int64 {
+	if m != nil && m.Weeks != nil {
+		return *m.Weeks
+	}
+	return 0
+}
+
+func (m *Interval) GetWeekday() int64 {
+	if m != nil && m.Weekday != nil {
+		return *m.Weekday
+	}
+	return 0
+}
+
 type IteratorStats struct {
 	SeriesN              *int64   `protobuf:"varint,1,opt,name=SeriesN" json:"SeriesN,omitempty"`
 	PointN               *int64   `protobuf:"varint,2,opt,name=PointN" json:"PointN,omitempty"`
@@ -706,55 +730,57 @@ func init() {
 func init() { proto.RegisterFile("internal/internal.proto", fileDescriptor_41ca0a4a9dd77d9e) }
 
 var fileDescriptor_41ca0a4a9dd77d9e = []byte{
-	// 796 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x55, 0x6d, 0x6f, 0xe3, 0x44,
-	0x10, 0x96, 0xe3, 0x3a, 0x8d, 0x27, 0xcd, 0xf5, 0x58, 0x4a, 0x59, 0xa1, 0x13, 0xb2, 0x2c, 0x40,
-	0x16, 0xa0, 0x22, 0xf5, 0x13, 0x9f, 0x90, 0x72, 0xf4, 0x8a, 0x2a, 0xdd, 0xb5, 0xa7, 0x4d, 0xe9,
-	0xf7, 0x25, 0x9e, 0x5a, 0x2b, 0x39, 0xeb, 0xb0, 0x5e, 0xa3, 0xe4, 0x07, 0xf4, 0x87, 0xf1, 0x13,
-	0xf8, 0x47, 0x68, 0x67, 0xd7, 0x89, 0x53, 0x81, 0x7a, 0x9f, 0x32, 0xcf, 0x33, 0x93, 0x7d, 0x79,
-	0xe6, 0x99, 0x35, 0x7c, 0xa9, 0xb4, 0x45, 0xa3, 0x65, 0xfd, 0x53, 0x1f, 0x5c, 0xac, 0x4d, 0x63,
-	0x1b, 0x96, 0xfc, 0xd9, 0xa1, 0xd9, 0xe6, 0x4f, 0x31, 0x24, 0x1f, 0x1b, 0xa5, 0x2d, 0x63, 0x70,
-	0x74, 0x2b, 0x57, 0xc8, 0xa3, 0x6c, 0x54, 0xa4, 0x82, 0x62, 0xc7, 0xdd, 0xcb, 0xaa, 0xe5, 0x23,
-	0xcf, 0xb9, 0x98, 0x38, 0xb5, 0x42, 0x1e, 0x67, 0xa3, 0x22, 0x16, 0x14, 0xb3, 0xd7, 0x10, 0xdf,
-	0xaa, 0x9a, 0x1f, 0x65, 0xa3, 0x62, 0x22, 0x5c, 0xc8, 0xde, 0x40, 0x3c, 0xef, 0x36, 0x3c, 0xc9,
-	0xe2, 0x62, 0x7a, 0x09, 0x17, 0xb4, 0xd9, 0xc5, 0xbc, 0xdb, 0x08, 0x47, 0xb3, 0xaf, 0x01, 0xe6,
-	0x55, 0x65, 0xb0, 0x92, 0x16, 0x4b, 0x3e, 0xce, 0xa2, 0x62, 0x26, 0x06, 0x8c, 0xcb, 0x5f, 0xd7,
-	0x8d, 0xb4, 0x0f, 0xb2, 0xee, 0x90, 0x1f, 0x67, 0x51, 0x11, 0x89, 0x01, 0xc3, 0x72, 0x38, 0xb9,
-	0xd1, 0x16, 0x2b, 0x34, 0xbe, 0x62, 0x92, 0x45, 0x45, 0x2c, 0x0e, 0x38, 0x96, 0xc1, 0x74, 0x61,
-	0x8d, 0xd2, 0x95, 0x2f, 0x49, 0xb3, 0xa8, 0x48, 0xc5, 0x90, 0x72, 0xab, 0xbc, 0x6d, 0x9a, 0x1a,
-	0xa5, 0xf6, 0x25, 0x90, 0x45, 0xc5, 0x44, 0x1c, 0x70, 0xec, 0x1b, 0x98, 0xfd, 0xae, 0x5b, 0x55,
-	0x69, 0x2c, 0x7d, 0xd1, 0x49, 0x16, 0x15, 0x47, 0xe2, 0x90, 0x64, 0xdf, 0x43, 0xb2, 0xb0, 0xd2,
-	0xb6, 0x7c, 0x9a, 0x45, 0xc5, 0xf4, 0xf2, 0x2c, 0xdc, 0xf7, 0xc6, 0xa2, 0x91, 0xb6, 0x31, 0x94,
-	0x13, 0xbe, 0x84, 0x9d, 0x41, 0x72, 0x6f, 0xe4, 0x12, 0xf9, 0x2c, 0x8b, 0x8a, 0x13, 0xe1, 0x41,
-	0xfe, 0x4f, 0x44, 0x82, 0xb1, 0xaf, 0x60, 0x72, 0x25, 0xad, 0xbc, 0xdf, 0xae, 0x7d, 0x27, 0x12,
-	0xb1, 0xc3, 0xcf, 0x54, 0x19, 0xbd, 0xa8, 0x4a, 0xfc, 0xb2, 0x2a, 0x47, 0x2f, 0xab, 0x92, 0x7c,
-	0x8a, 0x2a, 0xe3, 0xff, 0x50, 0x25, 0x7f, 0x4a, 0xe0, 0xb4, 0x97, 0xe0, 0x6e, 0x6d, 0x55, 0xa3,
-	0xc9, 0x3d, 0xef, 0x36, 0x6b, 0xc3, 0x23, 0xda, 0x98, 0x62, 0xe7, 0x1e, 0xe7, 0x95, 0x51, 0x16,
-	0x17, 0xa9, 0xf7, 0xc7, 0xb7, 0x30, 0xbe, 0x56, 0x58, 0x97, 0x2d, 0xff, 0x8c, 0x0c, 0x34, 0x0b,
-	0x82, 0x3e, 0x48, 0x23, 0xf0, 0x51, 0x84, 0x24, 0xfb, 0x11, 0x8e, 0x17, 0x4d, 0x67, 0x96, 0xd8,
-	0xf2, 0x98, 0xea, 0x58, 0xa8, 0xfb, 0x80, 0xb2, 0xed, 0x0c, 0xae, 0x50, 0x5b, 0xd1, 0x97, 0xb0,
-	0x1f, 0x60, 0xe2, 0xa4, 0x30, 0x7f, 0xc9, 0x9a, 0xee, 0x3d, 0xbd, 0x3c, 0xed, 0xfb, 0x14, 0x68,
-	0xb1, 0x2b, 0x70, 0x5a, 0x5f, 0xa9, 0x15, 0xea, 0xd6, 0x9d, 0x9a, 0x6c, 0x9c, 0x8a, 0x01, 0xc3,
-	0x38, 0x1c, 0xff, 0x66, 0x9a, 0x6e, 0xfd, 0x76, 0xcb, 0x3f, 0xa7, 0x64, 0x0f, 0xdd, 0x0d, 0xaf,
-	0x55, 0x5d, 0x93, 0x24, 0x89, 0xa0, 0x98, 0xbd, 0x81, 0xd4, 0xfd, 0x0e, 0xed, 0xbc, 0x27, 0x5c,
-	0xf6, 0xd7, 0x46, 0x97, 0xca, 0x29, 0x44, 0x56, 0x4e, 0xc5, 0x9e, 0x70, 0xd9, 0x85, 0x95, 0xc6,
-	0xd2, 0xd0, 0xa5, 0xd4, 0xd2, 0x3d, 0xe1, 0xce, 0xf1, 0x4e, 0x97, 0x94, 0x03, 0xca, 0xf5, 0xd0,
-	0x39, 0xe9, 0x7d, 0xb3, 0x94, 0xb4, 0xe8, 0x17, 0xb4, 0xe8, 0x0e, 0xbb, 0x35, 0xe7, 0xed, 0x12,
-	0x75, 0xa9, 0x74, 0x45, 0x9e, 0x9d, 0x88, 0x3d, 0xe1, 0x1c, 0xfa, 0x5e, 0xad, 0x94, 0x25, 0xaf,
-	0xc7, 0xc2, 0x03, 0x76, 0x0e, 0xe3, 0xbb, 0xc7, 0xc7, 0x16, 0x2d, 0x19, 0x37, 0x16, 0x01, 0x39,
-	0x7e, 0xe1, 0xcb, 0x5f, 0x79, 0xde, 0x23, 0x77, 0xb2, 0x45, 0xf8, 0xc3, 0xa9, 0x3f, 0x59, 0x80,
-	0xfe, 0x46, 0x46, 0xad, 0xe9, 0xb9, 0x39, 0xf7, 0xbb, 0xef, 0x08, 0xb7, 0xde, 0x15, 0x96, 0xdd,
-	0x1a, 0xf9, 0x6b, 0x4a, 0x05, 0xe4, 0x3a, 0xf2, 0x41, 0x6e, 0x16, 0x68, 0x14, 0xb6, 0xb7, 0x9c,
-	0xd1, 0x92, 0x03, 0xc6, 0xed, 0x77, 0x67, 0x4a, 0x34, 0x58, 0xf2, 0x33, 0xfa, 0x63, 0x0f, 0xf3,
-	0x9f, 0xe1, 0x64, 0x60, 0x88, 0x96, 0x15, 0x90, 0xdc, 0x58, 0x5c, 0xb5, 0x3c, 0xfa, 0x5f, 0xd3,
-	0xf8, 0x82, 0xfc, 0xef, 0x08, 0xa6, 0x03, 0xba, 0x9f, 0xce, 0x3f, 0x64, 0x8b, 0xc1, 0xc1, 0x3b,
-	0xcc, 0x0a, 0x38, 0x15, 0x68, 0x51, 0x3b, 0x81, 0x3f, 0x36, 0xb5, 0x5a, 0x6e, 0x69, 0x44, 0x53,
-	0xf1, 0x9c, 0xde, 0xbd, 0xb4, 0xb1, 0x9f, 0x01, 0xba, 0xf5, 0x19, 0x24, 0x02, 0x2b, 0xdc, 0x84,
-	0x89, 0xf4, 0xc0, 0xed, 0x77, 0xd3, 0xde, 0x4b, 0x53, 0xa1, 0x0d, 0x73, 0xb8, 0xc3, 0xec, 0x3b,
-	0x78, 0xb5, 0xd8, 0xb6, 0x16, 0x57, 0xfd, 0x88, 0x91, 0xe3, 0x52, 0xf1, 0x8c, 0xcd, 0x7f, 0xd9,
-	0xdb, 0x9e, 0xce, 0xdf, 0x19, 0xef, 0x89, 0x88, 0x14, 0xdc, 0xe1, 0x41, 0x7f, 0x47, 0xc3, 0xfe,
-	0xe6, 0x73, 0x98, 0x1d, 0xbc, 0x63, 0xd4, 0xd8, 0xd0, 0x85, 0x28, 0x34, 0x36, 0xb4, 0xe0, 0x1c,
-	0xc6, 0xf4, 0x2d, 0xb9, 0xed, 0x97, 0xf0, 0x28, 0xbf, 0x80, 0xb1, 0x9f, 0x5c, 0x37, 0xea, 0x0f,
-	0xb2, 0x0e, 0xdf, 0x18, 0x17, 0xd2, 0xe7, 0xc4, 0x3d, 0x76, 0x23, 0x3f, 0x2e, 0x2e, 0xfe, 0x37,
-	0x00, 0x00, 0xff, 0xff, 0x07, 0x98, 0x54, 0xa1, 0xb5, 0x06, 0x00, 0x00,
+	// 822 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x55, 0xdd, 0x6e, 0x23, 0x35,
+	0x14, 0x96, 0x33, 0x9d, 0x34, 0xe3, 0x34, 0xdb, 0x62, 0xca, 0x62, 0xa1, 0x15, 0x1a, 0x8d, 0x00,
+	0x8d, 0x00, 0x15, 0xa9, 0x57, 0xdc, 0x66, 0xe9, 0x16, 0x55, 0xda, 0xb6, 0x2b, 0xa7, 0x94, 0x6b,
+	0x93, 0x39, 0x1d, 0x2c, 0x26, 0x9e, 0x60, 0x7b, 0x50, 0xf2, 0x00, 0xe5, 0xbd, 0x78, 0x04, 0xde,
+	0x08, 0xf9, 0xd8, 0x93, 0x4c, 0x2b, 0x50, 0xb9, 0xca, 0xf9, 0xbe, 0x73, 0xe2, 0x9f, 0xef, 0x7c,
+	0xc7, 0x43, 0x3f, 0x55, 0xda, 0x81, 0xd1, 0xb2, 0xf9, 0xae, 0x0f, 0xce, 0xd6, 0xa6, 0x75, 0x2d,
+	0x4b, 0x7f, 0xef, 0xc0, 0x6c, 0x8b, 0xc7, 0x84, 0xa6, 0x1f, 0x5a, 0xa5, 0x1d, 0x63, 0xf4, 0xe0,
+	0x46, 0xae, 0x80, 0x93, 0x7c, 0x54, 0x66, 0x02, 0x63, 0xcf, 0xdd, 0xc9, 0xda, 0xf2, 0x51, 0xe0,
+	0x7c, 0x8c, 0x9c, 0x5a, 0x01, 0x4f, 0xf2, 0x51, 0x99, 0x08, 0x8c, 0xd9, 0x09, 0x4d, 0x6e, 0x54,
+	0xc3, 0x0f, 0xf2, 0x51, 0x39, 0x11, 0x3e, 0x64, 0x6f, 0x68, 0x32, 0xef, 0x36, 0x3c, 0xcd, 0x93,
+	0x72, 0x7a, 0x4e, 0xcf, 0x70, 0xb3, 0xb3, 0x79, 0xb7, 0x11, 0x9e, 0x66, 0x9f, 0x53, 0x3a, 0xaf,
+	0x6b, 0x03, 0xb5, 0x74, 0x50, 0xf1, 0x71, 0x4e, 0xca, 0x99, 0x18, 0x30, 0x3e, 0x7f, 0xd9, 0xb4,
+	0xd2, 0xdd, 0xcb, 0xa6, 0x03, 0x7e, 0x98, 0x93, 0x92, 0x88, 0x01, 0xc3, 0x0a, 0x7a, 0x74, 0xa5,
+	0x1d, 0xd4, 0x60, 0x42, 0xc5, 0x24, 0x27, 0x65, 0x22, 0x9e, 0x70, 0x2c, 0xa7, 0xd3, 0x85, 0x33,
+	0x4a, 0xd7, 0xa1, 0x24, 0xcb, 0x49, 0x99, 0x89, 0x21, 0xe5, 0x57, 0x79, 0xdb, 0xb6, 0x0d, 0x48,
+	0x1d, 0x4a, 0x68, 0x4e, 0xca, 0x89, 0x78, 0xc2, 0xb1, 0x2f, 0xe8, 0xec, 0x27, 0x6d, 0x55, 0xad,
+	0xa1, 0x0a, 0x45, 0x47, 0x39, 0x29, 0x0f, 0xc4, 0x53, 0x92, 0x7d, 0x4d, 0xd3, 0x85, 0x93, 0xce,
+	0xf2, 0x69, 0x4e, 0xca, 0xe9, 0xf9, 0x69, 0xbc, 0xef, 0x95, 0x03, 0x23, 0x5d, 0x6b, 0x30, 0x27,
+	0x42, 0x09, 0x3b, 0xa5, 0xe9, 0x9d, 0x91, 0x4b, 0xe0, 0xb3, 0x9c, 0x94, 0x47, 0x22, 0x80, 0xe2,
+	0x6f, 0x82, 0x82, 0xb1, 0xcf, 0xe8, 0xe4, 0x42, 0x3a, 0x79, 0xb7, 0x5d, 0x87, 0x4e, 0xa4, 0x62,
+	0x87, 0x9f, 0xa9, 0x32, 0x7a, 0x51, 0x95, 0xe4, 0x65, 0x55, 0x0e, 0x5e, 0x56, 0x25, 0xfd, 0x3f,
+	0xaa, 0x8c, 0xff, 0x45, 0x95, 0xe2, 0x31, 0xa5, 0xc7, 0xbd, 0x04, 0xb7, 0x6b, 0xa7, 0x5a, 0x8d,
+	0xee, 0x79, 0xb7, 0x59, 0x1b, 0x4e, 0x70, 0x63, 0x8c, 0xd9, 0x49, 0xf0, 0xca, 0x28, 0x4f, 0xca,
+	0x2c, 0xf8, 0xe3, 0x4b, 0x3a, 0xbe, 0x54, 0xd0, 0x54, 0x96, 0x7f, 0x84, 0x06, 0x9a, 0x45, 0x41,
+	0xef, 0xa5, 0x11, 0xf0, 0x20, 0x62, 0x92, 0x7d, 0x4b, 0x0f, 0x17, 0x6d, 0x67, 0x96, 0x60, 0x79,
+	0x82, 0x75, 0x2c, 0xd6, 0x5d, 0x83, 0xb4, 0x9d, 0x81, 0x15, 0x68, 0x27, 0xfa, 0x12, 0xf6, 0x0d,
+	0x9d, 0x78, 0x29, 0xcc, 0x1f, 0xb2, 0xc1, 0x7b, 0x4f, 0xcf, 0x8f, 0xfb, 0x3e, 0x45, 0x5a, 0xec,
+	0x0a, 0xbc, 0xd6, 0x17, 0x6a, 0x05, 0xda, 0xfa, 0x53, 0xa3, 0x8d, 0x33, 0x31, 0x60, 0x18, 0xa7,
+	0x87, 0x3f, 0x9a, 0xb6, 0x5b, 0xbf, 0xdd, 0xf2, 0x8f, 0x31, 0xd9, 0x43, 0x7f, 0xc3, 0x4b, 0xd5,
+	0x34, 0x28, 0x49, 0x2a, 0x30, 0x66, 0x6f, 0x68, 0xe6, 0x7f, 0x87, 0x76, 0xde, 0x13, 0x3e, 0xfb,
+	0x43, 0xab, 0x2b, 0xe5, 0x15, 0x42, 0x2b, 0x67, 0x62, 0x4f, 0xf8, 0xec, 0xc2, 0x49, 0xe3, 0x70,
+	0xe8, 0x32, 0x6c, 0xe9, 0x9e, 0xf0, 0xe7, 0x78, 0xa7, 0x2b, 0xcc, 0x51, 0xcc, 0xf5, 0xd0, 0x3b,
+	0xe9, 0x7d, 0xbb, 0x94, 0xb8, 0xe8, 0x27, 0xb8, 0xe8, 0x0e, 0xfb, 0x35, 0xe7, 0x76, 0x09, 0xba,
+	0x52, 0xba, 0x46, 0xcf, 0x4e, 0xc4, 0x9e, 0xf0, 0x0e, 0x7d, 0xaf, 0x56, 0xca, 0xa1, 0xd7, 0x13,
+	0x11, 0x00, 0x7b, 0x4d, 0xc7, 0xb7, 0x0f, 0x0f, 0x16, 0x1c, 0x1a, 0x37, 0x11, 0x11, 0x79, 0x7e,
+	0x11, 0xca, 0x5f, 0x05, 0x3e, 0x20, 0x7f, 0xb2, 0x45, 0xfc, 0xc3, 0x71, 0x38, 0x59, 0x84, 0xe1,
+	0x46, 0x46, 0xad, 0xf1, 0xb9, 0x79, 0x1d, 0x76, 0xdf, 0x11, 0x7e, 0xbd, 0x0b, 0xa8, 0xba, 0x35,
+	0xf0, 0x13, 0x4c, 0x45, 0xe4, 0x3b, 0x72, 0x2d, 0x37, 0x0b, 0x30, 0x0a, 0xec, 0x0d, 0x67, 0xb8,
+	0xe4, 0x80, 0xf1, 0xfb, 0xdd, 0x9a, 0x0a, 0x0c, 0x54, 0xfc, 0x14, 0xff, 0xd8, 0xc3, 0xe2, 0x7b,
+	0x7a, 0x34, 0x30, 0x84, 0x65, 0x25, 0x4d, 0xaf, 0x1c, 0xac, 0x2c, 0x27, 0xff, 0x69, 0x9a, 0x50,
+	0x50, 0xfc, 0x45, 0xe8, 0x74, 0x40, 0xf7, 0xd3, 0xf9, 0x8b, 0xb4, 0x10, 0x1d, 0xbc, 0xc3, 0xac,
+	0xa4, 0xc7, 0x02, 0x1c, 0x68, 0x2f, 0xf0, 0x87, 0xb6, 0x51, 0xcb, 0x2d, 0x8e, 0x68, 0x26, 0x9e,
+	0xd3, 0xbb, 0x97, 0x36, 0x09, 0x33, 0x80, 0xb7, 0x3e, 0xa5, 0xa9, 0x80, 0x1a, 0x36, 0x71, 0x22,
+	0x03, 0xf0, 0xfb, 0x5d, 0xd9, 0x3b, 0x69, 0x6a, 0x70, 0x71, 0x0e, 0x77, 0x98, 0x7d, 0x45, 0x5f,
+	0x2d, 0xb6, 0xd6, 0xc1, 0xaa, 0x1f, 0x31, 0x74, 0x5c, 0x26, 0x9e, 0xb1, 0xc5, 0x9f, 0x64, 0xef,
+	0x7b, 0xbc, 0x40, 0x67, 0x82, 0x29, 0x08, 0x4a, 0xb8, 0xc3, 0x83, 0x06, 0x8f, 0x9e, 0x37, 0xf8,
+	0xba, 0xd5, 0xee, 0x57, 0x1b, 0x1f, 0x94, 0x88, 0xfc, 0x91, 0x7f, 0x06, 0xf8, 0xcd, 0xe2, 0x91,
+	0x13, 0x11, 0x80, 0x6f, 0x83, 0x0f, 0x2a, 0xb9, 0xc5, 0x13, 0x27, 0xa2, 0x87, 0xc5, 0x9c, 0xce,
+	0x9e, 0x3c, 0x88, 0xe8, 0x90, 0xd8, 0x4e, 0x12, 0x1d, 0x12, 0xa0, 0xdf, 0x12, 0x3f, 0x4a, 0x37,
+	0xfd, 0x51, 0x02, 0x2a, 0xce, 0xe8, 0x38, 0x3c, 0x01, 0xfe, 0xcd, 0xb8, 0x97, 0x4d, 0xfc, 0x58,
+	0xf9, 0x10, 0xbf, 0x4b, 0xfe, 0xd5, 0x1c, 0x85, 0xb9, 0xf3, 0xf1, 0x3f, 0x03, 0x00, 0x9b, 0x49,
+	0xd8, 0xf7, 0xfe, 0x06, 0x00, 0x00,
 }
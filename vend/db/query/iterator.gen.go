@@ -316,6 +316,9 @@ type floatSortedMergeIterator struct {
 	inputs []FloatIterator
 	heap   *floatSortedMergeHeap
 	init   bool
+
+	// The memory of the heap reserved from the budget of the query.
+	reserved int64
 }
 
 // newFloatSortedMergeIterator returns an instance of floatSortedMergeIterator.
@@ -351,6 +354,8 @@ func (itr *floatSortedMergeIterator) Close() error {
 	for _, input := range itr.inputs {
 		input.Close()
 	}
+	itr.heap.opt.Memory.Release(itr.reserved)
+	itr.reserved = 0
 	return nil
 }
 
@@ -363,6 +368,14 @@ func (itr *floatSortedMergeIterator) pop() (*FloatPoint, error) {
 	// Initialize the heap. See the MergeIterator to see why this has to be done lazily.
 	if !itr.init {
 		items := itr.heap.items
+
+		// Charge the points buffered by the heap to the budget of the query.
+		size := int64(len(items)) * bufferedPointSize
+		if err := itr.heap.opt.Memory.Reserve(size); err != nil {
+			return nil, err
+		}
+		itr.reserved = size
+
 		itr.heap.items = make([]*floatSortedMergeHeapItem, 0, len(items))
 		for _, item := range items {
 			var err error
@@ -831,6 +844,10 @@ CONSTRUCT:
 					return nil, err
 				} else if next != nil && next.Name == itr.window.name && next.Tags.ID() == itr.window.tags.ID() {
 					interval := int64(itr.opt.Interval.Duration)
+					if !itr.opt.Interval.Calendar.IsZero() {
+						// Calendar windows vary in length, so interpolate by time.
+						interval = 1
+					}
 					start := itr.window.time / interval
 					p.Value = linearFloat(start, itr.prev.Time/interval, next.Time/interval, itr.prev.Value, next.Value)
 				} else {
@@ -859,15 +876,22 @@ CONSTRUCT:
 	// Advance the expected time. Do not advance to a new window here
 	// as there may be lingering points with the same timestamp in the previous
 	// window.
-	if itr.opt.Ascending {
+	if !itr.opt.Interval.Calendar.IsZero() {
+		// Calendar windows vary in length, so move to the adjacent window.
+		if itr.opt.Ascending {
+			_, itr.window.time = itr.opt.Window(itr.window.time)
+		} else {
+			itr.window.time, _ = itr.opt.Window(itr.window.time - 1)
+		}
+	} else if itr.opt.Ascending {
 		itr.window.time += int64(itr.opt.Interval.Duration)
 	} else {
 		itr.window.time -= int64(itr.opt.Interval.Duration)
 	}
 
 	// Check to see if we have passed over an offset change and adjust the time
-	// to account for this new offset.
-	if itr.opt.Location != nil {
+	// to account for this new offset. Calendar windows already account for it.
+	if itr.opt.Location != nil && itr.opt.Interval.Calendar.IsZero() {
 		if _, offset := itr.opt.Zone(itr.window.time - 1); offset != itr.window.offset {
 			diff := itr.window.offset - offset
 			if abs(diff) < int64(itr.opt.Interval.Duration) {
@@ -1040,6 +1064,9 @@ type floatReduceFloatPoint struct {
 	Tags       Tags
 	Aggregator FloatPointAggregator
 	Emitter    FloatPointEmitter
+
+	// The number of points buffered by the aggregator.
+	bufferedN int
 }
 
 // reduce executes fn once for every point in the next window.
@@ -1068,8 +1095,11 @@ func (itr *floatReduceFloatIterator) reduce() ([]FloatPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. The memory of the reducers is charged to the
+	// budget of the query until the window has been reduced.
 	m := make(map[string]*floatReduceFloatPoint)
+	mem := reduceMemory{budget: itr.opt.Memory}
+	defer mem.release()
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
@@ -1109,8 +1139,24 @@ func (itr *floatReduceFloatIterator) reduce() ([]FloatPoint, error) {
 				Emitter:    emitter,
 			}
 			m[id] = rp
+			if err := mem.grow(reducePointSize); err != nil {
+				return nil, err
+			}
 		}
 		rp.Aggregator.AggregateFloat(curr)
+
+		// Charge the points kept by aggregators buffering them.
+		if mem.budget == nil {
+			continue
+		} else if buf, ok := rp.Aggregator.(pointBuffer); ok {
+			if n := buf.bufferedN(); n > rp.bufferedN {
+				size := int64(n-rp.bufferedN) * bufferedPointSize
+				if err := mem.grow(size); err != nil {
+					return nil, err
+				}
+				rp.bufferedN = n
+			}
+		}
 	}
 
 	keys := make([]string, 0, len(m))
@@ -1328,6 +1374,9 @@ type floatReduceIntegerPoint struct {
 	Tags       Tags
 	Aggregator FloatPointAggregator
 	Emitter    IntegerPointEmitter
+
+	// The number of points buffered by the aggregator.
+	bufferedN int
 }
 
 // reduce executes fn once for every point in the next window.
@@ -1356,8 +1405,11 @@ func (itr *floatReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. The memory of the reducers is charged to the
+	// budget of the query until the window has been reduced.
 	m := make(map[string]*floatReduceIntegerPoint)
+	mem := reduceMemory{budget: itr.opt.Memory}
+	defer mem.release()
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
@@ -1397,8 +1449,24 @@ func (itr *floatReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 				Emitter:    emitter,
 			}
 			m[id] = rp
+			if err := mem.grow(reducePointSize); err != nil {
+				return nil, err
+			}
 		}
 		rp.Aggregator.AggregateFloat(curr)
+
+		// Charge the points kept by aggregators buffering them.
+		if mem.budget == nil {
+			continue
+		} else if buf, ok := rp.Aggregator.(pointBuffer); ok {
+			if n := buf.bufferedN(); n > rp.bufferedN {
+				size := int64(n-rp.bufferedN) * bufferedPointSize
+				if err := mem.grow(size); err != nil {
+					return nil, err
+				}
+				rp.bufferedN = n
+			}
+		}
 	}
 
 	keys := make([]string, 0, len(m))
@@ -1616,6 +1684,9 @@ type floatReduceUnsignedPoint struct {
 	Tags       Tags
 	Aggregator FloatPointAggregator
 	Emitter    UnsignedPointEmitter
+
+	// The number of points buffered by the aggregator.
+	bufferedN int
 }
 
 // reduce executes fn once for every point in the next window.
@@ -1644,8 +1715,11 @@ func (itr *floatReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. The memory of the reducers is charged to the
+	// budget of the query until the window has been reduced.
 	m := make(map[string]*floatReduceUnsignedPoint)
+	mem := reduceMemory{budget: itr.opt.Memory}
+	defer mem.release()
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
@@ -1685,8 +1759,24 @@ func (itr *floatReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 				Emitter:    emitter,
 			}
 			m[id] = rp
+			if err := mem.grow(reducePointSize); err != nil {
+				return nil, err
+			}
 		}
 		rp.Aggregator.AggregateFloat(curr)
+
+		// Charge the points kept by aggregators buffering them.
+		if mem.budget == nil {
+			continue
+		} else if buf, ok := rp.Aggregator.(pointBuffer); ok {
+			if n := buf.bufferedN(); n > rp.bufferedN {
+				size := int64(n-rp.bufferedN) * bufferedPointSize
+				if err := mem.grow(size); err != nil {
+					return nil, err
+				}
+				rp.bufferedN = n
+			}
+		}
 	}
 
 	keys := make([]string, 0, len(m))
@@ -1904,6 +1994,9 @@ type floatReduceStringPoint struct {
 	Tags       Tags
 	Aggregator FloatPointAggregator
 	Emitter    StringPointEmitter
+
+	// The number of points buffered by the aggregator.
+	bufferedN int
 }
 
 // reduce executes fn once for every point in the next window.
@@ -1932,8 +2025,11 @@ func (itr *floatReduceStringIterator) reduce() ([]StringPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. The memory of the reducers is charged to the
+	// budget of the query until the window has been reduced.
 	m := make(map[string]*floatReduceStringPoint)
+	mem := reduceMemory{budget: itr.opt.Memory}
+	defer mem.release()
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
@@ -1973,8 +2069,24 @@ func (itr *floatReduceStringIterator) reduce() ([]StringPoint, error) {
 				Emitter:    emitter,
 			}
 			m[id] = rp
+			if err := mem.grow(reducePointSize); err != nil {
+				return nil, err
+			}
 		}
 		rp.Aggregator.AggregateFloat(curr)
+
+		// Charge the points kept by aggregators buffering them.
+		if mem.budget == nil {
+			continue
+		} else if buf, ok := rp.Aggregator.(pointBuffer); ok {
+			if n := buf.bufferedN(); n > rp.bufferedN {
+				size := int64(n-rp.bufferedN) * bufferedPointSize
+				if err := mem.grow(size); err != nil {
+					return nil, err
+				}
+				rp.bufferedN = n
+			}
+		}
 	}
 
 	keys := make([]string, 0, len(m))
@@ -2192,6 +2304,9 @@ type floatReduceBooleanPoint struct {
 	Tags       Tags
 	Aggregator FloatPointAggregator
 	Emitter    BooleanPointEmitter
+
+	// The number of points buffered by the aggregator.
+	bufferedN int
 }
 
 // reduce executes fn once for every point in the next window.
@@ -2220,8 +2335,11 @@ func (itr *floatReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. The memory of the reducers is charged to the
+	// budget of the query until the window has been reduced.
 	m := make(map[string]*floatReduceBooleanPoint)
+	mem := reduceMemory{budget: itr.opt.Memory}
+	defer mem.release()
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
@@ -2261,8 +2379,24 @@ func (itr *floatReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 				Emitter:    emitter,
 			}
 			m[id] = rp
+			if err := mem.grow(reducePointSize); err != nil {
+				return nil, err
+			}
 		}
 		rp.Aggregator.AggregateFloat(curr)
+
+		// Charge the points kept by aggregators buffering them.
+		if mem.budget == nil {
+			continue
+		} else if buf, ok := rp.Aggregator.(pointBuffer); ok {
+			if n := buf.bufferedN(); n > rp.bufferedN {
+				size := int64(n-rp.bufferedN) * bufferedPointSize
+				if err := mem.grow(size); err != nil {
+					return nil, err
+				}
+				rp.bufferedN = n
+			}
+		}
 	}
 
 	keys := make([]string, 0, len(m))
@@ -2980,6 +3114,9 @@ type integerSortedMergeIterator struct {
 	inputs []IntegerIterator
 	heap   *integerSortedMergeHeap
 	init   bool
+
+	// The memory of the heap reserved from the budget of the query.
+	reserved int64
 }
 
 // newIntegerSortedMergeIterator returns an instance of integerSortedMergeIterator.
@@ -3015,6 +3152,8 @@ func (itr *integerSortedMergeIterator) Close() error {
 	for _, input := range itr.inputs {
 		input.Close()
 	}
+	itr.heap.opt.Memory.Release(itr.reserved)
+	itr.reserved = 0
 	return nil
 }
 
@@ -3027,6 +3166,14 @@ func (itr *integerSortedMergeIterator) pop() (*IntegerPoint, error) {
 	// Initialize the heap. See the MergeIterator to see why this has to be done lazily.
 	if !itr.init {
 		items := itr.heap.items
+
+		// Charge the points buffered by the heap to the budget of the query.
+		size := int64(len(items)) * bufferedPointSize
+		if err := itr.heap.opt.Memory.Reserve(size); err != nil {
+			return nil, err
+		}
+		itr.reserved = size
+
 		itr.heap.items = make([]*integerSortedMergeHeapItem, 0, len(items))
 		for _, item := range items {
 			var err error
@@ -3495,6 +3642,10 @@ CONSTRUCT:
 					return nil, err
 				} else if next != nil && next.Name == itr.window.name && next.Tags.ID() == itr.window.tags.ID() {
 					interval := int64(itr.opt.Interval.Duration)
+					if !itr.opt.Interval.Calendar.IsZero() {
+						// Calendar windows vary in length, so interpolate by time.
+						interval = 1
+					}
 					start := itr.window.time / interval
 					p.Value = linearInteger(start, itr.prev.Time/interval, next.Time/interval, itr.prev.Value, next.Value)
 				} else {
@@ -3523,15 +3674,22 @@ CONSTRUCT:
 	// Advance the expected time. Do not advance to a new window here
 	// as there may be lingering points with the same timestamp in the previous
 	// window.
-	if itr.opt.Ascending {
+	if !itr.opt.Interval.Calendar.IsZero() {
+		// Calendar windows vary in length, so move to the adjacent window.
+		if itr.opt.Ascending {
+			_, itr.window.time = itr.opt.Window(itr.window.time)
+		} else {
+			itr.window.time, _ = itr.opt.Window(itr.window.time - 1)
+		}
+	} else if itr.opt.Ascending {
 		itr.window.time += int64(itr.opt.Interval.Duration)
 	} else {
 		itr.window.time -= int64(itr.opt.Interval.Duration)
 	}
 
 	// Check to see if we have passed over an offset change and adjust the time
-	// to account for this new offset.
-	if itr.opt.Location != nil {
+	// to account for this new offset. Calendar windows already account for it.
+	if itr.opt.Location != nil && itr.opt.Interval.Calendar.IsZero() {
 		if _, offset := itr.opt.Zone(itr.window.time - 1); offset != itr.window.offset {
 			diff := itr.window.offset - offset
 			if abs(diff) < int64(itr.opt.Interval.Duration) {
@@ -3704,6 +3862,9 @@ type integerReduceFloatPoint struct {
 	Tags       Tags
 	Aggregator IntegerPointAggregator
 	Emitter    FloatPointEmitter
+
+	// The number of points buffered by the aggregator.
+	bufferedN int
 }
 
 // reduce executes fn once for every point in the next window.
@@ -3732,8 +3893,11 @@ func (itr *integerReduceFloatIterator) reduce() ([]FloatPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. The memory of the reducers is charged to the
+	// budget of the query until the window has been reduced.
 	m := make(map[string]*integerReduceFloatPoint)
+	mem := reduceMemory{budget: itr.opt.Memory}
+	defer mem.release()
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
@@ -3773,8 +3937,24 @@ func (itr *integerReduceFloatIterator) reduce() ([]FloatPoint, error) {
 				Emitter:    emitter,
 			}
 			m[id] = rp
+			if err := mem.grow(reducePointSize); err != nil {
+				return nil, err
+			}
 		}
 		rp.Aggregator.AggregateInteger(curr)
+
+		// Charge the points kept by aggregators buffering them.
+		if mem.budget == nil {
+			continue
+		} else if buf, ok := rp.Aggregator.(pointBuffer); ok {
+			if n := buf.bufferedN(); n > rp.bufferedN {
+				size := int64(n-rp.bufferedN) * bufferedPointSize
+				if err := mem.grow(size); err != nil {
+					return nil, err
+				}
+				rp.bufferedN = n
+			}
+		}
 	}
 
 	keys := make([]string, 0, len(m))
@@ -3992,6 +4172,9 @@ type integerReduceIntegerPoint struct {
 	Tags       Tags
 	Aggregator IntegerPointAggregator
 	Emitter    IntegerPointEmitter
+
+	// The number of points buffered by the aggregator.
+	bufferedN int
 }
 
 // reduce executes fn once for every point in the next window.
@@ -4020,8 +4203,11 @@ func (itr *integerReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. The memory of the reducers is charged to the
+	// budget of the query until the window has been reduced.
 	m := make(map[string]*integerReduceIntegerPoint)
+	mem := reduceMemory{budget: itr.opt.Memory}
+	defer mem.release()
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
@@ -4061,8 +4247,24 @@ func (itr *integerReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 				Emitter:    emitter,
 			}
 			m[id] = rp
+			if err := mem.grow(reducePointSize); err != nil {
+				return nil, err
+			}
 		}
 		rp.Aggregator.AggregateInteger(curr)
+
+		// Charge the points kept by aggregators buffering them.
+		if mem.budget == nil {
+			continue
+		} else if buf, ok := rp.Aggregator.(pointBuffer); ok {
+			if n := buf.bufferedN(); n > rp.bufferedN {
+				size := int64(n-rp.bufferedN) * bufferedPointSize
+				if err := mem.grow(size); err != nil {
+					return nil, err
+				}
+				rp.bufferedN = n
+			}
+		}
 	}
 
 	keys := make([]string, 0, len(m))
@@ -4280,6 +4482,9 @@ type integerReduceUnsignedPoint struct {
 	Tags       Tags
 	Aggregator IntegerPointAggregator
 	Emitter    UnsignedPointEmitter
+
+	// The number of points buffered by the aggregator.
+	bufferedN int
 }
 
 // reduce executes fn once for every point in the next window.
@@ -4308,8 +4513,11 @@ func (itr *integerReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. The memory of the reducers is charged to the
+	// budget of the query until the window has been reduced.
 	m := make(map[string]*integerReduceUnsignedPoint)
+	mem := reduceMemory{budget: itr.opt.Memory}
+	defer mem.release()
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
@@ -4349,8 +4557,24 @@ func (itr *integerReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 				Emitter:    emitter,
 			}
 			m[id] = rp
+			if err := mem.grow(reducePointSize); err != nil {
+				return nil, err
+			}
 		}
 		rp.Aggregator.AggregateInteger(curr)
+
+		// Charge the points kept by aggregators buffering them.
+		if mem.budget == nil {
+			continue
+		} else if buf, ok := rp.Aggregator.(pointBuffer); ok {
+			if n := buf.bufferedN(); n > rp.bufferedN {
+				size := int64(n-rp.bufferedN) * bufferedPointSize
+				if err := mem.grow(size); err != nil {
+					return nil, err
+				}
+				rp.bufferedN = n
+			}
+		}
 	}
 
 	keys := make([]string, 0, len(m))
@@ -4568,6 +4792,9 @@ type integerReduceStringPoint struct {
 	Tags       Tags
 	Aggregator IntegerPointAggregator
 	Emitter    StringPointEmitter
+
+	// The number of points buffered by the aggregator.
+	bufferedN int
 }
 
 // reduce executes fn once for every point in the next window.
@@ -4596,8 +4823,11 @@ func (itr *integerReduceStringIterator) reduce() ([]StringPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. The memory of the reducers is charged to the
+	// budget of the query until the window has been reduced.
 	m := make(map[string]*integerReduceStringPoint)
+	mem := reduceMemory{budget: itr.opt.Memory}
+	defer mem.release()
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
@@ -4637,8 +4867,24 @@ func (itr *integerReduceStringIterator) reduce() ([]StringPoint, error) {
 				Emitter:    emitter,
 			}
 			m[id] = rp
+			if err := mem.grow(reducePointSize); err != nil {
+				return nil, err
+			}
 		}
 		rp.Aggregator.AggregateInteger(curr)
+
+		// Charge the points kept by aggregators buffering them.
+		if mem.budget == nil {
+			continue
+		} else if buf, ok := rp.Aggregator.(pointBuffer); ok {
+			if n := buf.bufferedN(); n > rp.bufferedN {
+				size := int64(n-rp.bufferedN) * bufferedPointSize
+				if err := mem.grow(size); err != nil {
+					return nil, err
+				}
+				rp.bufferedN = n
+			}
+		}
 	}
 
 	keys := make([]string, 0, len(m))
@@ -4856,6 +5102,9 @@ type integerReduceBooleanPoint struct {
 	Tags       Tags
 	Aggregator IntegerPointAggregator
 	Emitter    BooleanPointEmitter
+
+	// The number of points buffered by the aggregator.
+	bufferedN int
 }
 
 // reduce executes fn once for every point in the next window.
@@ -4884,8 +5133,11 @@ func (itr *integerReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. The memory of the reducers is charged to the
+	// budget of the query until the window has been reduced.
 	m := make(map[string]*integerReduceBooleanPoint)
+	mem := reduceMemory{budget: itr.opt.Memory}
+	defer mem.release()
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
@@ -4925,8 +5177,24 @@ func (itr *integerReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 				Emitter:    emitter,
 			}
 			m[id] = rp
+			if err := mem.grow(reducePointSize); err != nil {
+				return nil, err
+			}
 		}
 		rp.Aggregator.AggregateInteger(curr)
+
+		// Charge the points kept by aggregators buffering them.
+		if mem.budget == nil {
+			continue
+		} else if buf, ok := rp.Aggregator.(pointBuffer); ok {
+			if n := buf.bufferedN(); n > rp.bufferedN {
+				size := int64(n-rp.bufferedN) * bufferedPointSize
+				if err := mem.grow(size); err != nil {
+					return nil, err
+				}
+				rp.bufferedN = n
+			}
+		}
 	}
 
 	keys := make([]string, 0, len(m))
@@ -5644,6 +5912,9 @@ type unsignedSortedMergeIterator struct {
 	inputs []UnsignedIterator
 	heap   *unsignedSortedMergeHeap
 	init   bool
+
+	// The memory of the heap reserved from the budget of the query.
+	reserved int64
 }
 
 // newUnsignedSortedMergeIterator returns an instance of unsignedSortedMergeIterator.
@@ -5679,6 +5950,8 @@ func (itr *unsignedSortedMergeIterator) Close() error {
 	for _, input := range itr.inputs {
 		input.Close()
 	}
+	itr.heap.opt.Memory.Release(itr.reserved)
+	itr.reserved = 0
 	return nil
 }
 
@@ -5691,6 +5964,14 @@ func (itr *unsignedSortedMergeIterator) pop() (*UnsignedPoint, error) {
 	// Initialize the heap. See the MergeIterator to see why this has to be done lazily.
 	if !itr.init {
 		items := itr.heap.items
+
+		// Charge the points buffered by the heap to the budget of the query.
+		size := int64(len(items)) * bufferedPointSize
+		if err := itr.heap.opt.Memory.Reserve(size); err != nil {
+			return nil, err
+		}
+		itr.reserved = size
+
 		itr.heap.items = make([]*unsignedSortedMergeHeapItem, 0, len(items))
 		for _, item := range items {
 			var err error
@@ -6159,6 +6440,10 @@ CONSTRUCT:
 					return nil, err
 				} else if next != nil && next.Name == itr.window.name && next.Tags.ID() == itr.window.tags.ID() {
 					interval := int64(itr.opt.Interval.Duration)
+					if !itr.opt.Interval.Calendar.IsZero() {
+						// Calendar windows vary in length, so interpolate by time.
+						interval = 1
+					}
 					start := itr.window.time / interval
 					p.Value = linearUnsigned(start, itr.prev.Time/interval, next.Time/interval, itr.prev.Value, next.Value)
 				} else {
@@ -6187,15 +6472,22 @@ CONSTRUCT:
 	// Advance the expected time. Do not advance to a new window here
 	// as there may be lingering points with the same timestamp in the previous
 	// window.
-	if itr.opt.Ascending {
+	if !itr.opt.Interval.Calendar.IsZero() {
+		// Calendar windows vary in length, so move to the adjacent window.
+		if itr.opt.Ascending {
+			_, itr.window.time = itr.opt.Window(itr.window.time)
+		} else {
+			itr.window.time, _ = itr.opt.Window(itr.window.time - 1)
+		}
+	} else if itr.opt.Ascending {
 		itr.window.time += int64(itr.opt.Interval.Duration)
 	} else {
 		itr.window.time -= int64(itr.opt.Interval.Duration)
 	}
 
 	// Check to see if we have passed over an offset change and adjust the time
-	// to account for this new offset.
-	if itr.opt.Location != nil {
+	// to account for this new offset. Calendar windows already account for it.
+	if itr.opt.Location != nil && itr.opt.Interval.Calendar.IsZero() {
 		if _, offset := itr.opt.Zone(itr.window.time - 1); offset != itr.window.offset {
 			diff := itr.window.offset - offset
 			if abs(diff) < int64(itr.opt.Interval.Duration) {
@@ -6368,6 +6660,9 @@ type unsignedReduceFloatPoint struct {
 	Tags       Tags
 	Aggregator UnsignedPointAggregator
 	Emitter    FloatPointEmitter
+
+	// The number of points buffered by the aggregator.
+	bufferedN int
 }
 
 // reduce executes fn once for every point in the next window.
@@ -6396,8 +6691,11 @@ func (itr *unsignedReduceFloatIterator) reduce() ([]FloatPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. The memory of the reducers is charged to the
+	// budget of the query until the window has been reduced.
 	m := make(map[string]*unsignedReduceFloatPoint)
+	mem := reduceMemory{budget: itr.opt.Memory}
+	defer mem.release()
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
@@ -6437,8 +6735,24 @@ func (itr *unsignedReduceFloatIterator) reduce() ([]FloatPoint, error) {
 				Emitter:    emitter,
 			}
 			m[id] = rp
+			if err := mem.grow(reducePointSize); err != nil {
+				return nil, err
+			}
 		}
 		rp.Aggregator.AggregateUnsigned(curr)
+
+		// Charge the points kept by aggregators buffering them.
+		if mem.budget == nil {
+			continue
+		} else if buf, ok := rp.Aggregator.(pointBuffer); ok {
+			if n := buf.bufferedN(); n > rp.bufferedN {
+				size := int64(n-rp.bufferedN) * bufferedPointSize
+				if err := mem.grow(size); err != nil {
+					return nil, err
+				}
+				rp.bufferedN = n
+			}
+		}
 	}
 
 	keys := make([]string, 0, len(m))
@@ -6656,6 +6970,9 @@ type unsignedReduceIntegerPoint struct {
 	Tags       Tags
 	Aggregator UnsignedPointAggregator
 	Emitter    IntegerPointEmitter
+
+	// The number of points buffered by the aggregator.
+	bufferedN int
 }
 
 // reduce executes fn once for every point in the next window.
@@ -6684,8 +7001,11 @@ func (itr *unsignedReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. The memory of the reducers is charged to the
+	// budget of the query until the window has been reduced.
 	m := make(map[string]*unsignedReduceIntegerPoint)
+	mem := reduceMemory{budget: itr.opt.Memory}
+	defer mem.release()
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
@@ -6725,8 +7045,24 @@ func (itr *unsignedReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 				Emitter:    emitter,
 			}
 			m[id] = rp
+			if err := mem.grow(reducePointSize); err != nil {
+				return nil, err
+			}
 		}
 		rp.Aggregator.AggregateUnsigned(curr)
+
+		// Charge the points kept by aggregators buffering them.
+		if mem.budget == nil {
+			continue
+		} else if buf, ok := rp.Aggregator.(pointBuffer); ok {
+			if n := buf.bufferedN(); n > rp.bufferedN {
+				size := int64(n-rp.bufferedN) * bufferedPointSize
+				if err := mem.grow(size); err != nil {
+					return nil, err
+				}
+				rp.bufferedN = n
+			}
+		}
 	}
 
 	keys := make([]string, 0, len(m))
@@ -6944,6 +7280,9 @@ type unsignedReduceUnsignedPoint struct {
 	Tags       Tags
 	Aggregator UnsignedPointAggregator
 	Emitter    UnsignedPointEmitter
+
+	// The number of points buffered by the aggregator.
+	bufferedN int
 }
 
 // reduce executes fn once for every point in the next window.
@@ -6972,8 +7311,11 @@ func (itr *unsignedReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. The memory of the reducers is charged to the
+	// budget of the query until the window has been reduced.
 	m := make(map[string]*unsignedReduceUnsignedPoint)
+	mem := reduceMemory{budget: itr.opt.Memory}
+	defer mem.release()
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
@@ -7013,8 +7355,24 @@ func (itr *unsignedReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 				Emitter:    emitter,
 			}
 			m[id] = rp
+			if err := mem.grow(reducePointSize); err != nil {
+				return nil, err
+			}
 		}
 		rp.Aggregator.AggregateUnsigned(curr)
+
+		// Charge the points kept by aggregators buffering them.
+		if mem.budget == nil {
+			continue
+		} else if buf, ok := rp.Aggregator.(pointBuffer); ok {
+			if n := buf.bufferedN(); n > rp.bufferedN {
+				size := int64(n-rp.bufferedN) * bufferedPointSize
+				if err := mem.grow(size); err != nil {
+					return nil, err
+				}
+				rp.bufferedN = n
+			}
+		}
 	}
 
 	keys := make([]string, 0, len(m))
@@ -7232,6 +7590,9 @@ type unsignedReduceStringPoint struct {
 	Tags       Tags
 	Aggregator UnsignedPointAggregator
 	Emitter    StringPointEmitter
+
+	// The number of points buffered by the aggregator.
+	bufferedN int
 }
 
 // reduce executes fn once for every point in the next window.
@@ -7260,8 +7621,11 @@ func (itr *unsignedReduceStringIterator) reduce() ([]StringPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. The memory of the reducers is charged to the
+	// budget of the query until the window has been reduced.
 	m := make(map[string]*unsignedReduceStringPoint)
+	mem := reduceMemory{budget: itr.opt.Memory}
+	defer mem.release()
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
@@ -7301,8 +7665,24 @@ func (itr *unsignedReduceStringIterator) reduce() ([]StringPoint, error) {
 				Emitter:    emitter,
 			}
 			m[id] = rp
+			if err := mem.grow(reducePointSize); err != nil {
+				return nil, err
+			}
 		}
 		rp.Aggregator.AggregateUnsigned(curr)
+
+		// Charge the points kept by aggregators buffering them.
+		if mem.budget == nil {
+			continue
+		} else if buf, ok := rp.Aggregator.(pointBuffer); ok {
+			if n := buf.bufferedN(); n > rp.bufferedN {
+				size := int64(n-rp.bufferedN) * bufferedPointSize
+				if err := mem.grow(size); err != nil {
+					return nil, err
+				}
+				rp.bufferedN = n
+			}
+		}
 	}
 
 	keys := make([]string, 0, len(m))
@@ -7520,6 +7900,9 @@ type unsignedReduceBooleanPoint struct {
 	Tags       Tags
 	Aggregator UnsignedPointAggregator
 	Emitter    BooleanPointEmitter
+
+	// The number of points buffered by the aggregator.
+	bufferedN int
 }
 
 // reduce executes fn once for every point in the next window.
@@ -7548,8 +7931,11 @@ func (itr *unsignedReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. The memory of the reducers is charged to the
+	// budget of the query until the window has been reduced.
 	m := make(map[string]*unsignedReduceBooleanPoint)
+	mem := reduceMemory{budget: itr.opt.Memory}
+	defer mem.release()
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
@@ -7589,8 +7975,24 @@ func (itr *unsignedReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 				Emitter:    emitter,
 			}
 			m[id] = rp
+			if err := mem.grow(reducePointSize); err != nil {
+				return nil, err
+			}
 		}
 		rp.Aggregator.AggregateUnsigned(curr)
+
+		// Charge the points kept by aggregators buffering them.
+		if mem.budget == nil {
+			continue
+		} else if buf, ok := rp.Aggregator.(pointBuffer); ok {
+			if n := buf.bufferedN(); n > rp.bufferedN {
+				size := int64(n-rp.bufferedN) * bufferedPointSize
+				if err := mem.grow(size); err != nil {
+					return nil, err
+				}
+				rp.bufferedN = n
+			}
+		}
 	}
 
 	keys := make([]string, 0, len(m))
@@ -8308,6 +8710,9 @@ type stringSortedMergeIterator struct {
 	inputs []StringIterator
 	heap   *stringSortedMergeHeap
 	init   bool
+
+	// The memory of the heap reserved from the budget of the query.
+	reserved int64
 }
 
 // newStringSortedMergeIterator returns an instance of stringSortedMergeIterator.
@@ -8343,6 +8748,8 @@ func (itr *stringSortedMergeIterator) Close() error {
 	for _, input := range itr.inputs {
 		input.Close()
 	}
+	itr.heap.opt.Memory.Release(itr.reserved)
+	itr.reserved = 0
 	return nil
 }
 
@@ -8355,6 +8762,14 @@ func (itr *stringSortedMergeIterator) pop() (*StringPoint, error) {
 	// Initialize the heap. See the MergeIterator to see why this has to be done lazily.
 	if !itr.init {
 		items := itr.heap.items
+
+		// Charge the points buffered by the heap to the budget of the query.
+		size := int64(len(items)) * bufferedPointSize
+		if err := itr.heap.opt.Memory.Reserve(size); err != nil {
+			return nil, err
+		}
+		itr.reserved = size
+
 		itr.heap.items = make([]*stringSortedMergeHeapItem, 0, len(items))
 		for _, item := range items {
 			var err error
@@ -8837,15 +9252,22 @@ CONSTRUCT:
 	// Advance the expected time. Do not advance to a new window here
 	// as there may be lingering points with the same timestamp in the previous
 	// window.
-	if itr.opt.Ascending {
+	if !itr.opt.Interval.Calendar.IsZero() {
+		// Calendar windows vary in length, so move to the adjacent window.
+		if itr.opt.Ascending {
+			_, itr.window.time = itr.opt.Window(itr.window.time)
+		} else {
+			itr.window.time, _ = itr.opt.Window(itr.window.time - 1)
+		}
+	} else if itr.opt.Ascending {
 		itr.window.time += int64(itr.opt.Interval.Duration)
 	} else {
 		itr.window.time -= int64(itr.opt.Interval.Duration)
 	}
 
 	// Check to see if we have passed over an offset change and adjust the time
-	// to account for this new offset.
-	if itr.opt.Location != nil {
+	// to account for this new offset. Calendar windows already account for it.
+	if itr.opt.Location != nil && itr.opt.Interval.Calendar.IsZero() {
 		if _, offset := itr.opt.Zone(itr.window.time - 1); offset != itr.window.offset {
 			diff := itr.window.offset - offset
 			if abs(diff) < int64(itr.opt.Interval.Duration) {
@@ -9018,6 +9440,9 @@ type stringReduceFloatPoint struct {
 	Tags       Tags
 	Aggregator StringPointAggregator
 	Emitter    FloatPointEmitter
+
+	// The number of points buffered by the aggregator.
+	bufferedN int
 }
 
 // reduce executes fn once for every point in the next window.
@@ -9046,8 +9471,11 @@ func (itr *stringReduceFloatIterator) reduce() ([]FloatPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. The memory of the reducers is charged to the
+	// budget of the query until the window has been reduced.
 	m := make(map[string]*stringReduceFloatPoint)
+	mem := reduceMemory{budget: itr.opt.Memory}
+	defer mem.release()
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
@@ -9087,8 +9515,24 @@ func (itr *stringReduceFloatIterator) reduce() ([]FloatPoint, error) {
 				Emitter:    emitter,
 			}
 			m[id] = rp
+			if err := mem.grow(reducePointSize); err != nil {
+				return nil, err
+			}
 		}
 		rp.Aggregator.AggregateString(curr)
+
+		// Charge the points kept by aggregators buffering them.
+		if mem.budget == nil {
+			continue
+		} else if buf, ok := rp.Aggregator.(pointBuffer); ok {
+			if n := buf.bufferedN(); n > rp.bufferedN {
+				size := int64(n-rp.bufferedN)*bufferedPointSize + int64(len(curr.Value))
+				if err := mem.grow(size); err != nil {
+					return nil, err
+				}
+				rp.bufferedN = n
+			}
+		}
 	}
 
 	keys := make([]string, 0, len(m))
@@ -9306,6 +9750,9 @@ type stringReduceIntegerPoint struct {
 	Tags       Tags
 	Aggregator StringPointAggregator
 	Emitter    IntegerPointEmitter
+
+	// The number of points buffered by the aggregator.
+	bufferedN int
 }
 
 // reduce executes fn once for every point in the next window.
@@ -9334,8 +9781,11 @@ func (itr *stringReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. The memory of the reducers is charged to the
+	// budget of the query until the window has been reduced.
 	m := make(map[string]*stringReduceIntegerPoint)
+	mem := reduceMemory{budget: itr.opt.Memory}
+	defer mem.release()
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
@@ -9375,8 +9825,24 @@ func (itr *stringReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 				Emitter:    emitter,
 			}
 			m[id] = rp
+			if err := mem.grow(reducePointSize); err != nil {
+				return nil, err
+			}
 		}
 		rp.Aggregator.AggregateString(curr)
+
+		// Charge the points kept by aggregators buffering them.
+		if mem.budget == nil {
+			continue
+		} else if buf, ok := rp.Aggregator.(pointBuffer); ok {
+			if n := buf.bufferedN(); n > rp.bufferedN {
+				size := int64(n-rp.bufferedN)*bufferedPointSize + int64(len(curr.Value))
+				if err := mem.grow(size); err != nil {
+					return nil, err
+				}
+				rp.bufferedN = n
+			}
+		}
 	}
 
 	keys := make([]string, 0, len(m))
@@ -9594,6 +10060,9 @@ type stringReduceUnsignedPoint struct {
 	Tags       Tags
 	Aggregator StringPointAggregator
 	Emitter    UnsignedPointEmitter
+
+	// The number of points buffered by the aggregator.
+	bufferedN int
 }
 
 // reduce executes fn once for every point in the next window.
@@ -9622,8 +10091,11 @@ func (itr *stringReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. The memory of the reducers is charged to the
+	// budget of the query until the window has been reduced.
 	m := make(map[string]*stringReduceUnsignedPoint)
+	mem := reduceMemory{budget: itr.opt.Memory}
+	defer mem.release()
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
@@ -9663,8 +10135,24 @@ func (itr *stringReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 				Emitter:    emitter,
 			}
 			m[id] = rp
+			if err := mem.grow(reducePointSize); err != nil {
+				return nil, err
+			}
 		}
 		rp.Aggregator.AggregateString(curr)
+
+		// Charge the points kept by aggregators buffering them.
+		if mem.budget == nil {
+			continue
+		} else if buf, ok := rp.Aggregator.(pointBuffer); ok {
+			if n := buf.bufferedN(); n > rp.bufferedN {
+				size := int64(n-rp.bufferedN)*bufferedPointSize + int64(len(curr.Value))
+				if err := mem.grow(size); err != nil {
+					return nil, err
+				}
+				rp.bufferedN = n
+			}
+		}
 	}
 
 	keys := make([]string, 0, len(m))
@@ -9882,6 +10370,9 @@ type stringReduceStringPoint struct {
 	Tags       Tags
 	Aggregator StringPointAggregator
 	Emitter    StringPointEmitter
+
+	// The number of points buffered by the aggregator.
+	bufferedN int
 }
 
 // reduce executes fn once for every point in the next window.
@@ -9910,8 +10401,11 @@ func (itr *stringReduceStringIterator) reduce() ([]StringPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. The memory of the reducers is charged to the
+	// budget of the query until the window has been reduced.
 	m := make(map[string]*stringReduceStringPoint)
+	mem := reduceMemory{budget: itr.opt.Memory}
+	defer mem.release()
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
@@ -9951,8 +10445,24 @@ func (itr *stringReduceStringIterator) reduce() ([]StringPoint, error) {
 				Emitter:    emitter,
 			}
 			m[id] = rp
+			if err := mem.grow(reducePointSize); err != nil {
+				return nil, err
+			}
 		}
 		rp.Aggregator.AggregateString(curr)
+
+		// Charge the points kept by aggregators buffering them.
+		if mem.budget == nil {
+			continue
+		} else if buf, ok := rp.Aggregator.(pointBuffer); ok {
+			if n := buf.bufferedN(); n > rp.bufferedN {
+				size := int64(n-rp.bufferedN)*bufferedPointSize + int64(len(curr.Value))
+				if err := mem.grow(size); err != nil {
+					return nil, err
+				}
+				rp.bufferedN = n
+			}
+		}
 	}
 
 	keys := make([]string, 0, len(m))
@@ -10170,6 +10680,9 @@ type stringReduceBooleanPoint struct {
 	Tags       Tags
 	Aggregator StringPointAggregator
 	Emitter    BooleanPointEmitter
+
+	// The number of points buffered by the aggregator.
+	bufferedN int
 }
 
 // reduce executes fn once for every point in the next window.
@@ -10198,8 +10711,11 @@ func (itr *stringReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. The memory of the reducers is charged to the
+	// budget of the query until the window has been reduced.
 	m := make(map[string]*stringReduceBooleanPoint)
+	mem := reduceMemory{budget: itr.opt.Memory}
+	defer mem.release()
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
@@ -10239,8 +10755,24 @@ func (itr *stringReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 				Emitter:    emitter,
 			}
 			m[id] = rp
+			if err := mem.grow(reducePointSize); err != nil {
+				return nil, err
+			}
 		}
 		rp.Aggregator.AggregateString(curr)
+
+		// Charge the points kept by aggregators buffering them.
+		if mem.budget == nil {
+			continue
+		} else if buf, ok := rp.Aggregator.(pointBuffer); ok {
+			if n := buf.bufferedN(); n > rp.bufferedN {
+				size := int64(n-rp.bufferedN)*bufferedPointSize + int64(len(curr.Value))
+				if err := mem.grow(size); err != nil {
+					return nil, err
+				}
+				rp.bufferedN = n
+			}
+		}
 	}
 
 	keys := make([]string, 0, len(m))
@@ -10958,6 +11490,9 @@ type booleanSortedMergeIterator struct {
 	inputs []BooleanIterator
 	heap   *booleanSortedMergeHeap
 	init   bool
+
+	// The memory of the heap reserved from the budget of the query.
+	reserved int64
 }
 
 // newBooleanSortedMergeIterator returns an instance of booleanSortedMergeIterator.
@@ -10993,6 +11528,8 @@ func (itr *booleanSortedMergeIterator) Close() error {
 	for _, input := range itr.inputs {
 		input.Close()
 	}
+	itr.heap.opt.Memory.Release(itr.reserved)
+	itr.reserved = 0
 	return nil
 }
 
@@ -11005,6 +11542,14 @@ func (itr *booleanSortedMergeIterator) pop() (*BooleanPoint, error) {
 	// Initialize the heap. See the MergeIterator to see why this has to be done lazily.
 	if !itr.init {
 		items := itr.heap.items
+
+		// Charge the points buffered by the heap to the budget of the query.
+		size := int64(len(items)) * bufferedPointSize
+		if err := itr.heap.opt.Memory.Reserve(size); err != nil {
+			return nil, err
+		}
+		itr.reserved = size
+
 		itr.heap.items = make([]*booleanSortedMergeHeapItem, 0, len(items))
 		for _, item := range items {
 			var err error
@@ -11487,15 +12032,22 @@ CONSTRUCT:
 	// Advance the expected time. Do not advance to a new window here
 	// as there may be lingering points with the same timestamp in the previous
 	// window.
-	if itr.opt.Ascending {
+	if !itr.opt.Interval.Calendar.IsZero() {
+		// Calendar windows vary in length, so move to the adjacent window.
+		if itr.opt.Ascending {
+			_, itr.window.time = itr.opt.Window(itr.window.time)
+		} else {
+			itr.window.time, _ = itr.opt.Window(itr.window.time - 1)
+		}
+	} else if itr.opt.Ascending {
 		itr.window.time += int64(itr.opt.Interval.Duration)
 	} else {
 		itr.window.time -= int64(itr.opt.Interval.Duration)
 	}
 
 	// Check to see if we have passed over an offset change and adjust the time
-	// to account for this new offset.
-	if itr.opt.Location != nil {
+	// to account for this new offset. Calendar windows already account for it.
+	if itr.opt.Location != nil && itr.opt.Interval.Calendar.IsZero() {
 		if _, offset := itr.opt.Zone(itr.window.time - 1); offset != itr.window.offset {
 			diff := itr.window.offset - offset
 			if abs(diff) < int64(itr.opt.Interval.Duration) {
@@ -11668,6 +12220,9 @@ type booleanReduceFloatPoint struct {
 	Tags       Tags
 	Aggregator BooleanPointAggregator
 	Emitter    FloatPointEmitter
+
+	// The number of points buffered by the aggregator.
+	bufferedN int
 }
 
 // reduce executes fn once for every point in the next window.
@@ -11696,8 +12251,11 @@ func (itr *booleanReduceFloatIterator) reduce() ([]FloatPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. The memory of the reducers is charged to the
+	// budget of the query until the window has been reduced.
 	m := make(map[string]*booleanReduceFloatPoint)
+	mem := reduceMemory{budget: itr.opt.Memory}
+	defer mem.release()
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
@@ -11737,8 +12295,24 @@ func (itr *booleanReduceFloatIterator) reduce() ([]FloatPoint, error) {
 				Emitter:    emitter,
 			}
 			m[id] = rp
+			if err := mem.grow(reducePointSize); err != nil {
+				return nil, err
+			}
 		}
 		rp.Aggregator.AggregateBoolean(curr)
+
+		// Charge the points kept by aggregators buffering them.
+		if mem.budget == nil {
+			continue
+		} else if buf, ok := rp.Aggregator.(pointBuffer); ok {
+			if n := buf.bufferedN(); n > rp.bufferedN {
+				size := int64(n-rp.bufferedN) * bufferedPointSize
+				if err := mem.grow(size); err != nil {
+					return nil, err
+				}
+				rp.bufferedN = n
+			}
+		}
 	}
 
 	keys := make([]string, 0, len(m))
@@ -11956,6 +12530,9 @@ type booleanReduceIntegerPoint struct {
 	Tags       Tags
 	Aggregator BooleanPointAggregator
 	Emitter    IntegerPointEmitter
+
+	// The number of points buffered by the aggregator.
+	bufferedN int
 }
 
 // reduce executes fn once for every point in the next window.
@@ -11984,8 +12561,11 @@ func (itr *booleanReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. The memory of the reducers is charged to the
+	// budget of the query until the window has been reduced.
 	m := make(map[string]*booleanReduceIntegerPoint)
+	mem := reduceMemory{budget: itr.opt.Memory}
+	defer mem.release()
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
@@ -12025,8 +12605,24 @@ func (itr *booleanReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 				Emitter:    emitter,
 			}
 			m[id] = rp
+			if err := mem.grow(reducePointSize); err != nil {
+				return nil, err
+			}
 		}
 		rp.Aggregator.AggregateBoolean(curr)
+
+		// Charge the points kept by aggregators buffering them.
+		if mem.budget == nil {
+			continue
+		} else if buf, ok := rp.Aggregator.(pointBuffer); ok {
+			if n := buf.bufferedN(); n > rp.bufferedN {
+				size := int64(n-rp.bufferedN) * bufferedPointSize
+				if err := mem.grow(size); err != nil {
+					return nil, err
+				}
+				rp.bufferedN = n
+			}
+		}
 	}
 
 	keys := make([]string, 0, len(m))
@@ -12244,6 +12840,9 @@ type booleanReduceUnsignedPoint struct {
 	Tags       Tags
 	Aggregator BooleanPointAggregator
 	Emitter    UnsignedPointEmitter
+
+	// The number of points buffered by the aggregator.
+	bufferedN int
 }
 
 // reduce executes fn once for every point in the next window.
@@ -12272,8 +12871,11 @@ func (itr *booleanReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. The memory of the reducers is charged to the
+	// budget of the query until the window has been reduced.
 	m := make(map[string]*booleanReduceUnsignedPoint)
+	mem := reduceMemory{budget: itr.opt.Memory}
+	defer mem.release()
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
@@ -12313,8 +12915,24 @@ func (itr *booleanReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 				Emitter:    emitter,
 			}
 			m[id] = rp
+			if err := mem.grow(reducePointSize); err != nil {
+				return nil, err
+			}
 		}
 		rp.Aggregator.AggregateBoolean(curr)
+
+		// Charge the points kept by aggregators buffering them.
+		if mem.budget == nil {
+			continue
+		} else if buf, ok := rp.Aggregator.(pointBuffer); ok {
+			if n := buf.bufferedN(); n > rp.bufferedN {
+				size := int64(n-rp.bufferedN) * bufferedPointSize
+				if err := mem.grow(size); err != nil {
+					return nil, err
+				}
+				rp.bufferedN = n
+			}
+		}
 	}
 
 	keys := make([]string, 0, len(m))
@@ -12532,6 +13150,9 @@ type booleanReduceStringPoint struct {
 	Tags       Tags
 	Aggregator BooleanPointAggregator
 	Emitter    StringPointEmitter
+
+	// The number of points buffered by the aggregator.
+	bufferedN int
 }
 
 // reduce executes fn once for every point in the next window.
@@ -12560,8 +13181,11 @@ func (itr *booleanReduceStringIterator) reduce() ([]StringPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. The memory of the reducers is charged to the
+	// budget of the query until the window has been reduced.
 	m := make(map[string]*booleanReduceStringPoint)
+	mem := reduceMemory{budget: itr.opt.Memory}
+	defer mem.release()
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
@@ -12601,8 +13225,24 @@ func (itr *booleanReduceStringIterator) reduce() ([]StringPoint, error) {
 				Emitter:    emitter,
 			}
 			m[id] = rp
+			if err := mem.grow(reducePointSize); err != nil {
+				return nil, err
+			}
 		}
 		rp.Aggregator.AggregateBoolean(curr)
+
+		// Charge the points kept by aggregators buffering them.
+		if mem.budget == nil {
+			continue
+		} else if buf, ok := rp.Aggregator.(pointBuffer); ok {
+			if n := buf.bufferedN(); n > rp.bufferedN {
+				size := int64(n-rp.bufferedN) * bufferedPointSize
+				if err := mem.grow(size); err != nil {
+					return nil, err
+				}
+				rp.bufferedN = n
+			}
+		}
 	}
 
 	keys := make([]string, 0, len(m))
@@ -12820,6 +13460,9 @@ type booleanReduceBooleanPoint struct {
 	Tags       Tags
 	Aggregator BooleanPointAggregator
 	Emitter    BooleanPointEmitter
+
+	// The number of points buffered by the aggregator.
+	bufferedN int
 }
 
 // reduce executes fn once for every point in the next window.
@@ -12848,8 +13491,11 @@ func (itr *booleanReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 		break
 	}
 
-	// Create points by tags.
+	// Create points by tags. The memory of the reducers is charged to the
+	// budget of the query until the window has been reduced.
 	m := make(map[string]*booleanReduceBooleanPoint)
+	mem := reduceMemory{budget: itr.opt.Memory}
+	defer mem.release()
 	for {
 		// Read next point.
 		curr, err := itr.input.NextInWindow(startTime, endTime)
@@ -12889,8 +13535,24 @@ func (itr *booleanReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 				Emitter:    emitter,
 			}
 			m[id] = rp
+			if err := mem.grow(reducePointSize); err != nil {
+				return nil, err
+			}
 		}
 		rp.Aggregator.AggregateBoolean(curr)
+
+		// Charge the points kept by aggregators buffering them.
+		if mem.budget == nil {
+			continue
+		} else if buf, ok := rp.Aggregator.(pointBuffer); ok {
+			if n := buf.bufferedN(); n > rp.bufferedN {
+				size := int64(n-rp.bufferedN) * bufferedPointSize
+				if err := mem.grow(size); err != nil {
+					return nil, err
+				}
+				rp.bufferedN = n
+			}
+		}
 	}
 
 	keys := make([]string, 0, len(m))
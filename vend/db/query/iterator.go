@@ -141,12 +141,29 @@ func (a Iterators) Merge(opt IteratorOptions) (Iterator, error) {
 		return itr, nil
 	}
 
-	// When merging the count() function, use sum() to sum the counted points.
-	if call.Name == "count" {
+	switch call.Name {
+	case "count":
+		// When merging the count() function, use sum() to sum the counted points.
 		opt.Expr = &cnosql.Call{
 			Name: "sum",
 			Args: call.Args,
 		}
+	case "count_distinct_approx", mergeHLLFunction:
+		// Approximate aggregates emit sketches that are merged together.
+		opt.Expr = &cnosql.Call{
+			Name: mergeHLLFunction,
+			Args: call.Args,
+		}
+	case "quantile_approx", mergeDDSketchFunction:
+		opt.Expr = &cnosql.Call{
+			Name: mergeDDSketchFunction,
+			Args: call.Args,
+		}
+	case "histogram", "histogram_buckets", mergeHistogramFunction:
+		opt.Expr = &cnosql.Call{
+			Name: mergeHistogramFunction,
+			Args: call.Args,
+		}
 	}
 	return NewCallIterator(itr, opt)
 }
@@ -619,6 +636,9 @@ type IteratorOptions struct {
 
 	// Authorizer can limit access to data
 	Authorizer FineAuthorizer
+
+	// Memory is the budget charged by iterators buffering points.
+	Memory *MemoryBudget
 }
 
 // newIteratorOptionsStmt creates the iterator options from stmt.
@@ -655,6 +675,10 @@ func newIteratorOptionsStmt(stmt *cnosql.SelectStatement, sopt SelectOptions) (o
 		if err != nil {
 			return opt, err
 		}
+		opt.Interval.Calendar, err = stmt.GroupByCalendar()
+		if err != nil {
+			return opt, err
+		}
 	}
 	opt.Interval.Duration = interval
 
@@ -717,6 +741,7 @@ func newIteratorOptionsSubstatement(ctx context.Context, stmt *cnosql.SelectStat
 		subOpt.GroupBy[d] = struct{}{}
 	}
 	subOpt.InterruptCh = opt.InterruptCh
+	subOpt.Memory = opt.Memory
 
 	// Extract the time range and condition from the condition.
 	valuer := &cnosql.NowValuer{Location: stmt.Location}
@@ -794,6 +819,8 @@ func (opt IteratorOptions) StopTime() int64 {
 func (opt IteratorOptions) Window(t int64) (start, end int64) {
 	if opt.Interval.IsZero() {
 		return opt.StartTime, opt.EndTime + 1
+	} else if !opt.Interval.Calendar.IsZero() {
+		return opt.calendarWindow(t)
 	}
 
 	// Subtract the offset to the time so we calculate the correct base interval.
@@ -870,6 +897,31 @@ func (opt IteratorOptions) Window(t int64) (start, end int64) {
 	return
 }
 
+// calendarWindow returns the calendar interval [start,end) that t falls
+// within. The window is computed on the calendar of the query's time zone,
+// so it always starts and ends at local midnight.
+func (opt IteratorOptions) calendarWindow(t int64) (start, end int64) {
+	loc := opt.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	cal := opt.Interval.Calendar
+	first := cal.Truncate(time.Unix(0, t).In(loc))
+	if first.Before(time.Unix(0, cnosql.MinTime)) {
+		start = cnosql.MinTime
+	} else {
+		start = first.UnixNano()
+	}
+
+	if next := cal.Next(first); next.After(time.Unix(0, cnosql.MaxTime)) {
+		end = cnosql.MaxTime
+	} else {
+		end = next.UnixNano()
+	}
+	return start, end
+}
+
 // DerivativeInterval returns the time interval for the derivative function.
 func (opt IteratorOptions) DerivativeInterval() Interval {
 	// Use the interval on the derivative() call, if specified.
@@ -1144,22 +1196,37 @@ func decodeMeasurement(pb *internal.Measurement) (*cnosql.Measurement, error) {
 type Interval struct {
 	Duration time.Duration
 	Offset   time.Duration
+
+	// Calendar is set when the interval follows the calendar of the query's
+	// time zone. Duration is then the longest duration of the interval.
+	Calendar cnosql.CalendarInterval
 }
 
 // IsZero returns true if the interval has no duration.
 func (i Interval) IsZero() bool { return i.Duration == 0 }
 
 func encodeInterval(i Interval) *internal.Interval {
-	return &internal.Interval{
+	pb := &internal.Interval{
 		Duration: proto.Int64(i.Duration.Nanoseconds()),
 		Offset:   proto.Int64(i.Offset.Nanoseconds()),
 	}
+	if !i.Calendar.IsZero() {
+		pb.Months = proto.Int64(int64(i.Calendar.Months))
+		pb.Weeks = proto.Int64(int64(i.Calendar.Weeks))
+		pb.Weekday = proto.Int64(int64(i.Calendar.Weekday))
+	}
+	return pb
 }
 
 func decodeInterval(pb *internal.Interval) Interval {
 	return Interval{
 		Duration: time.Duration(pb.GetDuration()),
 		Offset:   time.Duration(pb.GetOffset()),
+		Calendar: cnosql.CalendarInterval{
+			Months:  int(pb.GetMonths()),
+			Weeks:   int(pb.GetWeeks()),
+			Weekday: time.Weekday(pb.GetWeekday()),
+		},
 	}
 }
 
@@ -1266,6 +1333,14 @@ type IteratorCost struct {
 
 	// The amount of data that can be potentially read.
 	BlockSize int64
+
+	// The number of joins of the query.
+	NumJoins int64
+
+	// The total number of series read by the sides of joins. A join holds
+	// the rows of the series of both of its sides with the same time in
+	// memory while matching them.
+	JoinedSeries int64
 }
 
 // Combine combines the results of two IteratorCost structures into one.
@@ -1277,9 +1352,20 @@ func (c IteratorCost) Combine(other IteratorCost) IteratorCost {
 		NumFiles:     c.NumFiles + other.NumFiles,
 		BlocksRead:   c.BlocksRead + other.BlocksRead,
 		BlockSize:    c.BlockSize + other.BlockSize,
+		NumJoins:     c.NumJoins + other.NumJoins,
+		JoinedSeries: c.JoinedSeries + other.JoinedSeries,
 	}
 }
 
+// Join returns the cost of joining the rows of the iterators of costs c and
+// other, which are both read entirely.
+func (c IteratorCost) Join(other IteratorCost) IteratorCost {
+	cost := c.Combine(other)
+	cost.NumJoins++
+	cost.JoinedSeries += c.NumSeries + other.NumSeries
+	return cost
+}
+
 // floatFastDedupeIterator outputs unique points where the point has a single aux field.
 type floatFastDedupeIterator struct {
 	input FloatIterator
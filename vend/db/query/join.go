@@ -0,0 +1,360 @@
+package query
+
+import (
+	"context"
+
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+)
+
+// joinBuilder builds the iterators of a join. The sides of the join are the
+// subqueries planned by planJoin, so the columns of the rows of a side are
+// the fields of its statement.
+type joinBuilder struct {
+	ic   IteratorCreator
+	join *cnosql.Join
+}
+
+// buildAuxIterator constructs an auxiliary Iterator from a join.
+func (b *joinBuilder) buildAuxIterator(ctx context.Context, opt IteratorOptions) (Iterator, error) {
+	indexes := b.mapAuxFields(opt.Aux)
+
+	cur, err := b.buildCursor(ctx, opt)
+	if err != nil {
+		return nil, err
+	}
+	return b.mapCursor(cur, nil, indexes, opt), nil
+}
+
+func (b *joinBuilder) buildVarRefIterator(ctx context.Context, expr *cnosql.VarRef, opt IteratorOptions) (Iterator, error) {
+	// Look for the column driving this query. Without one there are no
+	// results.
+	driver := b.mapAuxField(expr)
+	if driver == nil {
+		return nil, nil
+	}
+
+	indexes := b.mapAuxFields(opt.Aux)
+	cur, err := b.buildCursor(ctx, opt)
+	if err != nil {
+		return nil, err
+	}
+	return b.mapCursor(cur, driver, indexes, opt), nil
+}
+
+// mapCursor filters the rows of the join by the condition of the query and
+// constructs an iterator of their values.
+func (b *joinBuilder) mapCursor(cur Cursor, driver IteratorMap, indexes []IteratorMap, opt IteratorOptions) Iterator {
+	if opt.Condition != nil {
+		cur = newFilterCursor(cur, opt.Condition)
+	}
+
+	itr := NewIteratorMapper(cur, driver, indexes, opt)
+	if len(opt.GetDimensions()) != len(b.join.On) {
+		itr = NewTagSubsetIterator(itr, opt)
+	}
+	return itr
+}
+
+func (b *joinBuilder) mapAuxFields(auxFields []cnosql.VarRef) []IteratorMap {
+	indexes := make([]IteratorMap, len(auxFields))
+	for i, name := range auxFields {
+		m := b.mapAuxField(&name)
+		if m == nil {
+			// If this field doesn't map to anything, use the NullMap so it
+			// shows up as null.
+			m = NullMap{}
+		}
+		indexes[i] = m
+	}
+	return indexes
+}
+
+// mapAuxField maps a join key to the tag of the rows of the join, and a
+// column qualified by the name of a side to the values of the side.
+func (b *joinBuilder) mapAuxField(name *cnosql.VarRef) IteratorMap {
+	if b.join.IsKey(name.Val) {
+		return TagMap(name.Val)
+	}
+
+	side, column := b.join.Column(name.Val)
+	if side == nil {
+		return nil
+	}
+	offset := 0
+	if side == b.join.Right {
+		offset = len(joinStatement(b.join.Left).Fields)
+	}
+	for i, f := range joinStatement(side).Fields {
+		if f.Name() == column {
+			return FieldMap{
+				Index: offset + i,
+				// Cast the value of the column into the desired type.
+				Type: name.Type,
+			}
+		}
+	}
+	return nil
+}
+
+// joinStatement returns the statement reading the rows of a side of a join.
+func joinStatement(side *cnosql.JoinSource) *cnosql.SelectStatement {
+	return side.Source.(*cnosql.SubQuery).Statement
+}
+
+// buildCursor constructs the cursor of the rows of the join. The rows of
+// both sides are read ordered by the dimensions of the query and time, so
+// they are matched a time of a group of series at a time.
+func (b *joinBuilder) buildCursor(ctx context.Context, opt IteratorOptions) (Cursor, error) {
+	// The series limits of the query apply to the series of the join, not
+	// to those of its sides.
+	opt.Ordered = true
+	opt.SLimit, opt.SOffset = 0, 0
+
+	// Record the cost of the join when explaining the query.
+	e, explain := b.ic.(*explainIteratorCreator)
+	var start, mid int
+	if explain {
+		start = len(e.nodes)
+	}
+
+	left, err := b.buildSide(ctx, b.join.Left, opt)
+	if err != nil {
+		return nil, err
+	}
+	if explain {
+		mid = len(e.nodes)
+	}
+	right, err := b.buildSide(ctx, b.join.Right, opt)
+	if err != nil {
+		left.Close()
+		return nil, err
+	}
+	if explain {
+		e.explainJoin(b.join, start, mid)
+	}
+	return newJoinCursor(b.join, left, right, opt), nil
+}
+
+func (b *joinBuilder) buildSide(ctx context.Context, side *cnosql.JoinSource, opt IteratorOptions) (Cursor, error) {
+	stmt := joinStatement(side)
+	subOpt, err := newIteratorOptionsSubstatement(ctx, stmt, opt)
+	if err != nil {
+		return nil, err
+	}
+	return buildCursor(ctx, stmt, b.ic, subOpt)
+}
+
+// joinSide reads the rows of a side of a join.
+type joinSide struct {
+	cur Cursor
+
+	// row is the next row of the side if peeked is set.
+	row    Row
+	peeked bool
+	done   bool
+}
+
+// peek returns the next row of the side without reading it, or nil if there
+// are no more rows.
+func (s *joinSide) peek() *Row {
+	if !s.peeked && !s.done {
+		if s.cur.Scan(&s.row) {
+			s.peeked = true
+		} else {
+			s.done = true
+		}
+	}
+	if s.done {
+		return nil
+	}
+	return &s.row
+}
+
+// joinCursor matches the rows of two cursors with the same time and the same
+// values of the keys of a join.
+type joinCursor struct {
+	typ       cnosql.JoinType
+	keys      []string
+	dims      []string
+	ascending bool
+
+	left, right *joinSide
+	columns     []cnosql.VarRef
+	// nleft is the number of columns of the left side.
+	nleft int
+
+	// rows holds the joined rows of the last time read.
+	rows   []Row
+	series Series
+	err    error
+}
+
+func newJoinCursor(j *cnosql.Join, left, right Cursor, opt IteratorOptions) *joinCursor {
+	cur := &joinCursor{
+		typ:       j.Type,
+		keys:      j.On,
+		dims:      opt.GetDimensions(),
+		ascending: opt.Ascending,
+		left:      &joinSide{cur: left},
+		right:     &joinSide{cur: right},
+	}
+	cur.columns = append(joinColumns(j.Left, left), joinColumns(j.Right, right)...)
+	cur.nleft = len(left.Columns())
+	return cur
+}
+
+// joinColumns returns the columns of the cursor of a side of a join,
+// qualified by the name of the side.
+func joinColumns(side *cnosql.JoinSource, cur Cursor) []cnosql.VarRef {
+	columns := make([]cnosql.VarRef, 0, len(cur.Columns()))
+	for _, col := range cur.Columns() {
+		columns = append(columns, cnosql.VarRef{
+			Val:  side.Name() + "." + col.Val,
+			Type: col.Type,
+		})
+	}
+	return columns
+}
+
+func (cur *joinCursor) Scan(row *Row) bool {
+	for len(cur.rows) == 0 {
+		if !cur.next() {
+			return false
+		}
+	}
+
+	*row = cur.rows[0]
+	if row.Series.Name != cur.series.Name || !row.Series.Tags.Equals(&cur.series.Tags) {
+		cur.series.Name = row.Series.Name
+		cur.series.Tags = row.Series.Tags
+		cur.series.id++
+	}
+	row.Series = cur.series
+	cur.rows = cur.rows[1:]
+	return true
+}
+
+// next joins the rows of both sides with the next time of the next group of
+// series. It returns false once both sides are read.
+func (cur *joinCursor) next() bool {
+	l, r := cur.left.peek(), cur.right.peek()
+	if l == nil && r == nil {
+		if err := cur.left.cur.Err(); err != nil {
+			cur.err = err
+		} else if err := cur.right.cur.Err(); err != nil {
+			cur.err = err
+		}
+		return false
+	}
+
+	// Read the side whose rows come first.
+	next := l
+	if l == nil || (r != nil && cur.less(r, l)) {
+		next = r
+	}
+	id, t := next.Series.Tags.Subset(cur.dims).ID(), next.Time
+
+	cur.join(cur.read(cur.left, id, t), cur.read(cur.right, id, t), t)
+	return true
+}
+
+// less returns true if row a comes before row b in the order of the query.
+func (cur *joinCursor) less(a, b *Row) bool {
+	aid, bid := a.Series.Tags.Subset(cur.dims).ID(), b.Series.Tags.Subset(cur.dims).ID()
+	if cur.ascending {
+		return aid < bid || (aid == bid && a.Time < b.Time)
+	}
+	return aid > bid || (aid == bid && a.Time > b.Time)
+}
+
+// read reads the rows of a side with time t in the group of series id.
+func (cur *joinCursor) read(s *joinSide, id string, t int64) []Row {
+	var rows []Row
+	for row := s.peek(); row != nil; row = s.peek() {
+		if row.Time != t || row.Series.Tags.Subset(cur.dims).ID() != id {
+			break
+		}
+		// Cursors reuse the values of the rows they scan.
+		rows = append(rows, Row{
+			Time:   row.Time,
+			Series: row.Series,
+			Values: append([]interface{}(nil), row.Values...),
+		})
+		s.peeked = false
+	}
+	return rows
+}
+
+// join matches the rows of both sides on the keys of the join.
+func (cur *joinCursor) join(left, right []Row, t int64) {
+	index := make(map[string][]int, len(right))
+	for i := range right {
+		id := right[i].Series.Tags.Subset(cur.keys).ID()
+		index[id] = append(index[id], i)
+	}
+
+	matched := make([]bool, len(right))
+	for i := range left {
+		found := false
+		for _, j := range index[left[i].Series.Tags.Subset(cur.keys).ID()] {
+			cur.emit(&left[i], &right[j], t)
+			matched[j], found = true, true
+		}
+		if !found && (cur.typ == cnosql.LeftJoin || cur.typ == cnosql.FullJoin) {
+			cur.emit(&left[i], nil, t)
+		}
+	}
+	if cur.typ == cnosql.RightJoin || cur.typ == cnosql.FullJoin {
+		for j := range right {
+			if !matched[j] {
+				cur.emit(nil, &right[j], t)
+			}
+		}
+	}
+}
+
+// emit appends the row of the values of the left and the right row, the
+// values of a missing side being null.
+func (cur *joinCursor) emit(left, right *Row, t int64) {
+	n := cur.nleft
+	values := make([]interface{}, len(cur.columns))
+
+	var tags Tags
+	if left != nil {
+		copy(values[:n], left.Values)
+		tags = left.Series.Tags.Subset(cur.keys)
+	}
+	if right != nil {
+		copy(values[n:], right.Values)
+		if left == nil {
+			tags = right.Series.Tags.Subset(cur.keys)
+		}
+	}
+	cur.rows = append(cur.rows, Row{
+		Time:   t,
+		Series: Series{Tags: tags},
+		Values: values,
+	})
+}
+
+func (cur *joinCursor) Stats() IteratorStats {
+	stats := cur.left.cur.Stats()
+	stats.Add(cur.right.cur.Stats())
+	return stats
+}
+
+func (cur *joinCursor) Err() error {
+	return cur.err
+}
+
+func (cur *joinCursor) Columns() []cnosql.VarRef {
+	return cur.columns
+}
+
+func (cur *joinCursor) Close() error {
+	err := cur.left.cur.Close()
+	if e := cur.right.cur.Close(); e != nil && err == nil {
+		err = e
+	}
+	return err
+}
@@ -5,11 +5,12 @@ import (
 	"math"
 
 	"github.com/cnosdb/cnosdb/vend/cnosql"
+	"github.com/cnosdb/cnosdb/vend/db/pkg/geo"
 )
 
 func isMathFunction(call *cnosql.Call) bool {
 	switch call.Name {
-	case "abs", "sin", "cos", "tan", "asin", "acos", "atan", "atan2", "exp", "log", "ln", "log2", "log10", "sqrt", "pow", "floor", "ceil", "round":
+	case "abs", "sin", "cos", "tan", "asin", "acos", "atan", "atan2", "exp", "log", "ln", "log2", "log10", "sqrt", "pow", "floor", "ceil", "round", "geo_distance":
 		return true
 	}
 	return false
@@ -67,6 +68,15 @@ func (MathTypeMapper) CallType(name string, args []cnosql.DataType) (cnosql.Data
 		default:
 			return cnosql.Unknown, fmt.Errorf("invalid argument type for the second argument in %s(): %s", name, arg1)
 		}
+	case "geo_distance":
+		for i, arg := range args {
+			switch arg {
+			case cnosql.Float, cnosql.Integer, cnosql.Unsigned, cnosql.Unknown:
+			default:
+				return cnosql.Unknown, fmt.Errorf("invalid argument type for argument %d in %s(): %s", i+1, name, arg)
+			}
+		}
+		return cnosql.Float, nil
 	case "abs", "floor", "ceil", "round":
 		var arg0 cnosql.DataType
 		if len(args) > 0 {
@@ -208,6 +218,13 @@ func (v MathValuer) Call(name string, args []interface{}) (interface{}, bool) {
 			}
 			return nil, true
 		}
+	} else if len(args) == 4 && name == "geo_distance" {
+		lat1, lon1, ok1 := asFloats(args[0], args[1])
+		lat2, lon2, ok2 := asFloats(args[2], args[3])
+		if ok1 && ok2 {
+			return geo.Distance(lat1, lon1, lat2, lon2), true
+		}
+		return nil, true
 	}
 	return nil, false
 }
@@ -0,0 +1,109 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+const (
+	// bufferedPointSize is the estimated number of bytes of a point buffered
+	// by an iterator. The name and the tags of points are shared between the
+	// points of a series and are not included.
+	bufferedPointSize = 64
+
+	// reducePointSize is the estimated number of bytes used by the reducer
+	// of a name/tag combination within a window.
+	reducePointSize = 256
+)
+
+// ErrMaxSelectMemoryLimitExceeded is an error when a query needs more memory
+// than it is allowed to use.
+func ErrMaxSelectMemoryLimitExceeded(n, limit int64) error {
+	return fmt.Errorf("max-select-memory limit exceeded: (%d/%d)", n, limit)
+}
+
+// MemoryBudget accounts for the memory buffered by the iterators of a query.
+// Iterators reserve the memory of the points they buffer before buffering
+// them and release it when the points are no longer needed.
+//
+// A nil MemoryBudget is unlimited.
+type MemoryBudget struct {
+	limit int64
+	used  int64
+
+	// SpillDir is the directory where iterators spill their buffers to
+	// temporary files when they are over budget. The default directory for
+	// temporary files is used if it is empty.
+	SpillDir string
+}
+
+// NewMemoryBudget returns a MemoryBudget of limit bytes.
+func NewMemoryBudget(limit int64, spillDir string) *MemoryBudget {
+	return &MemoryBudget{limit: limit, SpillDir: spillDir}
+}
+
+// Reserve reserves n bytes or returns an error if the budget would be
+// exceeded.
+func (b *MemoryBudget) Reserve(n int64) error {
+	if b == nil {
+		return nil
+	}
+	if used := atomic.AddInt64(&b.used, n); used > b.limit {
+		atomic.AddInt64(&b.used, -n)
+		return ErrMaxSelectMemoryLimitExceeded(used, b.limit)
+	}
+	return nil
+}
+
+// Release returns n reserved bytes to the budget.
+func (b *MemoryBudget) Release(n int64) {
+	if b == nil {
+		return
+	}
+	atomic.AddInt64(&b.used, -n)
+}
+
+// Used returns the number of reserved bytes.
+func (b *MemoryBudget) Used() int64 {
+	if b == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&b.used)
+}
+
+// MemoryBudgetFromContext returns the MemoryBudget embedded within the
+// Context if one exists.
+func MemoryBudgetFromContext(ctx context.Context) *MemoryBudget {
+	b, _ := ctx.Value(memoryBudgetContextKey{}).(*MemoryBudget)
+	return b
+}
+
+// pointBuffer is implemented by aggregators that keep the points they have
+// aggregated in memory.
+type pointBuffer interface {
+	// bufferedN returns the number of points kept by the aggregator.
+	bufferedN() int
+}
+
+// reduceMemory is the memory reserved by a reduce iterator for the window
+// it is reducing.
+type reduceMemory struct {
+	budget *MemoryBudget
+	size   int64
+}
+
+// grow reserves n more bytes for the window.
+func (m *reduceMemory) grow(n int64) error {
+	if err := m.budget.Reserve(n); err != nil {
+		return err
+	}
+	m.size += n
+	return nil
+}
+
+// release releases the memory of the window once it has been reduced.
+func (m *reduceMemory) release() {
+	m.budget.Release(m.size)
+	m.size = 0
+}
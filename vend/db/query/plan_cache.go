@@ -0,0 +1,215 @@
+package query
+
+import (
+	"container/list"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+)
+
+// DefaultPlanCacheSize is the default number of compiled statements kept by
+// a PlanCache.
+const DefaultPlanCacheSize = 1000
+
+// PlanCache is a least recently used cache of compiled SELECT statements. The
+// statements are keyed by their normalized query text, so a statement that
+// is executed again is not compiled again.
+//
+// The cached statements are compiled without their conditions, which are
+// bound to the current time and to the values of the bound parameters of a
+// prepared query each time the statement is executed. The statements calling
+// now() outside of their conditions are not cached. The cache must be purged
+// when the meta data changes.
+type PlanCache struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	plans map[string]*list.Element
+
+	hits   int64
+	misses int64
+}
+
+type planCacheEntry struct {
+	key  string
+	stmt *compiledStatement
+}
+
+// NewPlanCache returns a PlanCache holding at most size statements.
+func NewPlanCache(size int) *PlanCache {
+	return &PlanCache{
+		size:  size,
+		ll:    list.New(),
+		plans: make(map[string]*list.Element),
+	}
+}
+
+// Compile returns the compiled statement from the cache or compiles it. A nil
+// PlanCache compiles every statement.
+func (c *PlanCache) Compile(stmt *cnosql.SelectStatement, opt CompileOptions) (Statement, error) {
+	if c == nil {
+		return Compile(stmt, opt)
+	}
+	if opt.Now.IsZero() {
+		opt.Now = time.Now().UTC()
+	}
+
+	// The fields of a statement are compiled with the values of their
+	// parameters, so those values are part of the key.
+	if hasFieldParameters(stmt) {
+		stmt = stmt.Clone()
+		if err := cnosql.BindParameters(stmt, opt.Params); err != nil {
+			return nil, err
+		}
+	}
+
+	key := planCacheKey(stmt)
+	plan, ok := c.get(key)
+	if ok {
+		atomic.AddInt64(&c.hits, 1)
+	} else {
+		atomic.AddInt64(&c.misses, 1)
+
+		var err error
+		if plan, err = compileStatement(stmt, opt); err != nil {
+			return nil, err
+		}
+		if !plan.UsesNow {
+			c.put(key, plan)
+		}
+	}
+	return plan.bind(opt)
+}
+
+// hasFieldParameters returns true if a bound parameter is kept outside of the
+// conditions of the statement or of its subqueries.
+func hasFieldParameters(stmt *cnosql.SelectStatement) bool {
+	var found bool
+	fn := func(n cnosql.Node) {
+		if _, ok := n.(*cnosql.BoundParameter); ok {
+			found = true
+		}
+	}
+	cnosql.WalkFunc(stmt, func(n cnosql.Node) {
+		if stmt, ok := n.(*cnosql.SelectStatement); ok {
+			cnosql.WalkFunc(stmt.Fields, fn)
+			cnosql.WalkFunc(stmt.Dimensions, fn)
+			if stmt.Having != nil {
+				cnosql.WalkFunc(stmt.Having, fn)
+			}
+		}
+	})
+	return found
+}
+
+func (c *PlanCache) get(key string) (*compiledStatement, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.plans[key]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(e)
+	return e.Value.(*planCacheEntry).stmt, true
+}
+
+func (c *PlanCache) put(key string, stmt *compiledStatement) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.plans[key]; ok {
+		c.ll.MoveToFront(e)
+		e.Value.(*planCacheEntry).stmt = stmt
+		return
+	}
+	c.plans[key] = c.ll.PushFront(&planCacheEntry{key: key, stmt: stmt})
+
+	for c.ll.Len() > c.size {
+		e := c.ll.Back()
+		c.ll.Remove(e)
+		delete(c.plans, e.Value.(*planCacheEntry).key)
+	}
+}
+
+// Purge removes all of the statements from the cache.
+func (c *PlanCache) Purge() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll.Init()
+	c.plans = make(map[string]*list.Element)
+}
+
+// Hits returns the number of statements whose compiled plan was cached.
+func (c *PlanCache) Hits() int64 {
+	if c == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&c.hits)
+}
+
+// Misses returns the number of statements that were compiled.
+func (c *PlanCache) Misses() int64 {
+	if c == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&c.misses)
+}
+
+// Len returns the number of statements in the cache.
+func (c *PlanCache) Len() int {
+	if c == nil {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// CacheKey returns the normalized text of a node used to identify it in a
+// cache. Numbers are formatted with a limited precision by the node, so their
+// exact values are appended to the text.
+func CacheKey(node cnosql.Node) string {
+	if node == nil {
+		return ""
+	}
+
+	var buf strings.Builder
+	buf.WriteString(node.String())
+	cnosql.WalkFunc(node, func(n cnosql.Node) {
+		if lit, ok := n.(*cnosql.NumberLiteral); ok {
+			buf.WriteByte(0)
+			buf.WriteString(strconv.FormatFloat(lit.Val, 'g', -1, 64))
+		}
+	})
+	return buf.String()
+}
+
+// planCacheKey returns the key of a statement in a PlanCache. The bound
+// parameters of a prepared query are part of the text as placeholders, so the
+// executions of the query with different values share a key. The flags set
+// on statements that are rewritten, such as the statements of SHOW commands,
+// are not part of their text and are appended to it.
+func planCacheKey(stmt *cnosql.SelectStatement) string {
+	var buf strings.Builder
+	buf.WriteString(CacheKey(stmt))
+	cnosql.WalkFunc(stmt, func(n cnosql.Node) {
+		switch n := n.(type) {
+		case *cnosql.SelectStatement:
+			fmt.Fprintf(&buf, "\x00%t,%t,%t,%t,%q,%q", n.IsRawQuery, n.OmitTime, n.StripName, n.Dedupe, n.TimeAlias, n.EmitName)
+		case *cnosql.Measurement:
+			fmt.Fprintf(&buf, "\x00%q,%q", n.Name, n.SystemIterator)
+		}
+	})
+	return buf.String()
+}
@@ -0,0 +1,164 @@
+package query_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+	"github.com/cnosdb/cnosdb/vend/db/query"
+)
+
+func TestPlanCache_Compile(t *testing.T) {
+	compile := func(c *query.PlanCache, s string) {
+		t.Helper()
+		stmt, err := cnosql.ParseStatement(s)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if _, err := c.Compile(stmt.(*cnosql.SelectStatement), query.CompileOptions{}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	expectStats := func(c *query.PlanCache, hits, misses int64, n int) {
+		t.Helper()
+		if got := c.Hits(); got != hits {
+			t.Fatalf("unexpected number of hits: got=%d exp=%d", got, hits)
+		} else if got := c.Misses(); got != misses {
+			t.Fatalf("unexpected number of misses: got=%d exp=%d", got, misses)
+		} else if got := c.Len(); got != n {
+			t.Fatalf("unexpected number of cached statements: got=%d exp=%d", got, n)
+		}
+	}
+
+	c := query.NewPlanCache(2)
+	compile(c, `SELECT value FROM cpu WHERE value > 1.0001 AND time >= '2000-01-01T00:00:00Z'`)
+	compile(c, `SELECT value FROM cpu WHERE value > 1.0001 AND time >= '2000-01-01T00:00:00Z'`)
+	expectStats(c, 1, 1, 1)
+
+	// Statements with different numbers are compiled separately.
+	compile(c, `SELECT value FROM cpu WHERE value > 1.0002 AND time >= '2000-01-01T00:00:00Z'`)
+	expectStats(c, 1, 2, 2)
+
+	// The least recently used statement is removed first.
+	compile(c, `SELECT value FROM cpu WHERE value > 1.0001 AND time >= '2000-01-01T00:00:00Z'`)
+	compile(c, `SELECT value FROM mem`)
+	compile(c, `SELECT value FROM cpu WHERE value > 1.0001 AND time >= '2000-01-01T00:00:00Z'`)
+	expectStats(c, 3, 3, 2)
+
+	c.Purge()
+	expectStats(c, 3, 3, 0)
+
+	// The conditions of statements are bound to the current time each time
+	// they are executed, so statements calling now() in them are cached.
+	for _, s := range []string{
+		`SELECT value FROM cpu WHERE time > now() - 1h`,
+		`SELECT mean(value) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' GROUP BY time(1m)`,
+	} {
+		compile(c, s)
+		compile(c, s)
+	}
+	expectStats(c, 5, 5, 2)
+
+	// Statements calling now() outside of their conditions are compiled
+	// every time.
+	c.Purge()
+	compile(c, `SELECT mean(value) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' GROUP BY time(1m, now())`)
+	compile(c, `SELECT mean(value) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' GROUP BY time(1m, now())`)
+	expectStats(c, 5, 7, 0)
+
+	// A nil cache compiles every statement.
+	var nilCache *query.PlanCache
+	compile(nilCache, `SELECT value FROM cpu`)
+	expectStats(nilCache, 0, 0, 0)
+}
+
+// Ensure the executions of a prepared query with different values of its
+// bound parameters share a compiled statement and read their own time range.
+func TestPlanCache_Compile_BoundParameters(t *testing.T) {
+	p := cnosql.NewParser(strings.NewReader(`SELECT mean(value) FROM cpu WHERE host = $host AND time >= $start AND time < $end GROUP BY time(1m)`))
+	p.KeepParams()
+	stmt, err := p.ParseStatement()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	c := query.NewPlanCache(10)
+	for _, tt := range []struct {
+		start, end string
+	}{
+		{start: "2000-01-01T00:00:00Z", end: "2000-01-01T01:00:00Z"},
+		{start: "2000-01-01T01:00:00Z", end: "2000-01-01T02:00:00Z"},
+		{start: "2000-01-01T00:00:00Z", end: "2000-01-01T01:00:00Z"},
+	} {
+		plan, err := c.Compile(stmt.(*cnosql.SelectStatement), query.CompileOptions{
+			Params: map[string]interface{}{
+				"host":  "serverA",
+				"start": tt.start,
+				"end":   tt.end,
+			},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		shardMapper := ShardMapper{
+			MapShardsFn: func(_ cnosql.Sources, tr cnosql.TimeRange) query.ShardGroup {
+				if got, want := tr.Min, mustParseTime(tt.start); !got.Equal(want) {
+					t.Errorf("unexpected start time: got=%s want=%s", got, want)
+				}
+				if got, want := tr.Max, mustParseTime(tt.end).Add(-1); !got.Equal(want) {
+					t.Errorf("unexpected end time: got=%s want=%s", got, want)
+				}
+				return &ShardGroup{}
+			},
+		}
+		if _, err := plan.Prepare(&shardMapper, query.SelectOptions{}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	if hits, misses, n := c.Hits(), c.Misses(), c.Len(); hits != 2 || misses != 1 || n != 1 {
+		t.Fatalf("unexpected cache statistics: hits=%d misses=%d len=%d", hits, misses, n)
+	}
+
+	// A missing parameter is reported when the statement is bound.
+	if _, err := c.Compile(stmt.(*cnosql.SelectStatement), query.CompileOptions{
+		Params: map[string]interface{}{"host": "serverA", "start": "2000-01-01T00:00:00Z"},
+	}); err == nil || err.Error() != "missing parameter: end" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure a rewritten SHOW statement and a query with the same text are not
+// compiled into the same plan.
+func TestPlanCache_Compile_RewrittenStatement(t *testing.T) {
+	parse := func(s string) *cnosql.SelectStatement {
+		t.Helper()
+		stmt, err := cnosql.ParseStatement(s)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		stmt, err = query.RewriteStatement(stmt)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		return stmt.(*cnosql.SelectStatement)
+	}
+
+	show := parse(`SHOW FIELD KEYS ON db0 FROM cpu`)
+	sel := parse(`SELECT fieldKey, fieldType FROM db0.._fieldKeys WHERE _name = 'cpu'`)
+	if show.String() != sel.String() {
+		t.Fatalf("expected the statements to have the same text:\n%s\n%s", show, sel)
+	}
+
+	c := query.NewPlanCache(10)
+	if _, err := c.Compile(show, query.CompileOptions{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := c.Compile(sel, query.CompileOptions{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if hits := c.Hits(); hits != 0 {
+		t.Fatal("expected the statements to be compiled separately")
+	} else if n := c.Len(); n != 2 {
+		t.Fatalf("unexpected number of cached statements: %d", n)
+	}
+}
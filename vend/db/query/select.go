@@ -104,6 +104,7 @@ type preparedStatement struct {
 		io.Closer
 	}
 	columns   []string
+	sortKeys  []sortKey
 	maxPointN int
 	now       time.Time
 }
@@ -116,11 +117,17 @@ func (p *preparedStatement) Select(ctx context.Context) (Cursor, error) {
 
 	opt := p.opt
 	opt.InterruptCh = ctx.Done()
+	opt.Memory = MemoryBudgetFromContext(ctx)
 	cur, err := buildCursor(ctx, p.stmt, p.ic, opt)
 	if err != nil {
 		return nil, err
 	}
 
+	// Order the rows by the values of the ORDER BY clause.
+	if p.sortKeys != nil {
+		cur = newSortCursor(cur, p.sortKeys, opt, p.stmt.Limit, p.stmt.Offset, p.stmt.SLimit, p.stmt.SOffset)
+	}
+
 	// If a monitor exists and we are told there is a maximum number of points,
 	// register the monitor function.
 	if m := MonitorFromContext(ctx); m != nil {
@@ -151,7 +158,12 @@ func buildExprIterator(ctx context.Context, expr cnosql.Expr, ic IteratorCreator
 	case *cnosql.VarRef:
 		return b.buildVarRefIterator(ctx, expr)
 	case *cnosql.Call:
+		if isConditionalFunction(expr) {
+			return b.buildConditionalIterator(ctx, expr)
+		}
 		return b.buildCallIterator(ctx, expr)
+	case *cnosql.CaseExpr:
+		return b.buildConditionalIterator(ctx, expr)
 	default:
 		return nil, fmt.Errorf("invalid expression type: %T", expr)
 	}
@@ -188,6 +200,18 @@ func (b *exprIteratorBuilder) buildVarRefIterator(ctx context.Context, expr *cno
 				} else if input != nil {
 					inputs = append(inputs, input)
 				}
+			case *cnosql.Join:
+				join := joinBuilder{
+					ic:   b.ic,
+					join: source,
+				}
+
+				input, err := join.buildVarRefIterator(ctx, expr, b.opt)
+				if err != nil {
+					return err
+				} else if input != nil {
+					inputs = append(inputs, input)
+				}
 			}
 		}
 		return nil
@@ -209,6 +233,65 @@ func (b *exprIteratorBuilder) buildVarRefIterator(ctx context.Context, expr *cno
 	return itr, nil
 }
 
+// buildConditionalIterator creates an iterator that evaluates a conditional
+// expression for each point of the sources. The storage engine does not
+// evaluate expressions, so the points are read with the fields and tags the
+// expression refers to as auxiliary fields.
+func (b *exprIteratorBuilder) buildConditionalIterator(ctx context.Context, expr cnosql.Expr) (Iterator, error) {
+	typ := cnosql.EvalType(expr, nil, DefaultTypeMapper)
+	refs := cnosql.ExprNames(expr)
+	if typ == cnosql.Unknown || !hasValidType(refs) {
+		return &nilFloatIterator{}, nil
+	}
+
+	// The referenced values are read before the auxiliary fields of the
+	// original options.
+	opt := b.opt
+	opt.Expr = nil
+	opt.Aux = make([]cnosql.VarRef, 0, len(refs)+len(b.opt.Aux))
+	opt.Aux = append(opt.Aux, refs...)
+	opt.Aux = append(opt.Aux, b.opt.Aux...)
+	input, err := buildAuxIterator(ctx, b.ic, b.sources, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Scan the values into symbols so they cannot conflict with each other.
+	keys := make([]cnosql.VarRef, 1, len(opt.Aux)+1)
+	symbols := make(map[cnosql.VarRef]cnosql.VarRef, len(refs))
+	for i, ref := range opt.Aux {
+		symbol := cnosql.VarRef{Val: fmt.Sprintf("val%d", i), Type: ref.Type}
+		if i < len(refs) {
+			symbols[ref] = symbol
+		}
+		keys = append(keys, symbol)
+	}
+	expr = cnosql.RewriteExpr(cnosql.CloneExpr(expr), func(expr cnosql.Expr) cnosql.Expr {
+		if ref, ok := expr.(*cnosql.VarRef); ok {
+			if symbol, ok := symbols[*ref]; ok {
+				return &symbol
+			}
+		}
+		return expr
+	})
+
+	// The cursor evaluates the expression followed by the auxiliary fields.
+	fields := make([]*cnosql.Field, 0, len(b.opt.Aux)+1)
+	fields = append(fields, &cnosql.Field{Expr: expr})
+	indexes := make([]IteratorMap, len(b.opt.Aux))
+	for i := range b.opt.Aux {
+		fields = append(fields, &cnosql.Field{Expr: &keys[len(refs)+i+1]})
+		indexes[i] = FieldMap{Index: i + 1, Type: b.opt.Aux[i].Type}
+	}
+
+	cur := newScannerCursor(NewIteratorScanner(input, keys, nil), fields, opt)
+	itr := NewIteratorMapper(cur, FieldMap{Index: 0, Type: typ}, indexes, opt)
+	if b.opt.InterruptCh != nil {
+		itr = NewInterruptIterator(itr, b.opt.InterruptCh)
+	}
+	return itr, nil
+}
+
 func (b *exprIteratorBuilder) buildCallIterator(ctx context.Context, expr *cnosql.Call) (Iterator, error) {
 	// TODO: Refactor this. This section needs to die in a fire.
 	opt := b.opt
@@ -379,6 +462,13 @@ func (b *exprIteratorBuilder) buildCallIterator(ctx context.Context, expr *cnosq
 			return nil, err
 		}
 		return newCumulativeSumIterator(input, opt)
+	case "state_duration", "state_count":
+		opt.Ordered = true
+		input, err := buildExprIterator(ctx, expr.Args[0], b.ic, b.sources, opt, b.selector, false)
+		if err != nil {
+			return nil, err
+		}
+		return newStateIterator(input, expr, opt)
 	case "integral":
 		opt.Ordered = true
 		input, err := buildExprIterator(ctx, expr.Args[0].(*cnosql.VarRef), b.ic, b.sources, opt, false, false)
@@ -511,28 +601,49 @@ func (b *exprIteratorBuilder) buildCallIterator(ctx context.Context, expr *cnosq
 			fallthrough
 		case "min", "max", "sum", "first", "last", "mean":
 			return b.callIterator(ctx, expr, opt)
+		case "count_distinct_approx":
+			input, err := b.callIterator(ctx, expr, opt)
+			if err != nil {
+				return nil, err
+			}
+			return newCountDistinctApproxFinalIterator(input, opt)
+		case "quantile_approx":
+			input, err := b.callIterator(ctx, expr, opt)
+			if err != nil {
+				return nil, err
+			}
+			return newQuantileApproxFinalIterator(input, opt, quantileApproxArg(expr))
+		case "histogram", "histogram_buckets":
+			// The bucket column is produced by the histogram and is not
+			// read from the sources.
+			opt.Aux = nil
+			input, err := b.callIterator(ctx, expr, opt)
+			if err != nil {
+				return nil, err
+			}
+			return newHistogramFinalIterator(input, opt)
 		case "median":
 			opt.Ordered = true
-			input, err := buildExprIterator(ctx, expr.Args[0].(*cnosql.VarRef), b.ic, b.sources, opt, false, false)
+			input, err := buildExprIterator(ctx, expr.Args[0], b.ic, b.sources, opt, false, false)
 			if err != nil {
 				return nil, err
 			}
 			return newMedianIterator(input, opt)
 		case "mode":
-			input, err := buildExprIterator(ctx, expr.Args[0].(*cnosql.VarRef), b.ic, b.sources, opt, false, false)
+			input, err := buildExprIterator(ctx, expr.Args[0], b.ic, b.sources, opt, false, false)
 			if err != nil {
 				return nil, err
 			}
 			return NewModeIterator(input, opt)
 		case "stddev":
-			input, err := buildExprIterator(ctx, expr.Args[0].(*cnosql.VarRef), b.ic, b.sources, opt, false, false)
+			input, err := buildExprIterator(ctx, expr.Args[0], b.ic, b.sources, opt, false, false)
 			if err != nil {
 				return nil, err
 			}
 			return newStddevIterator(input, opt)
 		case "spread":
 			// OPTIMIZE(benbjohnson): convert to map/reduce
-			input, err := buildExprIterator(ctx, expr.Args[0].(*cnosql.VarRef), b.ic, b.sources, opt, false, false)
+			input, err := buildExprIterator(ctx, expr.Args[0], b.ic, b.sources, opt, false, false)
 			if err != nil {
 				return nil, err
 			}
@@ -566,6 +677,9 @@ func (b *exprIteratorBuilder) buildCallIterator(ctx context.Context, expr *cnosq
 			itr = NewFillIterator(itr, expr, opt)
 		}
 	}
+	if b.writeMode && isHistogramFunction(expr.Name) {
+		itr = newHistogramTagIterator(itr)
+	}
 	if opt.InterruptCh != nil {
 		itr = NewInterruptIterator(itr, opt.InterruptCh)
 	}
@@ -576,31 +690,31 @@ func (b *exprIteratorBuilder) callIterator(ctx context.Context, expr *cnosql.Cal
 	inputs := make([]Iterator, 0, len(b.sources))
 	if err := func() error {
 		for _, source := range b.sources {
-			switch source := source.(type) {
-			case *cnosql.Measurement:
-				input, err := b.ic.CreateIterator(ctx, source, opt)
+			// Calls on a field of a measurement are computed by the storage
+			// engine. A conditional expression cannot be evaluated by the
+			// storage engine, so it is treated like the field of a subquery.
+			if m, ok := source.(*cnosql.Measurement); ok && !isConditionalExpr(expr.Args[0]) {
+				input, err := b.ic.CreateIterator(ctx, m, opt)
 				if err != nil {
 					return err
 				}
 				inputs = append(inputs, input)
-			case *cnosql.SubQuery:
-				// Identify the name of the field we are using.
-				arg0 := expr.Args[0].(*cnosql.VarRef)
+				continue
+			}
 
-				opt.Ordered = false
-				input, err := buildExprIterator(ctx, arg0, b.ic, []cnosql.Source{source}, opt, b.selector, false)
-				if err != nil {
-					return err
-				}
+			opt.Ordered = false
+			input, err := buildExprIterator(ctx, expr.Args[0], b.ic, []cnosql.Source{source}, opt, b.selector, false)
+			if err != nil {
+				return err
+			}
 
-				// Wrap the result in a call iterator.
-				i, err := NewCallIterator(input, opt)
-				if err != nil {
-					input.Close()
-					return err
-				}
-				inputs = append(inputs, i)
+			// Wrap the result in a call iterator.
+			i, err := NewCallIterator(input, opt)
+			if err != nil {
+				input.Close()
+				return err
 			}
+			inputs = append(inputs, i)
 		}
 		return nil
 	}(); err != nil {
@@ -666,6 +780,11 @@ func buildCursor(ctx context.Context, stmt *cnosql.SelectStatement, ic IteratorC
 					nf := cnosql.Field{Expr: expr.Args[i]}
 					fields = append(fields, valueMapper.Map(&nf))
 				}
+			} else if isHistogramFunction(expr.Name) {
+				// The upper bound of the bucket of each row is returned as
+				// an auxiliary field of the points of the histogram.
+				nf := cnosql.Field{Expr: &cnosql.VarRef{Val: histogramBucketColumn, Type: cnosql.String}}
+				fields = append(fields, valueMapper.Map(&nf))
 			}
 		}
 	}
@@ -676,6 +795,15 @@ func buildCursor(ctx context.Context, stmt *cnosql.SelectStatement, ic IteratorC
 		f.Alias = columns[i]
 	}
 
+	// The HAVING clause is evaluated as a hidden last column that is removed
+	// by the having cursor. The limits are applied by the having cursor
+	// because the iterators cannot know which rows will be filtered.
+	limit, offset, slimit, soffset := opt.Limit, opt.Offset, opt.SLimit, opt.SOffset
+	if stmt.Having != nil {
+		fields = append(fields, valueMapper.Map(&cnosql.Field{Expr: stmt.Having, Alias: "having"}))
+		opt.Limit, opt.Offset, opt.SLimit, opt.SOffset = 0, 0, 0, 0
+	}
+
 	// Retrieve the refs to retrieve the auxiliary fields.
 	var auxKeys []cnosql.VarRef
 	if len(valueMapper.refs) > 0 {
@@ -734,7 +862,14 @@ func buildCursor(ctx context.Context, stmt *cnosql.SelectStatement, ic IteratorC
 			continue
 		}
 
-		itr, err := buildFieldIterator(ctx, call, ic, stmt.Sources, opt, selector, stmt.Target != nil)
+		// A timeshift() call reads its argument from an earlier time range.
+		expr, fieldOpt, shift := cnosql.Expr(call), opt, time.Duration(0)
+		if call.Name == "timeshift" {
+			expr, shift = call.Args[0], timeShiftArg(call)
+			fieldOpt = opt.shiftTime(shift)
+		}
+
+		itr, err := buildFieldIterator(ctx, expr, ic, stmt.Sources, fieldOpt, selector, stmt.Target != nil)
 		if err != nil {
 			for _, s := range scanners {
 				s.Close()
@@ -747,15 +882,24 @@ func buildCursor(ctx context.Context, stmt *cnosql.SelectStatement, ic IteratorC
 		keys = append(keys, auxKeys...)
 
 		scanner := NewIteratorScanner(itr, keys, opt.FillValue)
+		if shift != 0 {
+			scanner = &timeShiftScanner{IteratorScanner: scanner, shift: int64(shift)}
+		}
 		scanners = append(scanners, scanner)
 	}
 
+	var cur Cursor
 	if len(scanners) == 0 {
-		return newNullCursor(fields), nil
+		cur = newNullCursor(fields)
 	} else if len(scanners) == 1 {
-		return newScannerCursor(scanners[0], fields, opt), nil
+		cur = newScannerCursor(scanners[0], fields, opt)
+	} else {
+		cur = newMultiScannerCursor(scanners, fields, opt)
+	}
+	if stmt.Having != nil {
+		cur = newHavingCursor(cur, limit, offset, slimit, soffset)
 	}
-	return newMultiScannerCursor(scanners, fields, opt), nil
+	return cur, nil
 }
 
 func buildAuxIterator(ctx context.Context, ic IteratorCreator, sources cnosql.Sources, opt IteratorOptions) (Iterator, error) {
@@ -788,6 +932,18 @@ func buildAuxIterator(ctx context.Context, ic IteratorCreator, sources cnosql.So
 					stmt: source.Statement,
 				}
 
+				input, err := b.buildAuxIterator(ctx, opt)
+				if err != nil {
+					return err
+				} else if input != nil {
+					inputs = append(inputs, input)
+				}
+			case *cnosql.Join:
+				b := joinBuilder{
+					ic:   ic,
+					join: source,
+				}
+
 				input, err := b.buildAuxIterator(ctx, opt)
 				if err != nil {
 					return err
@@ -907,7 +1063,7 @@ func (v *valueMapper) Visit(n cnosql.Node) cnosql.Visitor {
 		// as stored in the symbol table.
 		switch n := n.(type) {
 		case *cnosql.Call:
-			if isMathFunction(n) {
+			if isMathFunction(n) || isStringFunction(n) || isConditionalFunction(n) {
 				return v
 			}
 			v.calls[n] = struct{}{}
@@ -960,6 +1116,13 @@ func validateTypes(stmt *cnosql.SelectStatement) error {
 			return err
 		}
 	}
+	if stmt.Having != nil {
+		if typ, err := valuer.EvalType(stmt.Having); err != nil {
+			return err
+		} else if typ != cnosql.Boolean && typ != cnosql.Unknown {
+			return fmt.Errorf("HAVING must be a boolean expression, got %s", typ)
+		}
+	}
 	return nil
 }
 
@@ -0,0 +1,306 @@
+package query
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+	"github.com/cnosdb/cnosdb/vend/db/pkg/estimator/ddsketch"
+	"github.com/cnosdb/cnosdb/vend/db/pkg/estimator/hll"
+)
+
+// The approximate aggregates are computed in two phases. The call iterator
+// for count_distinct_approx() or quantile_approx() summarizes the raw points
+// of each window into a sketch that is emitted as a string point. Sketches
+// from different series, shards and nodes are then combined by the merge
+// functions below, which are substituted for the original call whenever
+// iterators are merged. The final estimate is only computed once all of
+// the sketches have been merged.
+const (
+	mergeHLLFunction      = "merge_hll"
+	mergeDDSketchFunction = "merge_ddsketch"
+)
+
+// newCountDistinctApproxIterator returns an iterator that summarizes the
+// distinct values of each window into a HyperLogLog++ sketch.
+func newCountDistinctApproxIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
+	switch input := input.(type) {
+	case FloatIterator:
+		createFn := func() (FloatPointAggregator, StringPointEmitter) {
+			fn := NewCountDistinctApproxReducer()
+			return fn, fn
+		}
+		return newFloatReduceStringIterator(input, opt, createFn), nil
+	case IntegerIterator:
+		createFn := func() (IntegerPointAggregator, StringPointEmitter) {
+			fn := NewCountDistinctApproxReducer()
+			return fn, fn
+		}
+		return newIntegerReduceStringIterator(input, opt, createFn), nil
+	case UnsignedIterator:
+		createFn := func() (UnsignedPointAggregator, StringPointEmitter) {
+			fn := NewCountDistinctApproxReducer()
+			return fn, fn
+		}
+		return newUnsignedReduceStringIterator(input, opt, createFn), nil
+	case StringIterator:
+		createFn := func() (StringPointAggregator, StringPointEmitter) {
+			fn := NewCountDistinctApproxReducer()
+			return fn, fn
+		}
+		return newStringReduceStringIterator(input, opt, createFn), nil
+	case BooleanIterator:
+		createFn := func() (BooleanPointAggregator, StringPointEmitter) {
+			fn := NewCountDistinctApproxReducer()
+			return fn, fn
+		}
+		return newBooleanReduceStringIterator(input, opt, createFn), nil
+	default:
+		return nil, fmt.Errorf("unsupported count_distinct_approx iterator type: %T", input)
+	}
+}
+
+// newQuantileApproxIterator returns an iterator that summarizes the values
+// of each window into a DDSketch.
+func newQuantileApproxIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
+	switch input := input.(type) {
+	case FloatIterator:
+		createFn := func() (FloatPointAggregator, StringPointEmitter) {
+			fn := NewQuantileApproxReducer()
+			return fn, fn
+		}
+		return newFloatReduceStringIterator(input, opt, createFn), nil
+	case IntegerIterator:
+		createFn := func() (IntegerPointAggregator, StringPointEmitter) {
+			fn := NewQuantileApproxReducer()
+			return fn, fn
+		}
+		return newIntegerReduceStringIterator(input, opt, createFn), nil
+	case UnsignedIterator:
+		createFn := func() (UnsignedPointAggregator, StringPointEmitter) {
+			fn := NewQuantileApproxReducer()
+			return fn, fn
+		}
+		return newUnsignedReduceStringIterator(input, opt, createFn), nil
+	default:
+		return nil, fmt.Errorf("unsupported quantile_approx iterator type: %T", input)
+	}
+}
+
+// newMergeHLLIterator returns an iterator that merges the HyperLogLog++
+// sketches of each window into a single sketch.
+func newMergeHLLIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
+	switch input := input.(type) {
+	case StringIterator:
+		createFn := func() (StringPointAggregator, StringPointEmitter) {
+			fn := NewCountDistinctApproxReducer()
+			return &HLLMergeReducer{CountDistinctApproxReducer: fn}, fn
+		}
+		return newStringReduceStringIterator(input, opt, createFn), nil
+	case *nilFloatIterator:
+		return input, nil
+	default:
+		return nil, fmt.Errorf("unsupported %s iterator type: %T", mergeHLLFunction, input)
+	}
+}
+
+// newMergeDDSketchIterator returns an iterator that merges the DDSketches
+// of each window into a single sketch.
+func newMergeDDSketchIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
+	switch input := input.(type) {
+	case StringIterator:
+		createFn := func() (StringPointAggregator, StringPointEmitter) {
+			fn := NewQuantileApproxReducer()
+			return &DDSketchMergeReducer{QuantileApproxReducer: fn}, fn
+		}
+		return newStringReduceStringIterator(input, opt, createFn), nil
+	case *nilFloatIterator:
+		return input, nil
+	default:
+		return nil, fmt.Errorf("unsupported %s iterator type: %T", mergeDDSketchFunction, input)
+	}
+}
+
+// newCountDistinctApproxFinalIterator returns an iterator that merges the
+// HyperLogLog++ sketches of each window and emits the estimated number of
+// distinct values.
+func newCountDistinctApproxFinalIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
+	switch input := input.(type) {
+	case StringIterator:
+		createFn := func() (StringPointAggregator, IntegerPointEmitter) {
+			fn := &HLLMergeReducer{CountDistinctApproxReducer: NewCountDistinctApproxReducer()}
+			return fn, fn
+		}
+		return newStringReduceIntegerIterator(input, opt, createFn), nil
+	case *nilFloatIterator:
+		return input, nil
+	default:
+		return nil, fmt.Errorf("unsupported count_distinct_approx iterator type: %T", input)
+	}
+}
+
+// newQuantileApproxFinalIterator returns an iterator that merges the
+// DDSketches of each window and emits the estimated value at quantile q,
+// the value percentile() returns for 100*q.
+func newQuantileApproxFinalIterator(input Iterator, opt IteratorOptions, q float64) (Iterator, error) {
+	switch input := input.(type) {
+	case StringIterator:
+		createFn := func() (StringPointAggregator, FloatPointEmitter) {
+			fn := &DDSketchMergeReducer{QuantileApproxReducer: NewQuantileApproxReducer(), q: q}
+			return fn, fn
+		}
+		return newStringReduceFloatIterator(input, opt, createFn), nil
+	case *nilFloatIterator:
+		return input, nil
+	default:
+		return nil, fmt.Errorf("unsupported quantile_approx iterator type: %T", input)
+	}
+}
+
+// CountDistinctApproxReducer adds the aggregated points to a HyperLogLog++
+// sketch and emits the encoded sketch.
+type CountDistinctApproxReducer struct {
+	plus *hll.Plus
+}
+
+// NewCountDistinctApproxReducer creates a new CountDistinctApproxReducer.
+func NewCountDistinctApproxReducer() *CountDistinctApproxReducer {
+	// The bias correction of the sketch is tuned for the default precision.
+	return &CountDistinctApproxReducer{plus: hll.NewDefaultPlus()}
+}
+
+// AggregateFloat aggregates a point into the reducer.
+func (r *CountDistinctApproxReducer) AggregateFloat(p *FloatPoint) {
+	r.addUint64(math.Float64bits(p.Value))
+}
+
+// AggregateInteger aggregates a point into the reducer.
+func (r *CountDistinctApproxReducer) AggregateInteger(p *IntegerPoint) {
+	r.addUint64(uint64(p.Value))
+}
+
+// AggregateUnsigned aggregates a point into the reducer.
+func (r *CountDistinctApproxReducer) AggregateUnsigned(p *UnsignedPoint) {
+	r.addUint64(p.Value)
+}
+
+// AggregateString aggregates a point into the reducer.
+func (r *CountDistinctApproxReducer) AggregateString(p *StringPoint) {
+	r.plus.Add([]byte(p.Value))
+}
+
+// AggregateBoolean aggregates a point into the reducer.
+func (r *CountDistinctApproxReducer) AggregateBoolean(p *BooleanPoint) {
+	if p.Value {
+		r.plus.Add([]byte{1})
+	} else {
+		r.plus.Add([]byte{0})
+	}
+}
+
+func (r *CountDistinctApproxReducer) addUint64(v uint64) {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+	r.plus.Add(buf[:])
+}
+
+// Emit emits the encoded sketch as a single point.
+func (r *CountDistinctApproxReducer) Emit() []StringPoint {
+	data, err := r.plus.MarshalBinary()
+	if err != nil {
+		return nil
+	}
+	return []StringPoint{{Time: ZeroTime, Value: string(data)}}
+}
+
+// HLLMergeReducer merges encoded HyperLogLog++ sketches.
+type HLLMergeReducer struct {
+	*CountDistinctApproxReducer
+}
+
+// AggregateString merges the sketch encoded in the point into the reducer.
+// Points that do not contain a valid sketch are ignored.
+func (r *HLLMergeReducer) AggregateString(p *StringPoint) {
+	var other hll.Plus
+	if err := other.UnmarshalBinary([]byte(p.Value)); err != nil {
+		return
+	}
+	r.plus.Merge(&other)
+}
+
+// Emit emits the estimated number of distinct values as a single point.
+func (r *HLLMergeReducer) Emit() []IntegerPoint {
+	return []IntegerPoint{{Time: ZeroTime, Value: int64(r.plus.Count())}}
+}
+
+// QuantileApproxReducer adds the aggregated points to a DDSketch and emits
+// the encoded sketch.
+type QuantileApproxReducer struct {
+	sketch *ddsketch.Sketch
+}
+
+// NewQuantileApproxReducer creates a new QuantileApproxReducer.
+func NewQuantileApproxReducer() *QuantileApproxReducer {
+	return &QuantileApproxReducer{sketch: ddsketch.NewDefault()}
+}
+
+// AggregateFloat aggregates a point into the reducer.
+func (r *QuantileApproxReducer) AggregateFloat(p *FloatPoint) {
+	r.sketch.Add(p.Value)
+}
+
+// AggregateInteger aggregates a point into the reducer.
+func (r *QuantileApproxReducer) AggregateInteger(p *IntegerPoint) {
+	r.sketch.Add(float64(p.Value))
+}
+
+// AggregateUnsigned aggregates a point into the reducer.
+func (r *QuantileApproxReducer) AggregateUnsigned(p *UnsignedPoint) {
+	r.sketch.Add(float64(p.Value))
+}
+
+// Emit emits the encoded sketch as a single point.
+func (r *QuantileApproxReducer) Emit() []StringPoint {
+	data, err := r.sketch.MarshalBinary()
+	if err != nil {
+		return nil
+	}
+	return []StringPoint{{Time: ZeroTime, Value: string(data)}}
+}
+
+// DDSketchMergeReducer merges encoded DDSketches.
+type DDSketchMergeReducer struct {
+	*QuantileApproxReducer
+	q float64
+}
+
+// AggregateString merges the sketch encoded in the point into the reducer.
+// Points that do not contain a valid sketch are ignored.
+func (r *DDSketchMergeReducer) AggregateString(p *StringPoint) {
+	var other ddsketch.Sketch
+	if err := other.UnmarshalBinary([]byte(p.Value)); err != nil {
+		return
+	}
+	r.sketch.Merge(&other)
+}
+
+// Emit emits the estimated value at the quantile as a single point.
+func (r *DDSketchMergeReducer) Emit() []FloatPoint {
+	v, ok := r.sketch.Quantile(r.q)
+	if !ok {
+		return nil
+	}
+	return []FloatPoint{{Time: ZeroTime, Value: v}}
+}
+
+// quantileApproxArg returns the quantile passed to quantile_approx().
+func quantileApproxArg(call *cnosql.Call) float64 {
+	switch arg := call.Args[1].(type) {
+	case *cnosql.NumberLiteral:
+		return arg.Val
+	case *cnosql.IntegerLiteral:
+		return float64(arg.Val)
+	}
+	return 0
+}
@@ -0,0 +1,643 @@
+package query
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+)
+
+// sortKey is one field of the ORDER BY clause of a query resolved to the
+// rows of its cursor.
+type sortKey struct {
+	// index is the column of the values of the key. It is only used if
+	// the key sorts by neither time nor a tag.
+	index int
+
+	// tag is the tag of the series of the rows sorted by.
+	tag string
+
+	// time is set if the key sorts by the time of the rows.
+	time bool
+
+	ascending bool
+}
+
+// value returns the value of the key in a row, or nil if it is null.
+func (k *sortKey) value(row *Row) interface{} {
+	switch {
+	case k.time:
+		return row.Time
+	case k.tag != "":
+		if v := row.Series.Tags.Value(k.tag); v != "" {
+			return v
+		}
+		return nil
+	}
+	if v := row.Values[k.index]; v != NullFloat {
+		return v
+	}
+	return nil
+}
+
+// newSortKeys resolves the ORDER BY clause of a statement to the columns
+// and the tags of the rows of its cursor. A field is either the name of a
+// column, the expression of a selected field or a tag the statement is
+// grouped by. It returns nil if the statement is only ordered by time.
+func newSortKeys(stmt *cnosql.SelectStatement) ([]sortKey, error) {
+	if stmt.SortFields.ByTime() {
+		return nil, nil
+	}
+
+	// Find the columns of the fields the way buildCursor creates them. The
+	// extra columns of top() and bottom() follow the column of the call.
+	columnNames := stmt.ColumnNames()
+	columns := make(map[string]int, len(columnNames))
+	exprs := make(map[string]int, len(stmt.Fields))
+	i := 0
+	if !stmt.OmitTime {
+		i++
+	}
+	for _, f := range stmt.Fields {
+		exprs[sortExprName(f.Expr)] = i
+		columns[columnNames[i]] = i
+		i++
+
+		if call, ok := f.Expr.(*cnosql.Call); ok && stmt.Target == nil && (call.Name == "top" || call.Name == "bottom") {
+			for _, arg := range call.Args[1:] {
+				if _, ok := arg.(*cnosql.VarRef); ok {
+					columns[columnNames[i]] = i
+					i++
+				}
+			}
+		}
+	}
+
+	tags := make(map[string]struct{})
+	for _, d := range stmt.Dimensions {
+		if ref, ok := d.Expr.(*cnosql.VarRef); ok {
+			tags[ref.Val] = struct{}{}
+		}
+	}
+
+	keys := make([]sortKey, 0, len(stmt.SortFields))
+	for _, f := range stmt.SortFields {
+		key := sortKey{ascending: f.Ascending}
+		if f.IsTime() || (!stmt.OmitTime && f.Name == columnNames[0]) {
+			key.time = true
+		} else if index, ok := columns[f.Name]; ok {
+			key.index = index
+		} else if index, ok := exprs[f.Name]; ok {
+			key.index = index
+		} else if _, ok := tags[f.Name]; ok {
+			key.tag = f.Name
+		} else {
+			return nil, fmt.Errorf("ORDER BY %s must be a selected field or a GROUP BY tag", f.Name)
+		}
+		keys = append(keys, key)
+	}
+
+	// The rows kept for each series are only bounded by the limits.
+	if (stmt.SLimit > 0 || stmt.SOffset > 0) && (stmt.Limit == 0 || stmt.SLimit == 0) {
+		return nil, errors.New("ORDER BY a field or a tag with SLIMIT or SOFFSET requires LIMIT and SLIMIT")
+	}
+	return keys, nil
+}
+
+// sortExprName returns the string of an expression without the types of
+// its variables, as they are written in an ORDER BY clause.
+func sortExprName(expr cnosql.Expr) string {
+	expr = cnosql.RewriteExpr(cnosql.CloneExpr(expr), func(e cnosql.Expr) cnosql.Expr {
+		if ref, ok := e.(*cnosql.VarRef); ok {
+			return &cnosql.VarRef{Val: ref.Val}
+		}
+		return e
+	})
+	return expr.String()
+}
+
+// compareSortValues compares two non-null values of a key. Numbers sort
+// before strings and strings before booleans.
+func compareSortValues(a, b interface{}) int {
+	if x, y, ok := asFloats(a, b); ok {
+		// Compare integers exactly if both values are integers.
+		if x, ok := a.(int64); ok {
+			if y, ok := b.(int64); ok {
+				return compareInt64(x, y)
+			}
+		}
+		if x, ok := a.(uint64); ok {
+			if y, ok := b.(uint64); ok {
+				switch {
+				case x < y:
+					return -1
+				case x > y:
+					return 1
+				}
+				return 0
+			}
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+
+	switch x := a.(type) {
+	case string:
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y)
+		}
+	case bool:
+		if y, ok := b.(bool); ok {
+			switch {
+			case x == y:
+				return 0
+			case !x:
+				return -1
+			}
+			return 1
+		}
+	case time.Time:
+		if y, ok := b.(time.Time); ok {
+			return compareInt64(x.UnixNano(), y.UnixNano())
+		}
+	}
+	return compareInt64(int64(sortTypeRank(a)), int64(sortTypeRank(b)))
+}
+
+func compareInt64(x, y int64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+// sortTypeRank returns the rank of the type of a value when comparing
+// values of different types.
+func sortTypeRank(v interface{}) int {
+	switch v.(type) {
+	case float64, int64, uint64:
+		return 0
+	case string:
+		return 1
+	case bool:
+		return 2
+	}
+	return 3
+}
+
+// rowOrder is the order of the rows of an ORDER BY clause.
+type rowOrder struct {
+	keys []sortKey
+
+	// ascending is the order of the time of rows with the same keys.
+	ascending bool
+}
+
+// less returns true if row a comes before row b. Null values come after
+// all other values whatever the direction of a key. Rows with the same
+// keys are ordered by series and then by time.
+func (o *rowOrder) less(a, b *Row) bool {
+	for i := range o.keys {
+		k := &o.keys[i]
+		av, bv := k.value(a), k.value(b)
+		if av == nil || bv == nil {
+			if (av == nil) != (bv == nil) {
+				return bv == nil
+			}
+			continue
+		}
+		if c := compareSortValues(av, bv); c != 0 {
+			if k.ascending {
+				return c < 0
+			}
+			return c > 0
+		}
+	}
+
+	if a.Series.Name != b.Series.Name {
+		return a.Series.Name < b.Series.Name
+	}
+	if aid, bid := a.Series.Tags.ID(), b.Series.Tags.ID(); aid != bid {
+		return aid < bid
+	}
+	if o.ascending {
+		return a.Time < b.Time
+	}
+	return a.Time > b.Time
+}
+
+// rowHeap keeps the first n rows of an order pushed into it. The last row
+// is at the top of the heap so it is the one dropped by a better row. A
+// zero n keeps every row. The memory of the rows is reserved from the budget
+// of the query.
+type rowHeap struct {
+	order *rowOrder
+	n     int
+	rows  []Row
+
+	mem  *MemoryBudget
+	size int64
+}
+
+func (h *rowHeap) Len() int           { return len(h.rows) }
+func (h *rowHeap) Less(i, j int) bool { return h.order.less(&h.rows[j], &h.rows[i]) }
+func (h *rowHeap) Swap(i, j int)      { h.rows[i], h.rows[j] = h.rows[j], h.rows[i] }
+
+func (h *rowHeap) Push(x interface{}) {
+	h.rows = append(h.rows, x.(Row))
+}
+
+func (h *rowHeap) Pop() interface{} {
+	n := len(h.rows)
+	row := h.rows[n-1]
+	h.rows = h.rows[:n-1]
+	return row
+}
+
+// add adds a row if it is within the first n rows. The values of the row
+// are copied as cursors reuse them.
+func (h *rowHeap) add(row *Row) error {
+	if h.n > 0 && len(h.rows) >= h.n {
+		if !h.order.less(row, &h.rows[0]) {
+			return nil
+		}
+		last := heap.Pop(h).(Row)
+		h.release(rowSize(&last))
+	}
+
+	size := rowSize(row)
+	if err := h.mem.Reserve(size); err != nil {
+		return err
+	}
+	h.size += size
+	heap.Push(h, copyRow(row))
+	return nil
+}
+
+// release releases the memory of rows removed from the heap.
+func (h *rowHeap) release(size int64) {
+	h.mem.Release(size)
+	h.size -= size
+}
+
+// sorted returns the rows of the heap in order, skipping the first offset.
+func (h *rowHeap) sorted(offset int) []Row {
+	rows := h.rows
+	sort.Slice(rows, func(i, j int) bool {
+		return h.order.less(&rows[i], &rows[j])
+	})
+	if offset >= len(rows) {
+		return nil
+	}
+	return rows[offset:]
+}
+
+// copyRow returns a copy of a row with its own values.
+func copyRow(row *Row) Row {
+	return Row{
+		Time:   row.Time,
+		Series: row.Series,
+		Values: append([]interface{}(nil), row.Values...),
+	}
+}
+
+// seriesGroup is the rows of a series within the limits of the query.
+type seriesGroup struct {
+	rows []Row
+	size int64
+}
+
+// groupHeap keeps series groups ordered by their first row. The last group
+// is at the top of the heap so it is the one dropped by a better group.
+type groupHeap struct {
+	order  *rowOrder
+	groups []*seriesGroup
+}
+
+func (h *groupHeap) Len() int { return len(h.groups) }
+func (h *groupHeap) Less(i, j int) bool {
+	return h.order.less(&h.groups[j].rows[0], &h.groups[i].rows[0])
+}
+func (h *groupHeap) Swap(i, j int) { h.groups[i], h.groups[j] = h.groups[j], h.groups[i] }
+
+func (h *groupHeap) Push(x interface{}) {
+	h.groups = append(h.groups, x.(*seriesGroup))
+}
+
+func (h *groupHeap) Pop() interface{} {
+	n := len(h.groups)
+	g := h.groups[n-1]
+	h.groups = h.groups[:n-1]
+	return g
+}
+
+// sortCursor orders the rows of a cursor by the fields of an ORDER BY
+// clause and applies the limits of the query to them.
+//
+// Without a series limit, LIMIT and OFFSET apply to the rows of all series
+// and only the first LIMIT+OFFSET rows are kept while reading. With an
+// SLIMIT or SOFFSET, LIMIT and OFFSET apply to the rows of each series and
+// the series are ordered by their first row before SLIMIT and SOFFSET are
+// applied to them. As the rows of a series are read together, LIMIT+OFFSET
+// rows of the series being read are kept, and only the first SLIMIT+SOFFSET
+// series once they are read.
+//
+// The rows kept are charged to the memory budget of the query. When all of
+// the rows are sorted and they do not fit in the budget, they are written
+// to temporary files in sorted runs that are merged while they are read.
+// Otherwise the query fails once it is over budget.
+type sortCursor struct {
+	cur   Cursor
+	order *rowOrder
+	mem   *MemoryBudget
+	loc   *time.Location
+
+	limit, offset   int
+	slimit, soffset int
+
+	rows   []Row
+	read   bool
+	series Series
+	err    error
+
+	// The memory reserved for rows.
+	size int64
+
+	// The runs of rows written to temporary files and the heap merging
+	// them. The rows of the merge are counted against the limits of the
+	// query as they are read.
+	runs   []*spillRun
+	merge  *spillHeap
+	skip   int
+	remain int
+}
+
+func newSortCursor(cur Cursor, keys []sortKey, opt IteratorOptions, limit, offset, slimit, soffset int) *sortCursor {
+	return &sortCursor{
+		cur:     cur,
+		order:   &rowOrder{keys: keys, ascending: opt.Ascending},
+		mem:     opt.Memory,
+		loc:     opt.Location,
+		limit:   limit,
+		offset:  offset,
+		slimit:  slimit,
+		soffset: soffset,
+	}
+}
+
+func (cur *sortCursor) Scan(row *Row) bool {
+	if !cur.read {
+		cur.read = true
+		if cur.slimit > 0 || cur.soffset > 0 {
+			cur.rows, cur.err = cur.sortSeries()
+		} else {
+			cur.rows, cur.err = cur.sortRows()
+		}
+	}
+	if cur.err != nil {
+		return false
+	}
+
+	var next Row
+	if cur.merge != nil {
+		ok, err := cur.nextMerged(&next)
+		if err != nil {
+			cur.err = err
+			return false
+		} else if !ok {
+			return false
+		}
+	} else if len(cur.rows) == 0 {
+		return false
+	} else {
+		next = cur.rows[0]
+		cur.rows = cur.rows[1:]
+	}
+
+	// Number the series again as the rows of a series may have been read
+	// under different ids.
+	*row = next
+	if row.Series.Name != cur.series.Name || !row.Series.Tags.Equals(&cur.series.Tags) {
+		cur.series.Name = row.Series.Name
+		cur.series.Tags = row.Series.Tags
+		cur.series.id++
+	}
+	row.Series = cur.series
+	return true
+}
+
+// bound returns the number of rows kept for a limit and an offset.
+func bound(limit, offset int) int {
+	if limit == 0 {
+		return 0
+	}
+	return limit + offset
+}
+
+// sortRows reads the rows of all series and returns the rows within the
+// limits of the query in order. If the rows are spilled to temporary
+// files, the rows are merged from the files instead.
+func (cur *sortCursor) sortRows() ([]Row, error) {
+	h := &rowHeap{order: cur.order, n: bound(cur.limit, cur.offset), mem: cur.mem}
+	defer func() { cur.size += h.size }()
+
+	var row Row
+	for cur.cur.Scan(&row) {
+		err := h.add(&row)
+		if err == nil {
+			continue
+		} else if h.n > 0 || len(h.rows) == 0 {
+			return nil, err
+		}
+
+		// Write the sorted rows to a run to make room for the row.
+		if err := cur.spill(h); err != nil {
+			return nil, err
+		} else if err := h.add(&row); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cur.runs) > 0 {
+		if err := cur.spill(h); err != nil {
+			return nil, err
+		}
+		return nil, cur.startMerge()
+	}
+
+	rows := h.sorted(cur.offset)
+	if cur.limit > 0 && len(rows) > cur.limit {
+		rows = rows[:cur.limit]
+	}
+	return rows, nil
+}
+
+// spill writes the rows of a heap to a new run in sorted order and
+// releases their memory.
+func (cur *sortCursor) spill(h *rowHeap) error {
+	r, err := newSpillRun(cur.mem.SpillDir, cur.loc)
+	if err != nil {
+		return err
+	}
+	cur.runs = append(cur.runs, r)
+
+	for _, row := range h.sorted(0) {
+		if err := r.write(&row); err != nil {
+			return err
+		}
+	}
+	h.rows = nil
+	h.release(h.size)
+	return nil
+}
+
+// startMerge reads the first row of the runs and applies the offset of the
+// query to the merged rows.
+func (cur *sortCursor) startMerge() error {
+	cur.merge = &spillHeap{order: cur.order}
+	for _, r := range cur.runs {
+		if ok, err := r.rewind(); err != nil {
+			return err
+		} else if ok {
+			cur.merge.runs = append(cur.merge.runs, r)
+		}
+	}
+	heap.Init(cur.merge)
+
+	cur.skip, cur.remain = cur.offset, cur.limit
+	return nil
+}
+
+// nextMerged reads the next row of the runs within the limits of the query.
+func (cur *sortCursor) nextMerged(row *Row) (bool, error) {
+	for {
+		if cur.merge.Len() == 0 || (cur.limit > 0 && cur.remain == 0) {
+			return false, nil
+		}
+
+		r := cur.merge.runs[0]
+		*row = r.row
+		if ok, err := r.next(); err != nil {
+			return false, err
+		} else if ok {
+			heap.Fix(cur.merge, 0)
+		} else {
+			heap.Pop(cur.merge)
+		}
+
+		if cur.skip > 0 {
+			cur.skip--
+			continue
+		}
+		cur.remain--
+		return true, nil
+	}
+}
+
+// sortSeries reads the rows of each series and returns the rows of the
+// series within the series limits of the query, a series at a time.
+func (cur *sortCursor) sortSeries() ([]Row, error) {
+	groups := &groupHeap{order: cur.order}
+	n := cur.slimit + cur.soffset
+
+	var h *rowHeap
+	defer func() {
+		for _, g := range groups.groups {
+			cur.size += g.size
+		}
+		if h != nil {
+			cur.size += h.size
+		}
+	}()
+
+	// finish keeps the rows of the series read if it is within the series
+	// limits and drops the last series kept if it is not anymore.
+	finish := func() {
+		rows := h.sorted(cur.offset)
+		if cur.limit > 0 && len(rows) > cur.limit {
+			rows = rows[:cur.limit]
+		}
+		g := &seriesGroup{rows: rows, size: h.size}
+		h = nil
+
+		if len(g.rows) == 0 {
+			cur.mem.Release(g.size)
+			return
+		} else if groups.Len() >= n {
+			if !cur.order.less(&g.rows[0], &groups.groups[0].rows[0]) {
+				cur.mem.Release(g.size)
+				return
+			}
+			last := heap.Pop(groups).(*seriesGroup)
+			cur.mem.Release(last.size)
+		}
+		heap.Push(groups, g)
+	}
+
+	var key string
+	var row Row
+	for cur.cur.Scan(&row) {
+		if k := row.Series.Name + "\x00" + row.Series.Tags.ID(); h == nil || k != key {
+			if h != nil {
+				finish()
+			}
+			h = &rowHeap{order: cur.order, n: bound(cur.limit, cur.offset), mem: cur.mem}
+			key = k
+		}
+		if err := h.add(&row); err != nil {
+			return nil, err
+		}
+	}
+	if h != nil {
+		finish()
+	}
+
+	// Order the series by their first row and skip those within SOFFSET.
+	sort.Slice(groups.groups, func(i, j int) bool {
+		return cur.order.less(&groups.groups[i].rows[0], &groups.groups[j].rows[0])
+	})
+	var rows []Row
+	for i, g := range groups.groups {
+		if i >= cur.soffset {
+			rows = append(rows, g.rows...)
+		}
+	}
+	return rows, nil
+}
+
+func (cur *sortCursor) Stats() IteratorStats {
+	return cur.cur.Stats()
+}
+
+func (cur *sortCursor) Err() error {
+	if cur.err != nil {
+		return cur.err
+	}
+	return cur.cur.Err()
+}
+
+func (cur *sortCursor) Columns() []cnosql.VarRef {
+	return cur.cur.Columns()
+}
+
+func (cur *sortCursor) Close() error {
+	for _, r := range cur.runs {
+		r.Close()
+	}
+	cur.runs, cur.merge = nil, nil
+	cur.mem.Release(cur.size)
+	cur.size = 0
+	return cur.cur.Close()
+}
@@ -0,0 +1,241 @@
+package query
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+)
+
+// The types of the values of rows written to a spill file.
+const (
+	spillNil byte = iota
+	spillNull
+	spillFloat
+	spillInteger
+	spillUnsigned
+	spillString
+	spillBoolean
+	spillTime
+)
+
+// rowSize returns the estimated number of bytes used by a row kept in
+// memory. The name and the tags of the series are shared between rows.
+func rowSize(row *Row) int64 {
+	n := int64(bufferedPointSize + 16*len(row.Values))
+	for _, v := range row.Values {
+		if s, ok := v.(string); ok {
+			n += int64(len(s))
+		}
+	}
+	return n
+}
+
+// spillRun is a sorted run of rows written to a temporary file when a sort
+// does not fit in the memory budget of its query.
+type spillRun struct {
+	f   *os.File
+	w   *bufio.Writer
+	r   *bufio.Reader
+	loc *time.Location
+	buf []byte
+
+	// The current row of the run while it is read.
+	row Row
+}
+
+// newSpillRun creates the temporary file of a run in dir.
+func newSpillRun(dir string, loc *time.Location) (*spillRun, error) {
+	f, err := os.CreateTemp(dir, "cnosdb-query-spill-")
+	if err != nil {
+		return nil, fmt.Errorf("create spill file: %s", err)
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	return &spillRun{f: f, w: bufio.NewWriter(f), loc: loc}, nil
+}
+
+// write appends a row to the run.
+func (r *spillRun) write(row *Row) error {
+	b := r.buf[:0]
+	b = appendVarint(b, row.Time)
+	b = appendSpillString(b, row.Series.Name)
+	b = appendSpillString(b, row.Series.Tags.ID())
+	b = appendUvarint(b, uint64(len(row.Values)))
+	for _, v := range row.Values {
+		switch v := v.(type) {
+		case nil:
+			b = append(b, spillNil)
+		case float64:
+			b = append(b, spillFloat)
+			var buf [8]byte
+			binary.BigEndian.PutUint64(buf[:], math.Float64bits(v))
+			b = append(b, buf[:]...)
+		case int64:
+			b = append(b, spillInteger)
+			b = appendVarint(b, v)
+		case uint64:
+			b = append(b, spillUnsigned)
+			b = appendUvarint(b, v)
+		case string:
+			b = append(b, spillString)
+			b = appendSpillString(b, v)
+		case bool:
+			b = append(b, spillBoolean)
+			if v {
+				b = append(b, 1)
+			} else {
+				b = append(b, 0)
+			}
+		case time.Time:
+			b = append(b, spillTime)
+			b = appendVarint(b, v.UnixNano())
+		default:
+			if v != NullFloat {
+				return fmt.Errorf("unable to spill value of type %T", v)
+			}
+			b = append(b, spillNull)
+		}
+	}
+	r.buf = b
+
+	_, err := r.w.Write(b)
+	return err
+}
+
+// rewind flushes the rows written to the run and reads them from the
+// start. It returns false if the run is empty.
+func (r *spillRun) rewind() (bool, error) {
+	if err := r.w.Flush(); err != nil {
+		return false, err
+	} else if _, err := r.f.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+	r.r = bufio.NewReader(r.f)
+	return r.next()
+}
+
+// next reads the next row of the run. It returns false at the end of the
+// run.
+func (r *spillRun) next() (bool, error) {
+	t, err := binary.ReadVarint(r.r)
+	if err == io.EOF {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	row := Row{Time: t}
+	if row.Series.Name, err = r.readString(); err != nil {
+		return false, err
+	}
+	id, err := r.readString()
+	if err != nil {
+		return false, err
+	}
+	row.Series.Tags = newTagsID(id)
+
+	n, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return false, err
+	}
+	row.Values = make([]interface{}, n)
+	for i := range row.Values {
+		if row.Values[i], err = r.readValue(); err != nil {
+			return false, err
+		}
+	}
+	r.row = row
+	return true, nil
+}
+
+func (r *spillRun) readValue() (interface{}, error) {
+	typ, err := r.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch typ {
+	case spillNil:
+		return nil, nil
+	case spillNull:
+		return NullFloat, nil
+	case spillFloat:
+		var b [8]byte
+		if _, err := io.ReadFull(r.r, b[:]); err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b[:])), nil
+	case spillInteger:
+		return binary.ReadVarint(r.r)
+	case spillUnsigned:
+		return binary.ReadUvarint(r.r)
+	case spillString:
+		return r.readString()
+	case spillBoolean:
+		v, err := r.r.ReadByte()
+		return v == 1, err
+	case spillTime:
+		v, err := binary.ReadVarint(r.r)
+		return time.Unix(0, v).In(r.loc), err
+	}
+	return nil, fmt.Errorf("invalid spilled value type: %d", typ)
+}
+
+func (r *spillRun) readString() (string, error) {
+	n, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return "", err
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r.r, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// Close closes and removes the temporary file of the run.
+func (r *spillRun) Close() error {
+	r.f.Close()
+	return os.Remove(r.f.Name())
+}
+
+func appendVarint(b []byte, v int64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(b, buf[:binary.PutVarint(buf[:], v)]...)
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(b, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+func appendSpillString(b []byte, s string) []byte {
+	b = appendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+// spillHeap is a heap of runs ordered by their current row. It merges the
+// rows of sorted runs.
+type spillHeap struct {
+	order *rowOrder
+	runs  []*spillRun
+}
+
+func (h *spillHeap) Len() int           { return len(h.runs) }
+func (h *spillHeap) Less(i, j int) bool { return h.order.less(&h.runs[i].row, &h.runs[j].row) }
+func (h *spillHeap) Swap(i, j int)      { h.runs[i], h.runs[j] = h.runs[j], h.runs[i] }
+
+func (h *spillHeap) Push(x interface{}) {
+	h.runs = append(h.runs, x.(*spillRun))
+}
+
+func (h *spillHeap) Pop() interface{} {
+	n := len(h.runs)
+	r := h.runs[n-1]
+	h.runs = h.runs[:n-1]
+	return r
+}
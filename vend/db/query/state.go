@@ -0,0 +1,203 @@
+package query
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+)
+
+// stateFieldName returns the name of the field whose values are passed to
+// a state function. The condition of the function refers to the values by
+// this name, even when they are the result of an aggregate such as
+// mean(value).
+func stateFieldName(expr cnosql.Expr) string {
+	switch expr := expr.(type) {
+	case *cnosql.VarRef:
+		return expr.Val
+	case *cnosql.Call:
+		if len(expr.Args) > 0 {
+			return stateFieldName(expr.Args[0])
+		}
+	}
+	return ""
+}
+
+// validateStateCondition verifies that the condition of a state function
+// only refers to the field passed to the function.
+func validateStateCondition(name, field string, cond cnosql.Expr) error {
+	var refs int
+	var err error
+	cnosql.WalkFunc(cond, func(n cnosql.Node) {
+		if err != nil {
+			return
+		}
+		switch n := n.(type) {
+		case *cnosql.VarRef:
+			if n.Val != field {
+				err = fmt.Errorf("condition of %s() can only refer to %s, got %s", name, field, n.Val)
+			}
+			refs++
+		case *cnosql.Call:
+			err = fmt.Errorf("condition of %s() cannot call %s()", name, n.Name)
+		case *cnosql.Wildcard:
+			err = fmt.Errorf("condition of %s() cannot use a wildcard", name)
+		}
+	})
+	if err != nil {
+		return err
+	} else if refs == 0 {
+		return fmt.Errorf("condition of %s() must refer to %s", name, field)
+	}
+	return nil
+}
+
+// stateUnit returns the unit of the durations returned by state_duration().
+// The durations are returned in seconds unless a unit is passed to the call.
+func stateUnit(call *cnosql.Call) time.Duration {
+	if len(call.Args) == 3 {
+		if lit, ok := call.Args[2].(*cnosql.DurationLiteral); ok {
+			return lit.Val
+		}
+	}
+	return time.Second
+}
+
+// newStateIterator returns an iterator for operating on a state_duration()
+// or state_count() call.
+func newStateIterator(input Iterator, call *cnosql.Call, opt IteratorOptions) (Iterator, error) {
+	field, cond := stateFieldName(call.Args[0]), call.Args[1]
+	newReducer := func() *StateReducer {
+		if call.Name == "state_count" {
+			return NewStateCountReducer(field, cond)
+		}
+		return NewStateDurationReducer(field, cond, stateUnit(call))
+	}
+
+	switch input := input.(type) {
+	case FloatIterator:
+		createFn := func() (FloatPointAggregator, IntegerPointEmitter) {
+			fn := newReducer()
+			return fn, fn
+		}
+		return newFloatStreamIntegerIterator(input, createFn, opt), nil
+	case IntegerIterator:
+		createFn := func() (IntegerPointAggregator, IntegerPointEmitter) {
+			fn := newReducer()
+			return fn, fn
+		}
+		return newIntegerStreamIntegerIterator(input, createFn, opt), nil
+	case UnsignedIterator:
+		createFn := func() (UnsignedPointAggregator, IntegerPointEmitter) {
+			fn := newReducer()
+			return fn, fn
+		}
+		return newUnsignedStreamIntegerIterator(input, createFn, opt), nil
+	case StringIterator:
+		createFn := func() (StringPointAggregator, IntegerPointEmitter) {
+			fn := newReducer()
+			return fn, fn
+		}
+		return newStringStreamIntegerIterator(input, createFn, opt), nil
+	case BooleanIterator:
+		createFn := func() (BooleanPointAggregator, IntegerPointEmitter) {
+			fn := newReducer()
+			return fn, fn
+		}
+		return newBooleanStreamIntegerIterator(input, createFn, opt), nil
+	default:
+		return nil, fmt.Errorf("unsupported %s iterator type: %T", call.Name, input)
+	}
+}
+
+// StateReducer tracks how long, or for how many consecutive points, the
+// values of a series have matched a condition. It emits -1 for the points
+// that do not match the condition. Points must be aggregated in ascending
+// time order. Null values are skipped without changing the state.
+type StateReducer struct {
+	field string
+	cond  cnosql.Expr
+	unit  int64 // zero when counting points
+
+	active bool
+	start  int64
+	count  int64
+	curr   IntegerPoint
+}
+
+// NewStateDurationReducer creates a new StateReducer that emits the time
+// since the values started to match the condition in multiples of unit.
+func NewStateDurationReducer(field string, cond cnosql.Expr, unit time.Duration) *StateReducer {
+	return &StateReducer{
+		field: field,
+		cond:  cond,
+		unit:  int64(unit),
+		curr:  IntegerPoint{Nil: true},
+	}
+}
+
+// NewStateCountReducer creates a new StateReducer that emits the number of
+// consecutive points that have matched the condition.
+func NewStateCountReducer(field string, cond cnosql.Expr) *StateReducer {
+	return &StateReducer{
+		field: field,
+		cond:  cond,
+		curr:  IntegerPoint{Nil: true},
+	}
+}
+
+// AggregateFloat aggregates a point into the reducer.
+func (r *StateReducer) AggregateFloat(p *FloatPoint) {
+	r.aggregate(p.Time, p.Value, p.Nil)
+}
+
+// AggregateInteger aggregates a point into the reducer.
+func (r *StateReducer) AggregateInteger(p *IntegerPoint) {
+	r.aggregate(p.Time, p.Value, p.Nil)
+}
+
+// AggregateUnsigned aggregates a point into the reducer.
+func (r *StateReducer) AggregateUnsigned(p *UnsignedPoint) {
+	r.aggregate(p.Time, p.Value, p.Nil)
+}
+
+// AggregateString aggregates a point into the reducer.
+func (r *StateReducer) AggregateString(p *StringPoint) {
+	r.aggregate(p.Time, p.Value, p.Nil)
+}
+
+// AggregateBoolean aggregates a point into the reducer.
+func (r *StateReducer) AggregateBoolean(p *BooleanPoint) {
+	r.aggregate(p.Time, p.Value, p.Nil)
+}
+
+func (r *StateReducer) aggregate(ts int64, value interface{}, isNil bool) {
+	if isNil {
+		r.curr = IntegerPoint{Nil: true}
+		return
+	}
+
+	if ok, _ := cnosql.Eval(r.cond, map[string]interface{}{r.field: value}).(bool); !ok {
+		r.active = false
+		r.curr = IntegerPoint{Time: ts, Value: -1}
+		return
+	}
+
+	if !r.active {
+		r.active, r.start, r.count = true, ts, 0
+	}
+	r.count++
+
+	r.curr = IntegerPoint{Time: ts, Value: r.count}
+	if r.unit != 0 {
+		r.curr.Value = (ts - r.start) / r.unit
+	}
+}
+
+// Emit emits the state of the reducer at the current point.
+func (r *StateReducer) Emit() []IntegerPoint {
+	if r.curr.Nil {
+		return nil
+	}
+	return []IntegerPoint{r.curr}
+}
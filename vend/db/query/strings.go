@@ -0,0 +1,263 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+)
+
+func isStringFunction(call *cnosql.Call) bool {
+	return isStringFunctionName(call.Name)
+}
+
+func isStringFunctionName(name string) bool {
+	switch name {
+	case "lower", "upper", "trim", "strlen", "substr", "concat", "replace",
+		"regexp_extract", "regexp_match", "split_part", "str_to_float", "str_to_int":
+		return true
+	}
+	return false
+}
+
+// stringFunctionArgs returns the minimum and maximum number of arguments
+// accepted by a string function. A maximum of -1 means the function is variadic.
+func stringFunctionArgs(name string) (min, max int) {
+	switch name {
+	case "substr", "regexp_extract":
+		return 2, 3
+	case "concat":
+		return 2, -1
+	case "replace", "split_part":
+		return 3, 3
+	case "regexp_match":
+		return 2, 2
+	default:
+		return 1, 1
+	}
+}
+
+// validateStringFunctionArgs verifies the number of arguments passed to a
+// string function.
+func validateStringFunctionArgs(expr *cnosql.Call) error {
+	min, max := stringFunctionArgs(expr.Name)
+	got := len(expr.Args)
+	switch {
+	case min == max && got != min:
+		return fmt.Errorf("invalid number of arguments for %s, expected %d, got %d", expr.Name, min, got)
+	case got < min:
+		return fmt.Errorf("invalid number of arguments for %s, expected at least %d, got %d", expr.Name, min, got)
+	case max >= 0 && got > max:
+		return fmt.Errorf("invalid number of arguments for %s, expected at most %d, got %d", expr.Name, max, got)
+	}
+	return nil
+}
+
+// compileRegexpPattern replaces the string literal pattern of a regular
+// expression function with a regex literal, so that it is compiled once for
+// the query rather than for every point. Invalid patterns are reported.
+func compileRegexpPattern(expr *cnosql.Call) error {
+	if expr.Name != "regexp_match" && expr.Name != "regexp_extract" {
+		return nil
+	}
+	lit, ok := expr.Args[1].(*cnosql.StringLiteral)
+	if !ok {
+		return nil
+	}
+	re, err := regexp.Compile(lit.Val)
+	if err != nil {
+		return fmt.Errorf("invalid regular expression for %s: %s", expr.Name, err)
+	}
+	expr.Args[1] = &cnosql.RegexLiteral{Val: re}
+	return nil
+}
+
+// StringValuer evaluates the string manipulation functions implemented by
+// the query engine.
+type StringValuer struct{}
+
+var _ cnosql.CallValuer = StringValuer{}
+
+func (StringValuer) Value(key string) (interface{}, bool) {
+	return nil, false
+}
+
+func (v StringValuer) Call(name string, args []interface{}) (interface{}, bool) {
+	if !isStringFunctionName(name) {
+		return nil, false
+	}
+	min, max := stringFunctionArgs(name)
+	if len(args) < min || (max >= 0 && len(args) > max) {
+		return nil, false
+	}
+
+	if name == "concat" {
+		var b strings.Builder
+		for _, arg := range args {
+			s, ok := formatString(arg)
+			if !ok {
+				return nil, true
+			}
+			b.WriteString(s)
+		}
+		return b.String(), true
+	}
+
+	arg0, ok := args[0].(string)
+	if !ok {
+		return nil, true
+	}
+	switch name {
+	case "lower":
+		return strings.ToLower(arg0), true
+	case "upper":
+		return strings.ToUpper(arg0), true
+	case "trim":
+		return strings.TrimSpace(arg0), true
+	case "strlen":
+		return int64(utf8.RuneCountInString(arg0)), true
+	case "substr":
+		start, ok := asInteger(args[1])
+		if !ok {
+			return nil, true
+		}
+		length := int64(-1)
+		if len(args) == 3 {
+			if length, ok = asInteger(args[2]); !ok || length < 0 {
+				return nil, true
+			}
+		}
+		return substr(arg0, start, length), true
+	case "replace":
+		old, ok1 := args[1].(string)
+		repl, ok2 := args[2].(string)
+		if !ok1 || !ok2 {
+			return nil, true
+		}
+		return strings.ReplaceAll(arg0, old, repl), true
+	case "regexp_match":
+		re, ok := asRegexp(args[1])
+		if !ok {
+			return nil, true
+		}
+		return re.MatchString(arg0), true
+	case "regexp_extract":
+		re, ok := asRegexp(args[1])
+		if !ok {
+			return nil, true
+		}
+		group := int64(0)
+		if len(args) == 3 {
+			if group, ok = asInteger(args[2]); !ok || group < 0 || group > int64(re.NumSubexp()) {
+				return nil, true
+			}
+		}
+		m := re.FindStringSubmatchIndex(arg0)
+		if m == nil || m[2*group] < 0 {
+			return nil, true
+		}
+		return arg0[m[2*group]:m[2*group+1]], true
+	case "split_part":
+		delim, ok := args[1].(string)
+		if !ok || delim == "" {
+			return nil, true
+		}
+		n, ok := asInteger(args[2])
+		if !ok || n < 1 {
+			return nil, true
+		}
+		parts := strings.Split(arg0, delim)
+		if n > int64(len(parts)) {
+			return "", true
+		}
+		return parts[n-1], true
+	case "str_to_float":
+		f, err := strconv.ParseFloat(strings.TrimSpace(arg0), 64)
+		if err != nil {
+			return nil, true
+		}
+		return f, true
+	case "str_to_int":
+		i, err := strconv.ParseInt(strings.TrimSpace(arg0), 10, 64)
+		if err != nil {
+			return nil, true
+		}
+		return i, true
+	}
+	return nil, false
+}
+
+// substr returns the substring of s beginning at the 1-based rune position
+// start. A negative length returns the remainder of the string.
+func substr(s string, start, length int64) string {
+	runes := []rune(s)
+	if start < 1 {
+		if length >= 0 {
+			if start <= -length {
+				length = 0
+			} else {
+				length += start - 1
+			}
+		}
+		start = 1
+	}
+	if start > int64(len(runes)) {
+		return ""
+	}
+	end := int64(len(runes))
+	if length >= 0 && length < end-(start-1) {
+		end = start - 1 + length
+	}
+	return string(runes[start-1 : end])
+}
+
+func asInteger(x interface{}) (int64, bool) {
+	switch arg := x.(type) {
+	case int64:
+		return arg, true
+	case uint64:
+		return int64(arg), true
+	case float64:
+		if arg != float64(int64(arg)) {
+			return 0, false
+		}
+		return int64(arg), true
+	default:
+		return 0, false
+	}
+}
+
+func asRegexp(x interface{}) (*regexp.Regexp, bool) {
+	switch arg := x.(type) {
+	case *regexp.Regexp:
+		return arg, true
+	case string:
+		re, err := regexp.Compile(arg)
+		if err != nil {
+			return nil, false
+		}
+		return re, true
+	default:
+		return nil, false
+	}
+}
+
+func formatString(x interface{}) (string, bool) {
+	switch arg := x.(type) {
+	case string:
+		return arg, true
+	case float64:
+		return strconv.FormatFloat(arg, 'f', -1, 64), true
+	case int64:
+		return strconv.FormatInt(arg, 10), true
+	case uint64:
+		return strconv.FormatUint(arg, 10), true
+	case bool:
+		return strconv.FormatBool(arg), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,36 @@
+package query_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cnosdb/cnosdb/vend/db/query"
+)
+
+func TestStringValuer_Substr(t *testing.T) {
+	for _, tt := range []struct {
+		args []interface{}
+		exp  interface{}
+	}{
+		{args: []interface{}{"server01", int64(1)}, exp: "server01"},
+		{args: []interface{}{"server01", int64(2), int64(3)}, exp: "erv"},
+		{args: []interface{}{"server01", int64(7), int64(5)}, exp: "01"},
+		{args: []interface{}{"server01", int64(9), int64(1)}, exp: ""},
+		{args: []interface{}{"server01", int64(0), int64(2)}, exp: "s"},
+		{args: []interface{}{"server01", int64(-2), int64(3)}, exp: ""},
+		{args: []interface{}{"héllo", int64(2), int64(2)}, exp: "él"},
+		{args: []interface{}{"server01", int64(2), int64(math.MaxInt64)}, exp: "erver01"},
+		{args: []interface{}{"server01", int64(-1), int64(math.MaxInt64)}, exp: "server01"},
+		{args: []interface{}{"server01", int64(math.MaxInt64), int64(math.MaxInt64)}, exp: ""},
+		{args: []interface{}{"server01", int64(math.MinInt64), int64(math.MaxInt64)}, exp: ""},
+		{args: []interface{}{"server01", int64(math.MinInt64), int64(5)}, exp: ""},
+		{args: []interface{}{"server01", int64(1), int64(-1)}, exp: nil},
+	} {
+		got, ok := query.StringValuer{}.Call("substr", tt.args)
+		if !ok {
+			t.Fatalf("substr%v not evaluated", tt.args)
+		} else if got != tt.exp {
+			t.Errorf("unexpected substr%v: got=%#v exp=%#v", tt.args, got, tt.exp)
+		}
+	}
+}
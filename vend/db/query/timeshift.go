@@ -0,0 +1,54 @@
+package query
+
+import (
+	"time"
+
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+)
+
+// timeShiftArg returns the duration passed to timeshift().
+func timeShiftArg(call *cnosql.Call) time.Duration {
+	if lit, ok := call.Args[1].(*cnosql.DurationLiteral); ok {
+		return lit.Val
+	}
+	return 0
+}
+
+// shiftTime returns the options for reading the data of the time range that
+// starts shift before the time range of opt. The windows are moved with the
+// time range so they still line up with the windows of opt once the points
+// are moved forward by shift.
+func (opt IteratorOptions) shiftTime(shift time.Duration) IteratorOptions {
+	if opt.StartTime != cnosql.MinTime {
+		if opt.StartTime-cnosql.MinTime > int64(shift) {
+			opt.StartTime -= int64(shift)
+		} else {
+			opt.StartTime = cnosql.MinTime
+		}
+	}
+	if opt.EndTime != cnosql.MaxTime {
+		opt.EndTime -= int64(shift)
+	}
+	if !opt.Interval.IsZero() {
+		opt.Interval.Offset -= shift % opt.Interval.Duration
+	}
+	return opt
+}
+
+// timeShiftScanner moves the points of an IteratorScanner forward in time.
+type timeShiftScanner struct {
+	IteratorScanner
+	shift int64
+}
+
+func (s *timeShiftScanner) Peek() (int64, string, Tags) {
+	ts, name, tags := s.IteratorScanner.Peek()
+	if ts != ZeroTime {
+		ts += s.shift
+	}
+	return ts, name, tags
+}
+
+func (s *timeShiftScanner) ScanAt(ts int64, name string, tags Tags, m map[string]interface{}) {
+	s.IteratorScanner.ScanAt(ts-s.shift, name, tags, m)
+}
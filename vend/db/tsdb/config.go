@@ -135,6 +135,12 @@ type Config struct {
 	// been found to be problematic in some cases. It may help users who have
 	// slow disks.
 	TSMWillNeed bool `toml:"tsm-use-madv-willneed"`
+
+	// LastValueCacheEnabled keeps the first and last values of every series in
+	// memory, so last() and first() queries without a time range do not read
+	// the TSM files. The cache is rebuilt from the TSM files in the background
+	// when a shard opens.
+	LastValueCacheEnabled bool `toml:"last-value-cache-enabled"`
 }
 
 // NewConfig returns the default configuration for tsdb.
@@ -220,5 +226,6 @@ func (c Config) Diagnostics() (*diagnostics.Diagnostics, error) {
 		"max-concurrent-compactions":         c.MaxConcurrentCompactions,
 		"max-index-log-file-size":            c.MaxIndexLogFileSize,
 		"series-id-set-cache-size":           c.SeriesIDSetCacheSize,
+		"last-value-cache-enabled":           c.LastValueCacheEnabled,
 	}), nil
 }
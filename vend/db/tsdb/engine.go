@@ -191,6 +191,14 @@ type EngineOptions struct {
 	SeriesIDSets   SeriesIDSets
 	FieldValidator FieldValidator
 
+	// MeasurementSchemas provides the write schemas enforced on points
+	// written to the shards. nil disables schema enforcement.
+	MeasurementSchemas MeasurementSchemaSource
+
+	// ShardObserver is notified when the data of a shard changes. nil
+	// disables the notifications.
+	ShardObserver ShardObserver
+
 	OnNewEngine func(Engine)
 
 	FileStoreObserver FileStoreObserver
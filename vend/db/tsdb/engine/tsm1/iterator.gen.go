@@ -226,6 +226,8 @@ func newFloatIterator(name string, tags query.Tags, opt query.IteratorOptions, c
 	itr.valuer = cnosql.ValuerEval{
 		Valuer: cnosql.MultiValuer(
 			query.MathValuer{},
+			query.StringValuer{},
+			query.ConditionalValuer{},
 			cnosql.MapValuer(itr.m),
 		),
 	}
@@ -706,6 +708,8 @@ func newIntegerIterator(name string, tags query.Tags, opt query.IteratorOptions,
 	itr.valuer = cnosql.ValuerEval{
 		Valuer: cnosql.MultiValuer(
 			query.MathValuer{},
+			query.StringValuer{},
+			query.ConditionalValuer{},
 			cnosql.MapValuer(itr.m),
 		),
 	}
@@ -1186,6 +1190,8 @@ func newUnsignedIterator(name string, tags query.Tags, opt query.IteratorOptions
 	itr.valuer = cnosql.ValuerEval{
 		Valuer: cnosql.MultiValuer(
 			query.MathValuer{},
+			query.StringValuer{},
+			query.ConditionalValuer{},
 			cnosql.MapValuer(itr.m),
 		),
 	}
@@ -1666,6 +1672,8 @@ func newStringIterator(name string, tags query.Tags, opt query.IteratorOptions,
 	itr.valuer = cnosql.ValuerEval{
 		Valuer: cnosql.MultiValuer(
 			query.MathValuer{},
+			query.StringValuer{},
+			query.ConditionalValuer{},
 			cnosql.MapValuer(itr.m),
 		),
 	}
@@ -2146,6 +2154,8 @@ func newBooleanIterator(name string, tags query.Tags, opt query.IteratorOptions,
 	itr.valuer = cnosql.ValuerEval{
 		Valuer: cnosql.MultiValuer(
 			query.MathValuer{},
+			query.StringValuer{},
+			query.ConditionalValuer{},
 			cnosql.MapValuer(itr.m),
 		),
 	}
@@ -2323,6 +2323,14 @@ func (is IndexSet) seriesByExprIterator(name []byte, expr cnosql.Expr) (SeriesID
 		}
 		return nil, nil
 
+	case *cnosql.Call:
+		// A function returning a boolean is evaluated by the query engine.
+		itr, err := is.measurementSeriesIDIterator(name)
+		if err != nil {
+			return nil, err
+		}
+		return newSeriesIDExprIterator(itr, expr), nil
+
 	default:
 		return nil, nil
 	}
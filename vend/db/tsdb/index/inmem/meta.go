@@ -870,6 +870,14 @@ func (m *measurement) WalkWhereForSeriesIds(expr cnosql.Expr) (seriesIDs, Filter
 			return m.SeriesIDs(), nil, nil
 		}
 		return nil, nil, nil
+	case *cnosql.Call:
+		// A function returning a boolean is evaluated by the query engine.
+		ids := m.SeriesIDs()
+		filters := make(FilterExprs, len(ids))
+		for _, id := range ids {
+			filters[id] = n
+		}
+		return ids, filters, nil
 	default:
 		return nil, nil, nil
 	}
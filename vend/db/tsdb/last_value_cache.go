@@ -0,0 +1,616 @@
+package tsdb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+	"github.com/cnosdb/cnosdb/vend/db/models"
+	"github.com/cnosdb/cnosdb/vend/db/query"
+)
+
+const (
+	// lastValueSeriesOverhead and lastValueFieldOverhead are the approximate
+	// memory used by the entries of a series and of a field in addition to
+	// their keys and values.
+	lastValueSeriesOverhead = 96
+	lastValueFieldOverhead  = 112
+)
+
+// lastValueCache keeps the first and last values of the fields of every series
+// of a shard by measurement. It is updated by writes, loaded from the engine
+// in the background when the shard opens and reloaded by measurement when
+// points are deleted.
+type lastValueCache struct {
+	mu           sync.RWMutex
+	gen          uint64
+	loaded       bool
+	closed       bool
+	measurements map[string]map[string]*lastValueSeries
+	size         int64
+
+	// version is incremented by every invalidation of a measurement.
+	// invalidated holds the version of the last invalidation of each
+	// measurement, and reloading the version of the measurements not used
+	// by queries until they are reloaded.
+	version     uint64
+	invalidated map[string]uint64
+	reloading   map[string]uint64
+
+	wg   sync.WaitGroup
+	hits int64
+}
+
+// lastValueEngine is the part of an Engine the cache is loaded from.
+type lastValueEngine interface {
+	CreateIterator(ctx context.Context, measurement string, opt query.IteratorOptions) (query.Iterator, error)
+	MeasurementFields(measurement []byte) *MeasurementFields
+	ForEachMeasurementName(fn func(name []byte) error) error
+	MeasurementTagKeysByExpr(name []byte, expr cnosql.Expr) (map[string]struct{}, error)
+}
+
+// lastValueSeries holds the values of the fields of a series.
+type lastValueSeries struct {
+	tags   models.Tags
+	fields map[string]*lastValueField
+}
+
+type lastValueField struct {
+	first, last lastValue
+}
+
+type lastValue struct {
+	time  int64
+	value interface{}
+}
+
+func newLastValueCache() *lastValueCache {
+	return &lastValueCache{
+		measurements: make(map[string]map[string]*lastValueSeries),
+		invalidated:  make(map[string]uint64),
+		reloading:    make(map[string]uint64),
+	}
+}
+
+// memSize returns the approximate memory used by the cache.
+func (c *lastValueCache) memSize() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.size
+}
+
+// reset empties the cache until it is loaded again. It returns the generation
+// of the cache a load must set the values for, the loads of the previous
+// generations stop.
+func (c *lastValueCache) reset() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	c.loaded = false
+	c.closed = false
+	c.measurements = make(map[string]map[string]*lastValueSeries)
+	c.invalidated = make(map[string]uint64)
+	c.reloading = make(map[string]uint64)
+	c.size = 0
+	return c.gen
+}
+
+// close resets the cache and waits for the loads in the background to stop.
+// The cache is not invalidated until it is loaded again.
+func (c *lastValueCache) close() {
+	c.reset()
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+	c.wg.Wait()
+}
+
+// write records the values of points written to the shard.
+func (c *lastValueCache) write(points []models.Point) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, p := range points {
+		t := p.UnixNano()
+		itr := p.FieldIterator()
+		for itr.Next() {
+			v, err := fieldIteratorValue(itr)
+			if err != nil {
+				continue
+			}
+			c.set(p.Name(), p.Key(), p.Tags, string(itr.FieldKey()), lastValue{time: t, value: v}, true, true, true)
+		}
+	}
+}
+
+// set records the value of a field of a series as its first and its last
+// value when they are older or newer. A value replaces a cached value at the
+// same time only if replace is true.
+func (c *lastValueCache) set(name, key []byte, tags func() models.Tags, field string, v lastValue, first, last, replace bool) {
+	series := c.measurements[string(name)]
+	if series == nil {
+		series = make(map[string]*lastValueSeries)
+		c.measurements[string(name)] = series
+	}
+
+	s := series[string(key)]
+	if s == nil {
+		s = &lastValueSeries{tags: tags().Clone(), fields: make(map[string]*lastValueField)}
+		series[string(key)] = s
+		c.size += int64(len(key)+s.tags.Size()) + lastValueSeriesOverhead
+	}
+
+	f := s.fields[field]
+	if f == nil {
+		s.fields[field] = &lastValueField{first: v, last: v}
+		c.size += int64(len(field)) + 2*valueSize(v.value) + lastValueFieldOverhead
+		return
+	}
+
+	if first && (v.time < f.first.time || (replace && v.time == f.first.time)) {
+		c.size += valueSize(v.value) - valueSize(f.first.value)
+		f.first = v
+	}
+	if last && (v.time > f.last.time || (replace && v.time == f.last.time)) {
+		c.size += valueSize(v.value) - valueSize(f.last.value)
+		f.last = v
+	}
+}
+
+// load resets the cache and rebuilds it in the background from the first and
+// last values of every field stored by the engine. Queries do not use the
+// cache until the load completes. onError is called if the load fails.
+func (c *lastValueCache) load(engine lastValueEngine, onError func(error)) {
+	gen := c.reset()
+
+	var names [][]byte
+	if err := engine.ForEachMeasurementName(func(name []byte) error {
+		names = append(names, append([]byte(nil), name...))
+		return nil
+	}); err != nil {
+		onError(err)
+		return
+	}
+
+	if len(names) == 0 {
+		c.mu.Lock()
+		if c.gen == gen {
+			c.loaded = true
+		}
+		c.mu.Unlock()
+		return
+	}
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		for _, name := range names {
+			if err := c.loadMeasurement(engine, gen, name); err != nil {
+				onError(err)
+				return
+			}
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.gen == gen {
+			c.loaded = true
+		}
+	}()
+}
+
+// invalidate drops the values of the measurements whose points were deleted
+// and reloads them from the engine in the background. Queries do not use the
+// cache for these measurements until they are reloaded. onError is called if
+// a reload fails, the measurement is then not used until the cache is loaded
+// again.
+func (c *lastValueCache) invalidate(engine lastValueEngine, names [][]byte, onError func(error)) {
+	if len(names) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+	gen := c.gen
+	versions := make([]uint64, len(names))
+	for i, name := range names {
+		c.version++
+		versions[i] = c.version
+		c.invalidated[string(name)] = c.version
+		c.reloading[string(name)] = c.version
+		c.drop(string(name))
+	}
+	c.wg.Add(1)
+	c.mu.Unlock()
+
+	go func() {
+		defer c.wg.Done()
+		for i, name := range names {
+			if err := c.loadMeasurement(engine, gen, name); err != nil {
+				onError(err)
+				continue
+			}
+
+			c.mu.Lock()
+			if c.gen == gen && c.reloading[string(name)] == versions[i] {
+				delete(c.reloading, string(name))
+			}
+			c.mu.Unlock()
+		}
+	}()
+}
+
+// drop removes the values of a measurement. This method assumes c's mutex is
+// already locked.
+func (c *lastValueCache) drop(name string) {
+	for key, s := range c.measurements[name] {
+		c.size -= int64(len(key)+s.tags.Size()) + lastValueSeriesOverhead
+		for field, f := range s.fields {
+			c.size -= int64(len(field)) + valueSize(f.first.value) + valueSize(f.last.value) + lastValueFieldOverhead
+		}
+	}
+	delete(c.measurements, name)
+}
+
+// loadMeasurement sets the first and last values of the fields of a
+// measurement stored by the engine. It stops without error when the cache is
+// reset or the measurement invalidated.
+func (c *lastValueCache) loadMeasurement(engine lastValueEngine, gen uint64, name []byte) error {
+	c.mu.RLock()
+	version := c.version
+	c.mu.RUnlock()
+
+	mf := engine.MeasurementFields(name)
+	if mf == nil {
+		return nil
+	}
+	keys, err := engine.MeasurementTagKeysByExpr(name, nil)
+	if err != nil {
+		return err
+	}
+	dimensions := make([]string, 0, len(keys))
+	for k := range keys {
+		dimensions = append(dimensions, k)
+	}
+	sort.Strings(dimensions)
+
+	for field, typ := range mf.FieldSet() {
+		for _, call := range []string{"first", "last"} {
+			if !c.current(gen, version, name) {
+				return nil
+			}
+
+			opt := query.IteratorOptions{
+				Expr: &cnosql.Call{
+					Name: call,
+					Args: []cnosql.Expr{&cnosql.VarRef{Val: field, Type: typ}},
+				},
+				Dimensions: dimensions,
+				StartTime:  cnosql.MinTime,
+				EndTime:    cnosql.MaxTime,
+				Ascending:  true,
+				Ordered:    true,
+			}
+			itr, err := engine.CreateIterator(context.Background(), string(name), opt)
+			if err != nil {
+				return err
+			} else if itr == nil {
+				continue
+			}
+
+			err = readIteratorValues(itr, func(tags query.Tags, v lastValue) bool {
+				return c.loadValue(gen, version, name, tags, field, v, call == "first")
+			})
+			itr.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// current returns true if the values of a measurement read by a load started
+// at the generation and the version of the cache are still valid.
+func (c *lastValueCache) current(gen, version uint64, name []byte) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.gen == gen && c.invalidated[string(name)] <= version
+}
+
+// loadValue sets a value read by a load of the cache. It returns false if
+// the cache was reset or the measurement invalidated since the load started.
+func (c *lastValueCache) loadValue(gen, version uint64, name []byte, qtags query.Tags, field string, v lastValue, first bool) bool {
+	tags := make(models.Tags, 0, len(qtags.KeyValues()))
+	for k, v := range qtags.KeyValues() {
+		if v != "" {
+			tags = append(tags, models.NewTag([]byte(k), []byte(v)))
+		}
+	}
+	sort.Sort(tags)
+	key := models.MakeKey(name, tags)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.gen != gen || c.invalidated[string(name)] > version {
+		return false
+	}
+	c.set(name, key, func() models.Tags { return tags }, field, v, first, !first, false)
+	return true
+}
+
+// iterator returns an iterator over the first or last values of a field
+// computed by the call of opt. It returns false if the cache cannot answer
+// the query.
+func (c *lastValueCache) iterator(ctx context.Context, database, name string, opt query.IteratorOptions, fields *MeasurementFields) (query.Iterator, bool, error) {
+	call, ok := opt.Expr.(*cnosql.Call)
+	if !ok || (call.Name != "first" && call.Name != "last") || len(call.Args) != 1 {
+		return nil, false, nil
+	}
+	ref, ok := call.Args[0].(*cnosql.VarRef)
+	if !ok || !opt.Interval.IsZero() || len(opt.Aux) > 0 || !opt.Ascending ||
+		opt.StartTime > cnosql.MinTime || opt.EndTime < cnosql.MaxTime ||
+		opt.Limit != 0 || opt.Offset != 0 || opt.SLimit != 0 || opt.SOffset != 0 {
+		return nil, false, nil
+	}
+
+	// Only conditions on the tags are evaluated with the cache.
+	if opt.Condition != nil {
+		tagsOnly := true
+		cnosql.WalkFunc(opt.Condition, func(n cnosql.Node) {
+			r, ok := n.(*cnosql.VarRef)
+			if !ok {
+				return
+			}
+			switch r.Type {
+			case cnosql.Tag:
+			case cnosql.Unknown:
+				if fields != nil && fields.HasField(r.Val) {
+					tagsOnly = false
+				}
+			default:
+				tagsOnly = false
+			}
+		})
+		if !tagsOnly {
+			return nil, false, nil
+		}
+	}
+
+	c.mu.RLock()
+	if _, ok := c.reloading[name]; !c.loaded || ok {
+		c.mu.RUnlock()
+		return nil, false, nil
+	}
+
+	type seriesValue struct {
+		tags  query.Tags
+		dims  string
+		value lastValue
+	}
+	// The tags missing from a series are empty, as they are for the index.
+	var condNames []cnosql.VarRef
+	if opt.Condition != nil {
+		condNames = cnosql.ExprNames(opt.Condition)
+	}
+
+	var values []seriesValue
+	for _, s := range c.measurements[name] {
+		f := s.fields[ref.Val]
+		if f == nil {
+			continue
+		}
+		if opt.Condition != nil {
+			m := make(map[string]interface{}, len(condNames)+len(s.tags))
+			for _, r := range condNames {
+				m[r.Val] = ""
+			}
+			for _, t := range s.tags {
+				m[string(t.Key)] = string(t.Value)
+			}
+			if !cnosql.EvalBool(opt.Condition, m) {
+				continue
+			}
+		}
+		if !query.AuthorizerIsOpen(opt.Authorizer) && !opt.Authorizer.AuthorizeSeriesRead(database, []byte(name), s.tags) {
+			continue
+		}
+
+		v := f.last
+		if call.Name == "first" {
+			v = f.first
+		}
+		// The values are cast to the type of the field of the query, and
+		// the series whose values cannot be cast are skipped, as the engine
+		// does.
+		if v.value, ok = castValue(v.value, ref.Type); !ok {
+			continue
+		}
+
+		tags := query.NewTags(s.tags.Map())
+		values = append(values, seriesValue{tags: tags, dims: tags.Subset(opt.Dimensions).ID(), value: v})
+	}
+	c.mu.RUnlock()
+	atomic.AddInt64(&c.hits, 1)
+
+	if len(values) == 0 {
+		return nil, true, nil
+	}
+
+	// The points of a series must be grouped and sorted for the call iterator.
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].dims != values[j].dims {
+			return values[i].dims < values[j].dims
+		} else if values[i].value.time != values[j].value.time {
+			return values[i].value.time < values[j].value.time
+		}
+		return values[i].tags.ID() < values[j].tags.ID()
+	})
+
+	var buf bytes.Buffer
+	typ := cnosql.Unknown
+	for _, v := range values {
+		t, err := encodeValue(&buf, name, v.tags, v.value)
+		if err != nil {
+			return nil, false, err
+		} else if typ != cnosql.Unknown && t != typ {
+			// The values have different types so the cache cannot be used.
+			return nil, false, nil
+		}
+		typ = t
+	}
+
+	itr, err := query.NewCallIterator(query.NewReaderIterator(ctx, &buf, typ, query.IteratorStats{}), opt)
+	if err != nil {
+		return nil, false, err
+	}
+	return itr, true, nil
+}
+
+// fieldIteratorValue returns the value of the current field of a point.
+func fieldIteratorValue(itr models.FieldIterator) (interface{}, error) {
+	switch itr.Type() {
+	case models.Float:
+		return itr.FloatValue()
+	case models.Integer:
+		return itr.IntegerValue()
+	case models.Unsigned:
+		return itr.UnsignedValue()
+	case models.String:
+		return itr.StringValue(), nil
+	case models.Boolean:
+		return itr.BooleanValue()
+	default:
+		return nil, fmt.Errorf("unknown field type: %v", itr.Type())
+	}
+}
+
+// readIteratorValues calls fn with the tags and the value of every point of
+// an iterator until fn returns false.
+func readIteratorValues(itr query.Iterator, fn func(tags query.Tags, v lastValue) bool) error {
+	switch itr := itr.(type) {
+	case query.FloatIterator:
+		for {
+			p, err := itr.Next()
+			if err != nil || p == nil {
+				return err
+			} else if !p.Nil && !fn(p.Tags, lastValue{time: p.Time, value: p.Value}) {
+				return nil
+			}
+		}
+	case query.IntegerIterator:
+		for {
+			p, err := itr.Next()
+			if err != nil || p == nil {
+				return err
+			} else if !p.Nil && !fn(p.Tags, lastValue{time: p.Time, value: p.Value}) {
+				return nil
+			}
+		}
+	case query.UnsignedIterator:
+		for {
+			p, err := itr.Next()
+			if err != nil || p == nil {
+				return err
+			} else if !p.Nil && !fn(p.Tags, lastValue{time: p.Time, value: p.Value}) {
+				return nil
+			}
+		}
+	case query.StringIterator:
+		for {
+			p, err := itr.Next()
+			if err != nil || p == nil {
+				return err
+			} else if !p.Nil && !fn(p.Tags, lastValue{time: p.Time, value: p.Value}) {
+				return nil
+			}
+		}
+	case query.BooleanIterator:
+		for {
+			p, err := itr.Next()
+			if err != nil || p == nil {
+				return err
+			} else if !p.Nil && !fn(p.Tags, lastValue{time: p.Time, value: p.Value}) {
+				return nil
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported iterator type: %T", itr)
+	}
+}
+
+// castValue casts a value to typ. It returns false if the value cannot be
+// cast. Values are not cast if typ is unknown or any field.
+func castValue(v interface{}, typ cnosql.DataType) (interface{}, bool) {
+	switch typ {
+	case cnosql.Unknown, cnosql.AnyField:
+		return v, true
+	case cnosql.Float:
+		switch v := v.(type) {
+		case float64:
+			return v, true
+		case int64:
+			return float64(v), true
+		case uint64:
+			return float64(v), true
+		}
+	case cnosql.Integer:
+		switch v := v.(type) {
+		case int64:
+			return v, true
+		case float64:
+			return int64(v), true
+		case uint64:
+			return int64(v), true
+		}
+	case cnosql.Unsigned:
+		switch v := v.(type) {
+		case uint64:
+			return v, true
+		case float64:
+			return uint64(v), true
+		case int64:
+			return uint64(v), true
+		}
+	case cnosql.String:
+		_, ok := v.(string)
+		return v, ok
+	case cnosql.Boolean:
+		_, ok := v.(bool)
+		return v, ok
+	}
+	return nil, false
+}
+
+// encodeValue encodes a value as a point of its type.
+func encodeValue(buf *bytes.Buffer, name string, tags query.Tags, v lastValue) (cnosql.DataType, error) {
+	switch value := v.value.(type) {
+	case float64:
+		return cnosql.Float, query.NewFloatPointEncoder(buf).EncodeFloatPoint(&query.FloatPoint{Name: name, Tags: tags, Time: v.time, Value: value})
+	case int64:
+		return cnosql.Integer, query.NewIntegerPointEncoder(buf).EncodeIntegerPoint(&query.IntegerPoint{Name: name, Tags: tags, Time: v.time, Value: value})
+	case uint64:
+		return cnosql.Unsigned, query.NewUnsignedPointEncoder(buf).EncodeUnsignedPoint(&query.UnsignedPoint{Name: name, Tags: tags, Time: v.time, Value: value})
+	case string:
+		return cnosql.String, query.NewStringPointEncoder(buf).EncodeStringPoint(&query.StringPoint{Name: name, Tags: tags, Time: v.time, Value: value})
+	case bool:
+		return cnosql.Boolean, query.NewBooleanPointEncoder(buf).EncodeBooleanPoint(&query.BooleanPoint{Name: name, Tags: tags, Time: v.time, Value: value})
+	default:
+		return cnosql.Unknown, fmt.Errorf("unsupported value type: %T", v.value)
+	}
+}
+
+// valueSize returns the approximate memory used by a value.
+func valueSize(v interface{}) int64 {
+	if s, ok := v.(string); ok {
+		return int64(len(s)) + 16
+	}
+	return 8
+}
@@ -0,0 +1,453 @@
+package tsdb
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+	"github.com/cnosdb/cnosdb/vend/db/models"
+	"github.com/cnosdb/cnosdb/vend/db/query"
+)
+
+// lastValueTestEngine is an engine storing float points the cache is loaded
+// from.
+type lastValueTestEngine struct {
+	mu     sync.Mutex
+	points map[string][]models.Point
+
+	// onCreate is called by CreateIterator and onNext by the iterators it
+	// creates before they return a point, when they are not nil.
+	onCreate func(measurement string)
+	onNext   func()
+}
+
+func newLastValueTestEngine(t *testing.T, lines string) *lastValueTestEngine {
+	t.Helper()
+	points, err := models.ParsePointsString(lines)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	e := &lastValueTestEngine{points: make(map[string][]models.Point)}
+	for _, p := range points {
+		e.points[string(p.Name())] = append(e.points[string(p.Name())], p)
+	}
+	return e
+}
+
+func (e *lastValueTestEngine) delete(name string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.points, name)
+}
+
+func (e *lastValueTestEngine) ForEachMeasurementName(fn func(name []byte) error) error {
+	e.mu.Lock()
+	names := make([]string, 0, len(e.points))
+	for name := range e.points {
+		names = append(names, name)
+	}
+	e.mu.Unlock()
+
+	sort.Strings(names)
+	for _, name := range names {
+		if err := fn([]byte(name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *lastValueTestEngine) MeasurementFields(name []byte) *MeasurementFields {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	points, ok := e.points[string(name)]
+	if !ok {
+		return nil
+	}
+	mf := NewMeasurementFields()
+	for _, p := range points {
+		itr := p.FieldIterator()
+		for itr.Next() {
+			mf.CreateFieldIfNotExists(itr.FieldKey(), cnosql.Float)
+		}
+	}
+	return mf
+}
+
+func (e *lastValueTestEngine) MeasurementTagKeysByExpr(name []byte, expr cnosql.Expr) (map[string]struct{}, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	keys := make(map[string]struct{})
+	for _, p := range e.points[string(name)] {
+		for _, t := range p.Tags() {
+			keys[string(t.Key)] = struct{}{}
+		}
+	}
+	return keys, nil
+}
+
+// CreateIterator returns the first or the last value of a field of every
+// series of a measurement.
+func (e *lastValueTestEngine) CreateIterator(ctx context.Context, measurement string, opt query.IteratorOptions) (query.Iterator, error) {
+	if e.onCreate != nil {
+		e.onCreate(measurement)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	call := opt.Expr.(*cnosql.Call)
+	field := call.Args[0].(*cnosql.VarRef).Val
+	values := make(map[string]*query.FloatPoint)
+	for _, p := range e.points[measurement] {
+		itr := p.FieldIterator()
+		for itr.Next() {
+			if string(itr.FieldKey()) != field {
+				continue
+			}
+			v, err := itr.FloatValue()
+			if err != nil {
+				return nil, err
+			}
+			prev := values[string(p.Key())]
+			if prev == nil || (call.Name == "first" && p.UnixNano() < prev.Time) || (call.Name == "last" && p.UnixNano() > prev.Time) {
+				values[string(p.Key())] = &query.FloatPoint{Name: measurement, Tags: query.NewTags(p.Tags().Map()), Time: p.UnixNano(), Value: v}
+			}
+		}
+	}
+
+	itr := &lastValueTestIterator{onNext: e.onNext}
+	for _, p := range values {
+		itr.points = append(itr.points, p)
+	}
+	return itr, nil
+}
+
+type lastValueTestIterator struct {
+	points []*query.FloatPoint
+	onNext func()
+}
+
+func (itr *lastValueTestIterator) Stats() query.IteratorStats { return query.IteratorStats{} }
+func (itr *lastValueTestIterator) Close() error               { return nil }
+
+func (itr *lastValueTestIterator) Next() (*query.FloatPoint, error) {
+	if len(itr.points) == 0 {
+		return nil, nil
+	} else if itr.onNext != nil {
+		itr.onNext()
+	}
+	p := itr.points[0]
+	itr.points = itr.points[1:]
+	return p, nil
+}
+
+// lastValueCacheValues returns the first and last values of a field of the
+// series of a measurement by series key.
+func lastValueCacheValues(c *lastValueCache, name, field string) map[string][2]lastValue {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	values := make(map[string][2]lastValue)
+	for key, s := range c.measurements[name] {
+		if f := s.fields[field]; f != nil {
+			values[key] = [2]lastValue{f.first, f.last}
+		}
+	}
+	return values
+}
+
+func mustParsePoints(t *testing.T, lines string) []models.Point {
+	t.Helper()
+	points, err := models.ParsePointsString(lines)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return points
+}
+
+func loadLastValueCache(t *testing.T, e *lastValueTestEngine) *lastValueCache {
+	t.Helper()
+	c := newLastValueCache()
+	c.load(e, func(err error) { t.Errorf("unexpected error: %s", err) })
+	c.wg.Wait()
+	return c
+}
+
+func TestLastValueCache_Write(t *testing.T) {
+	c := newLastValueCache()
+	c.write(mustParsePoints(t, `cpu,host=a value=2 20
+cpu,host=a value=1 10
+cpu,host=a value=3 30
+cpu,host=b value=4 10
+cpu,host=b value=5 10`))
+
+	exp := map[string][2]lastValue{
+		"cpu,host=a": {{time: 10, value: 1.0}, {time: 30, value: 3.0}},
+		"cpu,host=b": {{time: 10, value: 5.0}, {time: 10, value: 5.0}},
+	}
+	if got := lastValueCacheValues(c, "cpu", "value"); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected values:\ngot=%v\nexp=%v", got, exp)
+	}
+	if c.memSize() <= 0 {
+		t.Fatalf("unexpected size: %d", c.memSize())
+	}
+}
+
+func TestLastValueCache_Load(t *testing.T) {
+	e := newLastValueTestEngine(t, `cpu,host=a value=1 10
+cpu,host=a value=2 20
+cpu,host=b value=3 15
+mem,host=a free=4 5`)
+	c := loadLastValueCache(t, e)
+
+	if !c.loaded {
+		t.Fatal("expected cache to be loaded")
+	}
+	if got, exp := lastValueCacheValues(c, "cpu", "value"), map[string][2]lastValue{
+		"cpu,host=a": {{time: 10, value: 1.0}, {time: 20, value: 2.0}},
+		"cpu,host=b": {{time: 15, value: 3.0}, {time: 15, value: 3.0}},
+	}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected values:\ngot=%v\nexp=%v", got, exp)
+	}
+	if got, exp := lastValueCacheValues(c, "mem", "free"), map[string][2]lastValue{
+		"mem,host=a": {{time: 5, value: 4.0}, {time: 5, value: 4.0}},
+	}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected values:\ngot=%v\nexp=%v", got, exp)
+	}
+
+	// An empty engine is loaded at once.
+	c = newLastValueCache()
+	c.load(newLastValueTestEngine(t, ""), func(err error) { t.Errorf("unexpected error: %s", err) })
+	if !c.loaded {
+		t.Fatal("expected cache of an empty engine to be loaded")
+	}
+}
+
+// Ensure the values written while the cache loads are merged with the
+// values loaded, the values written winning at the same time.
+func TestLastValueCache_WriteDuringLoad(t *testing.T) {
+	e := newLastValueTestEngine(t, `cpu,host=a value=1 10
+cpu,host=a value=2 20
+cpu,host=b value=3 15`)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	e.onCreate = func(string) {
+		once.Do(func() {
+			close(started)
+			<-release
+		})
+	}
+
+	c := newLastValueCache()
+	c.load(e, func(err error) { t.Errorf("unexpected error: %s", err) })
+	<-started
+
+	c.mu.RLock()
+	loaded := c.loaded
+	c.mu.RUnlock()
+	if loaded {
+		t.Fatal("expected cache not to be loaded")
+	}
+	if itr, ok, err := c.iterator(context.Background(), "db0", "cpu", query.IteratorOptions{
+		Expr:      &cnosql.Call{Name: "last", Args: []cnosql.Expr{&cnosql.VarRef{Val: "value"}}},
+		StartTime: cnosql.MinTime,
+		EndTime:   cnosql.MaxTime,
+		Ascending: true,
+	}, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if ok || itr != nil {
+		t.Fatal("expected the cache not to be used while it loads")
+	}
+
+	c.write(mustParsePoints(t, `cpu,host=a value=5 30
+cpu,host=b value=6 15
+cpu,host=c value=7 1`))
+	close(release)
+	c.wg.Wait()
+
+	if !c.loaded {
+		t.Fatal("expected cache to be loaded")
+	}
+	if got, exp := lastValueCacheValues(c, "cpu", "value"), map[string][2]lastValue{
+		"cpu,host=a": {{time: 10, value: 1.0}, {time: 30, value: 5.0}},
+		"cpu,host=b": {{time: 15, value: 6.0}, {time: 15, value: 6.0}},
+		"cpu,host=c": {{time: 1, value: 7.0}, {time: 1, value: 7.0}},
+	}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected values:\ngot=%v\nexp=%v", got, exp)
+	}
+}
+
+// Ensure a load stops without setting values when the cache is reset.
+func TestLastValueCache_ResetDuringLoad(t *testing.T) {
+	e := newLastValueTestEngine(t, `cpu,host=a value=1 10
+mem,host=a free=2 10`)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	e.onCreate = func(string) {
+		once.Do(func() {
+			close(started)
+			<-release
+		})
+	}
+
+	c := newLastValueCache()
+	c.load(e, func(err error) { t.Errorf("unexpected error: %s", err) })
+	<-started
+	c.reset()
+	c.write(mustParsePoints(t, `disk,host=a used=3 10`))
+	close(release)
+	c.wg.Wait()
+
+	if c.loaded {
+		t.Fatal("expected cache not to be loaded")
+	}
+	if got := lastValueCacheValues(c, "cpu", "value"); len(got) != 0 {
+		t.Fatalf("unexpected values: %v", got)
+	} else if got := lastValueCacheValues(c, "mem", "free"); len(got) != 0 {
+		t.Fatalf("unexpected values: %v", got)
+	} else if got := lastValueCacheValues(c, "disk", "used"); len(got) != 1 {
+		t.Fatalf("unexpected values: %v", got)
+	}
+
+	// The cache is loaded by the next load.
+	e.onCreate = nil
+	c.load(e, func(err error) { t.Errorf("unexpected error: %s", err) })
+	c.wg.Wait()
+	if !c.loaded {
+		t.Fatal("expected cache to be loaded")
+	} else if got := lastValueCacheValues(c, "mem", "free"); len(got) != 1 {
+		t.Fatalf("unexpected values: %v", got)
+	}
+
+	// A closed cache is not invalidated.
+	c.close()
+	c.invalidate(e, [][]byte{[]byte("cpu")}, func(err error) { t.Errorf("unexpected error: %s", err) })
+	c.wg.Wait()
+	if got := lastValueCacheValues(c, "cpu", "value"); len(got) != 0 {
+		t.Fatalf("unexpected values: %v", got)
+	}
+}
+
+// Ensure only the measurements invalidated are reloaded, and are not used by
+// queries until they are.
+func TestLastValueCache_Invalidate(t *testing.T) {
+	e := newLastValueTestEngine(t, `cpu,host=a value=1 10
+cpu,host=a value=2 20
+cpu,host=b value=3 15
+mem,host=a free=4 5`)
+	c := loadLastValueCache(t, e)
+	size := c.memSize()
+
+	// Delete the last value of cpu,host=a and cpu,host=b from the engine.
+	e.mu.Lock()
+	e.points["cpu"] = e.points["cpu"][:1]
+	e.mu.Unlock()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	var created []string
+	e.onCreate = func(name string) {
+		created = append(created, name)
+		once.Do(func() {
+			close(started)
+			<-release
+		})
+	}
+	c.invalidate(e, [][]byte{[]byte("cpu")}, func(err error) { t.Errorf("unexpected error: %s", err) })
+	<-started
+
+	opt := query.IteratorOptions{
+		Expr:      &cnosql.Call{Name: "last", Args: []cnosql.Expr{&cnosql.VarRef{Val: "value"}}},
+		StartTime: cnosql.MinTime,
+		EndTime:   cnosql.MaxTime,
+		Ascending: true,
+	}
+	if _, ok, err := c.iterator(context.Background(), "db0", "cpu", opt, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if ok {
+		t.Fatal("expected the cache not to be used for a measurement reloading")
+	}
+	opt.Expr = &cnosql.Call{Name: "last", Args: []cnosql.Expr{&cnosql.VarRef{Val: "free"}}}
+	if itr, ok, err := c.iterator(context.Background(), "db0", "mem", opt, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !ok {
+		t.Fatal("expected the cache to be used for other measurements")
+	} else {
+		itr.Close()
+	}
+
+	c.write(mustParsePoints(t, `cpu,host=c value=5 30`))
+	close(release)
+	c.wg.Wait()
+
+	for _, name := range created {
+		if name != "cpu" {
+			t.Fatalf("unexpected measurement reloaded: %s", name)
+		}
+	}
+	if got, exp := lastValueCacheValues(c, "cpu", "value"), map[string][2]lastValue{
+		"cpu,host=a": {{time: 10, value: 1.0}, {time: 10, value: 1.0}},
+		"cpu,host=c": {{time: 30, value: 5.0}, {time: 30, value: 5.0}},
+	}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected values:\ngot=%v\nexp=%v", got, exp)
+	}
+	opt.Expr = &cnosql.Call{Name: "last", Args: []cnosql.Expr{&cnosql.VarRef{Val: "value"}}}
+	if itr, ok, err := c.iterator(context.Background(), "db0", "cpu", opt, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !ok {
+		t.Fatal("expected the cache to be used once reloaded")
+	} else {
+		itr.Close()
+	}
+
+	// Dropping a measurement releases its memory.
+	e.delete("cpu")
+	e.onCreate = nil
+	c.invalidate(e, [][]byte{[]byte("cpu")}, func(err error) { t.Errorf("unexpected error: %s", err) })
+	c.wg.Wait()
+	if got := lastValueCacheValues(c, "cpu", "value"); len(got) != 0 {
+		t.Fatalf("unexpected values: %v", got)
+	}
+	if exp := loadLastValueCache(t, e).memSize(); c.memSize() != exp || exp >= size {
+		t.Fatalf("unexpected size: got=%d exp=%d", c.memSize(), exp)
+	}
+}
+
+// Ensure the values read by a load before a measurement is invalidated are
+// discarded.
+func TestLastValueCache_InvalidateDuringLoad(t *testing.T) {
+	e := newLastValueTestEngine(t, `cpu,host=a value=1 10
+cpu,host=a value=2 20`)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	e.onNext = func() {
+		once.Do(func() {
+			close(started)
+			<-release
+		})
+	}
+
+	c := newLastValueCache()
+	c.load(e, func(err error) { t.Errorf("unexpected error: %s", err) })
+	<-started
+
+	// The points are deleted after the load read them from the engine.
+	e.delete("cpu")
+	c.invalidate(e, [][]byte{[]byte("cpu")}, func(err error) { t.Errorf("unexpected error: %s", err) })
+	close(release)
+	c.wg.Wait()
+
+	if !c.loaded {
+		t.Fatal("expected cache to be loaded")
+	} else if got := lastValueCacheValues(c, "cpu", "value"); len(got) != 0 {
+		t.Fatalf("unexpected values: %v", got)
+	} else if n := c.memSize(); n != 0 {
+		t.Fatalf("unexpected size: %d", n)
+	}
+}
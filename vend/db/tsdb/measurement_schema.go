@@ -0,0 +1,88 @@
+package tsdb
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+	"github.com/cnosdb/cnosdb/vend/db/models"
+	"github.com/cnosdb/cnosdb/vend/db/query"
+)
+
+// MeasurementSchema is the write schema of a measurement. Points written to
+// the measurement may only use the declared tag keys and fields, must write
+// fields with their declared types and must include all required fields.
+type MeasurementSchema struct {
+	Tags   map[string]struct{}
+	Fields map[string]MeasurementSchemaField
+}
+
+// MeasurementSchemaField declares a field of a MeasurementSchema.
+type MeasurementSchemaField struct {
+	Type     cnosql.DataType
+	Required bool
+}
+
+// MeasurementSchemaSource provides the write schemas of the measurements in a database.
+type MeasurementSchemaSource interface {
+	// MeasurementSchemas returns the schemas declared in the database, keyed
+	// by measurement name. It returns nil if no schemas are declared.
+	MeasurementSchemas(database string) map[string]*MeasurementSchema
+}
+
+// Validate returns a PartialWriteError if the point does not conform to the schema.
+func (s *MeasurementSchema) Validate(point models.Point) error {
+	for _, t := range point.Tags() {
+		// The cell tag is added by the points writer to the points with a
+		// location, so it is declared implicitly.
+		if string(t.Key) == query.GeoCellTag {
+			continue
+		}
+		if _, ok := s.Tags[string(t.Key)]; !ok {
+			return schemaViolation("input tag \"%s\" on measurement \"%s\" is not declared", t.Key, point.Name())
+		}
+	}
+
+	iter := point.FieldIterator()
+	for iter.Next() {
+		// Skip fields named "time", they are illegal and dropped on write.
+		if bytes.Equal(iter.FieldKey(), timeBytes) {
+			continue
+		}
+
+		f, ok := s.Fields[string(iter.FieldKey())]
+		if !ok {
+			return schemaViolation("input field \"%s\" on measurement \"%s\" is not declared", iter.FieldKey(), point.Name())
+		}
+
+		if dataType := dataTypeFromModelsFieldType(iter.Type()); dataType != f.Type {
+			return schemaViolation("input field \"%s\" on measurement \"%s\" is type %s, declared as type %s",
+				iter.FieldKey(), point.Name(), dataType, f.Type)
+		}
+	}
+
+	for name, f := range s.Fields {
+		if f.Required && !hasField(point, name) {
+			return schemaViolation("required field \"%s\" on measurement \"%s\" is missing", name, point.Name())
+		}
+	}
+	return nil
+}
+
+// hasField returns true if the point has a field with the given name.
+func hasField(point models.Point, name string) bool {
+	iter := point.FieldIterator()
+	for iter.Next() {
+		if string(iter.FieldKey()) == name {
+			return true
+		}
+	}
+	return false
+}
+
+func schemaViolation(format string, a ...interface{}) error {
+	return PartialWriteError{
+		Reason:  fmt.Sprintf("%s: %s", ErrSchemaViolation, fmt.Sprintf(format, a...)),
+		Dropped: 1,
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -42,6 +43,9 @@ const (
 	statWritePointsOK      = "writePointsOk"
 	statWriteBytes         = "writeBytes"
 	statDiskBytes          = "diskBytes"
+
+	statLastValueCacheHits        = "lastValueCacheHits"
+	statLastValueCacheMemoryBytes = "lastValueCacheMemBytes"
 )
 
 var (
@@ -51,6 +55,10 @@ var (
 	// ErrFieldTypeConflict is returned when a new field already exists with a different type.
 	ErrFieldTypeConflict = errors.New("field type conflict")
 
+	// ErrSchemaViolation is returned when a point does not conform to the
+	// write schema of its measurement.
+	ErrSchemaViolation = errors.New("schema violation")
+
 	// ErrFieldNotFound is returned when a field cannot be found.
 	ErrFieldNotFound = errors.New("field not found")
 
@@ -106,6 +114,13 @@ func (e ShardError) Error() string {
 	return fmt.Sprintf("[shard %d] %s", e.id, e.Err)
 }
 
+// ShardObserver is notified when the data of a shard changes.
+type ShardObserver interface {
+	// DataChanged is called after the points between min and max, inclusive,
+	// are written to or deleted from a shard.
+	DataChanged(shardID uint64, min, max int64)
+}
+
 // PartialWriteError indicates a write request could only write a portion of the
 // requested values.
 type PartialWriteError struct {
@@ -140,6 +155,10 @@ type Shard struct {
 	index   Index
 	enabled bool
 
+	// lastValues keeps the first and last values of the series when the
+	// last value cache is enabled.
+	lastValues *lastValueCache
+
 	// expvar-based stats.
 	stats       *ShardStatistics
 	defaultTags models.StatisticTags
@@ -186,6 +205,9 @@ func NewShard(id uint64, path string, walPath string, sfile *SeriesFile, opt Eng
 		baseLogger:   logger,
 		EnableOnOpen: true,
 	}
+	if opt.Config.LastValueCacheEnabled {
+		s.lastValues = newLastValueCache()
+	}
 	return s
 }
 
@@ -287,6 +309,10 @@ func (s *Shard) Statistics(tags map[string]string) []models.Statistic {
 			statDiskBytes:          atomic.LoadInt64(&s.stats.DiskBytes),
 		},
 	}}
+	if s.lastValues != nil {
+		statistics[0].Values[statLastValueCacheHits] = atomic.LoadInt64(&s.lastValues.hits)
+		statistics[0].Values[statLastValueCacheMemoryBytes] = s.lastValues.memSize()
+	}
 
 	// Add the index and engine statistics.
 	statistics = append(statistics, engine.Statistics(tags)...)
@@ -346,6 +372,7 @@ func (s *Shard) Open() error {
 			return err
 		}
 		s._engine = e
+		s.loadLastValues(e)
 
 		return nil
 	}(); err != nil {
@@ -375,6 +402,11 @@ func (s *Shard) close() error {
 		return nil
 	}
 
+	// Stop loading the last value cache before the engine closes.
+	if s.lastValues != nil {
+		s.lastValues.close()
+	}
+
 	err := s._engine.Close()
 	if err == nil {
 		s._engine = nil
@@ -530,9 +562,89 @@ func (s *Shard) WritePoints(points []models.Point) error {
 	atomic.AddInt64(&s.stats.WritePointsOK, int64(len(points)))
 	atomic.AddInt64(&s.stats.WriteReqOK, 1)
 
+	if s.lastValues != nil {
+		s.lastValues.write(points)
+	}
+
+	if s.options.ShardObserver != nil && len(points) > 0 {
+		min, max := points[0].UnixNano(), points[0].UnixNano()
+		for _, p := range points[1:] {
+			if t := p.UnixNano(); t < min {
+				min = t
+			} else if t > max {
+				max = t
+			}
+		}
+		s.options.ShardObserver.DataChanged(s.id, min, max)
+	}
+
 	return writeError
 }
 
+// dataChanged notifies the observer of the shard that the data between min
+// and max changed.
+func (s *Shard) dataChanged(min, max int64) {
+	if s.options.ShardObserver != nil {
+		s.options.ShardObserver.DataChanged(s.id, min, max)
+	}
+}
+
+// loadLastValues rebuilds the last value cache from the engine in the
+// background. The cache is not used by queries until it is loaded.
+func (s *Shard) loadLastValues(engine Engine) {
+	if s.lastValues == nil {
+		return
+	}
+	s.lastValues.load(engine, s.lastValuesError)
+}
+
+// invalidateLastValues reloads the values of measurements whose points were
+// deleted into the last value cache in the background.
+func (s *Shard) invalidateLastValues(engine Engine, names [][]byte) {
+	if s.lastValues == nil {
+		return
+	}
+	s.lastValues.invalidate(engine, names, s.lastValuesError)
+}
+
+func (s *Shard) lastValuesError(err error) {
+	s.logger.Warn("Failed to load the last value cache", zap.Uint64("shard", s.id), zap.Error(err))
+}
+
+// measurementRecorder is a SeriesIterator recording the names of the
+// measurements of the series it returns.
+type measurementRecorder struct {
+	SeriesIterator
+	names map[string]struct{}
+}
+
+func newMeasurementRecorder(itr SeriesIterator) *measurementRecorder {
+	return &measurementRecorder{SeriesIterator: itr, names: make(map[string]struct{})}
+}
+
+func (r *measurementRecorder) Next() (SeriesElem, error) {
+	elem, err := r.SeriesIterator.Next()
+	if elem != nil {
+		r.names[string(elem.Name())] = struct{}{}
+	}
+	return elem, err
+}
+
+// Names returns the names of the measurements recorded, sorted.
+func (r *measurementRecorder) Names() [][]byte {
+	names := make([]string, 0, len(r.names))
+	for name := range r.names {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	a := make([][]byte, len(names))
+	for i, name := range names {
+		a[i] = []byte(name)
+	}
+	return a
+}
+
 // validateSeriesAndFields checks which series and fields are new and whose metadata should be saved and indexed.
 func (s *Shard) validateSeriesAndFields(points []models.Point) ([]models.Point, []*FieldCreate, error) {
 	var (
@@ -550,6 +662,12 @@ func (s *Shard) validateSeriesAndFields(points []models.Point) ([]models.Point,
 	// Check if keys should be unicode validated.
 	validateKeys := s.options.Config.ValidateKeys
 
+	// Look up the write schemas declared for the measurements of the database.
+	var schemas map[string]*MeasurementSchema
+	if s.options.MeasurementSchemas != nil {
+		schemas = s.options.MeasurementSchemas.MeasurementSchemas(s.database)
+	}
+
 	var j int
 	for i, p := range points {
 		tags := p.Tags()
@@ -574,6 +692,17 @@ func (s *Shard) validateSeriesAndFields(points []models.Point) ([]models.Point,
 			continue
 		}
 
+		// Drop any points that do not conform to the schema of their measurement.
+		if schema := schemas[string(p.Name())]; schema != nil {
+			if err := schema.Validate(p); err != nil {
+				dropped++
+				if err, ok := err.(PartialWriteError); ok && reason == "" {
+					reason = err.Reason
+				}
+				continue
+			}
+		}
+
 		keys[j] = p.Key()
 		names[j] = p.Name()
 		tagsSlice[j] = tags
@@ -728,7 +857,13 @@ func (s *Shard) DeleteSeriesRange(itr SeriesIterator, min, max int64) error {
 	if err != nil {
 		return err
 	}
-	return engine.DeleteSeriesRange(itr, min, max)
+	rec := newMeasurementRecorder(itr)
+	if err := engine.DeleteSeriesRange(rec, min, max); err != nil {
+		return err
+	}
+	s.invalidateLastValues(engine, rec.Names())
+	s.dataChanged(min, max)
+	return nil
 }
 
 // DeleteSeriesRangeWithPredicate deletes all values from for seriesKeys between min and max (inclusive)
@@ -738,7 +873,13 @@ func (s *Shard) DeleteSeriesRangeWithPredicate(itr SeriesIterator, predicate fun
 	if err != nil {
 		return err
 	}
-	return engine.DeleteSeriesRangeWithPredicate(itr, predicate)
+	rec := newMeasurementRecorder(itr)
+	if err := engine.DeleteSeriesRangeWithPredicate(rec, predicate); err != nil {
+		return err
+	}
+	s.invalidateLastValues(engine, rec.Names())
+	s.dataChanged(math.MinInt64, math.MaxInt64)
+	return nil
 }
 
 // DeleteMeasurement deletes a measurement and all underlying series.
@@ -747,7 +888,12 @@ func (s *Shard) DeleteMeasurement(name []byte) error {
 	if err != nil {
 		return err
 	}
-	return engine.DeleteMeasurement(name)
+	if err := engine.DeleteMeasurement(name); err != nil {
+		return err
+	}
+	s.invalidateLastValues(engine, [][]byte{name})
+	s.dataChanged(math.MinInt64, math.MaxInt64)
+	return nil
 }
 
 // SeriesN returns the unique number of series in the shard.
@@ -873,6 +1019,15 @@ func (s *Shard) CreateIterator(ctx context.Context, m *cnosql.Measurement, opt q
 	case "_tagKeys":
 		return NewTagKeysIterator(s, opt)
 	}
+
+	if s.lastValues != nil {
+		itr, ok, err := s.lastValues.iterator(ctx, s.database, m.Name, opt, engine.MeasurementFields([]byte(m.Name)))
+		if err != nil {
+			return nil, err
+		} else if ok {
+			return itr, nil
+		}
+	}
 	return engine.CreateIterator(ctx, m.Name, opt)
 }
 
@@ -1109,7 +1264,11 @@ func (s *Shard) Restore(r io.Reader, basePath string) error {
 	}
 
 	// Reopen engine.
-	return s.openNoLock()
+	if err := s.openNoLock(); err != nil {
+		return err
+	}
+	s.dataChanged(math.MinInt64, math.MaxInt64)
+	return nil
 }
 
 func (s *Shard) openNoLock() error {
@@ -1160,6 +1319,7 @@ func (s *Shard) openNoLock() error {
 			return err
 		}
 		s._engine = e
+		s.loadLastValues(e)
 
 		return nil
 	}(); err != nil {
@@ -1189,6 +1349,11 @@ func (s *Shard) closeNoLock() error {
 		return nil
 	}
 
+	// Stop loading the last value cache before the engine closes.
+	if s.lastValues != nil {
+		s.lastValues.close()
+	}
+
 	err := s._engine.Close()
 	if err == nil {
 		s._engine = nil
@@ -1213,7 +1378,12 @@ func (s *Shard) Import(r io.Reader, basePath string) error {
 	}
 
 	// Import to engine.
-	return s._engine.Import(r, basePath)
+	if err := s._engine.Import(r, basePath); err != nil {
+		return err
+	}
+	s.loadLastValues(s._engine)
+	s.dataChanged(math.MinInt64, math.MaxInt64)
+	return nil
 }
 
 // CreateSnapshot will return a path to a temp directory
@@ -2,12 +2,15 @@ package storage
 
 import (
 	"context"
+
+	"github.com/cnosdb/cnosdb/vend/db/query"
 )
 
 type key int
 
 const (
 	readOptionsKey key = iota
+	authorizerKey
 )
 
 // ReadOptions are additional options that may be passed with context.Context
@@ -27,3 +30,18 @@ func ReadOptionsFromContext(ctx context.Context) *ReadOptions {
 	opts, _ := ctx.Value(readOptionsKey).(*ReadOptions)
 	return opts
 }
+
+// NewContextWithAuthorizer returns a new Context with the authorizer of the
+// series read by a storage request added.
+func NewContextWithAuthorizer(ctx context.Context, auth query.FineAuthorizer) context.Context {
+	return context.WithValue(ctx, authorizerKey, auth)
+}
+
+// AuthorizerFromContext returns the authorizer associated with the context
+// or query.OpenAuthorizer if none has been specified.
+func AuthorizerFromContext(ctx context.Context) query.FineAuthorizer {
+	if auth, _ := ctx.Value(authorizerKey).(query.FineAuthorizer); auth != nil {
+		return auth
+	}
+	return query.OpenAuthorizer
+}
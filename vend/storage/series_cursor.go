@@ -23,6 +23,8 @@ var (
 )
 
 type indexSeriesCursor struct {
+	database        string
+	auth            query.FineAuthorizer
 	sqry            tsdb.SeriesCursor
 	fields          measurementFields
 	nf              []field
@@ -37,7 +39,7 @@ type indexSeriesCursor struct {
 	hasValueExpr    bool
 }
 
-func newIndexSeriesCursor(ctx context.Context, predicate *datatypes.Predicate, shards []*tsdb.Shard) (*indexSeriesCursor, error) {
+func newIndexSeriesCursor(ctx context.Context, database string, predicate *datatypes.Predicate, shards []*tsdb.Shard) (*indexSeriesCursor, error) {
 	queries, err := tsdb.CreateCursorIterators(ctx, shards)
 	if err != nil {
 		return nil, err
@@ -55,11 +57,15 @@ func newIndexSeriesCursor(ctx context.Context, predicate *datatypes.Predicate, s
 
 	opt := query.IteratorOptions{
 		Aux:        []cnosql.VarRef{{Val: "key"}},
-		Authorizer: query.OpenAuthorizer,
+		Authorizer: AuthorizerFromContext(ctx),
 		Ascending:  true,
 		Ordered:    true,
 	}
-	p := &indexSeriesCursor{row: reads.SeriesRow{Query: queries}}
+	p := &indexSeriesCursor{
+		database: database,
+		auth:     opt.Authorizer,
+		row:      reads.SeriesRow{Query: queries},
+	}
 
 	if root := predicate.GetRoot(); root != nil {
 		if p.cond, err = reads.NodeToExpr(root, measurementRemap); err != nil {
@@ -168,6 +174,8 @@ func (c *indexSeriesCursor) Next() *reads.SeriesRow {
 			} else if sr == nil {
 				c.Close()
 				return nil
+			} else if !c.auth.IsOpen() && !c.auth.AuthorizeSeriesRead(c.database, sr.Name, sr.Tags) {
+				continue
 			}
 
 			c.row.Name = sr.Name
@@ -9,8 +9,12 @@ import (
 	"github.com/cnosdb/cnosdb/meta"
 	"github.com/cnosdb/cnosdb/storage/reads"
 	"github.com/cnosdb/cnosdb/storage/reads/datatypes"
+	"github.com/cnosdb/cnosdb/vend/cnosql"
 	"github.com/cnosdb/cnosdb/vend/db/models"
+	"github.com/cnosdb/cnosdb/vend/db/query"
 	"github.com/cnosdb/cnosdb/vend/db/tsdb"
+	"github.com/cnosdb/cnosdb/vend/db/tsdb/cursors"
+	"github.com/gogo/protobuf/proto"
 	"github.com/gogo/protobuf/types"
 	"go.uber.org/zap"
 )
@@ -126,7 +130,7 @@ func (s *Store) ReadFilter(ctx context.Context, req *datatypes.ReadFilterRequest
 	}
 
 	var cur reads.SeriesCursor
-	if ic, err := newIndexSeriesCursor(ctx, req.Predicate, s.TSDBStore.Shards(shardIDs)); err != nil {
+	if ic, err := newIndexSeriesCursor(ctx, database, req.Predicate, s.TSDBStore.Shards(shardIDs)); err != nil {
 		return nil, err
 	} else if ic == nil { // TODO: this was a typed nil
 		return nil, nil
@@ -139,3 +143,258 @@ func (s *Store) ReadFilter(ctx context.Context, req *datatypes.ReadFilterRequest
 
 	return reads.NewFilteredResultSet(ctx, req, cur), nil
 }
+
+func (s *Store) ReadGroup(ctx context.Context, req *datatypes.ReadGroupRequest) (reads.GroupResultSet, error) {
+	if req.ReadSource == nil {
+		return nil, errors.New("missing read source")
+	}
+
+	source, err := GetReadSource(*req.ReadSource)
+	if err != nil {
+		return nil, err
+	}
+
+	database, rp, start, end, err := s.validateArgs(source.Database, source.RetentionPolicy, req.Range.Start, req.Range.End)
+	if err != nil {
+		return nil, err
+	}
+
+	shardIDs, err := s.findShardIDs(database, rp, false, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if len(shardIDs) == 0 {
+		return nil, nil
+	}
+
+	shards := s.TSDBStore.Shards(shardIDs)
+
+	req.Range.Start = start
+	req.Range.End = end
+
+	newCursor := func() (reads.SeriesCursor, error) {
+		cur, err := newIndexSeriesCursor(ctx, database, req.Predicate, shards)
+		if cur == nil || err != nil {
+			return nil, err
+		}
+		return cur, nil
+	}
+
+	rs := reads.NewGroupResultSet(ctx, req, newCursor)
+	if rs == nil {
+		return nil, nil
+	}
+	return rs, nil
+}
+
+func (s *Store) TagKeys(ctx context.Context, req *datatypes.TagKeysRequest) (cursors.StringIterator, error) {
+	if req.TagsSource == nil {
+		return nil, errors.New("missing read source")
+	}
+
+	source, err := GetReadSource(*req.TagsSource)
+	if err != nil {
+		return nil, err
+	}
+
+	database, rp, start, end, err := s.validateArgs(source.Database, source.RetentionPolicy, req.Range.Start, req.Range.End)
+	if err != nil {
+		return nil, err
+	}
+
+	shardIDs, err := s.findShardIDs(database, rp, false, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if len(shardIDs) == 0 {
+		return cursors.EmptyStringIterator, nil
+	}
+
+	expr, err := tagsPredicateExpr(req.Predicate)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := s.TSDBStore.TagKeys(AuthorizerFromContext(ctx), shardIDs, expr)
+	if err != nil {
+		return nil, err
+	}
+
+	m := map[string]struct{}{
+		measurementKey: {},
+		fieldKey:       {},
+	}
+	for _, ks := range keys {
+		for _, k := range ks.Keys {
+			m[k] = struct{}{}
+		}
+	}
+	return newSortedStringIterator(m), nil
+}
+
+func (s *Store) TagValues(ctx context.Context, req *datatypes.TagValuesRequest) (cursors.StringIterator, error) {
+	if req.TagsSource == nil {
+		return nil, errors.New("missing read source")
+	}
+
+	source, err := GetReadSource(*req.TagsSource)
+	if err != nil {
+		return nil, err
+	}
+
+	database, rp, start, end, err := s.validateArgs(source.Database, source.RetentionPolicy, req.Range.Start, req.Range.End)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.TagKey == "" {
+		return nil, errors.New("missing tag key")
+	}
+
+	shardIDs, err := s.findShardIDs(database, rp, false, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if len(shardIDs) == 0 {
+		return cursors.EmptyStringIterator, nil
+	}
+
+	if req.TagKey == measurementKey || req.TagKey == models.MeasurementTagKey {
+		return s.measurementNames(AuthorizerFromContext(ctx), shardIDs, req.Predicate)
+	}
+
+	expr, err := tagsPredicateExpr(req.Predicate)
+	if err != nil {
+		return nil, err
+	}
+
+	tagKeyExpr := &cnosql.BinaryExpr{
+		Op:  cnosql.EQ,
+		LHS: &cnosql.VarRef{Val: "_tagKey"},
+		RHS: &cnosql.StringLiteral{Val: req.TagKey},
+	}
+	if expr != nil {
+		expr = &cnosql.BinaryExpr{
+			Op:  cnosql.AND,
+			LHS: tagKeyExpr,
+			RHS: &cnosql.ParenExpr{Expr: expr},
+		}
+	} else {
+		expr = tagKeyExpr
+	}
+
+	values, err := s.TSDBStore.TagValues(AuthorizerFromContext(ctx), shardIDs, expr)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]struct{})
+	for _, kvs := range values {
+		for _, kv := range kvs.Values {
+			m[kv.Value] = struct{}{}
+		}
+	}
+	return newSortedStringIterator(m), nil
+}
+
+// MeasurementNames returns the names of the measurements which have series
+// in the requested time range that match the predicate.
+func (s *Store) MeasurementNames(ctx context.Context, req *datatypes.MeasurementNamesRequest) (cursors.StringIterator, error) {
+	if req.Source == nil {
+		return nil, errors.New("missing read source")
+	}
+
+	source, err := GetReadSource(*req.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	database, rp, start, end, err := s.validateArgs(source.Database, source.RetentionPolicy, req.Range.Start, req.Range.End)
+	if err != nil {
+		return nil, err
+	}
+
+	shardIDs, err := s.findShardIDs(database, rp, false, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if len(shardIDs) == 0 {
+		return cursors.EmptyStringIterator, nil
+	}
+	return s.measurementNames(AuthorizerFromContext(ctx), shardIDs, req.Predicate)
+}
+
+func (s *Store) measurementNames(auth query.FineAuthorizer, shardIDs []uint64, predicate *datatypes.Predicate) (cursors.StringIterator, error) {
+	expr, err := tagsPredicateExpr(predicate)
+	if err != nil {
+		return nil, err
+	}
+
+	is := tsdb.IndexSet{Indexes: make([]tsdb.Index, 0, len(shardIDs))}
+	for _, sh := range s.TSDBStore.Shards(shardIDs) {
+		if is.SeriesFile == nil {
+			sfile, err := sh.SeriesFile()
+			if err != nil {
+				return nil, err
+			}
+			is.SeriesFile = sfile
+		}
+
+		index, err := sh.Index()
+		if err != nil {
+			return nil, err
+		}
+		is.Indexes = append(is.Indexes, index)
+	}
+	if len(is.Indexes) == 0 {
+		return cursors.EmptyStringIterator, nil
+	}
+
+	names, err := is.DedupeInmemIndexes().MeasurementNamesByExpr(auth, expr)
+	if err != nil {
+		return nil, err
+	}
+
+	a := make([]string, 0, len(names))
+	for _, name := range names {
+		a = append(a, string(name))
+	}
+	return cursors.NewStringSliceIterator(a), nil
+}
+
+func (s *Store) GetSource(db, rp string) proto.Message {
+	return &ReadSource{Database: db, RetentionPolicy: rp}
+}
+
+// tagsPredicateExpr converts the predicate of a tag or measurement request
+// into an expression accepted by the index. Predicates on field values are
+// not supported.
+func tagsPredicateExpr(predicate *datatypes.Predicate) (cnosql.Expr, error) {
+	root := predicate.GetRoot()
+	if root == nil {
+		return nil, nil
+	}
+
+	expr, err := reads.NodeToExpr(root, measurementRemap)
+	if err != nil {
+		return nil, err
+	}
+
+	if found := reads.HasFieldValueKey(expr); found {
+		return nil, errors.New("field values unsupported")
+	}
+	expr = cnosql.Reduce(RewriteExprRemoveFieldKeyAndValue(cnosql.CloneExpr(expr)), nil)
+	if reads.IsTrueBooleanLiteral(expr) {
+		return nil, nil
+	}
+	return expr, nil
+}
+
+func newSortedStringIterator(m map[string]struct{}) cursors.StringIterator {
+	a := make([]string, 0, len(m))
+	for v := range m {
+		a = append(a, v)
+	}
+	sort.Strings(a)
+	return cursors.NewStringSliceIterator(a)
+}